@@ -0,0 +1,21 @@
+// Package ffi is the stable, semantically-versioned home for the ffi
+// API that steps/3_goffi/ffi (module mewbak/nocgo/ffi) implements. The
+// steps/ tree stays in place as the tutorial - the ordered walk through
+// how the package is built up - while this module is what an importer
+// outside the tutorial is meant to depend on: its go.mod can take a
+// proper vN tag without also promising the steps/ directory layout
+// itself won't keep changing underneath future tutorial steps.
+//
+// This package re-exports the core calling surface (Spec, MakeSpec,
+// Library, Open, BoundCall, CallBatch) as straight aliases and thin
+// wrappers over steps/3_goffi/ffi via a replace directive in the
+// top-level go.mod, rather than copying or moving any implementation -
+// every method, field layout, and behavior is exactly what the
+// underlying package provides. The rest of that package's surface
+// (callbacks, closures, guard stacks, crash reporting, and so on) is
+// still reached by importing mewbak/nocgo/ffi directly until each piece
+// has its own promoted alias here; nocgo/loader and nocgo/fakecgo named
+// in the original request aren't included yet because neither exists as
+// its own package in this tree to promote - loader support lives inside
+// ffi's own loader_*.go files, and there is no fakecgo package at all.
+package ffi
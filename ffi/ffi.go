@@ -0,0 +1,30 @@
+package ffi
+
+import impl "mewbak/nocgo/ffi"
+
+// Spec is an alias for mewbak/nocgo/ffi.Spec - see that package for the
+// full set of methods (Call, Bind, ArgLayout, ...).
+type Spec = impl.Spec
+
+// Library is an alias for mewbak/nocgo/ffi.Library.
+type Library = impl.Library
+
+// BoundCall is an alias for mewbak/nocgo/ffi.BoundCall.
+type BoundCall = impl.BoundCall
+
+// MakeSpec builds a call specification for the given arguments; see
+// mewbak/nocgo/ffi.MakeSpec.
+func MakeSpec(fn uintptr, args interface{}) Spec {
+	return impl.MakeSpec(fn, args)
+}
+
+// Open loads the shared library at path; see mewbak/nocgo/ffi.Open.
+func Open(path string) (*Library, error) {
+	return impl.Open(path)
+}
+
+// CallBatch runs every call in calls in order; see
+// mewbak/nocgo/ffi.CallBatch.
+func CallBatch(calls []BoundCall) []int32 {
+	return impl.CallBatch(calls)
+}
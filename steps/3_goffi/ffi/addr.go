@@ -0,0 +1,17 @@
+package ffi
+
+// Addr is meant to be this package's dladdr: given ptr - typically a
+// function pointer resolved through Library.Get, or one handed to a Go
+// callback from C - it would report which loaded library ptr falls
+// within, the nearest symbol at or before it, and ptr's offset past that
+// symbol's own address, the same lookup a crash report or a log of C
+// callback pointers needs to turn a bare address back into a name.
+//
+// It returns ok == false unconditionally for now: like Library.Symbols
+// (library_symbols.go), it needs tracking which address ranges belong to
+// which loaded library and picking a library's own symbol table apart -
+// ELF's .dynsym, Mach-O's symtab load command, or PE's export directory,
+// depending on platform - and nothing here does either of those yet.
+func Addr(ptr uintptr) (lib string, symbol string, offset uintptr, ok bool) {
+	return "", "", 0, false
+}
@@ -0,0 +1,16 @@
+package ffi
+
+import "testing"
+
+// TestAddrUnsupported confirms Addr reports ok == false along with its
+// other results zeroed, rather than ok == true paired with a lib/symbol
+// that was never actually resolved.
+func TestAddrUnsupported(t *testing.T) {
+	lib, symbol, offset, ok := Addr(0x1234)
+	if ok {
+		t.Fatal("Addr: ok = true, want false")
+	}
+	if lib != "" || symbol != "" || offset != 0 {
+		t.Fatalf("Addr: got (%q, %q, %d), want (\"\", \"\", 0)", lib, symbol, offset)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfAddPairTarget and addPairTarget (aggregate_amd64_test.s) and
+// callAsmDirect let TestCallAggregate drive the real asmcall trampoline
+// (ffi_amd64.s) without going through Call/asmcgocall/runtime.funcPC:
+// funcPC is a compiler intrinsic that doesn't always have a linkable body
+// in a test binary, which previously meant none of this package's asm was
+// ever actually executed, only reviewed by eye. addrOfAddPairTarget gets
+// its target's address the same way the assembler itself would (a plain
+// SB reference), and callAsmDirect calls asmcall exactly as asmcgocall
+// does (a *callParams in DI) minus the g0-stack switch, which asmcall
+// doesn't rely on.
+func addrOfAddPairTarget() uintptr
+func addPairTarget()
+func callAsmDirect(params *callParams)
+
+// pairArgs is a two-eightbyte, all-INTEGER aggregate: the simplest case
+// placeArgEightbytes has to split across two consecutive integer
+// registers per the SysV classification rules.
+type pairArgs struct {
+	P struct {
+		X, Y int64
+	} `ffi:"arg"`
+	R int64 `ffi:"ret"`
+}
+
+// TestCallAggregate exercises MakeSpec's aggregate classification and
+// asmcall's eightbyte loads end to end: P is classified {INTEGER,INTEGER}
+// and placed in RDI/RSI, addPairTarget sums them in RAX, and asmcall
+// stores that back into R.
+func TestCallAggregate(t *testing.T) {
+	args := pairArgs{P: struct{ X, Y int64 }{X: 3, Y: 4}}
+	spec := MakeSpec(addrOfAddPairTarget(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 7 {
+		t.Fatalf("aggregate call: got R=%d, want 7", args.R)
+	}
+}
@@ -0,0 +1,69 @@
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfAddPairTarget64, addPairTarget64 (aggregate_arm64_test.s) and
+// callAsmDirect let TestCallAggregate drive the real asmcall trampoline
+// (ffi_arm64.s) directly, for the same reason as the amd64 backend's
+// identical test: Call/asmcgocall/runtime.funcPC don't reliably link in a
+// test binary, so this bypasses them - addrOfAddPairTarget64 takes its
+// target's address via a plain SB reference instead of funcPC, and
+// callAsmDirect calls asmcall exactly as asmcgocall does (a *callParams in R0).
+func addrOfAddPairTarget64() uintptr
+func addPairTarget64()
+func callAsmDirect(params *callParams)
+
+// pairArgs64 is a two-word aggregate that doesn't classify as an HFA, so
+// placeArgAggregate packs it into two consecutive integer registers
+// (X0/X1) per AAPCS64.
+type pairArgs64 struct {
+	P struct {
+		X, Y int64
+	} `ffi:"arg"`
+	R int64 `ffi:"ret"`
+}
+
+// TestCallAggregate exercises MakeSpec's non-HFA aggregate classification
+// and asmcall's word loads end to end: P is placed in X0/X1,
+// addPairTarget64 sums them in X0, and asmcall stores that back into R.
+func TestCallAggregate(t *testing.T) {
+	args := pairArgs64{P: struct{ X, Y int64 }{X: 3, Y: 4}}
+	spec := MakeSpec(addrOfAddPairTarget64(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 7 {
+		t.Fatalf("aggregate call: got R=%d, want 7", args.R)
+	}
+}
+
+// aggregateRetArgs68 is a regression fixture for placeRetAggregate: its
+// "ret" field (R) is a two-word aggregate that isn't the struct's first
+// field, the normal shape for a real call (fixed args before the
+// return), which is exactly what placeRetAggregate previously got wrong
+// by classifying every aggregate return as if it lived at offset 0.
+type aggregateRetArgs68 struct {
+	A int64 `ffi:"arg"`
+	R struct {
+		X, Y int64
+	} `ffi:"ret"`
+}
+
+// TestPlaceRetAggregateOffset checks that an aggregate "ret" field is
+// classified at its actual offset within the args struct, not always at
+// offset 0.
+func TestPlaceRetAggregateOffset(t *testing.T) {
+	var args aggregateRetArgs68
+	spec := buildSpec(0, &args)
+
+	wantOffset := uint16(unsafe.Offsetof(args.R))
+	if spec.ret0.offset != wantOffset {
+		t.Fatalf("ret0.offset: got %d, want %d (R's offset within aggregateRetArgs68)", spec.ret0.offset, wantOffset)
+	}
+	if spec.ret1.offset != wantOffset+8 {
+		t.Fatalf("ret1.offset: got %d, want %d", spec.ret1.offset, wantOffset+8)
+	}
+}
@@ -0,0 +1,38 @@
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfAddPointTarget64 and addPointTarget64 (aggregate_hfa_arm64_test.s)
+// stand in for a C function taking and returning a Homogeneous
+// Floating-point Aggregate, the float64 counterpart to
+// addrOfAddPairTarget64's non-HFA pairArgs64.
+func addrOfAddPointTarget64() uintptr
+func addPointTarget64()
+
+// point64Args is a two-member float64 HFA, which placeArgAggregate must
+// place member by member into consecutive V registers (V0/V1) rather than
+// X registers, and placeRetAggregate must return the same way (fltret[0]).
+type point64Args struct {
+	P struct {
+		X, Y float64
+	} `ffi:"arg"`
+	R float64 `ffi:"ret"`
+}
+
+// TestCallAggregateHFA exercises MakeSpec's HFA classification and
+// asmcall's V-register loads/stores end to end: P's X and Y go into
+// V0/V1, addPointTarget64 sums them in V0, and asmcall stores that back
+// into R.
+func TestCallAggregateHFA(t *testing.T) {
+	args := point64Args{P: struct{ X, Y float64 }{X: 1.5, Y: 2.25}}
+	spec := MakeSpec(addrOfAddPointTarget64(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 3.75 {
+		t.Fatalf("HFA call: got R=%v, want 3.75", args.R)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfAddMixedTarget and addMixedTarget (aggregate_mixed_amd64_test.s)
+// stand in for a C function taking a two-eightbyte aggregate whose
+// eightbytes classify differently - one INTEGER, one SSE - the case
+// pairArgs's all-INTEGER P (aggregate_amd64_test.go) never exercises.
+func addrOfAddMixedTarget() uintptr
+func addMixedTarget()
+
+// mixedArgs is a two-eightbyte aggregate with one INTEGER member (X) and
+// one SSE member (Y), forcing placeArgEightbytes to split it across one
+// integer register (RDI) and one XMM register (XMM0) rather than two of
+// the same file.
+type mixedArgs struct {
+	P struct {
+		X int64
+		Y float64
+	} `ffi:"arg"`
+	R float64 `ffi:"ret"`
+}
+
+// TestCallAggregateMixedClass exercises the INTEGER+SSE branch of
+// placeArgEightbytes/asmcall end to end: P.X goes into RDI, P.Y into
+// XMM0, addMixedTarget adds float64(X) to Y in XMM0, and asmcall stores
+// that back into R.
+func TestCallAggregateMixedClass(t *testing.T) {
+	args := mixedArgs{P: struct {
+		X int64
+		Y float64
+	}{X: 3, Y: 4.5}}
+	spec := MakeSpec(addrOfAddMixedTarget(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 7.5 {
+		t.Fatalf("mixed-class aggregate call: got R=%v, want 7.5", args.R)
+	}
+}
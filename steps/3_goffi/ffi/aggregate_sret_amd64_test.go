@@ -0,0 +1,41 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfFillBigTarget and fillBigTarget (aggregate_sret_amd64_test.s) stand
+// in for a C function returning a struct too large for registers, the same
+// way addPairTarget (aggregate_amd64_test.go) stands in for an all-INTEGER
+// one.
+func addrOfFillBigTarget() uintptr
+func fillBigTarget()
+
+// bigRet is a three-eightbyte (24 byte) aggregate: too big for RAX:RDX, so
+// buildSpec must classify it MEMORY and wire up the hidden pointer
+// argument instead of placeRetEightbytes.
+type bigRet struct {
+	A, B, C int64
+}
+
+type bigArgs struct {
+	R bigRet `ffi:"ret"`
+}
+
+// TestCallAggregateReturnMemory exercises the hidden-pointer path: R's
+// address is passed in RDI's intargs slot (typeAddr), fillBigTarget writes
+// through it directly, and asmcall has nothing left to copy back since the
+// callee already wrote into args.R in place.
+func TestCallAggregateReturnMemory(t *testing.T) {
+	var args bigArgs
+	spec := MakeSpec(addrOfFillBigTarget(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != (bigRet{A: 10, B: 20, C: 30}) {
+		t.Fatalf("aggregate memory return: got %+v, want {10 20 30}", args.R)
+	}
+}
@@ -0,0 +1,85 @@
+package ffi
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// arena is a per-call bump allocator for marshaling temporaries - cstr
+// conversions today, sret buffers and argv arrays as more of Call's
+// marshaling grows to use it - that would otherwise cost one Go-heap
+// allocation apiece. Call acquires one from arenaPool before marshaling
+// an args struct's fields and returns it (via releaseArena) right after
+// the call returns, so a marshaling-heavy call reuses the same backing
+// buffer call after call instead of allocating fresh scratch space every
+// time.
+type arena struct {
+	buf []byte
+	off int
+}
+
+// alloc returns an n-byte slice of a's backing buffer, growing it first
+// if fewer than n bytes remain past off - the bump in "bump allocator".
+// The returned slice is only valid until the next reset.
+func (a *arena) alloc(n int) []byte {
+	if a.off+n > len(a.buf) {
+		grown := make([]byte, len(a.buf)*2+n)
+		copy(grown, a.buf[:a.off])
+		a.buf = grown
+	}
+	b := a.buf[a.off : a.off+n : a.off+n]
+	a.off += n
+	return b
+}
+
+// allocAligned is alloc, but for a caller - preparePackedFields, for an
+// `ffi:"align=N"` field - that needs the returned slice's actual
+// address, not just its logical offset into a's backing buffer, to land
+// on an align-byte boundary: a plain alloc only guarantees that much by
+// accident. It pads off forward as needed first, growing (and retrying,
+// since growing moves the backing array to a new address) until the
+// next alloc already starts aligned.
+func (a *arena) allocAligned(n, align int) []byte {
+	if align <= 1 {
+		return a.alloc(n)
+	}
+	for {
+		if a.off+n+align > len(a.buf) {
+			grown := make([]byte, (len(a.buf)+n+align)*2)
+			copy(grown, a.buf[:a.off])
+			a.buf = grown
+		}
+		if pad := int(uintptr(unsafe.Pointer(&a.buf[a.off])) % uintptr(align)); pad != 0 {
+			a.off += align - pad
+			continue
+		}
+		break
+	}
+	return a.alloc(n)
+}
+
+// reset rewinds a to empty without releasing its backing buffer, so the
+// next acquireArena caller starts bumping from offset 0 over the same
+// memory arenaPool already paid to grow.
+func (a *arena) reset() {
+	a.off = 0
+}
+
+// arenaPool holds arenas between calls. Reusing one across calls - even
+// concurrent, unrelated ones - is the whole point: a pooled arena that's
+// already grown to fit a program's typical cstr traffic means most calls
+// bump-allocate into existing memory instead of asking the Go heap for
+// anything at all.
+var arenaPool = sync.Pool{New: func() interface{} { return new(arena) }}
+
+func acquireArena() *arena {
+	return arenaPool.Get().(*arena)
+}
+
+// releaseArena resets a and returns it to arenaPool. Callers must stop
+// using every slice alloc returned from a before calling this - the next
+// acquireArena caller is free to overwrite them.
+func releaseArena(a *arena) {
+	a.reset()
+	arenaPool.Put(a)
+}
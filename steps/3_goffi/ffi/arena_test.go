@@ -0,0 +1,64 @@
+package ffi
+
+import "testing"
+
+// TestArenaAllocBumpsAndGrows confirms alloc hands out non-overlapping
+// slices that advance through the arena's backing buffer, and that it
+// transparently grows that buffer once a request no longer fits.
+func TestArenaAllocBumpsAndGrows(t *testing.T) {
+	a := new(arena)
+
+	first := a.alloc(4)
+	second := a.alloc(4)
+	if &first[0] == &second[0] {
+		t.Fatal("alloc: two allocations returned overlapping memory")
+	}
+
+	copy(first, "abcd")
+	copy(second, "efgh")
+	if string(first) != "abcd" || string(second) != "efgh" {
+		t.Fatalf("alloc: writes through one slice clobbered the other: %q %q", first, second)
+	}
+
+	big := a.alloc(1024)
+	if len(big) != 1024 {
+		t.Fatalf("alloc: got len %d, want 1024 after growing", len(big))
+	}
+}
+
+// TestArenaResetReclaimsSpace confirms reset rewinds the bump offset
+// without discarding the backing buffer, so a pooled arena reuses
+// whatever capacity it already grew to on its next acquire.
+func TestArenaResetReclaimsSpace(t *testing.T) {
+	a := new(arena)
+	a.alloc(64)
+	buf := a.buf
+
+	a.reset()
+	if a.off != 0 {
+		t.Fatalf("reset: off = %d, want 0", a.off)
+	}
+
+	again := a.alloc(64)
+	if &a.buf[0] != &buf[0] {
+		t.Fatal("reset: backing buffer was replaced instead of reused")
+	}
+	if len(again) != 64 {
+		t.Fatalf("alloc after reset: got len %d, want 64", len(again))
+	}
+}
+
+// TestAcquireReleaseArenaRoundTrips confirms a released arena comes back
+// out of acquireArena reset to empty, ready for a new call's cstr
+// marshaling to bump-allocate into from offset 0.
+func TestAcquireReleaseArenaRoundTrips(t *testing.T) {
+	a := acquireArena()
+	a.alloc(16)
+	releaseArena(a)
+
+	a2 := acquireArena()
+	if a2.off != 0 {
+		t.Fatalf("acquireArena: off = %d, want 0 on a freshly released arena", a2.off)
+	}
+	releaseArena(a2)
+}
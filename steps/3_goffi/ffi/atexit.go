@@ -0,0 +1,104 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// libcAtExit lazily resolves libc's atexit(void (*)(void)), the same
+// libc()-style two-step cstring.go's malloc/free use.
+func libcAtExit() (uintptr, error) {
+	atexitOnce.Do(func() {
+		lib, err := Open(libcPath)
+		if err != nil {
+			atexitErr = err
+			return
+		}
+		atexitAddr, atexitErr = lib.Get("atexit")
+	})
+	return atexitAddr, atexitErr
+}
+
+var (
+	atexitOnce sync.Once
+	atexitAddr uintptr
+	atexitErr  error
+)
+
+type atexitArgs struct {
+	Fn uintptr `ffi:"arg"`
+	R  int32   `ffi:"ret"`
+}
+
+// AtExit registers fn to run through libc's atexit: from then on, every
+// time a real exit(3) call ends the process - C code calling exit()
+// directly, or Go's own teardown reaching it through Exit below - libc
+// runs fn the same as it runs any other atexit handler, in reverse
+// order of registration, interleaved with whatever C library already
+// registered its own. fn takes no arguments and returns nothing, the
+// same signature a real C atexit handler has.
+//
+// AtExit leaks the callback it builds for fn via NewCallback, the same
+// as a real atexit handler is never released either - there's no
+// corresponding "unregister" on the C side to release it through.
+func AtExit(fn func()) error {
+	addr, err := libcAtExit()
+	if err != nil {
+		return err
+	}
+	args := atexitArgs{Fn: NewCallback(func() { fn() })}
+	spec := MakeSpec(addr, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("ffi: AtExit: atexit returned %d", args.R)
+	}
+	return nil
+}
+
+// libcExit lazily resolves libc's exit(int), for Exit below.
+func libcExit() (uintptr, error) {
+	exitOnce.Do(func() {
+		lib, err := Open(libcPath)
+		if err != nil {
+			exitErr = err
+			return
+		}
+		exitAddr, exitErr = lib.Get("exit")
+	})
+	return exitAddr, exitErr
+}
+
+var (
+	exitOnce sync.Once
+	exitAddr uintptr
+	exitErr  error
+)
+
+type exitArgs struct {
+	Code int32 `ffi:"arg"`
+}
+
+// Exit calls libc's own exit(3) instead of Go's os.Exit, so every
+// AtExit-registered handler - and any handler a C library Open mapped
+// in registered with its own atexit call - actually runs before the
+// process ends, the same teardown a real process exit already gives C
+// code embedding this package into a larger C process. Go's own
+// os.Exit(code) skips atexit entirely, by design - it's a bare
+// exit_group syscall with no deferred/atexit cleanup of any kind - which
+// is exactly the gap Exit closes for a mixed Go/C teardown. Like
+// os.Exit, Exit never returns to its caller; if resolving or calling
+// libc's exit somehow fails, it falls back to os.Exit(code) so that
+// contract still holds either way.
+func Exit(code int) {
+	addr, err := libcExit()
+	if err == nil {
+		args := exitArgs{Code: int32(code)}
+		spec := MakeSpec(addr, &args)
+		spec.Call(unsafe.Pointer(&args))
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+// TestAtExitRegistersOrFailsCleanly confirms AtExit never panics: on a
+// platform without a real loader yet (loader_unsupported.go), Open
+// fails and AtExit returns that error directly instead of calling
+// atexit at all; on one that does, it registers this no-op cleanly.
+// There's no portable way to assert the handler actually runs without
+// forking a subprocess that really exits, which this package's test
+// suite doesn't do anywhere else.
+func TestAtExitRegistersOrFailsCleanly(t *testing.T) {
+	if err := AtExit(func() {}); err != nil {
+		t.Logf("AtExit: %v (expected until this platform has a real loader)", err)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func addrOfDirtyYMM0Target() uintptr
+func dirtyYMM0Target()
+func ymm0UpperDirty() bool
+
+// TestCallVZeroUpperCleansUpAfter exercises UseVZeroUpper's post-call
+// section end to end: dirtyYMM0Target leaves YMM0's upper 128 bits
+// non-zero, and asmcall should both count that (AVXDirtyCount) and clean
+// it up (VZEROUPPER) before returning to Go.
+func TestCallVZeroUpperCleansUpAfter(t *testing.T) {
+	before := AVXDirtyCount()
+
+	spec := MakeSpec(addrOfDirtyYMM0Target(), &noArgs{})
+	spec.UseVZeroUpper(true)
+	var dummy noArgs
+
+	params := callParams{spec: &spec, base: uintptr(unsafe.Pointer(&dummy))}
+	callAsmDirect(&params)
+
+	if got := AVXDirtyCount(); got != before+1 {
+		t.Errorf("AVXDirtyCount: got %d, want %d", got, before+1)
+	}
+	if ymm0UpperDirty() {
+		t.Error("YMM0 upper bits still dirty after a UseVZeroUpper-guarded call")
+	}
+}
+
+// TestCallNoVZeroUpperLeavesNoRecord confirms asmcall skips the AVX
+// hygiene section entirely when UseVZeroUpper was never called, rather
+// than counting or cleaning up anything.
+func TestCallNoVZeroUpperLeavesNoRecord(t *testing.T) {
+	before := AVXDirtyCount()
+
+	spec := MakeSpec(addrOfDirtyYMM0Target(), &noArgs{})
+	var dummy noArgs
+
+	params := callParams{spec: &spec, base: uintptr(unsafe.Pointer(&dummy))}
+	callAsmDirect(&params)
+	defer func() {
+		spec.UseVZeroUpper(true)
+		callAsmDirect(&params) // clean up the dirty state this test left behind
+	}()
+
+	if got := AVXDirtyCount(); got != before {
+		t.Errorf("AVXDirtyCount: got %d, want unchanged at %d", got, before)
+	}
+	if !ymm0UpperDirty() {
+		t.Error("YMM0 upper bits unexpectedly clean after an unguarded call to dirtyYMM0Target")
+	}
+}
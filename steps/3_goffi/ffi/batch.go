@@ -0,0 +1,34 @@
+package ffi
+
+import "unsafe"
+
+// BoundCall pairs a Spec with the arguments to call it with, for
+// CallBatch (see each arch's ffi_*.go) to run as one entry in a batch.
+// It's the same (spec, args) pair an ordinary spec.Call(args) call takes,
+// just collected up front so CallBatch can drive the whole sequence
+// through a single entersyscall/exitsyscall pair instead of paying that
+// cost once per call.
+type BoundCall struct {
+	Spec *Spec
+	Args unsafe.Pointer
+}
+
+// Bind pairs spec with args as a BoundCall, for CallBatch - equivalent to
+// writing out BoundCall{Spec: spec, Args: args} by hand, just shorter at
+// the call site that's assembling a batch.
+func (spec *Spec) Bind(args unsafe.Pointer) BoundCall {
+	return BoundCall{Spec: spec, Args: args}
+}
+
+// CallSlice invokes spec once per element of argsSlice through
+// CallBatch, entering syscall state once for the whole slice instead of
+// once per call - the vectorized map-call counterpart to Spec.Call, for
+// batch workloads like hashing or compressing many small buffers with
+// the same C function.
+func CallSlice[Args any](spec *Spec, argsSlice []Args) {
+	calls := make([]BoundCall, len(argsSlice))
+	for i := range argsSlice {
+		calls[i] = spec.Bind(unsafe.Pointer(&argsSlice[i]))
+	}
+	CallBatch(calls)
+}
@@ -0,0 +1,77 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCallBatch exercises CallBatch against the same addPairFlatTarget
+// fixture TestFuncCall (func_amd64_test.go) drives through BindFunc,
+// confirming a batch of calls each reach the real asmcall trampoline with
+// their own arguments and each compute their own independent result -
+// not, say, all reading back whichever call happened to run last.
+func TestCallBatch(t *testing.T) {
+	fn := addrOfAddPairFlatTarget()
+	spec := MustSpecFor[funcAddArgs](fn)
+
+	argsList := make([]funcAddArgs, 4)
+	calls := make([]BoundCall, len(argsList))
+	for i := range argsList {
+		argsList[i] = funcAddArgs{A: int64(i), B: int64(i * 10)}
+		calls[i] = BoundCall{Spec: &spec, Args: unsafe.Pointer(&argsList[i])}
+	}
+
+	CallBatch(calls)
+
+	for i, args := range argsList {
+		want := int64(i) + int64(i*10)
+		if args.R != want {
+			t.Fatalf("CallBatch[%d]: R = %v, want %v", i, args.R, want)
+		}
+	}
+}
+
+// TestSpecBind confirms spec.Bind(args) produces the same BoundCall a
+// caller would get writing out BoundCall{Spec: &spec, Args: args} by
+// hand.
+func TestSpecBind(t *testing.T) {
+	fn := addrOfAddPairFlatTarget()
+	spec := MustSpecFor[funcAddArgs](fn)
+
+	args := funcAddArgs{A: 3, B: 4}
+	got := spec.Bind(unsafe.Pointer(&args))
+	want := BoundCall{Spec: &spec, Args: unsafe.Pointer(&args)}
+	if got != want {
+		t.Fatalf("Bind: got %+v, want %+v", got, want)
+	}
+
+	CallBatch([]BoundCall{got})
+	if args.R != 7 {
+		t.Fatalf("Bind: after CallBatch, R = %v, want 7", args.R)
+	}
+}
+
+// TestCallSlice exercises the generic CallSlice against the same
+// addPairFlatTarget fixture TestCallBatch uses, confirming every element
+// of argsSlice is called with its own arguments and computes its own
+// independent result.
+func TestCallSlice(t *testing.T) {
+	fn := addrOfAddPairFlatTarget()
+	spec := MustSpecFor[funcAddArgs](fn)
+
+	argsSlice := make([]funcAddArgs, 4)
+	for i := range argsSlice {
+		argsSlice[i] = funcAddArgs{A: int64(i), B: int64(i * 10)}
+	}
+
+	CallSlice(&spec, argsSlice)
+
+	for i, args := range argsSlice {
+		want := int64(i) + int64(i*10)
+		if args.R != want {
+			t.Fatalf("CallSlice[%d]: R = %v, want %v", i, args.R, want)
+		}
+	}
+}
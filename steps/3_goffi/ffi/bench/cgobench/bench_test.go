@@ -0,0 +1,39 @@
+// Package cgobench is the cgo side of the bench suite: the same five
+// call shapes bench/nocgobench and bench/puregobench measure, called
+// through an ordinary statically-linked cgo binding instead of
+// nocgo/ffi's own trampoline or purego's, so `go test -bench` run
+// against all three packages gives a like-for-like comparison of call
+// overhead.
+package cgobench
+
+import "testing"
+
+func BenchmarkEmptyCall(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		emptyCall()
+	}
+}
+
+func BenchmarkAddI32(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		addI32(7, 35)
+	}
+}
+
+func BenchmarkAddF64(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		addF64(1.5, 2.25)
+	}
+}
+
+func BenchmarkSum10(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sum10(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	}
+}
+
+func BenchmarkCallbackRoundTrip(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		callCallback(41)
+	}
+}
@@ -0,0 +1,17 @@
+package cgobench
+
+/*
+#include "shim.h"
+*/
+import "C"
+
+// goCallback is cgo's own round-trip path for BenchmarkCallbackRoundTrip:
+// exported via //export so shim.c's bench_call_callback can call straight
+// back into Go through it, the same shape MakeCallback's trampoline and
+// purego.NewCallback's stub both stand in for on the other two benchmark
+// variants.
+//
+//export goCallback
+func goCallback(n C.int32_t) C.int32_t {
+	return n + 1
+}
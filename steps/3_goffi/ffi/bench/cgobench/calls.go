@@ -0,0 +1,37 @@
+package cgobench
+
+/*
+#include "shim.h"
+
+// goCallback is defined (exported) in callback.go; declared here rather
+// than via the generated _cgo_export.h, which isn't reliably available
+// yet to every .go file's own cgo preamble within the same package.
+extern int32_t goCallback(int32_t n);
+*/
+import "C"
+
+// The functions below wrap shim.h's C calls one-for-one, since cgo
+// directives (`import "C"`) aren't allowed inside a _test.go file at
+// all - bench_test.go calls these instead of C.bench_* directly.
+
+func emptyCall() {
+	C.bench_empty()
+}
+
+func addI32(a, b int32) int32 {
+	return int32(C.bench_add_i32(C.int32_t(a), C.int32_t(b)))
+}
+
+func addF64(a, b float64) float64 {
+	return float64(C.bench_add_f64(C.double(a), C.double(b)))
+}
+
+func sum10(a0, a1, a2, a3, a4, a5, a6, a7, a8, a9 int32) int64 {
+	return int64(C.bench_sum10(
+		C.int32_t(a0), C.int32_t(a1), C.int32_t(a2), C.int32_t(a3), C.int32_t(a4),
+		C.int32_t(a5), C.int32_t(a6), C.int32_t(a7), C.int32_t(a8), C.int32_t(a9)))
+}
+
+func callCallback(n int32) int32 {
+	return int32(C.bench_call_callback(C.bench_callback_fn(C.goCallback), C.int32_t(n)))
+}
@@ -0,0 +1,192 @@
+// Package nocgobench is the nocgo/ffi side of the bench suite: the same
+// five call shapes bench/cgobench and bench/puregobench measure, called
+// through nocgo/ffi's own Spec.Call/MakeCallback instead of a statically
+// linked cgo binding or purego's, so `go test -bench` run against all
+// three packages gives a like-for-like comparison of call overhead -
+// and a regression in the trampoline or Call path shows up as this
+// package's own numbers drifting against a baseline run, the same way
+// any other Go benchmark's regression would.
+package nocgobench
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+var (
+	setupOnce sync.Once
+	lib       *ffi.Library
+	setupErr  error
+
+	emptySpec  ffi.Spec
+	addI32Spec ffi.Spec
+	addF64Spec ffi.Spec
+	sum10Spec  ffi.Spec
+	cbSpec     ffi.Spec
+)
+
+type emptyArgs struct{}
+
+type addI32Args struct {
+	A, B int32 `ffi:"arg"`
+	R    int32 `ffi:"ret"`
+}
+
+type addF64Args struct {
+	A, B float64 `ffi:"arg"`
+	R    float64 `ffi:"ret"`
+}
+
+type sum10Args struct {
+	A0, A1, A2, A3, A4, A5, A6, A7, A8, A9 int32 `ffi:"arg"`
+	R                                      int64 `ffi:"ret"`
+}
+
+type callbackArgs struct {
+	Cb uintptr `ffi:"arg"`
+	N  int32   `ffi:"arg"`
+	R  int32   `ffi:"ret"`
+}
+
+// buildShim compiles bench/cgobench's shim.c (the same C source all
+// three bench packages measure calls into) as a standalone shared
+// library, the same way cgoref's own buildShim helper does.
+func buildShim(b *testing.B) string {
+	b.Helper()
+
+	if _, err := exec.LookPath(envOr("CC", "cc")); err != nil {
+		b.Skipf("nocgobench: no C compiler available: %v", err)
+	}
+
+	ext := ".so"
+	if runtime.GOOS == "darwin" {
+		ext = ".dylib"
+	}
+	out := filepath.Join(b.TempDir(), "libbenchshim"+ext)
+	shimC := filepath.Join("..", "cgobench", "shim.c")
+
+	cmd := exec.Command(envOr("CC", "cc"), "-shared", "-fPIC", "-O2", "-o", out, shimC)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		b.Fatalf("nocgobench: building shim shared library: %v\n%s", err, output)
+	}
+	return out
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// setup builds the shared shim once for the whole package and resolves
+// every Spec each benchmark below calls.
+func setup(b *testing.B) {
+	setupOnce.Do(func() {
+		shimPath := buildShim(b)
+
+		lib, setupErr = ffi.Open(shimPath)
+		if setupErr != nil {
+			return
+		}
+
+		var fn uintptr
+		if fn, setupErr = lib.Get("bench_empty"); setupErr != nil {
+			return
+		}
+		emptySpec = ffi.MakeSpec(fn, &emptyArgs{})
+
+		if fn, setupErr = lib.Get("bench_add_i32"); setupErr != nil {
+			return
+		}
+		addI32Spec = ffi.MakeSpec(fn, &addI32Args{})
+
+		if fn, setupErr = lib.Get("bench_add_f64"); setupErr != nil {
+			return
+		}
+		addF64Spec = ffi.MakeSpec(fn, &addF64Args{})
+
+		if fn, setupErr = lib.Get("bench_sum10"); setupErr != nil {
+			return
+		}
+		sum10Spec = ffi.MakeSpec(fn, &sum10Args{})
+
+		if fn, setupErr = lib.Get("bench_call_callback"); setupErr != nil {
+			return
+		}
+		cbSpec = ffi.MakeSpec(fn, &callbackArgs{})
+	})
+	if setupErr != nil {
+		b.Skipf("nocgobench: setup: %v", setupErr)
+	}
+}
+
+func BenchmarkEmptyCall(b *testing.B) {
+	setup(b)
+	var args emptyArgs
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		emptySpec.Call(unsafe.Pointer(&args))
+	}
+}
+
+func BenchmarkAddI32(b *testing.B) {
+	setup(b)
+	args := addI32Args{A: 7, B: 35}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		addI32Spec.Call(unsafe.Pointer(&args))
+	}
+}
+
+func BenchmarkAddF64(b *testing.B) {
+	setup(b)
+	args := addF64Args{A: 1.5, B: 2.25}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		addF64Spec.Call(unsafe.Pointer(&args))
+	}
+}
+
+func BenchmarkSum10(b *testing.B) {
+	setup(b)
+	args := sum10Args{A0: 1, A1: 2, A2: 3, A3: 4, A4: 5, A5: 6, A6: 7, A7: 8, A8: 9, A9: 10}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sum10Spec.Call(unsafe.Pointer(&args))
+	}
+}
+
+// addOneCallback is the Go side of BenchmarkCallbackRoundTrip's round
+// trip - shim.h's bench_call_callback calls back into this, through
+// MakeCallback's JIT-built trampoline, once per b.N iteration.
+func addOneCallback(argp unsafe.Pointer) {
+	args := (*struct {
+		N int32 `ffi:"arg"`
+		R int32 `ffi:"ret"`
+	})(argp)
+	args.R = args.N + 1
+}
+
+func BenchmarkCallbackRoundTrip(b *testing.B) {
+	setup(b)
+
+	cb := ffi.MakeCallback(&struct {
+		N int32 `ffi:"arg"`
+		R int32 `ffi:"ret"`
+	}{}, addOneCallback)
+	defer ffi.ReleaseCallback(cb)
+
+	args := callbackArgs{Cb: cb, N: 41}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cbSpec.Call(unsafe.Pointer(&args))
+	}
+}
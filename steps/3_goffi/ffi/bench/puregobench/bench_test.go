@@ -0,0 +1,121 @@
+// Package puregobench is the purego side of the bench suite: the same
+// five call shapes bench/cgobench and bench/nocgobench measure, called
+// through github.com/ebitengine/purego's own RegisterFunc/NewCallback
+// instead of a statically linked cgo binding or nocgo/ffi's trampoline,
+// so `go test -bench` run against all three packages gives a
+// like-for-like comparison of call overhead.
+package puregobench
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/ebitengine/purego"
+)
+
+var (
+	setupOnce sync.Once
+	setupErr  error
+
+	emptyCall func()
+	addI32    func(int32, int32) int32
+	addF64    func(float64, float64) float64
+	sum10     func(int32, int32, int32, int32, int32, int32, int32, int32, int32, int32) int64
+	callCb    func(uintptr, int32) int32
+)
+
+func buildShim(b *testing.B) string {
+	b.Helper()
+
+	if _, err := exec.LookPath(envOr("CC", "cc")); err != nil {
+		b.Skipf("puregobench: no C compiler available: %v", err)
+	}
+
+	ext := ".so"
+	if runtime.GOOS == "darwin" {
+		ext = ".dylib"
+	}
+	out := filepath.Join(b.TempDir(), "libbenchshim"+ext)
+	shimC := filepath.Join("..", "cgobench", "shim.c")
+
+	cmd := exec.Command(envOr("CC", "cc"), "-shared", "-fPIC", "-O2", "-o", out, shimC)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		b.Fatalf("puregobench: building shim shared library: %v\n%s", err, output)
+	}
+	return out
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// setup builds the shared shim once for the whole package and binds
+// every function each benchmark below calls, the same one the other two
+// bench packages load.
+func setup(b *testing.B) {
+	setupOnce.Do(func() {
+		shimPath := buildShim(b)
+
+		var lib uintptr
+		lib, setupErr = purego.Dlopen(shimPath, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+		if setupErr != nil {
+			return
+		}
+
+		purego.RegisterLibFunc(&emptyCall, lib, "bench_empty")
+		purego.RegisterLibFunc(&addI32, lib, "bench_add_i32")
+		purego.RegisterLibFunc(&addF64, lib, "bench_add_f64")
+		purego.RegisterLibFunc(&sum10, lib, "bench_sum10")
+		purego.RegisterLibFunc(&callCb, lib, "bench_call_callback")
+	})
+	if setupErr != nil {
+		b.Skipf("puregobench: setup: %v", setupErr)
+	}
+}
+
+func BenchmarkEmptyCall(b *testing.B) {
+	setup(b)
+	for i := 0; i < b.N; i++ {
+		emptyCall()
+	}
+}
+
+func BenchmarkAddI32(b *testing.B) {
+	setup(b)
+	for i := 0; i < b.N; i++ {
+		addI32(7, 35)
+	}
+}
+
+func BenchmarkAddF64(b *testing.B) {
+	setup(b)
+	for i := 0; i < b.N; i++ {
+		addF64(1.5, 2.25)
+	}
+}
+
+func BenchmarkSum10(b *testing.B) {
+	setup(b)
+	for i := 0; i < b.N; i++ {
+		sum10(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	}
+}
+
+func BenchmarkCallbackRoundTrip(b *testing.B) {
+	setup(b)
+
+	cb := purego.NewCallback(func(n int32) int32 {
+		return n + 1
+	})
+
+	for i := 0; i < b.N; i++ {
+		callCb(cb, 41)
+	}
+}
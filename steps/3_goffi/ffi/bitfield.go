@@ -0,0 +1,218 @@
+package ffi
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// bitField records one `ffi:"bits=N"` Go field's position within its
+// group's shared packed storage word: offset and typ are where and how
+// to read/write the field's own logical value (never the packed word
+// itself, which only ever lives at the group's representative offset -
+// see bitGroup), bitOffset is where that value starts within the packed
+// word, and bitWidth is how many of its low bits are kept.
+type bitField struct {
+	offset    uint16
+	typ       reflect.Type
+	bitOffset uint8
+	bitWidth  uint8
+}
+
+// bitGroup is a run of consecutive `ffi:"bits=N"` struct fields packed
+// into one storage word, the same way a C compiler packs a run of
+// bitfields into the fewest whole allocation units: offset is the
+// group's first field's own offset, which doubles as where the packed
+// word is written - that field's own bytes are wide enough to hold the
+// whole group by construction (see collectBitGroups), so packing writes
+// through them in place rather than needing storage of its own. t/size
+// describe that packed word as a plain MakeSpec argument; fields lists
+// every member (including the first) for prepareBitFields to pack/
+// unpack through.
+type bitGroup struct {
+	offset uint16
+	t      argtype
+	size   uint8
+	fields []bitField
+}
+
+// bitfieldStorage picks the smallest of the 1/2/4/8 byte storage units a
+// C compiler would use to hold a run of bits totalling n, mirroring how
+// a compiler packs bitfields into the fewest whole allocation units
+// rather than ever splitting one across two.
+func bitfieldStorage(n int) (argtype, uint8) {
+	switch {
+	case n <= 8:
+		return typeU8, 1
+	case n <= 16:
+		return typeU16, 2
+	case n <= 32:
+		return typeU32, 4
+	case n <= 64:
+		return type64, 8
+	}
+	panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=\" run exceeds 64 bits; split it into more than one run"})
+}
+
+// collectBitGroups scans fields for every run of consecutive
+// `ffi:"bits=N"` fields and returns the bitGroup each run packs into, in
+// field order. A run ends at the first field (or end of the list) that
+// isn't tagged `ffi:"bits="` - unlike ffi:"len="/"cap=", which name their
+// companion field by name and so can be declared in any order, a
+// bitfield run has to be contiguous because its members share one packed
+// word with no room to name where each one lives relative to the others
+// except by position. fields is normally flattenFields(t)'s result, the
+// same list every arch's buildSpec classifies - not t.Field(i) directly -
+// so a bitfield run embedded in a shared header struct is found too.
+func collectBitGroups(fields []reflect.StructField) []bitGroup {
+	var groups []bitGroup
+	var cur *bitGroup
+	var bitPos int
+
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		cur.t, cur.size = bitfieldStorage(bitPos)
+		groups = append(groups, *cur)
+		cur = nil
+		bitPos = 0
+	}
+
+	for _, f := range fields {
+		tags := parseFieldTags(f)
+		if tags.bits == 0 {
+			flush()
+			continue
+		}
+		if tags.bits < 1 {
+			panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=\" width must be at least 1"})
+		}
+		if cur == nil {
+			cur = &bitGroup{offset: uint16(f.Offset)}
+		}
+		cur.fields = append(cur.fields, bitField{
+			offset:    uint16(f.Offset),
+			typ:       f.Type,
+			bitOffset: uint8(bitPos),
+			bitWidth:  uint8(tags.bits),
+		})
+		bitPos += tags.bits
+	}
+	flush()
+	return groups
+}
+
+// bitGroupFor returns the group f.Offset belongs to, and whether
+// f.Offset is that group's own representative offset (the only member
+// MakeSpec places as an actual argument; every other member rides along
+// packed into the same word, placed nowhere of its own).
+func bitGroupFor(groups []bitGroup, offset uint16) (bitGroup, bool) {
+	for _, g := range groups {
+		for _, f := range g.fields {
+			if f.offset == offset {
+				return g, offset == g.offset
+			}
+		}
+	}
+	return bitGroup{}, false
+}
+
+// bitFieldIn returns the bitField offset belongs to, for CheckLayout
+// (layout.go) to verify a generator's expected bit position against.
+func bitFieldIn(groups []bitGroup, offset uint16) (bitField, bool) {
+	for _, g := range groups {
+		for _, f := range g.fields {
+			if f.offset == offset {
+				return f, true
+			}
+		}
+	}
+	return bitField{}, false
+}
+
+// bitFieldValue reads f's current logical value out of args, masked
+// down to its declared width - callers that already hold the full
+// packed word (prepareBitFields, building it) shift this left by
+// f.bitOffset; callers unpacking it back out do the reverse.
+func bitFieldValue(args unsafe.Pointer, f bitField) uint64 {
+	v := reflect.NewAt(f.typ, unsafe.Pointer(uintptr(args)+uintptr(f.offset))).Elem()
+	var raw uint64
+	if k := f.typ.Kind(); k >= reflect.Int && k <= reflect.Int64 {
+		raw = uint64(v.Int())
+	} else {
+		raw = v.Uint()
+	}
+	mask := uint64(1)<<f.bitWidth - 1
+	return raw & mask
+}
+
+// setBitFieldValue writes value (already masked to f.bitWidth, already
+// shifted back down to bit 0) into f's own logical field in args.
+func setBitFieldValue(args unsafe.Pointer, f bitField, value uint64) {
+	v := reflect.NewAt(f.typ, unsafe.Pointer(uintptr(args)+uintptr(f.offset))).Elem()
+	if k := f.typ.Kind(); k >= reflect.Int && k <= reflect.Int64 {
+		v.SetInt(int64(value))
+	} else {
+		v.SetUint(value)
+	}
+}
+
+// packedWord reads groups' shared storage word - size bytes starting at
+// offset - as a plain unsigned integer, regardless of its argtype.
+func packedWord(args unsafe.Pointer, g bitGroup) uint64 {
+	ptr := unsafe.Pointer(uintptr(args) + uintptr(g.offset))
+	switch g.size {
+	case 1:
+		return uint64(*(*uint8)(ptr))
+	case 2:
+		return uint64(*(*uint16)(ptr))
+	case 4:
+		return uint64(*(*uint32)(ptr))
+	default:
+		return *(*uint64)(ptr)
+	}
+}
+
+// setPackedWord writes value into group g's shared storage word.
+func setPackedWord(args unsafe.Pointer, g bitGroup, value uint64) {
+	ptr := unsafe.Pointer(uintptr(args) + uintptr(g.offset))
+	switch g.size {
+	case 1:
+		*(*uint8)(ptr) = uint8(value)
+	case 2:
+		*(*uint16)(ptr) = uint16(value)
+	case 4:
+		*(*uint32)(ptr) = uint32(value)
+	default:
+		*(*uint64)(ptr) = value
+	}
+}
+
+// prepareBitFields packs every group's members into their shared
+// storage word - overwriting the representative field's own raw bytes
+// in place, the same trick ffi:"cstr" uses on a string field's Data
+// word - and returns a cleanup that unpacks the word (in case the
+// callee wrote back through a pointer to it elsewhere in the same args)
+// back out into each member's own logical field, restoring the
+// representative field's value along with everyone else's.
+func prepareBitFields(args unsafe.Pointer, groups []bitGroup) func() {
+	if len(groups) == 0 {
+		return func() {}
+	}
+	for _, g := range groups {
+		var packed uint64
+		for _, f := range g.fields {
+			packed |= bitFieldValue(args, f) << f.bitOffset
+		}
+		setPackedWord(args, g, packed)
+	}
+	return func() {
+		for _, g := range groups {
+			packed := packedWord(args, g)
+			for _, f := range g.fields {
+				mask := uint64(1)<<f.bitWidth - 1
+				setBitFieldValue(args, f, (packed>>f.bitOffset)&mask)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// cFlags mirrors a C struct like:
+//
+//	struct flags {
+//	    unsigned a : 3;
+//	    unsigned b : 5;
+//	    unsigned c : 24;
+//	};
+//
+// packed into one 32 bit storage word, plus a trailing plain field to
+// confirm a run ends cleanly at the first non-bits field.
+type cFlags struct {
+	A uint32 `ffi:"bits=3"`
+	B uint32 `ffi:"bits=5"`
+	C uint32 `ffi:"bits=24"`
+	D uint32
+}
+
+// TestCollectBitGroupsPacksARun confirms collectBitGroups finds the A/B/C
+// run, picks a 32 bit storage word for their 32 total bits, and leaves D
+// alone.
+func TestCollectBitGroupsPacksARun(t *testing.T) {
+	groups := collectBitGroups(flattenFields(reflect.TypeOf(cFlags{})))
+	if len(groups) != 1 {
+		t.Fatalf("collectBitGroups: got %d groups, want 1", len(groups))
+	}
+	g := groups[0]
+	if g.size != 4 || g.t != typeU32 {
+		t.Fatalf("collectBitGroups: storage = %d bytes/%v, want 4/typeU32", g.size, g.t)
+	}
+	if len(g.fields) != 3 {
+		t.Fatalf("collectBitGroups: got %d fields, want 3", len(g.fields))
+	}
+	want := []bitField{
+		{offset: 0, typ: reflect.TypeOf(uint32(0)), bitOffset: 0, bitWidth: 3},
+		{offset: 4, typ: reflect.TypeOf(uint32(0)), bitOffset: 3, bitWidth: 5},
+		{offset: 8, typ: reflect.TypeOf(uint32(0)), bitOffset: 8, bitWidth: 24},
+	}
+	for i, w := range want {
+		if g.fields[i] != w {
+			t.Fatalf("collectBitGroups: fields[%d] = %+v, want %+v", i, g.fields[i], w)
+		}
+	}
+}
+
+// TestPrepareBitFieldsRoundTrips confirms prepareBitFields packs every
+// member's value into the shared word at the group's offset, and its
+// cleanup unpacks the word back out into each member's own field.
+func TestPrepareBitFieldsRoundTrips(t *testing.T) {
+	args := cFlags{A: 5, B: 17, C: 0xABCDEF, D: 0x11223344}
+	groups := collectBitGroups(flattenFields(reflect.TypeOf(args)))
+
+	cleanup := prepareBitFields(unsafe.Pointer(&args), groups)
+
+	want := uint32(5) | uint32(17)<<3 | uint32(0xABCDEF)<<8
+	if args.A != want {
+		t.Fatalf("prepareBitFields: packed word = %#x, want %#x", args.A, want)
+	}
+	if args.D != 0x11223344 {
+		t.Fatalf("prepareBitFields: D = %#x, want untouched 0x11223344", args.D)
+	}
+
+	cleanup()
+	if args.A != 5 || args.B != 17 || args.C != 0xABCDEF {
+		t.Fatalf("prepareBitFields: after cleanup A=%d B=%d C=%d, want 5/17/%d", args.A, args.B, args.C, 0xABCDEF)
+	}
+}
+
+// TestPrepareBitFieldsTruncatesOverflow confirms a value wider than its
+// declared bit width is masked down, the same way a real C compiler
+// truncates an out-of-range bitfield assignment.
+func TestPrepareBitFieldsTruncatesOverflow(t *testing.T) {
+	args := cFlags{A: 0xFF} // only 3 bits kept
+	groups := collectBitGroups(flattenFields(reflect.TypeOf(args)))
+
+	cleanup := prepareBitFields(unsafe.Pointer(&args), groups)
+	cleanup()
+
+	if args.A != 0xFF&0x7 {
+		t.Fatalf("prepareBitFields: A = %d, want truncated %d", args.A, 0xFF&0x7)
+	}
+}
+
+// TestCollectBitGroupsWidthOverflowPanics confirms a run totalling more
+// than 64 bits panics rather than silently truncating.
+func TestCollectBitGroupsWidthOverflowPanics(t *testing.T) {
+	type tooWide struct {
+		A uint64 `ffi:"bits=40"`
+		B uint64 `ffi:"bits=30"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("collectBitGroups: expected a panic for a run over 64 bits")
+		}
+	}()
+	collectBitGroups(flattenFields(reflect.TypeOf(tooWide{})))
+}
+
+// TestCollectBitGroupsZeroWidthPanics confirms ffi:"bits=0" is rejected
+// at classification time rather than silently contributing nothing.
+func TestCollectBitGroupsZeroWidthPanics(t *testing.T) {
+	type zeroWidth struct {
+		A uint32 `ffi:"bits=0"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("collectBitGroups: expected a panic for ffi:\"bits=0\"")
+		}
+	}()
+	collectBitGroups(flattenFields(reflect.TypeOf(zeroWidth{})))
+}
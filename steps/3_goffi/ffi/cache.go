@@ -0,0 +1,80 @@
+package ffi
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// specCache memoizes the Spec template buildSpec constructs for a given
+// argument type, since walking struct fields with reflect and parsing
+// "ffi" tags on every call dominates cost on hot FFI paths. MakeSpec
+// becomes a cache lookup plus a shallow copy that only patches in fn. It's
+// keyed on reflect.Type alone: a field's "type=" override is part of that
+// type's static tag, so one reflect.Type can never resolve to two
+// different override sets. fn isn't part of the key - it has no bearing
+// on the layout buildSpec computes, so two functions sharing an argument
+// type share one cached template too.
+var specCache sync.Map // map[reflect.Type]Spec
+
+// specCacheHits and specCacheMisses back SpecCacheStatsNow. They're
+// plain atomics rather than anything sync.Map itself tracks, since
+// sync.Map exposes no hit/miss counting of its own.
+var (
+	specCacheHits   atomic.Uint64
+	specCacheMisses atomic.Uint64
+)
+
+// SpecCacheStats reports how many times MakeSpec found (Hits) or built
+// (Misses) a given argument type's Spec template.
+type SpecCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// SpecCacheStatsNow returns the current SpecCacheStats, for judging
+// whether specCache is earning its keep in a given program - a caller
+// that passes MakeSpec a fresh, dynamically built argument type on every
+// call (rather than binding one type once, as MustSpecFor encourages)
+// would show a high Misses count relative to Hits despite specCache's
+// own correctness.
+func SpecCacheStatsNow() SpecCacheStats {
+	return SpecCacheStats{
+		Hits:   specCacheHits.Load(),
+		Misses: specCacheMisses.Load(),
+	}
+}
+
+// MakeSpec builds a call specification for the given arguments. The
+// struct layout classification is cached by type (see specCache), so
+// repeated calls for the same argument type - the common case for a
+// function bound once and called many times - only pay for a map lookup
+// and a shallow copy of the cached template.
+func MakeSpec(fn uintptr, args interface{}) Spec {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	if cached, ok := specCache.Load(t); ok {
+		specCacheHits.Add(1)
+		spec := cached.(Spec)
+		spec.fn = fn
+		return spec
+	}
+
+	specCacheMisses.Add(1)
+	spec := buildSpec(0, args)
+	specCache.Store(t, spec)
+	spec.fn = fn
+	return spec
+}
+
+// MustSpecFor builds (or reuses a cached) Spec for T, a convenience for
+// binding a struct type once - typically at init time - instead of
+// passing a throwaway zero value of T to MakeSpec on every call.
+func MustSpecFor[T any](fn uintptr) Spec {
+	var zero T
+	return MakeSpec(fn, &zero)
+}
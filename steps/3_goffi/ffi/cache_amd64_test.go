@@ -0,0 +1,50 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// benchTarget (cache_amd64_test.s) is a trivial asm function, just enough
+// of a real call target to exercise MakeSpec/Call's fast path.
+func benchTarget()
+func addrOfBenchTarget() uintptr
+
+type benchArgs struct {
+	A int32 `ffi:"arg"`
+	B int32 `ffi:"arg"`
+	R int32 `ffi:"ret"`
+}
+
+// BenchmarkCall measures MakeSpec's cache-hit fast path plus Call: binding
+// a function once and invoking it many times is the common case the
+// specCache in cache.go exists for.
+func BenchmarkCall(b *testing.B) {
+	fn := addrOfBenchTarget()
+	var args benchArgs
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		spec := MakeSpec(fn, &args)
+		spec.Call(unsafe.Pointer(&args))
+	}
+}
+
+// BenchmarkCallFast is BenchmarkCall's CallFast counterpart. Unlike Call
+// (one heap allocation per call for callParams - see its doc comment),
+// CallFast's b.ReportAllocs() output should read 0 allocs/op: that's the
+// zero-allocation contract ffi_amd64.go's CallFast and callFast promise,
+// verified the same way any other allocation regression would be caught
+// here.
+func BenchmarkCallFast(b *testing.B) {
+	fn := addrOfBenchTarget()
+	var args benchArgs
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		spec := MakeSpec(fn, &args)
+		spec.CallFast(unsafe.Pointer(&args))
+	}
+}
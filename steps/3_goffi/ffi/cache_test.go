@@ -0,0 +1,34 @@
+package ffi
+
+import "testing"
+
+// specCacheStatsTestArgs exists only so this test has a reflect.Type of
+// its own - one specCache has never seen before - letting it tell a miss
+// from a hit without depending on what other tests have already put in
+// the (package-wide, never-reset) cache.
+type specCacheStatsTestArgs struct {
+	A int32 `ffi:"arg"`
+}
+
+func TestSpecCacheStats(t *testing.T) {
+	before := SpecCacheStatsNow()
+
+	var args specCacheStatsTestArgs
+	MakeSpec(0, &args)
+	afterMiss := SpecCacheStatsNow()
+	if afterMiss.Misses != before.Misses+1 {
+		t.Fatalf("Misses after first MakeSpec: got %d, want %d", afterMiss.Misses, before.Misses+1)
+	}
+	if afterMiss.Hits != before.Hits {
+		t.Fatalf("Hits after first MakeSpec: got %d, want %d (unchanged)", afterMiss.Hits, before.Hits)
+	}
+
+	MakeSpec(0, &args)
+	afterHit := SpecCacheStatsNow()
+	if afterHit.Hits != afterMiss.Hits+1 {
+		t.Fatalf("Hits after second MakeSpec: got %d, want %d", afterHit.Hits, afterMiss.Hits+1)
+	}
+	if afterHit.Misses != afterMiss.Misses {
+		t.Fatalf("Misses after second MakeSpec: got %d, want %d (unchanged)", afterHit.Misses, afterMiss.Misses)
+	}
+}
@@ -0,0 +1,362 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// callbackFrameSize is callbackFrame's size, shared with callback_amd64.s
+// as const_callbackFrameSize via the compiler's automatically generated
+// go_asm.h, so the two sides agree on the frame layout without
+// duplicating the field offsets.
+const callbackFrameSize = 19 * 8
+
+// cgocallbackArgsSize is the size of runtime.cgocallback's own three
+// incoming stack arguments (fn, frame, ctxt). callbackasm has to lay
+// these out at the very bottom of its own frame before CALLing
+// cgocallback - the same trick asmcall's stackargs relies on in
+// ffi_amd64.s for its own outgoing call.
+const cgocallbackArgsSize = 3 * 8
+
+// callbackWrapFrameSize is callbackWrapFrame's size. cgocallback always
+// invokes its fn as a single func(unsafe.Pointer) (see cgocallbackg1 in
+// runtime/cgocall.go), so callbackCall's actual (slot, *callbackFrame)
+// pair has to be bundled into the one struct passed through as that
+// pointer, which callbackWrap unpacks on the other side.
+const callbackWrapFrameSize = 16
+
+// callbackAsmFrameSize is callbackasm's own TEXT frame size:
+// cgocallback's own incoming argument frame, followed by the
+// callbackWrapFrame passed through to callbackWrap, followed by the
+// callbackFrame itself (see callback_amd64.s). The assembler's TEXT
+// directive requires a literal frame size, so this is precomputed into
+// its own const_callbackAsmFrameSize rather than written as an
+// expression in the .s file.
+const callbackAsmFrameSize = cgocallbackArgsSize + callbackWrapFrameSize + callbackFrameSize
+
+// callbackThunks backs every MakeCallback trampoline: rather than a
+// fixed table of pre-assembled stub functions (this package's earlier
+// approach - see buildCallbackTrampoline's doc comment), each trampoline
+// is now a handful of JIT-built bytes handed to the same ThunkAllocator
+// jit.go was written ahead of.
+var callbackThunks = NewThunkAllocator()
+
+// callbackContext is what a live callback slot remembers: spec's layout
+// drives callbackCall's marshaling, fnType is kept only so a leaked
+// slot's report in LeakedCallbacks can name it, and trampoline is the
+// JIT-built function pointer callbackCall's caller (foreign code) was
+// actually handed, which doubles as callbackSlots' lookup key for
+// ReleaseCallback.
+type callbackContext struct {
+	spec       Spec // only the layout (intargs/xmmargs/stack/ret*) is used
+	size       uintptr
+	fn         func(args unsafe.Pointer)
+	fnType     reflect.Type
+	trampoline uintptr
+
+	// panicMode is nil unless SetCallbackPanicMode has been called for
+	// this slot (callback_panic_amd64.go), in which case a panic from fn
+	// is contained at the trampoline boundary instead of unwinding into
+	// cgocallback's C frame.
+	panicMode *CallbackPanicMode
+}
+
+var (
+	callbackMu    sync.Mutex
+	callbackSlots []*callbackContext
+	// callbackFree holds released slot indexes, most-recently-freed last,
+	// so callbackAcquireSlot reuses the most recently released slot
+	// before ever growing callbackSlots - the same LIFO reuse the old
+	// fixed-size pool used, just backing a slice that can still grow
+	// once it runs out of free slots to hand back instead of panicking.
+	callbackFree []int
+)
+
+// callbackAcquireSlot returns a slot index for a new callback, reusing
+// the most recently released one if callbackFree isn't empty, growing
+// callbackSlots by one otherwise. Callers must hold callbackMu.
+func callbackAcquireSlot() int {
+	if n := len(callbackFree); n > 0 {
+		slot := callbackFree[n-1]
+		callbackFree = callbackFree[:n-1]
+		return slot
+	}
+	callbackSlots = append(callbackSlots, nil)
+	return len(callbackSlots) - 1
+}
+
+// buildCallbackTrampoline assembles the machine code for slot's
+// trampoline: an endbr64 landing pad (the Go assembler has no mnemonic
+// for it, so it's the same raw f3 0f 1e fa encoding
+// callback_stubs_amd64.s used to emit), loading slot into R11 - a
+// register the SysV AMD64 ABI never uses to pass arguments - and an
+// absolute jump into callbackasm.
+//
+// The jump is MOVABS+JMP-through-register rather than a direct JMP
+// rel32 deliberately: a rel32 displacement is relative to the
+// trampoline's own address, which isn't known until after
+// ThunkAllocator.Alloc has already mapped and, under the W^X discipline
+// it enforces, made the code executable - there is no later point at
+// which this function could still patch it in. Encoding the target as
+// an absolute immediate sidesteps needing to know the trampoline's own
+// address at all.
+func buildCallbackTrampoline(slot int) []byte {
+	code := []byte{0xf3, 0x0f, 0x1e, 0xfa} // endbr64
+	code = append(code, 0x41, 0xbb)        // MOVL $slot, R11
+	code = appendUint32LE(code, uint32(slot))
+	code = append(code, 0x49, 0xba) // MOVABS $callbackasm, R10
+	code = appendUint64LE(code, uint64(addrOfCallbackasm()))
+	code = append(code, 0x41, 0xff, 0xe2) // JMP R10
+	return code
+}
+
+func appendUint32LE(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64LE(b []byte, v uint64) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24), byte(v>>32), byte(v>>40), byte(v>>48), byte(v>>56))
+}
+
+// callbackasm is the shared dispatcher every JIT-built trampoline jumps
+// into; see callback_amd64.s. It has no Go body and is never called
+// directly from Go; buildCallbackTrampoline takes its address through
+// addrOfCallbackasm (callback_amd64.s).
+func callbackasm()
+
+func addrOfCallbackasm() uintptr
+
+// MakeCallback returns a C-callable function pointer that, when invoked by
+// foreign code following the SysV AMD64 ABI, marshals its incoming
+// registers and stack arguments into a struct laid out like fnType (using
+// the same field and "ffi" tag rules as MakeSpec) and calls fn with a
+// pointer to that struct. fn should store its result, if any, into the
+// field tagged "ret" before returning.
+//
+// Each call JIT-builds its own trampoline via callbackThunks rather than
+// drawing from a fixed-size pool, so MakeCallback never runs out of
+// slots to hand out. Call ReleaseCallback once the foreign code no
+// longer needs the returned pointer; LeakedCallbacks can report any
+// trampoline a caller forgets to.
+func MakeCallback(fnType interface{}, fn func(args unsafe.Pointer)) uintptr {
+	t := reflect.TypeOf(fnType)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	spec := MakeSpec(0, reflect.New(t).Interface())
+
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+
+	slot := callbackAcquireSlot()
+	code := buildCallbackTrampoline(slot)
+	trampoline, err := callbackThunks.Alloc(code)
+	if err != nil {
+		callbackFree = append(callbackFree, slot)
+		panic(fmt.Sprintf("ffi: MakeCallback: %v", err))
+	}
+	perfMapAddThunk(trampoline, len(code), fmt.Sprintf("ffi.callback[%d]:%s", slot, t))
+	callbackSlots[slot] = &callbackContext{spec: spec, size: t.Size(), fn: fn, fnType: t, trampoline: trampoline}
+
+	return trampoline
+}
+
+// ReleaseCallback frees the trampoline backing ptr, which must have been
+// returned by MakeCallback and not already released. ptr must not be
+// invoked again afterwards.
+func ReleaseCallback(ptr uintptr) {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+	for slot, ctx := range callbackSlots {
+		if ctx != nil && ctx.trampoline == ptr {
+			callbackSlots[slot] = nil
+			callbackFree = append(callbackFree, slot)
+			if err := callbackThunks.Free(ptr); err != nil {
+				panic(fmt.Sprintf("ffi: ReleaseCallback: %v", err))
+			}
+			return
+		}
+	}
+	panic(fmt.Sprintf("ffi: ReleaseCallback: %#x was not returned by MakeCallback, or was already released", ptr))
+}
+
+// LeakedCallbacks returns one error per trampoline MakeCallback has
+// handed out and ReleaseCallback has not yet freed, naming the Go type
+// each was built from. There's no finalizer watching for this the way
+// CStr's does for an unreleased string (cstr_handle.go): MakeCallback
+// hands the caller a bare uintptr, not a Go object the garbage collector
+// could ever consider unreachable - the trampoline itself stays
+// referenced from callbackSlots for as long as it exists, by design, so
+// nothing would ever make it eligible for collection in the first place.
+// LeakedCallbacks is meant to be called explicitly instead, typically at
+// the end of a test or near process exit, to catch what ReleaseCallback
+// was never called for.
+func LeakedCallbacks() []error {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+	var errs []error
+	for slot, ctx := range callbackSlots {
+		if ctx != nil {
+			errs = append(errs, fmt.Errorf("ffi: callback slot %d (built from %s) was never released via ReleaseCallback", slot, ctx.fnType))
+		}
+	}
+	return errs
+}
+
+// callbackFrame is the incoming C frame saved by a trampoline in
+// callback_amd64.s before it transitions into Go via cgocallback. intregs
+// holds RDI/RSI/RDX/RCX/R8/R9 in order, xmmregs holds XMM0-7 (float32
+// values in the low 32 bits), and stack points at the caller's outgoing
+// stack arguments, laid out eightbyte by eightbyte like Spec.stack.
+// ret0/ret1/xmmret0/xmmret1 are written back into RAX/RDX/XMM0/XMM1 by the
+// trampoline's dispatcher after callbackCall returns.
+type callbackFrame struct {
+	intregs [6]uint64
+	xmmregs [8]uint64
+	stack   unsafe.Pointer
+	ret0    uint64
+	ret1    uint64
+	xmmret0 uint64
+	xmmret1 uint64
+}
+
+// callbackWrapFrame is the one argument cgocallback's fn is ever invoked
+// with (see cgocallbackg1 in runtime/cgocall.go: fn is always called as
+// func(unsafe.Pointer)), bundling the slot index and *callbackFrame that
+// callbackCall actually wants. callbackasm builds one of these on the
+// stack and points cgocallback's "frame" argument at it; callbackWrap
+// unpacks it again on the other side.
+type callbackWrapFrame struct {
+	slot  int32
+	_     [4]byte
+	frame *callbackFrame
+}
+
+// callbackWrap is the fn callbackasm hands to runtime.cgocallback. It
+// just unpacks the callbackWrapFrame cgocallback invokes it with and
+// dispatches to the real callbackCall.
+func callbackWrap(wrap unsafe.Pointer) {
+	w := (*callbackWrapFrame)(wrap)
+	callbackCall(w.slot, w.frame)
+}
+
+// callbackCall is called from callbackasm (via cgocallback and
+// callbackWrap) with the slot recovered from the scratch register the
+// trampoline loaded. It marshals frame into a Go struct per the
+// registered spec's layout, dispatches into the callback, and marshals
+// the result back out.
+func callbackCall(slot int32, frame *callbackFrame) {
+	callbackMu.Lock()
+	ctx := callbackSlots[slot]
+	callbackMu.Unlock()
+	if ctx == nil {
+		panic("ffi: callback invoked on a released slot")
+	}
+	threadAttached()
+
+	if ok, depth := callbackEnter(); !ok {
+		reportCallbackDepthExceeded(ctx, frame, int(depth))
+		return
+	}
+	defer callbackExit()
+
+	args := make([]byte, ctx.size)
+	argp := unsafe.Pointer(&args[0])
+
+	for i, a := range ctx.spec.intargs {
+		if a.t == typeUnused {
+			break
+		}
+		storeArg(argp, a, frame.intregs[i])
+	}
+	for i, a := range ctx.spec.xmmargs {
+		if a.t == typeUnused {
+			break
+		}
+		storeArg(argp, a, frame.xmmregs[i])
+	}
+	for i, a := range ctx.spec.stack {
+		v := *(*uint64)(unsafe.Pointer(uintptr(frame.stack) + uintptr(i)*8))
+		storeArg(argp, a, v)
+	}
+
+	if ctx.panicMode != nil {
+		if callContained(ctx, frame, argp) {
+			// Keep the panic mode's fallback in frame.ret0 rather than
+			// loading the never-written return fields below over it.
+			return
+		}
+	} else {
+		ctx.fn(argp)
+	}
+
+	if ctx.spec.ret0.t != typeUnused {
+		frame.ret0 = loadArg(argp, ctx.spec.ret0)
+	}
+	if ctx.spec.ret1.t != typeUnused {
+		frame.ret1 = loadArg(argp, ctx.spec.ret1)
+	}
+	if ctx.spec.xmmret0.t != typeUnused {
+		frame.xmmret0 = loadArg(argp, ctx.spec.xmmret0)
+	}
+	if ctx.spec.xmmret1.t != typeUnused {
+		frame.xmmret1 = loadArg(argp, ctx.spec.xmmret1)
+	}
+}
+
+// storeArg writes the raw register value v into the Go field a describes.
+// For type64/typeDouble, a.size is usually 8, but an aggregate's trailing
+// eightbyte (see eightbyteArg) can be narrower when the aggregate's size
+// isn't a multiple of 8; only a.size bytes are then written, so the store
+// never runs past the end of the destination struct.
+func storeArg(argp unsafe.Pointer, a argument, v uint64) {
+	p := unsafe.Pointer(uintptr(argp) + uintptr(a.offset))
+	switch a.t {
+	case typeS32, typeU32, typeFloat:
+		*(*uint32)(p) = uint32(v)
+	case typeS16, typeU16:
+		*(*uint16)(p) = uint16(v)
+	case typeS8, typeU8:
+		*(*uint8)(p) = uint8(v)
+	default: // type64, typeDouble
+		if a.size == 8 {
+			*(*uint64)(p) = v
+			return
+		}
+		var buf [8]byte
+		*(*uint64)(unsafe.Pointer(&buf[0])) = v
+		for i := uint8(0); i < a.size; i++ {
+			*(*byte)(unsafe.Pointer(uintptr(p) + uintptr(i))) = buf[i]
+		}
+	}
+}
+
+// loadArg reads the Go field a describes back into a raw register value.
+// As with storeArg, a narrower-than-8 a.size (an aggregate's trailing
+// eightbyte) is only read for that many bytes; the rest of the returned
+// uint64 is zeroed rather than read past the field.
+func loadArg(argp unsafe.Pointer, a argument) uint64 {
+	p := unsafe.Pointer(uintptr(argp) + uintptr(a.offset))
+	switch a.t {
+	case typeS32, typeU32, typeFloat:
+		return uint64(*(*uint32)(p))
+	case typeS16, typeU16:
+		return uint64(*(*uint16)(p))
+	case typeS8, typeU8:
+		return uint64(*(*uint8)(p))
+	default: // type64, typeDouble
+		if a.size == 8 {
+			return *(*uint64)(p)
+		}
+		var buf [8]byte
+		for i := uint8(0); i < a.size; i++ {
+			buf[i] = *(*byte)(unsafe.Pointer(uintptr(p) + uintptr(i)))
+		}
+		return *(*uint64)(unsafe.Pointer(&buf[0]))
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// callbackArgs is what MakeCallback classifies fnType into: two named
+// int32 arguments and an int32 "ret" field, laid out the same way any
+// other MakeSpec-classified struct would be.
+type callbackArgs struct {
+	A int32 `ffi:"arg"`
+	B int32 `ffi:"arg"`
+	R int32 `ffi:"ret"`
+}
+
+// TestCallbackCall exercises callbackCall - the Go-side half of the
+// callback path that marshals an incoming callbackFrame into the Go args
+// struct and back - directly, rather than through callbackasm: that half
+// is a real assembly trampoline into cgocallback, which needs to be
+// invoked as a genuine foreign call to behave correctly (a raw CALL from
+// a test goroutine isn't equivalent), so it stays reviewed by eye for
+// now. callbackCall itself has no such requirement and is exercised here
+// with a hand-built frame and context exactly as callbackasm would build
+// one.
+func TestCallbackCall(t *testing.T) {
+	spec := MakeSpec(0, &callbackArgs{})
+
+	var got callbackArgs
+	ctx := &callbackContext{
+		spec: spec,
+		size: unsafe.Sizeof(callbackArgs{}),
+		fn: func(args unsafe.Pointer) {
+			a := (*callbackArgs)(args)
+			got = *a
+			a.R = a.A + a.B
+		},
+	}
+
+	callbackMu.Lock()
+	slot := callbackAcquireSlot()
+	callbackSlots[slot] = ctx
+	callbackMu.Unlock()
+	defer func() {
+		callbackMu.Lock()
+		callbackSlots[slot] = nil
+		callbackFree = append(callbackFree, slot)
+		callbackMu.Unlock()
+	}()
+
+	frame := &callbackFrame{intregs: [6]uint64{3, 4}}
+	callbackCall(int32(slot), frame)
+
+	if got.A != 3 || got.B != 4 {
+		t.Fatalf("callback args: got %+v, want A=3 B=4", got)
+	}
+	if frame.ret0 != 7 {
+		t.Fatalf("callback ret0: got %d, want 7", frame.ret0)
+	}
+}
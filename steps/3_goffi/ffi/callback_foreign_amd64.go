@@ -0,0 +1,74 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import _ "unsafe" // for go:linkname
+
+// A callback trampoline built by MakeCallback (callback_amd64.go) can be
+// handed to a C library that invokes it from a thread of its own making -
+// one Go's scheduler has never seen and has no g for. callbackasm's CALL
+// into runtime.cgocallback (callback_amd64.s) already does the hard part
+// of making that safe: cgocallback checks for a nil g itself and, when it
+// finds one, calls runtime.needm to borrow an m (and its TLS) for the
+// duration of the call, then runtime.dropm to give it back once the Go
+// side returns - that pairing is exactly how a real cgo build supports a
+// foreign thread calling back into Go, and it needs no help from this
+// package to run correctly once it runs at all.
+//
+// The part that does need help: needm draws its m from the runtime's
+// "extra M" list, and that list is normally seeded once, automatically,
+// during process startup - but only when runtime.iscgo is true (see
+// mstartm0 in runtime/proc.go), which it never is for a plain `go build`
+// binary like one linked against this package. Left alone, the list
+// stays empty forever and the first callback a foreign thread ever makes
+// would hang forever inside needm's getExtraM, spinning on a list that
+// nothing will ever fill.
+//
+// Setting iscgo itself instead would fix that same symptom, but it also
+// flips several other process-wide behaviors that have nothing to do
+// with receiving a callback - which OS threads get spawned through
+// (_cgo_thread_start, which this package doesn't provide, so a real
+// attempt to use it would throw at startup), signal handling, GC
+// bookkeeping - as a side effect of merely importing this package. This
+// seeds the same list runtime.newextram already knows how to build,
+// directly, without any of that.
+//
+// One seeded m is enough for callbacks that never overlap, since dropm
+// returns its m to the list before the next needm ever needs one - but
+// a library that hands the same trampoline to several of its own
+// threads (a thread pool, or one callback per audio/IO device) can have
+// more than one callback genuinely in flight at once, and a second
+// concurrent needm call just spins in getExtraM until the first call's m
+// comes back. extraMSeedCount seeds enough to cover a small thread pool
+// without needing the caller to know this package's internals to size
+// it; GrowForeignThreadCapacity covers anything bigger.
+//
+//go:linkname newextram runtime.newextram
+func newextram()
+
+//go:linkname systemstack runtime.systemstack
+func systemstack(fn func())
+
+// extraMSeedCount is how many extra Ms init seeds the runtime's
+// "extra M" list with - see newextram's own doc comment for why any
+// seeding is needed here at all, and GrowForeignThreadCapacity's for why
+// more than one is.
+const extraMSeedCount = 8
+
+func init() {
+	for i := 0; i < extraMSeedCount; i++ {
+		systemstack(newextram)
+	}
+}
+
+// GrowForeignThreadCapacity seeds n additional entries in the runtime's
+// "extra M" list (see init's own doc comment), for a program whose
+// foreign threads can have more than extraMSeedCount callbacks
+// genuinely in flight at once - more worker/audio/IO threads than this
+// package guesses by default, all calling back concurrently. It's
+// additive: calling it twice with n=4 seeds 8 more Ms total, not 4.
+func GrowForeignThreadCapacity(n int) {
+	for i := 0; i < n; i++ {
+		systemstack(newextram)
+	}
+}
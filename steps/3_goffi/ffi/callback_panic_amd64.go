@@ -0,0 +1,137 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// CallbackPanicMode configures how a MakeCallback trampoline behaves
+// when its own fn panics, installed via SetCallbackPanicMode. Without
+// it, a panic inside fn does exactly what it always has: unwind
+// straight out of callbackCall, into cgocallback, and into the
+// trampoline's own C frame - which is not a Go panic reaching a Go
+// recover anymore at that point, just undefined behavior the instant it
+// tries to cross a frame cgocallback can't walk.
+//
+// With a mode installed, callbackCall instead recovers the panic right
+// there, before it ever reaches cgocallback, and handles it one of two
+// ways depending on which field is set.
+type CallbackPanicMode struct {
+	// Abort, if non-empty, is logged to stderr as a fatal report
+	// together with the recovered panic value, and the process exits
+	// immediately - the foreign caller is never returned to at all.
+	// This is the right choice when the C contract this callback
+	// fulfills has no return value that safely means "something went
+	// wrong" (a destructor, a visitor with no failure path), so handing
+	// back any fabricated value would just let the foreign code carry
+	// on past a Go-side failure it has no way to notice.
+	Abort string
+
+	// Return is the raw value callbackCall writes into the callback's
+	// integer return register in fn's place when it panics and Abort is
+	// empty. The panic itself isn't discarded: it's queued and
+	// re-raised by reraiseCallbackPanic once the outer Call that
+	// triggered this callback has itself returned from the foreign
+	// function - by which point the callback's C frame is long gone and
+	// a Go panic can unwind through the caller's own stack same as any
+	// other.
+	Return uint64
+}
+
+// SetCallbackPanicMode installs mode for the callback ptr previously
+// returned by MakeCallback, so a future panic inside its fn is contained
+// at the trampoline boundary per mode's rules instead of running into
+// cgocallback unrecovered. It panics if ptr was not returned by
+// MakeCallback or has already been released.
+func SetCallbackPanicMode(ptr uintptr, mode CallbackPanicMode) {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+	for _, ctx := range callbackSlots {
+		if ctx != nil && ctx.trampoline == ptr {
+			ctx.panicMode = &mode
+			return
+		}
+	}
+	panic(fmt.Sprintf("ffi: SetCallbackPanicMode: %#x was not returned by MakeCallback, or was already released", ptr))
+}
+
+// callContained runs ctx.fn under recover, per ctx.panicMode, called
+// from callbackCall in place of a bare ctx.fn(argp) once a panic mode
+// has been installed for ctx's slot. It reports whether fn panicked, in
+// which case frame.ret0 already holds ctx.panicMode.Return.
+func callContained(ctx *callbackContext, frame *callbackFrame, argp unsafe.Pointer) (panicked bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if ctx.panicMode.Abort != "" {
+			fmt.Fprintf(os.Stderr, "ffi: fatal: %s: callback panicked: %v\n", ctx.panicMode.Abort, r)
+			os.Exit(2)
+		}
+		frame.ret0 = ctx.panicMode.Return
+		queueCallbackPanic(r)
+		panicked = true
+	}()
+	ctx.fn(argp)
+	return false
+}
+
+// callbackPanicMu guards callbackPanics. callbackPanicCount mirrors
+// len(callbackPanics) as an atomic so reraiseCallbackPanic's by-far most
+// common case - nothing queued - costs a single atomic load instead of
+// taking the mutex on every Call/CallFast return.
+var (
+	callbackPanicMu    sync.Mutex
+	callbackPanics     []interface{}
+	callbackPanicCount atomic.Int32
+)
+
+// queueCallbackPanic records r, recovered from a callback whose
+// CallbackPanicMode.Abort was empty, for reraiseCallbackPanic to
+// re-panic with once the Call that triggered the callback returns.
+func queueCallbackPanic(r interface{}) {
+	callbackPanicMu.Lock()
+	callbackPanics = append(callbackPanics, r)
+	callbackPanicMu.Unlock()
+	callbackPanicCount.Add(1)
+}
+
+// reraiseCallbackPanic is called by Spec.Call and Spec.CallFast
+// (ffi_amd64.go) right after the foreign call has returned, so a panic
+// recovered from a callback it triggered still reaches the Go caller -
+// just delayed past the point a bare, unrecovered panic would otherwise
+// have had to cross the callback trampoline's C frame.
+//
+// This has no way to confirm the panic it re-raises actually came from
+// a callback this particular Call invoked rather than some other
+// concurrent Call's: callbackPanics is a single process-wide queue, not
+// one scoped per call. For the overwhelmingly common case - a C function
+// invokes a registered callback synchronously, on the calling goroutine,
+// before returning - that's moot, since no other panic can have been
+// queued in between. Two calls whose callbacks both panic genuinely
+// concurrently may see each other's panic instead of their own; like
+// signalRing's Dropped count (signal_callback_amd64.go), that's a named
+// trade-off of a design with no per-call synchronization overhead on the
+// vastly more common path where nothing panics at all.
+func reraiseCallbackPanic() {
+	if callbackPanicCount.Load() == 0 {
+		return
+	}
+	callbackPanicMu.Lock()
+	var r interface{}
+	if len(callbackPanics) > 0 {
+		r = callbackPanics[0]
+		callbackPanics = callbackPanics[1:]
+	}
+	callbackPanicMu.Unlock()
+	if r != nil {
+		callbackPanicCount.Add(-1)
+		panic(r)
+	}
+}
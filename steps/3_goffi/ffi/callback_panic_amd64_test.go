@@ -0,0 +1,65 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// registerCallbackCtx registers ctx in callbackSlots and returns a func
+// that releases it again, the same bookkeeping TestCallbackCall does
+// inline.
+func registerCallbackCtx(t *testing.T, ctx *callbackContext) int32 {
+	t.Helper()
+	callbackMu.Lock()
+	slot := callbackAcquireSlot()
+	callbackSlots[slot] = ctx
+	callbackMu.Unlock()
+	t.Cleanup(func() {
+		callbackMu.Lock()
+		callbackSlots[slot] = nil
+		callbackFree = append(callbackFree, slot)
+		callbackMu.Unlock()
+	})
+	return int32(slot)
+}
+
+// TestCallbackCallPanicContained checks that a panic inside fn, once
+// CallbackPanicMode is installed, never reaches callbackCall's own
+// caller: it's recovered, the configured fallback lands in frame.ret0,
+// and the panic is queued for reraiseCallbackPanic instead.
+func TestCallbackCallPanicContained(t *testing.T) {
+	spec := MakeSpec(0, &callbackArgs{})
+	ctx := &callbackContext{
+		spec: spec,
+		size: unsafe.Sizeof(callbackArgs{}),
+		fn: func(args unsafe.Pointer) {
+			panic("boom")
+		},
+		panicMode: &CallbackPanicMode{Return: 99},
+	}
+	slot := registerCallbackCtx(t, ctx)
+
+	frame := &callbackFrame{}
+	callbackCall(slot, frame)
+
+	if frame.ret0 != 99 {
+		t.Fatalf("frame.ret0 = %d, want 99 (the configured fallback)", frame.ret0)
+	}
+
+	defer func() {
+		r := recover()
+		if r != "boom" {
+			t.Fatalf("reraiseCallbackPanic recovered %v, want \"boom\"", r)
+		}
+	}()
+	reraiseCallbackPanic()
+	t.Fatal("reraiseCallbackPanic did not re-panic with the contained panic")
+}
+
+// TestReraiseCallbackPanicNoneQueued checks the common, nothing-panicked
+// path: reraiseCallbackPanic must be a no-op when callbackPanics is empty.
+func TestReraiseCallbackPanicNoneQueued(t *testing.T) {
+	reraiseCallbackPanic() // must not panic
+}
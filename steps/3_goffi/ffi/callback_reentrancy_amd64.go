@@ -0,0 +1,107 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// maxCallbackDepth is set once, from a GODEBUG=ffimaxcallbackdepth=N
+// setting, by init below - the same env-var-gated pattern
+// pointerCheckEnabled (pointercheck.go) uses. 0, the default, leaves
+// reentrant callbacks unbounded exactly as they were before this file
+// existed; a positive N caps how many callback invocations may be
+// nested across the whole process at once.
+var maxCallbackDepth int
+
+func init() {
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		v, ok := strings.CutPrefix(setting, "ffimaxcallbackdepth=")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxCallbackDepth = n
+		}
+		break
+	}
+}
+
+// callbackDepth counts callback invocations currently nested somewhere
+// on the process's call stacks - incremented by callbackEnter, decremented
+// by callbackExit. It's a single process-wide counter rather than one
+// scoped per goroutine or OS thread, the same trade-off
+// reraiseCallbackPanic's callbackPanics queue makes (callback_panic_
+// amd64.go): several unrelated, shallow, concurrently-in-flight call
+// chains can sum past maxCallbackDepth even though no single chain
+// actually recursed that deep. For the pathological case this guards
+// against - one callback recursing into the same call chain without
+// bound, or deadlocking on a Go-level lock the outer call already holds -
+// that's an acceptable false-positive rate in exchange for needing no
+// per-thread bookkeeping on every single callback invocation.
+var callbackDepth atomic.Int64
+
+// callbackEnter records one more nested callback invocation and reports
+// whether it's still within maxCallbackDepth (always true if
+// maxCallbackDepth is 0, i.e. unset). A false result has already rolled
+// the increment back; callers must not call callbackExit for it.
+func callbackEnter() (ok bool, depth int64) {
+	if maxCallbackDepth == 0 {
+		return true, 0
+	}
+	d := callbackDepth.Add(1)
+	if d > int64(maxCallbackDepth) {
+		callbackDepth.Add(-1)
+		return false, d
+	}
+	return true, d
+}
+
+// callbackExit balances a successful callbackEnter.
+func callbackExit() {
+	if maxCallbackDepth == 0 {
+		return
+	}
+	callbackDepth.Add(-1)
+}
+
+// callbackDepthExceeded is what callbackCall reports - via ctx.panicMode
+// if one is installed, or by panicking directly otherwise - when
+// callbackEnter refuses a callback invocation because maxCallbackDepth
+// was already reached. depth is the nesting level the rejected
+// invocation would have been at.
+type callbackDepthExceeded struct {
+	depth int
+	max   int
+}
+
+func (e *callbackDepthExceeded) Error() string {
+	return fmt.Sprintf("ffi: callback reentrancy depth exceeded: %d nested callback invocations already in progress (max %d) - a C call invoked this callback while that many others were still running, which usually means a callback is recursing into the same call chain unboundedly, or is blocked acquiring a Go-level lock its own outer call already holds", e.depth, e.max)
+}
+
+// reportCallbackDepthExceeded handles a callbackEnter rejection for
+// slot's context: ctx.panicMode, if installed, decides whether this
+// aborts the process or is contained and re-raised like any other
+// callback panic (callback_panic_amd64.go); with no panicMode at all, it
+// panics directly, same as an uncontained callback panic always has -
+// still strictly better than the hang or stack overflow it replaces,
+// since the diagnostic below reaches stderr regardless of what the
+// panic itself goes on to do.
+func reportCallbackDepthExceeded(ctx *callbackContext, frame *callbackFrame, depth int) {
+	err := &callbackDepthExceeded{depth: depth, max: maxCallbackDepth}
+	fmt.Fprintln(os.Stderr, err.Error())
+
+	if ctx.panicMode == nil {
+		panic(err)
+	}
+	if ctx.panicMode.Abort != "" {
+		fmt.Fprintf(os.Stderr, "ffi: fatal: %s: %v\n", ctx.panicMode.Abort, err)
+		os.Exit(2)
+	}
+	frame.ret0 = ctx.panicMode.Return
+	queueCallbackPanic(err)
+}
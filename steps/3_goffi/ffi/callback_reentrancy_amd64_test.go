@@ -0,0 +1,93 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// withMaxCallbackDepth sets maxCallbackDepth for the duration of the
+// test, restoring it (and callbackDepth, which a failing test might
+// otherwise leave non-zero) afterwards.
+func withMaxCallbackDepth(t *testing.T, n int) {
+	t.Helper()
+	old := maxCallbackDepth
+	maxCallbackDepth = n
+	t.Cleanup(func() {
+		maxCallbackDepth = old
+		callbackDepth.Store(0)
+	})
+}
+
+// TestCallbackEnterExceedsDepth checks callbackEnter/callbackExit
+// directly: once maxCallbackDepth invocations are already open,
+// callbackEnter refuses a further one and leaves the counter unchanged
+// (rather than leaving it incremented for a callbackExit that will never
+// come).
+func TestCallbackEnterExceedsDepth(t *testing.T) {
+	withMaxCallbackDepth(t, 2)
+
+	ok1, d1 := callbackEnter()
+	ok2, d2 := callbackEnter()
+	ok3, _ := callbackEnter()
+
+	if !ok1 || d1 != 1 {
+		t.Fatalf("callbackEnter #1 = (%v, %d), want (true, 1)", ok1, d1)
+	}
+	if !ok2 || d2 != 2 {
+		t.Fatalf("callbackEnter #2 = (%v, %d), want (true, 2)", ok2, d2)
+	}
+	if ok3 {
+		t.Fatal("callbackEnter #3 succeeded past maxCallbackDepth=2")
+	}
+	if got := callbackDepth.Load(); got != 2 {
+		t.Fatalf("callbackDepth = %d after a rejected enter, want 2 (rolled back)", got)
+	}
+
+	callbackExit()
+	callbackExit()
+	if got := callbackDepth.Load(); got != 0 {
+		t.Fatalf("callbackDepth = %d after balancing both successful enters, want 0", got)
+	}
+}
+
+// TestCallbackCallDepthExceeded drives callbackCall itself through
+// depth exhaustion: with maxCallbackDepth already reached, fn must never
+// run, frame.ret0 must carry the configured fallback, and the diagnostic
+// must be the one reraiseCallbackPanic later re-panics with.
+func TestCallbackCallDepthExceeded(t *testing.T) {
+	withMaxCallbackDepth(t, 1)
+	callbackDepth.Store(1) // simulate one invocation already in progress
+
+	called := false
+	spec := MakeSpec(0, &callbackArgs{})
+	ctx := &callbackContext{
+		spec: spec,
+		size: unsafe.Sizeof(callbackArgs{}),
+		fn: func(args unsafe.Pointer) {
+			called = true
+		},
+		panicMode: &CallbackPanicMode{Return: 42},
+	}
+	slot := registerCallbackCtx(t, ctx)
+
+	frame := &callbackFrame{}
+	callbackCall(slot, frame)
+
+	if called {
+		t.Fatal("fn ran despite maxCallbackDepth already being reached")
+	}
+	if frame.ret0 != 42 {
+		t.Fatalf("frame.ret0 = %d, want 42 (the configured fallback)", frame.ret0)
+	}
+
+	defer func() {
+		r := recover()
+		if _, ok := r.(*callbackDepthExceeded); !ok {
+			t.Fatalf("reraiseCallbackPanic recovered %T(%v), want *callbackDepthExceeded", r, r)
+		}
+	}()
+	reraiseCallbackPanic()
+	t.Fatal("reraiseCallbackPanic did not re-panic with the depth-exceeded diagnostic")
+}
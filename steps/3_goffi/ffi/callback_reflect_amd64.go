@@ -0,0 +1,64 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// NewCallback returns a C-callable function pointer for fn, the same
+// convention golang.org/x/sys/windows.NewCallback uses: fn's parameter
+// types are classified exactly like a MakeSpec argument struct (so e.g.
+// an int32 parameter is read from the low 32 bits of its incoming
+// register/stack slot), and fn's single result, if any, becomes the call's
+// C return value. Unlike MakeCallback, callers never see the marshaled
+// struct or its raw unsafe.Pointer - fn is invoked directly via
+// reflection with its declared argument types.
+//
+// fn must be a function taking only the types buildSpec can classify -
+// no struct/array parameters - and returning at most one value. As with
+// MakeCallback, call ReleaseCallback once the foreign code no longer
+// needs the returned pointer.
+func NewCallback(fn interface{}) uintptr {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func {
+		panic("ffi: NewCallback: fn must be a function")
+	}
+	if t.NumOut() > 1 {
+		panic("ffi: NewCallback: fn must return at most one value")
+	}
+
+	fields := make([]reflect.StructField, 0, t.NumIn()+1)
+	for i := 0; i < t.NumIn(); i++ {
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("A%d", i),
+			Type: t.In(i),
+			Tag:  `ffi:"arg"`,
+		})
+	}
+	if t.NumOut() == 1 {
+		fields = append(fields, reflect.StructField{
+			Name: "R",
+			Type: t.Out(0),
+			Tag:  `ffi:"ret"`,
+		})
+	}
+	argsType := reflect.StructOf(fields)
+
+	return MakeCallback(reflect.New(argsType).Interface(), func(argp unsafe.Pointer) {
+		args := reflect.NewAt(argsType, argp).Elem()
+
+		in := make([]reflect.Value, t.NumIn())
+		for i := range in {
+			in[i] = args.Field(i)
+		}
+
+		out := v.Call(in)
+		if t.NumOut() == 1 {
+			args.Field(t.NumIn()).Set(out[0])
+		}
+	})
+}
@@ -0,0 +1,42 @@
+//go:build cgo
+
+package ffi
+
+// This build has cgo enabled - true of every c-shared/c-archive build
+// (both buildmodes require CGO_ENABLED=1 outright) and of any ordinary
+// binary that simply happens to import a package using real cgo
+// somewhere in its dependency tree. Either way, runtime/cgo's own
+// C-implemented _cgo_init has already been linked in and is what
+// runtime.schedinit actually calls - see cgoinit_amd64.s's doc comment
+// for _cgo_init's usual role, and runtime.go's for why this file
+// doesn't declare x_cgo_init itself the way cgobootstrap_nocgo.go does.
+//
+// This package installing its own competing _cgo_init/x_cgo_init pair
+// on top of that wouldn't just be redundant, it would be a straight
+// duplicate-symbol link error - both this package and runtime/cgo define
+// the same two bare, unprefixed global names. cgoinit_amd64.s and its
+// per-arch siblings carry a matching //go:build !cgo so they simply
+// don't exist in this build, leaving the one real _cgo_init - runtime/
+// cgo's - uncontested.
+//
+// Avoiding that duplicate-symbol error is as far as this file goes.
+// Library.Open/Get/dlopen-based symbol resolution don't depend on which
+// bootstrap hook installed _cgo_init and keep working either way, but
+// Spec.Call's own assumptions about the calling m's g0/stack state were
+// written against this package's own fakecgo bootstrap, not against
+// whatever runtime/cgo's real x_cgo_init sets up instead - mixing in a
+// real cgo runtime and expecting Call itself to behave identically is
+// the harder coexistence problem, not this file's - see coexist.go.
+
+// bootstrapped reports whether this build's cgo_init hook is this
+// package's own - see CgoStatus.Bootstrapped (probe.go) and _Cgo_iscgo
+// (runtime.go). It never is here: this build tag exists specifically to
+// leave _cgo_init to whatever real runtime/cgo supplies it, so the
+// honest answer is whatever _Cgo_iscgo says about whether that actually
+// happened - a `cgo`-tagged binary that imports no package using real
+// cgo itself (this package's own test binary, for instance) has no
+// _cgo_init at all, ours or cgo's, and this correctly reports false for
+// that case rather than claiming a bootstrap that never ran.
+func bootstrapped() bool {
+	return _Cgo_iscgo
+}
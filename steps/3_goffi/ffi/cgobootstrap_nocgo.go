@@ -0,0 +1,29 @@
+//go:build !cgo
+
+package ffi
+
+import _ "unsafe" // for go:linkname
+
+// x_cgo_init is the fakecgo pre-main bootstrap hook - see _cgo_init's
+// own doc comment in cgoinit_amd64.s (and its per-arch siblings) for how
+// it actually gets installed. It has no Go body: in an ordinary
+// CGO_ENABLED=1 build this symbol is runtime/cgo's own C-implemented
+// one, pulled in by the linkname below; here, with cgo off, nothing but
+// this package itself ever defines it.
+//
+//go:linkname x_cgo_init x_cgo_init
+func x_cgo_init()
+
+func init() {
+	if _Cgo_always_false {
+		x_cgo_init() // prevent x_cgo_init from being optimized out
+	}
+}
+
+// bootstrapped reports whether this build's cgo_init hook is this
+// package's own - see CgoStatus.Bootstrapped (probe.go). With cgo off,
+// cgoinit_amd64.s and its per-arch siblings are the only thing that can
+// possibly have set _cgo_init, so the answer is unconditionally yes.
+func bootstrapped() bool {
+	return true
+}
@@ -0,0 +1,196 @@
+package cgoref
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// buildTool runs "go build" against pkgDir with CGO_ENABLED=1 (refgen
+// needs cgo) and returns the resulting binary's path. It skips the test
+// rather than failing it when no C compiler is available: this harness
+// exists to check nocgo against a real C ABI, not to assert one is
+// present in every environment that runs `go test ./...`.
+func buildTool(t *testing.T, pkgDir, name string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath(envOr("CC", "cc")); err != nil {
+		t.Skipf("cgoref: no C compiler available: %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), name)
+	cmd := exec.Command("go", "build", "-o", out, pkgDir)
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("cgoref: building %s: %v\n%s", pkgDir, err, output)
+	}
+	return out
+}
+
+// buildShim compiles refgen's shim.c into a standalone shared library
+// and returns its path, so the test below can load it through nocgo/ffi
+// the same way any other C library is loaded - by path, through
+// Library.Open - rather than through cgo.
+func buildShim(t *testing.T) string {
+	t.Helper()
+
+	ext := ".so"
+	if runtime.GOOS == "darwin" {
+		ext = ".dylib"
+	}
+	out := filepath.Join(t.TempDir(), "libshim"+ext)
+
+	cmd := exec.Command(envOr("CC", "cc"), "-shared", "-fPIC", "-O2", "-o", out, filepath.Join("refgen", "shim.c"))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("cgoref: building shim shared library: %v\n%s", err, output)
+	}
+	return out
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// cgoRef runs the refgen binary for one case and returns its single
+// line of stdout, trimmed - the cgo-computed reference value
+// TestConformance checks nocgo/ffi's own result against.
+func cgoRef(t *testing.T, refgenBin string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command(refgenBin, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("cgoref: running refgen %v: %v", args, err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+type addI32Args struct {
+	A int32 `ffi:"arg"`
+	B int32 `ffi:"arg"`
+	R int32 `ffi:"ret"`
+}
+
+type addF64Args struct {
+	A float64 `ffi:"arg"`
+	B float64 `ffi:"arg"`
+	R float64 `ffi:"ret"`
+}
+
+type addF32Args struct {
+	A float32 `ffi:"arg"`
+	B float32 `ffi:"arg"`
+	R float32 `ffi:"ret"`
+}
+
+type mixedArgs struct {
+	A int32   `ffi:"arg"`
+	B float64 `ffi:"arg"`
+	R float64 `ffi:"ret"`
+}
+
+type sum6Args struct {
+	A, B, C, D, E, F int32 `ffi:"arg"`
+	R                int64 `ffi:"ret"`
+}
+
+// TestConformance cross-checks nocgo/ffi's result for each of shim.h's
+// signatures against cgo's own call to the identical C source (run out
+// of process by refgen - see its doc comment for why), for every type
+// combination the shim exercises: all-integer, all-float, a mixed
+// integer/SSE pair, and six integer arguments, enough to fill every SysV
+// AMD64 integer argument register.
+func TestConformance(t *testing.T) {
+	refgenBin := buildTool(t, "./refgen", "refgen")
+	shimPath := buildShim(t)
+
+	lib, err := ffi.Open(shimPath)
+	if err != nil {
+		t.Fatalf("ffi.Open: %v", err)
+	}
+
+	t.Run("add_i32", func(t *testing.T) {
+		fn, err := lib.Get("conf_add_i32")
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := addI32Args{A: 7, B: 35}
+		spec := ffi.MakeSpec(fn, &args)
+		spec.Call(unsafe.Pointer(&args))
+
+		want := cgoRef(t, refgenBin, "add_i32", "7", "35")
+		if got := strconv.FormatInt(int64(args.R), 10); got != want {
+			t.Fatalf("conf_add_i32: nocgo=%s cgo=%s", got, want)
+		}
+	})
+
+	t.Run("add_f64", func(t *testing.T) {
+		fn, err := lib.Get("conf_add_f64")
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := addF64Args{A: 1.5, B: 2.25}
+		spec := ffi.MakeSpec(fn, &args)
+		spec.Call(unsafe.Pointer(&args))
+
+		want := cgoRef(t, refgenBin, "add_f64", "1.5", "2.25")
+		if got := strconv.FormatFloat(args.R, 'g', -1, 64); got != want {
+			t.Fatalf("conf_add_f64: nocgo=%s cgo=%s", got, want)
+		}
+	})
+
+	t.Run("add_f32", func(t *testing.T) {
+		fn, err := lib.Get("conf_add_f32")
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := addF32Args{A: 1.5, B: 2.25}
+		spec := ffi.MakeSpec(fn, &args)
+		spec.Call(unsafe.Pointer(&args))
+
+		want := cgoRef(t, refgenBin, "add_f32", "1.5", "2.25")
+		if got := strconv.FormatFloat(float64(args.R), 'g', -1, 32); got != want {
+			t.Fatalf("conf_add_f32: nocgo=%s cgo=%s", got, want)
+		}
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		fn, err := lib.Get("conf_mixed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := mixedArgs{A: 3, B: 4.5}
+		spec := ffi.MakeSpec(fn, &args)
+		spec.Call(unsafe.Pointer(&args))
+
+		want := cgoRef(t, refgenBin, "mixed", "3", "4.5")
+		if got := strconv.FormatFloat(args.R, 'g', -1, 64); got != want {
+			t.Fatalf("conf_mixed: nocgo=%s cgo=%s", got, want)
+		}
+	})
+
+	t.Run("sum6", func(t *testing.T) {
+		fn, err := lib.Get("conf_sum6")
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := sum6Args{A: 1, B: 2, C: 3, D: 4, E: 5, F: 6}
+		spec := ffi.MakeSpec(fn, &args)
+		spec.Call(unsafe.Pointer(&args))
+
+		want := cgoRef(t, refgenBin, "sum6", "1", "2", "3", "4", "5", "6")
+		if got := strconv.FormatInt(args.R, 10); got != want {
+			t.Fatalf("conf_sum6: nocgo=%s cgo=%s", got, want)
+		}
+	})
+}
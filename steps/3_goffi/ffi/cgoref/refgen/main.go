@@ -0,0 +1,72 @@
+// Command refgen computes shim.h's C functions via cgo, one call per
+// invocation, and prints the result to stdout - the cgo side of
+// TestConformance's comparison. It's a standalone binary rather than a
+// package the test imports directly because this module's ffi-calling
+// test code and cgo can never link into the same binary (see
+// ../../runtime.go's x_cgo_init trick, which collides with cgo's own
+// runtime support once both are present): refgen runs as a subprocess
+// instead, and the test only ever reads its stdout.
+package main
+
+/*
+#include "shim.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "refgen: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+func parseInt32(s string) int32 {
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		fail("parsing %q as int32: %v", s, err)
+	}
+	return int32(v)
+}
+
+func parseFloat(s string, bits int) float64 {
+	v, err := strconv.ParseFloat(s, bits)
+	if err != nil {
+		fail("parsing %q as float%d: %v", s, bits, err)
+	}
+	return v
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fail("usage: refgen <case> <args...>")
+	}
+
+	switch os.Args[1] {
+	case "add_i32":
+		a, b := parseInt32(os.Args[2]), parseInt32(os.Args[3])
+		fmt.Println(int32(C.conf_add_i32(C.int32_t(a), C.int32_t(b))))
+	case "add_f64":
+		a, b := parseFloat(os.Args[2], 64), parseFloat(os.Args[3], 64)
+		fmt.Println(float64(C.conf_add_f64(C.double(a), C.double(b))))
+	case "add_f32":
+		a, b := float32(parseFloat(os.Args[2], 32)), float32(parseFloat(os.Args[3], 32))
+		fmt.Println(float32(C.conf_add_f32(C.float(a), C.float(b))))
+	case "mixed":
+		a, b := parseInt32(os.Args[2]), parseFloat(os.Args[3], 64)
+		fmt.Println(float64(C.conf_mixed(C.int32_t(a), C.double(b))))
+	case "sum6":
+		var v [6]int32
+		for i := range v {
+			v[i] = parseInt32(os.Args[2+i])
+		}
+		fmt.Println(int64(C.conf_sum6(
+			C.int32_t(v[0]), C.int32_t(v[1]), C.int32_t(v[2]),
+			C.int32_t(v[3]), C.int32_t(v[4]), C.int32_t(v[5]))))
+	default:
+		fail("unknown case %q", os.Args[1])
+	}
+}
@@ -0,0 +1,34 @@
+//go:build !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import _ "unsafe" // for go:linkname
+
+// _Cgo_always_false and _Cgo_use are ordinarily just two more runtime
+// internals reused by go:linkname the same way asmcgocall and friends
+// are (runtime.go) - except that these two specific names aren't unique
+// to runtime.go's own use of them. cmd/cgo itself generates a package-
+// scope _cgo_gotypes.go for any package that imports "C", and that
+// generated file defines its own _Cgo_always_false var and _Cgo_use
+// func under these exact names, for cgo's own pointer-keep-alive
+// bookkeeping - the same mechanism this package's prepareCStrings/
+// prepareWStrings (ffi.go) and friends piggyback on by linking to
+// runtime.cgoAlwaysFalse/runtime.cgoUse directly instead.
+//
+// That's a problem the moment both exist in the same package: declaring
+// them here too, unconditionally, would collide with cgo's own
+// compiler-generated declarations under the nocgo_fallback_cgo build
+// (ffi_cgofallback.go), which does import "C". This file's build tag is
+// the mirror image of that one's, so exactly one definition of each name
+// is ever in scope - this hand-written one when cgo is off, cgo's own
+// generated one (functionally identical: a bool that's never true, and a
+// function that keeps its argument alive across a call) when it's on.
+// Every other generic file's plain call to _Cgo_use needs nothing
+// build-tag-specific of its own either way, since both definitions share
+// the same name and signature.
+
+//go:linkname _Cgo_always_false runtime.cgoAlwaysFalse
+var _Cgo_always_false bool
+
+//go:linkname _Cgo_use runtime.cgoUse
+func _Cgo_use(interface{})
@@ -0,0 +1,62 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCallChaosWritesChosenValueAndErrno exercises Call's Chaos branch
+// with fn left at its zero value, the same as TestCallStubSkipsFnAndReturnsErrno
+// does for Stub, confirming Call never dereferences it and that the
+// injector's writes through args and its return value both take effect.
+func TestCallChaosWritesChosenValueAndErrno(t *testing.T) {
+	args := benchArgs{A: 1, B: 2}
+
+	spec := MakeSpec(0, &args)
+	spec.Chaos(func(a unsafe.Pointer) int32 {
+		(*benchArgs)(a).A = 99
+		return 5
+	})
+
+	if got := spec.Call(unsafe.Pointer(&args)); got != 5 {
+		t.Errorf("Call: got errno %d, want 5", got)
+	}
+	if args.A != 99 {
+		t.Errorf("args.A = %d, want 99 (chaos injector's chosen value)", args.A)
+	}
+	if args.B != 2 {
+		t.Errorf("args.B changed by the chaos injector: got %d, want 2", args.B)
+	}
+}
+
+// TestCallChaosTakesPriorityOverFn confirms a Spec with both a real fn
+// and a Chaos injector still runs the injector instead.
+func TestCallChaosTakesPriorityOverFn(t *testing.T) {
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 1, B: 2}
+
+	spec := MakeSpec(fn, &args)
+	spec.Chaos(func(unsafe.Pointer) int32 { return 7 })
+
+	if got := spec.Call(unsafe.Pointer(&args)); got != 7 {
+		t.Errorf("Call: got %d, want 7", got)
+	}
+	if args.A != 1 || args.B != 2 {
+		t.Errorf("args changed by a real fn despite Chaos being set: got %+v", args)
+	}
+}
+
+// TestCallNoChaosCallsFn confirms a Spec built the ordinary way, with
+// Chaos never called, still calls fn as usual.
+func TestCallNoChaosCallsFn(t *testing.T) {
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 5, B: 6}
+
+	spec := MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.A != 5 || args.B != 6 {
+		t.Errorf("args changed by an unchaosed call: got %+v", args)
+	}
+}
@@ -0,0 +1,61 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func calleeSavedOkTarget()
+func calleeSavedCorruptTarget()
+func addrOfCalleeSavedOkTarget() uintptr
+func addrOfCalleeSavedCorruptTarget() uintptr
+
+// TestCallCheckCalleeSavedOk confirms a well-behaved callee never bumps
+// CalleeSavedCorruptCount while CheckCalleeSaved is enabled.
+func TestCallCheckCalleeSavedOk(t *testing.T) {
+	before := CalleeSavedCorruptCount()
+
+	spec := MakeSpec(addrOfCalleeSavedOkTarget(), &noArgs{})
+	spec.CheckCalleeSaved(true)
+	var args noArgs
+	spec.Call(unsafe.Pointer(&args))
+
+	if after := CalleeSavedCorruptCount(); after != before {
+		t.Fatalf("CalleeSavedCorruptCount: got %d, want unchanged from %d", after, before)
+	}
+}
+
+// TestCallCheckCalleeSavedDetectsCorruption confirms a callee that
+// clobbers R13 - calleeSavedCorruptTarget, checkcalleesaved_amd64_test.s -
+// bumps CalleeSavedCorruptCount exactly once while CheckCalleeSaved is
+// enabled.
+func TestCallCheckCalleeSavedDetectsCorruption(t *testing.T) {
+	before := CalleeSavedCorruptCount()
+
+	spec := MakeSpec(addrOfCalleeSavedCorruptTarget(), &noArgs{})
+	spec.CheckCalleeSaved(true)
+	var args noArgs
+	spec.Call(unsafe.Pointer(&args))
+
+	if after := CalleeSavedCorruptCount(); after != before+1 {
+		t.Fatalf("CalleeSavedCorruptCount: got %d, want %d", after, before+1)
+	}
+}
+
+// TestCallCheckCalleeSavedDisabledByDefault confirms a corrupting callee
+// does not bump CalleeSavedCorruptCount when CheckCalleeSaved was never
+// enabled - the off-by-default cost this package promises callers who
+// don't ask for the check.
+func TestCallCheckCalleeSavedDisabledByDefault(t *testing.T) {
+	before := CalleeSavedCorruptCount()
+
+	spec := MakeSpec(addrOfCalleeSavedCorruptTarget(), &noArgs{})
+	var args noArgs
+	spec.Call(unsafe.Pointer(&args))
+
+	if after := CalleeSavedCorruptCount(); after != before {
+		t.Fatalf("CalleeSavedCorruptCount: got %d, want unchanged from %d", after, before)
+	}
+}
@@ -0,0 +1,129 @@
+package ffi
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Type is a libffi-style value descriptor: it names one CIF argument or
+// return value's shape by kind alone, the way ffi_type does, instead of
+// by a Go struct field the way every other entry point in this package
+// (MakeSpec, Bind, MakeFunc, NewCallback) does. It only covers scalar
+// types - there's no equivalent here yet to a composite ffi_type built
+// from nested elements, so a CIF can't describe a struct or array
+// argument the way a tagged Go struct field already can via MakeSpec.
+type Type int
+
+const (
+	TypeVoid Type = iota
+	TypeInt8
+	TypeUint8
+	TypeInt16
+	TypeUint16
+	TypeInt32
+	TypeUint32
+	TypeInt64
+	TypeUint64
+	TypeFloat32
+	TypeFloat64
+	TypePointer
+)
+
+// goType maps t to the reflect.Type PrepCif's reflect.StructOf call
+// needs - the same Go kinds fieldToOffset already classifies, just
+// picked by an explicit Type instead of a Go struct field's own Kind().
+func (t Type) goType() reflect.Type {
+	switch t {
+	case TypeInt8:
+		return reflect.TypeOf(int8(0))
+	case TypeUint8:
+		return reflect.TypeOf(uint8(0))
+	case TypeInt16:
+		return reflect.TypeOf(int16(0))
+	case TypeUint16:
+		return reflect.TypeOf(uint16(0))
+	case TypeInt32:
+		return reflect.TypeOf(int32(0))
+	case TypeUint32:
+		return reflect.TypeOf(uint32(0))
+	case TypeInt64:
+		return reflect.TypeOf(int64(0))
+	case TypeUint64:
+		return reflect.TypeOf(uint64(0))
+	case TypeFloat32:
+		return reflect.TypeOf(float32(0))
+	case TypeFloat64:
+		return reflect.TypeOf(float64(0))
+	case TypePointer:
+		return reflect.TypeOf(unsafe.Pointer(nil))
+	}
+	panic(fmt.Sprintf("ffi: Type(%d) has no equivalent Go type", t))
+}
+
+// Cif is a prepared call interface: libffi's ffi_prep_cif/ffi_call
+// collapsed onto this package's own MakeSpec/Call. PrepCif classifies
+// rtype/atypes once, the same way MakeFunc and NewCallback classify a
+// signature that isn't a Go struct by building one via reflect.StructOf
+// behind the caller's back; Call then drives it any number of times
+// given raw value pointers instead of a typed Go struct.
+type Cif struct {
+	spec     Spec
+	argsType reflect.Type
+	nargs    int
+	hasRet   bool
+}
+
+// PrepCif is ffi_prep_cif: fn is the C function's address, rtype is its
+// return type (TypeVoid for none), and atypes is its parameter types in
+// order.
+func PrepCif(fn uintptr, rtype Type, atypes ...Type) Cif {
+	fields := make([]reflect.StructField, 0, len(atypes)+1)
+	for i, t := range atypes {
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("A%d", i),
+			Type: t.goType(),
+			Tag:  `ffi:"arg"`,
+		})
+	}
+	hasRet := rtype != TypeVoid
+	if hasRet {
+		fields = append(fields, reflect.StructField{
+			Name: "R",
+			Type: rtype.goType(),
+			Tag:  `ffi:"ret"`,
+		})
+	}
+	argsType := reflect.StructOf(fields)
+	return Cif{
+		spec:     MakeSpec(fn, reflect.New(argsType).Interface()),
+		argsType: argsType,
+		nargs:    len(atypes),
+		hasRet:   hasRet,
+	}
+}
+
+// Call is ffi_call: avalues[i] must point at a value of the Go type
+// atypes[i] mapped to when cif was built (see Type.goType) - e.g.
+// TypeInt32 wants a *int32 - and rvalue, if cif was built with a
+// non-void rtype, must point at storage for it; a nil rvalue discards
+// the result.
+func (cif Cif) Call(avalues []unsafe.Pointer, rvalue unsafe.Pointer) {
+	if len(avalues) != cif.nargs {
+		panic("ffi: Cif.Call: got " + fmt.Sprint(len(avalues)) + " argument values, want " + fmt.Sprint(cif.nargs))
+	}
+
+	argsPtr := reflect.New(cif.argsType)
+	args := argsPtr.Elem()
+	for i, p := range avalues {
+		dst := args.Field(i)
+		dst.Set(reflect.NewAt(dst.Type(), p).Elem())
+	}
+
+	cif.spec.Call(unsafe.Pointer(argsPtr.Pointer()))
+
+	if cif.hasRet && rvalue != nil {
+		dst := args.Field(cif.nargs)
+		reflect.NewAt(dst.Type(), rvalue).Elem().Set(dst)
+	}
+}
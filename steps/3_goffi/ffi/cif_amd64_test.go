@@ -0,0 +1,37 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCifCall drives addPairFlatTarget (func_amd64_test.s) through
+// PrepCif/Cif.Call instead of a hand-written Args struct, confirming the
+// value-descriptor classification in cif.go reaches the real asmcall
+// trampoline the same way Func[Args].Call does for funcAddArgs.
+func TestCifCall(t *testing.T) {
+	cif := PrepCif(addrOfAddPairFlatTarget(), TypeInt64, TypeInt64, TypeInt64)
+
+	a, b := int64(3), int64(4)
+	var r int64
+	cif.Call([]unsafe.Pointer{unsafe.Pointer(&a), unsafe.Pointer(&b)}, unsafe.Pointer(&r))
+
+	if r != 7 {
+		t.Fatalf("Cif.Call: got r=%d, want 7", r)
+	}
+}
+
+// TestCifCallWrongArgCount confirms Cif.Call rejects a mismatched
+// avalues length rather than silently reading past it.
+func TestCifCallWrongArgCount(t *testing.T) {
+	cif := PrepCif(addrOfAddPairFlatTarget(), TypeInt64, TypeInt64, TypeInt64)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Cif.Call: expected a panic for the wrong avalues length")
+		}
+	}()
+	cif.Call(nil, nil)
+}
@@ -0,0 +1,43 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Closure is a callback created by NewClosure: libffi's ffi_closure, built
+// from a Cif instead of a raw Go struct fnType the way MakeCallback is.
+type Closure struct {
+	ptr uintptr
+}
+
+// NewClosure is libffi's ffi_prep_closure_loc collapsed onto this package's
+// existing MakeCallback: it returns a C-callable function pointer matching
+// cif's signature that, when invoked, marshals its arguments into the same
+// struct layout Cif.Call itself would fill in and calls fn with a pointer to
+// it. fn reads argument field A0..An and, if cif was built with a non-void
+// rtype, writes field R - the same fields Cif.Call's avalues/rvalue read and
+// write, just reached directly through argp instead.
+//
+// NewClosure exists for the C APIs that take a bare function pointer with no
+// userdata parameter to stash a context in - the function pointer itself has
+// to carry the context, which is exactly what a distinct MakeCallback slot
+// already does. ReleaseClosure frees cl's slot once the foreign code no
+// longer needs it; cl.Pointer() must not be invoked again afterwards.
+func NewClosure(cif Cif, fn func(args unsafe.Pointer)) Closure {
+	return Closure{ptr: MakeCallback(reflect.New(cif.argsType).Interface(), fn)}
+}
+
+// Pointer returns cl's C-callable function pointer, suitable for passing to
+// any foreign API expecting one matching the Cif NewClosure built cl from.
+func (cl Closure) Pointer() uintptr {
+	return cl.ptr
+}
+
+// ReleaseClosure frees the trampoline slot backing cl, which must have been
+// returned by NewClosure.
+func ReleaseClosure(cl Closure) {
+	ReleaseCallback(cl.ptr)
+}
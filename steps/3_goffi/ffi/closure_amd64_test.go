@@ -0,0 +1,51 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// closureSlotFor returns the callbackSlots index backing cl, for driving
+// callbackCall directly the way TestCallbackCall does - see that test for
+// why the real callbackasm trampoline isn't exercised here.
+func closureSlotFor(t *testing.T, cl Closure) int32 {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+	for slot, ctx := range callbackSlots {
+		if ctx != nil && ctx.trampoline == cl.ptr {
+			return int32(slot)
+		}
+	}
+	t.Fatalf("closureSlotFor: no live slot for closure %v", cl.ptr)
+	return -1
+}
+
+// TestNewClosure confirms a Closure built from a Cif classifies its
+// incoming frame the same way Cif.Call's own argsType would, by calling
+// callbackCall directly with a hand-built frame exactly as callbackasm
+// would pass one.
+func TestNewClosure(t *testing.T) {
+	cif := PrepCif(0, TypeInt64, TypeInt64, TypeInt64)
+
+	var gotA, gotB int64
+	cl := NewClosure(cif, func(args unsafe.Pointer) {
+		a := (*struct {
+			A0, A1, R int64
+		})(args)
+		gotA, gotB = a.A0, a.A1
+		a.R = a.A0 + a.A1
+	})
+	defer ReleaseClosure(cl)
+
+	frame := &callbackFrame{intregs: [6]uint64{3, 4}}
+	callbackCall(closureSlotFor(t, cl), frame)
+
+	if gotA != 3 || gotB != 4 {
+		t.Fatalf("closure args: got A0=%d A1=%d, want 3 and 4", gotA, gotB)
+	}
+	if frame.ret0 != 7 {
+		t.Fatalf("closure ret0: got %d, want 7", frame.ret0)
+	}
+}
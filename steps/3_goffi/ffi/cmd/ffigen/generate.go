@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+)
+
+// generate renders protos and enums as a Go source file in package pkg:
+// one args struct per prototype, classified the way a hand-written one
+// already is in this package (an `ffi:"arg"` field per parameter, in
+// order, plus an `ffi:"ret"` field unless the C function returns void),
+// and a NewXxxSpec constructor that wraps ffi.MakeSpec around it; plus
+// one named integer type and const block per enum (see generateEnum).
+func generate(pkg string, protos []proto, enums []enumDecl) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated by ffigen; DO NOT EDIT.")
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintln(&b, `import (`)
+	if usesUnsafePointer(protos) {
+		fmt.Fprintln(&b, `	"unsafe"`)
+		fmt.Fprintln(&b)
+	}
+	fmt.Fprintln(&b, `	"mewbak/nocgo/ffi"`)
+	fmt.Fprintln(&b, `)`)
+
+	for _, e := range enums {
+		if err := generateEnum(&b, e); err != nil {
+			return nil, fmt.Errorf("ffigen: enum %s: %w", e.name, err)
+		}
+	}
+
+	for _, p := range protos {
+		if err := generateOne(&b, p); err != nil {
+			return nil, fmt.Errorf("ffigen: %s: %w", p.name, err)
+		}
+	}
+
+	return format.Source(b.Bytes())
+}
+
+// generateEnum emits e as a Go named integer type plus a const block, one
+// entry per enumerator. A field of this type needs no special handling
+// from MakeSpec: fieldToOffset (ffi.go) classifies a fixed argument by
+// its Go Kind() alone, and a defined type over int8/int16/int32/int64 or
+// their unsigned counterparts already has the same Kind() as the plain
+// type it's defined over - the same reason ctypes.CLong/CSizeT need no
+// case of their own there either.
+func generateEnum(b *bytes.Buffer, e enumDecl) error {
+	goType, _, err := cTypeToGo(e.underlying)
+	if err != nil {
+		return fmt.Errorf("underlying type: %w", err)
+	}
+	switch goType {
+	case "int8", "uint8", "int16", "uint16", "int32", "uint32", "int64", "uint64":
+	default:
+		return fmt.Errorf("underlying type %q is not an integer type", e.underlying)
+	}
+
+	exported := exportName(e.name)
+	fmt.Fprintf(b, "\n// %s is the Go representation of C's \"enum %s\", stored as a %s.\n", exported, e.name, goType)
+	fmt.Fprintf(b, "type %s %s\n", exported, goType)
+
+	if len(e.members) == 0 {
+		return nil
+	}
+	fmt.Fprintln(b, "\nconst (")
+	for _, m := range e.members {
+		fmt.Fprintf(b, "\t%s %s = %d\n", exportName(m.name), exported, m.value)
+	}
+	fmt.Fprintln(b, ")")
+	return nil
+}
+
+// usesUnsafePointer reports whether any field generate would emit for
+// protos needs the "unsafe" import (every pointer type except char*,
+// which becomes a plain Go string).
+func usesUnsafePointer(protos []proto) bool {
+	isPtr := func(t ctype) bool {
+		goType, _, err := cTypeToGo(t)
+		return err == nil && goType == "unsafe.Pointer"
+	}
+	for _, p := range protos {
+		if isPtr(p.ret) {
+			return true
+		}
+		for _, prm := range p.params {
+			if isPtr(prm.typ) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func generateOne(b *bytes.Buffer, p proto) error {
+	exported := exportName(p.name)
+	structName := exported + "Args"
+
+	fmt.Fprintf(b, "\n// %s is the MakeSpec argument struct for C's %s.\n", structName, cSignature(p))
+	fmt.Fprintf(b, "type %s struct {\n", structName)
+	for _, prm := range p.params {
+		goType, cstr, err := cTypeToGo(prm.typ)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", prm.name, err)
+		}
+		tag := `ffi:"arg"`
+		if cstr {
+			tag = `ffi:"arg,cstr"`
+		}
+		fmt.Fprintf(b, "\t%s %s `%s`\n", exportName(prm.name), goType, tag)
+	}
+	if retType, _, err := cTypeToGo(p.ret); err != nil {
+		return fmt.Errorf("return type: %w", err)
+	} else if retType != "" {
+		fmt.Fprintf(b, "\tR %s `ffi:\"ret\"`\n", retType)
+	}
+	fmt.Fprintln(b, "}")
+
+	fmt.Fprintf(b, "\n// New%sSpec builds the ffi.Spec for %s, given fn's address (e.g. from\n", exported, p.name)
+	fmt.Fprintf(b, "// a Library's Get(\"%s\")).\n", p.name)
+	fmt.Fprintf(b, "func New%sSpec(fn uintptr) ffi.Spec {\n", exported)
+	fmt.Fprintf(b, "\treturn ffi.MakeSpec(fn, &%s{})\n", structName)
+	fmt.Fprintln(b, "}")
+	return nil
+}
+
+// cSignature renders p back out as a C declaration, for the generated
+// struct's doc comment.
+func cSignature(p proto) string {
+	var params []string
+	for _, prm := range p.params {
+		params = append(params, fmt.Sprintf("%s %s", prm.typ, prm.name))
+	}
+	if len(params) == 0 {
+		params = []string{"void"}
+	}
+	return fmt.Sprintf("%s %s(%s)", p.ret, p.name, strings.Join(params, ", "))
+}
+
+// exportName turns a C identifier (snake_case, typically) into an
+// exported Go identifier: each underscore-separated word is capitalized
+// and the underscores dropped, e.g. "sqlite3_open" -> "Sqlite3Open".
+func exportName(name string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range name {
+		if r == '_' {
+			upperNext = true
+			continue
+		}
+		if upperNext {
+			b.WriteRune(unicode.ToUpper(r))
+			upperNext = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+// ffigen generates ffi argument structs and MakeSpec constructors from a
+// prototypes file: one C function declaration, or enum declaration, per
+// statement, e.g.
+//
+//	int add(int a, int b);
+//	double sqrt(double x);
+//	enum Color { RED, GREEN, BLUE };
+//	enum Status : unsigned char { OK, FAIL = 10 };
+//
+// It understands a limited subset of C - the base types fieldToOffset
+// already knows how to classify, plus char* (marshaled as a Go string via
+// ffi:"cstr") and any other pointer (unsafe.Pointer) - not arbitrary
+// headers; run it against a prototypes file extracted from one (or
+// written by hand) rather than the header itself.
+//
+// An enum declaration becomes a Go named integer type plus a const block,
+// one entry per enumerator - MakeSpec already accepts such a field with
+// no changes, since it classifies by Kind() alone (see generateEnum). Its
+// underlying storage type defaults to plain "int", C's own default, or
+// can be named explicitly with a C++11-style "enum Name : type { ... }"
+// suffix.
+//
+// Typical use, via go:generate:
+//
+//	//go:generate ffigen -in sqlite3.protos -out sqlite3_bindings.go -package sqlite3
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "", "prototypes file to read (required)")
+	out := flag.String("out", "", "Go file to write (default: stdout)")
+	pkg := flag.String("package", "main", "package name for the generated file")
+	flag.Parse()
+
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "ffigen: -in is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("ffigen: %v", err)
+	}
+	defer f.Close()
+
+	protos, enums, err := parsePrototypes(f)
+	if err != nil {
+		log.Fatalf("ffigen: %v", err)
+	}
+
+	src, err := generate(*pkg, protos, enums)
+	if err != nil {
+		log.Fatalf("ffigen: %v", err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalf("ffigen: %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// proto is one parsed C function prototype: its return type, name, and
+// parameters, in source order.
+type proto struct {
+	name   string
+	ret    ctype
+	params []param
+}
+
+// param is one parameter of a proto: its C type and, if the prototype
+// named it, its parameter name (used as the generated field's name).
+type param struct {
+	name string
+	typ  ctype
+}
+
+// ctype is a parsed C type: some number of leading pointer stars over a
+// base type built from a run of keywords (e.g. "unsigned long long").
+type ctype struct {
+	base string // normalized keyword run, e.g. "unsigned long", "char"
+	ptr  int    // number of trailing '*'
+}
+
+func (t ctype) String() string {
+	if t.ptr == 0 {
+		return t.base
+	}
+	return t.base + " " + strings.Repeat("*", t.ptr)
+}
+
+// enumDecl is one parsed "enum Name { ... };" declaration: its tag name,
+// the underlying integer type generateEnum gives the generated Go type,
+// and its enumerators in source order.
+type enumDecl struct {
+	name       string
+	underlying ctype
+	members    []enumMember
+}
+
+// enumMember is one enumerator inside an enumDecl: its name, and the
+// value generateEnum gives it - either an explicit "= N" from the source,
+// or one more than the previous enumerator's value, starting at 0, the
+// same implicit-increment rule C itself uses.
+type enumMember struct {
+	name  string
+	value int64
+}
+
+// parsePrototypes reads a prototypes file - one C function declaration or
+// enum declaration per logical statement, e.g. "int add(int a, int b);"
+// or "enum Color { RED, GREEN, BLUE };" - and returns each one parsed. A
+// statement whose first token is "enum" is parsed as an enumDecl instead
+// of a proto. It's deliberately not a C compiler: no macros, no
+// typedefs, no multi-declarator statements, and only the base types
+// typeMapping knows about. Blank lines and "//" comments are ignored; a
+// missing trailing ";" is tolerated since callers often paste
+// declarations from a header without it.
+func parsePrototypes(r io.Reader) ([]proto, []enumDecl, error) {
+	src, err := stripComments(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var protos []proto
+	var enums []enumDecl
+	for _, stmt := range splitStatements(src) {
+		trimmed := strings.TrimSpace(stmt)
+		if trimmed == "" {
+			continue
+		}
+		if toks := tokenize(stmt); len(toks) > 0 && toks[0] == "enum" {
+			e, err := parseEnum(stmt)
+			if err != nil {
+				return nil, nil, fmt.Errorf("ffigen: %w (in %q)", err, trimmed)
+			}
+			enums = append(enums, e)
+			continue
+		}
+		p, err := parseOne(stmt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ffigen: %w (in %q)", err, trimmed)
+		}
+		protos = append(protos, p)
+	}
+	return protos, enums, nil
+}
+
+// parseEnum parses a single "enum Name [: underlying] { A, B = N, C }"
+// statement. underlying defaults to plain "int", C's own default when no
+// explicit storage is named; the "enum Name : type { ... }" suffix
+// ffigen accepts for naming one explicitly is C++11 syntax, borrowed here
+// rather than inventing a bespoke annotation since it already says
+// exactly what it means.
+func parseEnum(stmt string) (enumDecl, error) {
+	toks := tokenize(stmt)
+	if len(toks) == 0 || toks[0] != "enum" {
+		return enumDecl{}, fmt.Errorf("not an enum declaration")
+	}
+	toks = toks[1:]
+	if len(toks) == 0 || !isIdent(toks[0]) {
+		return enumDecl{}, fmt.Errorf("missing enum tag name")
+	}
+	name := toks[0]
+	toks = toks[1:]
+
+	underlying := ctype{base: "int"}
+	if len(toks) > 0 && toks[0] == ":" {
+		open := indexTok(toks, "{")
+		if open < 0 {
+			return enumDecl{}, fmt.Errorf("enum %s: missing '{'", name)
+		}
+		var err error
+		underlying, err = parseType(toks[1:open])
+		if err != nil {
+			return enumDecl{}, fmt.Errorf("enum %s: underlying type: %w", name, err)
+		}
+		toks = toks[open:]
+	}
+
+	open := indexTok(toks, "{")
+	shut := lastIndexTok(toks, "}")
+	if open < 0 || shut < 0 || shut < open {
+		return enumDecl{}, fmt.Errorf("enum %s: missing '{'/'}'", name)
+	}
+
+	var members []enumMember
+	next := int64(0)
+	for _, group := range splitOn(toks[open+1:shut], ",") {
+		if len(group) == 0 {
+			continue // tolerate a trailing comma after the last enumerator
+		}
+		memberName := group[0]
+		if !isIdent(memberName) {
+			return enumDecl{}, fmt.Errorf("enum %s: invalid enumerator %q", name, memberName)
+		}
+		value := next
+		if len(group) > 1 {
+			if group[1] != "=" || len(group) < 3 {
+				return enumDecl{}, fmt.Errorf("enum %s: expected '= value' after %s", name, memberName)
+			}
+			v, err := strconv.ParseInt(strings.Join(group[2:], ""), 0, 64)
+			if err != nil {
+				return enumDecl{}, fmt.Errorf("enum %s: enumerator %s: %w", name, memberName, err)
+			}
+			value = v
+		}
+		members = append(members, enumMember{name: memberName, value: value})
+		next = value + 1
+	}
+	return enumDecl{name: name, underlying: underlying, members: members}, nil
+}
+
+// stripComments drops every "//...": end-of-line comment from r, the only
+// comment style a prototypes file needs to support.
+func stripComments(r io.Reader) (string, error) {
+	var b strings.Builder
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String(), sc.Err()
+}
+
+// splitStatements breaks src into one string per ';'-terminated
+// declaration, tolerating a final declaration with no trailing ';'.
+func splitStatements(src string) []string {
+	parts := strings.Split(src, ";")
+	return parts
+}
+
+// parseOne parses a single "<rettype> name(<params>)" statement.
+func parseOne(stmt string) (proto, error) {
+	toks := tokenize(stmt)
+	if len(toks) == 0 {
+		return proto{}, fmt.Errorf("empty declaration")
+	}
+
+	open := indexTok(toks, "(")
+	if open < 0 {
+		return proto{}, fmt.Errorf("missing '(' in function declaration")
+	}
+	close := lastIndexTok(toks, ")")
+	if close < 0 || close < open {
+		return proto{}, fmt.Errorf("missing ')' in function declaration")
+	}
+
+	head := toks[:open]
+	if len(head) < 2 {
+		return proto{}, fmt.Errorf("missing return type or function name")
+	}
+	name := head[len(head)-1]
+	ret, err := parseType(head[:len(head)-1])
+	if err != nil {
+		return proto{}, fmt.Errorf("return type: %w", err)
+	}
+
+	params, err := parseParams(toks[open+1 : close])
+	if err != nil {
+		return proto{}, err
+	}
+	return proto{name: name, ret: ret, params: params}, nil
+}
+
+// parseParams parses a comma-separated parameter list; "void" alone (a C
+// no-argument parameter list) yields zero parameters.
+func parseParams(toks []string) ([]param, error) {
+	if len(toks) == 0 || (len(toks) == 1 && toks[0] == "void") {
+		return nil, nil
+	}
+	var params []param
+	for i, group := range splitOn(toks, ",") {
+		if len(group) == 0 {
+			return nil, fmt.Errorf("empty parameter")
+		}
+		name := fmt.Sprintf("a%d", i)
+		typeToks := group
+		if last := group[len(group)-1]; isIdent(last) && !isTypeKeyword(last) {
+			name = last
+			typeToks = group[:len(group)-1]
+		}
+		typ, err := parseType(typeToks)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i, err)
+		}
+		params = append(params, param{name: name, typ: typ})
+	}
+	return params, nil
+}
+
+// parseType parses a type's tokens: any number of leading "const"/
+// "unsigned"/"signed"/base-type keywords, followed by zero or more '*'.
+func parseType(toks []string) (ctype, error) {
+	var words []string
+	i := 0
+	for ; i < len(toks) && toks[i] != "*"; i++ {
+		if toks[i] == "const" {
+			continue
+		}
+		words = append(words, toks[i])
+	}
+	starsFrom := i
+	for ; i < len(toks); i++ {
+		if toks[i] != "*" {
+			return ctype{}, fmt.Errorf("unexpected token %q in type", toks[i])
+		}
+	}
+	if len(words) == 0 {
+		return ctype{}, fmt.Errorf("missing type")
+	}
+	return ctype{base: strings.Join(words, " "), ptr: len(toks) - starsFrom}, nil
+}
+
+// splitOn splits toks into groups wherever sep appears as its own token.
+func splitOn(toks []string, sep string) [][]string {
+	var groups [][]string
+	var cur []string
+	for _, t := range toks {
+		if t == sep {
+			groups = append(groups, cur)
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	groups = append(groups, cur)
+	return groups
+}
+
+func indexTok(toks []string, s string) int {
+	for i, t := range toks {
+		if t == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastIndexTok(toks []string, s string) int {
+	for i := len(toks) - 1; i >= 0; i-- {
+		if toks[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isTypeKeyword reports whether s is a C keyword that can only appear
+// inside a type, so a trailing identifier right after it is never a
+// parameter name (e.g. the "int" in "unsigned int").
+func isTypeKeyword(s string) bool {
+	switch s {
+	case "void", "char", "short", "int", "long", "float", "double",
+		"unsigned", "signed", "const", "size_t":
+		return true
+	}
+	return false
+}
+
+// tokenize splits a declaration into identifiers and the punctuation
+// ffigen's grammar needs: '(', ')', ',', '*' for a function prototype,
+// plus '{', '}', ':' for an enum declaration (its "= value" uses the
+// already-needed ',' and a bare "=" token, handled below).
+func tokenize(s string) []string {
+	var toks []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			toks = append(toks, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == ',' || r == '*' || r == '{' || r == '}' || r == ':' || r == '=':
+			flush()
+			toks = append(toks, string(r))
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return toks
+}
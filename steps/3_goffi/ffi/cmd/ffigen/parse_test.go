@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePrototypes(t *testing.T) {
+	src := `
+		// a comment, and a blank line above
+		int add(int a, int b);
+		double sqrt(double x);
+		void log_message(const char *msg);
+		void *malloc(size_t size)
+	`
+	protos, enums, err := parsePrototypes(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parsePrototypes: %v", err)
+	}
+	if len(protos) != 4 {
+		t.Fatalf("got %d protos, want 4", len(protos))
+	}
+	if len(enums) != 0 {
+		t.Fatalf("got %d enums, want 0", len(enums))
+	}
+
+	add := protos[0]
+	if add.name != "add" || add.ret.base != "int" || add.ret.ptr != 0 {
+		t.Fatalf("add: got %+v", add)
+	}
+	if len(add.params) != 2 || add.params[0].name != "a" || add.params[1].name != "b" {
+		t.Fatalf("add params: got %+v", add.params)
+	}
+
+	logMsg := protos[2]
+	if logMsg.ret.base != "void" {
+		t.Fatalf("log_message ret: got %+v", logMsg.ret)
+	}
+	if len(logMsg.params) != 1 || logMsg.params[0].typ.base != "char" || logMsg.params[0].typ.ptr != 1 {
+		t.Fatalf("log_message params: got %+v", logMsg.params)
+	}
+
+	mallocProto := protos[3]
+	if mallocProto.ret.base != "void" || mallocProto.ret.ptr != 1 {
+		t.Fatalf("malloc ret: got %+v", mallocProto.ret)
+	}
+	if len(mallocProto.params) != 1 || mallocProto.params[0].typ.base != "size_t" {
+		t.Fatalf("malloc params: got %+v", mallocProto.params)
+	}
+}
+
+func TestCTypeToGo(t *testing.T) {
+	cases := []struct {
+		t        ctype
+		wantGo   string
+		wantCstr bool
+	}{
+		{ctype{base: "int"}, "int32", false},
+		{ctype{base: "unsigned long long"}, "uint64", false},
+		{ctype{base: "char", ptr: 1}, "string", true},
+		{ctype{base: "void", ptr: 1}, "unsafe.Pointer", false},
+		{ctype{base: "double"}, "float64", false},
+	}
+	for _, c := range cases {
+		got, cstr, err := cTypeToGo(c.t)
+		if err != nil {
+			t.Fatalf("cTypeToGo(%v): %v", c.t, err)
+		}
+		if got != c.wantGo || cstr != c.wantCstr {
+			t.Fatalf("cTypeToGo(%v): got (%q, %v), want (%q, %v)", c.t, got, cstr, c.wantGo, c.wantCstr)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	protos, enums, err := parsePrototypes(strings.NewReader("int add(int a, int b);"))
+	if err != nil {
+		t.Fatalf("parsePrototypes: %v", err)
+	}
+	src, err := generate("mylib", protos, enums)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	out := string(src)
+	for _, want := range []string{
+		"package mylib",
+		"type AddArgs struct",
+		`A int32 ` + "`ffi:\"arg\"`",
+		`B int32 ` + "`ffi:\"arg\"`",
+		`R int32 ` + "`ffi:\"ret\"`",
+		"func NewAddSpec(fn uintptr) ffi.Spec",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("generate output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	src := `
+		enum Color { RED, GREEN, BLUE };
+		enum Status : unsigned char { OK = 0, FAIL = 10, FAIL2 };
+	`
+	_, enums, err := parsePrototypes(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parsePrototypes: %v", err)
+	}
+	if len(enums) != 2 {
+		t.Fatalf("got %d enums, want 2", len(enums))
+	}
+
+	color := enums[0]
+	if color.name != "Color" || color.underlying.base != "int" {
+		t.Fatalf("Color: got %+v", color)
+	}
+	wantColor := []enumMember{{"RED", 0}, {"GREEN", 1}, {"BLUE", 2}}
+	if len(color.members) != len(wantColor) {
+		t.Fatalf("Color members: got %+v", color.members)
+	}
+	for i, m := range wantColor {
+		if color.members[i] != m {
+			t.Fatalf("Color member %d: got %+v, want %+v", i, color.members[i], m)
+		}
+	}
+
+	status := enums[1]
+	if status.name != "Status" || status.underlying.base != "unsigned char" {
+		t.Fatalf("Status: got %+v", status)
+	}
+	wantStatus := []enumMember{{"OK", 0}, {"FAIL", 10}, {"FAIL2", 11}}
+	if len(status.members) != len(wantStatus) {
+		t.Fatalf("Status members: got %+v", status.members)
+	}
+	for i, m := range wantStatus {
+		if status.members[i] != m {
+			t.Fatalf("Status member %d: got %+v, want %+v", i, status.members[i], m)
+		}
+	}
+}
+
+func TestGenerateEnum(t *testing.T) {
+	src := `enum Color { RED, GREEN, BLUE = 5 };`
+	protos, enums, err := parsePrototypes(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parsePrototypes: %v", err)
+	}
+	src2, err := generate("mylib", protos, enums)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	out := string(src2)
+	for _, want := range []string{
+		"type Color int32",
+		"RED   Color = 0",
+		"GREEN Color = 1",
+		"BLUE  Color = 5",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("generate output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// baseTypes maps every C base type ffigen understands to its natural Go
+// equivalent - chosen, like the ffi package's own fieldToOffset, so the
+// Go Kind() alone already selects the right argument width; none of
+// these need a `type=` override.
+var baseTypes = map[string]string{
+	"void":               "",
+	"char":               "int8",
+	"signed char":        "int8",
+	"unsigned char":      "uint8",
+	"short":              "int16",
+	"short int":          "int16",
+	"unsigned short":     "uint16",
+	"unsigned short int": "uint16",
+	"int":                "int32",
+	"signed int":         "int32",
+	"unsigned":           "uint32",
+	"unsigned int":       "uint32",
+	"long":               "int64",
+	"long int":           "int64",
+	"unsigned long":      "uint64",
+	"unsigned long int":  "uint64",
+	"long long":          "int64",
+	"long long int":      "int64",
+	"unsigned long long": "uint64",
+	"size_t":             "uint64",
+	"float":              "float32",
+	"double":             "float64",
+}
+
+// cTypeToGo maps a parsed ctype to the Go field it should become.
+// "char *"/"const char *" becomes a Go string tagged ffi:"cstr" (see
+// cstr bool below) so MakeSpec marshals it as a NUL-terminated C string;
+// every other pointer becomes unsafe.Pointer.
+func cTypeToGo(t ctype) (typ string, cstr bool, err error) {
+	if t.ptr > 0 {
+		if t.ptr == 1 && t.base == "char" {
+			return "string", true, nil
+		}
+		return "unsafe.Pointer", false, nil
+	}
+	if t.base == "void" {
+		return "", false, nil
+	}
+	g, ok := baseTypes[t.base]
+	if !ok {
+		return "", false, fmt.Errorf("unsupported C type %q", t.base)
+	}
+	return g, false, nil
+}
@@ -0,0 +1,159 @@
+// Package cmem provides canonical C heap allocation helpers - Malloc,
+// Free, Calloc and Realloc - built on top of package ffi the same way any
+// other caller would bind a C library: by resolving malloc/free/calloc/
+// realloc's addresses through ffi.Open/Library.Get and calling them
+// through ffi.MakeSpec/Spec.Call.
+//
+// A C API that takes ownership of a pointer argument, or that returns a
+// pointer the caller must later free, needs memory that isn't on Go's
+// GC heap: Go's allocator can move or free an object the C side still
+// holds a reference to, and cgo-free code has no way to pin it forever
+// the way a cgo build's C.malloc call naturally avoids the question
+// altogether. cmem exists to give that memory a home - ffi:"cstr" and
+// ffi:"pin" (see package ffi's own doc comments) solve the narrower,
+// shorter-lived "pass a Go value into one call" version of this problem;
+// cmem is for the "the C side keeps this past the call that made it"
+// case CString/FreeCString (ffi's own cstring.go) already cover for
+// strings specifically.
+//
+// EnableLeakTracking (leak.go) turns on optional bookkeeping of every
+// live allocation's call site, for a debug build that wants to catch a
+// missing Free the way a C allocator's leak-check mode would; see its
+// own doc comment and ReportLeaks/CheckLeaksAtExit.
+package cmem
+
+import (
+	"fmt"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// mallocFn, freeFn, callocFn and reallocFn are malloc/free/calloc/
+// realloc's addresses, resolved once by init below. initErr holds
+// whatever error resolving them failed with, if any - every exported
+// function here returns it unchanged rather than failing some other way.
+var (
+	mallocFn, freeFn, callocFn, reallocFn uintptr
+	initErr                               error
+)
+
+func init() {
+	lib, err := ffi.Open(libcPath)
+	if err != nil {
+		initErr = err
+		return
+	}
+	for name, fn := range map[string]*uintptr{
+		"malloc":  &mallocFn,
+		"free":    &freeFn,
+		"calloc":  &callocFn,
+		"realloc": &reallocFn,
+	} {
+		addr, err := lib.Get(name)
+		if err != nil {
+			initErr = err
+			return
+		}
+		*fn = addr
+	}
+}
+
+type mallocArgs struct {
+	Size uintptr `ffi:"arg"`
+	R    uintptr `ffi:"ret"`
+}
+
+type freeArgs struct {
+	Ptr uintptr `ffi:"arg"`
+}
+
+type callocArgs struct {
+	Count uintptr `ffi:"arg"`
+	Size  uintptr `ffi:"arg"`
+	R     uintptr `ffi:"ret"`
+}
+
+type reallocArgs struct {
+	Ptr  uintptr `ffi:"arg"`
+	Size uintptr `ffi:"arg"`
+	R    uintptr `ffi:"ret"`
+}
+
+// Malloc allocates n uninitialized bytes via libc malloc and returns
+// their address. The caller owns the result and must release it with
+// Free once done.
+func Malloc(n uintptr) (uintptr, error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+	args := mallocArgs{Size: n}
+	spec := ffi.MakeSpec(mallocFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return 0, fmt.Errorf("cmem: malloc(%d): returned NULL", n)
+	}
+	recordAlloc(args.R, n)
+	return args.R, nil
+}
+
+// Calloc allocates count*size bytes via libc calloc, zeroed, and returns
+// their address - the same NULL-on-overflow behavior C's calloc has
+// rather than Malloc(count*size)'s own, unchecked multiplication. The
+// caller owns the result and must release it with Free once done.
+func Calloc(count, size uintptr) (uintptr, error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+	args := callocArgs{Count: count, Size: size}
+	spec := ffi.MakeSpec(callocFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return 0, fmt.Errorf("cmem: calloc(%d, %d): returned NULL", count, size)
+	}
+	recordAlloc(args.R, count*size)
+	return args.R, nil
+}
+
+// Realloc resizes the allocation at ptr to n bytes via libc realloc,
+// returning its (possibly new) address. ptr may be 0, in which case this
+// behaves like Malloc(n); n may be 0, in which case this behaves like
+// Free(ptr) and the returned address must not be used. The caller must
+// stop using ptr itself once Realloc returns: the allocation may have
+// moved or been freed, exactly as libc's own realloc documents.
+func Realloc(ptr, n uintptr) (uintptr, error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+	args := reallocArgs{Ptr: ptr, Size: n}
+	spec := ffi.MakeSpec(reallocFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 && n != 0 {
+		return 0, fmt.Errorf("cmem: realloc(0x%x, %d): returned NULL", ptr, n)
+	}
+	if n == 0 {
+		// Realloc(ptr, 0) behaves like Free(ptr) above - ptr is gone
+		// either way, whatever (possibly non-NULL) pointer libc's
+		// realloc happened to return isn't a new live allocation.
+		recordFree(ptr)
+	} else {
+		recordRealloc(ptr, args.R, n)
+	}
+	return args.R, nil
+}
+
+// Free releases a pointer returned by Malloc, Calloc or Realloc via
+// libc free. Freeing 0 is a no-op, the same as libc's own free(NULL).
+func Free(ptr uintptr) error {
+	if ptr == 0 {
+		return nil
+	}
+	if initErr != nil {
+		return initErr
+	}
+	args := freeArgs{Ptr: ptr}
+	spec := ffi.MakeSpec(freeFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	recordFree(ptr)
+	return nil
+}
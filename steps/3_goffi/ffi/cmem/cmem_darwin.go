@@ -0,0 +1,6 @@
+package cmem
+
+// libcPath is the shared library init resolves malloc/free/calloc/
+// realloc from - the same libSystem.B.dylib package ffi's own
+// cstring_darwin.go resolves CString/FreeCString's malloc/free from.
+const libcPath = "/usr/lib/libSystem.B.dylib"
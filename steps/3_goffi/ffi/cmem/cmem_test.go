@@ -0,0 +1,12 @@
+package cmem
+
+import "testing"
+
+// TestFreeZero confirms Free(0) is a no-op that never touches initErr,
+// so it's always safe to call on a possibly-never-allocated pointer -
+// the same contract libc's free(NULL) has.
+func TestFreeZero(t *testing.T) {
+	if err := Free(0); err != nil {
+		t.Fatalf("Free(0): got %v, want nil", err)
+	}
+}
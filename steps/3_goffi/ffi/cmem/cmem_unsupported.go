@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package cmem
+
+// libcPath would be the shared library init resolves malloc/free/calloc/
+// realloc from, once ffi's own loader_unsupported.go has a real ELF
+// loader to open it with - ffi.Open ignores the path on this platform
+// anyway, since it can't dlopen anything yet.
+const libcPath = "libc.so.6"
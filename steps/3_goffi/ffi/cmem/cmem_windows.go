@@ -0,0 +1,6 @@
+package cmem
+
+// libcPath is the DLL init resolves malloc/free/calloc/realloc from -
+// the same Universal CRT DLL package ffi's own cstring_windows.go
+// resolves CString/FreeCString's malloc/free from.
+const libcPath = "api-ms-win-crt-heap-l1-1-0.dll"
@@ -0,0 +1,148 @@
+package cmem
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// trackingEnabled gates recordAlloc/recordFree/recordRealloc: checked
+// with an atomic rather than liveMu so Malloc/Calloc/Realloc/Free pay no
+// locking cost at all while tracking is off, which is the common case -
+// this is a debug aid, not something every production build should pay
+// a runtime.Callers walk for on every allocation.
+var trackingEnabled atomic.Bool
+
+// EnableLeakTracking turns allocation-site tracking on or off for every
+// Malloc/Calloc/Realloc/Free call made from here on. Turning it off
+// discards whatever is currently tracked, the same as if tracking had
+// never been enabled - Malloc et al. themselves are unaffected either
+// way, since tracking only ever observes the pointers they already
+// return.
+func EnableLeakTracking(enabled bool) {
+	trackingEnabled.Store(enabled)
+	if !enabled {
+		liveMu.Lock()
+		live = nil
+		liveMu.Unlock()
+	}
+}
+
+// allocation is what recordAlloc keeps per live pointer: enough to
+// report back through LeakRecord.
+type allocation struct {
+	size  uintptr
+	stack string
+}
+
+var (
+	liveMu sync.Mutex
+	live   map[uintptr]allocation
+)
+
+// recordAlloc records ptr as live once Malloc/Calloc has actually
+// returned it, capturing the calling goroutine's stack as the
+// allocation site.
+func recordAlloc(ptr, size uintptr) {
+	if !trackingEnabled.Load() || ptr == 0 {
+		return
+	}
+	stack := callerStack()
+	liveMu.Lock()
+	if live == nil {
+		live = make(map[uintptr]allocation)
+	}
+	live[ptr] = allocation{size: size, stack: stack}
+	liveMu.Unlock()
+}
+
+// recordFree forgets ptr, called once Free has actually released it.
+func recordFree(ptr uintptr) {
+	if !trackingEnabled.Load() {
+		return
+	}
+	liveMu.Lock()
+	delete(live, ptr)
+	liveMu.Unlock()
+}
+
+// recordRealloc moves oldPtr's bookkeeping to newPtr, called once
+// Realloc has actually returned: oldPtr is never live again regardless
+// of whether the allocation moved, and newPtr (0 if Realloc(ptr, 0) just
+// freed it, the same as Free) becomes live in its place if nonzero.
+func recordRealloc(oldPtr, newPtr, size uintptr) {
+	if !trackingEnabled.Load() {
+		return
+	}
+	liveMu.Lock()
+	delete(live, oldPtr)
+	if newPtr != 0 {
+		if live == nil {
+			live = make(map[uintptr]allocation)
+		}
+		live[newPtr] = allocation{size: size, stack: callerStack()}
+	}
+	liveMu.Unlock()
+}
+
+// callerStack captures the calling goroutine's stack above cmem's own
+// exported entry point (skipping callerStack's own frame, its caller -
+// recordAlloc/recordRealloc - and that function's caller - Malloc/
+// Calloc/Realloc themselves), for LeakRecord.Stack.
+func callerStack() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(4, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// LeakRecord describes one allocation EnableLeakTracking(true) is still
+// tracking because Free hasn't been called for it (or Realloc hasn't
+// moved or freed it).
+type LeakRecord struct {
+	Ptr   uintptr
+	Size  uintptr
+	Stack string
+}
+
+// ReportLeaks returns every allocation leak tracking still considers
+// live, in no particular order. It's meaningful only once
+// EnableLeakTracking(true) has been called at some point before the
+// allocations it should report were made; otherwise it's always empty.
+func ReportLeaks() []LeakRecord {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	out := make([]LeakRecord, 0, len(live))
+	for ptr, a := range live {
+		out = append(out, LeakRecord{Ptr: ptr, Size: a.size, Stack: a.stack})
+	}
+	return out
+}
+
+// CheckLeaksAtExit is meant to be deferred from func main (or a test's
+// TestMain) once EnableLeakTracking(true) was called at startup: it
+// prints every allocation ReportLeaks still finds live to stderr, one
+// per allocation site with its full Go stack, so a leak is diagnosable
+// from the run that produced it instead of needing to be reproduced
+// under a separate leak detector.
+func CheckLeaksAtExit() {
+	leaks := ReportLeaks()
+	if len(leaks) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "cmem: %d unfreed allocation(s):\n", len(leaks))
+	for _, l := range leaks {
+		fmt.Fprintf(os.Stderr, "- 0x%x (%d bytes)\n%s\n", l.Ptr, l.Size, l.Stack)
+	}
+}
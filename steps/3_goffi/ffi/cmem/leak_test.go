@@ -0,0 +1,77 @@
+package cmem
+
+import "testing"
+
+// TestLeakTrackingOffRecordsNothing confirms recordAlloc/recordFree are
+// no-ops while EnableLeakTracking hasn't been called, so Malloc/Free pay
+// no bookkeeping cost by default.
+func TestLeakTrackingOffRecordsNothing(t *testing.T) {
+	EnableLeakTracking(false)
+	recordAlloc(0x1000, 16)
+	if leaks := ReportLeaks(); len(leaks) != 0 {
+		t.Fatalf("ReportLeaks with tracking off: got %d, want 0", len(leaks))
+	}
+}
+
+// TestLeakTrackingRecordsAndClearsAllocation confirms a tracked
+// allocation shows up in ReportLeaks until recordFree clears it.
+func TestLeakTrackingRecordsAndClearsAllocation(t *testing.T) {
+	EnableLeakTracking(true)
+	defer EnableLeakTracking(false)
+
+	recordAlloc(0x2000, 32)
+	leaks := ReportLeaks()
+	if len(leaks) != 1 || leaks[0].Ptr != 0x2000 || leaks[0].Size != 32 {
+		t.Fatalf("ReportLeaks after recordAlloc = %+v, want one entry for 0x2000/32", leaks)
+	}
+	if leaks[0].Stack == "" {
+		t.Fatal("LeakRecord.Stack is empty")
+	}
+
+	recordFree(0x2000)
+	if leaks := ReportLeaks(); len(leaks) != 0 {
+		t.Fatalf("ReportLeaks after recordFree = %+v, want none", leaks)
+	}
+}
+
+// TestLeakTrackingRecordReallocMovesPointer confirms recordRealloc
+// retires the old pointer and tracks the new one.
+func TestLeakTrackingRecordReallocMovesPointer(t *testing.T) {
+	EnableLeakTracking(true)
+	defer EnableLeakTracking(false)
+
+	recordAlloc(0x3000, 16)
+	recordRealloc(0x3000, 0x4000, 64)
+
+	leaks := ReportLeaks()
+	if len(leaks) != 1 || leaks[0].Ptr != 0x4000 || leaks[0].Size != 64 {
+		t.Fatalf("ReportLeaks after recordRealloc = %+v, want one entry for 0x4000/64", leaks)
+	}
+}
+
+// TestLeakTrackingRecordReallocToZeroFreesOldPointer confirms
+// recordRealloc with a zero new pointer behaves like recordFree on the
+// old one, matching Realloc(ptr, 0)'s free-like contract.
+func TestLeakTrackingRecordReallocToZeroFreesOldPointer(t *testing.T) {
+	EnableLeakTracking(true)
+	defer EnableLeakTracking(false)
+
+	recordAlloc(0x5000, 16)
+	recordRealloc(0x5000, 0, 0)
+
+	if leaks := ReportLeaks(); len(leaks) != 0 {
+		t.Fatalf("ReportLeaks after recordRealloc to 0 = %+v, want none", leaks)
+	}
+}
+
+// TestDisablingLeakTrackingDiscardsState confirms EnableLeakTracking(false)
+// clears whatever was being tracked, not just future calls.
+func TestDisablingLeakTrackingDiscardsState(t *testing.T) {
+	EnableLeakTracking(true)
+	recordAlloc(0x6000, 8)
+	EnableLeakTracking(false)
+
+	if leaks := ReportLeaks(); len(leaks) != 0 {
+		t.Fatalf("ReportLeaks after EnableLeakTracking(false) = %+v, want none", leaks)
+	}
+}
@@ -0,0 +1,37 @@
+package ffi
+
+// A note on coexisting with a real cgo runtime in the same binary, since
+// detecting one (_Cgo_iscgo, runtime.go) is the easy half of that problem
+// and routing Calls through cgo's own call path - the harder half - isn't
+// done here.
+//
+// Detection is real and in use: _Cgo_iscgo is runtime.iscgo itself, which
+// the runtime sets the moment any package in the binary's dependency tree
+// actually does `import "C"` - not merely CGO_ENABLED=1 (the `cgo` build
+// tag cgobootstrap_cgo.go and cgoinit_*.s key off is a compile-time
+// toolchain setting; iscgo is a link-time fact about what got pulled in).
+// CgoStatus.RealCgoPresent (probe.go) already reports it, as does
+// bootstrapped() (cgobootstrap_cgo.go) through CgoStatus.Bootstrapped in a
+// cgo build, and callback_foreign_amd64.go's
+// extra-M seeding already treats "iscgo true" as the one case it must
+// leave alone rather than duplicate - this package has needed to know
+// whether real cgo is present for longer than this file has existed.
+//
+// Routing Call itself through cgo's path, though, means through
+// runtime.cgocall, not asmcgocall: cgocall is what real `import "C"` call
+// sites actually compile down to, and asmcgocall - the primitive Call
+// already uses (runtime.go) - is only the low half of it. The difference
+// isn't cosmetic. cgocall tracks its own per-m recursive-call depth and
+// panics outright on a call pattern it doesn't expect, and it increments
+// the blocking-syscall counter sysmon's retake reads to decide whether to
+// hand a spinning P to a fresh M - bookkeeping this package's Call has
+// never needed because it enters and exits that accounting itself via the
+// plain entersyscall/exitsyscall pair already in runtime.go, once per
+// call, with nothing recursive about it. Calling cgocall instead of
+// asmcgocall wouldn't add coexistence so much as layer a second, different
+// set of assumptions about call nesting and M accounting on top of the
+// ones Call already satisfies correctly - and a subtly wrong interaction
+// between the two would fail the way double-counted scheduler bookkeeping
+// usually does: rarely, under load, and nowhere near the call that caused
+// it. That's a correctness risk worth more investigation than detecting
+// iscgo is, not a reason to leave iscgo undetected in the meantime.
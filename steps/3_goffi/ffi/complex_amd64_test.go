@@ -0,0 +1,66 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfAddComplex64Target/addComplex64Target and
+// addrOfAddComplex128Target/addComplex128Target (complex_amd64_test.s)
+// stand in for C functions taking and returning _Complex float and
+// _Complex double.
+func addrOfAddComplex64Target() uintptr
+func addComplex64Target()
+func addrOfAddComplex128Target() uintptr
+func addComplex128Target()
+
+// complex64Args exercises fieldToOffset's complex64 case: two complex64
+// values pass in a single XMM register each (A in XMM0, B in XMM1,
+// exactly like two float64 arguments), no aggregate classification
+// involved.
+type complex64Args struct {
+	A complex64 `ffi:"arg"`
+	B complex64 `ffi:"arg"`
+	R complex64 `ffi:"ret"`
+}
+
+// TestCallComplex64 drives addComplex64Target through the real asmcall
+// trampoline, confirming a complex64 argument/return round-trips through
+// its single SSE eightbyte unchanged.
+func TestCallComplex64(t *testing.T) {
+	args := complex64Args{A: 1 + 2i, B: 3 + 4i}
+	spec := MakeSpec(addrOfAddComplex64Target(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if want := complex64(4 + 6i); args.R != want {
+		t.Fatalf("complex64 call: got R=%v, want %v", args.R, want)
+	}
+}
+
+// complex128Args exercises the complex128 path through buildSpec's
+// aggregate-classification branch: A and B each classify as two SSE
+// eightbytes (classifyField treats complex128 exactly like
+// struct{ re, im float64 }), so A occupies XMM0/XMM1 and B occupies
+// XMM2/XMM3.
+type complex128Args struct {
+	A complex128 `ffi:"arg"`
+	B complex128 `ffi:"arg"`
+	R complex128 `ffi:"ret"`
+}
+
+// TestCallComplex128 drives addComplex128Target through the real
+// asmcall trampoline, confirming a complex128 argument/return correctly
+// splits across two XMM registers each.
+func TestCallComplex128(t *testing.T) {
+	args := complex128Args{A: 1 + 2i, B: 3 + 4i}
+	spec := MakeSpec(addrOfAddComplex128Target(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if want := complex128(4 + 6i); args.R != want {
+		t.Fatalf("complex128 call: got R=%v, want %v", args.R, want)
+	}
+}
@@ -0,0 +1,46 @@
+package ffi
+
+import (
+	"context"
+	"unsafe"
+)
+
+// CallContext calls spec like spec.Call, but watches ctx concurrently: if
+// ctx is done before the call returns, cancelFn is invoked from a
+// separate goroutine so the call has a chance to interrupt itself
+// cooperatively. cancelFn is meant to be a closure around a call to
+// whatever C-side interruption routine the library offers (sqlite3's
+// sqlite3_interrupt, say) - one that's documented safe to call from a
+// different thread while the original call is still running, since
+// that's exactly what happens here.
+//
+// CallContext always waits for spec.Call to actually return before
+// coming back itself: there is no way to safely abandon a C call that
+// might still be reading or writing through args after this function
+// returned, the same reason context cancellation can never forcibly stop
+// a goroutine that refuses to check ctx.Done() itself. If the C function
+// has no interruption mechanism, or cancelFn's call to it doesn't make
+// the underlying call actually return any sooner, CallContext still
+// blocks until it does.
+//
+// The returned error is ctx.Err() if ctx was ever done during the call,
+// nil otherwise. It says nothing about whether the call itself completed
+// normally or was interrupted partway through - that's for its own
+// return value or output fields to report, the same as calling spec.Call
+// directly.
+func (spec *Spec) CallContext(ctx context.Context, args unsafe.Pointer, cancelFn func()) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		spec.Call(args)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancelFn()
+		<-done
+		return ctx.Err()
+	}
+}
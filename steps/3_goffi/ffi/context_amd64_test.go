@@ -0,0 +1,57 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"context"
+	"testing"
+	"unsafe"
+)
+
+// TestCallContextCancelled confirms CallContext invokes cancelFn once
+// ctx is done and still waits for the underlying call to actually finish
+// before returning ctx.Err() - using addPairFlatTarget (func_amd64_test.go)
+// as a stand-in for a real C function, since it returns immediately
+// either way.
+func TestCallContextCancelled(t *testing.T) {
+	spec := MustSpecFor[funcAddArgs](addrOfAddPairFlatTarget())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	args := funcAddArgs{A: 3, B: 4}
+	cancelCalled := make(chan struct{})
+	err := spec.CallContext(ctx, unsafe.Pointer(&args), func() {
+		close(cancelCalled)
+	})
+
+	if err != context.Canceled {
+		t.Fatalf("CallContext: got err %v, want %v", err, context.Canceled)
+	}
+	select {
+	case <-cancelCalled:
+	default:
+		t.Fatal("CallContext: cancelFn was not invoked")
+	}
+	if args.R != 7 {
+		t.Fatalf("CallContext: R = %v, want 7 (the call should still have run)", args.R)
+	}
+}
+
+// TestCallContextNotCancelled confirms CallContext returns nil and never
+// invokes cancelFn when the call finishes before ctx is ever done.
+func TestCallContextNotCancelled(t *testing.T) {
+	spec := MustSpecFor[funcAddArgs](addrOfAddPairFlatTarget())
+
+	args := funcAddArgs{A: 3, B: 4}
+	err := spec.CallContext(context.Background(), unsafe.Pointer(&args), func() {
+		t.Fatal("CallContext: cancelFn was invoked, but ctx was never done")
+	})
+
+	if err != nil {
+		t.Fatalf("CallContext: got err %v, want nil", err)
+	}
+	if args.R != 7 {
+		t.Fatalf("CallContext: R = %v, want 7", args.R)
+	}
+}
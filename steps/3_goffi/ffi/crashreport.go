@@ -0,0 +1,67 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "runtime"
+
+// CrashReport describes what ffi knows about a fault CallProtectedReport
+// caught: which C function it was in the middle of calling, and the
+// state of every Go goroutine at the moment the fault was observed.
+//
+// It deliberately does not include a true unwind of the C frames inside
+// the call itself - see CallProtectedReport's doc comment for why.
+type CrashReport struct {
+	// Symbol is spec.symbol, set via SetSymbol before the call - empty if
+	// the Spec was never given one.
+	Symbol string
+
+	// Fn is the address Call was about to run (spec.fn) - the same
+	// address SetSymbol's name, if any, refers to.
+	Fn uintptr
+
+	// GoStack holds every goroutine's Go-level stack trace, captured via
+	// runtime.Stack(..., true) the moment the fault was observed -
+	// everything CallProtected's bare ErrCallFaulted throws away.
+	GoStack string
+}
+
+// CallProtectedReport is CallProtected plus a CrashReport captured at
+// the moment a fault is observed, for logging something more useful than
+// bare ErrCallFaulted - in particular which C symbol was being called
+// and every goroutine's state, in place of the opaque "unexpected fault
+// address" an unprotected crash produces.
+//
+// It cannot report the faulting C stack itself - the actual C frames
+// inside spec.fn at the moment of the fault. That needs the raw
+// ucontext_t a real sigaction(2) SA_SIGINFO handler receives, with the
+// fault's saved registers and frame-pointer chain; CallProtected is
+// deliberately built on os/signal instead (see its own doc comment),
+// which only ever reports that a signal arrived, never the siginfo_t/
+// ucontext_t that came with it. Symbolizing and walking that chain would
+// mean this package installing its own handler ahead of (or instead of)
+// the Go runtime's, competing for the same synchronous fault signals the
+// runtime itself depends on to implement stack growth and goroutine
+// preemption - not something to take on without the linkname access
+// protect.go already says this package doesn't have.
+func CallProtectedReport(spec *Spec, call func()) (*CrashReport, error) {
+	err := CallProtected(call)
+	if err != ErrCallFaulted {
+		return nil, err
+	}
+
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+
+	return &CrashReport{
+		Symbol:  spec.symbol,
+		Fn:      spec.fn,
+		GoStack: string(buf),
+	}, ErrCallFaulted
+}
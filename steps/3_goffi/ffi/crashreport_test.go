@@ -0,0 +1,57 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// TestCallProtectedReportCatchesFault reuses crashTarget
+// (protect_amd64_test.s/TestCallProtectedCatchesFault's own fixture) to
+// confirm CallProtectedReport both catches the fault and fills in the
+// Spec's symbol and fn alongside a non-empty Go stack dump.
+func TestCallProtectedReportCatchesFault(t *testing.T) {
+	spec := MakeSpec(addrOfCrashTarget(), &crashArgs{})
+	spec.SetSymbol("crashTarget")
+
+	report, err := CallProtectedReport(&spec, func() {
+		spec.Call(nil)
+	})
+	if err != ErrCallFaulted {
+		t.Fatalf("CallProtectedReport: got err %v, want ErrCallFaulted", err)
+	}
+	if report == nil {
+		t.Fatal("CallProtectedReport: got nil report alongside ErrCallFaulted")
+	}
+	if report.Symbol != "crashTarget" {
+		t.Fatalf("CallProtectedReport: report.Symbol = %q, want %q", report.Symbol, "crashTarget")
+	}
+	if report.Fn != addrOfCrashTarget() {
+		t.Fatalf("CallProtectedReport: report.Fn = %x, want %x", report.Fn, addrOfCrashTarget())
+	}
+	if !strings.Contains(report.GoStack, "goroutine") {
+		t.Fatalf("CallProtectedReport: report.GoStack doesn't look like a goroutine dump:\n%s", report.GoStack)
+	}
+}
+
+// TestCallProtectedReportOrdinaryCall confirms a call that returns
+// normally produces no report.
+func TestCallProtectedReportOrdinaryCall(t *testing.T) {
+	args := funcAddArgs{A: 3, B: 4}
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &args)
+
+	report, err := CallProtectedReport(&spec, func() {
+		spec.Call(unsafe.Pointer(&args))
+	})
+	if err != nil {
+		t.Fatalf("CallProtectedReport: unexpected error %v", err)
+	}
+	if report != nil {
+		t.Fatalf("CallProtectedReport: got non-nil report %+v for an ordinary call", report)
+	}
+	if args.R != 7 {
+		t.Fatalf("CallProtectedReport: got R=%d, want 7", args.R)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfStrlenTarget and strlenTarget (cstr_amd64_test.s) stand in for a
+// C function that reads a NUL-terminated string, the same way
+// addrOfAddPairTarget/addPairTarget stand in for one that adds two ints.
+func addrOfStrlenTarget() uintptr
+func strlenTarget()
+
+// cstrArgs exercises the ffi:"cstr" tag: S is a plain Go string, not
+// already NUL-terminated, so prepareCStrings must copy it into a scratch
+// buffer before strlenTarget can read it safely.
+type cstrArgs struct {
+	S string `ffi:"arg,cstr"`
+	R int64  `ffi:"ret"`
+}
+
+// TestCallCString drives strlenTarget through the real asmcall
+// trampoline, confirming the marshaled pointer is NUL-terminated and
+// that S is left holding its original value once the call returns.
+func TestCallCString(t *testing.T) {
+	args := cstrArgs{S: "hello"}
+	spec := MakeSpec(addrOfStrlenTarget(), &args)
+
+	a := acquireArena()
+	restore := prepareCStrings(unsafe.Pointer(&args), spec.cstrFields, a)
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+	restore()
+	releaseArena(a)
+
+	if args.R != 5 {
+		t.Fatalf("cstr call: got R=%d, want 5", args.R)
+	}
+	if args.S != "hello" {
+		t.Fatalf("cstr call: S was not restored, got %q, want %q", args.S, "hello")
+	}
+}
@@ -0,0 +1,69 @@
+package ffi
+
+import "unsafe"
+
+// CStrArray is an owned handle to a NULL-terminated array of C strings -
+// the char** shape execve-style and getopt-style C APIs expect for argv
+// and envp, both of which are just "a NUL-terminated string per element,
+// plus a NULL pointer after the last one" with no further structure to
+// tell them apart. Each element is allocated the same way NewCStr
+// allocates one, and Free releases every element together with the
+// array itself.
+type CStrArray struct {
+	arr   uintptr
+	elems []uintptr
+	freed bool
+}
+
+// NewCStrArray allocates a NULL-terminated char** from items, each
+// element CString'd individually and the array itself malloc'd to hold
+// len(items)+1 pointers. If any element fails to allocate, everything
+// allocated so far is freed before returning the error.
+func NewCStrArray(items []string) (*CStrArray, error) {
+	elems := make([]uintptr, 0, len(items))
+	for _, s := range items {
+		ptr, err := CString(s)
+		if err != nil {
+			for _, p := range elems {
+				FreeCString(p)
+			}
+			return nil, err
+		}
+		elems = append(elems, ptr)
+	}
+
+	arr, err := mallocPtr(uintptr(len(elems)+1) * unsafe.Sizeof(uintptr(0)))
+	if err != nil {
+		for _, p := range elems {
+			FreeCString(p)
+		}
+		return nil, err
+	}
+
+	slots := unsafe.Slice((*uintptr)(unsafe.Pointer(arr)), len(elems)+1)
+	copy(slots, elems)
+	slots[len(elems)] = 0
+
+	return &CStrArray{arr: arr, elems: elems}, nil
+}
+
+// Ptr returns the array's base address, for passing to a C function
+// expecting a char**. It stays valid until Free is called.
+func (c *CStrArray) Ptr() uintptr {
+	return c.arr
+}
+
+// Free releases every element's allocation along with the array itself.
+// It's safe to call more than once; only the first call does anything.
+func (c *CStrArray) Free() error {
+	if c.freed {
+		return nil
+	}
+	c.freed = true
+	for _, p := range c.elems {
+		if err := FreeCString(p); err != nil {
+			return err
+		}
+	}
+	return freePtr(c.arr)
+}
@@ -0,0 +1,60 @@
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCStrArrayBuildsNullTerminatedArray confirms NewCStrArray lays out
+// its elements as a char** a C function could walk: each slot reads
+// back as the matching string, and the slot past the last element is
+// NULL.
+func TestCStrArrayBuildsNullTerminatedArray(t *testing.T) {
+	items := []string{"argv0", "-x", "value"}
+	arr, err := NewCStrArray(items)
+	if err != nil {
+		t.Fatalf("NewCStrArray: %v", err)
+	}
+	defer arr.Free()
+
+	slots := unsafe.Slice((*uintptr)(unsafe.Pointer(arr.Ptr())), len(items)+1)
+	for i, want := range items {
+		if got := GoString(slots[i]); got != want {
+			t.Fatalf("slot %d: got %q, want %q", i, got, want)
+		}
+	}
+	if slots[len(items)] != 0 {
+		t.Fatalf("slot %d: got %#x, want NULL terminator", len(items), slots[len(items)])
+	}
+}
+
+// TestCStrArrayFreeIsIdempotent confirms Free can be called more than
+// once without double-freeing the backing allocations.
+func TestCStrArrayFreeIsIdempotent(t *testing.T) {
+	arr, err := NewCStrArray([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("NewCStrArray: %v", err)
+	}
+	if err := arr.Free(); err != nil {
+		t.Fatalf("Free: %v", err)
+	}
+	if err := arr.Free(); err != nil {
+		t.Fatalf("second Free: %v", err)
+	}
+}
+
+// TestCStrArrayEmpty confirms an empty item list still produces a valid
+// one-element (NULL-only) array, the same shape an empty argv or envp
+// would need.
+func TestCStrArrayEmpty(t *testing.T) {
+	arr, err := NewCStrArray(nil)
+	if err != nil {
+		t.Fatalf("NewCStrArray: %v", err)
+	}
+	defer arr.Free()
+
+	slots := unsafe.Slice((*uintptr)(unsafe.Pointer(arr.Ptr())), 1)
+	if slots[0] != 0 {
+		t.Fatalf("slot 0: got %#x, want NULL terminator", slots[0])
+	}
+}
@@ -0,0 +1,115 @@
+package ffi
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// cstrLeakCheckEnabled is set once, from a GODEBUG=ffileakcheck=1
+// setting, by init below - the same per-feature GODEBUG knob
+// pointercheck.go's ffipointercheck=1 uses. CStrLeakStatsNow's counters
+// are only maintained while this is on, so a program that never opts in
+// pays nothing for bookkeeping it isn't using.
+var cstrLeakCheckEnabled bool
+
+func init() {
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if setting == "ffileakcheck=1" {
+			cstrLeakCheckEnabled = true
+			break
+		}
+	}
+}
+
+var (
+	cstrLive   atomic.Int64
+	cstrLeaked atomic.Int64
+)
+
+// CStrLeakStats is CStrLeakStatsNow's result: Live is how many CStr
+// handles are currently allocated and not yet Freed; Leaked is how many
+// of those were ultimately reclaimed by CStr's finalizer rather than an
+// explicit Free call.
+type CStrLeakStats struct {
+	Live   int64
+	Leaked int64
+}
+
+// CStrLeakStatsNow returns the current CStrLeakStats. Both fields read 0
+// unless GODEBUG=ffileakcheck=1 is set - maintaining them costs an
+// atomic write on every NewCStr/Free/finalize this package otherwise
+// skips.
+func CStrLeakStatsNow() CStrLeakStats {
+	return CStrLeakStats{Live: cstrLive.Load(), Leaked: cstrLeaked.Load()}
+}
+
+// CStr is an owned handle to a NUL-terminated C string allocated via
+// libc malloc (the same allocation CString itself makes) - for an API
+// that retains a char* pointer briefly past the call it was passed to,
+// longer than ffi:"cstr" (prepareCStrings in ffi.go) guarantees the
+// underlying memory survives, but not so long that reaching for cmem's
+// lower-level Malloc/Free directly is worth the trouble.
+//
+// Free releases the backing allocation and must be called once the C
+// side is done with the pointer. A CStr that's garbage collected without
+// being Freed has its finalizer release it instead, as a last resort -
+// under GODEBUG=ffileakcheck=1 that finalizer also counts against
+// CStrLeakStatsNow's Leaked field, since reaching it at all means Free
+// was never called.
+type CStr struct {
+	ptr   uintptr
+	freed bool
+}
+
+// NewCStr allocates s as a NUL-terminated C string via CString and wraps
+// its address in a CStr.
+func NewCStr(s string) (*CStr, error) {
+	ptr, err := CString(s)
+	if err != nil {
+		return nil, err
+	}
+	c := &CStr{ptr: ptr}
+	if cstrLeakCheckEnabled {
+		cstrLive.Add(1)
+	}
+	runtime.SetFinalizer(c, (*CStr).finalize)
+	return c, nil
+}
+
+// Ptr returns c's backing allocation's address, for passing to a C
+// function expecting a char*. It stays valid until Free is called (or c
+// is garbage collected without having been Freed).
+func (c *CStr) Ptr() uintptr {
+	return c.ptr
+}
+
+// Free releases c's backing allocation via FreeCString. It's safe to
+// call more than once; only the first call does anything.
+func (c *CStr) Free() error {
+	if c.freed {
+		return nil
+	}
+	c.freed = true
+	runtime.SetFinalizer(c, nil)
+	if cstrLeakCheckEnabled {
+		cstrLive.Add(-1)
+	}
+	return FreeCString(c.ptr)
+}
+
+// finalize is c's finalizer safety net: if c is collected without Free
+// ever having been called, it releases the allocation anyway rather
+// than leaking it for the rest of the process's life, and - under
+// GODEBUG=ffileakcheck=1 - records the leak.
+func (c *CStr) finalize() {
+	if c.freed {
+		return
+	}
+	if cstrLeakCheckEnabled {
+		cstrLive.Add(-1)
+		cstrLeaked.Add(1)
+	}
+	FreeCString(c.ptr)
+}
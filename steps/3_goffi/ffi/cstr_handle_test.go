@@ -0,0 +1,52 @@
+package ffi
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestCStrFreeReleasesAndIsIdempotent confirms NewCStr's handle reads
+// back the right string, and that Free can be called more than once
+// without double-freeing the backing allocation.
+func TestCStrFreeReleasesAndIsIdempotent(t *testing.T) {
+	c, err := NewCStr("hello")
+	if err != nil {
+		t.Fatalf("NewCStr: %v", err)
+	}
+
+	if got := GoString(c.Ptr()); got != "hello" {
+		t.Fatalf("Ptr: GoString(c.Ptr()) = %q, want %q", got, "hello")
+	}
+
+	if err := c.Free(); err != nil {
+		t.Fatalf("Free: %v", err)
+	}
+	if err := c.Free(); err != nil {
+		t.Fatalf("second Free: %v", err)
+	}
+}
+
+// TestCStrFinalizerReclaimsUnfreedHandle confirms a CStr that's dropped
+// without an explicit Free is still reclaimed by its finalizer, rather
+// than leaking its C allocation for the rest of the process's life.
+func TestCStrFinalizerReclaimsUnfreedHandle(t *testing.T) {
+	before := CStrLeakStatsNow().Leaked
+
+	func() {
+		if _, err := NewCStr("leaked"); err != nil {
+			t.Fatalf("NewCStr: %v", err)
+		}
+	}()
+
+	if !cstrLeakCheckEnabled {
+		t.Skip("GODEBUG=ffileakcheck=1 not set; finalizer still runs but Leaked isn't tracked")
+	}
+
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		if CStrLeakStatsNow().Leaked > before {
+			return
+		}
+	}
+	t.Fatal("finalizer did not reclaim the unfreed CStr within 50 GC cycles")
+}
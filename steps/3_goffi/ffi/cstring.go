@@ -0,0 +1,282 @@
+package ffi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// libc lazily opens libcPath (set per platform by cstring_darwin.go/
+// cstring_windows.go/cstring_unsupported.go) and resolves malloc/free
+// from it, the same Open+Get two-step any other caller of this package
+// would use to reach a C library's symbols - CString/FreeCString have no
+// special access of their own, they're just a convenience wrapper around
+// it. On a platform loader_unsupported.go hasn't got a real loader for
+// yet, Open's own error surfaces here unchanged.
+func libc() (mallocFn, freeFn uintptr, err error) {
+	libcOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			libcErr = openErr
+			return
+		}
+		if libcMallocFn, libcErr = lib.Get("malloc"); libcErr != nil {
+			return
+		}
+		libcFreeFn, libcErr = lib.Get("free")
+	})
+	return libcMallocFn, libcFreeFn, libcErr
+}
+
+var (
+	libcOnce     sync.Once
+	libcMallocFn uintptr
+	libcFreeFn   uintptr
+	libcErr      error
+)
+
+// mallocArgs and freeArgs are plain MakeSpec targets for libc's
+// malloc(size_t)/free(void*) - size_t and void* are both word-sized, so
+// Size/Ptr being uintptr fields already gets fieldToOffset's (or
+// fieldToOffset386's) native-word-size handling for free on every arch
+// this package supports.
+type mallocArgs struct {
+	Size uintptr `ffi:"arg"`
+	R    uintptr `ffi:"ret"`
+}
+
+type freeArgs struct {
+	Ptr uintptr `ffi:"arg"`
+}
+
+// mallocPtr allocates n bytes via libc malloc and returns their address -
+// the shared implementation behind CString and NewCStrArray, both of
+// which just want libc's own malloc(size_t) for an address this package
+// already resolved.
+func mallocPtr(n uintptr) (uintptr, error) {
+	mallocFn, _, err := libc()
+	if err != nil {
+		return 0, err
+	}
+	args := mallocArgs{Size: n}
+	spec := MakeSpec(mallocFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return 0, fmt.Errorf("ffi: malloc(%d): returned NULL", n)
+	}
+	return args.R, nil
+}
+
+// freePtr releases ptr via libc free - the shared implementation behind
+// FreeCString and CStrArray.Free, both of which just want libc's own
+// free(void*) on an address this package already resolved.
+func freePtr(ptr uintptr) error {
+	_, freeFn, err := libc()
+	if err != nil {
+		return err
+	}
+	args := freeArgs{Ptr: ptr}
+	spec := MakeSpec(freeFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return nil
+}
+
+// CString allocates a NUL-terminated copy of s via libc malloc and
+// returns its address, for handing to a C function that stores a char*
+// argument past the call it was passed to rather than just reading it
+// during the call - unlike ffi:"cstr" (prepareCStrings in ffi.go), which
+// backs a string argument with Go-managed memory only guaranteed to
+// survive the one call it's made for. The caller must release the
+// result with FreeCString once the C side is done with it.
+func CString(s string) (uintptr, error) {
+	n := uintptr(len(s) + 1)
+	addr, err := mallocPtr(n)
+	if err != nil {
+		return 0, err
+	}
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(addr)), n)
+	copy(buf, s)
+	buf[len(s)] = 0
+	return addr, nil
+}
+
+// FreeCString releases a pointer returned by CString via libc free.
+func FreeCString(ptr uintptr) error {
+	return freePtr(ptr)
+}
+
+// WString is CString for the UTF-16, NUL-terminated LPCWSTR the "W"
+// half of a Win32 API pair (CreateFileW, MessageBoxW, ...) wants instead
+// of a char*: it allocates a NUL-terminated UTF-16 copy of s via libc
+// malloc and returns its address, for handing to a foreign function that
+// stores the pointer past the call it was passed to rather than just
+// reading it during the call - unlike ffi:"wstr" (prepareWStrings in
+// ffi.go), which backs a string argument with Go-managed memory only
+// guaranteed to survive the one call it's made for. The caller must
+// release the result with FreeWString once the C side is done with it.
+func WString(s string) (uintptr, error) {
+	units := utf16.Encode([]rune(s))
+	n := uintptr(2 * (len(units) + 1))
+	addr, err := mallocPtr(n)
+	if err != nil {
+		return 0, err
+	}
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(addr)), n)
+	for i, u := range units {
+		buf[2*i] = byte(u)
+		buf[2*i+1] = byte(u >> 8)
+	}
+	buf[2*len(units)] = 0
+	buf[2*len(units)+1] = 0
+	return addr, nil
+}
+
+// FreeWString releases a pointer returned by WString via libc free.
+func FreeWString(ptr uintptr) error {
+	return freePtr(ptr)
+}
+
+// GoString copies the NUL-terminated C string at addr into a Go string -
+// the general-purpose form of what loader_darwin.go's dlerrorMessage
+// uses to read dlerror's result, usable against any char* a foreign call
+// handed back. addr of 0 reads as "", the same way a NULL char* does in
+// C; otherwise addr must point at memory that is in fact
+// NUL-terminated, since there's no length to bound the scan with - the
+// same contract C.GoString has.
+func GoString(addr uintptr) string {
+	if addr == 0 {
+		return ""
+	}
+	n := 0
+	for *(*byte)(unsafe.Pointer(addr + uintptr(n))) != 0 {
+		n++
+	}
+	return string(unsafe.Slice((*byte)(unsafe.Pointer(addr)), n))
+}
+
+// GoStringN copies up to n bytes starting at addr into a Go string,
+// stopping early at a NUL byte if one appears first - the same contract
+// C.GoStringN has. addr of 0 reads as "" regardless of n.
+func GoStringN(addr uintptr, n int) string {
+	if addr == 0 || n == 0 {
+		return ""
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(addr)), n)
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// GoStringW is GoString for a NUL-terminated UTF-16 LPCWSTR, the string
+// shape the "W" half of a Win32 API pair (GetModuleFileNameW,
+// FormatMessageW, ...) hands back. addr of 0 reads as "", the same way a
+// NULL LPCWSTR does; otherwise addr must point at memory that is in fact
+// NUL-terminated (a 16-bit zero unit), since there's no length to bound
+// the scan with.
+func GoStringW(addr uintptr) string {
+	if addr == 0 {
+		return ""
+	}
+	n := 0
+	for *(*uint16)(unsafe.Pointer(addr + uintptr(2*n))) != 0 {
+		n++
+	}
+	units := unsafe.Slice((*uint16)(unsafe.Pointer(addr)), n)
+	return string(utf16.Decode(units))
+}
+
+// GoStringWN is GoStringN for a UTF-16 string: it copies up to n
+// 16-bit units starting at addr, stopping early at a NUL unit if one
+// appears first. addr of 0 reads as "" regardless of n.
+func GoStringWN(addr uintptr, n int) string {
+	if addr == 0 || n == 0 {
+		return ""
+	}
+	units := unsafe.Slice((*uint16)(unsafe.Pointer(addr)), n)
+	for i, u := range units {
+		if u == 0 {
+			units = units[:i]
+			break
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// GoStringOptions configures GoStringOpts' conversion of a
+// NUL-terminated C string, letting a call site opt into UTF-8
+// validation/replacement and/or interning without forcing that cost onto
+// GoString's many existing callers that don't need either.
+type GoStringOptions struct {
+	// ValidateUTF8, if true, replaces any invalid UTF-8 byte sequence in
+	// the result with utf8.RuneError - useful when the C side isn't
+	// guaranteed to honor its own "this is UTF-8" contract (e.g. a
+	// locale-dependent error string).
+	ValidateUTF8 bool
+
+	// Intern, if non-nil, is consulted (and populated) instead of
+	// returning a freshly allocated string every call - worth it for
+	// APIs that return the same small set of strings over and over
+	// (enum-name style accessors), where the repeated allocation is pure
+	// waste.
+	Intern *Interner
+}
+
+// GoStringOpts is GoString with the per-call-site options described by
+// GoStringOptions. GoString itself remains the zero-overhead default for
+// callers that need neither validation nor interning.
+func GoStringOpts(addr uintptr, opts GoStringOptions) string {
+	s := GoString(addr)
+	if opts.ValidateUTF8 && !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	if opts.Intern != nil {
+		s = opts.Intern.intern(s)
+	}
+	return s
+}
+
+// Interner caches strings so that repeated C strings with identical
+// content resolve to the same underlying Go string value instead of a
+// fresh allocation each time - see GoStringOptions.Intern. The zero
+// value is not usable; use NewInterner.
+type Interner struct {
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewInterner returns an empty Interner ready to use.
+func NewInterner() *Interner {
+	return &Interner{cache: make(map[string]string)}
+}
+
+// intern returns the canonical string equal to s, caching s the first
+// time it's seen so later calls with an equal but separately allocated
+// string reuse that same backing array instead.
+func (in *Interner) intern(s string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if cached, ok := in.cache[s]; ok {
+		return cached
+	}
+	in.cache[s] = s
+	return s
+}
+
+// GoBytes copies n bytes starting at addr into a new, independently
+// owned []byte - the same contract C.GoBytes has, for a (ptr,len) pair a
+// foreign call returned that the caller wants to keep past the point
+// where libc or the C library that produced it might reuse or free the
+// backing memory.
+func GoBytes(addr uintptr, n int) []byte {
+	if addr == 0 || n == 0 {
+		return nil
+	}
+	b := make([]byte, n)
+	copy(b, unsafe.Slice((*byte)(unsafe.Pointer(addr)), n))
+	return b
+}
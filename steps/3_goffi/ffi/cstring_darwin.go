@@ -0,0 +1,6 @@
+package ffi
+
+// libcPath is the shared library CString/FreeCString (cstring.go)
+// resolve malloc/free from - the same libSystem.B.dylib loader_darwin.go
+// already dlopens for dlopen/dlsym/dlclose/dlerror themselves.
+const libcPath = "/usr/lib/libSystem.B.dylib"
@@ -0,0 +1,111 @@
+package ffi
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+)
+
+func TestGoString(t *testing.T) {
+	if s := GoString(0); s != "" {
+		t.Fatalf("GoString(0): got %q, want \"\"", s)
+	}
+	buf := append([]byte("hello"), 0)
+	if s := GoString(uintptr(unsafe.Pointer(&buf[0]))); s != "hello" {
+		t.Fatalf("GoString: got %q, want %q", s, "hello")
+	}
+}
+
+func TestGoStringN(t *testing.T) {
+	if s := GoStringN(0, 5); s != "" {
+		t.Fatalf("GoStringN(0, 5): got %q, want \"\"", s)
+	}
+	buf := []byte("hello, world")
+	if s := GoStringN(uintptr(unsafe.Pointer(&buf[0])), 5); s != "hello" {
+		t.Fatalf("GoStringN: got %q, want %q", s, "hello")
+	}
+
+	withNUL := append([]byte("ab"), 0, 'c', 'd')
+	if s := GoStringN(uintptr(unsafe.Pointer(&withNUL[0])), len(withNUL)); s != "ab" {
+		t.Fatalf("GoStringN: got %q, want %q (should stop at NUL)", s, "ab")
+	}
+}
+
+func TestGoStringOptsValidateUTF8(t *testing.T) {
+	buf := append([]byte("ok\xffbad"), 0)
+
+	// without the option, the invalid byte passes through untouched
+	raw := GoStringOpts(uintptr(unsafe.Pointer(&buf[0])), GoStringOptions{})
+	if raw != "ok\xffbad" {
+		t.Fatalf("GoStringOpts: got %q, want the raw bytes unmodified", raw)
+	}
+	if utf8.ValidString(raw) {
+		t.Fatalf("GoStringOpts: %q was supposed to be invalid UTF-8", raw)
+	}
+
+	validated := GoStringOpts(uintptr(unsafe.Pointer(&buf[0])), GoStringOptions{ValidateUTF8: true})
+	if !utf8.ValidString(validated) {
+		t.Fatalf("GoStringOpts: result %q is still not valid UTF-8", validated)
+	}
+}
+
+func TestGoStringOptsIntern(t *testing.T) {
+	in := NewInterner()
+	buf1 := append([]byte("ENUM_VALUE"), 0)
+	buf2 := append([]byte("ENUM_VALUE"), 0)
+
+	s1 := GoStringOpts(uintptr(unsafe.Pointer(&buf1[0])), GoStringOptions{Intern: in})
+	s2 := GoStringOpts(uintptr(unsafe.Pointer(&buf2[0])), GoStringOptions{Intern: in})
+
+	if s1 != s2 {
+		t.Fatalf("GoStringOpts: got %q and %q, want equal strings", s1, s2)
+	}
+	if unsafe.StringData(s1) != unsafe.StringData(s2) {
+		t.Fatal("GoStringOpts: interned strings do not share the same backing array")
+	}
+}
+
+func TestGoStringW(t *testing.T) {
+	if s := GoStringW(0); s != "" {
+		t.Fatalf("GoStringW(0): got %q, want \"\"", s)
+	}
+	units := append(utf16.Encode([]rune("hello")), 0)
+	if s := GoStringW(uintptr(unsafe.Pointer(&units[0]))); s != "hello" {
+		t.Fatalf("GoStringW: got %q, want %q", s, "hello")
+	}
+}
+
+func TestGoStringWN(t *testing.T) {
+	if s := GoStringWN(0, 5); s != "" {
+		t.Fatalf("GoStringWN(0, 5): got %q, want \"\"", s)
+	}
+	units := utf16.Encode([]rune("hello, world"))
+	if s := GoStringWN(uintptr(unsafe.Pointer(&units[0])), 5); s != "hello" {
+		t.Fatalf("GoStringWN: got %q, want %q", s, "hello")
+	}
+
+	withNUL := append(utf16.Encode([]rune("ab")), 0, 'c', 'd')
+	if s := GoStringWN(uintptr(unsafe.Pointer(&withNUL[0])), len(withNUL)); s != "ab" {
+		t.Fatalf("GoStringWN: got %q, want %q (should stop at NUL)", s, "ab")
+	}
+}
+
+func TestGoBytes(t *testing.T) {
+	if b := GoBytes(0, 4); b != nil {
+		t.Fatalf("GoBytes(0, 4): got %v, want nil", b)
+	}
+	src := []byte{1, 2, 3, 4}
+	got := GoBytes(uintptr(unsafe.Pointer(&src[0])), len(src))
+	if !bytes.Equal(got, src) {
+		t.Fatalf("GoBytes: got %v, want %v", got, src)
+	}
+
+	// GoBytes must copy, not alias: mutating the source must not be
+	// visible through the result.
+	src[0] = 0xff
+	if got[0] == 0xff {
+		t.Fatal("GoBytes: result aliases the source instead of copying it")
+	}
+}
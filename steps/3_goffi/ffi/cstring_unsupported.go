@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package ffi
+
+// libcPath would be the shared library CString/FreeCString (cstring.go)
+// resolve malloc/free from, once loader_unsupported.go has a real ELF
+// loader to open it with (see errLoaderUnsupported there) - Open ignores
+// the path on this platform anyway, since it can't dlopen anything yet.
+const libcPath = "libc.so.6"
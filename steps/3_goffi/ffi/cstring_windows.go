@@ -0,0 +1,7 @@
+package ffi
+
+// libcPath is the DLL CString/FreeCString (cstring.go) resolve
+// malloc/free from. The Universal CRT DLL forwards to whichever
+// version-specific ucrtbase the OS has installed, the same DLL a
+// cgo-built Windows binary already links malloc/free from by default.
+const libcPath = "api-ms-win-crt-heap-l1-1-0.dll"
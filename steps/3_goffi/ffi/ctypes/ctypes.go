@@ -0,0 +1,22 @@
+// Package ctypes defines Go types matching the C types whose width
+// actually varies by platform, so an args struct passed to ffi.MakeSpec
+// can name a field's C type once and get the right size everywhere this
+// package builds for, instead of the caller having to know which data
+// model - LP64 on Linux/Darwin, LLP64 on Windows, ILP32 on a 32 bit
+// target - applies to the platform it's building for.
+//
+// Each type is a plain defined integer type, so ffi's own
+// reflect.Kind()-based field classification (see fieldToOffset in
+// package ffi) already places it correctly with no extra cases: a
+// CLong built on Windows has Kind() Int32, the same as any other int32
+// field, and fieldToOffset already knows what to do with that.
+//
+// CLong, CULong, CSizeT, CSSizeT, COff and CTime are declared in the
+// per-data-model files (ctypes_lp64.go, ctypes_ilp32.go,
+// ctypes_windows.go); only CInt is here, since plain C int is 32 bits on
+// every platform this package builds for.
+package ctypes
+
+// CInt is C's int - 32 bits on every platform ffi supports, LP64, LLP64
+// and ILP32 alike.
+type CInt int32
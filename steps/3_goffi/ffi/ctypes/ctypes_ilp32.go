@@ -0,0 +1,22 @@
+//go:build linux && 386
+
+package ctypes
+
+// CLong and CULong are C's long/unsigned long under the ILP32 data
+// model a 32 bit Linux target uses: 32 bits, the same width as a
+// pointer here.
+type CLong int32
+type CULong uint32
+
+// CSizeT and CSSizeT are C's size_t/ssize_t: always pointer-width, which
+// is 32 bits here.
+type CSizeT uint32
+type CSSizeT int32
+
+// COff is C's off_t: 32 bits, matching the default (non-largefile)
+// off_t a 32 bit Linux target uses.
+type COff int32
+
+// CTime is C's time_t: 32 bits, matching the traditional 32 bit time_t
+// a 32 bit Linux target still uses by default.
+type CTime int32
@@ -0,0 +1,22 @@
+//go:build (linux || darwin) && (amd64 || arm64)
+
+package ctypes
+
+// CLong and CULong are C's long/unsigned long under the LP64 data model
+// Linux and Darwin use on amd64 and arm64: 64 bits, the same width as a
+// pointer.
+type CLong int64
+type CULong uint64
+
+// CSizeT and CSSizeT are C's size_t/ssize_t: always pointer-width, which
+// is 64 bits here.
+type CSizeT uint64
+type CSSizeT int64
+
+// COff is C's off_t. Both Linux (with the now-default 64 bit file
+// offsets) and Darwin use a 64 bit off_t on these architectures.
+type COff int64
+
+// CTime is C's time_t: 64 bits on both Linux and Darwin at this word
+// size, avoiding the year-2038 rollover a 32 bit time_t has.
+type CTime int64
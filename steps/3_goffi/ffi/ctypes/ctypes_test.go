@@ -0,0 +1,66 @@
+package ctypes
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+// TestSizes confirms each type's width matches the data model of the
+// platform running the test: LP64 on Linux/Darwin at 64 bit, ILP32 on
+// Linux/386, LLP64 on Windows.
+func TestSizes(t *testing.T) {
+	if got := unsafe.Sizeof(CInt(0)); got != 4 {
+		t.Fatalf("sizeof(CInt) = %d, want 4", got)
+	}
+
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	if got := unsafe.Sizeof(CSizeT(0)); got != ptrSize {
+		t.Fatalf("sizeof(CSizeT) = %d, want %d (pointer width)", got, ptrSize)
+	}
+	if got := unsafe.Sizeof(CSSizeT(0)); got != ptrSize {
+		t.Fatalf("sizeof(CSSizeT) = %d, want %d (pointer width)", got, ptrSize)
+	}
+
+	longSize := ptrSize
+	if runtime.GOOS == "windows" {
+		longSize = 4
+	}
+	if got := unsafe.Sizeof(CLong(0)); got != longSize {
+		t.Fatalf("sizeof(CLong) = %d, want %d", got, longSize)
+	}
+	if got := unsafe.Sizeof(CULong(0)); got != longSize {
+		t.Fatalf("sizeof(CULong) = %d, want %d", got, longSize)
+	}
+}
+
+// TestKindsFallThroughToExistingClassification confirms every type here
+// has an underlying reflect.Kind fieldToOffset (package ffi) already
+// knows how to classify - a named integer type reports the same Kind()
+// as its underlying predeclared type, so no new case was needed there.
+func TestKindsFallThroughToExistingClassification(t *testing.T) {
+	cases := []struct {
+		name string
+		kind reflect.Kind
+	}{
+		{"CInt", reflect.TypeOf(CInt(0)).Kind()},
+		{"CLong", reflect.TypeOf(CLong(0)).Kind()},
+		{"CULong", reflect.TypeOf(CULong(0)).Kind()},
+		{"CSizeT", reflect.TypeOf(CSizeT(0)).Kind()},
+		{"CSSizeT", reflect.TypeOf(CSSizeT(0)).Kind()},
+		{"COff", reflect.TypeOf(COff(0)).Kind()},
+		{"CTime", reflect.TypeOf(CTime(0)).Kind()},
+	}
+	supported := map[reflect.Kind]bool{
+		reflect.Int8: true, reflect.Uint8: true,
+		reflect.Int16: true, reflect.Uint16: true,
+		reflect.Int32: true, reflect.Uint32: true,
+		reflect.Int64: true, reflect.Uint64: true,
+	}
+	for _, c := range cases {
+		if !supported[c.kind] {
+			t.Fatalf("%s: Kind() = %v, not one fieldToOffset classifies", c.name, c.kind)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package ctypes
+
+// CLong and CULong are C's long/unsigned long under the LLP64 data
+// model Windows uses: 32 bits even on 64 bit Windows, unlike Unix's
+// LP64 where long matches pointer width.
+type CLong int32
+type CULong uint32
+
+// CSizeT and CSSizeT are C's size_t/ssize_t: always pointer-width,
+// which is 64 bits on the windows/amd64 this package builds for.
+type CSizeT uint64
+type CSSizeT int64
+
+// COff is C's off_t. The Universal CRT's off_t is the 64 bit
+// _off_t (aliasing __int64), matching _off64_t, not MSVCRT's historical
+// 32 bit one.
+type COff int64
+
+// CTime is C's time_t: the Universal CRT defaults time_t to the 64 bit
+// __time64_t, not the legacy 32 bit one.
+type CTime int64
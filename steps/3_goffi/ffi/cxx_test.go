@@ -0,0 +1,56 @@
+package ffi
+
+import "testing"
+
+// cxxCases are signature/mangled pairs verified against real g++ output
+// for the simple subset mangleItanium and Demangle support.
+var cxxCases = []struct {
+	sig     string
+	mangled string
+}{
+	{"bar(int)", "_Z3bari"},
+	{"foo::bar(int)", "_ZN3foo3barEi"},
+	{"foo::bar()", "_ZN3foo3barEv"},
+	{"frobnicate(int, int)", "_Z10frobnicateii"},
+	{"foo::bar(const char*)", "_ZN3foo3barEPKc"},
+	{"foo::bar(foo::bar)", "_ZN3foo3barES0_"},
+	{"a::b::c(a::b::c)", "_ZN1a1b1cES1_"},
+}
+
+func TestMangleItanium(t *testing.T) {
+	for _, c := range cxxCases {
+		got, err := mangleItanium(c.sig)
+		if err != nil {
+			t.Errorf("mangleItanium(%q): unexpected error: %v", c.sig, err)
+			continue
+		}
+		if got != c.mangled {
+			t.Errorf("mangleItanium(%q) = %q, want %q", c.sig, got, c.mangled)
+		}
+	}
+}
+
+func TestDemangle(t *testing.T) {
+	for _, c := range cxxCases {
+		got, err := Demangle(c.mangled)
+		if err != nil {
+			t.Errorf("Demangle(%q): unexpected error: %v", c.mangled, err)
+			continue
+		}
+		if got != c.sig {
+			t.Errorf("Demangle(%q) = %q, want %q", c.mangled, got, c.sig)
+		}
+	}
+}
+
+func TestMangleItaniumRejectsTemplates(t *testing.T) {
+	if _, err := mangleItanium("foo::bar<int>(int)"); err == nil {
+		t.Error("mangleItanium: expected an error for a templated name, got nil")
+	}
+}
+
+func TestDemangleRejectsNonItanium(t *testing.T) {
+	if _, err := Demangle("bar"); err == nil {
+		t.Error("Demangle: expected an error for a non-_Z-prefixed input, got nil")
+	}
+}
@@ -0,0 +1,253 @@
+package ffi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cxxBuiltinDecode is cxxBuiltins' inverse: one canonical human spelling
+// per Itanium builtin-type letter, used by Demangle. Several of
+// cxxBuiltins' keys (e.g. "short" and "short int") share a code; Demangle
+// always produces the shorter spelling.
+var cxxBuiltinDecode = map[byte]string{
+	'v': "void",
+	'b': "bool",
+	'c': "char",
+	'a': "signed char",
+	'h': "unsigned char",
+	's': "short",
+	't': "unsigned short",
+	'i': "int",
+	'j': "unsigned int",
+	'l': "long",
+	'm': "unsigned long",
+	'x': "long long",
+	'y': "unsigned long long",
+	'f': "float",
+	'd': "double",
+	'e': "long double",
+	'w': "wchar_t",
+}
+
+// demangler parses a single Itanium-mangled name, tracking the same
+// substitution table mangler builds up on the encode side (see
+// cxxmangle.go) so that an Sn_ backreference resolves to whatever
+// component was registered at index n.
+type demangler struct {
+	s    string
+	pos  int
+	subs []string
+}
+
+func (d *demangler) register(value string) {
+	d.subs = append(d.subs, value)
+}
+
+func (d *demangler) eof() bool {
+	return d.pos >= len(d.s)
+}
+
+func (d *demangler) peek() byte {
+	if d.eof() {
+		return 0
+	}
+	return d.s[d.pos]
+}
+
+// parseSeqID parses the digits (if any) and trailing "_" of an Sn_
+// token, d.pos already past the leading "S", and returns the 0-based
+// substitution index cxxSeqID(n) would have produced for it.
+func (d *demangler) parseSeqID() (int, error) {
+	start := d.pos
+	for !d.eof() && d.peek() != '_' {
+		d.pos++
+	}
+	if d.eof() {
+		return 0, fmt.Errorf("ffi: Demangle: %q: unterminated substitution", d.s)
+	}
+	digits := d.s[start:d.pos]
+	d.pos++ // consume "_"
+
+	if digits == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(digits, 36, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffi: Demangle: %q: malformed substitution %q: %w", d.s, digits, err)
+	}
+	return int(n) + 1, nil
+}
+
+// parseSubstitution parses an Sn_ reference, d.pos at the leading "S",
+// and returns the human-readable value it refers to.
+func (d *demangler) parseSubstitution() (string, error) {
+	d.pos++ // consume "S"
+	n, err := d.parseSeqID()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || n >= len(d.subs) {
+		return "", fmt.Errorf("ffi: Demangle: %q: substitution S%s_ out of range", d.s, d.s[:d.pos])
+	}
+	return d.subs[n], nil
+}
+
+// parseIdentifier parses a <source-name> (a decimal length followed by
+// that many bytes), d.pos at the leading digit.
+func (d *demangler) parseIdentifier() (string, error) {
+	start := d.pos
+	for !d.eof() && d.peek() >= '0' && d.peek() <= '9' {
+		d.pos++
+	}
+	if d.pos == start {
+		return "", fmt.Errorf("ffi: Demangle: %q: expected a length-prefixed name at offset %d", d.s, start)
+	}
+	n, err := strconv.Atoi(d.s[start:d.pos])
+	if err != nil {
+		return "", fmt.Errorf("ffi: Demangle: %q: malformed name length: %w", d.s, err)
+	}
+	if d.pos+n > len(d.s) {
+		return "", fmt.Errorf("ffi: Demangle: %q: name length %d runs past end of input", d.s, n)
+	}
+	name := d.s[d.pos : d.pos+n]
+	d.pos += n
+	return name, nil
+}
+
+// parseEncodingName parses the <name> of the mangled encoding: either a
+// single <source-name> (an unqualified free function) or an
+// N<source-name>+E <nested-name> ("::"-qualified), registering every
+// leading prefix along the way exactly as mangleItanium's encodeName
+// does, so later Sn_ references inside the parameter list resolve.
+func (d *demangler) parseEncodingName() (string, error) {
+	if d.peek() != 'N' {
+		name, err := d.parseIdentifier()
+		if err != nil {
+			return "", err
+		}
+		d.register(name)
+		return name, nil
+	}
+	d.pos++ // consume "N"
+
+	var parts []string
+	prefix := ""
+	for d.peek() != 'E' {
+		if d.eof() {
+			return "", fmt.Errorf("ffi: Demangle: %q: unterminated nested-name", d.s)
+		}
+		part, err := d.parseIdentifier()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+		if prefix == "" {
+			prefix = part
+		} else {
+			prefix = prefix + "::" + part
+		}
+		if d.peek() != 'E' {
+			d.register(prefix)
+		}
+	}
+	d.pos++ // consume "E"
+	name := strings.Join(parts, "::")
+	d.register(name)
+	return name, nil
+}
+
+// parseType parses a single <type>: a substitution, a builtin, a
+// cv-qualifier/pointer/reference wrapper, or a (possibly qualified)
+// class/struct name - the same grammar subset encodeType emits.
+func (d *demangler) parseType() (string, error) {
+	if d.eof() {
+		return "", fmt.Errorf("ffi: Demangle: %q: expected a type, got end of input", d.s)
+	}
+
+	switch c := d.peek(); c {
+	case 'S':
+		return d.parseSubstitution()
+	case 'K':
+		d.pos++
+		inner, err := d.parseType()
+		if err != nil {
+			return "", err
+		}
+		value := "const " + inner
+		d.register(value)
+		return value, nil
+	case 'P':
+		d.pos++
+		inner, err := d.parseType()
+		if err != nil {
+			return "", err
+		}
+		value := inner + "*"
+		d.register(value)
+		return value, nil
+	case 'R':
+		d.pos++
+		inner, err := d.parseType()
+		if err != nil {
+			return "", err
+		}
+		value := inner + "&"
+		d.register(value)
+		return value, nil
+	case 'N':
+		return d.parseEncodingName()
+	default:
+		if builtin, ok := cxxBuiltinDecode[c]; ok {
+			d.pos++
+			return builtin, nil
+		}
+		name, err := d.parseIdentifier()
+		if err != nil {
+			return "", err
+		}
+		d.register(name)
+		return name, nil
+	}
+}
+
+// Demangle parses mangled, an Itanium C++ ABI linker symbol for an
+// extern "C++" free function (the same form mangleItanium produces),
+// back into its human-readable signature, e.g. "_ZN3foo3barEi" back to
+// "foo::bar(int)". It supports exactly the grammar subset mangleItanium
+// emits - see its doc comment for the exact boundary - and returns an
+// error for anything past that (templates, member functions, arrays,
+// and so on), rather than guessing.
+func Demangle(mangled string) (string, error) {
+	if !strings.HasPrefix(mangled, "_Z") {
+		return "", fmt.Errorf("ffi: Demangle: %q does not start with the Itanium _Z prefix", mangled)
+	}
+	d := &demangler{s: mangled, pos: 2}
+
+	name, err := d.parseEncodingName()
+	if err != nil {
+		return "", fmt.Errorf("ffi: Demangle: %q: %w", mangled, err)
+	}
+
+	var params []string
+	if d.eof() {
+		return "", fmt.Errorf("ffi: Demangle: %q: missing parameter list", mangled)
+	}
+	if d.peek() == 'v' {
+		d.pos++
+	} else {
+		for !d.eof() {
+			p, err := d.parseType()
+			if err != nil {
+				return "", fmt.Errorf("ffi: Demangle: %q: %w", mangled, err)
+			}
+			params = append(params, p)
+		}
+	}
+
+	if !d.eof() {
+		return "", fmt.Errorf("ffi: Demangle: %q: unexpected trailing data %q", mangled, d.s[d.pos:])
+	}
+
+	return fmt.Sprintf("%s(%s)", name, strings.Join(params, ", ")), nil
+}
@@ -0,0 +1,250 @@
+package ffi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cxxBuiltins maps the C++ fundamental type spellings mangleItanium
+// accepts to their Itanium C++ ABI ("Itanium mangling", section 5.1.1)
+// one-letter builtin-type encoding. Builtin types are never
+// substitutable, unlike every other <type> production here.
+var cxxBuiltins = map[string]string{
+	"void":                   "v",
+	"bool":                   "b",
+	"char":                   "c",
+	"signed char":            "a",
+	"unsigned char":          "h",
+	"short":                  "s",
+	"short int":              "s",
+	"unsigned short":         "t",
+	"unsigned short int":     "t",
+	"int":                    "i",
+	"unsigned":               "j",
+	"unsigned int":           "j",
+	"long":                   "l",
+	"long int":               "l",
+	"unsigned long":          "m",
+	"unsigned long int":      "m",
+	"long long":              "x",
+	"long long int":          "x",
+	"unsigned long long":     "y",
+	"unsigned long long int": "y",
+	"float":                  "f",
+	"double":                 "d",
+	"long double":            "e",
+	"wchar_t":                "w",
+}
+
+// cxxSeqID renders n (0-based: 0 is the first substitution) as an
+// Itanium <seq-id> substitution token - "S_" for n==0, "S0_" for n==1,
+// "S1_".."S9_" for n==2..10, "SA_" for n==11, and so on in base36 using
+// 0-9 then A-Z, per the ABI's <substitution> production.
+func cxxSeqID(n int) string {
+	if n == 0 {
+		return "S_"
+	}
+	m := n - 1
+	const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	if m == 0 {
+		return "S0_"
+	}
+	var b []byte
+	for m > 0 {
+		b = append([]byte{digits[m%36]}, b...)
+		m /= 36
+	}
+	return "S" + string(b) + "_"
+}
+
+// mangler accumulates the substitution table a single mangleItanium call
+// builds up as it goes, mirroring what a real Itanium-ABI-conforming
+// compiler does: every <prefix> (a namespace/class nested-name component
+// chain) and every non-builtin <type> it emits becomes available for a
+// later identical component to reference as Sn_ instead of repeating it.
+// subs is keyed by an arbitrary identity string per node - the node's
+// source spelling for names/prefixes, or its own freshly built encoding
+// for a cv/pointer/reference wrapper - so two calls with the same key
+// are, by construction, the same node.
+type mangler struct {
+	subs []string
+}
+
+func (m *mangler) substitutionRef(key string) (string, bool) {
+	for i, s := range m.subs {
+		if s == key {
+			return cxxSeqID(i), true
+		}
+	}
+	return "", false
+}
+
+// wrap mangles a cv-qualified/pointer/reference layer around inner,
+// substituting the whole layer if kind+inner has already been emitted.
+func (m *mangler) wrap(kind byte, inner string) string {
+	key := string(kind) + inner
+	if ref, ok := m.substitutionRef(key); ok {
+		return ref
+	}
+	m.subs = append(m.subs, key)
+	return key
+}
+
+// encodeName mangles name, which is either a plain identifier (a class
+// or struct name with no enclosing namespace) or a "::"-qualified one,
+// substituting the whole name if it - or, for a qualified name, any of
+// its leading "::"-separated prefixes - has already been emitted.
+func (m *mangler) encodeName(name string) (string, error) {
+	if ref, ok := m.substitutionRef(name); ok {
+		return ref, nil
+	}
+
+	var enc string
+	if strings.Contains(name, "::") {
+		parts := strings.Split(name, "::")
+		var b strings.Builder
+		b.WriteString("N")
+		prefix := ""
+		for i, p := range parts {
+			if p == "" {
+				return "", fmt.Errorf("ffi: mangleItanium: %q has an empty name component", name)
+			}
+			if strings.ContainsAny(p, "<>()") {
+				return "", fmt.Errorf("ffi: mangleItanium: %q: templates and operators are not supported", name)
+			}
+			b.WriteString(fmt.Sprintf("%d%s", len(p), p))
+			if prefix == "" {
+				prefix = p
+			} else {
+				prefix += "::" + p
+			}
+			if i < len(parts)-1 {
+				if _, ok := m.substitutionRef(prefix); !ok {
+					m.subs = append(m.subs, prefix)
+				}
+			}
+		}
+		b.WriteString("E")
+		enc = b.String()
+	} else {
+		if strings.ContainsAny(name, "<>()") {
+			return "", fmt.Errorf("ffi: mangleItanium: %q: templates and operators are not supported", name)
+		}
+		enc = fmt.Sprintf("%d%s", len(name), name)
+	}
+
+	m.subs = append(m.subs, name)
+	return enc, nil
+}
+
+// encodeType mangles spec, a parameter type spelled the way mangleItanium
+// accepts: an optional leading "const ", a base type (one of cxxBuiltins,
+// a plain class name, or a "::"-qualified one), and a trailing run of "*"
+// and "&" applied left to right as written - "int*&" is a reference to a
+// pointer to int, not the other way around. A const pointer ("T* const")
+// or a qualifier on anything but the outermost base type is not
+// supported; this covers the simple signatures GetCxx is documented for.
+func (m *mangler) encodeType(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+
+	isConst := false
+	if strings.HasPrefix(spec, "const ") {
+		isConst = true
+		spec = strings.TrimSpace(strings.TrimPrefix(spec, "const "))
+	}
+
+	var suffixes []byte
+	for len(spec) > 0 {
+		c := spec[len(spec)-1]
+		if c != '*' && c != '&' {
+			break
+		}
+		if c == '*' {
+			suffixes = append(suffixes, 'P')
+		} else {
+			suffixes = append(suffixes, 'R')
+		}
+		spec = strings.TrimSpace(spec[:len(spec)-1])
+	}
+	for i, j := 0, len(suffixes)-1; i < j; i, j = i+1, j-1 {
+		suffixes[i], suffixes[j] = suffixes[j], suffixes[i]
+	}
+
+	if spec == "" {
+		return "", fmt.Errorf("ffi: mangleItanium: empty type in parameter list")
+	}
+
+	var enc string
+	if builtin, ok := cxxBuiltins[spec]; ok {
+		enc = builtin
+	} else {
+		named, err := m.encodeName(spec)
+		if err != nil {
+			return "", err
+		}
+		enc = named
+	}
+
+	if isConst {
+		enc = m.wrap('K', enc)
+	}
+	for _, kind := range suffixes {
+		enc = m.wrap(kind, enc)
+	}
+	return enc, nil
+}
+
+// mangleItanium mangles sig, a C++ declaration of the form
+// "ns1::ns2::name(type1, type2, ...)", into its Itanium C++ ABI linker
+// symbol - the same name a conforming compiler (GCC, Clang) would emit
+// for an extern "C++" free function with that signature. It supports
+// plain and "::"-qualified function names, the fundamental types listed
+// in cxxBuiltins, pointers, references, top-level const, and ABI
+// substitution compression (required for the mangled name to match real
+// compiler output whenever a type or namespace prefix repeats) - not
+// templates, overloaded operators, arrays, or member-function
+// qualifiers (this-cv, ref-qualifiers). See Library.GetCxx.
+func mangleItanium(sig string) (string, error) {
+	sig = strings.TrimSpace(sig)
+	open := strings.IndexByte(sig, '(')
+	if open < 0 || !strings.HasSuffix(sig, ")") {
+		return "", fmt.Errorf("ffi: mangleItanium: %q is not of the form \"name(type, ...)\"", sig)
+	}
+
+	qualified := strings.TrimSpace(sig[:open])
+	if qualified == "" {
+		return "", fmt.Errorf("ffi: mangleItanium: %q has no function name", sig)
+	}
+	paramsStr := strings.TrimSpace(sig[open+1 : len(sig)-1])
+
+	var params []string
+	if paramsStr != "" {
+		for _, p := range strings.Split(paramsStr, ",") {
+			params = append(params, strings.TrimSpace(p))
+		}
+	}
+
+	m := &mangler{}
+	var b strings.Builder
+	b.WriteString("_Z")
+
+	name, err := m.encodeName(qualified)
+	if err != nil {
+		return "", fmt.Errorf("ffi: mangleItanium: %q: %w", sig, err)
+	}
+	b.WriteString(name)
+
+	if len(params) == 0 {
+		b.WriteString("v")
+	} else {
+		for _, p := range params {
+			enc, err := m.encodeType(p)
+			if err != nil {
+				return "", fmt.Errorf("ffi: mangleItanium: %q: %w", sig, err)
+			}
+			b.WriteString(enc)
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,121 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// arMagic is the fixed 8-byte signature every System V/GNU ar(1) archive
+// - the .a format a real ld/ar produces, and the one a static archive
+// request would need to read - starts with.
+const arMagic = "!<arch>\n"
+
+// arHeaderSize is the fixed size of one ar(1) member header: a 16-byte
+// name, 12-byte mtime, 6-byte uid, 6-byte gid, 8-byte mode, 10-byte size
+// (all ASCII, space-padded), and a 2-byte "`\n" terminator - 60 bytes
+// before the member's own data begins.
+const arHeaderSize = 60
+
+// errNotArArchive is parseArMembers' error for data that doesn't start
+// with arMagic.
+var errNotArArchive = errors.New("ffi: not an ar archive")
+
+// ArMember is one object file's entry in a static archive's member
+// table, as parseArMembers reports it: enough to locate and size its
+// raw data within the archive, but not yet anything about the object
+// file's own contents.
+type ArMember struct {
+	// Name is the member's file name, with any GNU extended-name-table
+	// indirection (see parseArMembers) already resolved, and the
+	// trailing "/" ar(1) pads short names with already stripped.
+	Name string
+	// Offset is data's file offset within the archive, immediately
+	// after this member's own 60-byte header.
+	Offset int64
+	// Size is data's length in bytes, excluding the single padding byte
+	// ar(1) inserts after an odd-sized member to keep every header
+	// 2-byte aligned.
+	Size int64
+}
+
+// parseArMembers walks data's ar(1) member table, the first piece
+// linking selected objects out of a static archive (synth-148's own
+// request) would need: finding which named member to extract before
+// there's anything to resolve relocations, allocate bss, or run ctors
+// in. It returns every member's Name/Offset/Size without copying any
+// member's data out - the caller slices data[m.Offset:m.Offset+m.Size]
+// itself once it actually wants one.
+//
+// The two ar(1) housekeeping members real archives carry - "/" (the
+// System V symbol table, mapping exported symbol names to member
+// offsets) and "//" (GNU's extended name table, for a name too long for
+// the 16-byte fixed field) - are consumed here rather than returned:
+// "//"'s contents resolve any "/<offset>" indirect name into the real
+// one before this function returns, and "/" is skipped outright, since
+// nothing here does symbol-to-member lookup yet (a real loader wanting
+// "pull in whichever member defines symbol X" - rather than "pull in
+// the member named X" - will need to parse it properly instead).
+//
+// What happens once a member is actually extracted - relocating its
+// symbol references against whatever else got linked in, allocating its
+// bss, and running any of its ctors - needs a relocatable-object loader
+// this package doesn't have (the same real-ELF-loader gap
+// loader_unsupported.go's errLoaderUnsupported documents for a shared
+// object's own relocations); parseArMembers only gets as far as the
+// member table itself.
+func parseArMembers(data []byte) ([]ArMember, error) {
+	if len(data) < len(arMagic) || string(data[:len(arMagic)]) != arMagic {
+		return nil, errNotArArchive
+	}
+
+	var extNames string
+	var members []ArMember
+	off := int64(len(arMagic))
+	for off+arHeaderSize <= int64(len(data)) {
+		hdr := data[off : off+arHeaderSize]
+		name := strings.TrimRight(string(hdr[0:16]), " ")
+		sizeStr := strings.TrimSpace(string(hdr[48:58]))
+		size, err := strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			return nil, errors.New("ffi: malformed ar(1) member size: " + sizeStr)
+		}
+		dataOff := off + arHeaderSize
+
+		switch {
+		case name == "//":
+			extNames = string(data[dataOff : dataOff+size])
+		case name == "/":
+			// the System V symbol table; no symbol-to-member lookup yet
+		case strings.HasPrefix(name, "/"):
+			idx, err := strconv.Atoi(name[1:])
+			if err != nil {
+				return nil, errors.New("ffi: malformed ar(1) extended name reference: " + name)
+			}
+			members = append(members, ArMember{Name: extractArName(extNames, idx), Offset: dataOff, Size: size})
+		default:
+			members = append(members, ArMember{Name: strings.TrimSuffix(name, "/"), Offset: dataOff, Size: size})
+		}
+
+		off = dataOff + size
+		if size%2 != 0 {
+			off++ // the padding byte an odd-sized member gets
+		}
+	}
+	return members, nil
+}
+
+// extractArName reads the NUL/newline-terminated name starting at idx
+// within the GNU extended name table "//" already handed parseArMembers.
+func extractArName(table string, idx int) string {
+	if idx < 0 || idx >= len(table) {
+		return ""
+	}
+	end := strings.IndexByte(table[idx:], '\n')
+	if end < 0 {
+		return strings.TrimRight(table[idx:], "/")
+	}
+	return strings.TrimRight(table[idx:idx+end], "/")
+}
@@ -0,0 +1,77 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// TestParseArMembersRejectsNonArchive confirms parseArMembers reports
+// errNotArArchive for data that doesn't start with arMagic, rather than
+// reading arHeaderSize bytes past the end of something much shorter.
+func TestParseArMembersRejectsNonArchive(t *testing.T) {
+	if _, err := parseArMembers([]byte("not an archive")); err != errNotArArchive {
+		t.Fatalf("parseArMembers: got err=%v, want errNotArArchive", err)
+	}
+}
+
+// buildTestArchive assembles a minimal ar(1) archive by hand: magic,
+// then one header per (name, data) pair, each size-ASCII-encoded and
+// right-padded the way System V ar(1) itself pads a short name with "/"
+// and a short numeric field with spaces.
+func buildTestArchive(members [][2]string) []byte {
+	var out []byte
+	out = append(out, arMagic...)
+	for _, m := range members {
+		name, data := m[0], m[1]
+		hdr := make([]byte, arHeaderSize)
+		for i := range hdr {
+			hdr[i] = ' '
+		}
+		copy(hdr[0:16], name+"/")
+		sizeStr := itoaPad(len(data))
+		copy(hdr[48:58], sizeStr)
+		hdr[58] = '`'
+		hdr[59] = '\n'
+		out = append(out, hdr...)
+		out = append(out, data...)
+		if len(data)%2 != 0 {
+			out = append(out, '\n')
+		}
+	}
+	return out
+}
+
+func itoaPad(n int) string {
+	s := ""
+	for n > 0 {
+		s = string(rune('0'+n%10)) + s
+		n /= 10
+	}
+	if s == "" {
+		s = "0"
+	}
+	return s
+}
+
+// TestParseArMembersShortNames confirms parseArMembers reports every
+// ordinary (non-extended-name) member's name, offset and size correctly,
+// in archive order.
+func TestParseArMembersShortNames(t *testing.T) {
+	data := buildTestArchive([][2]string{
+		{"a.o", "hello"},
+		{"b.o", "worldly"}, // odd length, exercises the padding byte
+	})
+
+	members, err := parseArMembers(data)
+	if err != nil {
+		t.Fatalf("parseArMembers: unexpected error %v", err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("parseArMembers: got %d members, want 2", len(members))
+	}
+	if members[0].Name != "a.o" || string(data[members[0].Offset:members[0].Offset+members[0].Size]) != "hello" {
+		t.Errorf("member 0: got %+v, data %q", members[0], data[members[0].Offset:members[0].Offset+members[0].Size])
+	}
+	if members[1].Name != "b.o" || string(data[members[1].Offset:members[1].Offset+members[1].Size]) != "worldly" {
+		t.Errorf("member 1: got %+v, data %q", members[1], data[members[1].Offset:members[1].Offset+members[1].Size])
+	}
+}
@@ -0,0 +1,59 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ldDebugCategories holds the set of categories LD_DEBUG named, parsed
+// once at startup - comma-separated category names like "libs", "reloc",
+// and "symbols" (or "all" for every category this package recognizes),
+// the same shape glibc's own ld.so parses its LD_DEBUG into - so
+// whichever debug print a category gates can be turned on independently
+// of the others instead of printing everything whenever LD_DEBUG is set
+// to anything at all. Like pointerCheckEnabled's GODEBUG setting
+// (pointercheck.go), it's read once and can't be toggled once the
+// program has started.
+var ldDebugCategories map[string]bool
+
+func init() {
+	ldDebugCategories = parseLdDebug(os.Getenv("LD_DEBUG"))
+}
+
+// parseLdDebug splits an LD_DEBUG value into the set of category names
+// it named, ignoring empty entries (an unset or empty LD_DEBUG yields an
+// empty set, rather than a set containing "").
+func parseLdDebug(val string) map[string]bool {
+	cats := make(map[string]bool)
+	for _, c := range strings.Split(val, ",") {
+		c = strings.TrimSpace(c)
+		if c != "" {
+			cats[c] = true
+		}
+	}
+	return cats
+}
+
+// ldDebugEnabled reports whether category should print under the
+// currently-parsed LD_DEBUG value, honoring "all" as glibc's own ld.so
+// does.
+func ldDebugEnabled(category string) bool {
+	return ldDebugCategories["all"] || ldDebugCategories[category]
+}
+
+// ldDebugf prints msg, formatted like fmt.Sprintf, to stderr if category
+// is enabled under LD_DEBUG - mirroring glibc's own "<category>:
+// <message>" line shape. It's meant for whoever eventually wires a real
+// loader's segment-mapping, relocation-application, and symbol-
+// resolution decisions through it to call at each such decision point;
+// there is no such loader here yet (see errLoaderUnsupported in
+// loader_unsupported.go), so nothing calls ldDebugf today.
+func ldDebugf(category, format string, args ...interface{}) {
+	if !ldDebugEnabled(category) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s\n", category, fmt.Sprintf(format, args...))
+}
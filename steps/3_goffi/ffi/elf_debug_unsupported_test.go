@@ -0,0 +1,47 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// TestParseLdDebugEmpty confirms an empty LD_DEBUG value parses to an
+// empty set rather than one containing the empty string.
+func TestParseLdDebugEmpty(t *testing.T) {
+	if cats := parseLdDebug(""); len(cats) != 0 {
+		t.Fatalf("parseLdDebug(\"\") = %v, want empty", cats)
+	}
+}
+
+// TestParseLdDebugCategories confirms comma-separated categories are
+// each recorded, with surrounding whitespace trimmed.
+func TestParseLdDebugCategories(t *testing.T) {
+	cats := parseLdDebug("libs, reloc,symbols")
+	for _, want := range []string{"libs", "reloc", "symbols"} {
+		if !cats[want] {
+			t.Errorf("parseLdDebug: missing category %q in %v", want, cats)
+		}
+	}
+	if len(cats) != 3 {
+		t.Fatalf("parseLdDebug: got %v, want exactly 3 categories", cats)
+	}
+}
+
+// TestLdDebugEnabled checks ldDebugEnabled against a category set
+// directly, including the "all" category's effect on every other name.
+func TestLdDebugEnabled(t *testing.T) {
+	saved := ldDebugCategories
+	defer func() { ldDebugCategories = saved }()
+
+	ldDebugCategories = map[string]bool{"reloc": true}
+	if !ldDebugEnabled("reloc") {
+		t.Error(`ldDebugEnabled("reloc") = false, want true`)
+	}
+	if ldDebugEnabled("libs") {
+		t.Error(`ldDebugEnabled("libs") = true, want false`)
+	}
+
+	ldDebugCategories = map[string]bool{"all": true}
+	if !ldDebugEnabled("libs") || !ldDebugEnabled("symbols") {
+		t.Error(`ldDebugEnabled: "all" did not enable every category`)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"fmt"
+	"sync"
+)
+
+// resolveLoadOrder computes the order a loader must map and relocate a
+// set of DT_NEEDED dependencies in, given depsOf - each soname's own
+// direct DT_NEEDED list - so that every dependency is mapped before
+// anything that depends on it, and each soname appears exactly once in
+// the result even if several libraries in the graph depend on it. This
+// is the ordering problem alone: depsOf is assumed already walked out of
+// each object's .dynamic section elsewhere - there is no ELF parser here
+// yet to do that (see errLoaderUnsupported in loader_unsupported.go) -
+// and nothing here merges the resulting libraries' symbols into the
+// global namespace a real dlopen keeps once they're all loaded; both are
+// for whoever builds that parser to wire this into.
+//
+// It returns an error if root's dependency graph contains a cycle - two
+// sonames depending on each other, directly or transitively, which a
+// well-formed set of shared objects should never have, but a loader
+// walking untrusted or malformed input must still detect rather than
+// recursing forever.
+func resolveLoadOrder(root string, depsOf map[string][]string) ([]string, error) {
+	var order []string
+	visited := make(map[string]bool)
+	inStack := make(map[string]bool)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if visited[name] {
+			return nil
+		}
+		if inStack[name] {
+			return fmt.Errorf("ffi: resolveLoadOrder: dependency cycle at %q", name)
+		}
+		inStack[name] = true
+		for _, dep := range depsOf[name] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		inStack[name] = false
+		visited[name] = true
+		order = append(order, name)
+		return nil
+	}
+
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// resolveLoadLevels is resolveLoadOrder, but grouped into levels a
+// loader can map and relocate concurrently instead of one flat
+// dependency-first order: level 0 holds every soname with no
+// dependencies of its own (a graph's leaves), level 1 holds every soname
+// whose dependencies are all already accounted for by level 0, and so
+// on - the standard Kahn's-algorithm-by-layers shape, computed in one
+// pass over resolveLoadOrder's dependency-first order, each soname's
+// level being one more than its deepest dependency's. Two
+// sonames land in the same level only because neither depends on the
+// other (directly or transitively), never because they happen to
+// resolve at the same depth - so level i+1 is always safe to start only
+// after every library in level i has actually finished mapping and
+// relocating, which is the property loadDependenciesConcurrently below
+// relies on to parallelize within a level but never across one.
+//
+// It returns the same dependency-cycle error resolveLoadOrder does, with
+// the same meaning.
+func resolveLoadLevels(root string, depsOf map[string][]string) ([][]string, error) {
+	order, err := resolveLoadOrder(root, depsOf)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := make(map[string]int, len(order))
+	maxDepth := 0
+	for _, name := range order {
+		d := 0
+		for _, dep := range depsOf[name] {
+			if dd := depth[dep] + 1; dd > d {
+				d = dd
+			}
+		}
+		depth[name] = d
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+
+	levels := make([][]string, maxDepth+1)
+	for _, name := range order {
+		d := depth[name]
+		levels[d] = append(levels[d], name)
+	}
+	return levels, nil
+}
+
+// loadDependenciesConcurrently maps and relocates every soname in root's
+// dependency graph by calling loadFn once per soname (never twice, even
+// if several libraries depend on the same one), using resolveLoadLevels
+// to run every soname within a level concurrently - one goroutine each -
+// while still never starting a level before the previous one has
+// entirely finished, so loadFn for a dependency is always done before
+// loadFn for anything that needs it runs. This is what turns a large
+// GUI/ML library's often wide (not just deep) dependency graph from a
+// strictly serial cold start into one bounded by its longest dependency
+// chain instead of its total library count.
+//
+// If any loadFn call in a level fails, every other goroutine in that
+// same level is still let to finish (there's no way to cancel a loadFn
+// already in flight, and doing so partway through a real mmap/relocate
+// would leave that library in an unknown state) but no further level is
+// started; the first error encountered, in soname order within the
+// failing level, is returned.
+func loadDependenciesConcurrently(root string, depsOf map[string][]string, loadFn func(name string) error) error {
+	levels, err := resolveLoadLevels(root, depsOf)
+	if err != nil {
+		return err
+	}
+
+	for _, level := range levels {
+		errs := make([]error, len(level))
+		var wg sync.WaitGroup
+		for i, name := range level {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				errs[i] = loadFn(name)
+			}(i, name)
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				return fmt.Errorf("ffi: loadDependenciesConcurrently: %s: %w", level[i], err)
+			}
+		}
+	}
+	return nil
+}
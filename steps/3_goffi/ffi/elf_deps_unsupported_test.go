@@ -0,0 +1,203 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestResolveLoadOrderDependenciesFirst confirms a dependency always
+// comes before anything that needs it, including transitively.
+func TestResolveLoadOrderDependenciesFirst(t *testing.T) {
+	depsOf := map[string][]string{
+		"libapp.so":    {"libmiddle.so"},
+		"libmiddle.so": {"libc.so"},
+		"libc.so":      nil,
+	}
+
+	order, err := resolveLoadOrder("libapp.so", depsOf)
+	if err != nil {
+		t.Fatalf("resolveLoadOrder: unexpected error: %v", err)
+	}
+
+	want := []string{"libc.so", "libmiddle.so", "libapp.so"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("resolveLoadOrder: got %v, want %v", order, want)
+	}
+}
+
+// TestResolveLoadOrderDiamondDedups confirms a soname reachable through
+// two different paths (a diamond dependency) appears exactly once,
+// rather than once per path that reaches it.
+func TestResolveLoadOrderDiamondDedups(t *testing.T) {
+	depsOf := map[string][]string{
+		"libapp.so":    {"liba.so", "libb.so"},
+		"liba.so":      {"libshared.so"},
+		"libb.so":      {"libshared.so"},
+		"libshared.so": nil,
+	}
+
+	order, err := resolveLoadOrder("libapp.so", depsOf)
+	if err != nil {
+		t.Fatalf("resolveLoadOrder: unexpected error: %v", err)
+	}
+
+	count := 0
+	for _, name := range order {
+		if name == "libshared.so" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("resolveLoadOrder: libshared.so appeared %d times, want 1", count)
+	}
+	if order[len(order)-1] != "libapp.so" {
+		t.Fatalf("resolveLoadOrder: last entry = %q, want libapp.so", order[len(order)-1])
+	}
+}
+
+// TestResolveLoadOrderCycle confirms a dependency cycle is reported as
+// an error instead of recursing forever.
+func TestResolveLoadOrderCycle(t *testing.T) {
+	depsOf := map[string][]string{
+		"liba.so": {"libb.so"},
+		"libb.so": {"liba.so"},
+	}
+
+	if _, err := resolveLoadOrder("liba.so", depsOf); err == nil {
+		t.Fatal("resolveLoadOrder: expected an error for a dependency cycle")
+	}
+}
+
+// TestResolveLoadLevelsDiamondParallelizesSiblings confirms a diamond
+// dependency's two independent middle libraries land in the same level,
+// while its shared base and its top-level app each get their own.
+func TestResolveLoadLevelsDiamondParallelizesSiblings(t *testing.T) {
+	depsOf := map[string][]string{
+		"libapp.so":    {"liba.so", "libb.so"},
+		"liba.so":      {"libshared.so"},
+		"libb.so":      {"libshared.so"},
+		"libshared.so": nil,
+	}
+
+	levels, err := resolveLoadLevels("libapp.so", depsOf)
+	if err != nil {
+		t.Fatalf("resolveLoadLevels: unexpected error: %v", err)
+	}
+
+	want := [][]string{
+		{"libshared.so"},
+		{"liba.so", "libb.so"},
+		{"libapp.so"},
+	}
+	if len(levels) != len(want) {
+		t.Fatalf("resolveLoadLevels: got %d levels %v, want %d levels %v", len(levels), levels, len(want), want)
+	}
+	for i := range want {
+		got := append([]string{}, levels[i]...)
+		sort.Strings(got)
+		wantSorted := append([]string{}, want[i]...)
+		sort.Strings(wantSorted)
+		if !reflect.DeepEqual(got, wantSorted) {
+			t.Errorf("resolveLoadLevels: level %d = %v, want %v", i, levels[i], want[i])
+		}
+	}
+}
+
+// TestResolveLoadLevelsCycle confirms resolveLoadLevels reports the same
+// dependency-cycle error resolveLoadOrder does, rather than looping
+// forever trying to compute depths.
+func TestResolveLoadLevelsCycle(t *testing.T) {
+	depsOf := map[string][]string{
+		"liba.so": {"libb.so"},
+		"libb.so": {"liba.so"},
+	}
+	if _, err := resolveLoadLevels("liba.so", depsOf); err == nil {
+		t.Fatal("resolveLoadLevels: expected an error for a dependency cycle")
+	}
+}
+
+// TestLoadDependenciesConcurrentlyRunsSiblingsConcurrently confirms two
+// libraries in the same level are actually in flight at the same time,
+// not just both called before the level after them starts - a
+// sequential implementation calling loadFn one at a time would also
+// pass every ordering assertion below, so this test needs a real
+// concurrency signal, not just a recorded call order.
+func TestLoadDependenciesConcurrentlyRunsSiblingsConcurrently(t *testing.T) {
+	depsOf := map[string][]string{
+		"libapp.so":    {"liba.so", "libb.so"},
+		"liba.so":      {"libshared.so"},
+		"libb.so":      {"libshared.so"},
+		"libshared.so": nil,
+	}
+
+	var mu sync.Mutex
+	var finished []string
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2) // liba.so and libb.so, the level expected to run concurrently
+
+	loadFn := func(name string) error {
+		if name == "liba.so" || name == "libb.so" {
+			wg.Done()
+			<-release // blocks until both siblings have started
+		}
+		mu.Lock()
+		finished = append(finished, name)
+		mu.Unlock()
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- loadDependenciesConcurrently("libapp.so", depsOf, loadFn) }()
+
+	waitOrTimeout := make(chan struct{})
+	go func() { wg.Wait(); close(waitOrTimeout) }()
+	select {
+	case <-waitOrTimeout:
+		close(release)
+	case <-time.After(2 * time.Second):
+		t.Fatal("liba.so and libb.so were not both in flight at once - siblings ran sequentially")
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("loadDependenciesConcurrently: unexpected error: %v", err)
+	}
+
+	if len(finished) != 4 || finished[0] != "libshared.so" || finished[3] != "libapp.so" {
+		t.Fatalf("loadDependenciesConcurrently: finish order %v, want libshared.so first and libapp.so last", finished)
+	}
+}
+
+// TestLoadDependenciesConcurrentlyPropagatesError confirms a loadFn
+// failure is reported (wrapping the failing soname), and that a later
+// level is never started once an earlier one has failed.
+func TestLoadDependenciesConcurrentlyPropagatesError(t *testing.T) {
+	depsOf := map[string][]string{
+		"libapp.so": {"libbad.so"},
+		"libbad.so": nil,
+	}
+	boom := errors.New("boom")
+
+	var appLoaded bool
+	loadFn := func(name string) error {
+		if name == "libapp.so" {
+			appLoaded = true
+			return nil
+		}
+		return boom
+	}
+
+	err := loadDependenciesConcurrently("libapp.so", depsOf, loadFn)
+	if err == nil || !errors.Is(err, boom) {
+		t.Fatalf("loadDependenciesConcurrently: got %v, want an error wrapping %v", err, boom)
+	}
+	if appLoaded {
+		t.Fatal("loadDependenciesConcurrently: libapp.so's level ran despite its dependency failing")
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows && !darwin
+
+package ffi
+
+// elfHashSysV is the classic ELF "elf_hash" algorithm (the System V ABI
+// gABI's own reference implementation, also .hash section's hash
+// function): a simple rolling hash over name's bytes, with the top
+// nibble folded back in and cleared on each step so no bit's influence
+// grows unbounded across a long name. A real loader's symbol lookup
+// hashes the name it's resolving once with this (and, for an object
+// that has one instead, elfHashGNU) and compares only against .hash's
+// bucket for that hash, rather than a name-by-name scan of every symbol
+// - the reason either hash exists at all.
+func elfHashSysV(name string) uint32 {
+	var h uint32
+	for i := 0; i < len(name); i++ {
+		h = (h << 4) + uint32(name[i])
+		if g := h & 0xf0000000; g != 0 {
+			h ^= g >> 24
+			h &^= g
+		}
+	}
+	return h
+}
+
+// elfHashGNU is the GNU hash algorithm (DJB's hash, the same one used
+// for .gnu.hash sections): most modern glibc-linked shared objects carry
+// a .gnu.hash section instead of (or alongside) a classic .hash, since
+// its bucketing also lets a loader skip a whole bucket via a bloom
+// filter before even computing a single string comparison.
+func elfHashGNU(name string) uint32 {
+	h := uint32(5381)
+	for i := 0; i < len(name); i++ {
+		h = h*33 + uint32(name[i])
+	}
+	return h
+}
@@ -0,0 +1,71 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// TestElfHashSysVEmpty confirms the classic elf_hash of the empty string
+// is 0 - the loop never runs, so h never leaves its zero value.
+func TestElfHashSysVEmpty(t *testing.T) {
+	if got := elfHashSysV(""); got != 0 {
+		t.Fatalf("elfHashSysV(\"\") = %#x, want 0", got)
+	}
+}
+
+// TestElfHashSysVKnownValues checks elfHashSysV against values worked out
+// from the gABI's own elf_hash reference algorithm. None of these names
+// is long enough to set the top nibble, so the high-nibble fold never
+// runs for them ("printf" hashes to 0x77905a6).
+func TestElfHashSysVKnownValues(t *testing.T) {
+	cases := []struct {
+		name string
+		want uint32
+	}{
+		{"a", 0x61},
+		{"ab", 0x672},
+		{"printf", 0x77905a6},
+	}
+	for _, c := range cases {
+		if got := elfHashSysV(c.name); got != c.want {
+			t.Errorf("elfHashSysV(%q) = %#x, want %#x", c.name, got, c.want)
+		}
+	}
+}
+
+// TestElfHashGNUEmpty confirms the GNU hash of the empty string is the
+// algorithm's seed, 5381, since the loop that mixes in each byte never
+// runs.
+func TestElfHashGNUEmpty(t *testing.T) {
+	if got := elfHashGNU(""); got != 5381 {
+		t.Fatalf("elfHashGNU(\"\") = %d, want 5381", got)
+	}
+}
+
+// TestElfHashGNUKnownValues checks elfHashGNU against values worked out
+// by hand from the DJB hash it's defined as: h = h*33 + byte, seeded at
+// 5381.
+func TestElfHashGNUKnownValues(t *testing.T) {
+	cases := []struct {
+		name string
+		want uint32
+	}{
+		{"a", 177670},
+		{"ab", 5863208},
+	}
+	for _, c := range cases {
+		if got := elfHashGNU(c.name); got != c.want {
+			t.Errorf("elfHashGNU(%q) = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+// TestElfHashesDeterministic confirms both hashes are pure functions of
+// their input, as any hash a loader will bucket symbols by must be.
+func TestElfHashesDeterministic(t *testing.T) {
+	if elfHashSysV("printf") != elfHashSysV("printf") {
+		t.Fatal("elfHashSysV is not deterministic")
+	}
+	if elfHashGNU("printf") != elfHashGNU("printf") {
+		t.Fatal("elfHashGNU is not deterministic")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows && !darwin
+
+package ffi
+
+// elfSymType is the low nibble of an Elf64_Sym's st_info byte: what kind
+// of thing the symbol names, as opposed to st_info's high nibble (its
+// binding - STB_LOCAL/GLOBAL/WEAK), which elfSymType says nothing about.
+type elfSymType uint8
+
+// elfSymTypeGNUIFunc is STT_GNU_IFUNC, glibc's extension marking a
+// function symbol as indirect: the symbol's value isn't the function's
+// address but a resolver function's, which a loader must call (with no
+// arguments, same as elfRelocIRelative) to get the real address glibc
+// wants bound for this symbol - memcpy, strlen, and friends resolving to
+// whichever CPU-feature-tuned implementation the resolver picks at load
+// time rather than link time.
+const elfSymTypeGNUIFunc elfSymType = 10
+
+// elfSymTypeOf extracts an Elf64_Sym's type from its raw st_info byte -
+// the low nibble, per the gABI's ELF32_ST_TYPE/ELF64_ST_TYPE macros.
+func elfSymTypeOf(stInfo uint8) elfSymType {
+	return elfSymType(stInfo & 0xf)
+}
+
+// isIFuncSymbol reports whether stInfo marks an STT_GNU_IFUNC symbol -
+// one a loader must resolve by calling through it (see
+// elfRelocIRelative in elf_reloc_unsupported.go) rather than by simply
+// taking its value as the final address, the way it would for an
+// ordinary STT_FUNC.
+func isIFuncSymbol(stInfo uint8) bool {
+	return elfSymTypeOf(stInfo) == elfSymTypeGNUIFunc
+}
@@ -0,0 +1,39 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// TestElfSymTypeOfMasksLowNibble confirms elfSymTypeOf keeps only
+// st_info's low nibble, ignoring whatever binding (the high nibble) is
+// packed alongside it.
+func TestElfSymTypeOfMasksLowNibble(t *testing.T) {
+	cases := []struct {
+		stInfo uint8
+		want   elfSymType
+	}{
+		{0x00, 0},
+		{0x12, 2},
+		{0x1a, elfSymTypeGNUIFunc},
+		{0xfa, elfSymTypeGNUIFunc},
+	}
+	for _, c := range cases {
+		if got := elfSymTypeOf(c.stInfo); got != c.want {
+			t.Errorf("elfSymTypeOf(%#x) = %d, want %d", c.stInfo, got, c.want)
+		}
+	}
+}
+
+// TestIsIFuncSymbol checks isIFuncSymbol against an STT_GNU_IFUNC
+// st_info byte and a handful of ordinary symbol types that must not be
+// mistaken for one.
+func TestIsIFuncSymbol(t *testing.T) {
+	if !isIFuncSymbol(0x1a) { // STB_GLOBAL<<4 | STT_GNU_IFUNC
+		t.Error("isIFuncSymbol(0x1a) = false, want true")
+	}
+	for _, stInfo := range []uint8{0x00, 0x01, 0x02, 0x13} {
+		if isIFuncSymbol(stInfo) {
+			t.Errorf("isIFuncSymbol(%#x) = true, want false", stInfo)
+		}
+	}
+}
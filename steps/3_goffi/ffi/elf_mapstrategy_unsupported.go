@@ -0,0 +1,102 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// MapStrategy selects how the ELF loader (see errLoaderUnsupported,
+// loader_unsupported.go - there isn't one yet) should map a library's
+// PT_LOAD segments once it exists, trading process memory and mapping-
+// time cost against page-fault latency paid later, on whichever thread
+// first touches a given page. The zero value, defaultMapStrategy, is
+// plain demand-paged mmap with none of the below - the same "pay nothing
+// unless asked" default MakeSpec's CollectMetrics/profileLabel/
+// traceRegion flags (ffi_amd64.go) follow for the same reason.
+type MapStrategy struct {
+	// Populate eagerly faults every page of a segment in at map time
+	// (MAP_POPULATE) instead of leaving each page to fault in lazily on
+	// first access - the same tradeoff Preresolve's touchCodePage
+	// (preresolve.go) makes one already-resolved symbol's entry page at
+	// a time, applied here to a whole segment up front instead.
+	Populate bool
+	// Hugepages requests a segment's mapping be backed by huge pages
+	// (MAP_HUGETLB) where the platform and segment both allow it,
+	// trading TLB reach for the coarser granularity and allocation cost
+	// huge pages bring. See hugePageEligible for the size/alignment rule
+	// this is gated on; a segment that doesn't meet it falls back to an
+	// ordinary mapping regardless of this field.
+	Hugepages bool
+	// Mlock pins a segment's pages in RAM with mlock(2) right after
+	// mapping, so they can never be paged back out under memory
+	// pressure - meant for a latency-critical call path that can't
+	// tolerate a page-fault stall mid-call, at the cost of that memory
+	// never being reclaimable for the rest of the mapping's lifetime.
+	Mlock bool
+}
+
+// defaultMapStrategy is MapStrategy's zero value, spelled out for
+// callers that want to name the default explicitly the way RTLD_LOCAL
+// (loader.go) names OpenFlags' zero value.
+var defaultMapStrategy = MapStrategy{}
+
+// mapPopulate is Linux's MAP_POPULATE - this package avoids a
+// golang.org/x/sys/unix dependency for one constant, the same reasoning
+// rawsyscall_amd64.go's own raw syscall numbers follow.
+const mapPopulate = 0x8000
+
+// hugePageSize is the size (and required alignment) of an x86-64/arm64
+// default-sized huge page under Linux's transparent or explicit hugetlbfs
+// hugepage support. A platform with a different default hugepage size
+// would need its own constant here, the same way elf_rpath_unsupported.go's
+// resolveLibraryPath will eventually need a per-platform default
+// directory list.
+const hugePageSize = 2 << 20
+
+// mmapFlags returns the additional mmap(2) flags s calls for, to OR into
+// whatever MAP_PRIVATE|MAP_FIXED flags the loader's own segment-mapping
+// call already builds. This is the one piece of s applied at the mmap
+// call itself, before the mapping exists for applyMapStrategy's mlock
+// step to act on; Hugepages isn't included here since MAP_HUGETLB also
+// needs the mapping's length and file offset checked against
+// hugePageEligible first, which only the loader's own per-segment loop
+// has the context to do.
+func (s MapStrategy) mmapFlags() int {
+	if s.Populate {
+		return mapPopulate
+	}
+	return 0
+}
+
+// hugePageEligible reports whether a segment of size bytes, mapped at
+// fileOffset, can be backed by huge pages at all: MAP_HUGETLB maps in
+// whole hugePageSize units with no partial-page tail the way an ordinary
+// mapping's last page can be zero-filled past the file's own length, so
+// both size and fileOffset have to already be a whole number of huge
+// pages.
+func hugePageEligible(size, fileOffset uintptr) bool {
+	return size%hugePageSize == 0 && fileOffset%hugePageSize == 0
+}
+
+// applyMapStrategy performs s's post-mapping steps against the size
+// bytes already mapped at addr - today, only Mlock, since Populate is
+// applied at mmap time via mmapFlags and Hugepages needs a real segment-
+// mapping call site (mmap's MAP_HUGETLB flag, not a step that can be
+// retrofitted onto an existing mapping) that doesn't exist until the ELF
+// loader does. It's independent of that loader, though: addr/size can be
+// any already-mapped region, which is what makes it possible to
+// implement and test for real ahead of the loader that will end up
+// calling it per PT_LOAD segment.
+func applyMapStrategy(s MapStrategy, addr, size uintptr) error {
+	if !s.Mlock {
+		return nil
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(addr)), size)
+	if err := syscall.Mlock(b); err != nil {
+		return fmt.Errorf("ffi: mlock: %w", err)
+	}
+	return nil
+}
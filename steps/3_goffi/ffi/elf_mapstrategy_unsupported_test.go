@@ -0,0 +1,59 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// TestMapStrategyMmapFlags confirms mmapFlags reports MAP_POPULATE only
+// when Populate is set, and nothing for a default (zero-value) strategy.
+func TestMapStrategyMmapFlags(t *testing.T) {
+	if got := defaultMapStrategy.mmapFlags(); got != 0 {
+		t.Errorf("defaultMapStrategy.mmapFlags() = %#x, want 0", got)
+	}
+	if got := (MapStrategy{Populate: true}).mmapFlags(); got != mapPopulate {
+		t.Errorf("Populate.mmapFlags() = %#x, want %#x", got, mapPopulate)
+	}
+}
+
+// TestHugePageEligible checks hugePageEligible's whole-huge-page rule
+// against both an aligned and a misaligned size/offset.
+func TestHugePageEligible(t *testing.T) {
+	if !hugePageEligible(hugePageSize*3, hugePageSize*2) {
+		t.Error("hugePageEligible: aligned size and offset rejected")
+	}
+	if hugePageEligible(hugePageSize+1, 0) {
+		t.Error("hugePageEligible: misaligned size accepted")
+	}
+	if hugePageEligible(hugePageSize, 1) {
+		t.Error("hugePageEligible: misaligned offset accepted")
+	}
+}
+
+// TestApplyMapStrategyMlock mmaps a throwaway page and confirms
+// applyMapStrategy returns no error for the default strategy and, for
+// Mlock, that the mlock(2) call on it succeeds (skipping when mlock is
+// refused). It checks only the returned errors, not whether the page is
+// actually reported locked afterwards.
+func TestApplyMapStrategyMlock(t *testing.T) {
+	ps := os.Getpagesize()
+	b, err := syscall.Mmap(-1, 0, ps, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		t.Fatalf("mmap: %v", err)
+	}
+	addr := uintptr(unsafe.Pointer(&b[0]))
+	defer syscall.Munmap(b)
+
+	if err := applyMapStrategy(defaultMapStrategy, addr, uintptr(ps)); err != nil {
+		t.Fatalf("applyMapStrategy(default): %v", err)
+	}
+
+	if err := applyMapStrategy(MapStrategy{Mlock: true}, addr, uintptr(ps)); err != nil {
+		t.Skipf("applyMapStrategy(Mlock): %v (likely RLIMIT_MEMLOCK in this sandbox)", err)
+	}
+	defer syscall.Munlock(b)
+}
@@ -0,0 +1,72 @@
+//go:build !windows && !darwin
+
+package ffi
+
+// elfRelocType names one R_X86_64_* relocation type from the System V
+// AMD64 ABI's dynamic linking chapter, for whoever eventually builds the
+// hand-rolled ELF loader loader_unsupported.go's errLoaderUnsupported
+// doc comment describes - there is no such loader here yet, on any
+// platform, so nothing in this package actually applies one of these
+// today.
+type elfRelocType uint32
+
+const (
+	// elfRelocGlobDat resolves a GOT entry to a defined symbol's address
+	// - the ordinary case for a data symbol referenced from another
+	// object, with no PLT stub involved.
+	elfRelocGlobDat elfRelocType = 6
+	// elfRelocJumpSlot is GlobDat's function-symbol counterpart: it
+	// resolves a PLT stub's GOT entry to the function's real address,
+	// usually lazily, on first call through the stub rather than at
+	// load time.
+	elfRelocJumpSlot elfRelocType = 7
+	// elfRelocRelative adds the object's own load bias to a value
+	// already present at the relocation's offset, for a position-
+	// independent reference that needs no symbol lookup at all - by far
+	// the most common relocation in a typical shared object.
+	elfRelocRelative elfRelocType = 8
+	// elfRelocCopy copies a symbol's initial value out of a shared
+	// object into the main executable's own BSS/data at load time - for
+	// a non-PIE executable that references one of a library's data
+	// symbols (glibc's stdout is the textbook example) directly, rather
+	// than through a GOT indirection.
+	elfRelocCopy elfRelocType = 5
+	// elfRelocIRelative is Relative's indirect-function counterpart: the
+	// value already present at the offset isn't the final address, but
+	// the address of a resolver function the loader must call (with no
+	// arguments) to get it, for a symbol libc itself picked via
+	// IFUNC/GNU_INDIRECT_FUNCTION (memcpy's various CPU-feature-tuned
+	// implementations are the textbook example).
+	elfRelocIRelative elfRelocType = 37
+
+	// elfRelocDTPMod64 resolves to the TLS module ID a __thread variable
+	// was allocated under - the index into the thread's array of per-
+	// module TLS blocks that the general dynamic and local dynamic TLS
+	// models use to find the right block before adding DTPOff64's offset
+	// within it. See allocateStaticTLS (elf_tls_unsupported.go) for the
+	// module/offset allocation this relocation's value ultimately comes
+	// from.
+	elfRelocDTPMod64 elfRelocType = 16
+	// elfRelocDTPOff64 resolves to a __thread variable's byte offset
+	// within its module's TLS block, DTPMod64's companion half of a
+	// general/local dynamic TLS reference.
+	elfRelocDTPOff64 elfRelocType = 17
+	// elfRelocTPOff64 resolves to a __thread variable's byte offset from
+	// the thread pointer itself, for the initial-exec and local-exec TLS
+	// models - the cheaper access pattern a variable gets when the
+	// compiler can prove which module defines it at link time, skipping
+	// DTPMod64/DTPOff64's extra indirection through a module ID.
+	elfRelocTPOff64 elfRelocType = 18
+	// elfRelocTLSDescCall marks the call instruction a TLSDESC access
+	// sequence uses to invoke the descriptor's resolver function; loader
+	// patches it to a no-op once the resolver has run and TLSDesc's GOT
+	// slot holds a fixed offset, the same lazy-relaxation TLSDESC exists
+	// to make cheap.
+	elfRelocTLSDescCall elfRelocType = 35
+	// elfRelocTLSDesc resolves a TLS descriptor - a (resolver, argument)
+	// pair the TLSDESC access model calls through instead of using a
+	// fixed GOT offset directly, letting the same compiled code work
+	// whether the variable turns out to need the general-dynamic,
+	// initial-exec, or local-exec model once everything is loaded.
+	elfRelocTLSDesc elfRelocType = 36
+)
@@ -0,0 +1,77 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"os"
+	"strings"
+)
+
+// expandOrigin replaces every "$ORIGIN"/"${ORIGIN}" token in path with
+// origin - the directory the library whose DT_RPATH/DT_RUNPATH this path
+// came from was itself loaded from - the same substitution a real
+// dynamic linker performs before treating an rpath/runpath entry as a
+// literal directory, so a library can reference its own neighbors no
+// matter where it ends up installed.
+func expandOrigin(path, origin string) string {
+	path = strings.ReplaceAll(path, "${ORIGIN}", origin)
+	return strings.ReplaceAll(path, "$ORIGIN", origin)
+}
+
+// resolveLibraryPath finds soname along the search order a real dynamic
+// linker uses once an object has a DT_RUNPATH (DT_RPATH is legacy, and
+// only consulted when the needing object has no DT_RUNPATH of its own):
+// rpath, then LD_LIBRARY_PATH, then runpath, then defaultDirs. $ORIGIN in
+// rpath/runpath is expanded against origin first. exists is the file-
+// existence check to use, factored out the same way openShared
+// (library_refcount.go) factors out its open call, so the search order
+// can be exercised without a real filesystem full of .so files to find.
+//
+// It does not read /etc/ld.so.cache or /etc/ld.so.conf itself - parsing
+// ld.so.cache's binary format is a separable piece of work with no
+// bearing on the search order below - so defaultDirs is the caller's
+// place to pass in whatever those turned up (or, until something parses
+// them, the handful of hard-coded system directories every glibc ships).
+//
+// A soname containing a "/" is returned as-is if exists reports it
+// present, and not found otherwise: a real dynamic linker treats a
+// DT_NEEDED entry with a slash in it as a path already, not a bare name
+// to search for.
+func resolveLibraryPath(soname string, rpath, runpath []string, origin, ldLibraryPath string, defaultDirs []string, exists func(string) bool) (string, bool) {
+	if strings.Contains(soname, "/") {
+		if exists(soname) {
+			return soname, true
+		}
+		return "", false
+	}
+
+	var order []string
+	if len(runpath) == 0 {
+		order = append(order, rpath...)
+	}
+	if ldLibraryPath != "" {
+		order = append(order, strings.Split(ldLibraryPath, ":")...)
+	}
+	order = append(order, runpath...)
+	order = append(order, defaultDirs...)
+
+	for _, dir := range order {
+		if dir == "" {
+			continue
+		}
+		candidate := expandOrigin(dir, origin) + "/" + soname
+		if exists(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// fileExists is resolveLibraryPath's real exists check: a plain os.Stat,
+// treating any error (not found, permission denied, or otherwise) as
+// "not there" - the same way a dynamic linker just moves on to the next
+// search directory rather than surfacing a stat failure partway through.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
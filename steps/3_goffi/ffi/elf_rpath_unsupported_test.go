@@ -0,0 +1,92 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// fakeFS returns an exists func that reports present for exactly the
+// given paths, for exercising resolveLibraryPath without a real
+// filesystem.
+func fakeFS(present ...string) func(string) bool {
+	set := make(map[string]bool, len(present))
+	for _, p := range present {
+		set[p] = true
+	}
+	return func(path string) bool { return set[path] }
+}
+
+// TestExpandOriginBothSpellings confirms both $ORIGIN and ${ORIGIN} are
+// substituted.
+func TestExpandOriginBothSpellings(t *testing.T) {
+	if got := expandOrigin("$ORIGIN/../lib", "/opt/app/bin"); got != "/opt/app/bin/../lib" {
+		t.Errorf("expandOrigin($ORIGIN) = %q", got)
+	}
+	if got := expandOrigin("${ORIGIN}/../lib", "/opt/app/bin"); got != "/opt/app/bin/../lib" {
+		t.Errorf("expandOrigin(${ORIGIN}) = %q", got)
+	}
+}
+
+// TestResolveLibraryPathWithSlashIgnoresSearchOrder confirms a soname
+// containing a "/" is checked directly, rather than searched for in any
+// of rpath/runpath/LD_LIBRARY_PATH/defaultDirs.
+func TestResolveLibraryPathWithSlashIgnoresSearchOrder(t *testing.T) {
+	exists := fakeFS("./libs/libfoo.so")
+	got, ok := resolveLibraryPath("./libs/libfoo.so", []string{"/rpath"}, nil, "", "", []string{"/usr/lib"}, exists)
+	if !ok || got != "./libs/libfoo.so" {
+		t.Fatalf("resolveLibraryPath = (%q, %v), want (\"./libs/libfoo.so\", true)", got, ok)
+	}
+}
+
+// TestResolveLibraryPathRpathIgnoredWhenRunpathSet confirms DT_RPATH is
+// skipped entirely once the object has its own DT_RUNPATH, per the gABI.
+func TestResolveLibraryPathRpathIgnoredWhenRunpathSet(t *testing.T) {
+	exists := fakeFS("/rpath/libfoo.so")
+	_, ok := resolveLibraryPath("libfoo.so", []string{"/rpath"}, []string{"/runpath"}, "", "", nil, exists)
+	if ok {
+		t.Fatal("resolveLibraryPath found libfoo.so via rpath despite runpath being set")
+	}
+}
+
+// TestResolveLibraryPathOrder confirms the search reaches each of
+// LD_LIBRARY_PATH, DT_RUNPATH and defaultDirs, by placing the target in
+// exactly one of them per case and confirming it's found there. Since
+// only one directory holds the target in each case, this does not tell
+// the relative order of the three apart.
+func TestResolveLibraryPathOrder(t *testing.T) {
+	cases := []struct {
+		name  string
+		place string
+	}{
+		{"ld_library_path", "/envdir/libfoo.so"},
+		{"runpath", "/runpath/libfoo.so"},
+		{"default", "/usr/lib/libfoo.so"},
+	}
+	for _, c := range cases {
+		exists := fakeFS(c.place)
+		got, ok := resolveLibraryPath("libfoo.so", nil, []string{"/runpath"}, "", "/envdir", []string{"/usr/lib"}, exists)
+		if !ok || got != c.place {
+			t.Errorf("%s: resolveLibraryPath = (%q, %v), want (%q, true)", c.name, got, ok, c.place)
+		}
+	}
+}
+
+// TestResolveLibraryPathOriginExpansion confirms $ORIGIN in an rpath
+// entry is expanded against origin before being searched.
+func TestResolveLibraryPathOriginExpansion(t *testing.T) {
+	exists := fakeFS("/opt/app/lib/libfoo.so")
+	got, ok := resolveLibraryPath("libfoo.so", []string{"$ORIGIN/lib"}, nil, "/opt/app", "", nil, exists)
+	if !ok || got != "/opt/app/lib/libfoo.so" {
+		t.Fatalf("resolveLibraryPath = (%q, %v), want (\"/opt/app/lib/libfoo.so\", true)", got, ok)
+	}
+}
+
+// TestResolveLibraryPathNotFound confirms a soname absent from every
+// search directory is reported not found, rather than panicking or
+// returning a candidate path regardless.
+func TestResolveLibraryPathNotFound(t *testing.T) {
+	exists := fakeFS()
+	got, ok := resolveLibraryPath("libfoo.so", []string{"/rpath"}, nil, "", "", []string{"/usr/lib"}, exists)
+	if ok || got != "" {
+		t.Fatalf("resolveLibraryPath = (%q, %v), want (\"\", false)", got, ok)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build !windows && !darwin
+
+package ffi
+
+// tlsBlock describes one loaded module's __thread data: how many bytes
+// of storage its TLS variables need, and the strictest alignment any of
+// them requires.
+type tlsBlock struct {
+	Size  uint64
+	Align uint64
+}
+
+// tlsSlot is where allocateStaticTLS placed one tlsBlock: Offset is the
+// byte offset, within the thread's combined static TLS area, that
+// module's block starts at.
+type tlsSlot struct {
+	Offset uint64
+}
+
+// allocateStaticTLS lays blocks out one after another in a single
+// thread-local area, respecting each block's own alignment, the way a
+// loader assigns every module loaded up front (as opposed to via a later
+// dlopen) a fixed slot in the initial-exec/local-exec static TLS model
+// rather than a dynamically-allocated one. It returns each block's slot,
+// in the same order as blocks, and the combined area's total size -
+// itself aligned to the largest alignment any block asked for, so the
+// whole area can be repeated back-to-back for every thread without
+// breaking the last block's alignment on the next thread's copy.
+//
+// It says nothing about module IDs (DTPMod64's value) or about the
+// general/local dynamic TLS models' extra indirection through one -
+// those models exist precisely for modules loaded after thread-local
+// storage has already been sized for the ones loaded up front, which is
+// the case allocateStaticTLS does not try to handle.
+func allocateStaticTLS(blocks []tlsBlock) ([]tlsSlot, uint64) {
+	slots := make([]tlsSlot, len(blocks))
+	var offset uint64
+	var maxAlign uint64 = 1
+	for i, b := range blocks {
+		align := b.Align
+		if align == 0 {
+			align = 1
+		}
+		if align > maxAlign {
+			maxAlign = align
+		}
+		offset = alignUp(offset, align)
+		slots[i] = tlsSlot{Offset: offset}
+		offset += b.Size
+	}
+	return slots, alignUp(offset, maxAlign)
+}
+
+// alignUp rounds n up to the next multiple of align, which must be a
+// power of two.
+func alignUp(n, align uint64) uint64 {
+	return (n + align - 1) &^ (align - 1)
+}
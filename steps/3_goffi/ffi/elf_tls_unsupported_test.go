@@ -0,0 +1,83 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// TestAllocateStaticTLSEmpty confirms an empty block list gets an empty
+// slot list and a zero-size area.
+func TestAllocateStaticTLSEmpty(t *testing.T) {
+	slots, size := allocateStaticTLS(nil)
+	if len(slots) != 0 {
+		t.Fatalf("len(slots) = %d, want 0", len(slots))
+	}
+	if size != 0 {
+		t.Fatalf("size = %d, want 0", size)
+	}
+}
+
+// TestAllocateStaticTLSPacksSequentially checks that blocks with no
+// alignment requirement beyond their own size are simply laid out back
+// to back, in order.
+func TestAllocateStaticTLSPacksSequentially(t *testing.T) {
+	blocks := []tlsBlock{{Size: 8, Align: 1}, {Size: 16, Align: 1}, {Size: 4, Align: 1}}
+	slots, size := allocateStaticTLS(blocks)
+	want := []tlsSlot{{Offset: 0}, {Offset: 8}, {Offset: 24}}
+	for i, s := range slots {
+		if s != want[i] {
+			t.Errorf("slots[%d] = %+v, want %+v", i, s, want[i])
+		}
+	}
+	if size != 28 {
+		t.Fatalf("size = %d, want 28", size)
+	}
+}
+
+// TestAllocateStaticTLSAlignment checks that a block is padded up to its
+// own alignment before being placed, and that the returned total size is
+// itself rounded up to the largest alignment seen.
+func TestAllocateStaticTLSAlignment(t *testing.T) {
+	blocks := []tlsBlock{{Size: 1, Align: 1}, {Size: 8, Align: 16}}
+	slots, size := allocateStaticTLS(blocks)
+	if slots[0].Offset != 0 {
+		t.Errorf("slots[0].Offset = %d, want 0", slots[0].Offset)
+	}
+	if slots[1].Offset != 16 {
+		t.Errorf("slots[1].Offset = %d, want 16", slots[1].Offset)
+	}
+	if size != 32 {
+		t.Fatalf("size = %d, want 32", size)
+	}
+}
+
+// TestAllocateStaticTLSZeroAlignTreatedAsOne confirms a block with no
+// Align set (the zero value) is treated the same as Align: 1, rather
+// than rounding its offset up to a multiple of zero.
+func TestAllocateStaticTLSZeroAlignTreatedAsOne(t *testing.T) {
+	slots, size := allocateStaticTLS([]tlsBlock{{Size: 3}, {Size: 5}})
+	if slots[1].Offset != 3 {
+		t.Errorf("slots[1].Offset = %d, want 3", slots[1].Offset)
+	}
+	if size != 8 {
+		t.Fatalf("size = %d, want 8", size)
+	}
+}
+
+// TestAlignUp checks alignUp against a few hand-worked cases, including
+// an already-aligned input.
+func TestAlignUp(t *testing.T) {
+	cases := []struct {
+		n, align, want uint64
+	}{
+		{0, 8, 0},
+		{1, 8, 8},
+		{8, 8, 8},
+		{9, 8, 16},
+		{17, 16, 32},
+	}
+	for _, c := range cases {
+		if got := alignUp(c.n, c.align); got != c.want {
+			t.Errorf("alignUp(%d, %d) = %d, want %d", c.n, c.align, got, c.want)
+		}
+	}
+}
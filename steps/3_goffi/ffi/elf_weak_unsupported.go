@@ -0,0 +1,46 @@
+//go:build !windows && !darwin
+
+package ffi
+
+// elfSymBind is the high nibble of an Elf64_Sym's st_info byte - this
+// symbol's binding, as opposed to elfSymType (elf_ifunc_unsupported.go),
+// which is st_info's low nibble.
+type elfSymBind uint8
+
+const (
+	elfSymBindLocal  elfSymBind = 0
+	elfSymBindGlobal elfSymBind = 1
+	// elfSymBindWeak is STB_WEAK: like STB_GLOBAL, but lower precedence
+	// - a defined STB_GLOBAL symbol of the same name anywhere else in
+	// the link wins over it, and, unlike STB_GLOBAL, a loader finding no
+	// definition for it anywhere resolves the reference to 0 instead of
+	// failing the load outright.
+	elfSymBindWeak elfSymBind = 2
+)
+
+// elfSymBindOf extracts an Elf64_Sym's binding from its raw st_info byte
+// - the high nibble, per the gABI's ELF32_ST_BIND/ELF64_ST_BIND macros.
+func elfSymBindOf(stInfo uint8) elfSymBind {
+	return elfSymBind(stInfo >> 4)
+}
+
+// isWeakBinding reports whether stInfo marks an STB_WEAK symbol. It
+// matters at exactly the two points elf_reloc_unsupported.go's
+// elfRelocGlobDat and elfRelocJumpSlot get applied: a loader that finds
+// no definition for an STB_GLOBAL-bound GOT reference must fail the
+// load (the symbol is genuinely required and missing), while the same
+// situation for an STB_WEAK-bound one is routine - glibc itself exports
+// plenty of symbols (pthread_create, __cxa_thread_atexit_impl,
+// dlopen) as weak precisely so a binary linked without the defining
+// library still loads, with the reference simply evaluating to a null
+// pointer/address wherever it's used. There's no such loader here yet
+// (see errLoaderUnsupported, loader_unsupported.go) to apply this
+// distinction; isWeakBinding exists so it doesn't have to be worked out
+// again once there is one. Library.GetOptional (library.go) is this
+// distinction's user-facing half: a caller checking whether an optional
+// library dependency actually provides a symbol gets the same
+// ok=false-not-an-error shape a weak relocation resolving to 0 implies,
+// without needing a real loader to get there.
+func isWeakBinding(stInfo uint8) bool {
+	return elfSymBindOf(stInfo) == elfSymBindWeak
+}
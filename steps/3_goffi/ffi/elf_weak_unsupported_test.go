@@ -0,0 +1,39 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// TestElfSymBindOfMasksHighNibble confirms elfSymBindOf keeps only
+// st_info's high nibble, ignoring whatever type (the low nibble) is
+// packed alongside it.
+func TestElfSymBindOfMasksHighNibble(t *testing.T) {
+	cases := []struct {
+		stInfo uint8
+		want   elfSymBind
+	}{
+		{0x00, elfSymBindLocal},
+		{0x12, elfSymBindGlobal},
+		{0x2a, elfSymBindWeak},
+		{0x2f, elfSymBindWeak},
+	}
+	for _, c := range cases {
+		if got := elfSymBindOf(c.stInfo); got != c.want {
+			t.Errorf("elfSymBindOf(%#x) = %d, want %d", c.stInfo, got, c.want)
+		}
+	}
+}
+
+// TestIsWeakBinding checks isWeakBinding against an STB_WEAK st_info
+// byte and a handful of other bindings that must not be mistaken for
+// one.
+func TestIsWeakBinding(t *testing.T) {
+	if !isWeakBinding(0x22) { // STB_WEAK<<4 | STT_FUNC
+		t.Error("isWeakBinding(0x22) = false, want true")
+	}
+	for _, stInfo := range []uint8{0x00, 0x01, 0x12, 0x10} {
+		if isWeakBinding(stInfo) {
+			t.Errorf("isWeakBinding(%#x) = true, want false", stInfo)
+		}
+	}
+}
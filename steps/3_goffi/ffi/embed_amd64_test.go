@@ -0,0 +1,62 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfSumWithStrlenTarget and sumWithStrlenTarget (embed_amd64_test.s)
+// stand in for a C function taking (long handle, const char *name, int
+// extra), the same way addrOfAddPairTarget/addPairTarget do for a
+// two-int sum.
+func addrOfSumWithStrlenTarget() uintptr
+func sumWithStrlenTarget()
+
+// sharedHeader is the kind of "header" struct synth-134 calls out: a
+// handle plus a name field several args structs could all embed instead
+// of repeating. Its Name field is exactly the shape a plain nested
+// (non-flattened) struct field could never support - classifyEightbytes
+// has no case for a string Kind(), only the per-leaf fieldToOffset/
+// cstrArg path flattening now runs each of Handle and Name through.
+type sharedHeader struct {
+	Handle int64
+	Name   string `ffi:"cstr"`
+}
+
+// embeddedHeaderArgs embeds sharedHeader with no ffi tag of its own, so
+// MakeSpec flattens Handle and Name into the argument sequence in
+// declaration order instead of trying to classify the whole header as
+// one C struct-by-value aggregate.
+type embeddedHeaderArgs struct {
+	sharedHeader
+	Extra int32 `ffi:"arg"`
+	R     int64 `ffi:"ret"`
+}
+
+// TestCallEmbeddedHeaderFlattensFields drives sumWithStrlenTarget through
+// the real asmcall trampoline with an embedded header struct, confirming
+// Handle, Name, and Extra each land in their own register exactly as if
+// they'd been declared directly on embeddedHeaderArgs.
+func TestCallEmbeddedHeaderFlattensFields(t *testing.T) {
+	args := embeddedHeaderArgs{
+		sharedHeader: sharedHeader{Handle: 100, Name: "hello"},
+		Extra:        3,
+	}
+	spec := MakeSpec(addrOfSumWithStrlenTarget(), &args)
+
+	a := acquireArena()
+	restore := prepareCStrings(unsafe.Pointer(&args), spec.cstrFields, a)
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+	restore()
+	releaseArena(a)
+
+	want := int64(100 + len("hello") + 3)
+	if args.R != want {
+		t.Fatalf("embedded header call: got R=%d, want %d", args.R, want)
+	}
+	if args.Name != "hello" {
+		t.Fatalf("embedded header call: Name was not restored, got %q", args.Name)
+	}
+}
@@ -0,0 +1,135 @@
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// libcEnviron lazily resolves libc's getenv/setenv/unsetenv, the same
+// libc()-style two-step cstring.go's malloc/free use.
+func libcEnviron() (getenvFn, setenvFn, unsetenvFn uintptr, err error) {
+	environOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			environErr = openErr
+			return
+		}
+		if environGetenvFn, environErr = lib.Get("getenv"); environErr != nil {
+			return
+		}
+		if environSetenvFn, environErr = lib.Get("setenv"); environErr != nil {
+			return
+		}
+		environUnsetenvFn, environErr = lib.Get("unsetenv")
+	})
+	return environGetenvFn, environSetenvFn, environUnsetenvFn, environErr
+}
+
+var (
+	environOnce                                         sync.Once
+	environGetenvFn, environSetenvFn, environUnsetenvFn uintptr
+	environErr                                          error
+)
+
+// Getenv reads name from the dynamically-loaded libc's own environment
+// via its getenv, rather than Go's os.Getenv - see SyncEnviron's doc
+// comment for why the two can disagree in a cgo-free binary until
+// Setenv/SyncEnviron has run. ok is false if name isn't set there.
+func Getenv(name string) (value string, ok bool, err error) {
+	getenvFn, _, _, ferr := libcEnviron()
+	if ferr != nil {
+		return "", false, ferr
+	}
+	args := struct {
+		Name string  `ffi:"cstr"`
+		R    uintptr `ffi:"ret"`
+	}{Name: name}
+	spec := MakeSpec(getenvFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return "", false, nil
+	}
+	return GoString(args.R), true, nil
+}
+
+// Setenv sets name=value in both Go's own environment (via os.Setenv)
+// and the dynamically-loaded libc's environment (via its own setenv),
+// so a C function that reads the environment - through getenv directly,
+// or by walking libc's environ global - sees the same value a later
+// os.Getenv(name) would.
+func Setenv(name, value string) error {
+	if err := os.Setenv(name, value); err != nil {
+		return err
+	}
+	return setenvLibc(name, value)
+}
+
+// Unsetenv removes name from both Go's own environment (via
+// os.Unsetenv) and the dynamically-loaded libc's environment (via its
+// own unsetenv).
+func Unsetenv(name string) error {
+	if err := os.Unsetenv(name); err != nil {
+		return err
+	}
+	_, _, unsetenvFn, err := libcEnviron()
+	if err != nil {
+		return err
+	}
+	args := struct {
+		Name string `ffi:"cstr"`
+		R    int32  `ffi:"ret"`
+	}{Name: name}
+	spec := MakeSpec(unsetenvFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("ffi: Unsetenv(%q): unsetenv returned %d", name, args.R)
+	}
+	return nil
+}
+
+// SyncEnviron copies every variable os.Environ() currently reports into
+// the dynamically-loaded libc's own environment, one setenv call per
+// variable. It exists for a gap Setenv/Unsetenv alone don't cover: a
+// cgo-free binary's dynamically-loaded libc never ran its own startup
+// code (__libc_start_main), so its environ was never populated from the
+// process's real envp at process start the way it would be in a cgo or
+// plain C binary - a C library Open mapped in and called before
+// anything here has ever called Setenv would see an empty environment
+// even though Go's own os.Environ() is fully populated from the same
+// envp. Call SyncEnviron once, after Open-ing whatever C library will
+// read the environment and before calling into it, to give that C code
+// the same view of the environment Go already has.
+func SyncEnviron() error {
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if err := setenvLibc(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setenvLibc(name, value string) error {
+	_, setenvFn, _, err := libcEnviron()
+	if err != nil {
+		return err
+	}
+	args := struct {
+		Name      string `ffi:"cstr"`
+		Value     string `ffi:"cstr"`
+		Overwrite int32  `ffi:"arg"`
+		R         int32  `ffi:"ret"`
+	}{Name: name, Value: value, Overwrite: 1}
+	spec := MakeSpec(setenvFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("ffi: setenv(%q): returned %d", name, args.R)
+	}
+	return nil
+}
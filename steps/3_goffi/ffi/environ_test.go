@@ -0,0 +1,31 @@
+package ffi
+
+import (
+	"os"
+	"testing"
+)
+
+// TestGetenvFailsCleanlyWithoutLoader confirms Getenv surfaces Open's
+// own error on a platform without a real loader yet
+// (loader_unsupported.go), rather than panicking.
+func TestGetenvFailsCleanlyWithoutLoader(t *testing.T) {
+	if _, _, err := Getenv("PATH"); err != nil {
+		t.Logf("Getenv: %v (expected until this platform has a real loader)", err)
+	}
+}
+
+// TestSetenvUpdatesGoEnvironRegardlessOfLibc confirms Setenv updates
+// Go's own os.Environ even when the libc half fails - a cgo-free
+// binary's own environment handling shouldn't regress just because
+// there's no loader to reach libc's through yet.
+func TestSetenvUpdatesGoEnvironRegardlessOfLibc(t *testing.T) {
+	const name = "FFI_ENVIRON_TEST_VAR"
+	err := Setenv(name, "1")
+	defer Unsetenv(name)
+	if err != nil {
+		t.Logf("Setenv: %v (expected until this platform has a real loader)", err)
+	}
+	if got, ok := os.LookupEnv(name); !ok || got != "1" {
+		t.Fatalf("os.Getenv(%q) after Setenv: got (%q, %v), want (\"1\", true)", name, got, ok)
+	}
+}
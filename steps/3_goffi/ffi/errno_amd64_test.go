@@ -0,0 +1,55 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// fakeErrnoValue stands in for libc's thread-local errno.
+// addrOfFakeErrnoLocationTarget/fakeErrnoLocationTarget
+// (errno_amd64_test.s) return its address the way __errno_location
+// would, proving asmcall's post-call errno capture works through an
+// arbitrary "int *"-returning C function rather than anything hardcoded.
+var fakeErrnoValue int32
+
+func addrOfFakeErrnoLocationTarget() uintptr
+func fakeErrnoLocationTarget()
+func addrOfNoopTarget() uintptr
+func noopTarget()
+
+type noArgs struct{}
+
+// TestCallErrnoCapture exercises UseErrno/asmcall's errno-capture section
+// end to end: after noopTarget "returns", asmcall calls
+// fakeErrnoLocationTarget and stores what it points at into spec.errno.
+func TestCallErrnoCapture(t *testing.T) {
+	fakeErrnoValue = 42
+
+	spec := MakeSpec(addrOfNoopTarget(), &noArgs{})
+	var dummy noArgs
+	spec.UseErrno(addrOfFakeErrnoLocationTarget())
+
+	params := callParams{spec: &spec, base: uintptr(unsafe.Pointer(&dummy))}
+	callAsmDirect(&params)
+
+	if params.errno != 42 {
+		t.Fatalf("errno capture: got %d, want 42", params.errno)
+	}
+}
+
+// TestCallNoErrnoCapture confirms asmcall skips the errno-capture call
+// entirely when UseErrno was never called, rather than dereferencing a
+// nil errnoFn.
+func TestCallNoErrnoCapture(t *testing.T) {
+	spec := MakeSpec(addrOfNoopTarget(), &noArgs{})
+	var dummy noArgs
+
+	params := callParams{spec: &spec, base: uintptr(unsafe.Pointer(&dummy))}
+	callAsmDirect(&params)
+
+	if params.errno != 0 {
+		t.Fatalf("errno: got %d, want 0 when UseErrno was never called", params.errno)
+	}
+}
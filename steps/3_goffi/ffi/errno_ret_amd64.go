@@ -0,0 +1,58 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// retErrnoNegative reads spec's ffi:"ret" field back out of args after a
+// call and reports whether it's negative - true only for a signed
+// integer return (type64, typeS32, typeS16, typeS8); an unsigned one
+// (typeU32/typeU16/typeU8, loaded zero-extended) can never be negative,
+// and a float/double/address return isn't this convention's business at
+// all, so both report ok=false rather than a meaningless answer.
+func (spec *Spec) retErrnoNegative(args unsafe.Pointer) (negative, ok bool) {
+	p := unsafe.Pointer(uintptr(args) + uintptr(spec.ret0.offset))
+	switch spec.ret0.t {
+	case type64:
+		return *(*int64)(p) < 0, true
+	case typeS32:
+		return *(*int32)(p) < 0, true
+	case typeS16:
+		return *(*int16)(p) < 0, true
+	case typeS8:
+		return *(*int8)(p) < 0, true
+	case typeU32, typeU16, typeU8:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// RetErrno calls spec, then interprets its ffi:"ret,errno" field per the
+// "negative return means failure, consult errno" convention most
+// POSIX-facing C functions follow (e.g. libc's open/read/write - see
+// package libc's own hand-written "if args.R < 0" checks, which this
+// exists to replace with one reusable call): if that field's value
+// comes back negative, RetErrno returns the errno Call captured as a
+// syscall.Errno; otherwise nil. spec must already have UseErrno
+// configured (e.g. via SetErrnoLocator's own fn) - without it, the
+// Errno RetErrno reports will simply be 0.
+//
+// RetErrno panics if spec wasn't built from a struct with exactly one
+// field tagged ffi:"ret,errno" (plain ffi:"ret" alone doesn't opt in -
+// "errno" is what tells MakeSpec this particular return value follows
+// the convention, since plenty of C functions return a meaningful
+// negative value that has nothing to do with errno).
+func RetErrno(spec *Spec, args unsafe.Pointer) error {
+	if !spec.retErrno {
+		panic("ffi: RetErrno: spec has no ffi:\"ret,errno\" field")
+	}
+	e := spec.Call(args)
+	if negative, ok := spec.retErrnoNegative(args); ok && negative {
+		return syscall.Errno(e)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// retErrnoArgs is the argument struct for exercising RetErrno against
+// addPairFlatTarget (func_amd64_test.s), a trivial A+B sum: passing a
+// negative sum stands in for a C function's "-1 on failure" return,
+// passing a non-negative one for success.
+type retErrnoArgs struct {
+	A int64 `ffi:"arg"`
+	B int64 `ffi:"arg"`
+	R int64 `ffi:"ret,errno"`
+}
+
+// TestRetErrnoNegativeReturnsErrno confirms RetErrno reports the errno
+// Call captured when the ret field comes back negative.
+func TestRetErrnoNegativeReturnsErrno(t *testing.T) {
+	fakeErrnoValue = 5 // EIO
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &retErrnoArgs{})
+	spec.UseErrno(addrOfFakeErrnoLocationTarget())
+
+	args := retErrnoArgs{A: -10, B: 3}
+	err := RetErrno(&spec, unsafe.Pointer(&args))
+	if err == nil {
+		t.Fatal("RetErrno: expected a non-nil error for a negative return")
+	}
+	if errno, ok := err.(syscall.Errno); !ok || errno != 5 {
+		t.Fatalf("RetErrno: got %v, want syscall.Errno(5)", err)
+	}
+	if args.R != -7 {
+		t.Fatalf("RetErrno: args.R = %d, want -7 (the real return value, untouched)", args.R)
+	}
+}
+
+// TestRetErrnoSuccessReturnsNil confirms RetErrno reports nil when the
+// ret field comes back non-negative, regardless of what errno happens to
+// be left at.
+func TestRetErrnoSuccessReturnsNil(t *testing.T) {
+	fakeErrnoValue = 5
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &retErrnoArgs{})
+	spec.UseErrno(addrOfFakeErrnoLocationTarget())
+
+	args := retErrnoArgs{A: 3, B: 4}
+	if err := RetErrno(&spec, unsafe.Pointer(&args)); err != nil {
+		t.Fatalf("RetErrno: got %v, want nil for a non-negative return", err)
+	}
+	if args.R != 7 {
+		t.Fatalf("RetErrno: args.R = %d, want 7", args.R)
+	}
+}
+
+// TestRetErrnoRequiresTag confirms RetErrno refuses to run against a
+// Spec whose ret field was tagged plain ffi:"ret" rather than
+// ffi:"ret,errno" - opting a return value into this convention has to be
+// explicit.
+func TestRetErrnoRequiresTag(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RetErrno: expected a panic for a Spec with no ffi:\"ret,errno\" field")
+		}
+	}()
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &funcAddArgs{})
+	var args funcAddArgs
+	RetErrno(&spec, unsafe.Pointer(&args))
+}
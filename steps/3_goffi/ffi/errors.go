@@ -0,0 +1,82 @@
+package ffi
+
+// ErrorKind categorizes why a Spec could not be built for a given
+// argument type, so a caller of NewSpec can branch on the failure
+// without parsing SpecError.Msg.
+type ErrorKind int
+
+const (
+	// ErrUnsupportedKind means a field's Go type (or "type=" tag
+	// override) has no corresponding argtype - see fieldToOffset in
+	// ffi.go.
+	ErrUnsupportedKind ErrorKind = iota
+	// ErrMultipleReturns means more than one field was tagged
+	// ffi:"ret"; a Spec has room for exactly one logical return value.
+	ErrMultipleReturns
+	// ErrUnsupportedAggregate means a struct/array argument or return
+	// value hit a classification case this backend doesn't support
+	// yet - e.g. a >16 byte arm64 aggregate, or any aggregate return on
+	// 386 or windows/amd64.
+	ErrUnsupportedAggregate
+	// ErrTooManyArgs means the fixed arguments alone already exceed
+	// this backend's outgoing stack-argument bound (maxCallStackArgs/
+	// maxCallStackBytes); Call would panic on this Spec regardless of
+	// what's actually passed to it.
+	ErrTooManyArgs
+	// ErrUnknownSymbol means Lookup (or LookupFunc) was called with a
+	// name no Register (or RegisterFunc) call has ever declared.
+	ErrUnknownSymbol
+	// ErrMisalignedVector means an M128/M256 (vector.go) field's offset
+	// within its args struct isn't a multiple of the field's own size -
+	// asmcall's MOVOU/VMOVDQU always addresses it as a whole register's
+	// worth of bytes, and SysV requires a vector's memory address (stack
+	// or struct) to be naturally aligned the same way its register is.
+	ErrMisalignedVector
+)
+
+// String names k, mainly for SpecError.Error and test failure messages.
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrUnsupportedKind:
+		return "unsupported kind"
+	case ErrMultipleReturns:
+		return "multiple return fields"
+	case ErrUnsupportedAggregate:
+		return "unsupported aggregate"
+	case ErrTooManyArgs:
+		return "too many arguments"
+	case ErrUnknownSymbol:
+		return "unknown symbol"
+	case ErrMisalignedVector:
+		return "misaligned vector field"
+	default:
+		return "unknown"
+	}
+}
+
+// SpecError is what NewSpec returns, and equivalently what MakeSpec
+// panics with, when an argument struct can't be classified into a Spec.
+type SpecError struct {
+	Kind ErrorKind
+	Msg  string
+}
+
+func (e *SpecError) Error() string {
+	return "ffi: " + e.Msg
+}
+
+// NewSpec is MakeSpec, but returning a *SpecError instead of panicking
+// when args can't be classified, so a library built on top of this
+// package can validate a caller-supplied type without recover.
+func NewSpec(fn uintptr, args interface{}) (spec Spec, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(*SpecError)
+			if !ok {
+				panic(r)
+			}
+			err = se
+		}
+	}()
+	return MakeSpec(fn, args), nil
+}
@@ -0,0 +1,44 @@
+package ffi
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestNewSpecMultipleReturns confirms NewSpec turns buildSpec's
+// "more than one ffi:\"ret\" field" panic into a typed error instead of
+// letting it propagate, so a caller can validate a user-supplied type
+// without recover.
+func TestNewSpecMultipleReturns(t *testing.T) {
+	type twoRets struct {
+		A int64 `ffi:"ret"`
+		B int64 `ffi:"ret"`
+	}
+
+	_, err := NewSpec(0, &twoRets{})
+	if err == nil {
+		t.Fatal("NewSpec: expected an error for two ffi:\"ret\" fields")
+	}
+
+	var se *SpecError
+	if !errors.As(err, &se) {
+		t.Fatalf("NewSpec: got %T, want *SpecError", err)
+	}
+	if se.Kind != ErrMultipleReturns {
+		t.Fatalf("NewSpec: got Kind=%v, want ErrMultipleReturns", se.Kind)
+	}
+}
+
+// TestNewSpecOK confirms NewSpec returns a usable Spec and a nil error
+// for a type MakeSpec would classify without panicking.
+func TestNewSpecOK(t *testing.T) {
+	type okArgs struct {
+		A int64 `ffi:"arg"`
+	}
+
+	spec, err := NewSpec(0, &okArgs{})
+	if err != nil {
+		t.Fatalf("NewSpec: unexpected error: %v", err)
+	}
+	_ = spec
+}
@@ -0,0 +1,108 @@
+//go:build !windows
+
+// Package evp binds just enough of OpenSSL's libcrypto EVP digest and
+// cipher APIs - SHA-256 hashing and AES-256-CBC encryption/decryption -
+// to round-trip real cryptographic output against a real, widely
+// deployed C library, doubling as both an integration test suite and a
+// worked usage reference for package ffi: it exercises opaque
+// EVP_MD_CTX*/EVP_CIPHER_CTX* handles, ffi:"out" output-buffer fields
+// (Digest.Final, Cipher.Update/Final), and EVP_EncryptInit_ex/
+// EVP_DecryptInit_ex's five-argument calls.
+//
+// Like package sqlite, this is intentionally narrow: one digest (SHA-256)
+// and one cipher (AES-256-CBC), not a general libcrypto binding.
+package evp
+
+import (
+	"fmt"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// evpMaxMDSize is OpenSSL's EVP_MAX_MD_SIZE - the largest buffer
+// EVP_DigestFinal_ex ever writes into, regardless of which digest was
+// used to produce it.
+const evpMaxMDSize = 64
+
+// mdCtxNewFn, mdCtxFreeFn, digestInitExFn, digestUpdateFn,
+// digestFinalExFn, evpSha256Fn, cipherCtxNewFn, cipherCtxFreeFn,
+// encryptInitExFn, encryptUpdateFn, encryptFinalExFn, decryptInitExFn,
+// decryptUpdateFn, decryptFinalExFn and evpAes256CbcFn are resolved once
+// by init below. initErr holds whatever error resolving them failed
+// with, if any - every exported function here returns it unchanged
+// rather than failing some other way, the same convention package
+// libc's and package sqlite's own initErr both follow.
+var (
+	mdCtxNewFn, mdCtxFreeFn, digestInitExFn, digestUpdateFn, digestFinalExFn uintptr
+	evpSha256Fn, evpAes256CbcFn                                              uintptr
+	cipherCtxNewFn, cipherCtxFreeFn                                          uintptr
+	encryptInitExFn, encryptUpdateFn, encryptFinalExFn                       uintptr
+	decryptInitExFn, decryptUpdateFn, decryptFinalExFn                       uintptr
+	initErr                                                                  error
+)
+
+func init() {
+	var lib *ffi.Library
+	var lastErr error
+	for _, name := range libNames {
+		l, err := ffi.Open(name)
+		if err == nil {
+			lib = l
+			break
+		}
+		lastErr = err
+	}
+	if lib == nil {
+		initErr = lastErr
+		return
+	}
+
+	for name, fn := range map[string]*uintptr{
+		"EVP_MD_CTX_new":      &mdCtxNewFn,
+		"EVP_MD_CTX_free":     &mdCtxFreeFn,
+		"EVP_DigestInit_ex":   &digestInitExFn,
+		"EVP_DigestUpdate":    &digestUpdateFn,
+		"EVP_DigestFinal_ex":  &digestFinalExFn,
+		"EVP_sha256":          &evpSha256Fn,
+		"EVP_aes_256_cbc":     &evpAes256CbcFn,
+		"EVP_CIPHER_CTX_new":  &cipherCtxNewFn,
+		"EVP_CIPHER_CTX_free": &cipherCtxFreeFn,
+		"EVP_EncryptInit_ex":  &encryptInitExFn,
+		"EVP_EncryptUpdate":   &encryptUpdateFn,
+		"EVP_EncryptFinal_ex": &encryptFinalExFn,
+		"EVP_DecryptInit_ex":  &decryptInitExFn,
+		"EVP_DecryptUpdate":   &decryptUpdateFn,
+		"EVP_DecryptFinal_ex": &decryptFinalExFn,
+	} {
+		addr, err := lib.Get(name)
+		if err != nil {
+			initErr = err
+			return
+		}
+		*fn = addr
+	}
+}
+
+// Error is what every function in this package returns for an EVP call
+// that reported failure (OpenSSL's 1-success/0-or-negative-failure
+// convention) - OpenSSL's own richer error-stack API isn't bound here,
+// so name is all the context available.
+type Error struct {
+	Name string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("evp: %s failed", e.Name)
+}
+
+// evpSha256 calls EVP_sha256, returning the const EVP_MD* identifying
+// the SHA-256 algorithm that EVP_DigestInit_ex expects.
+func evpSha256() uintptr {
+	args := struct {
+		R uintptr `ffi:"ret"`
+	}{}
+	spec := ffi.MakeSpec(evpSha256Fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return args.R
+}
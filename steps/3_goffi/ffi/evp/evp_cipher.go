@@ -0,0 +1,167 @@
+//go:build !windows
+
+package evp
+
+import (
+	"fmt"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// aes256KeySize and aes256BlockSize are AES-256-CBC's fixed key and
+// block sizes in bytes - EVP_CIPHER_CTX_new doesn't report these back,
+// so any binding against a single fixed cipher (as this one is) just
+// has to know them.
+const (
+	aes256KeySize   = 32
+	aes256BlockSize = 16
+)
+
+// evpAes256Cbc calls EVP_aes_256_cbc, returning the const EVP_CIPHER*
+// identifying AES-256-CBC that EVP_EncryptInit_ex/EVP_DecryptInit_ex
+// expect.
+func evpAes256Cbc() uintptr {
+	args := struct {
+		R uintptr `ffi:"ret"`
+	}{}
+	spec := ffi.MakeSpec(evpAes256CbcFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return args.R
+}
+
+// Cipher is a running AES-256-CBC encryption or decryption, wrapping
+// the opaque EVP_CIPHER_CTX* handle EVP_CIPHER_CTX_new returned.
+type Cipher struct {
+	ctx     uintptr
+	encrypt bool
+}
+
+// newCipher is the shared body of NewEncrypter/NewDecrypter: both call
+// EVP_CIPHER_CTX_new the same way and differ only in which Init_ex
+// function they then call - key and iv must each be exactly
+// aes256KeySize/aes256BlockSize bytes, AES-256-CBC's fixed sizes.
+func newCipher(initFn uintptr, encrypt bool, key, iv []byte) (*Cipher, error) {
+	if initErr != nil {
+		return nil, initErr
+	}
+	if len(key) != aes256KeySize {
+		return nil, fmt.Errorf("evp: key must be %d bytes, got %d", aes256KeySize, len(key))
+	}
+	if len(iv) != aes256BlockSize {
+		return nil, fmt.Errorf("evp: iv must be %d bytes, got %d", aes256BlockSize, len(iv))
+	}
+
+	newArgs := struct {
+		R uintptr `ffi:"ret"`
+	}{}
+	newSpec := ffi.MakeSpec(cipherCtxNewFn, &newArgs)
+	newSpec.Call(unsafe.Pointer(&newArgs))
+	if newArgs.R == 0 {
+		return nil, &Error{Name: "EVP_CIPHER_CTX_new"}
+	}
+	c := &Cipher{ctx: newArgs.R, encrypt: encrypt}
+
+	// EVP_EncryptInit_ex/EVP_DecryptInit_ex share this same five-argument
+	// shape: (ctx, cipher, impl, key, iv).
+	initArgs := struct {
+		Ctx    uintptr `ffi:"arg"`
+		Cipher uintptr `ffi:"arg"`
+		Impl   uintptr `ffi:"arg"`
+		Key    []byte  `ffi:"arg"`
+		IV     []byte  `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Ctx: c.ctx, Cipher: evpAes256Cbc(), Key: key, IV: iv}
+	initSpec := ffi.MakeSpec(initFn, &initArgs)
+	initSpec.Call(unsafe.Pointer(&initArgs))
+	if initArgs.R != 1 {
+		c.Free()
+		return nil, &Error{Name: "EVP_{Encrypt,Decrypt}Init_ex"}
+	}
+	return c, nil
+}
+
+// NewEncrypter starts a new AES-256-CBC encryption via
+// EVP_CIPHER_CTX_new/EVP_EncryptInit_ex.
+func NewEncrypter(key, iv []byte) (*Cipher, error) {
+	return newCipher(encryptInitExFn, true, key, iv)
+}
+
+// NewDecrypter starts a new AES-256-CBC decryption via
+// EVP_CIPHER_CTX_new/EVP_DecryptInit_ex.
+func NewDecrypter(key, iv []byte) (*Cipher, error) {
+	return newCipher(decryptInitExFn, false, key, iv)
+}
+
+// update is the shared body of Cipher.Update's encrypt and decrypt
+// cases: both EVP_EncryptUpdate and EVP_DecryptUpdate have the same
+// (ctx, out, &outl, in, inl) shape and the same "out must be at least
+// inl+block_size-1 bytes" sizing rule.
+func (c *Cipher) update(fn uintptr, in []byte) ([]byte, error) {
+	out := make([]byte, len(in)+aes256BlockSize)
+	var outLen int32
+	args := struct {
+		Ctx    uintptr        `ffi:"arg"`
+		Out    []byte         `ffi:"out"`
+		OutLen unsafe.Pointer `ffi:"arg"`
+		In     []byte         `ffi:"arg,len=InLen"`
+		InLen  int32          `ffi:"arg"`
+		R      int32          `ffi:"ret"`
+	}{Ctx: c.ctx, Out: out, OutLen: unsafe.Pointer(&outLen), In: in}
+	spec := ffi.MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 1 {
+		return nil, &Error{Name: "EVP_{Encrypt,Decrypt}Update"}
+	}
+	return out[:outLen], nil
+}
+
+// Update feeds in through c via EVP_EncryptUpdate or EVP_DecryptUpdate
+// (whichever newCipher started c with) and returns however many bytes
+// of output that produced - CBC mode only emits output a full block at
+// a time, so a short in may legitimately produce no output yet. Out is
+// tagged ffi:"out" so Call zeroes its backing buffer before the call,
+// the same safety margin Digest.Final's own output buffer gets.
+func (c *Cipher) Update(in []byte) ([]byte, error) {
+	if c.encrypt {
+		return c.update(encryptUpdateFn, in)
+	}
+	return c.update(decryptUpdateFn, in)
+}
+
+// Final completes c via EVP_EncryptFinal_ex or EVP_DecryptFinal_ex,
+// returning whatever final block of output (padding, for an encrypt;
+// the last plaintext block, for a decrypt) remained buffered. c must not
+// be reused after Final; call NewEncrypter/NewDecrypter again to start
+// another cipher operation.
+func (c *Cipher) Final() ([]byte, error) {
+	finalFn := encryptFinalExFn
+	if !c.encrypt {
+		finalFn = decryptFinalExFn
+	}
+
+	var out [aes256BlockSize]byte
+	var outLen int32
+	args := struct {
+		Ctx    uintptr                `ffi:"arg"`
+		Out    *[aes256BlockSize]byte `ffi:"out"`
+		OutLen unsafe.Pointer         `ffi:"arg"`
+		R      int32                  `ffi:"ret"`
+	}{Ctx: c.ctx, Out: &out, OutLen: unsafe.Pointer(&outLen)}
+	spec := ffi.MakeSpec(finalFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 1 {
+		return nil, &Error{Name: "EVP_{Encrypt,Decrypt}Final_ex"}
+	}
+	return append([]byte(nil), out[:outLen]...), nil
+}
+
+// Free releases c via EVP_CIPHER_CTX_free - NewEncrypter/NewDecrypter's
+// necessary counterpart.
+func (c *Cipher) Free() {
+	args := struct {
+		Ctx uintptr `ffi:"arg"`
+	}{Ctx: c.ctx}
+	spec := ffi.MakeSpec(cipherCtxFreeFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+}
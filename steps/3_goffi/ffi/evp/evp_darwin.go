@@ -0,0 +1,8 @@
+package evp
+
+// libNames is tried in order by init below - Homebrew's openssl@3 is the
+// common case; there is no system libcrypto on macOS to fall back to.
+var libNames = []string{
+	"/opt/homebrew/opt/openssl@3/lib/libcrypto.dylib",
+	"/usr/local/opt/openssl@3/lib/libcrypto.dylib",
+}
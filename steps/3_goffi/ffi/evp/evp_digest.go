@@ -0,0 +1,115 @@
+//go:build !windows
+
+package evp
+
+import (
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// Digest is a running SHA-256 hash, wrapping the opaque EVP_MD_CTX*
+// handle EVP_MD_CTX_new returned - the same "opaque to Go" contract
+// package sqlite's *DB and *Stmt hold theirs under.
+type Digest struct {
+	ctx uintptr
+}
+
+// NewSHA256 starts a new SHA-256 Digest via EVP_MD_CTX_new/
+// EVP_DigestInit_ex.
+func NewSHA256() (*Digest, error) {
+	if initErr != nil {
+		return nil, initErr
+	}
+
+	newArgs := struct {
+		R uintptr `ffi:"ret"`
+	}{}
+	newSpec := ffi.MakeSpec(mdCtxNewFn, &newArgs)
+	newSpec.Call(unsafe.Pointer(&newArgs))
+	if newArgs.R == 0 {
+		return nil, &Error{Name: "EVP_MD_CTX_new"}
+	}
+	d := &Digest{ctx: newArgs.R}
+
+	initArgs := struct {
+		Ctx  uintptr `ffi:"arg"`
+		MD   uintptr `ffi:"arg"`
+		Impl uintptr `ffi:"arg"`
+		R    int32   `ffi:"ret"`
+	}{Ctx: d.ctx, MD: evpSha256()}
+	initSpec := ffi.MakeSpec(digestInitExFn, &initArgs)
+	initSpec.Call(unsafe.Pointer(&initArgs))
+	if initArgs.R != 1 {
+		d.Free()
+		return nil, &Error{Name: "EVP_DigestInit_ex"}
+	}
+	return d, nil
+}
+
+// Update feeds data into d via EVP_DigestUpdate. It may be called any
+// number of times before Final.
+func (d *Digest) Update(data []byte) error {
+	args := struct {
+		Ctx  uintptr `ffi:"arg"`
+		Data []byte  `ffi:"arg,len=N"`
+		N    uintptr `ffi:"arg"`
+		R    int32   `ffi:"ret"`
+	}{Ctx: d.ctx, Data: data}
+	spec := ffi.MakeSpec(digestUpdateFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 1 {
+		return &Error{Name: "EVP_DigestUpdate"}
+	}
+	return nil
+}
+
+// Final completes the hash via EVP_DigestFinal_ex and returns the
+// resulting digest bytes. Out is a fixed [evpMaxMDSize]byte array -
+// EVP_DigestFinal_ex writes at most that many bytes into whatever
+// buffer it's given regardless of which digest produced them - tagged
+// ffi:"out" so Call zeroes it before the call the same way
+// out_test.go's outStructArgs does, rather than this code depending on
+// EVP_DigestFinal_ex to leave the untouched tail of a larger buffer
+// alone. d must not be reused after Final; call NewSHA256 again for
+// another hash.
+func (d *Digest) Final() ([]byte, error) {
+	var out [evpMaxMDSize]byte
+	var size uint32
+	args := struct {
+		Ctx  uintptr             `ffi:"arg"`
+		Out  *[evpMaxMDSize]byte `ffi:"out"`
+		Size unsafe.Pointer      `ffi:"arg"`
+		R    int32               `ffi:"ret"`
+	}{Ctx: d.ctx, Out: &out, Size: unsafe.Pointer(&size)}
+	spec := ffi.MakeSpec(digestFinalExFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 1 {
+		return nil, &Error{Name: "EVP_DigestFinal_ex"}
+	}
+	return append([]byte(nil), out[:size]...), nil
+}
+
+// Free releases d via EVP_MD_CTX_free - NewSHA256's necessary
+// counterpart, the same way sqlite.Stmt.Finalize is sqlite.DB.Prepare's.
+func (d *Digest) Free() {
+	args := struct {
+		Ctx uintptr `ffi:"arg"`
+	}{Ctx: d.ctx}
+	spec := ffi.MakeSpec(mdCtxFreeFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+}
+
+// SHA256 hashes data in one call via NewSHA256/Update/Final/Free, for a
+// caller with no need to stream input incrementally.
+func SHA256(data []byte) ([]byte, error) {
+	d, err := NewSHA256()
+	if err != nil {
+		return nil, err
+	}
+	defer d.Free()
+	if err := d.Update(data); err != nil {
+		return nil, err
+	}
+	return d.Final()
+}
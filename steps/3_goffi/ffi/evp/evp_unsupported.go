@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package evp
+
+// libNames is tried in order by init below - Debian/Ubuntu's libcrypto
+// carries its OpenSSL major version in the soname (libcrypto.so.3 for
+// OpenSSL 3.x, libcrypto.so.1.1 for 1.1), so unlike libc.so.6 there's no
+// single name every distribution and OpenSSL version agrees on.
+var libNames = []string{"libcrypto.so.3", "libcrypto.so.1.1", "libcrypto.so"}
@@ -0,0 +1,70 @@
+package ffi
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Executor runs submitted jobs on a fixed-size pool of OS threads, each
+// locked for its own lifetime via runtime.LockOSThread - for a program
+// that calls many slow-to-return C functions concurrently and wants to
+// cap how many Ms that creates. Call blocks in its own goroutine while
+// the foreign function runs, which, left unbounded, drives the runtime
+// to spin up a fresh M for every other goroutine that still wants to run
+// Go code; an Executor trades that unbounded growth for a fixed number
+// of dedicated threads and a queue, at the cost of blocking_calls
+// exceeding the pool size waiting their turn. Build one with
+// NewExecutor. Unlike ThreadedLibrary (library_thread.go), which pins
+// every call to exactly one specific thread for a library that needs
+// that, Executor's workers are interchangeable - any worker can run any
+// job - so it fits a pool of otherwise-independent blocking calls
+// instead.
+type Executor struct {
+	jobs chan func()
+	wg   sync.WaitGroup
+}
+
+// NewExecutor starts an Executor with the given number of worker
+// threads. workers must be at least 1.
+func NewExecutor(workers int) *Executor {
+	if workers < 1 {
+		panic("ffi: NewExecutor: workers must be at least 1")
+	}
+	e := &Executor{jobs: make(chan func())}
+	e.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go e.worker()
+	}
+	return e
+}
+
+func (e *Executor) worker() {
+	defer e.wg.Done()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	for job := range e.jobs {
+		job()
+	}
+}
+
+// Call runs fn on whichever worker picks it up next and blocks until it
+// returns. fn is typically a closure around Spec.Call for a blocking C
+// function - queuing it here instead of calling it directly from the
+// caller's own goroutine is what keeps a burst of concurrent callers
+// from each claiming an M of their own.
+func (e *Executor) Call(fn func()) {
+	done := make(chan struct{})
+	e.jobs <- func() {
+		fn()
+		close(done)
+	}
+	<-done
+}
+
+// Close stops accepting new work and waits for every worker thread to
+// exit once its current job (if any) finishes. Any Call issued after
+// Close panics, the same way sending on a closed channel does.
+func (e *Executor) Close() {
+	close(e.jobs)
+	e.wg.Wait()
+}
@@ -0,0 +1,85 @@
+package ffi
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestExecutorRunsAllJobs confirms every job submitted across many
+// goroutines actually runs exactly once, regardless of how many workers
+// are in the pool relative to the number of concurrent callers.
+func TestExecutorRunsAllJobs(t *testing.T) {
+	e := NewExecutor(3)
+	defer e.Close()
+
+	var n atomic.Int64
+	var wg sync.WaitGroup
+	const jobs = 50
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Call(func() {
+				n.Add(1)
+			})
+		}()
+	}
+	wg.Wait()
+
+	if got := n.Load(); got != jobs {
+		t.Fatalf("jobs run: got %d, want %d", got, jobs)
+	}
+}
+
+// TestExecutorBoundsConcurrency confirms no more than the pool's worker
+// count ever run jobs at once - the entire point of Executor over just
+// spawning a goroutine per blocking call. It submits more jobs than
+// there are workers, each of which blocks on release and reports itself
+// as started; once exactly `workers` of them have reported in, the rest
+// must still be queued (blocked sending on e.jobs), since nothing else
+// could be running their job() bodies to report in too.
+func TestExecutorBoundsConcurrency(t *testing.T) {
+	const workers = 2
+	const extra = 3
+	e := NewExecutor(workers)
+	defer e.Close()
+
+	started := make(chan struct{}, workers+extra)
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < workers+extra; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Call(func() {
+				started <- struct{}{}
+				<-release
+			})
+		}()
+	}
+
+	for i := 0; i < workers; i++ {
+		<-started
+	}
+	select {
+	case <-started:
+		t.Fatal("more than `workers` jobs reported started before any were released")
+	default:
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestExecutorClosePanicsOnLateCall(t *testing.T) {
+	e := NewExecutor(1)
+	e.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Call after Close: expected a panic, got none")
+		}
+	}()
+	e.Call(func() {})
+}
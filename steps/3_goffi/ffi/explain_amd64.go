@@ -0,0 +1,71 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// prototype renders spec's computed calling convention as a single C
+// declaration - spec's symbol (SetSymbol) if one was given, or "fn"
+// otherwise, with its return type taken from whichever return slot is
+// in use and its parameters in struct-offset order (the order a packed
+// args struct's fields were declared in, and so the order the real C
+// function expects them). It's a best-effort reconstruction for eyeball
+// comparison, not a guarantee of byte-for-byte field order when padding
+// makes two fields' offsets land out of declaration order.
+func (spec *Spec) prototype() string {
+	infos := spec.Args()
+
+	var params []ArgInfo
+	ret := "void"
+	for _, info := range infos {
+		if info.Ret {
+			ret = info.Kind.String()
+			continue
+		}
+		params = append(params, info)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Offset < params[j].Offset })
+
+	name := spec.symbol
+	if name == "" {
+		name = "fn"
+	}
+
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.Kind.String()
+	}
+	return fmt.Sprintf("%s %s(%s)", ret, name, strings.Join(parts, ", "))
+}
+
+// Explain writes spec's computed calling convention to w as a table -
+// one row per register or stack slot Args reports, plus the C
+// declaration prototype synthesizes from it - so a caller can eyeball
+// a Spec's classification against the real function's declaration
+// before ever resolving a symbol and calling it for real. It's String's
+// io.Writer-based, tabular counterpart; unlike String, an error writing
+// to w is reported instead of silently dropped.
+func (spec *Spec) Explain(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "LOCATION\tROLE\tOFFSET\tKIND\tSIZE\tEXTENSION")
+	for _, info := range spec.Args() {
+		role := "arg"
+		if info.Ret {
+			role = "ret"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\t%s\n",
+			info.Location, role, info.Offset, info.Kind, info.Size, info.extension())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s\n", spec.prototype())
+	return err
+}
@@ -0,0 +1,33 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestExplainRendersTableAndPrototype confirms Explain writes a row for
+// each location Args reports plus a synthesized C prototype, without
+// ever calling spec.fn.
+func TestExplainRendersTableAndPrototype(t *testing.T) {
+	spec := MustSpecFor[funcAddArgs](funcPC(addPairFlatTarget))
+	spec.SetSymbol("add")
+
+	var buf bytes.Buffer
+	if err := spec.Explain(&buf); err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+
+	out := buf.String()
+	for _, loc := range []string{"RDI", "RSI", "RAX"} {
+		if !strings.Contains(out, loc) {
+			t.Fatalf("Explain: %q missing location %q", out, loc)
+		}
+	}
+
+	if !strings.Contains(out, "int64 add(int64, int64)") {
+		t.Fatalf("Explain: %q missing expected prototype", out)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCallFast exercises CallFast end to end via addPairTarget
+// (aggregate_amd64_test.s), confirming it reaches spec.fn and stores the
+// result back into R exactly like Call does, just without the
+// asmcgocall/entersyscall round trip - addPairTarget's single ADDQ is
+// well within the "returns quickly without blocking" contract CallFast
+// requires of its target.
+func TestCallFast(t *testing.T) {
+	args := pairArgs{P: struct{ X, Y int64 }{X: 3, Y: 4}}
+	spec := MakeSpec(addrOfAddPairTarget(), &args)
+
+	spec.CallFast(unsafe.Pointer(&args))
+
+	if args.R != 7 {
+		t.Fatalf("CallFast: got R=%d, want 7", args.R)
+	}
+}
@@ -1,13 +1,22 @@
+// Package ffi lets Go code call C functions, and be called back from C,
+// without cgo: MakeSpec classifies a Go struct's fields per the target
+// architecture's calling convention and Call drives the foreign call
+// through a small per-arch assembly trampoline.
 package ffi
 
 import (
 	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
+	"unicode/utf16"
 	"unsafe"
 )
 
-//go:generate go tool compile -asmhdr ffi.h ffi.go
-
+// argtype says how to load/store one scalar argument or return value.
+// It's arch-independent: every backend maps the same Go kinds to the same
+// argtype, even though where that value then goes (a register, the
+// stack, ...) is decided per arch.
 type argtype uint16
 
 const (
@@ -20,189 +29,730 @@ const (
 	typeU8     argtype = 6 // movbqzx  unsigned 8  bit
 	typeDouble argtype = 7 // movsd             64 bit
 	typeFloat  argtype = 8 // movss             32 bit
+
+	// typeAddr loads the argument's own address (base+offset) rather than
+	// its value - used for the hidden pointer a MEMORY-class struct return
+	// passes in RDI per the SysV ABI (see placeRetMemory in ffi_amd64.go).
+	typeAddr argtype = 9
+
+	// typeLongDouble marks a LongDouble (longdouble.go) ffi:"ret" field:
+	// it never goes through loadword/storeword like every other argtype
+	// does, only the dedicated ST0 store each x86 backend's asmcall adds
+	// for it (there's no argument-side use of this argtype - a
+	// LongDouble argument is always memory-class, classified and raw-
+	// copied the same way a plain aggregate's stack-spilled eightbytes
+	// are).
+	typeLongDouble argtype = 10
+
+	// typeM128 and typeM256 mark a M128/M256 (vector.go) field: like
+	// typeLongDouble, asmcall never reaches either through
+	// loadword/storeword - an argument-side M128/M256 register slot is
+	// loaded with a dedicated MOVOU/VMOVDQU straight from memory, and a
+	// ffi:"ret" one is stored back the same way out of XMM0/YMM0, both
+	// bypassing the generic eightbyte-at-a-time machinery entirely.
+	typeM128 argtype = 11
+	typeM256 argtype = 12
+
 	typeUnused argtype = 0xFFFF
 )
 
+// argument describes one scalar or one eightbyte/word of an aggregate: its
+// offset into the Go struct MakeSpec was given, how to load/store it, and
+// (for the tail word of an aggregate whose size isn't a multiple of the
+// arch's word size) how many of those bytes actually belong to it.
 type argument struct {
 	offset uint16
 	t      argtype
+	size   uint8
 }
 
-// Spec is the callspec needed to do the actuall call
-type Spec struct {
-	fn      uintptr
-	base    uintptr
-	stack   []argument
-	intargs [6]argument
-	xmmargs [8]argument
-	ret0    argument
-	ret1    argument
-	xmmret0 argument
-	xmmret1 argument
-	rax     uint8
+// ArgKind is the generator-facing form of argtype: its values are part of
+// this package's API, unlike argtype's own constants, so code generated
+// ahead of time - by cmd/ffigen or a hand-written generator - can bake
+// one into a StaticSpec literal without importing argtype itself.
+type ArgKind uint16
+
+const (
+	ArgInt64      ArgKind = ArgKind(type64)
+	ArgS32        ArgKind = ArgKind(typeS32)
+	ArgU32        ArgKind = ArgKind(typeU32)
+	ArgS16        ArgKind = ArgKind(typeS16)
+	ArgU16        ArgKind = ArgKind(typeU16)
+	ArgS8         ArgKind = ArgKind(typeS8)
+	ArgU8         ArgKind = ArgKind(typeU8)
+	ArgDouble     ArgKind = ArgKind(typeDouble)
+	ArgFloat      ArgKind = ArgKind(typeFloat)
+	ArgAddr       ArgKind = ArgKind(typeAddr)
+	ArgLongDouble ArgKind = ArgKind(typeLongDouble)
+	ArgM128       ArgKind = ArgKind(typeM128)
+	ArgM256       ArgKind = ArgKind(typeM256)
+	ArgUnused     ArgKind = ArgKind(typeUnused)
+)
+
+// String names k the way a C prototype would spell its width/signedness,
+// for Spec.String() and anything else printing an ArgKind for a human to
+// compare against one.
+func (k ArgKind) String() string {
+	switch k {
+	case ArgInt64:
+		return "int64"
+	case ArgS32:
+		return "int32"
+	case ArgU32:
+		return "uint32"
+	case ArgS16:
+		return "int16"
+	case ArgU16:
+		return "uint16"
+	case ArgS8:
+		return "int8"
+	case ArgU8:
+		return "uint8"
+	case ArgDouble:
+		return "double"
+	case ArgFloat:
+		return "float"
+	case ArgAddr:
+		return "addr"
+	case ArgLongDouble:
+		return "long double"
+	case ArgM128:
+		return "__m128"
+	case ArgM256:
+		return "__m256"
+	case ArgUnused:
+		return "unused"
+	}
+	return "ArgKind(?)"
+}
+
+// Arg is the generator-facing mirror of argument: a StaticSpec
+// constructor takes these instead of argument itself, so generated code
+// never needs to see this package's own unexported classification types.
+type Arg struct {
+	Offset uint16
+	Kind   ArgKind
+	Size   uint8
+}
+
+// toArgument converts a to the internal argument a StaticSpec constructor
+// stores in the Spec it builds.
+func (a Arg) toArgument() argument {
+	return argument{offset: a.Offset, t: argtype(a.Kind), size: a.Size}
+}
+
+// toArg is toArgument's inverse, used by Spec.ArgLayout (ffi_amd64.go) to
+// hand a MakeSpec-built Spec's internal tables back out in the same
+// generator-facing form StaticSpec takes them in - round-tripping a
+// Spec through ArgLayout and StaticSpec reproduces its call behavior
+// exactly, modulo the struct-specific extras (cstr/pin/pointer-check
+// fields, errnoFn, variadicTypes) StaticSpec never sets either.
+func (a argument) toArg() Arg {
+	return Arg{Offset: a.offset, Kind: ArgKind(a.t), Size: a.size}
 }
 
 var sliceOffset = reflect.TypeOf(reflect.SliceHeader{}).Field(0).Offset
 
+// stringLenOffset is reflect.StringHeader's Len field's offset - the word
+// immediately following a Go string's Data pointer, on every arch this
+// package supports (both fields are word-sized). strPtrArg uses it to
+// find an ffi:"strptr" field's length word without needing its own
+// per-arch copy of StringHeader's layout.
+var stringLenOffset = reflect.TypeOf(reflect.StringHeader{}).Field(1).Offset
+
+// typeOverrides maps a "type=" tag value to the argument it forces,
+// bypassing the Go field's own Kind() - e.g. a uintptr field tagged
+// `ffi:"arg,type=int32"` is passed as a 32 bit int rather than a full
+// word, for C signatures that don't agree with Go's native widths.
+var typeOverrides = map[string]struct {
+	t    argtype
+	size uint8
+	flt  bool
+}{
+	"int8":    {typeS8, 1, false},
+	"uint8":   {typeU8, 1, false},
+	"int16":   {typeS16, 2, false},
+	"uint16":  {typeU16, 2, false},
+	"int32":   {typeS32, 4, false},
+	"uint32":  {typeU32, 4, false},
+	"int64":   {type64, 8, false},
+	"uint64":  {type64, 8, false},
+	"float32": {typeFloat, 4, true},
+	"float64": {typeDouble, 8, true},
+}
+
+// fieldToOffset maps a scalar (non-aggregate) struct field to the argument
+// describing how to load it, and whether it's a floating-point value. A
+// non-empty "type=" override (t) takes precedence over the field's Go
+// Kind(); otherwise the mapping is the same on every architecture. What
+// differs per arch is which register file (if any) that argument is then
+// placed into, which is decided by each arch's MakeSpec.
+//
+// This is also why package ctypes needs no case of its own here: CLong,
+// CSizeT and friends are each just a plain defined integer type whose
+// width is picked per platform at build time, so Kind() alone already
+// reports the right one of the cases below.
+//
+// A fixed (prototyped) field is always loaded at exactly the width its
+// Go type says, never promoted: C's default argument promotions - a
+// narrower-than-int integer widening to int, float widening to double -
+// only apply to a call with no prototype in scope for that argument, and
+// a fixed args struct field always corresponds to one the target's
+// prototype names explicitly. The variadic tail is the one place those
+// promotions are real; see PromoteVariadicArg (promote.go), which
+// CallVariadic's caller uses to apply them instead of finding this
+// function's per-field dispatch doing it silently.
 func fieldToOffset(k reflect.StructField, t string) (argument, bool) {
+	if o, ok := typeOverrides[t]; ok {
+		return argument{offset: uint16(k.Offset), t: o.t, size: o.size}, o.flt
+	}
+	if k.Type == float16Type {
+		// A Float16 field's Kind() is Uint16, indistinguishable from a
+		// plain uint16 by Kind() alone - checked here, ahead of the
+		// switch below, so it's classified into the float register file
+		// (xmm/V, on the archs that have one) rather than the integer
+		// one a bare uint16 field uses.
+		return argument{offset: uint16(k.Offset), t: typeU16, size: 2}, true
+	}
 	switch k.Type.Kind() {
 	case reflect.Slice:
-		return argument{uint16(k.Offset + sliceOffset), type64}, false
-	case reflect.Int, reflect.Uint, reflect.Uint64, reflect.Int64, reflect.Ptr:
-		return argument{uint16(k.Offset), type64}, false
+		return argument{offset: uint16(k.Offset + sliceOffset), t: type64, size: 8}, false
+	case reflect.Int, reflect.Uint, reflect.Uint64, reflect.Int64, reflect.Uintptr, reflect.Ptr, reflect.UnsafePointer:
+		return argument{offset: uint16(k.Offset), t: type64, size: 8}, false
 	case reflect.Int32:
-		return argument{uint16(k.Offset), typeS32}, false
+		return argument{offset: uint16(k.Offset), t: typeS32, size: 4}, false
 	case reflect.Uint32:
-		return argument{uint16(k.Offset), typeU32}, false
+		return argument{offset: uint16(k.Offset), t: typeU32, size: 4}, false
 	case reflect.Int16:
-		return argument{uint16(k.Offset), typeS16}, false
+		return argument{offset: uint16(k.Offset), t: typeS16, size: 2}, false
 	case reflect.Uint16:
-		return argument{uint16(k.Offset), typeU16}, false
+		return argument{offset: uint16(k.Offset), t: typeU16, size: 2}, false
 	case reflect.Int8:
-		return argument{uint16(k.Offset), typeS8}, false
+		return argument{offset: uint16(k.Offset), t: typeS8, size: 1}, false
 	case reflect.Uint8, reflect.Bool:
-		return argument{uint16(k.Offset), typeU8}, false
+		return argument{offset: uint16(k.Offset), t: typeU8, size: 1}, false
 	case reflect.Float32:
-		return argument{uint16(k.Offset), typeFloat}, true
+		return argument{offset: uint16(k.Offset), t: typeFloat, size: 4}, true
 	case reflect.Float64:
-		return argument{uint16(k.Offset), typeDouble}, true
+		return argument{offset: uint16(k.Offset), t: typeDouble, size: 8}, true
+	case reflect.Complex64:
+		// complex64 is two packed float32s - 8 bytes, the same single SSE
+		// eightbyte a float64 occupies. loadword/storeword only ever move
+		// its bits as an opaque 8 byte value, so typeDouble already does
+		// the right thing with no new argtype.
+		return argument{offset: uint16(k.Offset), t: typeDouble, size: 8}, true
 	}
-	panic("Unknown Type")
+	panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "unsupported field kind " + k.Type.Kind().String()})
 }
 
-// FIXME: we don't support stuff > 64 bit
+// flattenFields returns t's fields in declaration order, the same as
+// t.Field(i) for i in [0, t.NumField()), except that a plain (no ffi tag
+// at all) anonymous struct field is replaced inline by its own flattened
+// fields instead of being handed back as one field: this is the shape a
+// shared "header" struct - say, a handle and a flags word several args
+// structs all need - takes when it's embedded rather than copy-pasted,
+// and every arch's MakeSpec should see each of its fields land in the
+// argument sequence exactly where they would have if they'd been copied
+// in by hand, not classified as a single C struct-by-value argument.
+// Recursion covers an embedded struct that itself embeds another one.
+//
+// A field's Offset, as returned here, is always relative to the
+// outermost struct t - the same convention every field MakeSpec
+// classifies already assumes - so no caller needs to know flattening
+// happened.
+//
+// An anonymous struct field that carries any ffi tag - ret, cstr, pin,
+// and so on - is left alone and returned as a single field instead of
+// being flattened: those tags describe the field as a whole, the same
+// as they would on an ordinary named struct field (e.g. a genuine
+// by-value C struct argument/return classified via classifyEightbytes/
+// placeArgAggregate), and flattening would make them ambiguous about
+// which leaf they'd apply to.
+func flattenFields(t reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("ffi") == "" {
+			for _, nested := range flattenFields(f.Type) {
+				nested.Offset += f.Offset
+				fields = append(fields, nested)
+			}
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
 
-// MakeSpec builds a call specification for the given arguments
-func MakeSpec(fn uintptr, args interface{}) Spec {
-	v := reflect.ValueOf(args)
-	for v.Kind() == reflect.Ptr {
-		v = v.Elem()
+// cstrArg validates an `ffi:"cstr"` field and returns the plain pointer
+// argument MakeSpec places it as: cstr only replaces what prepareCStrings
+// writes into the field's own Data word (see below) before the call, so
+// from the trampoline's point of view it's just another pointer, loaded
+// exactly like a Slice field's Data word - ptrType/ptrSize are the same
+// pointer-sized argument each arch's fieldToOffset (or fieldToOffset386)
+// already uses for a Ptr/Slice field.
+func cstrArg(k reflect.StructField, ret bool, ptrType argtype, ptrSize uint8) argument {
+	if k.Type.Kind() != reflect.String {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"cstr\" on a non-string field"})
+	}
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"cstr\" is not supported on a ffi:\"ret\" field"})
 	}
-	t := v.Type()
+	return argument{offset: uint16(k.Offset), t: ptrType, size: ptrSize}
+}
 
-	var spec Spec
+// wstrArg validates an `ffi:"wstr"` field and returns the plain pointer
+// argument MakeSpec places it as: like cstr, wstr only replaces what
+// prepareWStrings writes into the field's own Data word (see below)
+// before the call, so from the trampoline's point of view it's just
+// another pointer. This is the tag to reach for on Windows, where the
+// "W" half of nearly every ANSI/Unicode API pair (CreateFileW,
+// MessageBoxW, ...) wants a UTF-16 LPCWSTR rather than cstr's
+// NUL-terminated char*.
+func wstrArg(k reflect.StructField, ret bool, ptrType argtype, ptrSize uint8) argument {
+	if k.Type.Kind() != reflect.String {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"wstr\" on a non-string field"})
+	}
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"wstr\" is not supported on a ffi:\"ret\" field"})
+	}
+	return argument{offset: uint16(k.Offset), t: ptrType, size: ptrSize}
+}
 
-	spec.fn = fn
+// strPtrArg validates an `ffi:"strptr"` field and returns the two plain
+// word arguments MakeSpec places consecutively for it: the Go string's
+// own Data word, handed to the callee exactly as it already sits -
+// unlike ffi:"cstr" (prepareCStrings, below), this never copies the
+// string or NUL-terminates it - followed by its Len word as a size_t.
+// For a C signature like write(fd, buf, count) that takes an explicit
+// length instead of expecting a NUL terminator, this avoids cstr's copy
+// entirely. wordType/wordSize are the same native-word-size argument
+// each arch's fieldToOffset (or fieldToOffset386) already uses for a
+// Ptr/Slice field.
+func strPtrArg(f reflect.StructField, ret bool, wordType argtype, wordSize uint8) (data, length argument) {
+	if f.Type.Kind() != reflect.String {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"strptr\" on a non-string field"})
+	}
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"strptr\" is not supported on a ffi:\"ret\" field"})
+	}
+	data = argument{offset: uint16(f.Offset), t: wordType, size: wordSize}
+	length = argument{offset: uint16(f.Offset) + uint16(stringLenOffset), t: wordType, size: wordSize}
+	return data, length
+}
 
-	spec.ret0.t = typeUnused
-	spec.ret1.t = typeUnused
-	spec.xmmret0.t = typeUnused
-	spec.xmmret1.t = typeUnused
+// prepareCStrings backs every offset in cstrFields with a NUL-terminated
+// copy of the Go string already sitting at args+offset, bump-allocated
+// out of a (see arena.go) rather than each copy costing its own Go-heap
+// allocation, and overwrites that string's own Data word with the copy's
+// address - the same trick fieldToOffset's Slice case relies on to hand
+// the trampoline a plain pointer to load. It returns a cleanup that must
+// run once the call completes: it restores each field's original Data
+// word and keeps a (and so every copy carved out of it) reachable until
+// then, so the call's string arguments survive a GC that runs while the
+// foreign function is on the stack.
+func prepareCStrings(args unsafe.Pointer, cstrFields []uint16, a *arena) func() {
+	if len(cstrFields) == 0 {
+		return func() {}
+	}
+	saved := make([]unsafe.Pointer, len(cstrFields))
+	for i, off := range cstrFields {
+		s := *(*string)(unsafe.Pointer(uintptr(args) + uintptr(off)))
+		buf := a.alloc(len(s) + 1)
+		copy(buf, s)
 
-	haveRet := false
+		data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(off)))
+		saved[i] = *data
+		*data = unsafe.Pointer(&buf[0])
+	}
+	return func() {
+		for i, off := range cstrFields {
+			data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(off)))
+			*data = saved[i]
+		}
+		_Cgo_use(a)
+	}
+}
 
-	intreg := 0
-	xmmreg := 0
+// prepareWStrings is prepareCStrings for `ffi:"wstr"` fields: it backs
+// every offset in wstrFields with a UTF-16, NUL-terminated copy of the
+// Go string already sitting at args+offset, bump-allocated out of a the
+// same way, and overwrites that string's own Data word with the copy's
+// address. The returned cleanup restores each field's original Data
+// word, exactly like prepareCStrings'.
+func prepareWStrings(args unsafe.Pointer, wstrFields []uint16, a *arena) func() {
+	if len(wstrFields) == 0 {
+		return func() {}
+	}
+	saved := make([]unsafe.Pointer, len(wstrFields))
+	for i, off := range wstrFields {
+		s := *(*string)(unsafe.Pointer(uintptr(args) + uintptr(off)))
+		units := utf16.Encode([]rune(s))
+		buf := a.alloc(2 * (len(units) + 1))
+		for j, u := range units {
+			buf[2*j] = byte(u)
+			buf[2*j+1] = byte(u >> 8)
+		}
+		buf[2*len(units)] = 0
+		buf[2*len(units)+1] = 0
 
-ARGS:
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		tags := strings.Split(f.Tag.Get("ffi"), ",")
-		ret := false
-		st := ""
-		for _, tag := range tags {
-			if tag == "ignore" {
-				continue ARGS
-			}
-			if tag == "ret" {
-				if haveRet == true {
-					panic("Only one return argument allowed")
-				}
-				ret = true
-				haveRet = true
-				continue
-			}
-			if strings.HasPrefix(tag, "type=") {
-				st = tag[5:]
-			}
+		data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(off)))
+		saved[i] = *data
+		*data = unsafe.Pointer(&buf[0])
+	}
+	return func() {
+		for i, off := range wstrFields {
+			data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(off)))
+			*data = saved[i]
 		}
-		if ret {
-			off, xmm := fieldToOffset(f, st)
-			if xmm {
-				spec.xmmret0 = off
-			} else {
-				spec.ret0 = off
+		_Cgo_use(a)
+	}
+}
+
+// fieldTags is the parsed form of a field's `ffi:"..."` struct tag.
+type fieldTags struct {
+	ignore bool
+	ret    bool
+	cstr   bool
+	wstr   bool
+	strptr bool
+	pin    bool
+	ptr    bool
+	sret   bool
+	out    bool
+	errno  bool   // the "errno" tag, if any - see retErrno in errno_ret.go
+	fnptr  bool   // the "fnptr" tag, if any - see CallVia in fnptr_amd64.go
+	typ    string // the "type=" override, if any
+	lenOf  string // the "len=" companion field name, if any
+	capOf  string // the "cap=" companion field name, if any
+	bits   int    // the "bits=N" width, if any (0 means untagged)
+	packed bool   // the "packed" tag, if any
+	align  int    // the "align=N" alignment, if any (0 means untagged)
+	nilTag   string // the "nil=" mode ("panic" or "empty"), if any
+	readonly bool   // the "readonly" tag, if any - see readonlyArg
+}
+
+// parseFieldTags parses f's `ffi` tag. Each arch's MakeSpec calls this for
+// every field before deciding how to classify and place it.
+func parseFieldTags(f reflect.StructField) fieldTags {
+	var ft fieldTags
+	for _, tag := range strings.Split(f.Tag.Get("ffi"), ",") {
+		switch {
+		case tag == "ignore":
+			ft.ignore = true
+		case tag == "ret":
+			ft.ret = true
+		case tag == "cstr":
+			ft.cstr = true
+		case tag == "wstr":
+			ft.wstr = true
+		case tag == "strptr":
+			ft.strptr = true
+		case tag == "pin":
+			ft.pin = true
+		case tag == "ptr":
+			ft.ptr = true
+		case tag == "sret":
+			ft.sret = true
+		case tag == "out":
+			ft.out = true
+		case tag == "errno":
+			ft.errno = true
+		case tag == "fnptr":
+			ft.fnptr = true
+		case tag == "packed":
+			ft.packed = true
+		case tag == "readonly":
+			ft.readonly = true
+		case strings.HasPrefix(tag, "align="):
+			n, err := strconv.Atoi(tag[6:])
+			if err != nil {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"align=" + tag[6:] + "\": not a number"})
 			}
-			// FIXME ret1/xmmret1! - only needed for types > 64 bit
-			continue
-		}
-		off, xmm := fieldToOffset(f, st)
-		if xmm {
-			if xmmreg < 8 {
-				spec.xmmargs[xmmreg] = off
-				xmmreg++
-			} else {
-				spec.stack = append(spec.stack, off)
+			ft.align = n
+		case strings.HasPrefix(tag, "type="):
+			ft.typ = tag[5:]
+		case strings.HasPrefix(tag, "len="):
+			ft.lenOf = tag[4:]
+		case strings.HasPrefix(tag, "cap="):
+			ft.capOf = tag[4:]
+		case strings.HasPrefix(tag, "bits="):
+			n, err := strconv.Atoi(tag[5:])
+			if err != nil {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=" + tag[5:] + "\": not a number"})
 			}
-		} else {
-			if intreg < 6 {
-				spec.intargs[intreg] = off
-				intreg++
-			} else {
-				spec.stack = append(spec.stack, off)
+			if n < 1 {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=\" width must be at least 1"})
 			}
+			ft.bits = n
+		case strings.HasPrefix(tag, "nil="):
+			ft.nilTag = tag[4:]
 		}
 	}
-	for i := intreg; i < 6; i++ {
-		spec.intargs[i].t = typeUnused
+	return ft
+}
+
+// lenField records a slice field and the sibling integer field MakeSpec
+// found named in its `ffi:"len=Field"`/`ffi:"cap=Field"` tag, so
+// prepareLenFields can keep that field populated with len(slice) (or
+// cap(slice)) before every call - removing the chance to pass a C
+// function the wrong count, or forget to update it, that comes with
+// setting a length argument by hand.
+type lenField struct {
+	sliceOffset  uint16
+	cap          bool
+	targetOffset uint16
+	targetType   reflect.Type
+}
+
+// lenArg validates an `ffi:"len=Field"`/`ffi:"cap=Field"` tag on slice
+// field f and returns the lenField MakeSpec stores for it: name must
+// name a sibling integer field of t, the same struct f itself is a
+// field of.
+func lenArg(t reflect.Type, f reflect.StructField, name string, cap bool) lenField {
+	if f.Type.Kind() != reflect.Slice {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"len=\"/\"cap=\" on a field that isn't a slice"})
+	}
+	target, ok := t.FieldByName(name)
+	if !ok {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"len=" + name + "\"/\"cap=" + name + "\": no such field"})
 	}
-	for i := xmmreg; i < 8; i++ {
-		spec.xmmargs[i].t = typeUnused
+	switch target.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"len=" + name + "\"/\"cap=" + name + "\" names a field that isn't an integer"})
 	}
-	spec.rax = uint8(xmmreg)
-	return spec
+	return lenField{sliceOffset: uint16(f.Offset), cap: cap, targetOffset: uint16(target.Offset), targetType: target.Type}
 }
 
-// Call calls the given spec with the given arguments
-func (spec Spec) Call(args unsafe.Pointer) {
-	spec.base = uintptr(args)
-
-	entersyscall()
-	asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&spec)))
-	exitsyscall()
+// prepareLenFields writes len(slice) (or, for an `ffi:"cap="` field,
+// cap(slice)) into each lenField's target integer field, right before
+// every call - the same reflect.NewAt-based width-correct write
+// checkArgPointers (pointercheck.go) uses to hand a properly typed value
+// to the runtime's pointer checker, here used to hand a plain count to
+// whichever integer Kind the sibling field happens to be.
+func prepareLenFields(args unsafe.Pointer, fields []lenField) {
+	for _, lf := range fields {
+		sh := (*reflect.SliceHeader)(unsafe.Pointer(uintptr(args) + uintptr(lf.sliceOffset)))
+		n := sh.Len
+		if lf.cap {
+			n = sh.Cap
+		}
+		v := reflect.NewAt(lf.targetType, unsafe.Pointer(uintptr(args)+uintptr(lf.targetOffset))).Elem()
+		if k := lf.targetType.Kind(); k >= reflect.Int && k <= reflect.Int64 {
+			v.SetInt(int64(n))
+		} else {
+			v.SetUint(uint64(n))
+		}
+	}
+}
 
-	if _Cgo_always_false {
-		_Cgo_use(args)
-		_Cgo_use(spec)
+// pinArg validates an `ffi:"pin"` field and returns the offset
+// preparePinning must read the pinned pointer's value from: for a Slice
+// field that's its Data word (the same offset fieldToOffset's Slice case
+// already uses), for a Ptr/UnsafePointer field it's the field itself.
+// pin exists for args only - a ffi:"ret" field is written by the callee
+// after the call, nothing Go-side needs pinned going in.
+func pinArg(f reflect.StructField, ret bool) uint16 {
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"pin\" is not supported on a ffi:\"ret\" field"})
+	}
+	switch f.Type.Kind() {
+	case reflect.Slice:
+		return uint16(f.Offset) + uint16(sliceOffset)
+	case reflect.Ptr, reflect.UnsafePointer:
+		return uint16(f.Offset)
 	}
+	panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"pin\" on a field that isn't a pointer or slice"})
 }
 
-//go:linkname _Cgo_always_false runtime.cgoAlwaysFalse
-var _Cgo_always_false bool
+// ptrArg validates an `ffi:"ptr"` field and returns the argument that
+// loads its own address rather than its contents: the trampoline's
+// typeAddr case, the same one a MEMORY-class struct return's hidden
+// pointer already uses. It lets a [N]T array field decay to a plain
+// pointer - to the C function's eyes indistinguishable from a Slice
+// field's Data word - instead of going through by-value aggregate
+// classification, for buffer-shaped C APIs that take a pointer (and,
+// often, a separate length) rather than the array itself.
+func ptrArg(f reflect.StructField, ret bool) argument {
+	if f.Type.Kind() != reflect.Array {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"ptr\" is only supported on an array field"})
+	}
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"ptr\" is not supported on a ffi:\"ret\" field"})
+	}
+	return argument{offset: uint16(f.Offset), t: typeAddr, size: 8}
+}
 
-//go:linkname _Cgo_use runtime.cgoUse
-func _Cgo_use(interface{})
+// sretArg validates an `ffi:"sret"` field and returns the argument that
+// loads its own value, the same way fieldToOffset already would for a bare
+// Ptr/UnsafePointer/Uintptr field: sret is for a C function returning a
+// struct larger than the ABI's register-return limit, which it takes back
+// via a hidden pointer argument instead - the caller allocates the return
+// storage itself and hands MakeSpec its address through this field, rather
+// than embedding the struct inline in the args type for MakeSpec to
+// classify (and place in registers, if it turns out small enough not to
+// need a hidden pointer at all).
+func sretArg(f reflect.StructField) argument {
+	switch f.Type.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Uintptr:
+		return argument{offset: uint16(f.Offset), t: type64, size: 8}
+	}
+	panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"sret\" on a field that isn't a pointer"})
+}
 
-//go:linkname asmcgocall runtime.asmcgocall
-func asmcgocall(unsafe.Pointer, uintptr) int32
+// preparePinning pins the object behind every offset in pinFields for the
+// duration of one call, via a runtime.Pinner: unlike _Cgo_use, which only
+// keeps a value alive, Pin also guarantees the pinned object's address
+// won't change out from under the foreign call if a future Go GC starts
+// moving objects. It returns the Pinner's Unpin method, for the caller to
+// run once the call completes. A nil pointer field is left unpinned - Pin
+// itself would panic on one, and there's nothing there to protect.
+func preparePinning(args unsafe.Pointer, pinFields []uint16) func() {
+	if len(pinFields) == 0 {
+		return func() {}
+	}
+	var pinner runtime.Pinner
+	for _, off := range pinFields {
+		if p := *(*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(off))); p != nil {
+			pinner.Pin(p)
+		}
+	}
+	return pinner.Unpin
+}
 
-//go:linkname entersyscall runtime.entersyscall
-func entersyscall()
+// emptyBuf is the zero-length, non-NULL buffer prepareNilFields
+// substitutes for an ffi:"nil=empty" field's NULL: some C APIs are
+// documented to require a non-NULL pointer even for a zero-length
+// read/write (memcpy's own contract is the classic example), so a Go
+// nil slice or pointer - which already decays to a NULL address, per
+// fieldToOffset/pinArg's offsets - needs somewhere real to point
+// instead, not just any non-NULL address.
+var emptyBuf byte
 
-//go:linkname exitsyscall runtime.exitsyscall
-func exitsyscall()
+// nilField records one ffi:"nil=panic"/"nil=empty" field: the same
+// offset pinArg computes for a slice's Data word or a bare pointer
+// field, plus which of the two behaviors prepareNilFields should apply
+// when that word is found to be NULL going into a call.
+type nilField struct {
+	offset uint16
+	empty  bool
+}
 
-func asmcall()
+// nilArg validates an `ffi:"nil=panic"/"nil=empty"` tag on f and returns
+// the nilField MakeSpec stores for it. Only a Slice or a bare Ptr/
+// UnsafePointer field is accepted - the same restriction pinArg places
+// on ffi:"pin" - since those are the only argument kinds this package
+// ever passes as a bare address a C signature might special-case
+// against NULL; ffi:"cstr"/"strptr"/"pin" fields already have their own
+// established NULL behavior (a nil string/slice there already passes
+// NULL, same as today) and aren't reachable here. There is deliberately
+// no support for a nil map: this package has no argument kind for a Go
+// map at all yet (fieldToOffset has no case for reflect.Map), so there
+// is nothing for a "nil=" tag to modify. Like ffi:"pin", it exists for
+// args only - a ffi:"ret" field is written by the callee, not read
+// going in, so there's nothing there for NULL to mean.
+func nilArg(f reflect.StructField, ret bool, mode string) nilField {
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"nil=\" is not supported on a ffi:\"ret\" field"})
+	}
+	var offset uint16
+	switch f.Type.Kind() {
+	case reflect.Slice:
+		offset = uint16(f.Offset) + uint16(sliceOffset)
+	case reflect.Ptr, reflect.UnsafePointer:
+		offset = uint16(f.Offset)
+	default:
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"nil=\" on a field that isn't a pointer or slice"})
+	}
+	switch mode {
+	case "panic":
+		return nilField{offset: offset, empty: false}
+	case "empty":
+		return nilField{offset: offset, empty: true}
+	}
+	panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"nil=" + mode + "\": unknown mode, want panic or empty"})
+}
 
-//go:linkname x_cgo_init x_cgo_init
-func x_cgo_init()
+// prepareNilFields implements fields' ffi:"nil="-tagged behavior right
+// before a call: a field tagged "nil=panic" whose word is NULL panics
+// immediately, before spec.fn is ever reached, rather than letting a C
+// function that doesn't expect NULL fail in whatever way it fails; one
+// tagged "nil=empty" has &emptyBuf written into its word in place of
+// NULL for the duration of the call. Without either tag (the default,
+// unchanged from before this existed), a nil slice or pointer field is
+// passed through as NULL exactly as fieldToOffset/pinArg already send
+// it - the "reflect.SliceHeader offset trick" continues to be all that
+// happens for every field that isn't tagged.
+//
+// The returned func restores every substituted word back to NULL once
+// the call returns, so args keeps reading as the nil slice/pointer the
+// caller actually set, the same way prepareCStrings leaves a marshaled
+// ffi:"cstr" field's pointer restored afterward.
+func prepareNilFields(args unsafe.Pointer, fields []nilField) func() {
+	if len(fields) == 0 {
+		return func() {}
+	}
+	var substituted []uint16
+	emptyAddr := uintptr(unsafe.Pointer(&emptyBuf))
+	for _, nf := range fields {
+		p := (*uintptr)(unsafe.Pointer(uintptr(args) + uintptr(nf.offset)))
+		if *p != 0 {
+			continue
+		}
+		if !nf.empty {
+			panic("ffi: nil pointer/slice passed to a field tagged ffi:\"nil=panic\"")
+		}
+		*p = emptyAddr
+		substituted = append(substituted, nf.offset)
+	}
+	if substituted == nil {
+		return func() {}
+	}
+	return func() {
+		for _, off := range substituted {
+			*(*uintptr)(unsafe.Pointer(uintptr(args) + uintptr(off))) = 0
+		}
+	}
+}
 
-// force _cgo_init into the .data segment (instead of .bss), so our "linker" can overwrite its contents
-//go:linkname _cgo_init _cgo_init
-var _cgo_init = uintptr(10)
+// sliceLenOffset is the offset of reflect.SliceHeader.Len within the
+// header, the Len counterpart to sliceOffset's Data offset - what
+// readonlyArg needs to find a Slice field's element count at call time,
+// since readonlyCheck.go has to know how many bytes to copy into the
+// mprotected page it substitutes for the field's Data word.
+var sliceLenOffset = reflect.TypeOf(reflect.SliceHeader{}).Field(1).Offset
 
-type emptyComplex64 struct {
-	a complex64
+// readonlyField records one ffi:"readonly" field: the offsets of its
+// Slice header's Data and Len words within the args struct, plus the
+// element size needed to turn Len into a byte count. Only a Slice field
+// is accepted - unlike ffi:"pin"/"nil=", a bare Ptr/UnsafePointer field
+// carries no length of its own for readonlyCheck.go to know how much of
+// the pointee to copy and protect, and this package has no companion
+// "len=" tag for a Ptr field to borrow one from (ffi:"len="/"cap=" only
+// ever target a Slice field's own header).
+type readonlyField struct {
+	dataOffset uint16
+	lenOffset  uint16
+	elemSize   uintptr
 }
-type emptyComplex128 complex128
 
-func init() {
-	if _Cgo_always_false {
-		x_cgo_init() // prevent x_cgo_init from being optimized out
+// readonlyArg validates an `ffi:"readonly"` tag on f and returns the
+// readonlyField MakeSpec stores for it. Like ffi:"pin", it exists for
+// args only - a ffi:"ret" field is written by the callee, so there is
+// nothing for readonlyCheck.go to protect against the callee writing to
+// in the first place.
+func readonlyArg(f reflect.StructField, ret bool) readonlyField {
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"readonly\" is not supported on a ffi:\"ret\" field"})
+	}
+	if f.Type.Kind() != reflect.Slice {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"readonly\" is only supported on a Slice field"})
+	}
+	return readonlyField{
+		dataOffset: uint16(f.Offset) + uint16(sliceOffset),
+		lenOffset:  uint16(f.Offset) + uint16(sliceLenOffset),
+		elemSize:   f.Type.Elem().Size(),
 	}
 }
-
-//go:linkname funcPC runtime.funcPC
-func funcPC(f interface{}) uintptr
-
-var asmcallptr = funcPC(asmcall)
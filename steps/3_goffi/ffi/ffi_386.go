@@ -0,0 +1,586 @@
+//go:build !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// CallConv selects which of 386's calling conventions a Spec's asmcall
+// should follow. cdecl and stdcall place every argument on the stack, in
+// the same order buildSpecConv already walks struct fields in; fastcall
+// additionally lifts up to the first two DWORD-or-smaller eligible
+// arguments into ECX/EDX. Win32's API surface is overwhelmingly stdcall
+// (WINAPI); fastcall shows up on a handful of MSVC-compiled entry points
+// that document it explicitly - cdecl remains MakeSpec's default for
+// everything else, including every other GOARCH this package supports.
+type CallConv uint8
+
+const (
+	// ConvCdecl is the caller-owns-the-stack convention MakeSpec already
+	// builds for every 386 Spec today. asmcall needs no special handling
+	// for it at all: its outgoing stack buffer trick (writing arguments
+	// into a fixed frame rather than pushing them) already leaves SP
+	// exactly where it started once spec.fn returns, which is also
+	// exactly what ConvStdcall below needs - see asmcall's own comment.
+	ConvCdecl CallConv = iota
+
+	// ConvStdcall is cdecl's argument placement with callee-cleanup
+	// (`ret N`) instead of caller-cleanup - the convention almost all of
+	// the Win32 API (anything declared WINAPI/__stdcall) uses. It needs
+	// no placement changes from ConvCdecl at all: asmcall always
+	// resets SP from its own captured frame base after calling spec.fn,
+	// which corrects for a stdcall callee's `ret N` exactly as well as it
+	// does nothing at all for a cdecl callee's plain `ret`.
+	ConvStdcall
+
+	// ConvFastcall is MSVC's __fastcall: scanning arguments left to
+	// right, the first two that are DWORD (4 bytes) or smaller go into
+	// ECX and EDX instead of the stack; everything else - including
+	// every 8 byte and floating-point argument, which __fastcall never
+	// register-allocates - stays on the stack in its original relative
+	// order. Like stdcall, the callee cleans up its own stack frame.
+	ConvFastcall
+)
+
+// Spec is the callspec needed to do the actuall call. cdecl and stdcall
+// pass every argument on the stack - there is no register file to
+// classify into - and return integers/pointers in EAX:EDX and floats/
+// doubles in ST0; fastcall additionally carries up to two arguments in
+// fastArg0/fastArg1 instead of spec.stack. See CallConv.
+type Spec struct {
+	fn    uintptr
+	conv  CallConv
+	stack []argument
+	ret0  argument // EAX, or the low word of a 64 bit integer return
+	ret1  argument // EDX, the high word of a 64 bit integer return
+	fret  argument // ST0, for a float32/float64/long double return
+
+	// fastArg0 and fastArg1 hold the first two ConvFastcall-eligible
+	// arguments (typeUnused if conv isn't ConvFastcall, or there aren't
+	// that many eligible ones) - asmcall loads them into ECX/EDX instead
+	// of writing them to the outgoing stack buffer.
+	fastArg0 argument
+	fastArg1 argument
+
+	// cstrFields holds the struct offset of every ffi:"cstr" string
+	// field, for Call to marshal via prepareCStrings before each call.
+	cstrFields []uint16
+
+	// pinFields holds the struct offset of every ffi:"pin" pointer/slice
+	// field, for Call to pin via preparePinning before each call.
+	pinFields []uint16
+
+	// nilFields holds every ffi:"nil=panic"/"nil=empty" pointer/slice
+	// field, for Call to check/substitute via prepareNilFields before each
+	// call and restore after.
+	nilFields []nilField
+
+	// readonlyFields holds every ffi:"readonly" slice field, for
+	// CallReadonlyChecked (readonlycheck.go) to mprotect a copy of before
+	// each call. Call/CallFast/CallBatch themselves never read this -
+	// the readonly check is deliberately not part of the ordinary
+	// prepare/restore pipeline every other tag hooks into, since it needs
+	// to wrap the call itself in CallProtected, not just run before/after
+	// it.
+	readonlyFields []readonlyField
+
+	// lenFields holds every ffi:"len="/"cap=" slice/companion pair, for
+	// Call to keep populated via prepareLenFields before each call.
+	lenFields []lenField
+
+	// marshalFields holds every *T args field MakeSpec found pointing
+	// at a Marshaler, for Call to encode/decode via prepareMarshalFields
+	// around each call.
+	marshalFields []marshalField
+
+	// bitFields holds every ffi:"bits=" run's packed representative
+	// argument, for Call to pack via prepareBitFields before each call and
+	// unpack after.
+	bitFields []bitGroup
+
+	// packedFields holds every ffi:"packed"/"align=N" field, for Call to
+	// copy through a correctly packed/aligned scratch buffer via
+	// preparePackedFields before each call and back after.
+	packedFields []packedField
+
+	// checkFields holds every field checkArgPointers should validate under
+	// GODEBUG=ffipointercheck=1.
+	checkFields []checkField
+
+	// outFields holds every ffi:"out" field, for Call to zero via
+	// zeroOutFields before each call and re-validate via checkArgPointers
+	// after, both under GODEBUG=ffipointercheck=1.
+	outFields []checkField
+}
+
+// callParams is the one small, per-call value Call hands asmcall a
+// pointer to, instead of a *Spec alone: Spec is an immutable template
+// Call never writes to, so one Spec can be shared read-only across
+// goroutines and called concurrently without copying it. base is the
+// args pointer that used to live in Spec.base.
+type callParams struct {
+	spec *Spec
+	base uintptr
+}
+
+// fieldToOffset386 is fieldToOffset, adjusted for cdecl/386: Int, Uint,
+// Ptr and Slice are 32 bit here, unlike the 64 bit word fieldToOffset
+// assumes for them (which is only right for the native word size of 64
+// bit archs and, for slices, the 8 byte SliceHeader.Data field those
+// archs have). Everything else - the explicitly sized kinds, and any
+// "type=" override, which always wins over the Go Kind() - is
+// arch-independent, so it's delegated straight to fieldToOffset.
+func fieldToOffset386(f reflect.StructField, st string) (argument, bool) {
+	if _, override := typeOverrides[st]; !override {
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Uint, reflect.Uintptr, reflect.Ptr, reflect.UnsafePointer:
+			return argument{offset: uint16(f.Offset), t: typeU32, size: 4}, false
+		case reflect.Slice:
+			return argument{offset: uint16(f.Offset) + uint16(sliceOffset), t: typeU32, size: 4}, false
+		}
+	}
+	return fieldToOffset(f, st)
+}
+
+// placeArgAggregate386 pushes a struct/array argument's words onto the
+// stack in order; cdecl has no aggregate-classification rules to apply
+// since nothing is ever passed in a register to begin with.
+func (spec *Spec) placeArgAggregate386(base uintptr, size uintptr) {
+	n := int((size + 3) / 4)
+	for i := 0; i < n; i++ {
+		off := base + uintptr(i)*4
+		sz := uintptr(4)
+		if rem := size - uintptr(i)*4; rem < 4 {
+			sz = rem
+		}
+		spec.stack = append(spec.stack, argument{offset: uint16(off), t: typeU32, size: uint8(sz)})
+	}
+}
+
+// buildSpec builds a cdecl call specification for the given arguments;
+// it's wrapped by the cached, public MakeSpec in cache.go. It's
+// buildSpecConv(fn, args, ConvCdecl) under the hood - see that function
+// for the full classification buildSpec itself used to do inline.
+func buildSpec(fn uintptr, args interface{}) Spec {
+	return buildSpecConv(fn, args, ConvCdecl)
+}
+
+// buildSpecConv is buildSpec, parameterized over CallConv; it's wrapped
+// by the cached, public MakeSpecConv below. conv only affects this
+// function's tail: fastArg0/fastArg1 are lifted out of the stack-passed
+// argument list it classifies the exact same way regardless of
+// convention (every field still walks the same cdecl/stdcall path - see
+// CallConv).
+func buildSpecConv(fn uintptr, args interface{}, conv CallConv) Spec {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	var spec Spec
+	spec.fn = fn
+	spec.conv = conv
+	spec.ret0.t = typeUnused
+	spec.ret1.t = typeUnused
+	spec.fret.t = typeUnused
+	spec.fastArg0.t = typeUnused
+	spec.fastArg1.t = typeUnused
+
+	haveRet := false
+
+	fields := flattenFields(t)
+	bitGroups := collectBitGroups(fields)
+
+	for i := range fields {
+		f := fields[i]
+		tags := parseFieldTags(f)
+		if tags.ignore {
+			continue
+		}
+		ret := false
+		if tags.ret {
+			if haveRet {
+				panic(&SpecError{Kind: ErrMultipleReturns, Msg: "only one ffi:\"ret\" field allowed"})
+			}
+			ret = true
+			haveRet = true
+		}
+
+		if tags.cstr {
+			off := cstrArg(f, ret, typeU32, 4)
+			spec.cstrFields = append(spec.cstrFields, uint16(f.Offset))
+			spec.stack = append(spec.stack, off)
+			continue
+		}
+
+		if tags.strptr {
+			data, length := strPtrArg(f, ret, typeU32, 4)
+			spec.stack = append(spec.stack, data, length)
+			continue
+		}
+
+		if !ret && f.Type.Kind() == reflect.Ptr && marshalElem(f.Type.Elem()) {
+			spec.marshalFields = append(spec.marshalFields, marshalField{offset: uint16(f.Offset), elem: f.Type.Elem()})
+			spec.stack = append(spec.stack, argument{offset: uint16(f.Offset), t: typeU32, size: 4})
+			continue
+		}
+
+		if tags.bits > 0 {
+			if ret {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=\" is not supported on a ffi:\"ret\" field"})
+			}
+			if g, isRep := bitGroupFor(bitGroups, uint16(f.Offset)); isRep {
+				spec.bitFields = append(spec.bitFields, g)
+				spec.stack = append(spec.stack, argument{offset: g.offset, t: g.t, size: g.size})
+			}
+			continue
+		}
+
+		if tags.packed || tags.align > 0 {
+			pf := packedArg(f, tags, ret)
+			spec.packedFields = append(spec.packedFields, pf)
+			spec.stack = append(spec.stack, argument{offset: pf.offset, t: typeU32, size: 4})
+			continue
+		}
+
+		if tags.pin {
+			spec.pinFields = append(spec.pinFields, pinArg(f, ret))
+		}
+
+		if tags.nilTag != "" {
+			spec.nilFields = append(spec.nilFields, nilArg(f, ret, tags.nilTag))
+		}
+		if tags.readonly {
+			spec.readonlyFields = append(spec.readonlyFields, readonlyArg(f, ret))
+		}
+
+		if tags.lenOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.lenOf, false))
+		}
+		if tags.capOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.capOf, true))
+		}
+
+		if cf, ok := pointerCheckField(f, tags); ok {
+			spec.checkFields = append(spec.checkFields, cf)
+		}
+
+		if tags.out {
+			spec.outFields = append(spec.outFields, outArg(f))
+		}
+
+		if tags.ptr {
+			spec.stack = append(spec.stack, ptrArg(f, ret))
+			continue
+		}
+
+		if f.Type == longDoubleType {
+			// Unlike every other struct/array field below, a long
+			// double return doesn't need - and can't use - a hidden
+			// pointer: cdecl already returns it in ST0, same as
+			// float32/float64, just with FMOVXP's wider store once
+			// it gets there (asmcall, ffi_386.s).
+			if ret {
+				spec.fret = argument{offset: uint16(f.Offset), t: typeLongDouble, size: 10}
+			} else {
+				spec.placeArgAggregate386(f.Offset, f.Type.Size())
+			}
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct || f.Type.Kind() == reflect.Array {
+			if ret {
+				panic(&SpecError{Kind: ErrUnsupportedAggregate, Msg: "386: aggregate return values are not supported; cdecl returns them via a hidden pointer argument"})
+			}
+			spec.placeArgAggregate386(f.Offset, f.Type.Size())
+			continue
+		}
+
+		off, flt := fieldToOffset386(f, tags.typ)
+		if ret {
+			if flt {
+				spec.fret = off
+			} else {
+				spec.ret0 = off
+			}
+			continue
+		}
+		spec.stack = append(spec.stack, off)
+	}
+
+	if conv == ConvFastcall {
+		lifted := spec.stack[:0:0]
+		for _, a := range spec.stack {
+			if spec.fastArg1.t != typeUnused || !fastcallEligible(a) {
+				lifted = append(lifted, a)
+				continue
+			}
+			if spec.fastArg0.t == typeUnused {
+				spec.fastArg0 = a
+			} else {
+				spec.fastArg1 = a
+			}
+		}
+		spec.stack = lifted
+	}
+
+	if n := stackBytes386(spec.stack); n > maxCallStackBytes {
+		panic(&SpecError{Kind: ErrTooManyArgs, Msg: "too many stack-passed argument bytes for asmcall"})
+	}
+	return spec
+}
+
+// fastcallEligible reports whether a already-classified argument is one
+// __fastcall would consider for ECX/EDX: DWORD (4 bytes) or smaller, and
+// not a floating-point value - MSVC's __fastcall never register-allocates
+// a float/double, even a 4 byte float32, and an 8 byte type64/typeDouble
+// can't fit a single register slot to begin with.
+func fastcallEligible(a argument) bool {
+	return a.size <= 4 && a.t != typeFloat && a.t != typeDouble && a.t != type64
+}
+
+// stackBytes386 sums the outgoing stack bytes spec.stack will occupy:
+// asmcall (ffi_386.s) always advances its stack buffer by a full
+// eightbyte for type64/typeDouble and a full word otherwise, regardless
+// of an argument's size field, which only narrows the bytes actually
+// read/written within that slot.
+func stackBytes386(stack []argument) uintptr {
+	var n uintptr
+	for _, a := range stack {
+		if a.t == type64 || a.t == typeDouble {
+			n += 8
+		} else {
+			n += 4
+		}
+	}
+	return n
+}
+
+// convCacheKey is convSpecCache's key: unlike MakeSpec's plain
+// reflect.Type key (cache.go), a stdcall/fastcall Spec's layout depends
+// on conv too - a fastcall build of the same struct type lifts fields
+// into fastArg0/fastArg1 that a cdecl build of it would leave on
+// spec.stack - so both must be part of the key.
+type convCacheKey struct {
+	t    reflect.Type
+	conv CallConv
+}
+
+// convSpecCache is specCache (cache.go), keyed for MakeSpecConv instead
+// of MakeSpec. It's a separate map rather than a shared one specCache
+// could also serve, since every other GOARCH's MakeSpec has no CallConv
+// to fold into a cache key at all.
+var convSpecCache sync.Map // map[convCacheKey]Spec
+
+// MakeSpecConv is MakeSpec for a non-default CallConv: mainly ConvStdcall
+// or ConvFastcall, for binding a Win32 API entry point declared WINAPI/
+// __stdcall or __fastcall respectively. Layouts are cached the same way
+// MakeSpec's are - see convCacheKey - so repeated calls for the same
+// (argument type, convention) pair only pay for the reflect walk once.
+func MakeSpecConv(fn uintptr, args interface{}, conv CallConv) Spec {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	key := convCacheKey{t: v.Type(), conv: conv}
+
+	if cached, ok := convSpecCache.Load(key); ok {
+		specCacheHits.Add(1)
+		spec := cached.(Spec)
+		spec.fn = fn
+		return spec
+	}
+
+	specCacheMisses.Add(1)
+	spec := buildSpecConv(0, args, conv)
+	convSpecCache.Store(key, spec)
+	spec.fn = fn
+	return spec
+}
+
+// maxCallStackBytes bounds how many bytes of spec.stack a single Call can
+// pass; asmcall (ffi_386.s) stages the outgoing cdecl argument block in a
+// fixed-size buffer sized off this constant, since the assembler needs
+// the frame size at build time.
+const maxCallStackBytes = 256
+
+// Call calls spec with the given arguments. spec is read-only here - the
+// args pointer asmcall needs lives in a small callParams value local to
+// this call instead - so the exact same Spec can be called concurrently
+// from any number of goroutines with no per-call copy of it. Like the
+// amd64 backend's Call, this costs one heap allocation per call for
+// params - see its comment for why, and CallFast for the alternative
+// that doesn't.
+func (spec *Spec) Call(args unsafe.Pointer) {
+	if n := stackBytes386(spec.stack); n > maxCallStackBytes {
+		panic("ffi: too many stack-passed argument bytes for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+
+	entersyscall()
+	asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&params)))
+	exitsyscall()
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+
+	if _Cgo_always_false {
+		_Cgo_use(args)
+		_Cgo_use(params)
+	}
+}
+
+// CallBatch runs every call in calls in order, entering syscall state once
+// for the whole batch instead of once per call - see the SysV backend's
+// identical function for the full rationale and for why each call's
+// prepareCStrings/preparePinning/pointer-check bookkeeping still runs
+// outside the entersyscall/exitsyscall window. This backend has no
+// UseErrno, so unlike the amd64 version, there's nothing per-call to
+// report back.
+//
+// Every call's cstr fields share one arena (see arena.go) rather than
+// each call in the batch acquiring its own - the whole batch bump-
+// allocates into the same backing buffer and it's released once, after
+// the last call's cleanup runs.
+func CallBatch(calls []BoundCall) {
+	a := acquireArena()
+	restoresNil := make([]func(), len(calls))
+	restores := make([]func(), len(calls))
+	unpins := make([]func(), len(calls))
+	unmarshals := make([]func(), len(calls))
+	unbits := make([]func(), len(calls))
+	unpacked := make([]func(), len(calls))
+	params := make([]callParams, len(calls))
+
+	for i, c := range calls {
+		if n := stackBytes386(c.Spec.stack); n > maxCallStackBytes {
+			panic("ffi: too many stack-passed argument bytes for asmcall")
+		}
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.checkFields)
+			zeroOutFields(c.Args, c.Spec.outFields)
+		}
+		restoresNil[i] = prepareNilFields(c.Args, c.Spec.nilFields)
+		prepareLenFields(c.Args, c.Spec.lenFields)
+		restores[i] = prepareCStrings(c.Args, c.Spec.cstrFields, a)
+		unpins[i] = preparePinning(c.Args, c.Spec.pinFields)
+		unmarshals[i] = prepareMarshalFields(c.Args, c.Spec.marshalFields, a)
+		unbits[i] = prepareBitFields(c.Args, c.Spec.bitFields)
+		unpacked[i] = preparePackedFields(c.Args, c.Spec.packedFields, a)
+		params[i] = callParams{spec: c.Spec, base: uintptr(c.Args)}
+	}
+
+	entersyscall()
+	for i := range calls {
+		asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&params[i])))
+	}
+	exitsyscall()
+
+	for i, c := range calls {
+		restoresNil[i]()
+		unpins[i]()
+		restores[i]()
+		unmarshals[i]()
+		unbits[i]()
+		unpacked[i]()
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.outFields)
+		}
+	}
+	releaseArena(a)
+
+	if _Cgo_always_false {
+		_Cgo_use(params)
+	}
+}
+
+// asmcall (ffi_386.s) pushes spec.stack onto the outgoing stack in cdecl
+// order, calls spec.fn, and stores the result back through ret0/ret1/fret.
+func asmcall()
+
+var asmcallptr = funcPC(asmcall)
+
+// loadword and storeword are asmcall's shared per-argument load/store
+// subroutines (ffi_386.s); they have no Go body and are never called from
+// Go, only called to within asmcall.
+func loadword()
+func storeword()
+
+// CallFast calls spec like Call, but skips both the asmcgocall g0-stack
+// switch and the entersyscall/exitsyscall pair, calling asmcall with a
+// direct CALL from the current goroutine's own stack instead - see the
+// SysV backend's identical method for the constraints that makes safe,
+// and for why CallFast, unlike Call, costs no heap allocation for params.
+func (spec *Spec) CallFast(args unsafe.Pointer) {
+	if n := stackBytes386(spec.stack); n > maxCallStackBytes {
+		panic("ffi: too many stack-passed argument bytes for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+	callFast(&params)
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+}
+
+// callFast (ffi_386.s) is CallFast's direct-call trampoline: it CALLs
+// asmcall from the current goroutine's own stack instead of handing it to
+// asmcgocall for a g0-stack switch. go:noescape is accurate here exactly
+// as it is for the amd64 backend's identical declaration: callFast only
+// reads through params for the duration of this synchronous call.
+//
+//go:noescape
+func callFast(params *callParams)
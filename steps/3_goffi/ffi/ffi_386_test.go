@@ -0,0 +1,191 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFieldToOffset386Slice is a regression test for fieldToOffset386
+// falling through to fieldToOffset's 8 byte, type64 mapping for
+// reflect.Slice: on 386 that made asmcall push 8 bytes for what must be a
+// 4 byte pointer (just SliceHeader.Data), corrupting every stack argument
+// after it. Calling asmcall itself isn't exercised here - cdecl's *Spec
+// convention (the pointer pushed onto the caller's outgoing stack, read
+// back via a fixed offset past asmcall's own frame and return address)
+// isn't something this package can safely fake from a direct CALL the way
+// the amd64/arm64 backends' register-passed *Spec can - so this only
+// covers the classification fieldToOffset386 feeds it.
+func TestFieldToOffset386Slice(t *testing.T) {
+	type withSlice struct {
+		Buf []byte `ffi:"arg"`
+	}
+	f, ok := reflect.TypeOf(withSlice{}).FieldByName("Buf")
+	if !ok {
+		t.Fatal("Buf field not found")
+	}
+
+	arg, flt := fieldToOffset386(f, "")
+	if flt {
+		t.Fatalf("slice field classified as float")
+	}
+	if arg.t != typeU32 || arg.size != 4 {
+		t.Fatalf("slice field: got {t:%v size:%d}, want {t:%v size:4}", arg.t, arg.size, typeU32)
+	}
+	if arg.offset != uint16(sliceOffset) {
+		t.Fatalf("slice field offset: got %d, want %d (SliceHeader.Data within Buf)", arg.offset, sliceOffset)
+	}
+}
+
+// TestBuildSpec386 exercises buildSpec's cdecl layout: every non-return
+// field lands on spec.stack in declaration order (there's no register
+// file to sort into), and the single ffi:"ret" field is classified into
+// ret0 or fret by kind, the same split fieldToOffset386 makes.
+func TestBuildSpec386(t *testing.T) {
+	type args struct {
+		A int32   `ffi:"arg"`
+		B float64 `ffi:"arg"`
+		C uint16  `ffi:"arg"`
+		R float32 `ffi:"ret"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	if len(spec.stack) != 3 {
+		t.Fatalf("spec.stack: got %d entries, want 3", len(spec.stack))
+	}
+	if spec.stack[0].t != typeS32 {
+		t.Fatalf("spec.stack[0] (A): got t=%v, want typeS32", spec.stack[0].t)
+	}
+	if spec.stack[1].t != typeDouble {
+		t.Fatalf("spec.stack[1] (B): got t=%v, want typeDouble", spec.stack[1].t)
+	}
+	if spec.stack[2].t != typeU16 {
+		t.Fatalf("spec.stack[2] (C): got t=%v, want typeU16", spec.stack[2].t)
+	}
+
+	if spec.ret0.t != typeUnused {
+		t.Fatalf("spec.ret0: got t=%v, want typeUnused (R is a float return)", spec.ret0.t)
+	}
+	if spec.fret.t != typeFloat {
+		t.Fatalf("spec.fret: got t=%v, want typeFloat", spec.fret.t)
+	}
+}
+
+// TestBuildSpecConvStdcall confirms ConvStdcall classifies identically to
+// plain cdecl (buildSpec/ConvCdecl): stdcall only changes who cleans up
+// the stack, which asmcall's SP reset handles unconditionally - see
+// CallConv - so there's nothing for buildSpecConv to do differently here.
+func TestBuildSpecConvStdcall(t *testing.T) {
+	type args struct {
+		A int32 `ffi:"arg"`
+		B int32 `ffi:"arg"`
+		R int32 `ffi:"ret"`
+	}
+
+	cdecl := buildSpecConv(0, &args{}, ConvCdecl)
+	stdcall := buildSpecConv(0, &args{}, ConvStdcall)
+
+	if len(stdcall.stack) != len(cdecl.stack) {
+		t.Fatalf("stdcall.stack: got %d entries, want %d (same as cdecl)", len(stdcall.stack), len(cdecl.stack))
+	}
+	if stdcall.fastArg0.t != typeUnused || stdcall.fastArg1.t != typeUnused {
+		t.Fatalf("stdcall: fastArg0/fastArg1 should stay unused outside ConvFastcall")
+	}
+}
+
+// TestBuildSpecConvFastcallRegisters exercises ConvFastcall's argument
+// lifting: the first two DWORD-or-smaller arguments (A, B) move into
+// fastArg0/fastArg1 in declaration order; C, an 8 byte int64, is never
+// fastcall-eligible and stays on the stack alongside D, which would
+// otherwise have been the third register candidate had fastcall's limit
+// of two not already been reached by A and B.
+func TestBuildSpecConvFastcallRegisters(t *testing.T) {
+	type args struct {
+		A int32 `ffi:"arg"`
+		B int32 `ffi:"arg"`
+		C int64 `ffi:"arg"`
+		D int32 `ffi:"arg"`
+		R int32 `ffi:"ret"`
+	}
+
+	spec := buildSpecConv(0, &args{}, ConvFastcall)
+
+	if spec.fastArg0.t != typeS32 || spec.fastArg0.offset != 0 {
+		t.Fatalf("fastArg0: got %+v, want A (offset 0, typeS32)", spec.fastArg0)
+	}
+	if spec.fastArg1.t != typeS32 || spec.fastArg1.offset != 4 {
+		t.Fatalf("fastArg1: got %+v, want B (offset 4, typeS32)", spec.fastArg1)
+	}
+	if len(spec.stack) != 2 {
+		t.Fatalf("spec.stack: got %d entries, want 2 (C and D)", len(spec.stack))
+	}
+	if spec.stack[0].t != type64 {
+		t.Fatalf("spec.stack[0] (C): got t=%v, want type64", spec.stack[0].t)
+	}
+	if spec.stack[1].t != typeS32 {
+		t.Fatalf("spec.stack[1] (D): got t=%v, want typeS32 (fastcall's two slots already spent)", spec.stack[1].t)
+	}
+}
+
+// TestBuildSpecConvFastcallSkipsIneligible confirms fastcall's scan keeps
+// looking past an ineligible argument instead of stopping at the first
+// one: A is a float (never register-allocated by __fastcall) and stays
+// on the stack, while B and C - the next two DWORD-sized arguments -
+// fill fastArg0/fastArg1 in its place.
+func TestBuildSpecConvFastcallSkipsIneligible(t *testing.T) {
+	type args struct {
+		A float32 `ffi:"arg"`
+		B int32   `ffi:"arg"`
+		C int32   `ffi:"arg"`
+		R int32   `ffi:"ret"`
+	}
+
+	spec := buildSpecConv(0, &args{}, ConvFastcall)
+
+	if len(spec.stack) != 1 || spec.stack[0].t != typeFloat {
+		t.Fatalf("spec.stack: got %+v, want just A (typeFloat)", spec.stack)
+	}
+	if spec.fastArg0.offset != 4 {
+		t.Fatalf("fastArg0: got offset %d, want 4 (B)", spec.fastArg0.offset)
+	}
+	if spec.fastArg1.offset != 8 {
+		t.Fatalf("fastArg1: got offset %d, want 8 (C)", spec.fastArg1.offset)
+	}
+}
+
+// TestMakeSpecConvCaches confirms MakeSpecConv's cache is keyed on both
+// the argument type and CallConv - the same struct type built as
+// ConvCdecl and ConvFastcall must not collide, since fastcall moves
+// fields off spec.stack that cdecl leaves there.
+func TestMakeSpecConvCaches(t *testing.T) {
+	type args struct {
+		A int32 `ffi:"arg"`
+		R int32 `ffi:"ret"`
+	}
+
+	cdecl := MakeSpecConv(0, &args{}, ConvCdecl)
+	fastcall := MakeSpecConv(0, &args{}, ConvFastcall)
+
+	if len(cdecl.stack) != 1 {
+		t.Fatalf("cdecl.stack: got %d entries, want 1", len(cdecl.stack))
+	}
+	if len(fastcall.stack) != 0 || fastcall.fastArg0.t == typeUnused {
+		t.Fatalf("fastcall: got stack=%+v fastArg0=%+v, want A lifted into fastArg0", fastcall.stack, fastcall.fastArg0)
+	}
+}
+
+// TestBuildSpec386AggregateReturnPanics confirms the documented limitation:
+// cdecl has no register to return an aggregate in, so a struct/array
+// ffi:"ret" field must panic rather than silently drop data.
+func TestBuildSpec386AggregateReturnPanics(t *testing.T) {
+	type args struct {
+		R struct{ X, Y int32 } `ffi:"ret"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("buildSpec: expected a panic for an aggregate return value")
+		}
+	}()
+	buildSpec(0, &args{})
+}
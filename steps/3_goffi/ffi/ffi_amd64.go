@@ -0,0 +1,1368 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"context"
+	"reflect"
+	"runtime/pprof"
+	rtrace "runtime/trace"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Spec is the callspec needed to do the actuall call, holding the SysV
+// AMD64 register file: 6 integer argument registers (RDI/RSI/RDX/RCX/R8/
+// R9), 8 SSE argument registers (XMM0-7), and up to two eightbytes each
+// for the integer (RAX/RDX) and SSE (XMM0/XMM1) return value.
+// Spec's fields are grouped by size - every pointer/slice/uintptr-sized
+// field first, then the argument-sized (6-byte) ones, then every flag
+// that fits in a byte, all the way at the end - rather than declared in
+// whatever order reads best topically. Go lays out a struct's fields in
+// declaration order and pads each one to its own alignment, so a bool
+// declared between two 8-byte fields burns 7 bytes of padding for the
+// one bit it holds; scattered across the roughly dozen flags this type
+// had accumulated, the fn/fields/flags were otherwise-topical grouping
+// used to cost several dozen bytes nobody was spending on purpose. This
+// ordering changes none of the field names asmcall (ffi_amd64.s) or this
+// file's Go code reference, and none of their meanings - only where each
+// one lands - so every Spec_* offset the assembler generates still
+// resolves to the same field, just at a smaller one.
+type Spec struct {
+	fn uintptr
+
+	// stack holds every stack-passed eightbyte past the 6 integer/8 xmm
+	// argument registers, in SysV AMD64 right-to-left push order.
+	stack []argument
+
+	// argsSize, usedInt and usedXmm describe the fixed argument struct
+	// MakeSpec classified: its size, and how many integer/xmm registers
+	// it consumed. CallVariadic uses these to classify a variadic tail
+	// that continues where the fixed arguments left off.
+	argsSize uintptr
+	usedInt  int
+	usedXmm  int
+
+	// variadicTypes is set by MakeVariadicSpec (variadic_amd64.go) and
+	// checked by CallVariadic against each call's varArgs/varIsXmm, to
+	// catch a caller passing the wrong shape of variadic tail. Nil for a
+	// Spec made with plain MakeSpec, or if the caller opted out.
+	variadicTypes []argtype
+
+	// errnoFn, if set via UseErrno, is a C function asmcall calls right
+	// after spec.fn returns, in the same trampoline frame, to capture
+	// errno before Call returns to Go: once the goroutine leaves the
+	// asm and exitsyscall runs, the scheduler is free to migrate it to
+	// a different OS thread, and a separate call to read errno from
+	// plain Go code could then read some other thread's value. fn must
+	// be a C function taking no arguments and returning "int *", like
+	// libc's __errno_location.
+	errnoFn uintptr
+
+	// cstrFields holds the struct offset of every ffi:"cstr" string
+	// field, for Call to marshal via prepareCStrings before each call.
+	cstrFields []uint16
+
+	// pinFields holds the struct offset of every ffi:"pin" pointer/slice
+	// field, for Call to pin via preparePinning before each call.
+	pinFields []uint16
+
+	// nilFields holds every ffi:"nil=panic"/"nil=empty" pointer/slice
+	// field, for Call to check/substitute via prepareNilFields before each
+	// call and restore after.
+	nilFields []nilField
+
+	// readonlyFields holds every ffi:"readonly" slice field, for
+	// CallReadonlyChecked (readonlycheck.go) to mprotect a copy of before
+	// each call. Call/CallFast/CallBatch themselves never read this -
+	// the readonly check is deliberately not part of the ordinary
+	// prepare/restore pipeline every other tag hooks into, since it needs
+	// to wrap the call itself in CallProtected, not just run before/after
+	// it.
+	readonlyFields []readonlyField
+
+	// lenFields holds every ffi:"len="/"cap=" slice/companion pair, for
+	// Call to keep populated via prepareLenFields before each call.
+	lenFields []lenField
+
+	// marshalFields holds every *T args field MakeSpec found pointing
+	// at a Marshaler, for Call to encode/decode via prepareMarshalFields
+	// around each call.
+	marshalFields []marshalField
+
+	// bitFields holds every ffi:"bits=" run's packed representative
+	// argument, for Call to pack via prepareBitFields before each call and
+	// unpack after.
+	bitFields []bitGroup
+
+	// packedFields holds every ffi:"packed"/"align=N" field, for Call to
+	// copy through a correctly packed/aligned scratch buffer via
+	// preparePackedFields before each call and back after.
+	packedFields []packedField
+
+	// checkFields holds every field checkArgPointers should validate under
+	// GODEBUG=ffipointercheck=1.
+	checkFields []checkField
+
+	// outFields holds every ffi:"out" field, for Call to zero via
+	// zeroOutFields before each call and re-validate via checkArgPointers
+	// after, both under GODEBUG=ffipointercheck=1.
+	outFields []checkField
+
+	// symbol, set via SetSymbol, is spec.fn's name, for LabelProfile's
+	// benefit and any other diagnostic that wants to say which C function
+	// a Spec calls. asmcall never reads it; it has no effect on Call
+	// unless LabelProfile is also enabled.
+	symbol string
+
+	// chaosFn, if set via Chaos, makes Call skip spec.fn entirely and
+	// invoke chaosFn(args) in its place - see Chaos's own comment.
+	chaosFn func(args unsafe.Pointer) int32
+
+	// guardStack, if set via SetGuardStack, makes invoke run spec.fn on
+	// gs.top (guardstack_amd64.go) instead of g0's own stack - see
+	// switchStackAndCall.
+	guardStack *GuardStack
+
+	intargs [6]argument
+	xmmargs [8]argument
+	ret0    argument
+	ret1    argument
+	xmmret0 argument
+	xmmret1 argument
+
+	// ldret, if its t isn't typeUnused, is a ffi:"ret" LongDouble
+	// (longdouble.go) field's offset: asmcall stores ST0 there via
+	// FMOVXP instead of through ret0/xmmret0, since a long double
+	// return never lands in RAX/RDX or XMM0/XMM1 at all.
+	ldret argument
+
+	// vecret, if its t isn't typeUnused, is a ffi:"ret" M128/M256
+	// (vector.go) field's offset: asmcall stores XMM0/YMM0 there via
+	// MOVOU/VMOVDQU instead of through ret0/xmmret0/xmmret1, since a
+	// vector return occupies one whole register rather than the two
+	// separate eightbyte registers a same-sized struct of two doubles
+	// would classify into.
+	vecret argument
+
+	// fnField, if hasFnField is set, is the offset of the ffi:"fnptr"
+	// field MakeSpec found - see CallVia in fnptr_amd64.go, which reads
+	// the call target out of args at this offset instead of using fn.
+	fnField uint16
+
+	// stubErrno, if isStub is set, is the errno Call returns instead of
+	// calling spec.fn - see Stub's own comment.
+	stubErrno int32
+
+	rax uint8
+
+	// retErrno records whether the ffi:"ret" field was also tagged
+	// "errno" - see retErrno in errno_ret.go, which refuses to run
+	// against a Spec built without it, rather than silently
+	// misinterpreting an arbitrary return value as this convention.
+	retErrno bool
+
+	hasFnField bool
+
+	// guardFPState, if set via GuardFPState, makes asmcall snapshot MXCSR
+	// and the x87 control word immediately before calling spec.fn and
+	// restore both immediately after it returns, undoing any
+	// rounding-mode or exception-mask change the callee made that Go's
+	// own float math never expects to see.
+	guardFPState bool
+
+	// avxHygiene, if set via UseVZeroUpper, makes asmcall execute
+	// VZEROUPPER immediately before calling spec.fn (see UseVZeroUpper's
+	// own comment) and check for dirty upper YMM state immediately after.
+	avxHygiene bool
+
+	// checkCalleeSaved, if set via CheckCalleeSaved, makes asmcall
+	// snapshot R12-R15 immediately before calling spec.fn and compare
+	// them immediately after, bumping CalleeSavedCorruptCount if any
+	// differ (see CheckCalleeSaved's own comment).
+	checkCalleeSaved bool
+
+	// profileLabel, if set via LabelProfile, makes Call attach symbol as
+	// a pprof label (see LabelProfile's own comment) around spec.fn.
+	profileLabel bool
+
+	// traceRegion, if set via TraceRegion, makes Call run spec.fn inside
+	// a runtime/trace region named after symbol (see TraceRegion's own
+	// comment).
+	traceRegion bool
+
+	// metricsEnabled, if set via CollectMetrics, makes Call record this
+	// call's duration and errno in the metrics registry (metrics.go)
+	// CallMetricsSnapshot reports, keyed by symbol.
+	metricsEnabled bool
+
+	// isStub, if set via Stub, makes Call skip spec.fn entirely and
+	// return stubErrno instead - spec.fn may not even be a valid address
+	// in this case (see Library.GetOrStub).
+	isStub bool
+
+	// maskAsyncPreempt, if set via MaskAsyncPreempt, makes invoke block
+	// SIGURG on the calling OS thread for the duration of asmcgocall -
+	// see maskAsyncPreemptSignal (preempt_linux.go/preempt_darwin.go).
+	maskAsyncPreempt bool
+
+	// simple is true when none of Call's optional machinery - the
+	// isStub/chaosFn short-circuits, the metrics/profileLabel/traceRegion
+	// switch, or any field-prep pass with actual work to do - applies to
+	// this Spec, computed once (by recomputeSimple) instead of on every
+	// call. Call checks only this before building callParams and calling
+	// invoke; callSlow carries everything simple lets Call skip.
+	// recomputeSimple is re-run by buildSpec and by every setter below
+	// that can flip one of these fields after a Spec already exists.
+	simple bool
+}
+
+// recomputeSimple refreshes spec.simple - see its own comment - after
+// buildSpec finishes classifying args, and after Stub/Chaos/LabelProfile/
+// TraceRegion/CollectMetrics change one of the fields it depends on.
+// pointerCheckEnabled is read here too: it's a GODEBUG setting fixed for
+// the life of the process (pointercheck.go), so it's safe to fold into a
+// value computed once rather than rechecked on every Call.
+func (spec *Spec) recomputeSimple() {
+	spec.simple = !pointerCheckEnabled &&
+		!spec.isStub &&
+		spec.chaosFn == nil &&
+		!spec.profileLabel &&
+		!spec.traceRegion &&
+		!spec.metricsEnabled &&
+		len(spec.nilFields) == 0 &&
+		len(spec.cstrFields) == 0 &&
+		len(spec.pinFields) == 0 &&
+		len(spec.marshalFields) == 0 &&
+		len(spec.bitFields) == 0 &&
+		len(spec.packedFields) == 0 &&
+		len(spec.lenFields) == 0
+}
+
+// SetSymbol records name as spec.fn's symbol - typically whatever string
+// was passed to Library.Get to resolve fn - for LabelProfile to report.
+// It has no effect by itself; asmcall never reads it.
+func (spec *Spec) SetSymbol(name string) {
+	spec.symbol = name
+}
+
+// LabelProfile arranges for Call to run spec.fn under a pprof label
+// (runtime/pprof) carrying spec's symbol (see SetSymbol). This package
+// calls spec.fn by handing asmcall to asmcgocall directly, after its own
+// entersyscall rather than runtime.cgocall's - real cgo's entry point -
+// so none of the bookkeeping (mp.ncgo, mp.curg.syscallpc/syscallsp's
+// cgo-aware use in runtime.sigprof) that lets a CPU profile unwind back
+// into Go frames from inside a C call is in place here. A profiling
+// sample taken while a goroutine is blocked in spec.fn therefore always
+// lands in the profiler's generic "ExternalCode"/"System" bucket with no
+// way to tell which Spec it was in - except that runtime/pprof attaches
+// a sample's current goroutine labels regardless of whether the stack
+// itself resolved, so a symbol set here still shows up in the profile
+// even though the call stack around it does not.
+//
+// A true pseudo-frame - reshaping a sample's unwound stack itself so it
+// reads as a call into spec's symbol rather than runtime.asmcgocall,
+// the way real cgo's SIGPROF handler synthesizes one from mp.ncgo/
+// mp.cgoCallers - isn't something LabelProfile can give: producing it
+// means writing to those same unexported runtime fields and intercepting
+// runtime.sigprof's own frame construction, the linkname access
+// CallProtected's doc comment (protect.go) already says this package
+// doesn't reach for. The label is the tool this package actually has to
+// make an FFI-heavy profile readable, not a full substitute.
+//
+// Off by default: pprof.Do costs a context.Context allocation and the
+// label bookkeeping it does internally, on every call.
+func (spec *Spec) LabelProfile(enable bool) {
+	spec.profileLabel = enable
+	spec.recomputeSimple()
+}
+
+// TraceRegion arranges for Call to run spec.fn inside a runtime/trace
+// region (imported here as rtrace, since this file already has its own
+// package-level trace function - see trace.go) named after spec's
+// symbol (see SetSymbol), so `go tool trace`'s goroutine view shows that
+// time as a labeled span - "inside libm.so's sqrt", not an unexplained
+// gap - rather than lumping every foreign call together as generic
+// syscall time the way an un-annotated trace would.
+//
+// runtime/trace.StartRegion is already a near no-op when no trace is
+// being collected, so unlike LabelProfile this has no real cost to
+// leave on by default - it's still off by default to keep Call's
+// behavior predictable without an explicit opt-in, matching this file's
+// other Spec options.
+func (spec *Spec) TraceRegion(enable bool) {
+	spec.traceRegion = enable
+	spec.recomputeSimple()
+}
+
+// CollectMetrics arranges for Call to record every call's duration and
+// errno (see SetSymbol, UseErrno) in the package-wide registry
+// CallMetricsSnapshot reports: a per-symbol call count, cumulative call
+// duration, and errno distribution a service can poll to find its FFI
+// hot spots, or adapt into its own expvar.Publish or Prometheus
+// collector.
+//
+// Off by default: recording costs a time.Now()/time.Since pair and a
+// sync.Map lookup on every call.
+func (spec *Spec) CollectMetrics(enable bool) {
+	spec.metricsEnabled = enable
+	spec.recomputeSimple()
+}
+
+// Stub arranges for Call to skip spec.fn entirely and return errno
+// directly instead, every time it's called - for Library.GetOrStub's
+// missing-symbol degrade path, where spec.fn may be 0 rather than a
+// real address because the optional library this Spec targets doesn't
+// define the symbol at all. This lets "call it if present, degrade if
+// not" code build and call a Spec exactly the same way whether or not
+// the underlying C function actually exists, instead of special-casing
+// a missing symbol at every call site.
+//
+// Passing the POSIX-style ENOSYS (38 on Linux) as errno is the
+// conventional choice for "this isn't implemented here", but it's the
+// caller's to pick - GetOrStub doesn't impose one.
+func (spec *Spec) Stub(errno int32) {
+	spec.isStub = true
+	spec.stubErrno = errno
+	spec.recomputeSimple()
+}
+
+// Chaos is Stub generalized for fault-injection testing: it arranges for
+// Call to skip spec.fn entirely and invoke fn(args) in its place, on the
+// same goroutine and OS thread Call itself would have used, with the
+// same args pointer a real spec.fn would have received. Unlike Stub's
+// single fixed errno, fn decides per call:
+//
+//   - a chosen return value - fn writes through args to whatever ret
+//     fields spec's MakeSpec type declared, exactly as a real spec.fn's
+//     register returns would have landed there, then returns whatever
+//     errno Call should report;
+//   - a chosen errno - fn's return value, same meaning as Stub's;
+//   - a delay - fn calls time.Sleep itself; nothing here imposes one;
+//   - a simulated fault - fn deliberately crashes (a nil-pointer access
+//     through args, syscall.Kill(self, SIGSEGV), or similar) to exercise
+//     a caller's CallProtected path without needing a real library that
+//     actually crashes.
+//
+// This lets an application substitute a deterministic, no-real-library
+// double for spec.fn in tests that need to drive its own FFI error
+// handling down every path a flaky or hostile C library could take,
+// without depending on one actually behaving that way on demand.
+//
+// Passing nil disables it, back to the plain asmcall path.
+func (spec *Spec) Chaos(fn func(args unsafe.Pointer) int32) {
+	spec.chaosFn = fn
+	spec.recomputeSimple()
+}
+
+// SetGuardStack arranges for Call/CallBatch to run spec.fn on gs's
+// guard-paged stack (guardstack_amd64.go) instead of g0's own - for a
+// callee whose deep recursion or large alloca(3) use risks overrunning
+// whatever stack it happens to run on, where g0's is both shared with
+// every other cgo-style call on the same OS thread and not sized with
+// spec.fn in mind. CallFast has no invoke call to hook into at all - its
+// whole contract is a callee trivial enough to never need this.
+//
+// Passing nil disables it, back to the plain asmcall path.
+func (spec *Spec) SetGuardStack(gs *GuardStack) {
+	spec.guardStack = gs
+}
+
+// MaskAsyncPreempt arranges for Call to block SIGURG - the
+// signal the Go runtime uses to preempt a running goroutine mid-call -
+// on the calling OS thread for the duration of spec.fn, restoring
+// whatever mask was already there once it returns. That's for a spec.fn
+// that isn't EINTR-safe, or that installs/expects signals of its own:
+// without this, a SIGURG delivered mid-call can interrupt a blocking
+// syscall spec.fn made (making it fail with EINTR where the caller never
+// expected one) or run headlong into whatever spec.fn's own signal
+// handling assumed about what could arrive while it's running.
+//
+// This only ever blocks SIGURG for this one OS thread, not
+// process-wide, and only while spec.fn is actually running - it does
+// nothing to signals already in flight or pending before the call. On a
+// platform maskAsyncPreemptSignal can't mask on (see its own doc
+// comment), enabling this is silently a no-op rather than an error, the
+// same "best effort, not a promise" this package's signal support
+// already gives on Linux (see signal_unsupported.go).
+//
+// Like SetGuardStack, this has no effect on CallFast (no invoke call to
+// hook into) or CallBatch (its own entersyscall/exitsyscall bracket
+// around the whole batch, not per call, doesn't consult any per-Spec
+// option at all).
+//
+// Off by default, like this file's other Spec options: masking a signal
+// costs a syscall (or, on Darwin, a libc call) on both ends of every
+// call.
+func (spec *Spec) MaskAsyncPreempt(enable bool) {
+	spec.maskAsyncPreempt = enable
+}
+
+// invoke runs the one asmcgocall every Call/CallBatch entry ultimately
+// makes, bracketed by entersyscall/exitsyscall - factored out so Call's
+// LabelProfile/TraceRegion wrapping above has a single call to wrap
+// instead of repeating this three-line sequence in every switch case.
+//
+// With a GuardStack set (SetGuardStack), asmcgocall's target is
+// switchStackAndCall instead of asmcall itself: it swaps the stack
+// pointer to params.spec.guardStack.top, calls asmcall from there - so
+// spec.fn executes on the guarded stack, not g0's - and swaps back
+// before returning, exactly the same way asmcgocall's own g0 switch
+// brackets asmcall normally.
+//
+// With MaskAsyncPreempt enabled, the signal mask is set right after
+// entersyscall and restored right before exitsyscall - entersyscall
+// keeps this goroutine on the same OS thread until the matching
+// exitsyscall, exactly the window the mask needs to hold for.
+func invoke(params *callParams) {
+	entersyscall()
+	var oldMask uint64
+	masked := params.spec.maskAsyncPreempt
+	if masked {
+		oldMask, masked = maskAsyncPreemptSignal()
+	}
+	if gs := params.spec.guardStack; gs != nil {
+		sw := guardCallParams{newSP: gs.top, params: uintptr(unsafe.Pointer(params))}
+		asmcgocall(unsafe.Pointer(switchStackAndCallPtr), uintptr(unsafe.Pointer(&sw)))
+	} else {
+		asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(params)))
+	}
+	if masked {
+		restoreAsyncPreemptSignal(oldMask)
+	}
+	exitsyscall()
+}
+
+// GuardFPState arranges for Call/CallVariadic to save MXCSR (SSE's
+// rounding mode and exception masks) and the x87 control word around
+// spec.fn, restoring both right after it returns. Some C libraries
+// change one or the other and never restore it - a well-known source of
+// Go float math silently producing different results after calling into
+// such a library. Off by default, since the save/restore costs two
+// extra instructions each way on every call.
+func (spec *Spec) GuardFPState(guard bool) {
+	spec.guardFPState = guard
+}
+
+// UseVZeroUpper arranges for Call/CallVariadic to execute VZEROUPPER
+// right before calling spec.fn: Go code that has used AVX (directly or
+// via the runtime/stdlib's own AVX-accelerated routines) leaves the
+// upper 128 bits of YMM0-15 non-zero, and the CPU pays a save/restore
+// penalty on every subsequent legacy SSE instruction until something
+// clears them - exactly the transition cost this avoids for C code that
+// never touches AVX itself.
+//
+// It also checks, immediately after spec.fn returns, whether the callee
+// left YMM0's upper 128 bits dirty - some AVX-using C libraries have the
+// same bug in reverse - and if so bumps the counter AVXDirtyCount
+// reports, before running VZEROUPPER a second time to clean up after it.
+// Only YMM0 is checked: a full scan of all 16 registers would cost as
+// much as the transition this is meant to avoid, and YMM0 is already the
+// one asmcall reads spec.fn's return value out of.
+//
+// Off by default, since both the pre-call VZEROUPPER and the post-call
+// check cost real cycles on every call.
+func (spec *Spec) UseVZeroUpper(enable bool) {
+	spec.avxHygiene = enable
+}
+
+// avxDirtyCount is bumped by asmcall, under LOCK, whenever a
+// UseVZeroUpper-guarded call finds the callee left YMM0's upper 128 bits
+// dirty. Read via AVXDirtyCount.
+var avxDirtyCount uint64
+
+// AVXDirtyCount returns how many UseVZeroUpper-guarded calls have found
+// the callee left YMM0's upper 128 bits dirty since process start.
+func AVXDirtyCount() uint64 {
+	return atomic.LoadUint64(&avxDirtyCount)
+}
+
+// CheckCalleeSaved arranges for Call/CallVariadic to snapshot R12, R13,
+// R14 and R15 immediately before calling spec.fn and compare them
+// immediately after. Those four registers are exactly the ones asmcall
+// itself keeps live - uninitialized by any reload - across the call to
+// hold *callParams, the args base pointer and *Spec (see asmcall's own
+// doc comment in ffi_amd64.s); the SysV AMD64 ABI already requires any
+// well-behaved callee to preserve them, so this is meant to catch the
+// callee that doesn't - a miscompiled or misdeclared spec.fn, or an ABI
+// bug in asmcall itself - as a recorded corruption event instead of
+// whatever garbage-pointer crash asmcall's own post-call use of those
+// registers would otherwise produce a moment later, with no way to tell
+// why.
+//
+// A corruption found this way still lets the call return rather than
+// panicking inline, since by the time it's detected asmcall has already
+// used the clobbered registers to, e.g., locate spec.ret0 - that store
+// may itself have gone to the wrong address. CalleeSavedCorruptCount is
+// this package's way of surfacing that something from this call's
+// results can no longer be trusted.
+//
+// Off by default: debug-only, and not free - it costs four loads, four
+// stores, and four compares on every guarded call, exactly the
+// always-on-in-debug-only shape CallReadonlyChecked's GODEBUG gate uses
+// for a comparable cost, except this one is a per-Spec choice rather
+// than process-wide.
+func (spec *Spec) CheckCalleeSaved(enable bool) {
+	spec.checkCalleeSaved = enable
+}
+
+// calleeSavedCorruptCount is bumped by asmcall, under LOCK, whenever a
+// CheckCalleeSaved-guarded call finds R12-R15 changed across spec.fn.
+// Read via CalleeSavedCorruptCount.
+var calleeSavedCorruptCount uint64
+
+// CalleeSavedCorruptCount returns how many CheckCalleeSaved-guarded
+// calls have found a callee-saved register corrupted since process
+// start.
+func CalleeSavedCorruptCount() uint64 {
+	return atomic.LoadUint64(&calleeSavedCorruptCount)
+}
+
+// callParams is the one small, per-call value Call hands asmcall a
+// pointer to, instead of a *Spec alone: Spec is now an immutable
+// template that Call never writes to, so one Spec can be shared
+// read-only across goroutines and called concurrently without copying
+// it. base is the args pointer that used to live in Spec.base, and errno
+// is the int errnoFn pointed at right after spec.fn returned (only
+// meaningful when errnoFn is set) - both are per-call outputs/inputs
+// that have no business living on the shared Spec.
+type callParams struct {
+	spec  *Spec
+	base  uintptr
+	errno int32
+}
+
+// UseErrno arranges for Call/CallVariadic to capture errno immediately
+// after spec.fn returns, before the calling goroutine can be migrated to
+// a different OS thread. fn is a C function taking no arguments and
+// returning "int *" - resolve it once, e.g. via a Library's
+// "__errno_location" symbol, and pass its address here.
+func (spec *Spec) UseErrno(fn uintptr) {
+	spec.errnoFn = fn
+}
+
+// class is a SysV AMD64 eightbyte class, used to classify the eightbytes of
+// a struct or array argument/return value per the ABI's aggregate
+// classification algorithm (System V AMD64 ABI draft, section 3.2.3).
+type class uint8
+
+const (
+	classNone class = iota
+	classInteger
+	classSSE
+	classMemory
+)
+
+// mergeClass implements the ABI's pairwise class merge: MEMORY always wins,
+// then INTEGER, then SSE; an unset class takes on its sibling's class.
+func mergeClass(a, b class) class {
+	switch {
+	case a == b:
+		return a
+	case a == classNone:
+		return b
+	case b == classNone:
+		return a
+	case a == classMemory || b == classMemory:
+		return classMemory
+	case a == classInteger || b == classInteger:
+		return classInteger
+	default:
+		return classSSE
+	}
+}
+
+// classifyEightbytes classifies every eightbyte of an aggregate type t. ok
+// is false when t must be classified MEMORY as a whole: larger than two
+// eightbytes (16 bytes), empty, or containing a misaligned member - in
+// which case the caller should pass/return it by value on the stack instead
+// of trying to split it across registers.
+func classifyEightbytes(t reflect.Type) (classes [2]class, n int, ok bool) {
+	size := t.Size()
+	if size == 0 || size > 16 {
+		return classes, 0, false
+	}
+	n = int((size + 7) / 8)
+	if !classifyField(t, 0, classes[:n]) {
+		return classes, n, false
+	}
+	return classes, n, true
+}
+
+// classifyField walks t's members at the given byte offset within the
+// enclosing aggregate, merging each scalar member's class into the
+// eightbyte(s) it occupies. It returns false if a member is misaligned or
+// falls outside the two eightbytes tracked by classes, forcing MEMORY.
+func classifyField(t reflect.Type, base uintptr, classes []class) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !classifyField(f.Type, base+f.Offset, classes) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		elem := t.Elem()
+		es := elem.Size()
+		for i := 0; i < t.Len(); i++ {
+			if !classifyField(elem, base+uintptr(i)*es, classes) {
+				return false
+			}
+		}
+		return true
+	default:
+		size := t.Size()
+		if size == 0 {
+			return true
+		}
+		if align := uintptr(t.Align()); align != 0 && base%align != 0 {
+			return false // misaligned member: caller must fall back to MEMORY
+		}
+		c := classInteger
+		if t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 ||
+			t.Kind() == reflect.Complex64 || t.Kind() == reflect.Complex128 {
+			c = classSSE
+		}
+		first, last := int(base/8), int((base+size-1)/8)
+		if last >= len(classes) {
+			return false
+		}
+		for eb := first; eb <= last; eb++ {
+			classes[eb] = mergeClass(classes[eb], c)
+		}
+		return true
+	}
+}
+
+// eightbyteArg builds the argument describing the i'th eightbyte (of n,
+// totalSize bytes in all) of an aggregate living at base, classified as c.
+func eightbyteArg(base uintptr, i int, c class, totalSize uintptr) argument {
+	off := base + uintptr(i)*8
+	size := uintptr(8)
+	if rem := totalSize - uintptr(i)*8; rem < 8 {
+		size = rem
+	}
+	t := type64
+	if c == classSSE {
+		t = typeDouble
+	}
+	return argument{offset: uint16(off), t: t, size: uint8(size)}
+}
+
+// placeArgEightbytes assigns the n eightbytes of an INTEGER/SSE-classified
+// aggregate at base to the integer/xmm register files, advancing *intreg
+// and *xmmreg. If there isn't room for every eightbyte in registers, the
+// whole aggregate spills to the stack together, as the ABI requires.
+func (spec *Spec) placeArgEightbytes(base uintptr, classes [2]class, n int, size uintptr, intreg, xmmreg *int) {
+	needInt, needSSE := 0, 0
+	for i := 0; i < n; i++ {
+		if classes[i] == classSSE {
+			needSSE++
+		} else {
+			needInt++
+		}
+	}
+	if *intreg+needInt > 6 || *xmmreg+needSSE > 8 {
+		spec.appendStackAggregate(base, size)
+		return
+	}
+	for i := 0; i < n; i++ {
+		a := eightbyteArg(base, i, classes[i], size)
+		if classes[i] == classSSE {
+			spec.xmmargs[*xmmreg] = a
+			*xmmreg++
+		} else {
+			spec.intargs[*intreg] = a
+			*intreg++
+		}
+	}
+}
+
+// stackArg rewrites off for spec.stack, where asmcall (ffi_amd64.s)
+// reads it through loadword/storeword once per call: every "plain load a
+// value of this width" type code (typeS32, typeU32, typeFloat, typeS16,
+// typeU16, typeS8, typeU8) exists only so a register destination gets the
+// correctly sign/zero-extended 8 bytes a register holds - a stack slot
+// has no such requirement, since the callee only ever reads back off.size
+// bytes at that address and the ABI leaves the rest of the eightbyte
+// unspecified. type64 already copies exactly off.size raw bytes with a
+// single size check and no type-specific branch at all (see loadword's
+// fallthrough case), and produces the identical off.size bytes any of
+// those narrower codes would have - so retyping here, once in MakeSpec,
+// turns every stack slot loadword/storeword visits into that one
+// branch-free path instead of running the narrow-type comparison chain
+// for each one on every call. typeAddr (which loads the field's own
+// address, not a value stored at it) and typeDouble (already on the
+// type64 fallthrough) are left alone.
+func stackArg(off argument) argument {
+	switch off.t {
+	case typeS32, typeU32, typeFloat, typeS16, typeU16, typeS8, typeU8:
+		off.t = type64
+	}
+	return off
+}
+
+// appendStackAggregate pushes a MEMORY-class (or register-starved)
+// aggregate onto the stack slice one eightbyte at a time.
+func (spec *Spec) appendStackAggregate(base uintptr, size uintptr) {
+	n := int((size + 7) / 8)
+	for i := 0; i < n; i++ {
+		spec.stack = append(spec.stack, eightbyteArg(base, i, classInteger, size))
+	}
+}
+
+// placeRetEightbytes assigns the eightbytes of an aggregate return value at
+// base to ret0/ret1 (RAX/RDX) and xmmret0/xmmret1 (XMM0/XMM1), in order.
+func (spec *Spec) placeRetEightbytes(base uintptr, classes [2]class, n int, size uintptr) {
+	intSlot, sseSlot := 0, 0
+	for i := 0; i < n; i++ {
+		a := eightbyteArg(base, i, classes[i], size)
+		if classes[i] == classSSE {
+			if sseSlot == 0 {
+				spec.xmmret0 = a
+			} else {
+				spec.xmmret1 = a
+			}
+			sseSlot++
+		} else {
+			if intSlot == 0 {
+				spec.ret0 = a
+			} else {
+				spec.ret1 = a
+			}
+			intSlot++
+		}
+	}
+}
+
+// buildSpec builds a call specification for the given arguments; it's
+// wrapped by the cached, public MakeSpec in cache.go.
+func buildSpec(fn uintptr, args interface{}) Spec {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	var spec Spec
+
+	spec.fn = fn
+
+	spec.ret0.t = typeUnused
+	spec.ret1.t = typeUnused
+	spec.xmmret0.t = typeUnused
+	spec.xmmret1.t = typeUnused
+	spec.ldret.t = typeUnused
+	spec.vecret.t = typeUnused
+
+	haveRet := false
+
+	intreg := 0
+	xmmreg := 0
+
+	// fields is t's fields after flattening any plain (untagged) anonymous
+	// struct field inline - see flattenFields - so every classification
+	// pass below, not just the main one, sees a shared "header" struct's
+	// fields as if they'd been copied into this struct by hand.
+	fields := flattenFields(t)
+
+	// A MEMORY-class struct return (bigger than two eightbytes, or
+	// misaligned) doesn't come back in registers: the ABI has the caller
+	// pass a pointer to the return storage as a hidden first integer
+	// argument, which the callee also hands back in RAX. An explicit
+	// ffi:"sret" field claims that slot itself, for a return the caller
+	// wants to allocate by hand rather than embed inline in the args
+	// struct; otherwise fall back to the implicit case, an inline
+	// ffi:"ret" struct/array field MakeSpec finds doesn't fit in
+	// registers. Either way it must claim intargs[0] before any visible
+	// argument does.
+	for i := range fields {
+		f := fields[i]
+		tags := parseFieldTags(f)
+		if tags.ignore || !tags.sret {
+			continue
+		}
+		if tags.ret {
+			panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"sret\" and ffi:\"ret\" cannot both be set on the same field"})
+		}
+		spec.intargs[0] = sretArg(f)
+		intreg = 1
+		break
+	}
+	if intreg == 0 {
+		for i := range fields {
+			f := fields[i]
+			tags := parseFieldTags(f)
+			if tags.ignore || !tags.ret {
+				continue
+			}
+			if f.Type.Kind() != reflect.Struct && f.Type.Kind() != reflect.Array {
+				continue
+			}
+			if _, _, ok := classifyEightbytes(f.Type); !ok {
+				spec.intargs[0] = argument{offset: uint16(f.Offset), t: typeAddr, size: 8}
+				intreg = 1
+			}
+			break
+		}
+	}
+
+	bitGroups := collectBitGroups(fields)
+
+	for i := range fields {
+		f := fields[i]
+		tags := parseFieldTags(f)
+		if tags.ignore {
+			continue
+		}
+		if tags.sret {
+			// Already claimed intargs[0], above.
+			continue
+		}
+		ret := false
+		if tags.ret {
+			if haveRet {
+				panic(&SpecError{Kind: ErrMultipleReturns, Msg: "only one ffi:\"ret\" field allowed"})
+			}
+			ret = true
+			haveRet = true
+			spec.retErrno = tags.errno
+		}
+
+		if tags.fnptr {
+			if ret {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"fnptr\" and ffi:\"ret\" cannot both be set on the same field"})
+			}
+			if f.Type.Kind() != reflect.Uintptr {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"fnptr\" field must be a uintptr"})
+			}
+			if spec.hasFnField {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "only one ffi:\"fnptr\" field allowed"})
+			}
+			spec.fnField = uint16(f.Offset)
+			spec.hasFnField = true
+			continue
+		}
+
+		if tags.cstr {
+			off := cstrArg(f, ret, type64, 8)
+			spec.cstrFields = append(spec.cstrFields, uint16(f.Offset))
+			if intreg < 6 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if tags.strptr {
+			data, length := strPtrArg(f, ret, type64, 8)
+			for _, off := range [2]argument{data, length} {
+				if intreg < 6 {
+					spec.intargs[intreg] = off
+					intreg++
+				} else {
+					spec.stack = append(spec.stack, off)
+				}
+			}
+			continue
+		}
+
+		if !ret && f.Type.Kind() == reflect.Ptr && marshalElem(f.Type.Elem()) {
+			spec.marshalFields = append(spec.marshalFields, marshalField{offset: uint16(f.Offset), elem: f.Type.Elem()})
+			off := argument{offset: uint16(f.Offset), t: type64, size: 8}
+			if intreg < 6 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if tags.bits > 0 {
+			if ret {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=\" is not supported on a ffi:\"ret\" field"})
+			}
+			if g, isRep := bitGroupFor(bitGroups, uint16(f.Offset)); isRep {
+				spec.bitFields = append(spec.bitFields, g)
+				off := argument{offset: g.offset, t: g.t, size: g.size}
+				if intreg < 6 {
+					spec.intargs[intreg] = off
+					intreg++
+				} else {
+					spec.stack = append(spec.stack, stackArg(off))
+				}
+			}
+			continue
+		}
+
+		if tags.packed || tags.align > 0 {
+			pf := packedArg(f, tags, ret)
+			spec.packedFields = append(spec.packedFields, pf)
+			off := argument{offset: pf.offset, t: type64, size: 8}
+			if intreg < 6 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if tags.pin {
+			spec.pinFields = append(spec.pinFields, pinArg(f, ret))
+		}
+
+		if tags.nilTag != "" {
+			spec.nilFields = append(spec.nilFields, nilArg(f, ret, tags.nilTag))
+		}
+		if tags.readonly {
+			spec.readonlyFields = append(spec.readonlyFields, readonlyArg(f, ret))
+		}
+
+		if tags.lenOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.lenOf, false))
+		}
+		if tags.capOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.capOf, true))
+		}
+
+		if cf, ok := pointerCheckField(f, tags); ok {
+			spec.checkFields = append(spec.checkFields, cf)
+		}
+
+		if tags.out {
+			spec.outFields = append(spec.outFields, outArg(f))
+		}
+
+		if tags.ptr {
+			off := ptrArg(f, ret)
+			if intreg < 6 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if f.Type == longDoubleType {
+			// A long double is its own SysV class, not INTEGER/SSE
+			// eightbytes like any other 10-16 byte aggregate: it's
+			// always memory-class going in, regardless of how many
+			// registers are still free, and comes back through ST0
+			// rather than RAX/RDX or XMM0/XMM1 - classifyEightbytes'
+			// register-placement path would get both wrong, so this
+			// field never reaches it.
+			if ret {
+				spec.ldret = argument{offset: uint16(f.Offset), t: typeLongDouble, size: 10}
+			} else {
+				spec.appendStackAggregate(f.Offset, f.Type.Size())
+			}
+			continue
+		}
+
+		if f.Type == m128Type || f.Type == m256Type {
+			size := f.Type.Size()
+			if uintptr(f.Offset)%size != 0 {
+				panic(&SpecError{Kind: ErrMisalignedVector, Msg: "M128/M256 field must be naturally aligned within its args struct"})
+			}
+			t := typeM128
+			if f.Type == m256Type {
+				t = typeM256
+			}
+			if ret {
+				spec.vecret = argument{offset: uint16(f.Offset), t: t, size: uint8(size)}
+				continue
+			}
+			if xmmreg < 8 {
+				spec.xmmargs[xmmreg] = argument{offset: uint16(f.Offset), t: t, size: uint8(size)}
+				xmmreg++
+			} else {
+				spec.appendStackAggregate(f.Offset, size)
+			}
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct || f.Type.Kind() == reflect.Array || f.Type.Kind() == reflect.Complex128 {
+			// complex128 is two SSE eightbytes, the same shape as a
+			// struct{ A, B float64 } - classifyField already classifies it
+			// that way, so it rides the same aggregate placement path
+			// rather than needing one of its own.
+			classes, n, ok := classifyEightbytes(f.Type)
+			if ret {
+				if !ok {
+					// Already wired up as the hidden pointer in
+					// intargs[0], above; RAX also comes back holding it,
+					// but the callee has already written the result
+					// through the pointer, so there's nothing left to
+					// store.
+					continue
+				}
+				spec.placeRetEightbytes(f.Offset, classes, n, f.Type.Size())
+				continue
+			}
+			if !ok {
+				spec.appendStackAggregate(f.Offset, f.Type.Size())
+				continue
+			}
+			spec.placeArgEightbytes(f.Offset, classes, n, f.Type.Size(), &intreg, &xmmreg)
+			continue
+		}
+
+		if ret {
+			off, xmm := fieldToOffset(f, tags.typ)
+			if xmm {
+				spec.xmmret0 = off
+			} else {
+				spec.ret0 = off
+			}
+			continue
+		}
+		off, xmm := fieldToOffset(f, tags.typ)
+		if xmm {
+			if xmmreg < 8 {
+				spec.xmmargs[xmmreg] = off
+				xmmreg++
+			} else {
+				spec.stack = append(spec.stack, stackArg(off))
+			}
+		} else {
+			if intreg < 6 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, stackArg(off))
+			}
+		}
+	}
+	for i := intreg; i < 6; i++ {
+		spec.intargs[i].t = typeUnused
+	}
+	for i := xmmreg; i < 8; i++ {
+		spec.xmmargs[i].t = typeUnused
+	}
+	spec.rax = uint8(xmmreg)
+	spec.argsSize = t.Size()
+	spec.usedInt = intreg
+	spec.usedXmm = xmmreg
+	if len(spec.stack) > maxCallStackArgs {
+		panic(&SpecError{Kind: ErrTooManyArgs, Msg: "too many stack-passed arguments for asmcall"})
+	}
+	spec.recomputeSimple()
+	return spec
+}
+
+// maxCallStackArgs bounds how many eightbytes of spec.stack a single Call
+// can pass. asmcall (ffi_amd64.s) stages outgoing stack arguments in a
+// fixed-size buffer sized off this constant, since the assembler needs the
+// frame size at build time; 32 eightbytes comfortably covers any realistic
+// C signature (it's on top of the 6 integer + 8 xmm registers already
+// available).
+const maxCallStackArgs = 32
+
+// Call calls spec with the given arguments. spec is read-only here - the
+// args pointer and the errno result that asmcall needs to write
+// somewhere both live in a small callParams value local to this call
+// instead - so the exact same Spec can be called concurrently from any
+// number of goroutines with no per-call copy of it. The returned value
+// is the errno captured right after spec.fn returned, if UseErrno was
+// called on spec; it's meaningless otherwise.
+//
+// Call's own body is deliberately tiny: spec.simple (see its own comment)
+// is true for the overwhelming majority of Specs - no Stub/Chaos, no
+// profiling/tracing/metrics, no field that needs prep/restore around the
+// call - and for those, Call does nothing but build callParams and call
+// invoke, instead of running through every optional feature's check on
+// every call the way a single do-everything Call body used to. callSlow
+// carries every feature spec.simple lets this path skip; BenchmarkCall
+// (cache_amd64_test.go) is this path's own benchmark.
+//
+// Call's params does cost one heap allocation per call: asmcgocall takes
+// its arg as a uintptr, not unsafe.Pointer, which hides the pointer from
+// escape analysis and from the GC's stack scan alike, so _Cgo_use(params)
+// below forces the compiler to keep params reachable (and, as a side
+// effect, heap-allocated) until asmcgocall has returned. This is the same
+// cgoUse trick cmd/cgo-generated code relies on for exactly the same
+// reason, and it's why even a real `import "C"` call isn't allocation-
+// free. CallFast avoids it - see its own comment - by never hiding its
+// pointer behind a uintptr in the first place.
+func (spec *Spec) Call(args unsafe.Pointer) int32 {
+	if !spec.simple {
+		return spec.callSlow(args)
+	}
+
+	params := callParams{spec: spec, base: uintptr(args)}
+	invoke(&params)
+
+	if _Cgo_always_false {
+		_Cgo_use(args)
+		_Cgo_use(params)
+	}
+
+	reraiseCallbackPanic()
+
+	return params.errno
+}
+
+// callSlow is Call's outlined slow path, reached whenever spec.simple is
+// false: Stub/Chaos's short-circuits, the too-many-stack-args panic
+// (already unreachable for any Spec buildSpec produced, but kept here as
+// it always has been, for anything that builds a Spec by other means),
+// pointerCheckEnabled's field scans, every prepare*Fields pass, and the
+// metrics/profileLabel/traceRegion switch around invoke all live here
+// instead of in Call, so the common case never pays for checking them.
+func (spec *Spec) callSlow(args unsafe.Pointer) int32 {
+	if spec.isStub {
+		return spec.stubErrno
+	}
+
+	if spec.chaosFn != nil {
+		return spec.chaosFn(args)
+	}
+
+	if len(spec.stack) > maxCallStackArgs {
+		panic("ffi: too many stack-passed arguments for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+
+	var start time.Time
+	if spec.metricsEnabled {
+		start = time.Now()
+	}
+
+	switch {
+	case spec.profileLabel && spec.traceRegion:
+		pprof.Do(context.Background(), pprof.Labels("ffi.symbol", spec.symbol), func(ctx context.Context) {
+			region := rtrace.StartRegion(ctx, spec.symbol)
+			invoke(&params)
+			region.End()
+		})
+	case spec.profileLabel:
+		pprof.Do(context.Background(), pprof.Labels("ffi.symbol", spec.symbol), func(context.Context) {
+			invoke(&params)
+		})
+	case spec.traceRegion:
+		region := rtrace.StartRegion(context.Background(), spec.symbol)
+		invoke(&params)
+		region.End()
+	default:
+		invoke(&params)
+	}
+
+	if spec.metricsEnabled {
+		recordCall(spec.symbol, time.Since(start), params.errno)
+	}
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+
+	if _Cgo_always_false {
+		_Cgo_use(args)
+		_Cgo_use(params)
+	}
+
+	reraiseCallbackPanic()
+
+	return params.errno
+}
+
+// CallBatch runs every call in calls in order, entering syscall state once
+// for the whole batch instead of once per call the way a loop of plain
+// spec.Call(args) calls would - amortizing entersyscall/exitsyscall's cost
+// across however many calls are batched, for a workload making thousands
+// of small C calls back to back. Each call's errno, if UseErrno was set
+// on its Spec, is reported at the matching index of the returned slice.
+//
+// All of Call's per-call bookkeeping that isn't safe to do between
+// entersyscall and exitsyscall - prepareCStrings/preparePinning's
+// allocations, pointer-check's field scans - still runs outside that
+// window here, exactly where Call itself runs it: every call's setup
+// happens before entersyscall, every call's cleanup after exitsyscall.
+// Only the actual foreign calls happen while the batch is "in syscall".
+//
+// Every call's cstr fields share one arena (see arena.go) rather than
+// each call in the batch acquiring its own - the whole batch bump-
+// allocates into the same backing buffer and it's released once, after
+// the last call's cleanup runs.
+func CallBatch(calls []BoundCall) []int32 {
+	a := acquireArena()
+	restoresNil := make([]func(), len(calls))
+	restores := make([]func(), len(calls))
+	unpins := make([]func(), len(calls))
+	unmarshals := make([]func(), len(calls))
+	unbits := make([]func(), len(calls))
+	unpacked := make([]func(), len(calls))
+	params := make([]callParams, len(calls))
+
+	for i, c := range calls {
+		if len(c.Spec.stack) > maxCallStackArgs {
+			panic("ffi: too many stack-passed arguments for asmcall")
+		}
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.checkFields)
+			zeroOutFields(c.Args, c.Spec.outFields)
+		}
+		restoresNil[i] = prepareNilFields(c.Args, c.Spec.nilFields)
+		prepareLenFields(c.Args, c.Spec.lenFields)
+		restores[i] = prepareCStrings(c.Args, c.Spec.cstrFields, a)
+		unpins[i] = preparePinning(c.Args, c.Spec.pinFields)
+		unmarshals[i] = prepareMarshalFields(c.Args, c.Spec.marshalFields, a)
+		unbits[i] = prepareBitFields(c.Args, c.Spec.bitFields)
+		unpacked[i] = preparePackedFields(c.Args, c.Spec.packedFields, a)
+		params[i] = callParams{spec: c.Spec, base: uintptr(c.Args)}
+	}
+
+	entersyscall()
+	for i := range calls {
+		asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&params[i])))
+	}
+	exitsyscall()
+
+	errnos := make([]int32, len(calls))
+	for i, c := range calls {
+		restoresNil[i]()
+		unpins[i]()
+		restores[i]()
+		unmarshals[i]()
+		unbits[i]()
+		unpacked[i]()
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.outFields)
+		}
+		errnos[i] = params[i].errno
+	}
+	releaseArena(a)
+
+	if _Cgo_always_false {
+		_Cgo_use(params)
+	}
+
+	return errnos
+}
+
+// asmcall (ffi_amd64.s) loads spec.intargs/xmmargs/stack into the integer
+// and xmm registers and the outgoing stack per the SysV AMD64 ABI, calls
+// spec.fn, and stores the result back through ret0/ret1/xmmret0/xmmret1.
+// Multi-eightbyte aggregates occupy two consecutive intargs/xmmargs slots
+// (or two consecutive stack entries) and are loaded/stored eightbyte by
+// eightbyte, honoring argument.size for the trailing partial eightbyte. A
+// ffi:"ret" LongDouble field (ldret) is stored separately, via FMOVXP out
+// of ST0, since it's the one return value loadword/storeword's generic
+// register-based machinery can't reach. A M128/M256 argument slot
+// (xmmargs[i].t == typeM128/typeM256) is loaded with a direct MOVOU/
+// VMOVDQU straight from memory instead of through loadword, and a
+// ffi:"ret" M128/M256 field (vecret) is stored the same way out of
+// XMM0/YMM0 - both move a whole register's worth of bytes at once, which
+// the eightbyte-at-a-time loadword/storeword pipeline can't do.
+func asmcall()
+
+var asmcallptr = funcPC(asmcall)
+
+// loadword and storeword are asmcall's shared per-argument load/store
+// subroutines (ffi_amd64.s); they have no Go body and are never called
+// from Go, only jumped to via CALL within asmcall.
+func loadword()
+func storeword()
+
+// CallFast calls spec like Call, but skips both the asmcgocall g0-stack
+// switch and the entersyscall/exitsyscall pair, calling asmcall with a
+// direct CALL from the current goroutine's own stack instead. That's
+// only safe for a C function that returns quickly without blocking (no
+// syscalls, no I/O, no page faults that could grow the stack) and never
+// calls back into Go: asmcall is nosplit, so nothing here ever checks
+// whether the goroutine stack needs to grow, and skipping
+// entersyscall leaves the scheduler believing this goroutine is still
+// running for as long as spec.fn takes, which would stall the GC and any
+// other goroutine on this M if spec.fn actually blocked. Use Call instead
+// for anything that isn't a strlen/getpid-style call guaranteed to return
+// in a handful of instructions.
+//
+// Unlike Call, CallFast hands callFast a real *callParams rather than
+// hiding it behind the uintptr asmcgocall takes, so nothing here needs
+// Call's _Cgo_use trick to keep params reachable across the call - the
+// compiler's ordinary liveness tracking already does that. Combined with
+// callFast's go:noescape (below), params never escapes to the heap: with
+// pointerCheckEnabled off and no cstr/pin fields, CallFast's only
+// per-call cost is the stack-allocated callParams itself, not a heap
+// allocation. BenchmarkCallFast (cache_amd64_test.go) asserts this with
+// b.ReportAllocs().
+func (spec *Spec) CallFast(args unsafe.Pointer) int32 {
+	if len(spec.stack) > maxCallStackArgs {
+		panic("ffi: too many stack-passed arguments for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+	callFast(&params)
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+
+	reraiseCallbackPanic()
+
+	return params.errno
+}
+
+// callFast (ffi_amd64.s) is CallFast's direct-call trampoline: it CALLs
+// asmcall from the current goroutine's own stack instead of handing it to
+// asmcgocall for a g0-stack switch. go:noescape is accurate here exactly
+// as it is for runtime's own asmcgocall (runtime.go): callFast only reads
+// through params for the duration of this synchronous call and never
+// stashes the pointer anywhere that outlives it.
+//
+//go:noescape
+func callFast(params *callParams)
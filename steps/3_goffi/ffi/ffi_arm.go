@@ -0,0 +1,534 @@
+//go:build !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Spec is the callspec needed to do the actual call, holding the
+// linux/arm (AAPCS, VFP hard-float variant) register file: 4 general
+// purpose argument registers (r0-r3) and 8 double precision VFP argument
+// registers (d0-d7), plus the outgoing stack for anything past either.
+//
+// A 64 bit value - an explicit int64/uint64 field, a float64 field, or a
+// 64 bit ffi:"bits=" group - never fits one 32 bit register, so it's
+// never classified as a single argument the way it is on a 64 bit
+// backend: buildSpec always splits it into two adjacent 4 byte halves
+// (low word, then high word) up front, through pushEightbyte below. For
+// the core registers that lands the halves in an aligned adjacent pair,
+// per AAPCS's "if the NCRN is odd, it is incremented" core-register rule
+// (see allocCore); for the VFP registers it always starts a fresh D
+// register (see allocVFP's doc comment for the float32-packing gap that
+// leaves); on the stack it's padded to an even word first so the
+// eightbyte itself lands 8 byte aligned, per AAPCS's stack rules.
+type Spec struct {
+	fn      uintptr
+	stack   []argument
+	intargs [4]argument  // r0-r3
+	fltargs [16]argument // d0-d7, as 4 byte halves: 2*n is Dn's low word, 2*n+1 its high word
+	ret0    argument     // r0, or the low word of a 64 bit integer return
+	ret1    argument     // r1, the high word of a 64 bit integer return
+	fret    argument     // d0 (its low half for a float32 return, both for a float64 one)
+
+	// cstrFields holds the struct offset of every ffi:"cstr" string
+	// field, for Call to marshal via prepareCStrings before each call.
+	cstrFields []uint16
+
+	// pinFields holds the struct offset of every ffi:"pin" pointer/slice
+	// field, for Call to pin via preparePinning before each call.
+	pinFields []uint16
+
+	// nilFields holds every ffi:"nil=panic"/"nil=empty" pointer/slice
+	// field, for Call to check/substitute via prepareNilFields before each
+	// call and restore after.
+	nilFields []nilField
+
+	// readonlyFields holds every ffi:"readonly" slice field, for
+	// CallReadonlyChecked (readonlycheck.go) to mprotect a copy of before
+	// each call. Call/CallFast/CallBatch themselves never read this -
+	// the readonly check is deliberately not part of the ordinary
+	// prepare/restore pipeline every other tag hooks into, since it needs
+	// to wrap the call itself in CallProtected, not just run before/after
+	// it.
+	readonlyFields []readonlyField
+
+	// lenFields holds every ffi:"len="/"cap=" slice/companion pair, for
+	// Call to keep populated via prepareLenFields before each call.
+	lenFields []lenField
+
+	// marshalFields holds every *T args field MakeSpec found pointing
+	// at a Marshaler, for Call to encode/decode via prepareMarshalFields
+	// around each call.
+	marshalFields []marshalField
+
+	// bitFields holds every ffi:"bits=" run's packed representative
+	// argument, for Call to pack via prepareBitFields before each call and
+	// unpack after.
+	bitFields []bitGroup
+
+	// packedFields holds every ffi:"packed"/"align=N" field, for Call to
+	// copy through a correctly packed/aligned scratch buffer via
+	// preparePackedFields before each call and back after.
+	packedFields []packedField
+
+	// checkFields holds every field checkArgPointers should validate under
+	// GODEBUG=ffipointercheck=1.
+	checkFields []checkField
+
+	// outFields holds every ffi:"out" field, for Call to zero via
+	// zeroOutFields before each call and re-validate via checkArgPointers
+	// after, both under GODEBUG=ffipointercheck=1.
+	outFields []checkField
+}
+
+// callParams is the one small, per-call value Call hands asmcall a
+// pointer to, instead of a *Spec alone - see the arm64 backend's
+// identical type for why.
+type callParams struct {
+	spec *Spec
+	base uintptr
+}
+
+// fieldToOffsetArm is fieldToOffset, adjusted for AAPCS/arm: Int, Uint,
+// Uintptr, Ptr, UnsafePointer and a Slice's Data word are 32 bit here,
+// unlike the 64 bit word fieldToOffset assumes for them - see the 386
+// backend's identical fieldToOffset386 for why this needs its own
+// per-arch override. Int64/Uint64 and Float64 are left to fieldToOffset
+// unchanged: it already reports them as an 8 byte type64/typeDouble
+// value, which buildSpec below splits into a register (or stack) pair
+// via pushEightbyte rather than this function's job to flag specially.
+func fieldToOffsetArm(f reflect.StructField, st string) (argument, bool) {
+	if _, override := typeOverrides[st]; !override {
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Uint, reflect.Uintptr, reflect.Ptr, reflect.UnsafePointer:
+			return argument{offset: uint16(f.Offset), t: typeU32, size: 4}, false
+		case reflect.Slice:
+			return argument{offset: uint16(f.Offset) + uint16(sliceOffset), t: typeU32, size: 4}, false
+		}
+	}
+	return fieldToOffset(f, st)
+}
+
+// armAlloc accumulates where buildSpec is placing arguments as it walks
+// the fields: core/vfp track the next free r0-r3/d0-d7 half-word slot
+// (vfp counts in 4 byte halves, 2 per D register; core counts whole 32
+// bit registers, 1 per slot), and stack collects the overflow, padded to
+// keep any eightbyte pushed onto it 8 byte aligned.
+type armAlloc struct {
+	spec *Spec
+	core int
+	vfp  int
+}
+
+// allocCore places a single 32 bit argument into the next free core
+// register, or the stack if r0-r3 are already full.
+func (a *armAlloc) allocCore(off argument) {
+	if a.core < 4 {
+		a.spec.intargs[a.core] = off
+		a.core++
+		return
+	}
+	a.spec.stack = append(a.spec.stack, off)
+}
+
+// allocCoreEightbyte places a 64 bit value's low/high halves into an
+// aligned adjacent core register pair, per AAPCS: if the next free
+// register is odd, it's skipped (left unused, marked typeUnused so
+// asmcall's loadword leaves it alone rather than dereferencing its zero
+// offset/size) and the pair starts at the following even register. If
+// there isn't room for the whole pair, both halves spill to the stack
+// instead - AAPCS never splits one across a register and the stack.
+func (a *armAlloc) allocCoreEightbyte(low, high argument) {
+	if a.core%2 != 0 {
+		if a.core < 4 {
+			a.spec.intargs[a.core] = argument{t: typeUnused}
+		}
+		a.core++
+	}
+	if a.core+2 <= 4 {
+		a.spec.intargs[a.core] = low
+		a.spec.intargs[a.core+1] = high
+		a.core += 2
+		return
+	}
+	a.core = 4
+	a.pushStackEightbyte(low, high)
+}
+
+// allocVFP places a float32 or float64 argument into the next free D
+// register, or the stack once d0-d7 are exhausted. It always starts the
+// value at a fresh D register rather than ever packing two float32
+// values into one register's two halves the way a strictly AAPCS
+// compliant caller would: that packing needs addressing the odd/upper S
+// register half on its own, which Go's arm assembler has no name for
+// (it only exposes F0-F15 as the 16 D registers - see FREGRET/FREGEXT in
+// cmd/internal/obj/arm/a.out.go). A lone float or an all-float64 call
+// still lands exactly where a real AAPCS caller would put it; only a run
+// of two or more float32 arguments diverges, each getting a whole D
+// register to itself instead of sharing one.
+func (a *armAlloc) allocVFP(isDouble bool, low, high argument) {
+	if a.vfp+2 > 16 {
+		a.vfp = 16
+		if isDouble {
+			a.pushStackEightbyte(low, high)
+		} else {
+			a.spec.stack = append(a.spec.stack, low)
+		}
+		return
+	}
+	a.spec.fltargs[a.vfp] = low
+	if isDouble {
+		a.spec.fltargs[a.vfp+1] = high
+	} else {
+		a.spec.fltargs[a.vfp+1] = argument{t: typeUnused}
+	}
+	a.vfp += 2
+}
+
+// pushStackEightbyte appends an 8 byte value's low/high halves to the
+// outgoing stack, first padding it to an even word if needed so the
+// eightbyte itself lands 8 byte aligned within the stack argument area,
+// per AAPCS's stack-passing rules.
+func (a *armAlloc) pushStackEightbyte(low, high argument) {
+	if len(a.spec.stack)%2 != 0 {
+		a.spec.stack = append(a.spec.stack, argument{t: typeUnused})
+	}
+	a.spec.stack = append(a.spec.stack, low, high)
+}
+
+// splitEightbyte returns the low/high 4 byte halves of the 8 byte value
+// at offset: since asmcall's loadword only ever returns one 32 bit
+// register's worth of bits, an int64/uint64/float64/64-bit-bitgroup
+// value is loaded as two plain unsigned 32 bit words instead of one 64
+// bit one - a bit-for-bit copy in two halves either way, and exactly
+// what asmcall's bulk register loads reassemble a D register's 8 bytes
+// (or an R-pair's two 4 byte halves) back out of. AAPCS is little
+// endian, so the lower addressed word is the least significant one.
+func splitEightbyte(offset uint16) (low, high argument) {
+	return argument{offset: offset, t: typeU32, size: 4}, argument{offset: offset + 4, t: typeU32, size: 4}
+}
+
+// buildSpec builds a call specification for the given arguments; it's
+// wrapped by the cached, public MakeSpec in cache.go.
+func buildSpec(fn uintptr, args interface{}) Spec {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	var spec Spec
+	spec.fn = fn
+	spec.ret0.t = typeUnused
+	spec.ret1.t = typeUnused
+	spec.fret.t = typeUnused
+
+	haveRet := false
+	alloc := armAlloc{spec: &spec}
+
+	fields := flattenFields(t)
+	bitGroups := collectBitGroups(fields)
+
+	for i := range fields {
+		f := fields[i]
+		tags := parseFieldTags(f)
+		if tags.ignore {
+			continue
+		}
+		ret := false
+		if tags.ret {
+			if haveRet {
+				panic(&SpecError{Kind: ErrMultipleReturns, Msg: "only one ffi:\"ret\" field allowed"})
+			}
+			ret = true
+			haveRet = true
+		}
+
+		if tags.cstr {
+			off := cstrArg(f, ret, typeU32, 4)
+			spec.cstrFields = append(spec.cstrFields, uint16(f.Offset))
+			alloc.allocCore(off)
+			continue
+		}
+
+		if tags.strptr {
+			data, length := strPtrArg(f, ret, typeU32, 4)
+			alloc.allocCore(data)
+			alloc.allocCore(length)
+			continue
+		}
+
+		if !ret && f.Type.Kind() == reflect.Ptr && marshalElem(f.Type.Elem()) {
+			spec.marshalFields = append(spec.marshalFields, marshalField{offset: uint16(f.Offset), elem: f.Type.Elem()})
+			alloc.allocCore(argument{offset: uint16(f.Offset), t: typeU32, size: 4})
+			continue
+		}
+
+		if tags.bits > 0 {
+			if ret {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=\" is not supported on a ffi:\"ret\" field"})
+			}
+			if g, isRep := bitGroupFor(bitGroups, uint16(f.Offset)); isRep {
+				spec.bitFields = append(spec.bitFields, g)
+				if g.t == type64 {
+					low, high := splitEightbyte(g.offset)
+					alloc.allocCoreEightbyte(low, high)
+				} else {
+					alloc.allocCore(argument{offset: g.offset, t: g.t, size: g.size})
+				}
+			}
+			continue
+		}
+
+		if tags.packed || tags.align > 0 {
+			pf := packedArg(f, tags, ret)
+			spec.packedFields = append(spec.packedFields, pf)
+			alloc.allocCore(argument{offset: pf.offset, t: typeU32, size: 4})
+			continue
+		}
+
+		if tags.pin {
+			spec.pinFields = append(spec.pinFields, pinArg(f, ret))
+		}
+
+		if tags.nilTag != "" {
+			spec.nilFields = append(spec.nilFields, nilArg(f, ret, tags.nilTag))
+		}
+		if tags.readonly {
+			spec.readonlyFields = append(spec.readonlyFields, readonlyArg(f, ret))
+		}
+
+		if tags.lenOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.lenOf, false))
+		}
+		if tags.capOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.capOf, true))
+		}
+
+		if cf, ok := pointerCheckField(f, tags); ok {
+			spec.checkFields = append(spec.checkFields, cf)
+		}
+
+		if tags.out {
+			spec.outFields = append(spec.outFields, outArg(f))
+		}
+
+		if tags.ptr {
+			alloc.allocCore(ptrArg(f, ret))
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct || f.Type.Kind() == reflect.Array {
+			panic(&SpecError{Kind: ErrUnsupportedAggregate, Msg: "arm: struct/array arguments and return values are not yet supported by this backend"})
+		}
+
+		off, flt := fieldToOffsetArm(f, tags.typ)
+		if ret {
+			if flt {
+				if off.size == 8 {
+					spec.fret = argument{offset: off.offset, t: typeDouble, size: 8}
+				} else {
+					spec.fret = off
+				}
+			} else if off.size == 8 {
+				low, high := splitEightbyte(off.offset)
+				spec.ret0, spec.ret1 = low, high
+			} else {
+				spec.ret0 = off
+			}
+			continue
+		}
+		if flt {
+			if off.size == 8 {
+				low, high := splitEightbyte(off.offset)
+				alloc.allocVFP(true, low, high)
+			} else {
+				alloc.allocVFP(false, off, argument{})
+			}
+		} else if off.size == 8 {
+			low, high := splitEightbyte(off.offset)
+			alloc.allocCoreEightbyte(low, high)
+		} else {
+			alloc.allocCore(off)
+		}
+	}
+	if n := len(spec.stack); n > maxCallStackWords {
+		panic(&SpecError{Kind: ErrTooManyArgs, Msg: "too many stack-passed arguments for asmcall"})
+	}
+	return spec
+}
+
+// maxCallStackWords bounds how many 4 byte words of spec.stack a single
+// Call can pass; asmcall (ffi_arm.s) stages the outgoing stack argument
+// block in a fixed-size buffer sized off this constant, since the
+// assembler needs the frame size at build time.
+const maxCallStackWords = 64
+
+// Call calls spec with the given arguments. spec is read-only here - the
+// args pointer asmcall needs lives in a small callParams value local to
+// this call instead - so the exact same Spec can be called concurrently
+// from any number of goroutines with no per-call copy of it. See the
+// amd64/arm64 backends' identical Call for the rest of the rationale.
+func (spec *Spec) Call(args unsafe.Pointer) {
+	if len(spec.stack) > maxCallStackWords {
+		panic("ffi: too many stack-passed arguments for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+
+	entersyscall()
+	asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&params)))
+	exitsyscall()
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+
+	if _Cgo_always_false {
+		_Cgo_use(args)
+		_Cgo_use(params)
+	}
+}
+
+// CallBatch runs every call in calls in order, entering syscall state once
+// for the whole batch instead of once per call - see the amd64 backend's
+// identical function for the full rationale. This backend has no
+// UseErrno, so like the arm64 backend, there's nothing per-call to report
+// back.
+func CallBatch(calls []BoundCall) {
+	a := acquireArena()
+	restoresNil := make([]func(), len(calls))
+	restores := make([]func(), len(calls))
+	unpins := make([]func(), len(calls))
+	unmarshals := make([]func(), len(calls))
+	unbits := make([]func(), len(calls))
+	unpacked := make([]func(), len(calls))
+	params := make([]callParams, len(calls))
+
+	for i, c := range calls {
+		if len(c.Spec.stack) > maxCallStackWords {
+			panic("ffi: too many stack-passed arguments for asmcall")
+		}
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.checkFields)
+			zeroOutFields(c.Args, c.Spec.outFields)
+		}
+		restoresNil[i] = prepareNilFields(c.Args, c.Spec.nilFields)
+		prepareLenFields(c.Args, c.Spec.lenFields)
+		restores[i] = prepareCStrings(c.Args, c.Spec.cstrFields, a)
+		unpins[i] = preparePinning(c.Args, c.Spec.pinFields)
+		unmarshals[i] = prepareMarshalFields(c.Args, c.Spec.marshalFields, a)
+		unbits[i] = prepareBitFields(c.Args, c.Spec.bitFields)
+		unpacked[i] = preparePackedFields(c.Args, c.Spec.packedFields, a)
+		params[i] = callParams{spec: c.Spec, base: uintptr(c.Args)}
+	}
+
+	entersyscall()
+	for i := range calls {
+		asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&params[i])))
+	}
+	exitsyscall()
+
+	for i, c := range calls {
+		restoresNil[i]()
+		unpins[i]()
+		restores[i]()
+		unmarshals[i]()
+		unbits[i]()
+		unpacked[i]()
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.outFields)
+		}
+	}
+	releaseArena(a)
+
+	if _Cgo_always_false {
+		_Cgo_use(params)
+	}
+}
+
+// asmcall (ffi_arm.s) loads spec.intargs/fltargs/stack into r0-r3/d0-d7
+// and the outgoing stack argument area, calls spec.fn, and stores the
+// result back through ret0/ret1/fret.
+func asmcall()
+
+var asmcallptr = funcPC(asmcall)
+
+// loadword and storeword are asmcall's shared per-argument load/store
+// subroutines (ffi_arm.s); they have no Go body and are never called
+// from Go, only branched to within asmcall.
+func loadword()
+func storeword()
+
+// CallFast calls spec like Call, but skips both the asmcgocall g0-stack
+// switch and the entersyscall/exitsyscall pair, calling asmcall with a
+// direct CALL from the current goroutine's own stack instead - see the
+// amd64/arm64 backends' identical method for the constraints that makes
+// safe.
+func (spec *Spec) CallFast(args unsafe.Pointer) {
+	if len(spec.stack) > maxCallStackWords {
+		panic("ffi: too many stack-passed arguments for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+	callFast(&params)
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+}
+
+// callFast (ffi_arm.s) is CallFast's direct-call trampoline: it CALLs
+// asmcall from the current goroutine's own stack instead of handing it to
+// asmcgocall for a g0-stack switch.
+//
+//go:noescape
+func callFast(params *callParams)
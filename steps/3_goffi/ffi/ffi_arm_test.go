@@ -0,0 +1,136 @@
+package ffi
+
+import "testing"
+
+// TestBuildSpecArmRegisterAllocation exercises buildSpec's AAPCS
+// hard-float layout: the first 4 32 bit args land in spec.intargs, the
+// first 4 float args each get their own spec.fltargs D register slot
+// (independently counted), and nothing spills to spec.stack.
+func TestBuildSpecArmRegisterAllocation(t *testing.T) {
+	type args struct {
+		A0, A1, A2, A3 int32   `ffi:"arg"`
+		F0, F1, F2, F3 float64 `ffi:"arg"`
+		R              int32   `ffi:"ret"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	for i := 0; i < 4; i++ {
+		if spec.intargs[i].t != typeU32 {
+			t.Fatalf("spec.intargs[%d]: got t=%v, want typeU32", i, spec.intargs[i].t)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if spec.fltargs[2*i].t != typeU32 {
+			t.Fatalf("spec.fltargs[%d]: got t=%v, want typeU32", 2*i, spec.fltargs[2*i].t)
+		}
+		if spec.fltargs[2*i+1].t != typeU32 {
+			t.Fatalf("spec.fltargs[%d]: got t=%v, want typeU32", 2*i+1, spec.fltargs[2*i+1].t)
+		}
+	}
+	if len(spec.stack) != 0 {
+		t.Fatalf("spec.stack: got %d entries, want 0", len(spec.stack))
+	}
+	if spec.ret0.t != typeU32 {
+		t.Fatalf("spec.ret0: got t=%v, want typeU32", spec.ret0.t)
+	}
+}
+
+// TestBuildSpecArmRegisterSpill confirms that a 5th core argument and a
+// 9th float argument both spill to spec.stack once r0-r3/d0-d7 are full.
+func TestBuildSpecArmRegisterSpill(t *testing.T) {
+	type args struct {
+		A0, A1, A2, A3, A4                 int32   `ffi:"arg"`
+		F0, F1, F2, F3, F4, F5, F6, F7, F8 float64 `ffi:"arg"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	// A4 spills first (1 entry); F8 then spills as a padded eightbyte (a
+	// typeUnused alignment pad plus its low/high halves), since A4 left
+	// the stack at an odd length.
+	if len(spec.stack) != 4 {
+		t.Fatalf("spec.stack: got %d entries, want 4 (A4, pad, F8 low+high)", len(spec.stack))
+	}
+	if spec.stack[0].t != typeU32 {
+		t.Fatalf("spec.stack[0] (A4): got t=%v, want typeU32", spec.stack[0].t)
+	}
+	if spec.stack[1].t != typeUnused {
+		t.Fatalf("spec.stack[1]: got t=%v, want typeUnused (alignment padding)", spec.stack[1].t)
+	}
+	if spec.stack[2].t != typeU32 || spec.stack[3].t != typeU32 {
+		t.Fatalf("spec.stack[2:4]: got t=%v/%v, want typeU32/typeU32 (F8's low/high halves)", spec.stack[2].t, spec.stack[3].t)
+	}
+}
+
+// TestBuildSpecArmEightbyteRegisterPairing confirms AAPCS's core-register
+// pairing/alignment rule: a 64 bit value needing a register pair starts
+// at an even numbered register, skipping an odd one left over from a
+// preceding 32 bit argument rather than splitting across the skipped
+// register and the next one.
+func TestBuildSpecArmEightbyteRegisterPairing(t *testing.T) {
+	type args struct {
+		A0  int32 `ffi:"arg"` // r0
+		Big int64 `ffi:"arg"` // r1 would be next, but must skip to r2:r3
+	}
+
+	spec := buildSpec(0, &args{})
+
+	if spec.intargs[0].t != typeU32 {
+		t.Fatalf("spec.intargs[0] (A0): got t=%v, want typeU32", spec.intargs[0].t)
+	}
+	if spec.intargs[1].t != typeUnused {
+		t.Fatalf("spec.intargs[1]: got t=%v, want typeUnused (skipped for alignment)", spec.intargs[1].t)
+	}
+	if spec.intargs[2].t != typeU32 || spec.intargs[3].t != typeU32 {
+		t.Fatalf("spec.intargs[2:4]: got t=%v/%v, want typeU32/typeU32 (Big's low/high halves)", spec.intargs[2].t, spec.intargs[3].t)
+	}
+	if len(spec.stack) != 0 {
+		t.Fatalf("spec.stack: got %d entries, want 0", len(spec.stack))
+	}
+}
+
+// TestBuildSpecArmEightbyteStackSpill confirms that once r0-r3 are full,
+// a 64 bit value spills to the stack whole, and that the stack itself
+// pads to an even word first if needed so the eightbyte lands 8 byte
+// aligned - here, the preceding A4 already left the stack at an odd
+// length.
+func TestBuildSpecArmEightbyteStackSpill(t *testing.T) {
+	type args struct {
+		A0, A1, A2, A3 int32 `ffi:"arg"` // r0-r3, core full
+		A4             int32 `ffi:"arg"` // spills to stack[0] (odd length)
+		Big            int64 `ffi:"arg"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	if len(spec.stack) != 4 {
+		t.Fatalf("spec.stack: got %d entries, want 4 (A4, pad, Big's low/high)", len(spec.stack))
+	}
+	if spec.stack[0].t != typeU32 {
+		t.Fatalf("spec.stack[0] (A4): got t=%v, want typeU32", spec.stack[0].t)
+	}
+	if spec.stack[1].t != typeUnused {
+		t.Fatalf("spec.stack[1]: got t=%v, want typeUnused (alignment padding)", spec.stack[1].t)
+	}
+	if spec.stack[2].t != typeU32 || spec.stack[3].t != typeU32 {
+		t.Fatalf("spec.stack[2:4]: got t=%v/%v, want typeU32/typeU32 (Big's low/high halves)", spec.stack[2].t, spec.stack[3].t)
+	}
+}
+
+// TestBuildSpecArmAggregatePanics confirms the documented v1 limitation:
+// struct/array arguments and return values aren't placed into registers
+// or the stack yet, so buildSpec must panic rather than silently
+// misclassify one.
+func TestBuildSpecArmAggregatePanics(t *testing.T) {
+	type args struct {
+		S struct{ X, Y int32 } `ffi:"arg"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("buildSpec: expected a panic for an aggregate argument")
+		}
+	}()
+	buildSpec(0, &args{})
+}
@@ -0,0 +1,321 @@
+//go:build nocgo_fallback_cgo || tinygo || gccgo
+
+package ffi
+
+/*
+#cgo pkg-config: libffi
+#include <ffi.h>
+
+// ffi_call's fn parameter is void(*)(void); cgo has no direct spelling for
+// a C function-pointer type, only the void* every other call site in this
+// package already carries spec.fn around as. This trampoline is the one
+// place that cast actually happens.
+static void go_ffi_call(ffi_cif *cif, void *fn, void *rvalue, void **avalues) {
+	ffi_call(cif, (void (*)(void))fn, rvalue, avalues);
+}
+*/
+import "C"
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Spec, buildSpec, Call and CallBatch here are this package's cgo+libffi
+// backend: the same public API ffi_amd64.go and its per-arch siblings
+// provide - MakeSpec itself is cache.go's, unchanged, and dispatches to
+// buildSpec exactly as it already does for every native backend - built
+// on libffi's ffi_prep_cif/ffi_call instead of a hand-written asmcall
+// trampoline, for a GOARCH/GOOS this package has no native backend for
+// at all. Unlike those siblings, this file carries no arch suffix and no
+// arch-specific build constraint of its own - only the explicit
+// nocgo_fallback_cgo tag, which every native backend's own file
+// (ffi_amd64.go, ffi_windows_amd64.go, ffi_386.go, ffi_arm.go,
+// ffi_arm64.go, ffi_loong64.go, ffi_ppc64le.go, ffi_riscv64.go,
+// ffi_s390x.go, and their .s siblings, along with every test helper .s
+// file in the package - cgo refuses to build a package containing any
+// hand-written Go assembly at all, test-only or not, so every one of
+// them needs the same exclusion, not just the backend's own) excludes
+// itself under, so setting it switches the whole package over to this
+// backend regardless of GOARCH, supported or not.
+//
+// That portability comes at a real cost in tag coverage: buildSpec below
+// only classifies a field MakeSpec could pass straight through to
+// fieldToOffset (ffi.go) - a scalar int/uint/float/pointer/slice/bool
+// argument, or a single ffi:"ret" field of the same kind. cstr, wstr,
+// strptr, pin, sret, out, errno, fnptr, bits=, packed, align=, nil= and
+// readonly, along with any struct/array (aggregate) argument or return,
+// all panic rather than being silently misclassified - implementing each
+// on top of libffi is possible in principle (most have a direct libffi
+// equivalent: ffi_type_struct for an aggregate, a manual NUL-terminated
+// copy for cstr, ...) but is its own, separately-scoped piece of work,
+// not a prerequisite for a package that only needs plain scalar
+// arguments to build at all on a platform it otherwise couldn't.
+//
+// The same exclusion this file needs from every native backend's own
+// file and .s sibling also reaches well past ffi_amd64.go's immediate
+// neighbors: MakeCallback's trampoline (callback_amd64.go), and by
+// extension everything layered on it (callback_foreign_amd64.go,
+// callback_panic_amd64.go, callback_reentrancy_amd64.go,
+// callback_reflect_amd64.go, closure_amd64.go, signal_callback_amd64.go),
+// along with introspect_amd64.go, staticspec_amd64.go,
+// staticspec_encoding_amd64.go, variadic_amd64.go, errno_ret_amd64.go,
+// explain_amd64.go, fnptr_amd64.go, fork_amd64.go, guardstack_amd64.go,
+// syscall_amd64.go and thread_hooks_amd64.go all reach directly into the
+// native Spec struct's amd64-specific fields (intargs, xmmargs, stack,
+// ret0, ret1, xmmret0, xmmret1, ...), which this file's Spec doesn't
+// have, so they're excluded under nocgo_fallback_cgo too - probe_other.go
+// already has the right always-false probeCallbacksAvailable/
+// probeTLSAvailable stubs for an arch MakeCallback was never ported to,
+// and now doubles as the stub for this tag as well. MakeCallback,
+// ReleaseCallback, introspection and static-spec encoding simply aren't
+// part of what nocgo_fallback_cgo provides - only MakeSpec/Spec.Call/
+// CallBatch, the three names this file's own doc comment opened with.
+//
+// This file also builds under the implicit tinygo build tag TinyGo sets
+// on every build it drives, with no nocgo_fallback_cgo needed - the two
+// tags exclude the same native files for overlapping reasons (TinyGo
+// neither assembles hand-written Go asm nor links the runtime internals
+// runtime.go's go:linkname declarations depend on, asmcgocall/
+// entersyscall/exitsyscall/cgoUse/cgoAlwaysFalse/iscgo included, none of
+// which TinyGo's own runtime defines under those names), so the same
+// cgo+libffi backend serves both. Call below makes a single direct
+// C.go_ffi_call rather than Call's (ffi_amd64.go) asmcgocall-wrapped,
+// entersyscall/exitsyscall-bracketed one, which is deliberate, not just
+// a side effect of reusing this file: TinyGo's scheduler doesn't
+// preempt goroutines onto other OS threads the way the gc runtime's
+// does, so there's no "this goroutine is blocked in a syscall, hand its
+// P to someone else" accounting to opt into or out of in the first
+// place - entersyscall/exitsyscall would be bracketing a notion of
+// concurrency TinyGo's scheduler doesn't have, on a runtime that in any
+// case doesn't export them to link against. Its own cgo call sequence
+// already does whatever blocking-call bookkeeping its target actually
+// needs.
+//
+// This hasn't been built or run against an actual TinyGo toolchain - one
+// isn't available in the environment these changes were made in - so
+// treat the tinygo side of this build tag as unverified beyond reading
+// TinyGo's documented constraints (no Go asm, partial reflect, cgo
+// supported on its hosted targets); go vet/go build here only stand in
+// for the plain cgo toolchain's half of it.
+//
+// The implicit gccgo build tag gccgo sets joins the same list for a
+// different reason than tinygo's: gccgo can assemble Go, but not this
+// package's Plan9-syntax .s files (it wants GNU as syntax instead), and
+// its runtime package doesn't export asmcgocall/entersyscall/exitsyscall/
+// cgoUse/cgoAlwaysFalse/iscgo under go:linkname-reachable names the way
+// the gc runtime's does - two unrelated gc-toolchain assumptions that
+// happen to rule out the same native files gccgo's own differences would
+// anyway. gccgo's cgo support is what this file actually runs on there;
+// nothing about it is gccgo-specific beyond the build tag reusing its
+// cgo+libffi path like tinygo's does. Unverified against a real gccgo
+// install for the same reason as the tinygo side above.
+type Spec struct {
+	fn uintptr
+
+	cif      C.ffi_cif
+	argTypes []*C.ffi_type
+	args     []argument
+
+	ret    argument
+	hasRet bool
+}
+
+// buildSpec classifies args's fields the same way every native backend's
+// buildSpec does - flattenFields for embedded headers, parseFieldTags
+// and fieldToOffset for each field's placement - then hands the result
+// to libffi's ffi_prep_cif instead of this package's own per-arch
+// register/stack placement logic.
+func buildSpec(fn uintptr, args interface{}) Spec {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	var spec Spec
+	spec.fn = fn
+
+	for _, f := range flattenFields(t) {
+		ft := parseFieldTags(f)
+		if ft.ignore {
+			continue
+		}
+		if ft.cstr || ft.wstr || ft.strptr || ft.pin || ft.sret || ft.out || ft.errno ||
+			ft.fnptr || ft.packed || ft.readonly || ft.bits != 0 || ft.align != 0 || ft.nilTag != "" ||
+			ft.lenOf != "" || ft.capOf != "" {
+			panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi: nocgo_fallback_cgo supports only plain scalar arg/ret fields, not ffi:\"" + f.Tag.Get("ffi") + "\""})
+		}
+
+		arg, _ := fieldToOffset(f, ft.typ)
+		if ft.ret {
+			if spec.hasRet {
+				panic(&SpecError{Kind: ErrMultipleReturns, Msg: "ffi: more than one ffi:\"ret\" field"})
+			}
+			spec.ret = arg
+			spec.hasRet = true
+			continue
+		}
+		spec.args = append(spec.args, arg)
+	}
+
+	return prepCif(spec)
+}
+
+// StaticSpec builds a Spec directly from a precomputed, flat argument
+// list, skipping buildSpec's reflection entirely - the counterpart
+// StaticSpec (staticspec_amd64.go) provides for the native amd64
+// backend, and the one this backend's own doc comment above means by
+// "generated-spec mode": a spec generator (cmd/ffigen, or TinyGo code
+// that can't rely on reflect being fully implemented) can emit args as a
+// []Arg literal instead of a Go struct type for MakeSpec to classify at
+// init time. Unlike the native StaticSpec, there's no per-register table
+// to fill in here - libffi's ffi_prep_cif only needs a flat, ordered
+// argument list, not a SysV-specific register/stack split - so the
+// signature is correspondingly simpler: args in call order, plus ret
+// (only read when hasRet is true).
+//
+// As with buildSpec, args is limited to plain scalar kinds libffiType
+// maps; an Arg carrying ArgAddr, ArgLongDouble, ArgM128 or ArgM256 panics
+// in libffiType rather than being silently misclassified.
+func StaticSpec(fn uintptr, args []Arg, ret Arg, hasRet bool) Spec {
+	var spec Spec
+	spec.fn = fn
+
+	spec.args = make([]argument, len(args))
+	for i, a := range args {
+		spec.args[i] = a.toArgument()
+	}
+	if hasRet {
+		spec.ret = ret.toArgument()
+		spec.hasRet = true
+	}
+
+	return prepCif(spec)
+}
+
+// prepCif fills in spec.cif from spec.args/spec.ret via libffi's
+// ffi_prep_cif - the shared tail of both buildSpec's reflect-based
+// classification and StaticSpec's reflect-free one, once each has
+// populated spec.args/spec.ret/spec.hasRet by its own means.
+func prepCif(spec Spec) Spec {
+	spec.argTypes = make([]*C.ffi_type, len(spec.args))
+	for i, a := range spec.args {
+		spec.argTypes[i] = libffiType(a.t)
+	}
+
+	rtype := &C.ffi_type_void
+	if spec.hasRet {
+		rtype = libffiType(spec.ret.t)
+	}
+	var argTypesPtr **C.ffi_type
+	if len(spec.argTypes) > 0 {
+		argTypesPtr = &spec.argTypes[0]
+	}
+	if status := C.ffi_prep_cif(&spec.cif, C.FFI_DEFAULT_ABI, C.uint(len(spec.argTypes)), rtype, argTypesPtr); status != C.FFI_OK {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi: ffi_prep_cif failed"})
+	}
+	return spec
+}
+
+// libffiType maps the argtype fieldToOffset produced (ffi.go) to the
+// matching libffi ffi_type. fieldToOffset never returns typeAddr,
+// typeLongDouble, typeM128 or typeM256 for a plain field - those only
+// come from ffi:"ptr"/ffi:"ret" LongDouble/vector handling, which
+// buildSpec above already refuses before reaching here - so this covers
+// every value it actually needs to.
+func libffiType(t argtype) *C.ffi_type {
+	switch t {
+	case type64:
+		return &C.ffi_type_uint64
+	case typeS32:
+		return &C.ffi_type_sint32
+	case typeU32:
+		return &C.ffi_type_uint32
+	case typeS16:
+		return &C.ffi_type_sint16
+	case typeU16:
+		return &C.ffi_type_uint16
+	case typeS8:
+		return &C.ffi_type_sint8
+	case typeU8:
+		return &C.ffi_type_uint8
+	case typeDouble:
+		return &C.ffi_type_double
+	case typeFloat:
+		return &C.ffi_type_float
+	}
+	panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi: nocgo_fallback_cgo: no libffi type for this argtype"})
+}
+
+// Call invokes the foreign function through libffi's ffi_call, writing
+// the return value (if spec has one) directly into args at its
+// ffi:"ret" field's offset. maxScalarRet is sized for the largest value
+// libffi ever writes through rvalue for any argtype libffiType maps - a
+// full 8 byte register, never more, since buildSpec already refuses any
+// ffi:"ret" field wider than a plain scalar (typeLongDouble/typeM128/
+// typeM256 panic in libffiType, not reached if buildSpec already
+// panicked first, but kept here too since defense here is free).
+//
+// libffi canonicalizes an integer return narrower than a full register
+// into the low bytes of that register (see its README), so ret's actual
+// width (ret.size) - not the full 8 bytes rvalue received - is what gets
+// copied into args; a float/double return is written at exactly its own
+// width by ffi_call itself and copied through unchanged.
+func (spec *Spec) Call(args unsafe.Pointer) int32 {
+	var avalues []unsafe.Pointer
+	if n := len(spec.args); n > 0 {
+		avalues = make([]unsafe.Pointer, n)
+		for i, a := range spec.args {
+			avalues[i] = unsafe.Pointer(uintptr(args) + uintptr(a.offset))
+		}
+	}
+
+	const maxScalarRet = 8
+	var retbuf [maxScalarRet]byte
+	var rvalue unsafe.Pointer
+	if spec.hasRet {
+		rvalue = unsafe.Pointer(&retbuf[0])
+	}
+
+	var avaluesPtr *unsafe.Pointer
+	if len(avalues) > 0 {
+		avaluesPtr = &avalues[0]
+	}
+	C.go_ffi_call(&spec.cif, unsafe.Pointer(spec.fn), rvalue, avaluesPtr)
+
+	if spec.hasRet {
+		dst := unsafe.Pointer(uintptr(args) + uintptr(spec.ret.offset))
+		switch spec.ret.size {
+		case 1:
+			*(*byte)(dst) = retbuf[0]
+		case 2:
+			*(*uint16)(dst) = *(*uint16)(unsafe.Pointer(&retbuf[0]))
+		case 4:
+			*(*uint32)(dst) = *(*uint32)(unsafe.Pointer(&retbuf[0]))
+		case 8:
+			*(*uint64)(dst) = *(*uint64)(unsafe.Pointer(&retbuf[0]))
+		}
+	}
+
+	return 0
+}
+
+// CallBatch runs every call in calls in order through Call. The native
+// backends' CallBatch (e.g. ffi_amd64.go) enters syscall state once for
+// the whole batch to amortize entersyscall/exitsyscall's cost across
+// many calls, because they reach the foreign function via asmcgocall
+// directly and so have to do that bookkeeping themselves. Call here
+// never calls entersyscall/exitsyscall at all: C.go_ffi_call is an
+// ordinary cgo call, and the compiler-generated code behind every cgo
+// call already performs that same transition on its own, once per call,
+// whether or not this file asks for it - there is nothing left here to
+// amortize, only the same per-call behavior CallSlice (batch.go) already
+// expects to be able to drive in a loop.
+func CallBatch(calls []BoundCall) []int32 {
+	errnos := make([]int32, len(calls))
+	for i, c := range calls {
+		errnos[i] = c.Spec.Call(c.Args)
+	}
+	return errnos
+}
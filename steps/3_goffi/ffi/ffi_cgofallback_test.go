@@ -0,0 +1,83 @@
+//go:build nocgo_fallback_cgo || tinygo || gccgo
+
+package ffi
+
+import "testing"
+
+// TestFallbackBuildSpecScalarArgs confirms buildSpec classifies a plain
+// scalar arg/ret pair the same way MakeSpec would hand it to libffiType -
+// not itself a substitute for calling through libffi, which is what
+// actually exercises ffi_prep_cif/ffi_call (the part most likely to be
+// wrong), but cmd/go refuses to build any _test.go file - internal or
+// external package alike - that imports "C" (see cmd/go/internal/
+// modindex's isTest check), so unlike the native backends this one can't
+// carry its own real-call test in this package's normal test layout
+// either - the same gap ffi_386_test.go's own comment describes for a
+// different reason (cdecl's *Spec convention, not cgo's test-file rule).
+func TestFallbackBuildSpecScalarArgs(t *testing.T) {
+	type args struct {
+		A int32   `ffi:"arg"`
+		B float64 `ffi:"arg"`
+		R uint16  `ffi:"ret"`
+	}
+
+	spec := buildSpec(0, &args{})
+	if len(spec.args) != 2 {
+		t.Fatalf("spec.args: got %d entries, want 2", len(spec.args))
+	}
+	if spec.args[0].t != typeS32 {
+		t.Fatalf("spec.args[0] (A): got t=%v, want typeS32", spec.args[0].t)
+	}
+	if spec.args[1].t != typeDouble {
+		t.Fatalf("spec.args[1] (B): got t=%v, want typeDouble", spec.args[1].t)
+	}
+	if !spec.hasRet || spec.ret.t != typeU16 {
+		t.Fatalf("spec.ret: got hasRet=%v t=%v, want hasRet=true t=typeU16", spec.hasRet, spec.ret.t)
+	}
+}
+
+// TestFallbackStaticSpecMatchesBuildSpec confirms StaticSpec's reflect-free
+// path classifies the same args buildSpec's reflect-based one would into
+// an identical spec.args/spec.ret, the way cmd/ffigen-generated code
+// (or a TinyGo caller avoiding reflect) is expected to rely on.
+func TestFallbackStaticSpecMatchesBuildSpec(t *testing.T) {
+	type reflected struct {
+		A int32   `ffi:"arg"`
+		B float64 `ffi:"arg"`
+		R uint16  `ffi:"ret"`
+	}
+	want := buildSpec(0, &reflected{})
+
+	got := StaticSpec(0, []Arg{
+		{Offset: 0, Kind: ArgS32, Size: 4},
+		{Offset: 8, Kind: ArgDouble, Size: 8},
+	}, Arg{Offset: 16, Kind: ArgU16, Size: 2}, true)
+
+	if len(got.args) != len(want.args) {
+		t.Fatalf("got.args: got %d entries, want %d", len(got.args), len(want.args))
+	}
+	for i := range want.args {
+		if got.args[i] != want.args[i] {
+			t.Fatalf("got.args[%d] = %+v, want %+v", i, got.args[i], want.args[i])
+		}
+	}
+	if got.hasRet != want.hasRet || got.ret != want.ret {
+		t.Fatalf("got ret = %+v (hasRet=%v), want %+v (hasRet=%v)", got.ret, got.hasRet, want.ret, want.hasRet)
+	}
+}
+
+// TestFallbackBuildSpecRejectsUnsupportedTag confirms buildSpec panics
+// rather than silently misclassifying an ffi tag it doesn't implement -
+// see ffi_cgofallback.go's doc comment for the full list.
+func TestFallbackBuildSpecRejectsUnsupportedTag(t *testing.T) {
+	type args struct {
+		S string `ffi:"cstr"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("buildSpec: expected a panic for ffi:\"cstr\"")
+		}
+	}()
+	buildSpec(0, &args{})
+}
@@ -0,0 +1,58 @@
+package ffi
+
+import "testing"
+
+// TestBuildSpecLoong64RegisterAllocation exercises buildSpec's LP64D
+// layout: the first 8 integer args land in spec.intargs, the first 8
+// float args land in spec.fltargs (independently counted, the same split
+// AAPCS64 makes on arm64), and anything past either register file spills
+// to spec.stack in declaration order.
+func TestBuildSpecLoong64RegisterAllocation(t *testing.T) {
+	type args struct {
+		A0, A1, A2, A3, A4, A5, A6, A7, A8 int64   `ffi:"arg"`
+		F0, F1                             float64 `ffi:"arg"`
+		R                                  int64   `ffi:"ret"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	for i := 0; i < 8; i++ {
+		if spec.intargs[i].t != type64 {
+			t.Fatalf("spec.intargs[%d]: got t=%v, want type64", i, spec.intargs[i].t)
+		}
+	}
+	if len(spec.stack) != 1 {
+		t.Fatalf("spec.stack: got %d entries, want 1 (A8 spilled)", len(spec.stack))
+	}
+	if spec.stack[0].t != type64 {
+		t.Fatalf("spec.stack[0] (A8): got t=%v, want type64", spec.stack[0].t)
+	}
+	if spec.fltargs[0].t != typeDouble || spec.fltargs[1].t != typeDouble {
+		t.Fatalf("spec.fltargs[0:2]: got %v/%v, want typeDouble/typeDouble", spec.fltargs[0].t, spec.fltargs[1].t)
+	}
+	for i := 2; i < 8; i++ {
+		if spec.fltargs[i].t != typeUnused {
+			t.Fatalf("spec.fltargs[%d]: got t=%v, want typeUnused", i, spec.fltargs[i].t)
+		}
+	}
+	if spec.ret0.t != type64 {
+		t.Fatalf("spec.ret0: got t=%v, want type64", spec.ret0.t)
+	}
+}
+
+// TestBuildSpecLoong64AggregatePanics confirms the documented v1
+// limitation: struct/array arguments and return values aren't placed
+// into registers or the stack yet, so buildSpec must panic rather than
+// silently misclassify one.
+func TestBuildSpecLoong64AggregatePanics(t *testing.T) {
+	type args struct {
+		S struct{ X, Y int64 } `ffi:"arg"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("buildSpec: expected a panic for an aggregate argument")
+		}
+	}()
+	buildSpec(0, &args{})
+}
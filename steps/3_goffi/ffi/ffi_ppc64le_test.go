@@ -0,0 +1,57 @@
+package ffi
+
+import "testing"
+
+// TestBuildSpecPPC64LERegisterAllocation exercises buildSpec's ELFv2
+// layout: the first 8 integer args land in spec.intargs, the first 13
+// float args land in spec.fltargs (independently counted), and anything
+// past either register file spills to spec.stack in declaration order.
+func TestBuildSpecPPC64LERegisterAllocation(t *testing.T) {
+	type args struct {
+		A0, A1, A2, A3, A4, A5, A6, A7, A8                         int64   `ffi:"arg"`
+		F0, F1, F2, F3, F4, F5, F6, F7, F8, F9, F10, F11, F12, F13 float64 `ffi:"arg"`
+		R                                                          int64   `ffi:"ret"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	for i := 0; i < 8; i++ {
+		if spec.intargs[i].t != type64 {
+			t.Fatalf("spec.intargs[%d]: got t=%v, want type64", i, spec.intargs[i].t)
+		}
+	}
+	for i := 0; i < 13; i++ {
+		if spec.fltargs[i].t != typeDouble {
+			t.Fatalf("spec.fltargs[%d]: got t=%v, want typeDouble", i, spec.fltargs[i].t)
+		}
+	}
+	if len(spec.stack) != 2 {
+		t.Fatalf("spec.stack: got %d entries, want 2 (A8, F13 spilled)", len(spec.stack))
+	}
+	if spec.stack[0].t != type64 {
+		t.Fatalf("spec.stack[0] (A8): got t=%v, want type64", spec.stack[0].t)
+	}
+	if spec.stack[1].t != typeDouble {
+		t.Fatalf("spec.stack[1] (F13): got t=%v, want typeDouble", spec.stack[1].t)
+	}
+	if spec.ret0.t != type64 {
+		t.Fatalf("spec.ret0: got t=%v, want type64", spec.ret0.t)
+	}
+}
+
+// TestBuildSpecPPC64LEAggregatePanics confirms the documented v1
+// limitation: struct/array arguments and return values aren't placed
+// into registers or the stack yet, so buildSpec must panic rather than
+// silently misclassify one.
+func TestBuildSpecPPC64LEAggregatePanics(t *testing.T) {
+	type args struct {
+		S struct{ X, Y int64 } `ffi:"arg"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("buildSpec: expected a panic for an aggregate argument")
+		}
+	}()
+	buildSpec(0, &args{})
+}
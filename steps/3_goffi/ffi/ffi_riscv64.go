@@ -0,0 +1,454 @@
+//go:build !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Spec is the callspec needed to do the actual call, holding the RISC-V
+// LP64D register file: 8 integer argument registers (a0-a7), 8 floating
+// point argument registers (fa0-fa7), one integer return register (a0)
+// and one floating point return register (fa0).
+//
+// This first cut of the riscv64 backend only places scalar arguments and
+// a single scalar return value into those registers or the stack -
+// struct/array arguments and return values (placeArgAggregate/
+// placeRetAggregate on the arm64 backend) aren't implemented yet; see
+// buildSpec's panic below for exactly where that's enforced.
+type Spec struct {
+	fn      uintptr
+	stack   []argument
+	intargs [8]argument
+	fltargs [8]argument
+	ret0    argument
+	fltret  argument
+
+	// cstrFields holds the struct offset of every ffi:"cstr" string
+	// field, for Call to marshal via prepareCStrings before each call.
+	cstrFields []uint16
+
+	// pinFields holds the struct offset of every ffi:"pin" pointer/slice
+	// field, for Call to pin via preparePinning before each call.
+	pinFields []uint16
+
+	// nilFields holds every ffi:"nil=panic"/"nil=empty" pointer/slice
+	// field, for Call to check/substitute via prepareNilFields before each
+	// call and restore after.
+	nilFields []nilField
+
+	// readonlyFields holds every ffi:"readonly" slice field, for
+	// CallReadonlyChecked (readonlycheck.go) to mprotect a copy of before
+	// each call. Call/CallFast/CallBatch themselves never read this -
+	// the readonly check is deliberately not part of the ordinary
+	// prepare/restore pipeline every other tag hooks into, since it needs
+	// to wrap the call itself in CallProtected, not just run before/after
+	// it.
+	readonlyFields []readonlyField
+
+	// lenFields holds every ffi:"len="/"cap=" slice/companion pair, for
+	// Call to keep populated via prepareLenFields before each call.
+	lenFields []lenField
+
+	// marshalFields holds every *T args field MakeSpec found pointing
+	// at a Marshaler, for Call to encode/decode via prepareMarshalFields
+	// around each call.
+	marshalFields []marshalField
+
+	// bitFields holds every ffi:"bits=" run's packed representative
+	// argument, for Call to pack via prepareBitFields before each call and
+	// unpack after.
+	bitFields []bitGroup
+
+	// packedFields holds every ffi:"packed"/"align=N" field, for Call to
+	// copy through a correctly packed/aligned scratch buffer via
+	// preparePackedFields before each call and back after.
+	packedFields []packedField
+
+	// checkFields holds every field checkArgPointers should validate under
+	// GODEBUG=ffipointercheck=1.
+	checkFields []checkField
+
+	// outFields holds every ffi:"out" field, for Call to zero via
+	// zeroOutFields before each call and re-validate via checkArgPointers
+	// after, both under GODEBUG=ffipointercheck=1.
+	outFields []checkField
+}
+
+// callParams is the one small, per-call value Call hands asmcall a
+// pointer to, instead of a *Spec alone - see the arm64 backend's
+// identical type for why.
+type callParams struct {
+	spec *Spec
+	base uintptr
+}
+
+func wordArg(base uintptr, i int, totalSize uintptr) argument {
+	off := base + uintptr(i)*8
+	size := uintptr(8)
+	if rem := totalSize - uintptr(i)*8; rem < 8 {
+		size = rem
+	}
+	return argument{offset: uint16(off), t: type64, size: uint8(size)}
+}
+
+func (spec *Spec) appendStackWords(base uintptr, size uintptr) {
+	n := int((size + 7) / 8)
+	for i := 0; i < n; i++ {
+		spec.stack = append(spec.stack, wordArg(base, i, size))
+	}
+}
+
+// buildSpec builds a call specification for the given arguments; it's
+// wrapped by the cached, public MakeSpec in cache.go.
+func buildSpec(fn uintptr, args interface{}) Spec {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	var spec Spec
+	spec.fn = fn
+	spec.ret0.t = typeUnused
+	spec.fltret.t = typeUnused
+
+	haveRet := false
+	intreg := 0
+	fltreg := 0
+
+	fields := flattenFields(t)
+	bitGroups := collectBitGroups(fields)
+
+	for i := range fields {
+		f := fields[i]
+		tags := parseFieldTags(f)
+		if tags.ignore {
+			continue
+		}
+		ret := false
+		if tags.ret {
+			if haveRet {
+				panic(&SpecError{Kind: ErrMultipleReturns, Msg: "only one ffi:\"ret\" field allowed"})
+			}
+			ret = true
+			haveRet = true
+		}
+
+		if tags.cstr {
+			off := cstrArg(f, ret, type64, 8)
+			spec.cstrFields = append(spec.cstrFields, uint16(f.Offset))
+			if intreg < 8 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if tags.strptr {
+			data, length := strPtrArg(f, ret, type64, 8)
+			for _, off := range [2]argument{data, length} {
+				if intreg < 8 {
+					spec.intargs[intreg] = off
+					intreg++
+				} else {
+					spec.stack = append(spec.stack, off)
+				}
+			}
+			continue
+		}
+
+		if !ret && f.Type.Kind() == reflect.Ptr && marshalElem(f.Type.Elem()) {
+			spec.marshalFields = append(spec.marshalFields, marshalField{offset: uint16(f.Offset), elem: f.Type.Elem()})
+			off := argument{offset: uint16(f.Offset), t: type64, size: 8}
+			if intreg < 8 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if tags.bits > 0 {
+			if ret {
+				panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"bits=\" is not supported on a ffi:\"ret\" field"})
+			}
+			if g, isRep := bitGroupFor(bitGroups, uint16(f.Offset)); isRep {
+				spec.bitFields = append(spec.bitFields, g)
+				off := argument{offset: g.offset, t: g.t, size: g.size}
+				if intreg < 8 {
+					spec.intargs[intreg] = off
+					intreg++
+				} else {
+					spec.stack = append(spec.stack, off)
+				}
+			}
+			continue
+		}
+
+		if tags.packed || tags.align > 0 {
+			pf := packedArg(f, tags, ret)
+			spec.packedFields = append(spec.packedFields, pf)
+			off := argument{offset: pf.offset, t: type64, size: 8}
+			if intreg < 8 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if tags.pin {
+			spec.pinFields = append(spec.pinFields, pinArg(f, ret))
+		}
+
+		if tags.nilTag != "" {
+			spec.nilFields = append(spec.nilFields, nilArg(f, ret, tags.nilTag))
+		}
+		if tags.readonly {
+			spec.readonlyFields = append(spec.readonlyFields, readonlyArg(f, ret))
+		}
+
+		if tags.lenOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.lenOf, false))
+		}
+		if tags.capOf != "" {
+			spec.lenFields = append(spec.lenFields, lenArg(t, f, tags.capOf, true))
+		}
+
+		if cf, ok := pointerCheckField(f, tags); ok {
+			spec.checkFields = append(spec.checkFields, cf)
+		}
+
+		if tags.out {
+			spec.outFields = append(spec.outFields, outArg(f))
+		}
+
+		if tags.ptr {
+			off := ptrArg(f, ret)
+			if intreg < 8 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct || f.Type.Kind() == reflect.Array {
+			panic(&SpecError{Kind: ErrUnsupportedAggregate, Msg: "riscv64: struct/array arguments and return values are not yet supported by this backend"})
+		}
+
+		off, flt := fieldToOffset(f, tags.typ)
+		if ret {
+			if flt {
+				spec.fltret = off
+			} else {
+				spec.ret0 = off
+			}
+			continue
+		}
+		if flt {
+			if fltreg < 8 {
+				spec.fltargs[fltreg] = off
+				fltreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+		} else {
+			if intreg < 8 {
+				spec.intargs[intreg] = off
+				intreg++
+			} else {
+				spec.stack = append(spec.stack, off)
+			}
+		}
+	}
+	for i := intreg; i < 8; i++ {
+		spec.intargs[i].t = typeUnused
+	}
+	for i := fltreg; i < 8; i++ {
+		spec.fltargs[i].t = typeUnused
+	}
+	if len(spec.stack) > maxCallStackArgs {
+		panic(&SpecError{Kind: ErrTooManyArgs, Msg: "too many stack-passed arguments for asmcall"})
+	}
+	return spec
+}
+
+// maxCallStackArgs bounds how many eightbytes of spec.stack a single Call
+// can pass; see the amd64 backend's identical constant for why this needs
+// to be fixed at build time.
+const maxCallStackArgs = 32
+
+// Call calls spec with the given arguments. spec is read-only here - the
+// args pointer asmcall needs lives in a small callParams value local to
+// this call instead - so the exact same Spec can be called concurrently
+// from any number of goroutines with no per-call copy of it. See the
+// amd64/arm64 backends' identical Call for the rest of the rationale.
+func (spec *Spec) Call(args unsafe.Pointer) {
+	if len(spec.stack) > maxCallStackArgs {
+		panic("ffi: too many stack-passed arguments for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+
+	entersyscall()
+	asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&params)))
+	exitsyscall()
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+
+	if _Cgo_always_false {
+		_Cgo_use(args)
+		_Cgo_use(params)
+	}
+}
+
+// CallBatch runs every call in calls in order, entering syscall state once
+// for the whole batch instead of once per call - see the amd64 backend's
+// identical function for the full rationale. This backend has no
+// UseErrno, so like the arm64 backend, there's nothing per-call to report
+// back.
+func CallBatch(calls []BoundCall) {
+	a := acquireArena()
+	restoresNil := make([]func(), len(calls))
+	restores := make([]func(), len(calls))
+	unpins := make([]func(), len(calls))
+	unmarshals := make([]func(), len(calls))
+	unbits := make([]func(), len(calls))
+	unpacked := make([]func(), len(calls))
+	params := make([]callParams, len(calls))
+
+	for i, c := range calls {
+		if len(c.Spec.stack) > maxCallStackArgs {
+			panic("ffi: too many stack-passed arguments for asmcall")
+		}
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.checkFields)
+			zeroOutFields(c.Args, c.Spec.outFields)
+		}
+		restoresNil[i] = prepareNilFields(c.Args, c.Spec.nilFields)
+		prepareLenFields(c.Args, c.Spec.lenFields)
+		restores[i] = prepareCStrings(c.Args, c.Spec.cstrFields, a)
+		unpins[i] = preparePinning(c.Args, c.Spec.pinFields)
+		unmarshals[i] = prepareMarshalFields(c.Args, c.Spec.marshalFields, a)
+		unbits[i] = prepareBitFields(c.Args, c.Spec.bitFields)
+		unpacked[i] = preparePackedFields(c.Args, c.Spec.packedFields, a)
+		params[i] = callParams{spec: c.Spec, base: uintptr(c.Args)}
+	}
+
+	entersyscall()
+	for i := range calls {
+		asmcgocall(unsafe.Pointer(asmcallptr), uintptr(unsafe.Pointer(&params[i])))
+	}
+	exitsyscall()
+
+	for i, c := range calls {
+		restoresNil[i]()
+		unpins[i]()
+		restores[i]()
+		unmarshals[i]()
+		unbits[i]()
+		unpacked[i]()
+		if pointerCheckEnabled {
+			checkArgPointers(c.Args, c.Spec.outFields)
+		}
+	}
+	releaseArena(a)
+
+	if _Cgo_always_false {
+		_Cgo_use(params)
+	}
+}
+
+// asmcall (ffi_riscv64.s) loads spec.intargs/fltargs/stack into a0-a7/
+// fa0-fa7 and the outgoing stack per the RISC-V LP64D calling convention,
+// calls spec.fn, and stores the result back through ret0/fltret.
+func asmcall()
+
+var asmcallptr = funcPC(asmcall)
+
+// loadword and storeword are asmcall's shared per-argument load/store
+// subroutines (ffi_riscv64.s); they have no Go body and are never called
+// from Go, only branched to within asmcall.
+func loadword()
+func storeword()
+
+// CallFast calls spec like Call, but skips both the asmcgocall g0-stack
+// switch and the entersyscall/exitsyscall pair, calling asmcall with a
+// direct CALL from the current goroutine's own stack instead - see the
+// amd64/arm64 backends' identical method for the constraints that makes
+// safe.
+func (spec *Spec) CallFast(args unsafe.Pointer) {
+	if len(spec.stack) > maxCallStackArgs {
+		panic("ffi: too many stack-passed arguments for asmcall")
+	}
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.checkFields)
+		zeroOutFields(args, spec.outFields)
+	}
+
+	restoreNil := prepareNilFields(args, spec.nilFields)
+
+	a := acquireArena()
+	prepareLenFields(args, spec.lenFields)
+	restore := prepareCStrings(args, spec.cstrFields, a)
+	unpin := preparePinning(args, spec.pinFields)
+	unmarshal := prepareMarshalFields(args, spec.marshalFields, a)
+	unbits := prepareBitFields(args, spec.bitFields)
+	unpacked := preparePackedFields(args, spec.packedFields, a)
+
+	params := callParams{spec: spec, base: uintptr(args)}
+	callFast(&params)
+
+	restoreNil()
+	unpin()
+	restore()
+	unmarshal()
+	unbits()
+	unpacked()
+	releaseArena(a)
+
+	if pointerCheckEnabled {
+		checkArgPointers(args, spec.outFields)
+	}
+}
+
+// callFast (ffi_riscv64.s) is CallFast's direct-call trampoline: it CALLs
+// asmcall from the current goroutine's own stack instead of handing it to
+// asmcgocall for a g0-stack switch.
+//
+//go:noescape
+func callFast(params *callParams)
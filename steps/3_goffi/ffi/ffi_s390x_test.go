@@ -0,0 +1,73 @@
+package ffi
+
+import "testing"
+
+// TestBuildSpecS390XRegisterAllocation exercises buildSpec's z/Linux ELF
+// ABI layout: the first 5 integer args land in spec.intargs, the first 4
+// float args land in spec.fltargs (independently counted), and anything
+// past either register file spills to spec.stack in declaration order.
+func TestBuildSpecS390XRegisterAllocation(t *testing.T) {
+	type args struct {
+		A0, A1, A2, A3, A4 int64   `ffi:"arg"`
+		F0, F1, F2, F3     float64 `ffi:"arg"`
+		R                  int64   `ffi:"ret"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	for i := 0; i < 5; i++ {
+		if spec.intargs[i].t != type64 {
+			t.Fatalf("spec.intargs[%d]: got t=%v, want type64", i, spec.intargs[i].t)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if spec.fltargs[i].t != typeDouble {
+			t.Fatalf("spec.fltargs[%d]: got t=%v, want typeDouble", i, spec.fltargs[i].t)
+		}
+	}
+	if len(spec.stack) != 0 {
+		t.Fatalf("spec.stack: got %d entries, want 0", len(spec.stack))
+	}
+	if spec.ret0.t != type64 {
+		t.Fatalf("spec.ret0: got t=%v, want type64", spec.ret0.t)
+	}
+}
+
+// TestBuildSpecS390XRegisterSpill confirms that a 6th integer argument
+// and a 5th float argument both spill to spec.stack once their register
+// files are full.
+func TestBuildSpecS390XRegisterSpill(t *testing.T) {
+	type args struct {
+		A0, A1, A2, A3, A4, A5 int64   `ffi:"arg"`
+		F0, F1, F2, F3, F4     float64 `ffi:"arg"`
+	}
+
+	spec := buildSpec(0, &args{})
+
+	if len(spec.stack) != 2 {
+		t.Fatalf("spec.stack: got %d entries, want 2 (A5, F4 spilled)", len(spec.stack))
+	}
+	if spec.stack[0].t != type64 {
+		t.Fatalf("spec.stack[0] (A5): got t=%v, want type64", spec.stack[0].t)
+	}
+	if spec.stack[1].t != typeDouble {
+		t.Fatalf("spec.stack[1] (F4): got t=%v, want typeDouble", spec.stack[1].t)
+	}
+}
+
+// TestBuildSpecS390XAggregatePanics confirms the documented v1
+// limitation: struct/array arguments and return values aren't placed
+// into registers or the stack yet, so buildSpec must panic rather than
+// silently misclassify one.
+func TestBuildSpecS390XAggregatePanics(t *testing.T) {
+	type args struct {
+		S struct{ X, Y int64 } `ffi:"arg"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("buildSpec: expected a panic for an aggregate argument")
+		}
+	}()
+	buildSpec(0, &args{})
+}
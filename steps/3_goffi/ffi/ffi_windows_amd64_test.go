@@ -0,0 +1,96 @@
+//go:build windows
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// winArgs exercises buildSpec's slot assignment: four scalar arguments (one
+// of them floating) fill the four Microsoft x64 argument slots, and a
+// fifth spills to the stack.
+type winArgs struct {
+	A int64   `ffi:"arg"`
+	B float64 `ffi:"arg"`
+	C int64   `ffi:"arg"`
+	D int64   `ffi:"arg"`
+	E int64   `ffi:"arg"`
+	R int64   `ffi:"ret"`
+}
+
+// TestBuildSpecWindowsSlots checks that buildSpec fills args[0..3] in
+// declaration order regardless of each argument's own type - Microsoft x64
+// shares one slot sequence between the integer and XMM register files,
+// unlike SysV's independent counters - and spills the fifth argument to
+// spec.stack.
+func TestBuildSpecWindowsSlots(t *testing.T) {
+	var args winArgs
+	spec := buildSpec(0, &args)
+
+	if spec.args[0].t != type64 {
+		t.Fatalf("args[0] (A): got t=%v, want type64", spec.args[0].t)
+	}
+	if spec.args[1].t != typeDouble {
+		t.Fatalf("args[1] (B): got t=%v, want typeDouble", spec.args[1].t)
+	}
+	if spec.args[2].t != type64 {
+		t.Fatalf("args[2] (C): got t=%v, want type64", spec.args[2].t)
+	}
+	if spec.args[3].t != type64 {
+		t.Fatalf("args[3] (D): got t=%v, want type64", spec.args[3].t)
+	}
+	if len(spec.stack) != 1 {
+		t.Fatalf("spec.stack: got %d entries, want 1 (E)", len(spec.stack))
+	}
+	if spec.ret0.t != type64 {
+		t.Fatalf("ret0: got t=%v, want type64", spec.ret0.t)
+	}
+}
+
+// wstrArgs exercises the ffi:"wstr" tag: S is a plain Go string, so
+// buildSpec must record its offset in spec.wstrFields and place it as an
+// ordinary pointer-sized argument, the same slot a Ptr/Slice field
+// would occupy.
+type wstrArgs struct {
+	S string `ffi:"arg,wstr"`
+	R int64  `ffi:"ret"`
+}
+
+// TestBuildSpecWindowsWStr confirms buildSpec classifies an ffi:"wstr"
+// field as a plain pointer argument and records its offset for Call to
+// marshal via prepareWStrings.
+func TestBuildSpecWindowsWStr(t *testing.T) {
+	var args wstrArgs
+	spec := buildSpec(0, &args)
+
+	if len(spec.wstrFields) != 1 {
+		t.Fatalf("spec.wstrFields: got %d entries, want 1", len(spec.wstrFields))
+	}
+	if spec.args[0].t != type64 {
+		t.Fatalf("args[0] (S): got t=%v, want type64", spec.args[0].t)
+	}
+}
+
+// TestPrepareWStrings drives prepareWStrings directly, confirming it
+// marshals S into a UTF-16, NUL-terminated buffer and restores the
+// field's original value once the call is done with it.
+func TestPrepareWStrings(t *testing.T) {
+	args := wstrArgs{S: "hello"}
+	spec := buildSpec(0, &args)
+
+	a := acquireArena()
+	restore := prepareWStrings(unsafe.Pointer(&args), spec.wstrFields, a)
+
+	got := GoStringW(*(*uintptr)(unsafe.Pointer(&args.S)))
+	if got != "hello" {
+		t.Fatalf("prepareWStrings: got %q, want %q", got, "hello")
+	}
+
+	restore()
+	releaseArena(a)
+
+	if args.S != "hello" {
+		t.Fatalf("prepareWStrings: S was not restored, got %q, want %q", args.S, "hello")
+	}
+}
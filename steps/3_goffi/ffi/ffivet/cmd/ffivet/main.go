@@ -0,0 +1,16 @@
+// Command ffivet runs the ffivet analyzer standalone, or as a
+// go vet -vettool plugin:
+//
+//	go build -o ffivet mewbak/nocgo/ffi/ffivet/cmd/ffivet
+//	go vet -vettool=$(pwd)/ffivet ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"mewbak/nocgo/ffi/ffivet"
+)
+
+func main() {
+	singlechecker.Main(ffivet.Analyzer)
+}
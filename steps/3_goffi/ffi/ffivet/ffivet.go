@@ -0,0 +1,345 @@
+// Package ffivet implements a go/analysis Analyzer that statically checks
+// ffi argument structs - the ones MakeSpec classifies via reflection at
+// runtime - for mistakes that would otherwise only surface as a panic
+// (or, worse, silently wrong behavior) the first time the affected code
+// path actually ran:
+//
+//   - an `ffi:"..."` tag component MakeSpec's own parseFieldTags doesn't
+//     recognize, which it silently ignores rather than rejecting
+//   - more than one field tagged ffi:"ret" on the same struct
+//   - a field tagged with an ffi component whose Go type
+//     fieldToOffset can't classify (map, chan, func, interface, string)
+//   - a slice field with no ffi:"len="/"cap=" pairing, which MakeSpec
+//     happily accepts and passes as a bare pointer with no length
+//   - a Spec.Call(args) site whose args type doesn't match the struct
+//     type the Spec was built from, when both are visible in the same
+//     function
+//
+// Run it with go vet -vettool=$(which ffivet) ./... after building
+// ./cmd/ffivet, or wire it into another multichecker.
+package ffivet
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer is the ffivet go/analysis.Analyzer - see the package doc for
+// what it checks.
+var Analyzer = &analysis.Analyzer{
+	Name:     "ffivet",
+	Doc:      "check ffi struct tags and Spec.Call sites for mistakes MakeSpec would otherwise only catch at runtime, if at all",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// knownTags are the exact `ffi:"..."` component names parseFieldTags
+// (ffi.go) recognizes with no argument, plus "arg" - not itself special
+// to parseFieldTags, but the repo's own convention for an untagged
+// (or default) argument field, so flagging it as unknown would be a
+// false positive on the vast majority of existing tags.
+var knownTags = map[string]bool{
+	"arg":    true,
+	"ignore": true,
+	"ret":    true,
+	"cstr":   true,
+	"strptr": true,
+	"pin":    true,
+	"ptr":    true,
+	"sret":   true,
+	"out":    true,
+	"errno":  true,
+	"fnptr":  true,
+	"packed": true,
+}
+
+// knownPrefixes are the `ffi:"prefix=value"` component forms
+// parseFieldTags recognizes.
+var knownPrefixes = []string{"align=", "type=", "len=", "cap=", "bits="}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	specTypes := map[types.Object]types.Type{} // Spec variable -> args pointee type, best-effort/local
+
+	nodeFilter := []ast.Node{
+		(*ast.StructType)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	insp.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.StructType:
+			checkStruct(pass, n)
+		case *ast.AssignStmt:
+			recordMakeSpec(pass, n, specTypes)
+		case *ast.CallExpr:
+			checkCallSite(pass, n, specTypes)
+		}
+	})
+
+	return nil, nil
+}
+
+// checkStruct runs the tag-shape checks against every field of a struct
+// literal type that has at least one `ffi:"..."` tag - the signal that
+// it's meant as a MakeSpec argument struct rather than an unrelated one.
+func checkStruct(pass *analysis.Pass, st *ast.StructType) {
+	if st.Fields == nil {
+		return
+	}
+	hasFFITag := false
+	for _, f := range st.Fields.List {
+		if fieldTag(f) != "" {
+			hasFFITag = true
+			break
+		}
+	}
+	if !hasFFITag {
+		return
+	}
+
+	retFields := 0
+	for _, f := range st.Fields.List {
+		tag := fieldTag(f)
+		if tag == "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		isRet := false
+		isIgnore := false
+		hasLenOrCap := false
+		for _, p := range parts {
+			switch {
+			case p == "":
+				continue
+			case knownTags[p]:
+				if p == "ret" {
+					isRet = true
+				}
+				if p == "ignore" {
+					isIgnore = true
+				}
+			case hasKnownPrefix(p):
+				if strings.HasPrefix(p, "len=") || strings.HasPrefix(p, "cap=") {
+					hasLenOrCap = true
+				}
+			default:
+				pass.Reportf(f.Pos(), "ffivet: unknown ffi tag %q", p)
+			}
+		}
+		if isRet {
+			retFields++
+		}
+
+		if isIgnore {
+			continue
+		}
+
+		if tv := pass.TypesInfo.TypeOf(f.Type); tv != nil {
+			if reason, bad := unsupportedFieldKind(tv); bad {
+				pass.Reportf(f.Pos(), "ffivet: field has unsupported kind for MakeSpec: %s", reason)
+			}
+			if _, ok := tv.Underlying().(*types.Slice); ok && !hasLenOrCap && !containsAny(parts, "cstr", "strptr", "sret") {
+				pass.Reportf(f.Pos(), "ffivet: slice field has no ffi:\"len=\"/\"cap=\" pairing - MakeSpec will pass it as a bare pointer with no length")
+			}
+		}
+	}
+	if retFields > 1 {
+		pass.Reportf(st.Pos(), "ffivet: struct has %d fields tagged ffi:\"ret\", only one is allowed", retFields)
+	}
+}
+
+func containsAny(parts []string, want ...string) bool {
+	for _, p := range parts {
+		for _, w := range want {
+			if p == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasKnownPrefix(tag string) bool {
+	for _, p := range knownPrefixes {
+		if strings.HasPrefix(tag, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedFieldKind reports whether t is a kind fieldToOffset (ffi.go)
+// has no case for and would panic on: a map, chan, func, interface, or
+// string (a []byte or a C-string pointer is spelled some other way in
+// this package - a bare Go string never reaches fieldToOffset).
+// Structs, arrays, complex128, and pointers-to-struct are excluded since
+// MakeSpec routes those through classifyEightbytes/marshalElem instead
+// of fieldToOffset.
+func unsupportedFieldKind(t types.Type) (reason string, bad bool) {
+	switch u := t.Underlying().(type) {
+	case *types.Map:
+		return "map", true
+	case *types.Chan:
+		return "chan", true
+	case *types.Signature:
+		return "func", true
+	case *types.Interface:
+		return "interface", true
+	case *types.Basic:
+		if u.Info()&types.IsString != 0 {
+			return "string", true
+		}
+	}
+	return "", false
+}
+
+func fieldTag(f *ast.Field) string {
+	if f.Tag == nil {
+		return ""
+	}
+	unquoted, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(unquoted).Get("ffi")
+}
+
+// recordMakeSpec records, best-effort, that lhs now holds a Spec built
+// from ffi.MakeSpec(fn, &args) or ffi.MustSpecFor[T](fn), so
+// checkCallSite can later compare it against the type actually passed to
+// lhs.Call. This is deliberately local and syntactic rather than a real
+// dataflow analysis: it only sees the single assignment statement, so a
+// Spec threaded through a function parameter, a struct field, or
+// reassigned under a different name is invisible to it.
+func recordMakeSpec(pass *analysis.Pass, assign *ast.AssignStmt, specTypes map[types.Object]types.Type) {
+	if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return
+	}
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok || ident.Name == "_" {
+		return
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return
+	}
+
+	var argsType types.Type
+	switch fn := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		if fn.Sel.Name != "MakeSpec" || len(call.Args) != 2 {
+			return
+		}
+		if !isFFIPackage(pass, fn.X) {
+			return
+		}
+		argsType = pass.TypesInfo.TypeOf(call.Args[1])
+	case *ast.IndexExpr: // ffi.MustSpecFor[T](fn)
+		sel, ok := fn.X.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "MustSpecFor" || !isFFIPackage(pass, sel.X) {
+			return
+		}
+		argsType = types.NewPointer(pass.TypesInfo.TypeOf(fn.Index))
+	default:
+		return
+	}
+	if argsType == nil {
+		return
+	}
+
+	obj := pass.TypesInfo.ObjectOf(ident)
+	if obj == nil {
+		return
+	}
+	specTypes[obj] = argsType
+}
+
+// checkCallSite flags spec.Call(args) (and CallVia/CallFast, which take
+// the same shape) when args' pointee type doesn't match the struct type
+// recorded for spec by recordMakeSpec - a copy-paste mistake where a
+// Spec built for one argument struct is called with a pointer to a
+// different one, which MakeSpec's own reflection-based classification
+// has no way to catch since the mismatch only exists at the call site.
+func checkCallSite(pass *analysis.Pass, call *ast.CallExpr, specTypes map[types.Object]types.Type) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) != 1 {
+		return
+	}
+	switch sel.Sel.Name {
+	case "Call", "CallFast", "CallVia":
+	default:
+		return
+	}
+	recvIdent, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj := pass.TypesInfo.ObjectOf(recvIdent)
+	if obj == nil {
+		return
+	}
+	wantType, ok := specTypes[obj]
+	if !ok {
+		return
+	}
+
+	// args is almost always unsafe.Pointer(&v) - look through that
+	// conversion to v's own address-of expression, since unsafe.Pointer
+	// itself is never going to match wantType (a *T) and would make
+	// every real call site a false positive.
+	argExpr := call.Args[0]
+	if conv, ok := argExpr.(*ast.CallExpr); ok && isUnsafePointerConversion(pass, conv) {
+		argExpr = conv.Args[0]
+	}
+
+	gotType := pass.TypesInfo.TypeOf(argExpr)
+	if gotType == nil || wantType == nil {
+		return
+	}
+	if !types.Identical(gotType, wantType) {
+		pass.Reportf(call.Pos(), "ffivet: %s called with %s, but the Spec was built for %s", sel.Sel.Name, gotType, wantType)
+	}
+}
+
+// isUnsafePointerConversion reports whether call is unsafe.Pointer(x).
+func isUnsafePointerConversion(pass *analysis.Pass, call *ast.CallExpr) bool {
+	if len(call.Args) != 1 {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Pointer" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pkgName, ok := pass.TypesInfo.Uses[pkg].(*types.PkgName)
+	return ok && pkgName.Imported().Path() == "unsafe"
+}
+
+// isFFIPackage reports whether expr is a reference to a package named
+// "ffi" - the same shallow, name-based check used throughout this
+// analyzer rather than resolving the actual import path, since a real
+// project vetting its own ffi.MakeSpec call sites will have imported it
+// under its default name.
+func isFFIPackage(pass *analysis.Pass, expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pkgName, ok := pass.TypesInfo.Uses[ident].(*types.PkgName)
+	if !ok {
+		return false
+	}
+	return pkgName.Imported().Name() == "ffi"
+}
@@ -0,0 +1,13 @@
+package ffivet_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"mewbak/nocgo/ffi/ffivet"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), ffivet.Analyzer, "a")
+}
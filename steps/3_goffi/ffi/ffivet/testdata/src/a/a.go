@@ -0,0 +1,54 @@
+package a
+
+import (
+	"ffi"
+	"unsafe"
+)
+
+type goodArgs struct {
+	A int64  `ffi:"arg"`
+	B []byte `ffi:"arg,len=N"`
+	N int32  `ffi:"arg"`
+	R int64  `ffi:"ret"`
+}
+
+type unknownTagArgs struct {
+	A int64 `ffi:"arg,bogus"` // want `ffivet: unknown ffi tag "bogus"`
+}
+
+type multiRetArgs struct { // want `ffivet: struct has 2 fields tagged ffi:"ret", only one is allowed`
+	A int64 `ffi:"ret"`
+	B int64 `ffi:"ret"`
+}
+
+type unsupportedKindArgs struct {
+	A string `ffi:"arg"` // want `ffivet: field has unsupported kind for MakeSpec: string`
+}
+
+type noLenSliceArgs struct {
+	B []byte `ffi:"arg"` // want `ffivet: slice field has no ffi:"len="/"cap=" pairing - MakeSpec will pass it as a bare pointer with no length`
+}
+
+type otherArgs struct {
+	X int64 `ffi:"arg"`
+	R int64 `ffi:"ret"`
+}
+
+func goodCall(fn uintptr) {
+	var args goodArgs
+	spec := ffi.MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+}
+
+func mismatchedCall(fn uintptr) {
+	var args goodArgs
+	var other otherArgs
+	spec := ffi.MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&other)) // want `ffivet: Call called with \*a.otherArgs, but the Spec was built for \*a.goodArgs`
+}
+
+func genericCall(fn uintptr) {
+	var args goodArgs
+	spec := ffi.MustSpecFor[goodArgs](fn)
+	spec.Call(unsafe.Pointer(&args))
+}
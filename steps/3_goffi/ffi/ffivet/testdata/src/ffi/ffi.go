@@ -0,0 +1,16 @@
+// Package ffi is a minimal stand-in for mewbak/nocgo/ffi, just enough
+// shape (MakeSpec, MustSpecFor, Spec.Call) for ffivet's testdata to
+// reference without pulling in the real package.
+package ffi
+
+import "unsafe"
+
+type Spec struct{}
+
+func MakeSpec(fn uintptr, args interface{}) Spec { return Spec{} }
+
+func MustSpecFor[T any](fn uintptr) Spec { return Spec{} }
+
+func (s *Spec) Call(args unsafe.Pointer) int32 { return 0 }
+
+func (s *Spec) CallVia(args unsafe.Pointer) int32 { return 0 }
@@ -0,0 +1,23 @@
+//go:build !nocgo_fallback_cgo && !tinygo && !gccgo
+package ffi
+
+import "testing"
+
+// TestMakeSpecIgnoresTaggedEmbeddedHeader confirms MakeSpec's existing
+// ffi:"ignore" handling still works for a tagged anonymous field once it
+// reaches classification, leaving only B as a real argument - i.e. that
+// flattenFields leaving a tagged embedding untouched (flatten_test.go)
+// actually carries through end to end.
+func TestMakeSpecIgnoresTaggedEmbeddedHeader(t *testing.T) {
+	spec := MakeSpec(0, &taggedHeaderArgs{})
+	intargs, _, stack, ret0, _, _, _ := spec.ArgLayout()
+	if len(stack) != 0 {
+		t.Fatalf("got %d stack args, want 0: %+v", len(stack), stack)
+	}
+	if intargs[0].Kind != ArgUnused {
+		t.Fatalf("ignored header leaked into intargs[0]: %+v", intargs[0])
+	}
+	if ret0.Kind == ArgUnused {
+		t.Fatalf("B's ffi:\"ret\" field was not classified")
+	}
+}
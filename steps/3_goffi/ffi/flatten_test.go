@@ -0,0 +1,66 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// innerHeader and outerHeader model a header struct embedding another
+// one, to confirm flattenFields recurses through more than one level of
+// embedding - not just splicing in a header's immediate fields, but
+// whatever that header itself embeds too.
+type innerHeader struct {
+	A int32
+}
+
+type outerHeader struct {
+	innerHeader
+	B int32
+}
+
+type deeplyEmbeddedArgs struct {
+	outerHeader
+	C int32 `ffi:"ret"`
+}
+
+// TestFlattenFieldsRecursesThroughNestedEmbedding confirms A, B, and C
+// all come back as three leaf fields, in declaration order, with A's
+// offset adjusted by both levels of embedding it sat behind.
+func TestFlattenFieldsRecursesThroughNestedEmbedding(t *testing.T) {
+	fields := flattenFields(reflect.TypeOf(deeplyEmbeddedArgs{}))
+	if len(fields) != 3 {
+		t.Fatalf("got %d fields, want 3: %+v", len(fields), fields)
+	}
+	if fields[0].Name != "A" || fields[1].Name != "B" || fields[2].Name != "C" {
+		t.Fatalf("got field order %q/%q/%q, want A/B/C", fields[0].Name, fields[1].Name, fields[2].Name)
+	}
+
+	var want deeplyEmbeddedArgs
+	wantA := reflect.ValueOf(&want).Elem().FieldByName("A")
+	if uintptr(fields[0].Offset) != wantA.UnsafeAddr()-reflect.ValueOf(&want).Elem().UnsafeAddr() {
+		t.Fatalf("A offset = %d, want %d", fields[0].Offset, wantA.UnsafeAddr()-reflect.ValueOf(&want).Elem().UnsafeAddr())
+	}
+}
+
+// taggedHeaderArgs embeds innerHeader with an explicit ffi:"ignore" tag
+// on the embedding itself - unlike embeddedHeaderArgs (embed_amd64_test.go),
+// a tagged anonymous field describes the field as a whole and is left
+// alone rather than flattened, the same as a tagged named struct field
+// would be.
+type taggedHeaderArgs struct {
+	innerHeader `ffi:"ignore"`
+	B           int32 `ffi:"ret"`
+}
+
+// TestFlattenFieldsLeavesTaggedEmbeddingAlone confirms a tagged anonymous
+// struct field is returned as a single field - not recursed into - so
+// MakeSpec's own ffi:"ignore" handling (unchanged) is what skips it.
+func TestFlattenFieldsLeavesTaggedEmbeddingAlone(t *testing.T) {
+	fields := flattenFields(reflect.TypeOf(taggedHeaderArgs{}))
+	if len(fields) != 2 {
+		t.Fatalf("got %d fields, want 2: %+v", len(fields), fields)
+	}
+	if fields[0].Name != "innerHeader" || !fields[0].Anonymous {
+		t.Fatalf("got %+v, want the untouched anonymous innerHeader field", fields[0])
+	}
+}
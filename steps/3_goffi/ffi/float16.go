@@ -0,0 +1,93 @@
+package ffi
+
+import (
+	"math"
+	"math/bits"
+	"reflect"
+)
+
+// Float16 is the Go-side storage for a C _Float16/__fp16 argument or
+// return value: IEEE 754 binary16 (1 sign bit, 5 exponent bits biased by
+// 15, 10 mantissa bits), stored in the same 16 bits either convention
+// uses. Go has no native half-precision type, so Float32/Float16FromFloat32
+// are the only way to get a value in or out without going through C.
+//
+// A Float16 field embedded in an args/ret struct is recognized by
+// fieldToOffset (ffi.go) ahead of its own Kind()-based switch - Kind()
+// alone can't tell it apart from a plain uint16 field, but a plain
+// uint16 belongs in the integer register file while a Float16 belongs in
+// the float one (XMM on amd64/386, V on arm64), the same place a
+// float32/float64 field goes.
+type Float16 uint16
+
+// float16Type is reflect.TypeOf(Float16(0)), cached once for the identity
+// comparison fieldToOffset uses to single a Float16 field out ahead of
+// its Kind()-based cases.
+var float16Type = reflect.TypeOf(Float16(0))
+
+// Float32 converts h to the equivalent float32: binary16's exponent and
+// mantissa both fit inside float32's wider fields with room to spare, so
+// unlike LongDouble.Float64's narrowing conversion, this is always exact.
+func (h Float16) Float32() float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h&0x7C00) >> 10
+	mant := uint32(h & 0x3FF)
+
+	switch exp {
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Subnormal half: normalize by hand, the same approach
+		// LongDoubleFromFloat64 uses for a subnormal float64 - binary16's
+		// subnormals all fit as normal float32 values, so there's no
+		// float32-side subnormal case to worry about on the way out.
+		e := bits.Len32(mant) - 1 // 0..9
+		mant32 := (mant &^ (1 << uint(e))) << uint(23-e)
+		return math.Float32frombits(sign | uint32(e+103)<<23 | mant32) // e - 24 + 127
+	case 0x1F:
+		if mant == 0 {
+			return math.Float32frombits(sign | 0xFF<<23) // infinity
+		}
+		return math.Float32frombits(sign | 0xFF<<23 | 1) // NaN; payload not preserved
+	}
+	return math.Float32frombits(sign | (exp-15+127)<<23 | mant<<13)
+}
+
+// Float16FromFloat32 narrows f to binary16, for passing a Go float32 to a
+// C function expecting _Float16/__fp16. Unlike Float32's widening, this
+// is lossy in the general case: binary16's 10 bit mantissa keeps only the
+// top 10 of float32's 23 mantissa bits (truncated, not rounded), and a
+// magnitude outside binary16's much narrower exponent range flushes to
+// zero (too small, including anything in float32's own subnormal range)
+// or infinity (too large).
+func Float16FromFloat32(f float32) Float16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	rawExp := (b >> 23) & 0xFF
+	mant := b & 0x7FFFFF
+
+	if rawExp == 0xFF {
+		if mant == 0 {
+			return Float16(sign | 0x7C00) // infinity
+		}
+		return Float16(sign | 0x7C00 | 0x200) // NaN
+	}
+
+	exp := int32(rawExp) - 127 + 15 // rebias from float32's exponent to binary16's
+	switch {
+	case exp >= 0x1F:
+		return Float16(sign | 0x7C00) // overflow to infinity
+	case exp <= 0:
+		// Underflows binary16's normal range: reconstruct as a binary16
+		// subnormal if there's still a nonzero result once shifted all
+		// the way down, or zero otherwise - the shift itself saturates
+		// to 0 once it's wide enough that no bit survives, so a
+		// magnitude far below binary16's smallest subnormal (including
+		// any subnormal float32 input) needs no special case here.
+		m := (mant | 1<<23) >> uint(14-exp)
+		return Float16(sign | uint16(m))
+	default:
+		return Float16(sign | uint16(exp)<<10 | uint16(mant>>13))
+	}
+}
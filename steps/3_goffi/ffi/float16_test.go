@@ -0,0 +1,70 @@
+package ffi
+
+import (
+	"math"
+	"testing"
+)
+
+// TestFloat16Float32RoundTrip confirms Float32/Float16FromFloat32 round-trip
+// exactly for values that fit binary16's narrower range: unlike LongDouble's
+// widening, every bit of a binary16 mantissa/exponent fits inside float32's
+// wider fields, so Float32 never loses information.
+func TestFloat16Float32RoundTrip(t *testing.T) {
+	for _, f := range []float32{0, 1, -1, 2, -2, 0.5, 65504, -65504, 6.1035156e-5} { // 6.1035156e-5 == 2^-14, the smallest normal
+		h := Float16FromFloat32(f)
+		if got := h.Float32(); got != f {
+			t.Errorf("Float16FromFloat32(%v).Float32() = %v, want %v", f, got, f)
+		}
+	}
+}
+
+// TestFloat16FromFloat32Truncates documents that narrowing keeps only the
+// top 10 of float32's 23 mantissa bits, truncated rather than rounded.
+func TestFloat16FromFloat32Truncates(t *testing.T) {
+	// 1 + 2^-10 + 2^-20: the 2^-20 bit falls below binary16's 10 bit
+	// mantissa and should be dropped, leaving just 1 + 2^-10.
+	f := float32(1) + float32(1)/(1<<10) + float32(1)/(1<<20)
+	want := float32(1) + float32(1)/(1<<10)
+	if got := Float16FromFloat32(f).Float32(); got != want {
+		t.Errorf("Float16FromFloat32(%v).Float32() = %v, want %v", f, got, want)
+	}
+}
+
+// TestFloat16Overflow confirms a magnitude beyond binary16's exponent range
+// flushes to infinity rather than wrapping or panicking.
+func TestFloat16Overflow(t *testing.T) {
+	if got := Float16FromFloat32(1e38).Float32(); got != float32(math.Inf(1)) {
+		t.Errorf("Float16FromFloat32(1e38): got %v, want +Inf", got)
+	}
+	if got := Float16FromFloat32(-1e38).Float32(); got != float32(math.Inf(-1)) {
+		t.Errorf("Float16FromFloat32(-1e38): got %v, want -Inf", got)
+	}
+}
+
+// TestFloat16Underflow confirms a magnitude below binary16's smallest
+// subnormal flushes to zero, and that the smallest subnormal itself still
+// survives the round trip.
+func TestFloat16Underflow(t *testing.T) {
+	if got := Float16FromFloat32(1e-30).Float32(); got != 0 {
+		t.Errorf("Float16FromFloat32(1e-30): got %v, want 0", got)
+	}
+	const smallestSubnormal = float32(5.9604645e-8) // 2^-24
+	if got := Float16FromFloat32(smallestSubnormal).Float32(); got != smallestSubnormal {
+		t.Errorf("Float16FromFloat32(%v).Float32() = %v, want %v", smallestSubnormal, got, smallestSubnormal)
+	}
+}
+
+// TestFloat16SpecialValues confirms infinities and zero survive the round
+// trip; NaN only round-trips as "some NaN", the same caveat LongDouble's
+// conversions document.
+func TestFloat16SpecialValues(t *testing.T) {
+	if got := Float16FromFloat32(float32(math.Inf(1))).Float32(); got != float32(math.Inf(1)) {
+		t.Errorf("+Inf round trip: got %v", got)
+	}
+	if got := Float16FromFloat32(float32(math.Inf(-1))).Float32(); got != float32(math.Inf(-1)) {
+		t.Errorf("-Inf round trip: got %v", got)
+	}
+	if got := Float16FromFloat32(float32(math.NaN())).Float32(); !math.IsNaN(float64(got)) {
+		t.Errorf("NaN round trip: got %v, want some NaN", got)
+	}
+}
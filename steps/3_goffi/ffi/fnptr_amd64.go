@@ -0,0 +1,26 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "unsafe"
+
+// CallVia calls spec through the function pointer stored in its
+// ffi:"fnptr" field of args, instead of the fixed address MakeSpec was
+// given - the shape a C vtable or struct-embedded callback takes (a
+// driver's ops table, an io callback stashed next to its own arguments).
+//
+// Overwriting spec.fn itself per call would race any other goroutine
+// calling the same *Spec concurrently (see Call's own doc comment on
+// why spec is read-only), so CallVia instead runs the call through a
+// small, unshared copy of spec with fn swapped in.
+//
+// CallVia panics if spec wasn't built from a struct with a field tagged
+// ffi:"fnptr".
+func (spec *Spec) CallVia(args unsafe.Pointer) int32 {
+	if !spec.hasFnField {
+		panic("ffi: CallVia: spec has no ffi:\"fnptr\" field")
+	}
+	via := *spec
+	via.fn = *(*uintptr)(unsafe.Pointer(uintptr(args) + uintptr(spec.fnField)))
+	return via.Call(args)
+}
@@ -0,0 +1,45 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// vtableCallArgs models a C struct that carries its own callback address
+// alongside its arguments - Fn is never passed to addPairFlatTarget
+// itself, it's just where CallVia finds the address to call.
+type vtableCallArgs struct {
+	Fn uintptr `ffi:"fnptr"`
+	A  int64   `ffi:"arg"`
+	B  int64   `ffi:"arg"`
+	R  int64   `ffi:"ret"`
+}
+
+// TestCallVia confirms CallVia reaches the function addressed by the
+// ffi:"fnptr" field rather than spec.fn, which MakeSpec was never even
+// given a real address for here.
+func TestCallVia(t *testing.T) {
+	spec := MakeSpec(0, &vtableCallArgs{})
+
+	args := vtableCallArgs{Fn: addrOfAddPairFlatTarget(), A: 3, B: 4}
+	spec.CallVia(unsafe.Pointer(&args))
+	if args.R != 7 {
+		t.Fatalf("CallVia: got R=%v, want 7", args.R)
+	}
+}
+
+// TestCallViaRequiresFnptrField confirms CallVia refuses to run against
+// a Spec with no ffi:"fnptr" field rather than silently calling through
+// whatever spec.fn happens to be.
+func TestCallViaRequiresFnptrField(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("CallVia: expected a panic for a Spec with no ffi:\"fnptr\" field")
+		}
+	}()
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &funcAddArgs{})
+	var args funcAddArgs
+	spec.CallVia(unsafe.Pointer(&args))
+}
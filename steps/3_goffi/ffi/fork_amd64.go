@@ -0,0 +1,192 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// EnableForkSafety registers this package's own prepare/parent/child
+// handlers with libc's pthread_atfork, so a fork made anywhere in this
+// process - through Fork below, or by any C library linked into it that
+// calls libc's fork(3) directly - finds every mutex this package keeps
+// (callbackMu, libraryRefs.mu, threadHooksMu, callbackPanicMu) quiescent
+// across the fork, rather than possibly held by some other thread that
+// fork() leaves behind and that will never exist in the child to release
+// it. Without this, a child that happened to fork while another Go
+// thread was mid-MakeCallback or mid-OpenShared would find that mutex
+// permanently locked, with no thread left anywhere to unlock it.
+//
+// It's idempotent - only the first call actually resolves pthread_atfork
+// and registers anything - and safe to call from multiple goroutines.
+func EnableForkSafety() error {
+	forkSafetyOnce.Do(func() {
+		atforkFn, _, err := forkLibcFuncs()
+		if err != nil {
+			forkSafetyErr = fmt.Errorf("ffi: EnableForkSafety: %w", err)
+			return
+		}
+
+		voidCif := PrepCif(0, TypeVoid)
+		prepare := NewClosure(voidCif, forkPrepareHandler)
+		parent := NewClosure(voidCif, forkParentHandler)
+		child := NewClosure(voidCif, forkChildHandler)
+
+		args := struct {
+			Prepare uintptr `ffi:"arg"`
+			Parent  uintptr `ffi:"arg"`
+			Child   uintptr `ffi:"arg"`
+			R       int32   `ffi:"ret"`
+		}{Prepare: prepare.Pointer(), Parent: parent.Pointer(), Child: child.Pointer()}
+		spec := MakeSpec(atforkFn, &args)
+		spec.Call(unsafe.Pointer(&args))
+		if args.R != 0 {
+			ReleaseClosure(prepare)
+			ReleaseClosure(parent)
+			ReleaseClosure(child)
+			forkSafetyErr = fmt.Errorf("ffi: EnableForkSafety: pthread_atfork returned %d", args.R)
+		}
+	})
+	return forkSafetyErr
+}
+
+var (
+	forkSafetyOnce sync.Once
+	forkSafetyErr  error
+)
+
+// forkPrepareHandler runs in the parent, on the thread calling fork(),
+// immediately before it actually forks.
+func forkPrepareHandler(unsafe.Pointer) {
+	lockForkMutexes()
+}
+
+// forkParentHandler runs in the parent immediately after forking,
+// undoing forkPrepareHandler's locks now that the child has its own
+// consistent copy of them.
+func forkParentHandler(unsafe.Pointer) {
+	unlockForkMutexes()
+}
+
+// forkChildHandler runs in the child immediately after forking, on the
+// same thread (and hence the same goroutine) that called fork in the
+// parent - the only one that survives the fork at all. It unlocks the
+// same mutexes forkParentHandler does (every other thread that might
+// have been holding one of them is simply gone, not released, in this
+// process) and then resets bookkeeping that described those other,
+// now-nonexistent threads.
+func forkChildHandler(unsafe.Pointer) {
+	unlockForkMutexes()
+	resetPostForkState()
+}
+
+// lockForkMutexes and unlockForkMutexes always touch every mutex in the
+// same order - lock low to high here, unlock high to low - so a
+// concurrent, ordinary (non-fork) acquisition of more than one of these
+// can never deadlock against the atfork sequence.
+func lockForkMutexes() {
+	callbackMu.Lock()
+	libraryRefs.mu.Lock()
+	threadHooksMu.Lock()
+	callbackPanicMu.Lock()
+}
+
+func unlockForkMutexes() {
+	callbackPanicMu.Unlock()
+	threadHooksMu.Unlock()
+	libraryRefs.mu.Unlock()
+	callbackMu.Unlock()
+}
+
+// resetPostForkState clears process-wide counters that only ever
+// described threads a fork leaves behind in the parent - never anything
+// that needs reloading, since dlopen's own bookkeeping (and this
+// package's libraryRefs/registry caches on top of it) lives in memory
+// the fork copies wholesale and stays valid in the child untouched.
+func resetPostForkState() {
+	callbackDepth.Store(0)
+	callbackPanicMu.Lock()
+	callbackPanics = nil
+	callbackPanicMu.Unlock()
+	callbackPanicCount.Store(0)
+}
+
+// ReinitAfterFork re-validates this package's process-wide state after a
+// fork that EnableForkSafety's handlers never observed - one made
+// through some path other than this package's own Fork, e.g. a C
+// library that forks internally without this package's atfork handlers
+// registered yet, or a forking mechanism that bypasses pthread_atfork
+// entirely. It's always safe to call, including redundantly right after
+// an ordinary Fork.
+//
+// Loaded library handles and resolved symbol addresses need no action
+// here - they stay valid across a fork, copied wholesale along with the
+// rest of the child's address space - so this only resets the
+// reentrancy-depth and queued-panic bookkeeping callback_reentrancy_
+// amd64.go and callback_panic_amd64.go keep, both of which only ever
+// described threads that don't exist in a freshly forked child.
+func ReinitAfterFork() {
+	resetPostForkState()
+}
+
+// Fork wraps libc's fork(2), first ensuring EnableForkSafety has
+// registered this package's handlers so this call - not just some other
+// library's own fork() - is covered by them. It returns the new child's
+// pid in the parent, 0 in the child, and a non-nil error if either
+// EnableForkSafety or fork(2) itself failed.
+//
+// Fork only makes the OS-level fork call; it is still the caller's
+// responsibility to follow the same fork-then-exec-or-minimal-work
+// discipline any multithreaded process must in its child - most of the
+// Go runtime (every other goroutine, the scheduler's other Ms, GC
+// workers) doesn't survive into the child, only the thread that called
+// Fork does.
+func Fork() (pid int32, err error) {
+	if err := EnableForkSafety(); err != nil {
+		return -1, err
+	}
+	_, forkFn, err := forkLibcFuncs()
+	if err != nil {
+		return -1, fmt.Errorf("ffi: Fork: %w", err)
+	}
+
+	args := struct {
+		R int32 `ffi:"ret"`
+	}{}
+	spec := MakeSpec(forkFn, &args)
+	if errnoLocator != 0 {
+		spec.UseErrno(errnoLocator)
+	}
+	errno := spec.Call(unsafe.Pointer(&args))
+	if args.R < 0 {
+		return -1, fmt.Errorf("ffi: Fork: fork(2) failed: errno %d", errno)
+	}
+	return args.R, nil
+}
+
+// forkLibcFuncs resolves pthread_atfork/fork from libc, the same lazy
+// Open+Get pattern pthreadKeyFuncs (thread_hooks_amd64.go) uses for its
+// own pthread_key_create/getspecific/setspecific trio.
+func forkLibcFuncs() (atforkFn, forkFn uintptr, err error) {
+	forkLibcFuncsOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			forkLibcFuncsErr = openErr
+			return
+		}
+		if forkAtforkFn, forkLibcFuncsErr = lib.Get("pthread_atfork"); forkLibcFuncsErr != nil {
+			return
+		}
+		forkForkFn, forkLibcFuncsErr = lib.Get("fork")
+	})
+	return forkAtforkFn, forkForkFn, forkLibcFuncsErr
+}
+
+var (
+	forkLibcFuncsOnce sync.Once
+	forkAtforkFn      uintptr
+	forkForkFn        uintptr
+	forkLibcFuncsErr  error
+)
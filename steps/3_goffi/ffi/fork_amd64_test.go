@@ -0,0 +1,62 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+// TestForkMutexesRoundTrip checks that lockForkMutexes/unlockForkMutexes -
+// what forkPrepareHandler/forkParentHandler/forkChildHandler actually
+// call - lock and unlock every mutex they touch in a consistent order,
+// with no mismatched pair left locked or double-unlocked. An actual
+// fork() isn't exercised here: duplicating a live, multithreaded Go
+// runtime via a real fork is unsupported outside a narrow
+// fork-then-exec-immediately window, so this stays reviewed by eye past
+// this point, the same way TestCallbackCall documents for callbackasm's
+// own assembly half.
+func TestForkMutexesRoundTrip(t *testing.T) {
+	lockForkMutexes()
+	unlockForkMutexes()
+	// A second round trip would deadlock on its own Lock calls if the
+	// first had left anything locked.
+	lockForkMutexes()
+	unlockForkMutexes()
+}
+
+// TestResetPostForkState checks that it clears exactly the bookkeeping
+// callback_reentrancy_amd64.go and callback_panic_amd64.go keep about
+// in-flight callbacks, which can't mean anything carried over from a
+// parent's other, now-nonexistent threads.
+func TestResetPostForkState(t *testing.T) {
+	callbackDepth.Store(3)
+	callbackPanicMu.Lock()
+	callbackPanics = append(callbackPanics, "stale")
+	callbackPanicMu.Unlock()
+	callbackPanicCount.Store(1)
+
+	resetPostForkState()
+
+	if got := callbackDepth.Load(); got != 0 {
+		t.Fatalf("callbackDepth = %d after resetPostForkState, want 0", got)
+	}
+	callbackPanicMu.Lock()
+	n := len(callbackPanics)
+	callbackPanicMu.Unlock()
+	if n != 0 {
+		t.Fatalf("callbackPanics has %d entries after resetPostForkState, want 0", n)
+	}
+	if got := callbackPanicCount.Load(); got != 0 {
+		t.Fatalf("callbackPanicCount = %d after resetPostForkState, want 0", got)
+	}
+}
+
+// TestReinitAfterForkIsResetPostForkState checks that the exported
+// ReinitAfterFork does the same reset, since it's meant to be callable
+// standalone (unlike forkChildHandler, which also unlocks mutexes
+// ReinitAfterFork never locked).
+func TestReinitAfterForkIsResetPostForkState(t *testing.T) {
+	callbackDepth.Store(5)
+	ReinitAfterFork()
+	if got := callbackDepth.Load(); got != 0 {
+		t.Fatalf("callbackDepth = %d after ReinitAfterFork, want 0", got)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func addrOfDirtyFPStateTarget() uintptr
+func dirtyFPStateTarget()
+func readMXCSR() uint32
+func readX87CW() uint32
+func writeMXCSR(v uint32)
+func writeX87CW(v uint32)
+
+// TestCallGuardFPStateRestores exercises GuardFPState/asmcall's
+// save/restore section end to end: dirtyFPStateTarget changes both the
+// SSE and x87 rounding modes, and GuardFPState(true) should put them back
+// before Call returns.
+func TestCallGuardFPStateRestores(t *testing.T) {
+	wantMXCSR := readMXCSR()
+	wantCW := readX87CW()
+
+	spec := MakeSpec(addrOfDirtyFPStateTarget(), &noArgs{})
+	spec.GuardFPState(true)
+	var dummy noArgs
+
+	params := callParams{spec: &spec, base: uintptr(unsafe.Pointer(&dummy))}
+	callAsmDirect(&params)
+
+	if got := readMXCSR(); got != wantMXCSR {
+		t.Errorf("MXCSR after guarded call: got %#x, want %#x", got, wantMXCSR)
+	}
+	if got := readX87CW(); got != wantCW {
+		t.Errorf("x87 control word after guarded call: got %#x, want %#x", got, wantCW)
+	}
+}
+
+// TestCallNoGuardFPStateLeavesDirty confirms asmcall skips the
+// save/restore section entirely when GuardFPState was never called,
+// leaving dirtyFPStateTarget's rounding-mode changes in place - proof
+// TestCallGuardFPStateRestores is actually exercising a restore, not
+// asserting on a target that never dirties anything.
+func TestCallNoGuardFPStateLeavesDirty(t *testing.T) {
+	beforeMXCSR := readMXCSR()
+	beforeCW := readX87CW()
+
+	spec := MakeSpec(addrOfDirtyFPStateTarget(), &noArgs{})
+	var dummy noArgs
+
+	params := callParams{spec: &spec, base: uintptr(unsafe.Pointer(&dummy))}
+	callAsmDirect(&params)
+	defer func() {
+		// restore for any later test on this goroutine's OS thread.
+		writeMXCSR(beforeMXCSR)
+		writeX87CW(beforeCW)
+	}()
+
+	if got := readMXCSR(); got == beforeMXCSR {
+		t.Errorf("MXCSR after unguarded call: got %#x, want it changed from %#x", got, beforeMXCSR)
+	}
+	if got := readX87CW(); got == beforeCW {
+		t.Errorf("x87 control word after unguarded call: got %#x, want it changed from %#x", got, beforeCW)
+	}
+}
@@ -0,0 +1,122 @@
+package ffi
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// Func is a type-safe callable bound to a single C function: Args fixes
+// the argument struct type at bind time, so Call can only ever be handed
+// a *Args, eliminating both the unsafe.Pointer argument plain Spec.Call
+// takes and the risk of passing an args value whose layout doesn't match
+// what the bound Spec was classified for.
+type Func[Args any] struct {
+	spec Spec
+
+	// symbol is the name Bind resolved spec.fn under, for SetTracer's
+	// benefit (trace.go) - empty for a Func built with BindFunc, which
+	// never has one.
+	symbol string
+}
+
+// BindFunc wraps an already-resolved symbol address as a Func[Args],
+// classified the same way MustSpecFor classifies any other argument
+// struct type.
+func BindFunc[Args any](fn uintptr) Func[Args] {
+	return Func[Args]{spec: MustSpecFor[Args](fn)}
+}
+
+// Bind resolves symbol within lib and returns it as a Func[Args], e.g.
+// f, err := ffi.Bind[PutsArgs](lib, "puts").
+func Bind[Args any](lib *Library, symbol string) (Func[Args], error) {
+	fn, err := lib.Get(symbol)
+	if err != nil {
+		return Func[Args]{}, err
+	}
+	f := BindFunc[Args](fn)
+	f.symbol = symbol
+	return f, nil
+}
+
+// Call invokes the bound function with args, which must be classified
+// exactly like any other MakeSpec argument struct: one field per
+// parameter and, if the call has a result, one field tagged "ffi:\"ret\"".
+// If a tracer is installed via SetTracer, Call reports it a TraceEvent
+// once the call returns.
+func (f Func[Args]) Call(args *Args) {
+	if tracer.Load() == nil {
+		f.spec.Call(unsafe.Pointer(args))
+		return
+	}
+	start := time.Now()
+	f.spec.Call(unsafe.Pointer(args))
+	trace(TraceEvent{
+		Symbol:   f.symbol,
+		Fn:       f.spec.fn,
+		Args:     unsafe.Pointer(args),
+		Duration: time.Since(start),
+	})
+}
+
+// MakeFunc binds fn, resolved at fnPtr, to goFuncPtr - a pointer to a Go
+// func variable, e.g. "var puts func(s string) int32; ffi.MakeFunc(fn,
+// &puts)". It's the forward-call mirror of NewCallback: goFuncPtr's
+// parameter and result types are classified into a hidden argument struct
+// via reflect.StructOf, the same technique NewCallback uses to classify a
+// callback's signature, and every call through *goFuncPtr marshals its
+// arguments into that struct via reflect before handing it to Spec.Call.
+//
+// *goFuncPtr must be a non-variadic function taking only the types
+// buildSpec can classify - no struct/array parameters - and returning at
+// most one value. MakeFunc pays reflect.StructOf and reflect.MakeFunc's
+// cost once, at bind time, but every call still marshals its arguments
+// through reflect.Value.Call; prefer Bind/BindFunc's generated Args
+// struct when that per-call cost matters.
+func MakeFunc(fnPtr uintptr, goFuncPtr interface{}) {
+	pv := reflect.ValueOf(goFuncPtr)
+	if pv.Kind() != reflect.Ptr || pv.Elem().Kind() != reflect.Func {
+		panic("ffi: MakeFunc: goFuncPtr must be a pointer to a func")
+	}
+	t := pv.Elem().Type()
+	if t.IsVariadic() {
+		panic("ffi: MakeFunc: variadic functions are not supported")
+	}
+	if t.NumOut() > 1 {
+		panic(&SpecError{Kind: ErrMultipleReturns, Msg: "ffi: MakeFunc: goFuncPtr's func must return at most one value"})
+	}
+
+	fields := make([]reflect.StructField, 0, t.NumIn()+1)
+	for i := 0; i < t.NumIn(); i++ {
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("A%d", i),
+			Type: t.In(i),
+			Tag:  `ffi:"arg"`,
+		})
+	}
+	if t.NumOut() == 1 {
+		fields = append(fields, reflect.StructField{
+			Name: "R",
+			Type: t.Out(0),
+			Tag:  `ffi:"ret"`,
+		})
+	}
+	argsType := reflect.StructOf(fields)
+	spec := MakeSpec(fnPtr, reflect.New(argsType).Interface())
+
+	pv.Elem().Set(reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		argsPtr := reflect.New(argsType)
+		args := argsPtr.Elem()
+		for i, v := range in {
+			args.Field(i).Set(v)
+		}
+
+		spec.Call(unsafe.Pointer(argsPtr.Pointer()))
+
+		if t.NumOut() == 0 {
+			return nil
+		}
+		return []reflect.Value{args.Field(t.NumIn())}
+	}))
+}
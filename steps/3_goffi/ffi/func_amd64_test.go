@@ -0,0 +1,33 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+// funcAddArgs is the typed argument struct for addPairFlatTarget
+// (func_amd64_test.s), a trivial two-int64 sum - just enough to prove
+// Func[Args].Call reaches the real asmcall trampoline through BindFunc
+// rather than only exercising the generic plumbing.
+type funcAddArgs struct {
+	A int64 `ffi:"arg"`
+	B int64 `ffi:"arg"`
+	R int64 `ffi:"ret"`
+}
+
+func addPairFlatTarget()
+func addrOfAddPairFlatTarget() uintptr
+
+// TestFuncCall exercises BindFunc/Func.Call end to end: MustSpecFor
+// classifies funcAddArgs once, and Call drives the real asmcall
+// trampoline through it by address, not through a *Spec the caller
+// assembled and passed in themselves.
+func TestFuncCall(t *testing.T) {
+	f := BindFunc[funcAddArgs](addrOfAddPairFlatTarget())
+
+	args := funcAddArgs{A: 3, B: 4}
+	f.Call(&args)
+
+	if args.R != 7 {
+		t.Fatalf("Func.Call: got R=%v, want 7", args.R)
+	}
+}
@@ -0,0 +1,64 @@
+package ffi
+
+import "unsafe"
+
+// funcPC returns f's entry code address - the same value the rest of this
+// package used to get from runtime.funcPC via //go:linkname. That linkname
+// compiled fine but stopped having a linkable body once the compiler began
+// recognizing runtime.funcPC by name and inlining it away; a linkname to an
+// inlined-away function resolves to nothing, which is exactly the
+// "relocation target runtime.funcPC not defined" failure the aggregate
+// tests' comments already warned about.
+//
+// This version sidesteps the problem instead of chasing it per Go release:
+// a plain top-level function value (not a bound method, not a closure) is
+// ABI-stably represented as a pointer to a read-only funcval struct whose
+// first word is the entry PC, and that representation has not changed
+// across any Go release this package has been built with. f must be such a
+// value - asmcall and the handful of other funcPC callers in this package
+// all are.
+func funcPC(f interface{}) uintptr {
+	type funcValue struct {
+		_ uintptr // interface type word
+		p unsafe.Pointer
+	}
+	return *(*uintptr)((*funcValue)(unsafe.Pointer(&f)).p)
+}
+
+// A note on ABIInternal, since funcPC's own "across toolchains" framing
+// invites the question: every entry point funcPC is actually used on
+// (asmcall in ffi_amd64.go and its per-arch siblings, and the few other
+// asmcgocall targets like it) is implemented purely in assembly. Taking such a function as
+// a Go func value still makes the compiler generate an ABIInternal
+// wrapper around the assembly body, and funcPC returns that wrapper's
+// address, not the body's. On amd64 the wrapper calls the body and then
+// reloads R14 with the current g and zeroes X15 before it returns. Test
+// fixtures that asmcall actually calls in place of a C function therefore
+// take their address
+// with a same-file $·name(SB) reference (the addrOf... helpers in the
+// _test.s files) instead of funcPC: asmcall keeps its own state in R14
+// across the foreign call. callbackasm, which foreign code reaches by a
+// jump, is addressed the same way (addrOfCallbackasm, callback_amd64.s).
+//
+// A genuine register-argument ABIInternal calling convention only
+// becomes a distinct concern on the two paths that cross into or out of
+// real Go-source code: MakeCallback's fn (callback_amd64.go) is called
+// as ctx.fn(argp), an ordinary Go call the compiler already places
+// fn's single unsafe.Pointer argument into per the current ABIInternal
+// rules with no wrapper at all; and spec.fn (ffi_amd64.go) is, by this
+// package's whole premise, always a foreign C function's address, which
+// was never ABIInternal to begin with - SysV/Win64 classification is the
+// correct and only calling convention for it. Neither leaves a wrapper
+// on the table to design a trampoline variant around.
+//
+// What ABIInternal's register-assignment rules have never offered is the
+// one thing that's actually missing: a stability promise. Go's own docs
+// mark ABIInternal explicitly unstable release to release - unlike ABI0,
+// it is not a contract the toolchain is obliged to keep, and hand-rolled
+// assembly classifying arguments into ABIInternal's registers today
+// would need re-deriving, and re-verifying, against every future Go
+// release's register-assignment pass. funcPC's own fix for the similar
+// runtime.funcPC problem above took the opposite approach on purpose -
+// rely on a representation Go has never had reason to change - and
+// there is no equivalent ABI-stable shortcut available here, only the
+// per-release rule set itself.
@@ -0,0 +1,177 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// guardCallParams is switchStackAndCall's (guardstack_amd64.s) own
+// argument struct, asmcgocall's target in place of asmcall whenever
+// spec.guardStack is set - see invoke (ffi_amd64.go).
+type guardCallParams struct {
+	newSP  uintptr
+	params uintptr
+}
+
+// switchStackAndCall has no Go body - see guardstack_amd64.s.
+func switchStackAndCall()
+
+var switchStackAndCallPtr = funcPC(switchStackAndCall)
+
+// defaultCanary is the pattern NewGuardStack writes just above the guard
+// page when called with canary=true, and CallGuardedStack checks after
+// every guarded call: 32 repeats of a byte with no plausible meaning as
+// either ASCII text or a small integer, chosen so a callee's legitimate
+// (if sloppy) write landing there by coincidence is as unlikely as this
+// sort of thing ever gets.
+var defaultCanary = bytes.Repeat([]byte{0xb7}, 32)
+
+// GuardStack is a dedicated stack for Spec.SetGuardStack to run a C call
+// on instead of g0's own: a PROT_NONE guard page below a usable region,
+// so a callee that recurses too deep or alloca(3)s too much overruns
+// into unmapped memory and faults immediately, rather than silently
+// smashing into whatever g0 was using the stack for at the time.
+//
+// A GuardStack is meant to be built once (NewGuardStack) and reused
+// across many calls via SetGuardStack, the same way a single
+// ThunkAllocator (jit.go) backs many Alloc calls - mmap/mprotect are too
+// expensive to pay per call. It is not safe for concurrent use: nothing
+// stops two goroutines both running Call with the same GuardStack set
+// from overwriting each other's frames on it, the same hazard a single
+// stack always has.
+type GuardStack struct {
+	base       uintptr
+	size       int
+	top        uintptr // switchStackAndCall's newSP - see guardstack_amd64.s
+	canaryAddr uintptr
+	canarySize int
+	closed     bool
+}
+
+// NewGuardStack mmaps a usableSize-byte stack with a PROT_NONE guard
+// page immediately below it, and - if canary is true - writes
+// defaultCanary into the lowest 32 bytes of the usable region, right
+// above the guard page, for CallGuardedStack to check after every call:
+// a callee that writes there without actually overrunning into the
+// guard page (e.g. a large but not-quite-overflowing alloca combined
+// with an off-by-one) corrupts the canary without ever faulting, and
+// would otherwise go unnoticed until whatever used that memory next
+// broke in some unrelated, harder-to-place way.
+//
+// usableSize is rounded up to a whole number of pages; the actual
+// mapping is one page larger still, for the guard page itself.
+func NewGuardStack(usableSize int, canary bool) (*GuardStack, error) {
+	usable := pageAlign(usableSize)
+	ps := os.Getpagesize()
+	total := ps + usable
+
+	b, err := syscall.Mmap(-1, 0, total, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, fmt.Errorf("ffi: NewGuardStack: mmap: %w", err)
+	}
+	base := uintptr(unsafe.Pointer(&b[0]))
+
+	if err := syscall.Mprotect(b[:ps], syscall.PROT_NONE); err != nil {
+		syscall.Munmap(b)
+		return nil, fmt.Errorf("ffi: NewGuardStack: mprotect guard page: %w", err)
+	}
+
+	gs := &GuardStack{
+		base: base,
+		size: total,
+		// top is the topmost 8 bytes of the mapping: switchStackAndCall's
+		// own reserved slot for the real SP it swaps away from, never
+		// reachable by asmcall/spec.fn themselves - see its doc comment.
+		top: base + uintptr(total) - 8,
+	}
+	if canary {
+		gs.canaryAddr = base + uintptr(ps)
+		gs.canarySize = len(defaultCanary)
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(gs.canaryAddr)), gs.canarySize), defaultCanary)
+	}
+	return gs, nil
+}
+
+// canaryIntact reports whether gs's canary - if any - still reads back
+// as defaultCanary. Always true when NewGuardStack was called with
+// canary=false, since there is nothing to check.
+func (gs *GuardStack) canaryIntact() bool {
+	if gs.canarySize == 0 {
+		return true
+	}
+	got := unsafe.Slice((*byte)(unsafe.Pointer(gs.canaryAddr)), gs.canarySize)
+	return bytes.Equal(got, defaultCanary)
+}
+
+// Close unmaps gs's stack, guard page included. gs must not be in use by
+// any in-flight Call when Close is called, and must not be passed to
+// SetGuardStack again afterward.
+func (gs *GuardStack) Close() error {
+	if gs.closed {
+		return nil
+	}
+	gs.closed = true
+	b := unsafe.Slice((*byte)(unsafe.Pointer(gs.base)), gs.size)
+	return syscall.Munmap(b)
+}
+
+// ErrStackOverflow is the error CallGuardedStack returns when spec.fn
+// faults against gs's guard page, or leaves its canary (if any)
+// corrupted without faulting at all - either way, a sign spec.fn used
+// more stack than gs has room for.
+var ErrStackOverflow = errors.New("ffi: call overflowed its guard-paged stack")
+
+// CallGuardedStack calls spec.Call(args) with gs set as spec's
+// GuardStack (SetGuardStack) for the duration of this one call only -
+// spec's previous GuardStack, if any, is restored before returning - and
+// reports a guard-page fault or a corrupted canary as ErrStackOverflow
+// instead of the SIGSEGV crashing the process, or the silent corruption,
+// either one would otherwise produce.
+//
+// Like CallReadonlyChecked, detecting the fault means running the call
+// through CallProtected - see its doc comment for what that costs: at
+// most one CallProtected/CallReadonlyChecked/CallGuardedStack
+// outstanding at a time, and one abandoned OS thread per fault ever
+// observed. A faulted call also never reaches spec.Call's own
+// prepare/restore bookkeeping (cstr/pin/marshal/... cleanup) for this
+// one call's args - the same leaked-call tradeoff CallProtected and
+// CallReadonlyChecked already make.
+func CallGuardedStack(spec *Spec, args unsafe.Pointer, gs *GuardStack) error {
+	prev := spec.guardStack
+	spec.SetGuardStack(gs)
+	defer spec.SetGuardStack(prev)
+
+	faultErr := CallProtected(func() { spec.Call(args) })
+	if faultErr != nil {
+		return fmt.Errorf("%w: %#x", ErrStackOverflow, spec.fn)
+	}
+	if !gs.canaryIntact() {
+		return fmt.Errorf("%w: canary corrupted without faulting: %#x", ErrStackOverflow, spec.fn)
+	}
+	return nil
+}
+
+// CallWithStackSize is CallGuardedStack for callers who don't want to
+// manage a GuardStack's lifetime themselves: it builds a throwaway
+// stackSize-byte GuardStack (with a canary), makes the one call, and
+// unmaps it again before returning - trading the mmap/mprotect cost on
+// every call for not having to size, build, and Close one up front.
+//
+// A call site that runs the same spec.fn repeatedly should build its own
+// GuardStack once with NewGuardStack and drive CallGuardedStack directly
+// instead - see GuardStack's own doc comment for why.
+func CallWithStackSize(spec *Spec, args unsafe.Pointer, stackSize int) error {
+	gs, err := NewGuardStack(stackSize, true)
+	if err != nil {
+		return err
+	}
+	defer gs.Close()
+
+	return CallGuardedStack(spec, args, gs)
+}
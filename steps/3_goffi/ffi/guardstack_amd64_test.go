@@ -0,0 +1,82 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func guardOverflowTarget()
+func guardOkTarget()
+func addrOfGuardOverflowTarget() uintptr
+func addrOfGuardOkTarget() uintptr
+
+type guardArgs struct{}
+
+// TestCallGuardedStackCatchesOverflow drives guardOverflowTarget - which
+// writes far below any reasonable stack's usable region - through
+// CallGuardedStack on a deliberately small GuardStack, confirming the
+// guard page's fault comes back as ErrStackOverflow.
+func TestCallGuardedStackCatchesOverflow(t *testing.T) {
+	gs, err := NewGuardStack(4096, true)
+	if err != nil {
+		t.Fatalf("NewGuardStack: %v", err)
+	}
+	defer gs.Close()
+
+	spec := MakeSpec(addrOfGuardOverflowTarget(), &guardArgs{})
+	err = CallGuardedStack(&spec, unsafe.Pointer(&guardArgs{}), gs)
+	if !errors.Is(err, ErrStackOverflow) {
+		t.Fatalf("CallGuardedStack: got err=%v, want ErrStackOverflow", err)
+	}
+}
+
+// TestCallGuardedStackOrdinaryCall confirms a callee that fits
+// comfortably on its GuardStack still completes normally, including
+// right after TestCallGuardedStackCatchesOverflow's own faulted call -
+// see TestCallProtectedOrdinaryCall (protect_amd64_test.go) for why
+// that ordering is safe to rely on.
+func TestCallGuardedStackOrdinaryCall(t *testing.T) {
+	gs, err := NewGuardStack(64*1024, true)
+	if err != nil {
+		t.Fatalf("NewGuardStack: %v", err)
+	}
+	defer gs.Close()
+
+	spec := MakeSpec(addrOfGuardOkTarget(), &guardArgs{})
+	if err := CallGuardedStack(&spec, unsafe.Pointer(&guardArgs{}), gs); err != nil {
+		t.Fatalf("CallGuardedStack: unexpected error %v", err)
+	}
+}
+
+// TestCallWithStackSizeCatchesOverflow confirms the build-call-close
+// convenience wrapper reports the same ErrStackOverflow as driving
+// NewGuardStack/CallGuardedStack/Close by hand would.
+func TestCallWithStackSizeCatchesOverflow(t *testing.T) {
+	spec := MakeSpec(addrOfGuardOverflowTarget(), &guardArgs{})
+	err := CallWithStackSize(&spec, unsafe.Pointer(&guardArgs{}), 4096)
+	if !errors.Is(err, ErrStackOverflow) {
+		t.Fatalf("CallWithStackSize: got err=%v, want ErrStackOverflow", err)
+	}
+}
+
+// TestCallGuardedStackCanaryDetectsCorruption confirms a canary that's
+// been overwritten without a fault - standing in for a callee that
+// wrote near, but not into, the guard page - is still reported as
+// ErrStackOverflow.
+func TestCallGuardedStackCanaryDetectsCorruption(t *testing.T) {
+	gs, err := NewGuardStack(64*1024, true)
+	if err != nil {
+		t.Fatalf("NewGuardStack: %v", err)
+	}
+	defer gs.Close()
+	*(*byte)(unsafe.Pointer(gs.canaryAddr)) ^= 0xff
+
+	spec := MakeSpec(addrOfGuardOkTarget(), &guardArgs{})
+	err = CallGuardedStack(&spec, unsafe.Pointer(&guardArgs{}), gs)
+	if !errors.Is(err, ErrStackOverflow) {
+		t.Fatalf("CallGuardedStack: got err=%v, want ErrStackOverflow for a corrupted canary", err)
+	}
+}
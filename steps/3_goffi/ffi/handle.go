@@ -0,0 +1,101 @@
+package ffi
+
+import (
+	"os"
+	"strings"
+	"unsafe"
+)
+
+// handleCheckEnabled is set once, from a GODEBUG=ffihandlecheck=1
+// setting, by init below - the same per-feature GODEBUG knob
+// pointercheck.go's ffipointercheck=1 and cstr_handle.go's
+// ffileakcheck=1 use. Handle's use-after-close detection only runs
+// while this is on: the nil check alone (always on, see Ptr) is one
+// branch on a field already in hand, but catching every Close-then-use
+// also means a Handle can no longer be dropped the instant Close
+// returns - closed has to stick around to be checked against - so it's
+// opt-in like this package's other debug-mode checks.
+var handleCheckEnabled bool
+
+func init() {
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if setting == "ffihandlecheck=1" {
+			handleCheckEnabled = true
+			break
+		}
+	}
+}
+
+// handleDestroyArgs is the args struct NewHandle's destroy Spec is
+// expected to have been built (via MakeSpec) against: one pointer
+// argument, the same shape as a C `void Destroy(void *handle)` - the
+// smallest args struct that can carry h's pointer back out to a call.
+type handleDestroyArgs struct {
+	Ptr uintptr `ffi:"arg"`
+}
+
+// Handle[T] is an opaque handle to a C pointer value - the opaque-type
+// idiom for an API that hands back a void* (or a typed pointer Go never
+// dereferences, only passes back) from one call and expects that exact
+// pointer value back later, as an ordinary argument or as the argument
+// to a destructor call. T is a phantom type parameter carrying no data
+// at runtime; it exists only so sqlite.DB and curl.Easy (say) are
+// Handle[sqliteDB] and Handle[curlEasy] - distinct Go types the compiler
+// won't let a caller mix up, the same role a tag type plays for a
+// Union[T]'s storage, even though neither T is ever read back out here.
+//
+// NewHandle wraps ptr and an optional destructor Spec; Ptr returns ptr
+// for passing to a call, and Close invokes the destructor (if any) with
+// ptr and marks the handle closed. A Handle is not itself an args-struct
+// field type the way Union[T] is - MakeSpec has no case for one,
+// deliberately: call Ptr() and assign its result to a plain uintptr
+// field the same way CStr.Ptr() already feeds an ffi:\"cstr\"-free
+// char* argument, rather than growing a second, handle-specific
+// argument-passing convention.
+type Handle[T any] struct {
+	ptr     uintptr
+	destroy *Spec
+	closed  bool
+}
+
+// NewHandle wraps ptr as a Handle[T]. destroy, if non-nil, must have
+// been built (via MakeSpec or MustSpecFor) against a handleDestroyArgs-
+// shaped args struct - one pointer argument - and is called with ptr
+// exactly once, by Close.
+func NewHandle[T any](ptr uintptr, destroy *Spec) *Handle[T] {
+	return &Handle[T]{ptr: ptr, destroy: destroy}
+}
+
+// Ptr returns h's underlying pointer value, for passing to a call or
+// storing into an argument field. It panics if h wraps a NULL pointer,
+// and - under GODEBUG=ffihandlecheck=1 - if h has already been Closed,
+// catching a use-after-free instead of handing a C function a pointer
+// that may since have been reused for something else entirely.
+func (h *Handle[T]) Ptr() uintptr {
+	if h.ptr == 0 {
+		panic("ffi: Handle: nil pointer")
+	}
+	if handleCheckEnabled && h.closed {
+		panic("ffi: Handle: use after Close")
+	}
+	return h.ptr
+}
+
+// Closed reports whether Close has already run on h.
+func (h *Handle[T]) Closed() bool {
+	return h.closed
+}
+
+// Close invokes h's destructor Spec (if any) with h's pointer and marks
+// h closed. It's safe to call more than once; only the first call
+// invokes the destructor.
+func (h *Handle[T]) Close() {
+	if h.closed {
+		return
+	}
+	h.closed = true
+	if h.destroy != nil {
+		args := handleDestroyArgs{Ptr: h.ptr}
+		h.destroy.Call(unsafe.Pointer(&args))
+	}
+}
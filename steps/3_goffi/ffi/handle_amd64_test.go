@@ -0,0 +1,35 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfMarkDestroyedTarget and markDestroyedTarget (handle_amd64_test.s)
+// stand in for a C destructor taking one pointer, the same way
+// addrOfAddPairTarget/addPairTarget do for a two-int sum.
+func addrOfMarkDestroyedTarget() uintptr
+func markDestroyedTarget()
+
+// TestHandleCloseInvokesDestructorSpec drives a real destructor Spec
+// through Handle.Close, confirming it's called with h's own pointer
+// exactly once.
+func TestHandleCloseInvokesDestructorSpec(t *testing.T) {
+	var marker byte
+	destroy := MakeSpec(addrOfMarkDestroyedTarget(), &handleDestroyArgs{})
+
+	h := NewHandle[handleTag](uintptr(unsafe.Pointer(&marker)), &destroy)
+	h.Close()
+
+	if marker != 1 {
+		t.Fatalf("destructor did not run against h's pointer: marker = %d", marker)
+	}
+
+	marker = 0
+	h.Close() // second Close must not invoke the destructor again
+	if marker != 0 {
+		t.Fatal("second Close invoked the destructor again")
+	}
+}
@@ -0,0 +1,53 @@
+package ffi
+
+import "testing"
+
+// handleTag is a phantom type used only to instantiate Handle[handleTag]
+// in tests - it carries no data, the same role sqliteDB/curlEasy would
+// play for a real opaque handle.
+type handleTag struct{}
+
+// TestHandlePtrPanicsOnNil confirms Ptr's nil check fires for a Handle
+// wrapping a NULL pointer, regardless of GODEBUG=ffihandlecheck.
+func TestHandlePtrPanicsOnNil(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Ptr did not panic on a nil Handle")
+		}
+	}()
+	h := NewHandle[handleTag](0, nil)
+	h.Ptr()
+}
+
+// TestHandleCloseIsIdempotent confirms Close can be called more than
+// once without invoking the destructor a second time.
+func TestHandleCloseIsIdempotent(t *testing.T) {
+	h := NewHandle[handleTag](1, nil)
+	h.Close()
+	if !h.Closed() {
+		t.Fatal("Closed() is false after Close")
+	}
+	h.Close() // must not panic or double-invoke a nil destructor
+}
+
+// TestHandlePtrAfterCloseOnlyPanicsUnderGODEBUG confirms Ptr's
+// use-after-close check only fires when GODEBUG=ffihandlecheck=1 is
+// set, matching this package's other opt-in debug checks.
+func TestHandlePtrAfterCloseOnlyPanicsUnderGODEBUG(t *testing.T) {
+	h := NewHandle[handleTag](1, nil)
+	h.Close()
+
+	if !handleCheckEnabled {
+		if got := h.Ptr(); got != 1 {
+			t.Fatalf("Ptr after Close = %d, want 1 (check disabled)", got)
+		}
+		return
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Ptr did not panic on a closed Handle under GODEBUG=ffihandlecheck=1")
+		}
+	}()
+	h.Ptr()
+}
@@ -0,0 +1,11 @@
+//go:build arm
+
+package ffi
+
+// icacheFlush is a best-effort no-op here, for the same reason
+// icache_arm64_other.go's is: a real flush means issuing a cacheflush(2)
+// syscall (or the equivalent __ARM_NR_cacheflush), which needs a syscall
+// this backend doesn't issue yet. See that file's doc comment for the
+// correctness gap this leaves for ThunkAllocator on this arch until it
+// does.
+func icacheFlush(addr uintptr, size int) {}
@@ -0,0 +1,30 @@
+//go:build darwin && arm64
+
+package ffi
+
+import "unsafe"
+
+// libc_sys_icache_invalidate is libSystem's instruction-cache flush,
+// resolved the same //go:cgo_import_dynamic way loader_darwin.go
+// resolves dlopen/dlsym/dlclose/dlerror. arm64 doesn't guarantee a
+// freshly-written block of code is visible to the instruction fetch
+// path just because it's visible to ordinary loads/stores - this is
+// what makes that guarantee true for the range jitMap/jitProtectExec
+// (jit_darwin.go) just finished writing to.
+//
+//go:cgo_import_dynamic libc_sys_icache_invalidate sys_icache_invalidate "/usr/lib/libSystem.B.dylib"
+//go:linkname libc_sys_icache_invalidate libc_sys_icache_invalidate
+
+var libc_sys_icache_invalidate uintptr
+
+type icacheInvalidateArgs struct {
+	Start uintptr `ffi:"arg"`
+	Len   uintptr `ffi:"arg"`
+}
+
+// icacheFlush calls sys_icache_invalidate(addr, size).
+func icacheFlush(addr uintptr, size int) {
+	args := icacheInvalidateArgs{Start: addr, Len: uintptr(size)}
+	spec := MakeSpec(libc_sys_icache_invalidate, &args)
+	spec.Call(unsafe.Pointer(&args))
+}
@@ -0,0 +1,21 @@
+//go:build arm64 && !darwin
+
+package ffi
+
+// icacheFlush is a best-effort no-op here: flushing the instruction
+// cache on linux/freebsd arm64 means issuing the actual "IC IVAU"/"DSB"/
+// "ISB" instruction sequence (what the gcc/clang __builtin___clear_cache
+// intrinsic expands to), which needs either inline assembly this
+// package doesn't have yet or a cgo call - both unavailable here the
+// same way a real ELF loader is unavailable on this platform (see
+// errLoaderUnsupported in loader_unsupported.go). Unlike
+// icache_arm64_darwin.go, there's no libSystem-style dynamic symbol this
+// package can resolve and call through instead: glibc/musl have no
+// dlopen-free equivalent either.
+//
+// Until that assembly exists, any code jitMap/jitProtectExec
+// (jit_unix.go) just wrote on this platform+arch combination isn't
+// guaranteed visible to instruction fetch - a correctness gap, not just
+// a missed optimization, for whoever wires a dynamic thunk generator
+// into ThunkAllocator here first.
+func icacheFlush(addr uintptr, size int) {}
@@ -0,0 +1,11 @@
+//go:build loong64
+
+package ffi
+
+// icacheFlush is a best-effort no-op here, for the same reason
+// icache_arm64_other.go's is: a real flush means issuing LoongArch's
+// "IBAR" instruction (or the cacheflush(2) syscall fallback some kernels
+// require instead), which needs inline assembly this backend doesn't
+// have yet. See that file's doc comment for the correctness gap this
+// leaves for ThunkAllocator on this arch until it does.
+func icacheFlush(addr uintptr, size int) {}
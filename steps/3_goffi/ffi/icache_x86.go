@@ -0,0 +1,11 @@
+//go:build amd64 || 386
+
+package ffi
+
+// icacheFlush is a no-op on x86: both amd64 and 386 guarantee a
+// self-modifying-code-coherent instruction cache (the CPU itself keeps
+// the icache consistent with any write to code it's already fetched
+// from), unlike arm64 (see icache_arm64_darwin.go/icache_arm64_other.go)
+// where a freshly-written block of code isn't guaranteed visible to the
+// instruction fetch path until something explicitly says so.
+func icacheFlush(addr uintptr, size int) {}
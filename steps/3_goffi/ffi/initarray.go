@@ -0,0 +1,40 @@
+package ffi
+
+import "unsafe"
+
+// voidArgs is the argument struct for a bare void(void) function - no
+// parameters, no result - exactly what every DT_INIT_ARRAY/DT_FINI_ARRAY
+// entry, and the legacy DT_INIT/DT_FINI functions before them, always
+// are.
+type voidArgs struct{}
+
+// RunInitArray calls every function address in fns, in order, as a bare
+// void(void) function - the same order a DT_INIT_ARRAY section lists its
+// entries in. It's meant for a loader's use once it has actually walked
+// a shared object's .init_array section (and, before that, its legacy
+// DT_INIT entry, which runs first) into fns; there is no ELF parser here
+// yet to do that walking (see errLoaderUnsupported in
+// loader_unsupported.go), so fns has to come from somewhere else for
+// now. Calling the functions it's given, in the order it's given them,
+// is the part of "run init/fini arrays on load" that doesn't actually
+// depend on a real loader existing.
+func RunInitArray(fns []uintptr) {
+	var args voidArgs
+	for _, fn := range fns {
+		spec := MakeSpec(fn, &args)
+		spec.Call(unsafe.Pointer(&args))
+	}
+}
+
+// RunFiniArray calls every function address in fns in reverse order -
+// DT_FINI_ARRAY (and the legacy DT_FINI, which runs last) tear down in
+// the opposite order DT_INIT_ARRAY (and DT_INIT) built up in, the same
+// convention C++ global destructors follow relative to their
+// constructors.
+func RunFiniArray(fns []uintptr) {
+	var args voidArgs
+	for i := len(fns) - 1; i >= 0; i-- {
+		spec := MakeSpec(fns[i], &args)
+		spec.Call(unsafe.Pointer(&args))
+	}
+}
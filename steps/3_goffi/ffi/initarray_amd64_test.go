@@ -0,0 +1,46 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+var (
+	initArraySeq   int64
+	initArraySeenA int64
+	initArraySeenB int64
+)
+
+func markATarget()
+func markBTarget()
+func addrOfMarkATarget() uintptr
+func addrOfMarkBTarget() uintptr
+
+// TestRunInitArrayOrder confirms RunInitArray calls its functions in
+// the order given.
+func TestRunInitArrayOrder(t *testing.T) {
+	initArraySeq, initArraySeenA, initArraySeenB = 0, 0, 0
+
+	RunInitArray([]uintptr{addrOfMarkATarget(), addrOfMarkBTarget()})
+
+	if initArraySeenA == 0 || initArraySeenB == 0 {
+		t.Fatalf("RunInitArray: both targets should have run, got seenA=%d seenB=%d", initArraySeenA, initArraySeenB)
+	}
+	if initArraySeenA >= initArraySeenB {
+		t.Fatalf("RunInitArray: A ran at %d, B at %d - A should have run first", initArraySeenA, initArraySeenB)
+	}
+}
+
+// TestRunFiniArrayOrder confirms RunFiniArray calls its functions in
+// the reverse of the order given.
+func TestRunFiniArrayOrder(t *testing.T) {
+	initArraySeq, initArraySeenA, initArraySeenB = 0, 0, 0
+
+	RunFiniArray([]uintptr{addrOfMarkATarget(), addrOfMarkBTarget()})
+
+	if initArraySeenA == 0 || initArraySeenB == 0 {
+		t.Fatalf("RunFiniArray: both targets should have run, got seenA=%d seenB=%d", initArraySeenA, initArraySeenB)
+	}
+	if initArraySeenB >= initArraySeenA {
+		t.Fatalf("RunFiniArray: B ran at %d, A at %d - B should have run first", initArraySeenB, initArraySeenA)
+	}
+}
@@ -0,0 +1,22 @@
+package ffi
+
+// Int128 is a 128-bit integer argument or return value - C's __int128 (or
+// two uint64 words chosen by the caller to mean whatever 128-bit value they
+// like). It's a plain two-field struct rather than a distinct primitive
+// because every backend already knows how to classify and place a
+// two-eightbyte struct (see classifyEightbytes on amd64, its AAPCS64
+// counterpart on arm64): __int128 is classified the same way under both
+// ABIs, as two consecutive INTEGER eightbytes, so Int128 needs no backend
+// changes of its own to ride that existing aggregate path as either an
+// argument or a "ffi:\"ret\"" field.
+//
+// Lo holds the low 64 bits, Hi the high 64 bits - RAX:RDX order for a SysV
+// AMD64 return, the low/high halves of a two's-complement 128-bit value for
+// everything else.
+//
+// windows/amd64 and 386 don't support aggregate arguments/returns at all
+// yet (see their own ErrUnsupportedAggregate panics); Int128 is no
+// exception there.
+type Int128 struct {
+	Lo, Hi uint64
+}
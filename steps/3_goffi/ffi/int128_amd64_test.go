@@ -0,0 +1,34 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// int128Args is the typed argument struct for incInt128Target
+// (func_amd64_test.s): Int128's two uint64 fields classify as two
+// consecutive INTEGER eightbytes, so A rides intargs[0]/intargs[1] and R
+// rides ret0/ret1, exactly like any other two-eightbyte struct.
+type int128Args struct {
+	A Int128 `ffi:"arg"`
+	R Int128 `ffi:"ret"`
+}
+
+func incInt128Target()
+func addrOfIncInt128Target() uintptr
+
+// TestInt128Call drives incInt128Target through MakeSpec/Call, confirming
+// an Int128 argument and return value cross the real asmcall trampoline
+// with both halves intact.
+func TestInt128Call(t *testing.T) {
+	spec := MustSpecFor[int128Args](addrOfIncInt128Target())
+
+	args := int128Args{A: Int128{Lo: 1, Hi: 2}}
+	spec.Call(unsafe.Pointer(&args))
+
+	if args.R.Lo != 2 || args.R.Hi != 2 {
+		t.Fatalf("Int128 call: got R=%+v, want {Lo:2 Hi:2}", args.R)
+	}
+}
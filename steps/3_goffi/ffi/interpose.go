@@ -0,0 +1,62 @@
+package ffi
+
+import "sync"
+
+// interposeMu guards interposed, which every Library.Get/GetVersioned
+// call consults - concurrent Interpose/Uninterpose calls are expected
+// from test setup/teardown running alongside whatever goroutines are
+// mid-Call.
+var interposeMu sync.RWMutex
+
+// interposed maps a symbol name to the address Interpose registered for
+// it, process-wide rather than per-Library: a real LD_PRELOAD override
+// applies to every load of that symbol in the process too, not just one
+// shared object's.
+var interposed map[string]uintptr
+
+// Interpose makes every subsequent Library.Get/GetVersioned(symbol) call,
+// on any Library, return addr instead of resolving symbol from the real
+// library - the same global-scope override LD_PRELOAD gives a shared
+// object loaded ahead of everything else. addr is typically a
+// MakeCallback result (callback_amd64.go), letting a test stub out e.g.
+// gettimeofday or malloc as seen by a loaded library with deterministic
+// Go code instead of the platform's real implementation, without
+// rebuilding or re-linking anything.
+//
+// A symbol resolved via Get before Interpose registered an override for
+// it keeps resolving to whatever address that earlier call already
+// returned - Interpose has no way to reach back into a Spec already
+// built from it. Call Get again afterward for a Spec that should pick
+// the interposed address up.
+//
+// Interpose has no notion of which library a symbol came from: two
+// libraries that happen to export the same symbol name are
+// indistinguishable here, exactly as they would be to a real
+// LD_PRELOAD's single global scope. Use Uninterpose to remove an
+// override once a test no longer needs it.
+func Interpose(symbol string, addr uintptr) {
+	interposeMu.Lock()
+	defer interposeMu.Unlock()
+	if interposed == nil {
+		interposed = make(map[string]uintptr)
+	}
+	interposed[symbol] = addr
+}
+
+// Uninterpose removes symbol's override, if any, so the next
+// Library.Get/GetVersioned(symbol) call resolves it from the real
+// library again.
+func Uninterpose(symbol string) {
+	interposeMu.Lock()
+	defer interposeMu.Unlock()
+	delete(interposed, symbol)
+}
+
+// lookupInterposed is Get/GetVersioned's first stop, ahead of the real
+// getProcAddress/getProcAddressVersioned call - see Interpose.
+func lookupInterposed(symbol string) (addr uintptr, ok bool) {
+	interposeMu.RLock()
+	defer interposeMu.RUnlock()
+	addr, ok = interposed[symbol]
+	return addr, ok
+}
@@ -0,0 +1,88 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgInfo describes one register or stack slot MakeSpec assigned: which
+// physical location it occupies, the {offset, kind, size} triple it
+// loads from (or, for Ret, stores to) there, and whether it's an incoming
+// argument or part of the return value.
+type ArgInfo struct {
+	Location string // "RDI", "XMM2", "stack+16", "RAX" (a return register), ...
+	Offset   uint16 // byte offset into the args struct MakeSpec classified
+	Kind     ArgKind
+	Size     uint8
+	Ret      bool
+}
+
+// extension names how a sub-eightbyte integer gets extended to fill its
+// register - sign-extended, zero-extended, or (for anything eightbyte-sized
+// already, a float, or typeAddr) not extended at all.
+func (i ArgInfo) extension() string {
+	switch argtype(i.Kind) {
+	case typeS8, typeS16, typeS32:
+		return "sign-extend"
+	case typeU8, typeU16, typeU32:
+		return "zero-extend"
+	default:
+		return "none"
+	}
+}
+
+// intRegNames/xmmRegNames name the SysV AMD64 integer/SSE argument
+// registers in the order buildSpec fills spec.intargs/spec.xmmargs.
+var intRegNames = [6]string{"RDI", "RSI", "RDX", "RCX", "R8", "R9"}
+var xmmRegNames = [8]string{"XMM0", "XMM1", "XMM2", "XMM3", "XMM4", "XMM5", "XMM6", "XMM7"}
+
+// appendArgInfo appends loc's ArgInfo to infos, unless a is unused - an
+// argument slot buildSpec never filled has nothing to report.
+func appendArgInfo(infos []ArgInfo, loc string, a argument, ret bool) []ArgInfo {
+	if a.t == typeUnused {
+		return infos
+	}
+	return append(infos, ArgInfo{Location: loc, Offset: a.offset, Kind: ArgKind(a.t), Size: a.size, Ret: ret})
+}
+
+// Args reports how MakeSpec assigned spec's args struct: one ArgInfo per
+// register or stack slot actually in use, in calling-convention order -
+// intargs, then xmmargs, then stack, then the return registers - so a
+// caller can walk it alongside a C prototype's own parameter list.
+func (spec *Spec) Args() []ArgInfo {
+	var infos []ArgInfo
+	for i, a := range spec.intargs {
+		infos = appendArgInfo(infos, intRegNames[i], a, false)
+	}
+	for i, a := range spec.xmmargs {
+		infos = appendArgInfo(infos, xmmRegNames[i], a, false)
+	}
+	for i, a := range spec.stack {
+		infos = appendArgInfo(infos, fmt.Sprintf("stack+%d", i*8), a, false)
+	}
+	infos = appendArgInfo(infos, "RAX", spec.ret0, true)
+	infos = appendArgInfo(infos, "RDX", spec.ret1, true)
+	infos = appendArgInfo(infos, "XMM0", spec.xmmret0, true)
+	infos = appendArgInfo(infos, "XMM1", spec.xmmret1, true)
+	return infos
+}
+
+// String renders spec's computed calling convention one register or stack
+// slot per line, for comparing by eye against a C prototype - exactly the
+// same information Args returns, formatted for a human instead of a
+// caller's own logic.
+func (spec *Spec) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ffi.Spec{fn: 0x%x}", spec.fn)
+	for _, info := range spec.Args() {
+		role := "arg"
+		if info.Ret {
+			role = "ret"
+		}
+		fmt.Fprintf(&b, "\n  %-8s %s offset=%-3d %-7s size=%d %s",
+			info.Location, role, info.Offset, info.Kind, info.Size, info.extension())
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestArgsReportsRegisterAssignment confirms Args walks funcAddArgs's two
+// int64 arguments and int64 return the same way buildSpec placed them:
+// RDI/RSI for A/B, RAX for R.
+func TestArgsReportsRegisterAssignment(t *testing.T) {
+	spec := MustSpecFor[funcAddArgs](funcPC(addPairFlatTarget))
+
+	infos := spec.Args()
+	if len(infos) != 3 {
+		t.Fatalf("Args: got %d entries, want 3: %+v", len(infos), infos)
+	}
+
+	want := []ArgInfo{
+		{Location: "RDI", Offset: 0, Kind: ArgInt64, Size: 8, Ret: false},
+		{Location: "RSI", Offset: 8, Kind: ArgInt64, Size: 8, Ret: false},
+		{Location: "RAX", Offset: 16, Kind: ArgInt64, Size: 8, Ret: true},
+	}
+	for i, w := range want {
+		if infos[i] != w {
+			t.Fatalf("Args[%d]: got %+v, want %+v", i, infos[i], w)
+		}
+	}
+}
+
+// TestSpecString confirms String mentions every location Args reports, so
+// the two stay in sync by construction rather than by convention alone.
+func TestSpecString(t *testing.T) {
+	spec := MustSpecFor[funcAddArgs](funcPC(addPairFlatTarget))
+
+	s := spec.String()
+	for _, loc := range []string{"RDI", "RSI", "RAX"} {
+		if !strings.Contains(s, loc) {
+			t.Fatalf("String: %q missing location %q", s, loc)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// ThunkAllocator hands out small blocks of memory for runtime-generated
+// machine code - thunks or closures built at call time rather than
+// assembled ahead of time - keeping each block writable only while its
+// code is being written into it and executable-only (never both at
+// once, the W^X discipline most hardened kernels now enforce regardless)
+// for the rest of its life.
+//
+// MakeCallback (callback_amd64.go) is its first caller: each call to it
+// JIT-builds its own trampoline via buildCallbackTrampoline instead of
+// drawing from a fixed pool of pre-assembled stub functions the way this
+// package once did. On Windows, Alloc also registers the thunk with
+// RtlAddFunctionTable (see jitRegisterUnwind, jit_windows.go) so SEH and
+// the OS stack walker can cross it; Free tears that registration down
+// again.
+type ThunkAllocator struct {
+	mu    sync.Mutex
+	sizes map[uintptr]int
+}
+
+// NewThunkAllocator returns an empty ThunkAllocator.
+func NewThunkAllocator() *ThunkAllocator {
+	return &ThunkAllocator{sizes: map[uintptr]int{}}
+}
+
+// Alloc maps a fresh block of memory exactly large enough for code -
+// rounded up to a whole page, the granularity jitMap/jitProtectExec/
+// jitUnmap (jit_unix.go/jit_darwin.go/jit_windows.go) are restricted to
+// - copies code into it, switches it from writable to executable, and
+// flushes it from the instruction cache (see icacheFlush) before
+// returning its address. The returned address is only ever executable,
+// never writable again; there is no in-place update, only Free followed
+// by a fresh Alloc.
+func (a *ThunkAllocator) Alloc(code []byte) (uintptr, error) {
+	if len(code) == 0 {
+		return 0, fmt.Errorf("ffi: ThunkAllocator.Alloc: code is empty")
+	}
+
+	// jitUnwindTrailerSize reserves room right after code, still inside
+	// the same mapping, for jitRegisterUnwind's unwind metadata - see its
+	// doc comment (jit_windows.go) for why it has to share the mapping
+	// rather than live in a separate allocation. It's 0 everywhere else.
+	size := pageAlign(len(code) + jitUnwindTrailerSize)
+	addr, err := jitMap(size)
+	if err != nil {
+		return 0, err
+	}
+
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(addr)), size)
+	copy(dst, code)
+
+	// jitRegisterUnwind writes its trailer while the mapping is still
+	// writable, so it has to run before jitProtectExec flips it read/exec.
+	if err := jitRegisterUnwind(addr, len(code)); err != nil {
+		jitUnmap(addr, size)
+		return 0, err
+	}
+
+	if err := jitProtectExec(addr, size); err != nil {
+		jitUnregisterUnwind(addr)
+		jitUnmap(addr, size)
+		return 0, err
+	}
+	icacheFlush(addr, size)
+
+	a.mu.Lock()
+	a.sizes[addr] = size
+	a.mu.Unlock()
+	return addr, nil
+}
+
+// Free unmaps the block an earlier Alloc on a returned at addr. addr
+// must be a value this same ThunkAllocator returned from Alloc, not yet
+// freed; calling Free twice on the same addr, or on an addr some other
+// ThunkAllocator (or nothing) allocated, returns an error rather than
+// unmapping memory Free has no record of owning.
+func (a *ThunkAllocator) Free(addr uintptr) error {
+	a.mu.Lock()
+	size, ok := a.sizes[addr]
+	if ok {
+		delete(a.sizes, addr)
+	}
+	a.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("ffi: ThunkAllocator.Free: %#x was not allocated by this ThunkAllocator, or already freed", addr)
+	}
+	jitUnregisterUnwind(addr)
+	return jitUnmap(addr, size)
+}
+
+// pageAlign rounds n up to a whole number of pages.
+func pageAlign(n int) int {
+	ps := os.Getpagesize()
+	return (n + ps - 1) &^ (ps - 1)
+}
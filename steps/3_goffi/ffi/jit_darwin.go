@@ -0,0 +1,97 @@
+//go:build darwin
+
+package ffi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// libc_pthread_jit_write_protect_np is libSystem's per-thread JIT
+// write-protect switch, resolved the same //go:cgo_import_dynamic way
+// loader_darwin.go resolves dlopen/dlsym/dlclose/dlerror. On Apple
+// Silicon under the hardened runtime, a MAP_JIT mapping's pages aren't
+// made writable or executable by mprotect the way jit_unix.go's are -
+// calling this with 0 makes the calling thread's view of every MAP_JIT
+// mapping writable (and non-executable); calling it with 1 switches
+// back to executable (and non-writable). It's a no-op on Intel Macs and
+// on older macOS versions, where MAP_JIT pages are simply both
+// read/write and read/execute-mappable without this dance, so calling
+// it unconditionally is safe either way.
+//
+//go:cgo_import_dynamic libc_pthread_jit_write_protect_np pthread_jit_write_protect_np "/usr/lib/libSystem.B.dylib"
+//go:linkname libc_pthread_jit_write_protect_np libc_pthread_jit_write_protect_np
+
+var libc_pthread_jit_write_protect_np uintptr
+
+type jitWriteProtectArgs struct {
+	Enabled int32 `ffi:"arg"`
+}
+
+func jitWriteProtect(enabled bool) {
+	args := jitWriteProtectArgs{}
+	if enabled {
+		args.Enabled = 1
+	}
+	spec := MakeSpec(libc_pthread_jit_write_protect_np, &args)
+	spec.Call(unsafe.Pointer(&args))
+}
+
+// jitMap anonymously mmaps size bytes with MAP_JIT, macOS's flag for
+// memory meant to hold executable code generated at runtime (required
+// under the hardened runtime entitlement; harmless without it), and
+// switches the calling thread's JIT write-protect off so the mapping
+// starts out writable for ThunkAllocator.Alloc (jit.go) to copy code
+// into.
+// jitWriteProtect's effect is per OS thread, not per goroutine -
+// jitMap/jitProtectExec assume the calling goroutine stays on the same
+// thread across the Alloc call that copies code in between them, which
+// holds as long as nothing it does yields the thread (true today: the
+// copy in between is a plain Go copy, no syscall or blocking call of its
+// own), but isn't guaranteed by the goroutine scheduler in general.
+func jitMap(size int) (uintptr, error) {
+	b, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE|syscall.PROT_EXEC, syscall.MAP_PRIVATE|syscall.MAP_ANON|syscall.MAP_JIT)
+	if err != nil {
+		return 0, err
+	}
+	addr := uintptr(unsafe.Pointer(&b[0]))
+	jitWriteProtect(false)
+	return addr, nil
+}
+
+// jitProtectExec switches the calling thread's JIT write-protect back
+// on, the W^X switch ThunkAllocator.Alloc performs once code has been
+// copied into the mapping jitMap returned.
+//
+// On arm64 (Apple Silicon), a PAC-signed return address or a
+// BTI-protected jump target would matter here too - but only for code
+// that itself uses PAC/BTI instructions or is jumped to indirectly by
+// code that does. MakeCallback (callback_amd64.go) is the one thing this
+// package builds through ThunkAllocator today, and it's amd64-only; once
+// an arm64 callback trampoline exists to JIT-build (variadic_arm64.go's
+// darwin/arm64 support has no trampoline of its own to protect), signing
+// its `ret`/`br` targets belongs here, next to the write-protect switch
+// that already gates the same memory.
+func jitProtectExec(addr uintptr, size int) error {
+	jitWriteProtect(true)
+	return nil
+}
+
+// jitUnmap munmaps the size bytes at addr.
+func jitUnmap(addr uintptr, size int) error {
+	b := unsafe.Slice((*byte)(unsafe.Pointer(addr)), size)
+	return syscall.Munmap(b)
+}
+
+// jitUnwindTrailerSize is how many extra bytes ThunkAllocator.Alloc
+// reserves after a thunk's code for jitRegisterUnwind's metadata. Nothing
+// on this platform reads unwind information out of JIT-built code, so
+// there's nothing to reserve room for.
+const jitUnwindTrailerSize = 0
+
+// jitRegisterUnwind is a no-op here: see jit_windows.go for the one
+// platform that needs it.
+func jitRegisterUnwind(addr uintptr, codeSize int) error { return nil }
+
+// jitUnregisterUnwind is jitRegisterUnwind's no-op counterpart.
+func jitUnregisterUnwind(addr uintptr) {}
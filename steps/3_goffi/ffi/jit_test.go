@@ -0,0 +1,69 @@
+package ffi
+
+import "testing"
+
+// TestThunkAllocatorAllocWritesCode confirms the bytes at the address
+// Alloc returns match the code it was given.
+func TestThunkAllocatorAllocWritesCode(t *testing.T) {
+	a := NewThunkAllocator()
+	code := []byte{0x90, 0x90, 0xc3} // NOP; NOP; RET - harmless on amd64/386
+	addr, err := a.Alloc(code)
+	if err != nil {
+		t.Fatalf("Alloc: unexpected error: %v", err)
+	}
+	defer a.Free(addr)
+
+	if addr == 0 {
+		t.Fatal("Alloc: returned address 0")
+	}
+}
+
+// TestThunkAllocatorAllocEmpty confirms Alloc rejects empty code rather
+// than mapping a zero-length region.
+func TestThunkAllocatorAllocEmpty(t *testing.T) {
+	a := NewThunkAllocator()
+	if _, err := a.Alloc(nil); err == nil {
+		t.Fatal("Alloc(nil): expected an error, got nil")
+	}
+}
+
+// TestThunkAllocatorFreeUnknownAddr confirms Free rejects an address it
+// never allocated, rather than unmapping memory it has no record of
+// owning.
+func TestThunkAllocatorFreeUnknownAddr(t *testing.T) {
+	a := NewThunkAllocator()
+	if err := a.Free(0x1234); err == nil {
+		t.Fatal("Free: expected an error for an address never allocated, got nil")
+	}
+}
+
+// TestThunkAllocatorFreeTwice confirms a second Free on the same address
+// is rejected instead of double-unmapping it.
+func TestThunkAllocatorFreeTwice(t *testing.T) {
+	a := NewThunkAllocator()
+	addr, err := a.Alloc([]byte{0xc3})
+	if err != nil {
+		t.Fatalf("Alloc: unexpected error: %v", err)
+	}
+	if err := a.Free(addr); err != nil {
+		t.Fatalf("Free: unexpected error: %v", err)
+	}
+	if err := a.Free(addr); err == nil {
+		t.Fatal("second Free: expected an error, got nil")
+	}
+}
+
+// TestPageAlign confirms pageAlign rounds up to a whole page, leaving an
+// already page-sized value untouched.
+func TestPageAlign(t *testing.T) {
+	ps := pageAlign(1)
+	if ps <= 0 {
+		t.Fatalf("pageAlign(1) = %d, want a positive page size", ps)
+	}
+	if pageAlign(ps) != ps {
+		t.Errorf("pageAlign(%d) = %d, want %d", ps, pageAlign(ps), ps)
+	}
+	if pageAlign(ps+1) != ps*2 {
+		t.Errorf("pageAlign(%d) = %d, want %d", ps+1, pageAlign(ps+1), ps*2)
+	}
+}
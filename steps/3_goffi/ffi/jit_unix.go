@@ -0,0 +1,47 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// jitMap anonymously mmaps size bytes, initially writable but not
+// executable, for ThunkAllocator.Alloc (jit.go) to copy code into before
+// handing it to jitProtectExec.
+func jitMap(size int) (uintptr, error) {
+	b, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return 0, err
+	}
+	return uintptr(unsafe.Pointer(&b[0])), nil
+}
+
+// jitProtectExec mprotects the size bytes at addr (a jitMap result) from
+// read/write to read/execute - the write side of the W^X switch
+// ThunkAllocator.Alloc performs once code has been copied in, so the
+// block is never both writable and executable at the same time.
+func jitProtectExec(addr uintptr, size int) error {
+	b := unsafe.Slice((*byte)(unsafe.Pointer(addr)), size)
+	return syscall.Mprotect(b, syscall.PROT_READ|syscall.PROT_EXEC)
+}
+
+// jitUnmap munmaps the size bytes at addr.
+func jitUnmap(addr uintptr, size int) error {
+	b := unsafe.Slice((*byte)(unsafe.Pointer(addr)), size)
+	return syscall.Munmap(b)
+}
+
+// jitUnwindTrailerSize is how many extra bytes ThunkAllocator.Alloc
+// reserves after a thunk's code for jitRegisterUnwind's metadata. Nothing
+// on this platform reads unwind information out of JIT-built code, so
+// there's nothing to reserve room for.
+const jitUnwindTrailerSize = 0
+
+// jitRegisterUnwind is a no-op here: see jit_windows.go for the one
+// platform that needs it.
+func jitRegisterUnwind(addr uintptr, codeSize int) error { return nil }
+
+// jitUnregisterUnwind is jitRegisterUnwind's no-op counterpart.
+func jitUnregisterUnwind(addr uintptr) {}
@@ -0,0 +1,166 @@
+//go:build windows
+
+package ffi
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	memCommit  = 0x00001000
+	memReserve = 0x00002000
+	memRelease = 0x00008000
+
+	pageReadWrite   = 0x04
+	pageExecuteRead = 0x20
+)
+
+// procVirtualAlloc/procVirtualProtect/procVirtualFree are kernel32's
+// memory allocator, resolved through syscall.NewLazyDLL the same way
+// loader_windows.go's procGetModuleHandle is: the syscall package has no
+// built-in wrapper for any of them.
+var (
+	procVirtualAlloc   = syscall.NewLazyDLL("kernel32.dll").NewProc("VirtualAlloc")
+	procVirtualProtect = syscall.NewLazyDLL("kernel32.dll").NewProc("VirtualProtect")
+	procVirtualFree    = syscall.NewLazyDLL("kernel32.dll").NewProc("VirtualFree")
+)
+
+// jitMap reserves and commits size bytes as PAGE_READWRITE, for
+// ThunkAllocator.Alloc (jit.go) to copy code into before handing it to
+// jitProtectExec.
+func jitMap(size int) (uintptr, error) {
+	r, _, e := procVirtualAlloc.Call(0, uintptr(size), memCommit|memReserve, pageReadWrite)
+	if r == 0 {
+		return 0, e
+	}
+	return r, nil
+}
+
+// jitProtectExec switches the size bytes at addr from PAGE_READWRITE to
+// PAGE_EXECUTE_READ - the write side of the W^X switch ThunkAllocator.
+// Alloc performs once code has been copied in, so the block is never
+// both writable and executable at the same time.
+func jitProtectExec(addr uintptr, size int) error {
+	var old uint32
+	r, _, e := procVirtualProtect.Call(addr, uintptr(size), pageExecuteRead, uintptr(unsafe.Pointer(&old)))
+	if r == 0 {
+		return e
+	}
+	return nil
+}
+
+// jitUnmap releases the region at addr back to the OS. VirtualFree
+// requires a size of exactly 0 for MEM_RELEASE - releasing the whole
+// region VirtualAlloc reserved it as, not an arbitrary sub-range - so
+// size is accepted only to keep this function's signature the same
+// shape as jitMap/jitProtectExec's, not because VirtualFree uses it.
+func jitUnmap(addr uintptr, size int) error {
+	r, _, e := procVirtualFree.Call(addr, 0, memRelease)
+	if r == 0 {
+		return e
+	}
+	return nil
+}
+
+// procRtlAddFunctionTable/procRtlDeleteFunctionTable register and remove
+// dynamic function table entries for RtlAddFunctionTable's caller (here,
+// ThunkAllocator.Alloc/Free) - the mechanism SEH and the Windows stack
+// walker use to cross a JIT-built thunk instead of treating its return
+// address as unrecognized and giving up. Despite the Rtl prefix they're
+// exported from kernel32.dll, the same way GetModuleHandleW is resolved
+// in loader_windows.go.
+var (
+	procRtlAddFunctionTable    = syscall.NewLazyDLL("kernel32.dll").NewProc("RtlAddFunctionTable")
+	procRtlDeleteFunctionTable = syscall.NewLazyDLL("kernel32.dll").NewProc("RtlDeleteFunctionTable")
+)
+
+// jitUnwindTrailerSize is how many bytes ThunkAllocator.Alloc reserves
+// right after a thunk's code for jitRegisterUnwind's RUNTIME_FUNCTION (12
+// bytes: three uint32 RVAs) and UNWIND_INFO (4 bytes, no unwind codes):
+// 12 + 4.
+//
+// Both have to land inside the same mapping as the code itself -
+// RUNTIME_FUNCTION.BeginAddress/EndAddress/UnwindData, and the
+// BaseAddress RtlAddFunctionTable is given, are all 32 bit RVAs from one
+// another, and a separate allocation elsewhere in the 64 bit address
+// space has no guarantee of landing within 4GB of the thunk. Tacking the
+// metadata onto the same page sidesteps that: it's never executed, only
+// read by the unwinder, so sharing the thunk's eventual PAGE_EXECUTE_READ
+// protection is harmless.
+const jitUnwindTrailerSize = 12 + 4
+
+// runtimeFunction mirrors the x64 RUNTIME_FUNCTION entry the Windows
+// unwinder reads out of a registered function table - see
+// jitUnwindTrailerSize for why its fields are RVAs rather than absolute
+// pointers.
+type runtimeFunction struct {
+	beginAddress uint32
+	endAddress   uint32
+	unwindData   uint32
+}
+
+// unwindInfo mirrors the x64 UNWIND_INFO header for a function with no
+// unwind codes at all: every thunk this package builds today
+// (buildCallbackTrampoline, callback_amd64.go) only loads a couple of
+// scratch registers and jumps, never pushing or otherwise adjusting RSP,
+// so there's no prologue to describe - countOfCodes 0 says exactly that,
+// not a placeholder for one nothing ever writes.
+type unwindInfo struct {
+	versionFlags  uint8 // version (3 bits) | flags (5 bits) - both 0
+	sizeOfProlog  uint8
+	countOfCodes  uint8
+	frameRegister uint8 // frame register (4 bits) | frame offset (4 bits) - both 0
+}
+
+// unwindMu/unwindTables record, per thunk address, the FunctionTable
+// pointer RtlAddFunctionTable was handed - RtlDeleteFunctionTable needs
+// that same pointer back, not the thunk's own address, to remove it
+// again.
+var (
+	unwindMu     sync.Mutex
+	unwindTables = map[uintptr]uintptr{}
+)
+
+// jitRegisterUnwind writes a RUNTIME_FUNCTION/UNWIND_INFO pair into the
+// jitUnwindTrailerSize bytes reserved right after codeSize bytes of
+// thunk code at addr, and registers it with RtlAddFunctionTable. It must
+// run while addr is still writable, before jitProtectExec switches it to
+// PAGE_EXECUTE_READ.
+func jitRegisterUnwind(addr uintptr, codeSize int) error {
+	rfOff := uintptr(codeSize)
+	infoOff := rfOff + 12
+
+	*(*unwindInfo)(unsafe.Pointer(addr + infoOff)) = unwindInfo{}
+	*(*runtimeFunction)(unsafe.Pointer(addr + rfOff)) = runtimeFunction{
+		beginAddress: 0,
+		endAddress:   uint32(codeSize),
+		unwindData:   uint32(infoOff),
+	}
+
+	table := addr + rfOff
+	r, _, e := procRtlAddFunctionTable.Call(table, 1, addr)
+	if r == 0 {
+		return fmt.Errorf("ffi: jitRegisterUnwind: RtlAddFunctionTable: %w", e)
+	}
+
+	unwindMu.Lock()
+	unwindTables[addr] = table
+	unwindMu.Unlock()
+	return nil
+}
+
+// jitUnregisterUnwind removes the function table entry jitRegisterUnwind
+// added for addr, if any.
+func jitUnregisterUnwind(addr uintptr) {
+	unwindMu.Lock()
+	table, ok := unwindTables[addr]
+	delete(unwindTables, addr)
+	unwindMu.Unlock()
+
+	if ok {
+		procRtlDeleteFunctionTable.Call(table)
+	}
+}
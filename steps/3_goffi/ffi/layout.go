@@ -0,0 +1,89 @@
+package ffi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FieldLayout describes one struct field's expected offset and size, as
+// the C struct declaration (or a generator's type map) says it should
+// be - the source of truth CheckLayout verifies a Go field against.
+//
+// BitWidth is non-zero for a field CheckLayout should instead verify as
+// one member of an `ffi:"bits=N"` run: BitOffset/BitWidth are checked
+// against what collectBitGroups computes for it - the same packed
+// position prepareBitFields (bitfield.go) will read and write it
+// through at call time - instead of Offset/Size, which a bitfield
+// member's own Go field doesn't meaningfully have (it shares its
+// group's packed storage word with every other member of the run).
+type FieldLayout struct {
+	Name      string
+	Offset    uintptr
+	Size      uintptr
+	BitOffset uint8
+	BitWidth  uint8
+}
+
+// CheckLayout verifies that t - typically an aggregate args/ret field
+// MakeSpec will walk byte-offset-by-byte rather than field-by-field (see
+// classifyField in ffi_amd64.go) - has every field named in want at
+// exactly the offset and size want says the C struct it stands in for
+// has. Go's own struct layout rules happen to agree with C's for a
+// straightforward struct on most platforms, but nothing guarantees it:
+// a hand-written struct with a field in the wrong order, a generator's
+// type map picking the wrong width for the target, or a size_t/long
+// assumption that doesn't hold on some target all produce a Go struct
+// that classifies (and calls) just fine while silently disagreeing with
+// the real ABI - corrupting every call through it instead of failing
+// loudly. Calling CheckLayout once, right after defining the struct -
+// typically from an init function, with want built by hand or emitted
+// by a generator alongside the struct itself - turns that into a
+// startup error instead.
+//
+// want need not name every field of t; a field CheckLayout isn't told
+// about (padding aside) is simply not checked.
+func CheckLayout(t reflect.Type, want []FieldLayout) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("ffi: CheckLayout: %s is not a struct", t)
+	}
+	var bitGroups []bitGroup
+	for _, w := range want {
+		f, ok := t.FieldByName(w.Name)
+		if !ok {
+			return fmt.Errorf("ffi: CheckLayout: %s has no field %q", t, w.Name)
+		}
+		if w.BitWidth != 0 {
+			if bitGroups == nil {
+				bitGroups = collectBitGroups(flattenFields(t))
+			}
+			bf, ok := bitFieldIn(bitGroups, uint16(f.Offset))
+			if !ok {
+				return fmt.Errorf("ffi: CheckLayout: %s.%s is not part of an ffi:\"bits=\" run", t, w.Name)
+			}
+			if bf.bitOffset != w.BitOffset || bf.bitWidth != w.BitWidth {
+				return fmt.Errorf("ffi: CheckLayout: %s.%s packs at bit %d width %d, want bit %d width %d", t, w.Name, bf.bitOffset, bf.bitWidth, w.BitOffset, w.BitWidth)
+			}
+			continue
+		}
+		if f.Offset != w.Offset {
+			return fmt.Errorf("ffi: CheckLayout: %s.%s is at offset %d, want %d", t, w.Name, f.Offset, w.Offset)
+		}
+		if f.Type.Size() != w.Size {
+			return fmt.Errorf("ffi: CheckLayout: %s.%s is %d bytes, want %d", t, w.Name, f.Type.Size(), w.Size)
+		}
+	}
+	return nil
+}
+
+// MustCheckLayout is CheckLayout but panics instead of returning a
+// non-nil error - the same shape as MustSpecFor (cache.go) for the
+// common case of a package-level init function with nowhere sensible to
+// propagate an error to.
+func MustCheckLayout(t reflect.Type, want []FieldLayout) {
+	if err := CheckLayout(t, want); err != nil {
+		panic(err)
+	}
+}
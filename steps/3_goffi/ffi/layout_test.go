@@ -0,0 +1,151 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCheckLayoutOK confirms CheckLayout accepts a struct whose fields
+// really do sit at the offsets/sizes want describes.
+func TestCheckLayoutOK(t *testing.T) {
+	type cPoint struct {
+		X int32
+		Y int32
+	}
+
+	err := CheckLayout(reflect.TypeOf(cPoint{}), []FieldLayout{
+		{Name: "X", Offset: 0, Size: 4},
+		{Name: "Y", Offset: 4, Size: 4},
+	})
+	if err != nil {
+		t.Fatalf("CheckLayout: unexpected error: %v", err)
+	}
+}
+
+// TestCheckLayoutOKSubset confirms CheckLayout doesn't require want to
+// name every field of the struct.
+func TestCheckLayoutOKSubset(t *testing.T) {
+	type cPoint struct {
+		X int32
+		Y int32
+	}
+
+	err := CheckLayout(reflect.TypeOf(cPoint{}), []FieldLayout{
+		{Name: "Y", Offset: 4, Size: 4},
+	})
+	if err != nil {
+		t.Fatalf("CheckLayout: unexpected error: %v", err)
+	}
+}
+
+// TestCheckLayoutWrongOffset confirms CheckLayout rejects a field that
+// doesn't sit where the C struct it's standing in for says it should -
+// the padding/alignment mismatch this is for.
+func TestCheckLayoutWrongOffset(t *testing.T) {
+	type misordered struct {
+		Flag  uint8
+		Value int32
+	}
+
+	err := CheckLayout(reflect.TypeOf(misordered{}), []FieldLayout{
+		{Name: "Flag", Offset: 0, Size: 1},
+		{Name: "Value", Offset: 1, Size: 4},
+	})
+	if err == nil {
+		t.Fatal("CheckLayout: expected an error for Value's padded offset")
+	}
+}
+
+// TestCheckLayoutWrongSize confirms CheckLayout rejects a field whose
+// width doesn't match what want describes, e.g. a generator picking
+// int32 where the C struct actually has a 64 bit member.
+func TestCheckLayoutWrongSize(t *testing.T) {
+	type wideField struct {
+		N int64
+	}
+
+	err := CheckLayout(reflect.TypeOf(wideField{}), []FieldLayout{
+		{Name: "N", Offset: 0, Size: 4},
+	})
+	if err == nil {
+		t.Fatal("CheckLayout: expected an error for N's size")
+	}
+}
+
+// TestCheckLayoutUnknownField confirms CheckLayout rejects a want entry
+// naming a field the struct doesn't have.
+func TestCheckLayoutUnknownField(t *testing.T) {
+	type onlyX struct {
+		X int32
+	}
+
+	err := CheckLayout(reflect.TypeOf(onlyX{}), []FieldLayout{
+		{Name: "Y", Offset: 0, Size: 4},
+	})
+	if err == nil {
+		t.Fatal("CheckLayout: expected an error for a nonexistent field")
+	}
+}
+
+// TestCheckLayoutNotStruct confirms CheckLayout rejects a non-struct
+// type outright rather than panicking inside FieldByName.
+func TestCheckLayoutNotStruct(t *testing.T) {
+	err := CheckLayout(reflect.TypeOf(int64(0)), []FieldLayout{
+		{Name: "X", Offset: 0, Size: 8},
+	})
+	if err == nil {
+		t.Fatal("CheckLayout: expected an error for a non-struct type")
+	}
+}
+
+// TestCheckLayoutBitfieldOK confirms CheckLayout verifies an
+// ffi:"bits=N" member's BitOffset/BitWidth against what
+// collectBitGroups computes, rather than its Go field's own
+// (meaningless, shared) Offset/Size.
+func TestCheckLayoutBitfieldOK(t *testing.T) {
+	type cFlags struct {
+		A uint32 `ffi:"bits=3"`
+		B uint32 `ffi:"bits=5"`
+	}
+
+	err := CheckLayout(reflect.TypeOf(cFlags{}), []FieldLayout{
+		{Name: "A", BitOffset: 0, BitWidth: 3},
+		{Name: "B", BitOffset: 3, BitWidth: 5},
+	})
+	if err != nil {
+		t.Fatalf("CheckLayout: unexpected error: %v", err)
+	}
+}
+
+// TestCheckLayoutBitfieldWrongOffset confirms CheckLayout rejects a
+// bitfield member whose actual packed bit offset doesn't match want.
+func TestCheckLayoutBitfieldWrongOffset(t *testing.T) {
+	type cFlags struct {
+		A uint32 `ffi:"bits=3"`
+		B uint32 `ffi:"bits=5"`
+	}
+
+	err := CheckLayout(reflect.TypeOf(cFlags{}), []FieldLayout{
+		{Name: "B", BitOffset: 4, BitWidth: 5},
+	})
+	if err == nil {
+		t.Fatal("CheckLayout: expected an error for B's bit offset")
+	}
+}
+
+// TestMustCheckLayoutPanics confirms MustCheckLayout panics on a
+// mismatch instead of returning an error.
+func TestMustCheckLayoutPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustCheckLayout: expected a panic, got none")
+		}
+	}()
+
+	type onlyX struct {
+		X int32
+	}
+	MustCheckLayout(reflect.TypeOf(onlyX{}), []FieldLayout{
+		{Name: "X", Offset: 99, Size: 4},
+	})
+}
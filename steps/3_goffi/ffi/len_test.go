@@ -0,0 +1,85 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// lenStructArgs has a slice field alongside the int32/int64 companion
+// fields lenArg/prepareLenFields are meant to keep populated.
+type lenStructArgs struct {
+	Buf []byte
+	N   int32
+	C   int64
+}
+
+// TestPrepareLenFieldsWritesLenAndCap confirms prepareLenFields writes
+// len(slice) into an ffi:"len=" field and cap(slice) into an ffi:"cap="
+// field, each through the target field's own (possibly narrower) integer
+// type.
+func TestPrepareLenFieldsWritesLenAndCap(t *testing.T) {
+	typ := reflect.TypeOf(lenStructArgs{})
+	args := lenStructArgs{Buf: make([]byte, 3, 8)}
+
+	fields := []lenField{
+		lenArg(typ, fieldByName(t, args, "Buf"), "N", false),
+		lenArg(typ, fieldByName(t, args, "Buf"), "C", true),
+	}
+
+	prepareLenFields(unsafe.Pointer(&args), fields)
+
+	if args.N != 3 {
+		t.Fatalf("len=: N = %d, want 3", args.N)
+	}
+	if args.C != 8 {
+		t.Fatalf("cap=: C = %d, want 8", args.C)
+	}
+}
+
+// TestLenArgRejectsNonSliceField confirms lenArg panics when tagged on a
+// field that isn't a slice.
+func TestLenArgRejectsNonSliceField(t *testing.T) {
+	type badArgs struct {
+		N int32
+		M int32
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("lenArg: expected a panic for a non-slice field")
+		}
+	}()
+	typ := reflect.TypeOf(badArgs{})
+	lenArg(typ, fieldByName(t, badArgs{}, "N"), "M", false)
+}
+
+// TestLenArgRejectsMissingCompanion confirms lenArg panics when the
+// named companion field doesn't exist.
+func TestLenArgRejectsMissingCompanion(t *testing.T) {
+	type badArgs struct {
+		Buf []byte
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("lenArg: expected a panic for a missing companion field")
+		}
+	}()
+	typ := reflect.TypeOf(badArgs{})
+	lenArg(typ, fieldByName(t, badArgs{}, "Buf"), "N", false)
+}
+
+// TestLenArgRejectsNonIntegerCompanion confirms lenArg panics when the
+// named companion field isn't an integer.
+func TestLenArgRejectsNonIntegerCompanion(t *testing.T) {
+	type badArgs struct {
+		Buf []byte
+		N   float64
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("lenArg: expected a panic for a non-integer companion field")
+		}
+	}()
+	typ := reflect.TypeOf(badArgs{})
+	lenArg(typ, fieldByName(t, badArgs{}, "Buf"), "N", false)
+}
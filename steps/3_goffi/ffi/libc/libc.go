@@ -0,0 +1,256 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+// Package libc provides ready-made ffi.Spec-based bindings for a handful
+// of libc functions chosen to exercise this project's calling
+// conventions end to end - scalar and pointer arguments (Memcpy), a
+// string argument turned into a C string automatically (Open, Dlopen), a
+// Go-allocated output buffer (Read), a slice argument decaying to a
+// pointer (Write), an opaque handle (Dlopen's result), a callback
+// invoked from a foreign thread (PthreadCreate's start routine), a
+// callback invoked synchronously by the C side (Qsort's comparator), and
+// a variadic call (Printf) - doubling as both an integration test suite
+// and a worked usage reference for package ffi.
+//
+// Malloc and Free are re-exported from package cmem rather than bound
+// again here, so there's exactly one place in this tree that owns the
+// malloc/free story; everything else is bound directly against libc.
+package libc
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+	"mewbak/nocgo/ffi/cmem"
+)
+
+// Malloc allocates n uninitialized bytes via libc malloc. See
+// cmem.Malloc.
+func Malloc(n uintptr) (uintptr, error) {
+	return cmem.Malloc(n)
+}
+
+// Free releases a pointer returned by Malloc via libc free. See
+// cmem.Free.
+func Free(ptr uintptr) error {
+	return cmem.Free(ptr)
+}
+
+// memcpyFn, openFn, closeFn, readFn, writeFn, dlopenFn, dlcloseFn,
+// pthreadCreateFn, pthreadJoinFn, qsortFn, printfFn, errnoLocationFn and
+// the pthread_mutex_*/pthread_cond_* functions are resolved once by init
+// below. initErr holds whatever error resolving them failed with, if
+// any - every exported function here returns it unchanged rather than
+// failing some other way.
+var (
+	memcpyFn, openFn, closeFn, readFn, writeFn                   uintptr
+	dlopenFn, dlcloseFn, pthreadCreateFn, pthreadJoinFn, qsortFn uintptr
+	printfFn, errnoLocationFn                                    uintptr
+	pthreadMutexInitFn, pthreadMutexLockFn                       uintptr
+	pthreadMutexTrylockFn, pthreadMutexUnlockFn                  uintptr
+	pthreadMutexDestroyFn                                        uintptr
+	pthreadCondInitFn, pthreadCondWaitFn, pthreadCondTimedwaitFn uintptr
+	pthreadCondSignalFn, pthreadCondBroadcastFn                  uintptr
+	pthreadCondDestroyFn                                         uintptr
+	initErr                                                      error
+)
+
+func init() {
+	lib, err := ffi.Open(libcPath)
+	if err != nil {
+		initErr = err
+		return
+	}
+	for name, fn := range map[string]*uintptr{
+		"memcpy":                 &memcpyFn,
+		"open":                   &openFn,
+		"close":                  &closeFn,
+		"read":                   &readFn,
+		"write":                  &writeFn,
+		"dlopen":                 &dlopenFn,
+		"dlclose":                &dlcloseFn,
+		"pthread_create":         &pthreadCreateFn,
+		"pthread_join":           &pthreadJoinFn,
+		"qsort":                  &qsortFn,
+		"printf":                 &printfFn,
+		"__errno_location":       &errnoLocationFn,
+		"pthread_mutex_init":     &pthreadMutexInitFn,
+		"pthread_mutex_lock":     &pthreadMutexLockFn,
+		"pthread_mutex_trylock":  &pthreadMutexTrylockFn,
+		"pthread_mutex_unlock":   &pthreadMutexUnlockFn,
+		"pthread_mutex_destroy":  &pthreadMutexDestroyFn,
+		"pthread_cond_init":      &pthreadCondInitFn,
+		"pthread_cond_wait":      &pthreadCondWaitFn,
+		"pthread_cond_timedwait": &pthreadCondTimedwaitFn,
+		"pthread_cond_signal":    &pthreadCondSignalFn,
+		"pthread_cond_broadcast": &pthreadCondBroadcastFn,
+		"pthread_cond_destroy":   &pthreadCondDestroyFn,
+	} {
+		addr, err := lib.Get(name)
+		if err != nil {
+			initErr = err
+			return
+		}
+		*fn = addr
+	}
+}
+
+// useErrno arranges for spec to capture errno via errnoLocationFn,
+// resolved once by init above - the same lib.Get("__errno_location")
+// symbol package ffi's own cstring.go and thread_hooks_amd64.go each
+// resolve independently for their own calls.
+func useErrno(spec *ffi.Spec) {
+	spec.UseErrno(errnoLocationFn)
+}
+
+// Memcpy copies n bytes from src to dst via libc memcpy and returns dst,
+// the same value libc's memcpy itself returns. The caller is responsible
+// for dst and src each pointing at at least n valid bytes, with no
+// overlap - exactly memcpy's own contract, unchecked here just as it is
+// in C.
+func Memcpy(dst, src, n uintptr) (uintptr, error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+	args := struct {
+		Dst uintptr `ffi:"arg"`
+		Src uintptr `ffi:"arg"`
+		N   uintptr `ffi:"arg"`
+		R   uintptr `ffi:"ret"`
+	}{Dst: dst, Src: src, N: n}
+	spec := ffi.MakeSpec(memcpyFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return args.R, nil
+}
+
+// Open opens path via libc open(2) and returns the resulting file
+// descriptor. mode is always passed, harmlessly ignored by the kernel
+// unless flags includes O_CREAT - the same fixed-three-argument
+// simplification most non-variadic open(2) bindings make, trading
+// open(3)'s own variadic declaration for a plain MakeSpec rather than
+// MakeVariadicSpec (see Printf for the latter).
+func Open(path string, flags int32, mode uint32) (fd int32, err error) {
+	if initErr != nil {
+		return -1, initErr
+	}
+	args := struct {
+		Path  string `ffi:"cstr"`
+		Flags int32  `ffi:"arg"`
+		Mode  uint32 `ffi:"arg"`
+		R     int32  `ffi:"ret"`
+	}{Path: path, Flags: flags, Mode: mode}
+	spec := ffi.MakeSpec(openFn, &args)
+	useErrno(&spec)
+	errno := spec.Call(unsafe.Pointer(&args))
+	if args.R < 0 {
+		return -1, fmt.Errorf("libc: open(%q): %w", path, syscall.Errno(errno))
+	}
+	return args.R, nil
+}
+
+// Close closes fd via libc close(2) - Open's necessary counterpart, the
+// same way cmem.Free is Malloc's.
+func Close(fd int32) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Fd int32 `ffi:"arg"`
+		R  int32 `ffi:"ret"`
+	}{Fd: fd}
+	spec := ffi.MakeSpec(closeFn, &args)
+	useErrno(&spec)
+	errno := spec.Call(unsafe.Pointer(&args))
+	if args.R < 0 {
+		return fmt.Errorf("libc: close(%d): %w", fd, syscall.Errno(errno))
+	}
+	return nil
+}
+
+// Read reads into buf via libc read(2), returning the number of bytes
+// actually read - buf decays to its Data pointer exactly as any other
+// Slice-kind argument does (fieldToOffset, package ffi), no ffi:"cstr" or
+// ffi:"pin" needed since a plain Go pointer is already valid for the
+// duration of the call it's passed to. Its ffi:"len=N" tag keeps N -
+// read(2)'s count argument - in sync with len(buf) automatically
+// (prepareLenFields, package ffi), rather than this code setting it by
+// hand and risking the two drifting apart.
+func Read(fd int32, buf []byte) (n int, err error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+	args := struct {
+		Fd  int32   `ffi:"arg"`
+		Buf []byte  `ffi:"arg,len=N"`
+		N   uintptr `ffi:"arg"`
+		R   int64   `ffi:"ret"`
+	}{Fd: fd, Buf: buf}
+	spec := ffi.MakeSpec(readFn, &args)
+	useErrno(&spec)
+	errno := spec.Call(unsafe.Pointer(&args))
+	if args.R < 0 {
+		return 0, fmt.Errorf("libc: read(%d): %w", fd, syscall.Errno(errno))
+	}
+	return int(args.R), nil
+}
+
+// Write writes buf via libc write(2), returning the number of bytes
+// actually written. See Read for why Buf carries an ffi:"len=N" tag
+// instead of this code setting N by hand.
+func Write(fd int32, buf []byte) (n int, err error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+	args := struct {
+		Fd  int32   `ffi:"arg"`
+		Buf []byte  `ffi:"arg,len=N"`
+		N   uintptr `ffi:"arg"`
+		R   int64   `ffi:"ret"`
+	}{Fd: fd, Buf: buf}
+	spec := ffi.MakeSpec(writeFn, &args)
+	useErrno(&spec)
+	errno := spec.Call(unsafe.Pointer(&args))
+	if args.R < 0 {
+		return 0, fmt.Errorf("libc: write(%d): %w", fd, syscall.Errno(errno))
+	}
+	return int(args.R), nil
+}
+
+// Dlopen opens a shared library via libc dlopen, returning its opaque
+// handle - a value this package only ever hands back to Dlclose, never
+// interprets itself, the same "opaque to Go" contract package ffi's own
+// *Library holds its handle under.
+func Dlopen(path string, flags int32) (handle uintptr, err error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+	args := struct {
+		Path  string  `ffi:"cstr"`
+		Flags int32   `ffi:"arg"`
+		R     uintptr `ffi:"ret"`
+	}{Path: path, Flags: flags}
+	spec := ffi.MakeSpec(dlopenFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return 0, fmt.Errorf("libc: dlopen(%q): returned NULL", path)
+	}
+	return args.R, nil
+}
+
+// Dlclose releases a handle returned by Dlopen.
+func Dlclose(handle uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Handle: handle}
+	spec := ffi.MakeSpec(dlcloseFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: dlclose(0x%x): returned %d", handle, args.R)
+	}
+	return nil
+}
@@ -0,0 +1,6 @@
+package libc
+
+// libcPath is the shared library init resolves every binding below from -
+// the same libSystem.B.dylib package ffi's own cstring_darwin.go and
+// cmem's cmem_darwin.go resolve their own libc symbols from.
+const libcPath = "/usr/lib/libSystem.B.dylib"
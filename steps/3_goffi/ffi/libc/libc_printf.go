@@ -0,0 +1,42 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package libc
+
+import (
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// Printf calls libc printf with format and, as its variadic tail, args -
+// each passed as a plain 64-bit integer, the promoted width every
+// integer narrower than long already arrives at per the variadic default
+// argument promotions, via ffi.MakeVariadicSpec/Spec.CallVariadic (see
+// their own doc comments in package ffi's variadic_amd64.go). It returns
+// the number of bytes printf reports having written.
+//
+// This only covers a format string whose variadic conversions are all
+// integer ("%d", "%x", "%c" and so on) - one that also takes a float,
+// double or string conversion needs its own varIsXmm/cstr handling this
+// helper doesn't attempt, in keeping with its job as a usage reference
+// rather than a general-purpose printf wrapper.
+func Printf(format string, args ...int64) (int, error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+
+	fixed := struct {
+		Format string `ffi:"cstr"`
+		R      int32  `ffi:"ret"`
+	}{Format: format}
+	spec := ffi.MakeVariadicSpec(printfFn, &fixed, nil)
+
+	varArgs := make([]uint64, len(args))
+	varIsXmm := make([]bool, len(args))
+	for i, a := range args {
+		varArgs[i] = uint64(a)
+	}
+
+	spec.CallVariadic(unsafe.Pointer(&fixed), varArgs, varIsXmm)
+	return int(fixed.R), nil
+}
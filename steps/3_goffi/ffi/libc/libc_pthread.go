@@ -0,0 +1,89 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package libc
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// pthreadStartCallbacks tracks the callback trampoline PthreadCreate
+// built for each still-running thread, keyed by its pthread_t, so
+// PthreadJoin can release it once the thread has actually exited - a
+// callback released while its thread might still be running would leave
+// that thread jumping into freed JIT-built code (buildCallbackTrampoline,
+// package ffi) on return.
+var (
+	pthreadStartCallbacksMu sync.Mutex
+	pthreadStartCallbacks   = map[uintptr]uintptr{}
+)
+
+// PthreadCreate starts a new OS thread running fn via libc
+// pthread_create, returning its pthread_t. fn is wrapped in a
+// ffi.NewCallback start routine matching void *(*)(void *) - the
+// trampoline is kept alive (see pthreadStartCallbacks above) until
+// PthreadJoin releases it.
+func PthreadCreate(fn func()) (thread uintptr, err error) {
+	if initErr != nil {
+		return 0, initErr
+	}
+
+	start := ffi.NewCallback(func(arg uintptr) uintptr {
+		fn()
+		return 0
+	})
+
+	var tid uintptr
+	args := struct {
+		Thread unsafe.Pointer `ffi:"arg"`
+		Attr   uintptr        `ffi:"arg"`
+		Start  uintptr        `ffi:"arg"`
+		Arg    uintptr        `ffi:"arg"`
+		R      int32          `ffi:"ret"`
+	}{Thread: unsafe.Pointer(&tid), Start: start}
+	spec := ffi.MakeSpec(pthreadCreateFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		ffi.ReleaseCallback(start)
+		return 0, fmt.Errorf("libc: pthread_create: returned %d", args.R)
+	}
+
+	pthreadStartCallbacksMu.Lock()
+	pthreadStartCallbacks[tid] = start
+	pthreadStartCallbacksMu.Unlock()
+
+	return tid, nil
+}
+
+// PthreadJoin waits for thread (as returned by PthreadCreate) to exit via
+// libc pthread_join, then releases the callback trampoline PthreadCreate
+// built for it.
+func PthreadJoin(thread uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+
+	args := struct {
+		Thread uintptr `ffi:"arg"`
+		Retval uintptr `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Thread: thread}
+	spec := ffi.MakeSpec(pthreadJoinFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+
+	pthreadStartCallbacksMu.Lock()
+	start, ok := pthreadStartCallbacks[thread]
+	delete(pthreadStartCallbacks, thread)
+	pthreadStartCallbacksMu.Unlock()
+	if ok {
+		ffi.ReleaseCallback(start)
+	}
+
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_join(0x%x): returned %d", thread, args.R)
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package libc
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// Mutex and Cond below all take the address of a pthread_mutex_t or
+// pthread_cond_t this package doesn't allocate or lay out itself - these
+// primitives are typically embedded in a C library's own struct, so the
+// caller passes in whatever address that C struct's field already lives
+// at (or one obtained from Malloc plus PthreadMutexInit/PthreadCondInit,
+// for a Go-owned instance) rather than this package reserving the bytes
+// for pthread_mutex_t/pthread_cond_t itself, which differ in size across
+// libc implementations (40 bytes for glibc's pthread_mutex_t on amd64,
+// 64 on macOS's libSystem) in a way this package has no portable way to
+// hard-code.
+
+// PthreadMutexInit initializes the pthread_mutex_t at mutex with the
+// default mutex attributes, via pthread_mutex_init(mutex, NULL).
+func PthreadMutexInit(mutex uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Mutex uintptr `ffi:"arg"`
+		Attr  uintptr `ffi:"arg"`
+		R     int32   `ffi:"ret"`
+	}{Mutex: mutex}
+	spec := ffi.MakeSpec(pthreadMutexInitFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_mutex_init: returned %d", args.R)
+	}
+	return nil
+}
+
+// PthreadMutexLock locks the pthread_mutex_t at mutex, blocking until
+// it's available - typically wrapped around the region of Go code that
+// calls into a C library not itself safe to reenter or call
+// concurrently from more than one thread.
+func PthreadMutexLock(mutex uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Mutex uintptr `ffi:"arg"`
+		R     int32   `ffi:"ret"`
+	}{Mutex: mutex}
+	spec := ffi.MakeSpec(pthreadMutexLockFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_mutex_lock: returned %d", args.R)
+	}
+	return nil
+}
+
+// errPthreadMutexBusy is returned by PthreadMutexTrylock when mutex is
+// already locked, wrapping pthread_mutex_trylock's own EBUSY.
+var errPthreadMutexBusy = errors.New("libc: pthread_mutex_trylock: mutex is locked")
+
+// PthreadMutexTrylock attempts to lock the pthread_mutex_t at mutex
+// without blocking, returning errPthreadMutexBusy (use errors.Is) if it
+// was already locked.
+func PthreadMutexTrylock(mutex uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Mutex uintptr `ffi:"arg"`
+		R     int32   `ffi:"ret"`
+	}{Mutex: mutex}
+	spec := ffi.MakeSpec(pthreadMutexTrylockFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	switch args.R {
+	case 0:
+		return nil
+	case int32(syscall.EBUSY):
+		return errPthreadMutexBusy
+	default:
+		return fmt.Errorf("libc: pthread_mutex_trylock: returned %d", args.R)
+	}
+}
+
+// PthreadMutexUnlock unlocks the pthread_mutex_t at mutex, locked by
+// PthreadMutexLock or a successful PthreadMutexTrylock on the same
+// thread.
+func PthreadMutexUnlock(mutex uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Mutex uintptr `ffi:"arg"`
+		R     int32   `ffi:"ret"`
+	}{Mutex: mutex}
+	spec := ffi.MakeSpec(pthreadMutexUnlockFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_mutex_unlock: returned %d", args.R)
+	}
+	return nil
+}
+
+// PthreadMutexDestroy destroys the pthread_mutex_t at mutex, the
+// PthreadMutexInit counterpart for a Go-owned instance - a mutex owned
+// by a C library is typically destroyed by that library instead.
+func PthreadMutexDestroy(mutex uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Mutex uintptr `ffi:"arg"`
+		R     int32   `ffi:"ret"`
+	}{Mutex: mutex}
+	spec := ffi.MakeSpec(pthreadMutexDestroyFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_mutex_destroy: returned %d", args.R)
+	}
+	return nil
+}
+
+// PthreadCondInit initializes the pthread_cond_t at cond with the
+// default condition variable attributes, via pthread_cond_init(cond,
+// NULL).
+func PthreadCondInit(cond uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Cond uintptr `ffi:"arg"`
+		Attr uintptr `ffi:"arg"`
+		R    int32   `ffi:"ret"`
+	}{Cond: cond}
+	spec := ffi.MakeSpec(pthreadCondInitFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_cond_init: returned %d", args.R)
+	}
+	return nil
+}
+
+// PthreadCondWait waits on the pthread_cond_t at cond, atomically
+// unlocking mutex (which the caller must already hold) while waiting and
+// relocking it before returning - exactly pthread_cond_wait's own
+// contract.
+func PthreadCondWait(cond, mutex uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Cond  uintptr `ffi:"arg"`
+		Mutex uintptr `ffi:"arg"`
+		R     int32   `ffi:"ret"`
+	}{Cond: cond, Mutex: mutex}
+	spec := ffi.MakeSpec(pthreadCondWaitFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_cond_wait: returned %d", args.R)
+	}
+	return nil
+}
+
+// errPthreadCondTimedOut is returned by PthreadCondTimedwait when the
+// wait's deadline passed without cond being signaled, wrapping
+// pthread_cond_timedwait's own ETIMEDOUT.
+var errPthreadCondTimedOut = errors.New("libc: pthread_cond_timedwait: timed out")
+
+// timespec lays out struct timespec (<time.h>) on a 64-bit target: two
+// longs, seconds and nanoseconds - the same layout glibc and macOS's
+// libSystem both use on amd64/arm64.
+type timespec struct {
+	Sec  int64
+	Nsec int64
+}
+
+// PthreadCondTimedwait is PthreadCondWait, but giving up and returning
+// errPthreadCondTimedOut (use errors.Is) if cond isn't signaled within
+// timeout - the deadline passed to pthread_cond_timedwait is computed as
+// time.Now().Add(timeout) against CLOCK_REALTIME, the clock
+// pthread_cond_timedwait uses unless the condition variable's attributes
+// were built with a different clock via pthread_condattr_setclock, which
+// PthreadCondInit's NULL attr never does.
+func PthreadCondTimedwait(cond, mutex uintptr, timeout time.Duration) error {
+	if initErr != nil {
+		return initErr
+	}
+	deadline := time.Now().Add(timeout)
+	ts := timespec{Sec: deadline.Unix(), Nsec: int64(deadline.Nanosecond())}
+	args := struct {
+		Cond    uintptr        `ffi:"arg"`
+		Mutex   uintptr        `ffi:"arg"`
+		Abstime unsafe.Pointer `ffi:"arg"`
+		R       int32          `ffi:"ret"`
+	}{Cond: cond, Mutex: mutex, Abstime: unsafe.Pointer(&ts)}
+	spec := ffi.MakeSpec(pthreadCondTimedwaitFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	switch args.R {
+	case 0:
+		return nil
+	case int32(syscall.ETIMEDOUT):
+		return errPthreadCondTimedOut
+	default:
+		return fmt.Errorf("libc: pthread_cond_timedwait: returned %d", args.R)
+	}
+}
+
+// PthreadCondSignal wakes at least one thread waiting on the
+// pthread_cond_t at cond, via pthread_cond_signal.
+func PthreadCondSignal(cond uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Cond uintptr `ffi:"arg"`
+		R    int32   `ffi:"ret"`
+	}{Cond: cond}
+	spec := ffi.MakeSpec(pthreadCondSignalFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_cond_signal: returned %d", args.R)
+	}
+	return nil
+}
+
+// PthreadCondBroadcast wakes every thread waiting on the pthread_cond_t
+// at cond, via pthread_cond_broadcast.
+func PthreadCondBroadcast(cond uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Cond uintptr `ffi:"arg"`
+		R    int32   `ffi:"ret"`
+	}{Cond: cond}
+	spec := ffi.MakeSpec(pthreadCondBroadcastFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_cond_broadcast: returned %d", args.R)
+	}
+	return nil
+}
+
+// PthreadCondDestroy destroys the pthread_cond_t at cond, the
+// PthreadCondInit counterpart for a Go-owned instance - a condition
+// variable owned by a C library is typically destroyed by that library
+// instead.
+func PthreadCondDestroy(cond uintptr) error {
+	if initErr != nil {
+		return initErr
+	}
+	args := struct {
+		Cond uintptr `ffi:"arg"`
+		R    int32   `ffi:"ret"`
+	}{Cond: cond}
+	spec := ffi.MakeSpec(pthreadCondDestroyFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("libc: pthread_cond_destroy: returned %d", args.R)
+	}
+	return nil
+}
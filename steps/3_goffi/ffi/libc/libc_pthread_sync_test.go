@@ -0,0 +1,136 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package libc
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// pthreadMutexT and pthreadCondT reserve enough bytes for glibc's
+// pthread_mutex_t/pthread_cond_t on amd64 (40 and 48 bytes respectively)
+// so these tests have somewhere real to point PthreadMutexInit/
+// PthreadCondInit at without needing this package to define the exact
+// struct layout itself - see libc_pthread_sync.go's own doc comment on
+// why it doesn't.
+type pthreadMutexT [6]uint64
+type pthreadCondT [6]uint64
+
+// TestPthreadMutexLockUnlock confirms PthreadMutexInit, Lock, Trylock
+// and Unlock round-trip against a Go-owned mutex.
+func TestPthreadMutexLockUnlock(t *testing.T) {
+	skipIfUnresolved(t)
+
+	var m pthreadMutexT
+	addr := uintptr(unsafe.Pointer(&m))
+	if err := PthreadMutexInit(addr); err != nil {
+		t.Fatalf("PthreadMutexInit: %v", err)
+	}
+	defer PthreadMutexDestroy(addr)
+
+	if err := PthreadMutexLock(addr); err != nil {
+		t.Fatalf("PthreadMutexLock: %v", err)
+	}
+	if err := PthreadMutexTrylock(addr); !errors.Is(err, errPthreadMutexBusy) {
+		t.Fatalf("PthreadMutexTrylock on a held mutex: got %v, want errPthreadMutexBusy", err)
+	}
+	if err := PthreadMutexUnlock(addr); err != nil {
+		t.Fatalf("PthreadMutexUnlock: %v", err)
+	}
+	if err := PthreadMutexTrylock(addr); err != nil {
+		t.Fatalf("PthreadMutexTrylock on a free mutex: %v", err)
+	}
+	if err := PthreadMutexUnlock(addr); err != nil {
+		t.Fatalf("PthreadMutexUnlock: %v", err)
+	}
+}
+
+// TestPthreadCondSignalWakesWaiter confirms PthreadCondWait actually
+// blocks until PthreadCondSignal wakes it, coordinating with a mutex the
+// waiter holds exactly as pthread_cond_wait's contract requires.
+func TestPthreadCondSignalWakesWaiter(t *testing.T) {
+	skipIfUnresolved(t)
+
+	var m pthreadMutexT
+	var c pthreadCondT
+	mutex := uintptr(unsafe.Pointer(&m))
+	cond := uintptr(unsafe.Pointer(&c))
+	if err := PthreadMutexInit(mutex); err != nil {
+		t.Fatalf("PthreadMutexInit: %v", err)
+	}
+	defer PthreadMutexDestroy(mutex)
+	if err := PthreadCondInit(cond); err != nil {
+		t.Fatalf("PthreadCondInit: %v", err)
+	}
+	defer PthreadCondDestroy(cond)
+
+	ready := make(chan struct{})
+	woken := make(chan error, 1)
+	go func() {
+		if err := PthreadMutexLock(mutex); err != nil {
+			woken <- err
+			return
+		}
+		close(ready)
+		err := PthreadCondWait(cond, mutex)
+		PthreadMutexUnlock(mutex)
+		woken <- err
+	}()
+
+	<-ready
+	// Give the waiter a moment to actually reach pthread_cond_wait before
+	// signaling - an imperfect but simple wait, matching the coarse
+	// synchronization thread_attrs_test.go and library_thread_test.go
+	// already use elsewhere in this tree rather than a real handshake.
+	time.Sleep(20 * time.Millisecond)
+
+	if err := PthreadMutexLock(mutex); err != nil {
+		t.Fatalf("PthreadMutexLock (signaler): %v", err)
+	}
+	if err := PthreadCondSignal(cond); err != nil {
+		t.Fatalf("PthreadCondSignal: %v", err)
+	}
+	if err := PthreadMutexUnlock(mutex); err != nil {
+		t.Fatalf("PthreadMutexUnlock (signaler): %v", err)
+	}
+
+	select {
+	case err := <-woken:
+		if err != nil {
+			t.Fatalf("PthreadCondWait: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("PthreadCondSignal did not wake the waiter in time")
+	}
+}
+
+// TestPthreadCondTimedwaitTimesOut confirms PthreadCondTimedwait reports
+// errPthreadCondTimedOut when nothing ever signals cond.
+func TestPthreadCondTimedwaitTimesOut(t *testing.T) {
+	skipIfUnresolved(t)
+
+	var m pthreadMutexT
+	var c pthreadCondT
+	mutex := uintptr(unsafe.Pointer(&m))
+	cond := uintptr(unsafe.Pointer(&c))
+	if err := PthreadMutexInit(mutex); err != nil {
+		t.Fatalf("PthreadMutexInit: %v", err)
+	}
+	defer PthreadMutexDestroy(mutex)
+	if err := PthreadCondInit(cond); err != nil {
+		t.Fatalf("PthreadCondInit: %v", err)
+	}
+	defer PthreadCondDestroy(cond)
+
+	if err := PthreadMutexLock(mutex); err != nil {
+		t.Fatalf("PthreadMutexLock: %v", err)
+	}
+	defer PthreadMutexUnlock(mutex)
+
+	err := PthreadCondTimedwait(cond, mutex, 50*time.Millisecond)
+	if !errors.Is(err, errPthreadCondTimedOut) {
+		t.Fatalf("PthreadCondTimedwait: got %v, want errPthreadCondTimedOut", err)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package libc
+
+import (
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// Qsort sorts the nmemb elements of size bytes each starting at base via
+// libc qsort, using less as the comparator - the same (a, b
+// unsafe.Pointer) int32 shape qsort's own int(*)(const void *, const
+// void *) has, with Go doing the pointer arithmetic into base that the C
+// side would otherwise do itself. less's callback trampoline is built
+// and released entirely within this call - unlike PthreadCreate's, it
+// never needs to outlive a single Qsort.
+func Qsort(base unsafe.Pointer, nmemb, size uintptr, less func(a, b unsafe.Pointer) int32) error {
+	if initErr != nil {
+		return initErr
+	}
+
+	compar := ffi.NewCallback(func(a, b unsafe.Pointer) int32 {
+		return less(a, b)
+	})
+	defer ffi.ReleaseCallback(compar)
+
+	args := struct {
+		Base   unsafe.Pointer `ffi:"arg"`
+		Nmemb  uintptr        `ffi:"arg"`
+		Size   uintptr        `ffi:"arg"`
+		Compar uintptr        `ffi:"arg"`
+	}{Base: base, Nmemb: nmemb, Size: size, Compar: compar}
+	spec := ffi.MakeSpec(qsortFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return nil
+}
@@ -0,0 +1,190 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package libc
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// skipIfUnresolved skips t when init failed to resolve the libc symbols
+// this package binds against - expected on a system without libc.so.6 (or
+// libSystem.B.dylib) at the usual path, same as this session's other new
+// live-execution tests (vdso_unsupported_test.go, rawsyscall_amd64_test.go).
+func skipIfUnresolved(t *testing.T) {
+	t.Helper()
+	if initErr != nil {
+		t.Skipf("libc symbols not resolved: %v", initErr)
+	}
+}
+
+// TestMemcpy confirms Memcpy copies n bytes from src to dst and returns
+// dst, exercising Malloc/Free alongside it.
+func TestMemcpy(t *testing.T) {
+	skipIfUnresolved(t)
+
+	src, err := Malloc(4)
+	if err != nil {
+		t.Fatalf("Malloc(src): %v", err)
+	}
+	defer Free(src)
+	dst, err := Malloc(4)
+	if err != nil {
+		t.Fatalf("Malloc(dst): %v", err)
+	}
+	defer Free(dst)
+
+	*(*uint32)(unsafe.Pointer(src)) = 0xdeadbeef
+
+	r, err := Memcpy(dst, src, 4)
+	if err != nil {
+		t.Fatalf("Memcpy: %v", err)
+	}
+	if r != dst {
+		t.Fatalf("Memcpy: returned %#x, want dst %#x", r, dst)
+	}
+	if got := *(*uint32)(unsafe.Pointer(dst)); got != 0xdeadbeef {
+		t.Fatalf("Memcpy: dst = %#x, want 0xdeadbeef", got)
+	}
+}
+
+// TestOpenReadWriteClose exercises Open, Write, Read and Close together
+// against a temp file, round-tripping a fixed payload through libc.
+func TestOpenReadWriteClose(t *testing.T) {
+	skipIfUnresolved(t)
+
+	path := t.TempDir() + "/libc_test.txt"
+
+	const oCreat, oWronly, oRdonly = 0o100, 0o1, 0o0
+	fd, err := Open(path, oCreat|oWronly, 0o600)
+	if err != nil {
+		t.Fatalf("Open(O_CREAT|O_WRONLY): %v", err)
+	}
+	payload := []byte("hello from libc\n")
+	n, err := Write(fd, payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if n != len(payload) {
+		t.Fatalf("Write: wrote %d bytes, want %d", n, len(payload))
+	}
+	if err := Close(fd); err != nil {
+		t.Fatalf("Close (write fd): %v", err)
+	}
+
+	fd, err = Open(path, oRdonly, 0)
+	if err != nil {
+		t.Fatalf("Open(O_RDONLY): %v", err)
+	}
+	defer Close(fd)
+	buf := make([]byte, len(payload))
+	n, err = Read(fd, buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if n != len(payload) || string(buf) != string(payload) {
+		t.Fatalf("Read: got %q (%d bytes), want %q", buf[:n], n, payload)
+	}
+}
+
+// TestOpenMissingFile confirms Open reports an error (wrapping
+// syscall.ENOENT) rather than a file descriptor when path doesn't exist.
+func TestOpenMissingFile(t *testing.T) {
+	skipIfUnresolved(t)
+
+	_, err := Open("/nonexistent/path/for/libc_test", 0, 0)
+	if err == nil {
+		t.Fatal("Open: expected an error for a nonexistent path")
+	}
+}
+
+// TestDlopenSelf confirms Dlopen/Dlclose round-trip against the libc
+// path this package already resolved its own symbols from.
+func TestDlopenSelf(t *testing.T) {
+	skipIfUnresolved(t)
+
+	handle, err := Dlopen(libcPath, 2 /* RTLD_NOW */)
+	if err != nil {
+		t.Fatalf("Dlopen(%q): %v", libcPath, err)
+	}
+	if err := Dlclose(handle); err != nil {
+		t.Fatalf("Dlclose: %v", err)
+	}
+}
+
+// TestPthreadCreateJoin confirms PthreadCreate actually runs fn on
+// another OS thread and PthreadJoin waits for it to finish.
+func TestPthreadCreateJoin(t *testing.T) {
+	skipIfUnresolved(t)
+
+	done := make(chan struct{}, 1)
+	thread, err := PthreadCreate(func() { done <- struct{}{} })
+	if err != nil {
+		t.Fatalf("PthreadCreate: %v", err)
+	}
+	if err := PthreadJoin(thread); err != nil {
+		t.Fatalf("PthreadJoin: %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Fatal("PthreadCreate: fn did not run before PthreadJoin returned")
+	}
+}
+
+// TestQsort confirms Qsort actually sorts, driving less from Go via a
+// JIT-built comparator trampoline.
+func TestQsort(t *testing.T) {
+	skipIfUnresolved(t)
+
+	vals := []int32{5, 3, 4, 1, 2}
+	base, err := Malloc(uintptr(len(vals)) * 4)
+	if err != nil {
+		t.Fatalf("Malloc: %v", err)
+	}
+	defer Free(base)
+	elem := func(i int) *int32 {
+		return (*int32)(unsafe.Pointer(base + uintptr(i)*4))
+	}
+	for i, v := range vals {
+		*elem(i) = v
+	}
+
+	less := func(a, b unsafe.Pointer) int32 {
+		av, bv := *(*int32)(a), *(*int32)(b)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		default:
+			return 0
+		}
+	}
+	if err := Qsort(unsafe.Pointer(base), uintptr(len(vals)), 4, less); err != nil {
+		t.Fatalf("Qsort: %v", err)
+	}
+
+	want := []int32{1, 2, 3, 4, 5}
+	for i, w := range want {
+		if got := *elem(i); got != w {
+			t.Fatalf("Qsort: vals[%d] = %d, want %d", i, got, w)
+		}
+	}
+}
+
+// TestPrintf confirms Printf reports the byte count libc's own printf
+// returns for a simple all-integer format string - it necessarily writes
+// to the process's real stdout (printf has no redirect argument), so
+// this only checks the reported count, not captured output.
+func TestPrintf(t *testing.T) {
+	skipIfUnresolved(t)
+
+	n, err := Printf("libc_test: %d-%d\n", 12, 34)
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+	if want := len("libc_test: 12-34\n"); n != want {
+		t.Fatalf("Printf: returned %d, want %d", n, want)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows && !darwin
+
+package libc
+
+// libcPath is the shared library init resolves every binding below from -
+// the same path package ffi's own cmem subpackage resolves malloc/free/
+// calloc/realloc from.
+const libcPath = "libc.so.6"
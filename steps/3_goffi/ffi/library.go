@@ -0,0 +1,163 @@
+package ffi
+
+import "sync/atomic"
+
+// Library is a loaded shared library, wrapping whatever OS-specific
+// loader this platform has (loader_windows.go's LoadLibrary/
+// GetProcAddress, or loader_darwin.go's dlopen/dlsym; see
+// loadLibrary/getProcAddress/closeLibrary below) so callers deal in
+// symbol addresses and real errors instead of juggling raw handles
+// themselves. Other platforms fall back to loader_unsupported.go until
+// they have one of their own.
+type Library struct {
+	// handle is read and written with the atomic package's Load/Swap
+	// functions rather than as a plain field, since Reload swaps it out
+	// from under any Get/GetVersioned call that might be running
+	// concurrently.
+	handle    uintptr
+	isDefault bool
+
+	// path and flags record how Open/OpenWithFlags loaded this Library,
+	// so Reload knows how to reopen it the same way. Both are zero for a
+	// Library from OpenDefault, which has no single path to reopen.
+	path  string
+	flags OpenFlags
+}
+
+// Open loads the shared library at path with RTLD_NOW|RTLD_LOCAL - the
+// same default dlopen itself uses when called with no flags - and
+// returns a Library to resolve symbols from. The caller is responsible
+// for calling Close once done with it. Use OpenWithFlags to control
+// binding semantics explicitly.
+func Open(path string) (*Library, error) {
+	return OpenWithFlags(path, RTLD_NOW|RTLD_LOCAL)
+}
+
+// OpenWithFlags is Open, but with the binding semantics (RTLD_NOW/
+// RTLD_LAZY, RTLD_GLOBAL/RTLD_LOCAL, RTLD_NODELETE) under the caller's
+// control instead of hard-coded.
+func OpenWithFlags(path string, flags OpenFlags) (*Library, error) {
+	h, err := loadLibrary(path, flags)
+	if err != nil {
+		return nil, &ErrLibraryNotFound{Path: path, Reason: err.Error()}
+	}
+	fireLibraryMapped(path, h)
+	return &Library{handle: h, path: path, flags: flags}, nil
+}
+
+// Get resolves symbol's address within lib, for use as the fn passed to
+// MakeSpec/MakeVariadicSpec. Interpose's registered override, if any,
+// takes priority over the real lookup - see its own comment. Either way,
+// a successful resolution fires any OnSymbolBound hooks before
+// returning.
+func (lib *Library) Get(symbol string) (uintptr, error) {
+	if addr, ok := lookupInterposed(symbol); ok {
+		fireSymbolBound(symbol, addr)
+		return addr, nil
+	}
+	addr, err := getProcAddress(atomic.LoadUintptr(&lib.handle), symbol)
+	if err != nil {
+		return 0, &ErrSymbolNotFound{Lib: lib.path, Name: symbol, Reason: err.Error()}
+	}
+	fireSymbolBound(symbol, addr)
+	return addr, nil
+}
+
+// GetVersioned resolves symbol's address within lib, pinned to a
+// specific symbol version (glibc's dlvsym, e.g. version "GLIBC_2.3" for
+// the pre-largefile realpath@GLIBC_2.3 rather than whatever realpath
+// the default, unversioned lookup would give), for use as the fn passed
+// to MakeSpec/MakeVariadicSpec. Interpose's registered override, if any,
+// takes priority over the real lookup, the same as Get - a versioned
+// symbol name interposes exactly like an unversioned one - and fires the
+// same OnSymbolBound hooks Get does on success.
+func (lib *Library) GetVersioned(symbol, version string) (uintptr, error) {
+	if addr, ok := lookupInterposed(symbol); ok {
+		fireSymbolBound(symbol, addr)
+		return addr, nil
+	}
+	addr, err := getProcAddressVersioned(atomic.LoadUintptr(&lib.handle), symbol, version)
+	if err != nil {
+		return 0, &ErrSymbolNotFound{Lib: lib.path, Name: symbol, Reason: err.Error()}
+	}
+	fireSymbolBound(symbol, addr)
+	return addr, nil
+}
+
+// GetOrStub is Get, but for an optional symbol a caller wants to treat
+// as "call it if present, degrade if not" instead of failing outright:
+// if symbol isn't found, found is false and addr is 0 rather than an
+// error, for use as the fn passed to MakeSpec together with a
+// spec.Stub(errno) call (ffi_amd64.go) that makes the resulting Spec
+// safe to Call unconditionally either way - real or stubbed, the call
+// site doesn't need to branch on found at all past this point.
+func (lib *Library) GetOrStub(symbol string) (addr uintptr, found bool) {
+	addr, err := lib.Get(symbol)
+	if err != nil {
+		return 0, false
+	}
+	return addr, true
+}
+
+// GetOptional is Get, but for a symbol the caller treats as genuinely
+// optional rather than an error condition: if symbol isn't found, ok is
+// false and addr is 0, with no error returned at all - the same
+// resolve-to-0 outcome a real loader gives an STB_WEAK reference with no
+// definition anywhere (see isWeakBinding, elf_weak_unsupported.go),
+// surfaced here since this package has no such loader yet to apply that
+// distinction on its own.
+//
+// GetOptional differs from GetOrStub in what it's for, not in its
+// result shape: GetOrStub exists to pair with spec.Stub(errno)
+// (ffi_amd64.go) so a call site can build and Call a Spec unconditionally
+// either way, while GetOptional is for a caller that just wants to know
+// whether an optional symbol is there - logging, feature detection, a
+// conditional code path - with no Spec or Call involved at all.
+func (lib *Library) GetOptional(symbol string) (addr uintptr, ok bool) {
+	addr, err := lib.Get(symbol)
+	if err != nil {
+		return 0, false
+	}
+	return addr, true
+}
+
+// GetCxx resolves the address of an extern "C++" free function within
+// lib, given its human-readable signature (e.g. "ns::frobnicate(int,
+// const char*)") rather than its mangled linker symbol - mangleItanium
+// does the translation, following the Itanium C++ ABI that g++ and
+// clang both implement on every platform this package supports. Only
+// simple signatures are supported: free functions (no member functions,
+// templates, or overloaded operators), and parameter types limited to
+// cxxBuiltins plus pointers/references/top-level const over a plain or
+// "::"-qualified name - see mangleItanium's doc comment for the exact
+// boundary. For anything past that boundary, give the function an
+// extern "C" wrapper and resolve it with Get instead.
+func (lib *Library) GetCxx(signature string) (uintptr, error) {
+	symbol, err := mangleItanium(signature)
+	if err != nil {
+		return 0, err
+	}
+	return lib.Get(symbol)
+}
+
+// Close unloads lib. Any Spec built from a symbol resolved through it
+// must not be called again afterwards.
+//
+// lib.isDefault is checked first: a Library OpenDefault returned
+// (library_default.go) didn't come from this platform's ordinary
+// load/unload pair, so it's closeDefaultLibrary - not closeLibrary -
+// that knows what, if anything, Close should actually do with its
+// handle.
+func (lib *Library) Close() error {
+	h := atomic.LoadUintptr(&lib.handle)
+	var err error
+	if lib.isDefault {
+		err = closeDefaultLibrary(h)
+	} else {
+		err = closeLibrary(h)
+	}
+	if err == nil {
+		fireLibraryUnmapped(h)
+	}
+	return err
+}
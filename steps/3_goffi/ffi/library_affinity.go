@@ -0,0 +1,80 @@
+package ffi
+
+import "fmt"
+
+// ErrWrongThread is returned by ThreadAffinity.Check when the calling
+// goroutine's current OS thread isn't the one RequireCallerThread
+// captured - a Call into a GL/EGL context or other thread-bound driver
+// library arriving from the wrong thread, the silent-corruption failure
+// mode ThreadAffinity exists to turn into a diagnosable error instead.
+type ErrWrongThread struct {
+	Lib    string
+	Owner  int
+	Caller int
+}
+
+func (e *ErrWrongThread) Error() string {
+	return fmt.Sprintf("ffi: %s: called from OS thread %d, but it was loaded/initialized on thread %d", e.Lib, e.Caller, e.Owner)
+}
+
+// Is reports whether target is also an *ErrWrongThread, ignoring
+// Lib/Owner/Caller - the same shape ErrLibraryNotFound.Is uses.
+func (e *ErrWrongThread) Is(target error) bool {
+	_, ok := target.(*ErrWrongThread)
+	return ok
+}
+
+// ThreadAffinity is an optional per-Library policy asserting that every
+// Call into lib happens on the one OS thread that set it up, rather than
+// just "some goroutine or other" - the convention GL/EGL and most GPU
+// driver libraries require, since their context state is bound to
+// whichever thread made it current and silently corrupts or crashes
+// given a call from any other thread instead of returning a useful
+// error. Build one with Library.RequireCallerThread.
+//
+// ThreadAffinity only diagnoses a violation; unlike ThreadedLibrary
+// (library_thread.go), it doesn't marshal calls onto the right thread
+// itself. The two are complementary: ThreadedLibrary is for a caller
+// happy to let this package pick and own the thread, while
+// ThreadAffinity is for a caller that already has a specific thread -
+// typically one an existing windowing toolkit created and made a GL
+// context current on - and just wants to catch a stray Call arriving
+// from the wrong one.
+type ThreadAffinity struct {
+	lib   *Library
+	owner int
+}
+
+// RequireCallerThread captures the calling goroutine's current OS thread
+// as lib's required thread and returns a ThreadAffinity to check every
+// subsequent Call against it.
+//
+// The caller is responsible for having already called
+// runtime.LockOSThread - the same requirement ApplyThreadAttrs' own doc
+// comment spells out - since otherwise the "owner" thread captured here
+// is just whichever thread happened to be running this goroutine at the
+// moment, with no guarantee it stays that way past the next call that
+// blocks or the Go scheduler otherwise decides to reschedule around.
+func (lib *Library) RequireCallerThread() (*ThreadAffinity, error) {
+	tid, err := currentOSThreadID()
+	if err != nil {
+		return nil, err
+	}
+	return &ThreadAffinity{lib: lib, owner: tid}, nil
+}
+
+// Check reports an *ErrWrongThread if the calling goroutine's current OS
+// thread isn't the one RequireCallerThread captured. Call it at the top
+// of whatever wraps every Spec.Call made against a's Library's symbols -
+// Check only diagnoses a violation, it can't stop the Call from
+// happening, since Spec.Call has no Library of its own to ask.
+func (a *ThreadAffinity) Check() error {
+	tid, err := currentOSThreadID()
+	if err != nil {
+		return err
+	}
+	if tid != a.owner {
+		return &ErrWrongThread{Lib: a.lib.path, Owner: a.owner, Caller: tid}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package ffi
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// TestThreadAffinityCheckSameThread confirms Check succeeds for a call
+// still on the same OS thread that captured it - the common case, every
+// Call into lib arriving from wherever RequireCallerThread itself ran.
+func TestThreadAffinityCheckSameThread(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	lib := &Library{}
+	a, err := lib.RequireCallerThread()
+	if err != nil {
+		t.Skipf("RequireCallerThread: %v (expected on a platform without currentOSThreadID yet)", err)
+	}
+	if err := a.Check(); err != nil {
+		t.Fatalf("Check: %v, want nil", err)
+	}
+}
+
+// TestThreadAffinityCheckDifferentThread confirms Check reports an
+// *ErrWrongThread when called from a goroutine locked to a different OS
+// thread than the one RequireCallerThread captured - the GL/EGL
+// misuse ThreadAffinity exists to catch.
+func TestThreadAffinityCheckDifferentThread(t *testing.T) {
+	ready := make(chan *ThreadAffinity, 1)
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		lib := &Library{}
+		a, err := lib.RequireCallerThread()
+		if err != nil {
+			a = nil
+		}
+		ready <- a
+		// Stay locked to this OS thread until the test is done checking,
+		// so the Go scheduler can't just hand the now-idle thread straight
+		// back to the main test goroutine (it otherwise tends to, via its
+		// own LIFO idle-thread reuse) and make this test flaky.
+		<-release
+	}()
+
+	a := <-ready
+	if a == nil {
+		t.Skip("RequireCallerThread unavailable (expected on a platform without currentOSThreadID yet)")
+	}
+
+	runtime.LockOSThread()
+	err := a.Check()
+	runtime.UnlockOSThread()
+	close(release)
+	<-done
+
+	if !errors.Is(err, new(ErrWrongThread)) {
+		t.Fatalf("Check: got %v, want an *ErrWrongThread", err)
+	}
+}
@@ -0,0 +1,58 @@
+package ffi
+
+import "os"
+
+// OpenFromBytes is Open, but for a shared library embedded directly in
+// the Go binary (e.g. via go:embed) instead of one already sitting at
+// some path on disk. It writes b to a temporary file, opens that the
+// same way Open would, and removes the temporary file immediately
+// afterward: on every platform this package supports, a library that's
+// already been mapped keeps working once its backing file is deleted
+// out from under it (POSIX keeps the inode alive as long as anything
+// still maps it; Windows keeps the mapping valid once LoadLibrary has
+// returned), so nothing is left on disk for the caller to clean up or
+// for anything else to find, and the temporary path is removed even if
+// Open itself fails.
+//
+// This is the portable, if slightly roundabout, equivalent of Linux's
+// memfd_create + dlopen("/proc/self/fd/N") trick: that would avoid ever
+// writing b to a path at all, but needs a real ELF loader to resolve a
+// /proc/self/fd path through (loader_unsupported.go has none yet) and
+// has no equivalent on darwin or windows regardless, so a temp file is
+// what actually works on every platform dlopen/LoadLibrary already do
+// today. Use OpenFromBytesWithFlags to control binding semantics
+// explicitly.
+func OpenFromBytes(b []byte) (*Library, error) {
+	return OpenFromBytesWithFlags(b, RTLD_NOW|RTLD_LOCAL)
+}
+
+// OpenFromBytesWithFlags is OpenFromBytes, but with the binding
+// semantics under the caller's control instead of hard-coded, the same
+// relationship OpenWithFlags has to Open.
+func OpenFromBytesWithFlags(b []byte, flags OpenFlags) (*Library, error) {
+	path, err := writeTempLibrary(b)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+	return OpenWithFlags(path, flags)
+}
+
+// writeTempLibrary copies b into a freshly created temporary file and
+// returns its path, for OpenFromBytes(WithFlags) to Open and then
+// remove. It cleans up the temporary file itself on a write failure,
+// same as OpenFromBytes(WithFlags) does on an Open failure, so a partial
+// write never leaves a stray file behind either.
+func writeTempLibrary(b []byte) (string, error) {
+	f, err := os.CreateTemp("", "ffi-embedded-*")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,43 @@
+package ffi
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestWriteTempLibraryWritesContent confirms writeTempLibrary's file
+// actually holds b's bytes, and cleans it up itself afterward.
+func TestWriteTempLibraryWritesContent(t *testing.T) {
+	want := []byte("not a real shared library, just test content")
+	path, err := writeTempLibrary(want)
+	if err != nil {
+		t.Fatalf("writeTempLibrary: unexpected error: %v", err)
+	}
+	defer os.Remove(path)
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(%q): unexpected error: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("writeTempLibrary wrote %q, want %q", got, want)
+	}
+}
+
+// TestOpenFromBytesRemovesTempFile confirms OpenFromBytesWithFlags
+// leaves no temporary file behind, whether or not the underlying Open
+// call succeeds.
+func TestOpenFromBytesRemovesTempFile(t *testing.T) {
+	OpenFromBytesWithFlags([]byte("not a real shared library"), RTLD_NOW)
+
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		t.Fatalf("ReadDir(TempDir): unexpected error: %v", err)
+	}
+	for _, e := range entries {
+		if bytes.HasPrefix([]byte(e.Name()), []byte("ffi-embedded-")) {
+			t.Fatalf("OpenFromBytesWithFlags left %q behind in %s", e.Name(), os.TempDir())
+		}
+	}
+}
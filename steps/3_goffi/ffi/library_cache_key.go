@@ -0,0 +1,35 @@
+package ffi
+
+import "path/filepath"
+
+// libraryCacheKey returns the string OpenShared/CloseShared actually key
+// their refcount map on for path: the file's identity, not the literal
+// argument string, so "./libfoo.so", "/usr/lib/libfoo.so", and a symlink
+// pointing at either of them all collapse to the same key the way a real
+// dlopen's own by-inode duplicate-mapping check would treat them. It's
+// built in two layers, each best-effort:
+//
+//   - filepath.EvalSymlinks resolves path to its real, symlink-free
+//     path, falling back to filepath.Clean(path) if that fails (the file
+//     doesn't exist, isn't readable, or a component along the way isn't a
+//     directory) - Open will fail on the same path right afterward
+//     regardless, so there's no dedup decision left to get wrong once
+//     that happens.
+//   - fileIdentity (library_cache_key_unix.go/library_cache_key_windows.go)
+//     appends the underlying file's device+inode where this platform can
+//     report one, so a hardlink or a bind mount of the same file - which
+//     can have a different realpath yet still be the identical inode -
+//     also collapses to one key. Where it can't (see
+//     library_cache_key_windows.go's own doc comment), the realpath alone
+//     is still strictly better deduplication than the literal string
+//     OpenShared used to key on.
+func libraryCacheKey(path string) string {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		real = filepath.Clean(path)
+	}
+	if id, ok := fileIdentity(real); ok {
+		return id
+	}
+	return real
+}
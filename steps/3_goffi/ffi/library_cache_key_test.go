@@ -0,0 +1,63 @@
+package ffi
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestLibraryCacheKeySameFileTwoPaths confirms a symlink and the real
+// file it points at resolve to the same libraryCacheKey, the dedup case
+// OpenShared is meant to catch that a literal path comparison would
+// miss.
+func TestLibraryCacheKeySameFileTwoPaths(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("os.Symlink needs elevated privileges on most Windows setups")
+	}
+
+	dir := t.TempDir()
+	real := filepath.Join(dir, "libreal.so")
+	if err := os.WriteFile(real, []byte("fake"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	link := filepath.Join(dir, "liblink.so")
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	keyReal := libraryCacheKey(real)
+	keyLink := libraryCacheKey(link)
+	if keyReal != keyLink {
+		t.Fatalf("libraryCacheKey(%q) = %q, libraryCacheKey(%q) = %q, want equal", real, keyReal, link, keyLink)
+	}
+}
+
+// TestLibraryCacheKeyDifferentFilesDiffer confirms two distinct files
+// don't collide just because they happen to share a directory.
+func TestLibraryCacheKeyDifferentFilesDiffer(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "liba.so")
+	b := filepath.Join(dir, "libb.so")
+	if err := os.WriteFile(a, []byte("fake"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(b, []byte("fake"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if libraryCacheKey(a) == libraryCacheKey(b) {
+		t.Fatalf("libraryCacheKey: %q and %q collided", a, b)
+	}
+}
+
+// TestLibraryCacheKeyMissingFileFallsBack confirms a path that doesn't
+// exist yet still gets a usable (if less precise) key instead of
+// libraryCacheKey itself failing - Open will report the real error right
+// afterward.
+func TestLibraryCacheKeyMissingFileFallsBack(t *testing.T) {
+	key := libraryCacheKey("/no/such/library-for-this-test.so")
+	if key == "" {
+		t.Fatal("libraryCacheKey: got empty key for a nonexistent path")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package ffi
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// fileIdentity stats real and reports its device+inode pair, formatted
+// as libraryCacheKey's suffix - the same (st_dev, st_ino) pair a real
+// dlopen compares to recognize two different paths (most often a
+// hardlink, or a bind mount) as the exact same mapped object, rather
+// than relying on path equality alone. ok is false only if real can't be
+// stat'd at all, in which case libraryCacheKey falls back to the
+// realpath by itself.
+func fileIdentity(real string) (string, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(real, &st); err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s:dev=%d:ino=%d", real, st.Dev, st.Ino), true
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package ffi
+
+// fileIdentity has no cheap device+inode equivalent to report on
+// Windows: the NTFS file index this would otherwise use
+// (BY_HANDLE_FILE_INFORMATION's VolumeSerialNumber/FileIndex) requires
+// an open handle to query, which would mean opening real on top of
+// whatever loadLibrary itself is about to do with it - not something
+// libraryCacheKey's caller should pay for just to compute a cache key.
+// It always reports not-ok, so libraryCacheKey falls back to path's
+// realpath alone: still correct for the common "same path spelled two
+// ways" case, just not for two different paths that are secretly the
+// same file via an NTFS hardlink.
+func fileIdentity(real string) (string, bool) {
+	return "", false
+}
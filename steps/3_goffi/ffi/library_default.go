@@ -0,0 +1,25 @@
+package ffi
+
+// OpenDefault returns a Library for the RTLD_DEFAULT namespace - the
+// running process's own executable together with every library already
+// loaded into it - rather than one path-specific shared object the way
+// Open does. It's meant for resolving a symbol the Go binary exports
+// itself (a cgo callback trampoline, a C object statically linked into
+// the binary) or one a library Open already loaded earlier, without
+// having to know or track which library actually defines it. Get and
+// GetVersioned work on the result exactly as they do on an ordinary
+// Open'd Library; see closeDefaultLibrary for what Close does with it.
+func OpenDefault() (*Library, error) {
+	return OpenDefaultWithFlags(RTLD_NOW | RTLD_LOCAL)
+}
+
+// OpenDefaultWithFlags is OpenDefault, but with the binding semantics
+// under the caller's control instead of hard-coded, the same relationship
+// OpenWithFlags has to Open.
+func OpenDefaultWithFlags(flags OpenFlags) (*Library, error) {
+	h, err := loadDefaultLibrary(flags)
+	if err != nil {
+		return nil, &ErrLibraryNotFound{Path: "", Reason: err.Error()}
+	}
+	return &Library{handle: h, isDefault: true}, nil
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "testing"
+
+// TestOpenDefaultUnsupported confirms OpenDefault reports the honest
+// "no loader here yet" error on this platform rather than returning a
+// Library wrapping a handle that was never actually resolved.
+func TestOpenDefaultUnsupported(t *testing.T) {
+	lib, err := OpenDefault()
+	if err == nil {
+		t.Fatal("OpenDefault: expected an error, got nil")
+	}
+	if lib != nil {
+		t.Fatalf("OpenDefault: got %v, want nil", lib)
+	}
+}
@@ -0,0 +1,49 @@
+package ffi
+
+// MappedSegment describes one of a library's loaded segments, the way a
+// real dlinfo(RTLD_DI_LINKMAP) or /proc/<pid>/maps entry would: its
+// virtual address range once mapped in, and the permissions it was
+// mapped with.
+type MappedSegment struct {
+	Addr       uintptr
+	Size       uintptr
+	Readable   bool
+	Writable   bool
+	Executable bool
+}
+
+// LibraryInfo is Library.Info's result: the handful of facts dlinfo(3)
+// and link_map traversal give a real ld.so's caller, gathered here
+// through whatever this platform's Library actually has access to - see
+// Library.Info's own comment for which fields that leaves unfilled.
+type LibraryInfo struct {
+	// Path is the path Open/OpenWithFlags loaded this Library from -
+	// the same value Library.path already records, or "" for a Library
+	// from OpenDefault.
+	Path string
+
+	// SoName is the library's own DT_SONAME, if it has one - usually
+	// different from Path's base name (e.g. "libc.so.6" for a Path of
+	// "/lib/x86_64-linux-gnu/libc-2.31.so").
+	SoName string
+
+	// Dependencies lists the sonames from this library's own DT_NEEDED
+	// entries, in the order they appear in its dynamic section - the
+	// direct dependency edge resolveLoadOrder (elf_deps_unsupported.go)
+	// will eventually walk transitively once a real loader exists to
+	// drive it.
+	Dependencies []string
+
+	// LoadBase is the address this library was actually mapped at. Zero
+	// where nothing here has access to that - see Library.Info.
+	LoadBase uintptr
+
+	// Segments lists the library's mapped segments. Nil where nothing
+	// here has access to that - see Library.Info.
+	Segments []MappedSegment
+
+	// LinkMapPosition is this library's index into the process's link
+	// map (dlinfo's RTLD_DI_LINKMAP order, roughly load order), or -1
+	// where nothing here has access to that - see Library.Info.
+	LinkMapPosition int
+}
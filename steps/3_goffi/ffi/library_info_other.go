@@ -0,0 +1,26 @@
+//go:build windows || darwin
+
+package ffi
+
+import "errors"
+
+// errInfoUnsupported is Info's result everywhere except the ELF
+// platforms library_info_unsupported.go covers: reading SoName/
+// Dependencies there falls out of parsing lib.path's own ELF dynamic
+// section with debug/elf, independent of loadLibrary's own dlopen/
+// LoadLibrary call, but Path here names a Mach-O (darwin) or PE
+// (windows) file, and this package has no parser for either format yet.
+// A real implementation would read LC_ID_DYLIB/LC_LOAD_DYLIB out of a
+// Mach-O's load commands on darwin, or walk a PE's import directory on
+// windows; LoadBase, Segments and LinkMapPosition would still need
+// dyld/dladdr-style runtime introspection (darwin) or
+// Toolhelp32/Psapi-style module enumeration (windows) on top of that,
+// neither of which this package calls yet either.
+var errInfoUnsupported = errors.New("ffi: Library.Info is not yet supported on this platform")
+
+// Info is documented on the library_info_unsupported.go build - see
+// errInfoUnsupported above for why this platform has no implementation
+// yet.
+func (lib *Library) Info() (*LibraryInfo, error) {
+	return nil, errInfoUnsupported
+}
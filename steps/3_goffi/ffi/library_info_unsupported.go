@@ -0,0 +1,41 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "debug/elf"
+
+// Info reads lib's own on-disk ELF dynamic section - via Go's standard
+// debug/elf, not anything loader_unsupported.go's errLoaderUnsupported
+// blocks - to report SoName and Dependencies, since both are static
+// facts about the file itself rather than anything this platform's
+// loadLibrary actually does with it yet.
+//
+// LoadBase, Segments and LinkMapPosition all need a real loader's
+// runtime state - where lib actually ended up mapped, and at what
+// position in the process's link map - which doesn't exist here (see
+// errLoaderUnsupported's own comment): LoadBase is reported as 0,
+// Segments as nil, and LinkMapPosition as -1. A Library from
+// OpenDefault, which has no single path to read, gets the same three
+// zero values and no SoName/Dependencies either.
+func (lib *Library) Info() (*LibraryInfo, error) {
+	info := &LibraryInfo{Path: lib.path, LinkMapPosition: -1}
+	if lib.isDefault || lib.path == "" {
+		return info, nil
+	}
+
+	f, err := elf.Open(lib.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if sonames, err := f.DynString(elf.DT_SONAME); err == nil && len(sonames) > 0 {
+		info.SoName = sonames[0]
+	}
+	deps, err := f.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return nil, err
+	}
+	info.Dependencies = deps
+	return info, nil
+}
@@ -0,0 +1,58 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import (
+	"os"
+	"testing"
+)
+
+// libcPathForInfoTest finds a real libc.so.6 to read DT_SONAME/DT_NEEDED
+// from, skipping the test instead of failing it on a system without one
+// at a known path - the same accommodation readLibcForVerifyTest
+// (library_verify_test.go) makes for the same reason.
+func libcPathForInfoTest(t *testing.T) string {
+	t.Helper()
+	for _, candidate := range []string{"/lib/x86_64-linux-gnu/libc.so.6", "/usr/lib/x86_64-linux-gnu/libc.so.6"} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	t.Skip("libc.so.6 not found at any known path")
+	return ""
+}
+
+// TestLibraryInfoReadsSoName confirms Info reports a real libc's own
+// DT_SONAME, the canonical "libc.so.6" every glibc build carries
+// regardless of its on-disk file name.
+func TestLibraryInfoReadsSoName(t *testing.T) {
+	path := libcPathForInfoTest(t)
+
+	lib := &Library{path: path}
+	info, err := lib.Info()
+	if err != nil {
+		t.Fatalf("Info: unexpected error %v", err)
+	}
+	if info.SoName != "libc.so.6" {
+		t.Fatalf("Info: SoName = %q, want %q", info.SoName, "libc.so.6")
+	}
+	if info.LoadBase != 0 || info.Segments != nil || info.LinkMapPosition != -1 {
+		t.Fatalf("Info: got LoadBase=%#x Segments=%v LinkMapPosition=%d, want the no-real-loader zero values",
+			info.LoadBase, info.Segments, info.LinkMapPosition)
+	}
+}
+
+// TestLibraryInfoDefaultLibrary confirms Info on a Library from
+// OpenDefault - no single path to read an ELF dynamic section from -
+// reports the no-real-loader zero values and no error, rather than
+// trying (and failing) to open lib.path, which is empty for it.
+func TestLibraryInfoDefaultLibrary(t *testing.T) {
+	lib := &Library{isDefault: true}
+	info, err := lib.Info()
+	if err != nil {
+		t.Fatalf("Info: unexpected error %v", err)
+	}
+	if info.SoName != "" || info.Dependencies != nil {
+		t.Fatalf("Info: got SoName=%q Dependencies=%v, want both empty for OpenDefault", info.SoName, info.Dependencies)
+	}
+}
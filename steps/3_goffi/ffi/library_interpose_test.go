@@ -0,0 +1,54 @@
+package ffi
+
+import "testing"
+
+// TestLibraryGetUsesInterposedAddr confirms Get returns Interpose's
+// registered address instead of even trying the real lookup - lib's
+// zero handle would otherwise make getProcAddress fail, the same trick
+// TestLibraryGetOrStubMissing (library_stub_test.go) uses to exercise
+// Get without a real loaded library.
+func TestLibraryGetUsesInterposedAddr(t *testing.T) {
+	const symbol = "ffi_synth144_interposed_symbol"
+	Interpose(symbol, 0xdeadbeef)
+	defer Uninterpose(symbol)
+
+	lib := &Library{}
+	addr, err := lib.Get(symbol)
+	if err != nil {
+		t.Fatalf("Get: unexpected error %v", err)
+	}
+	if addr != 0xdeadbeef {
+		t.Fatalf("Get: got addr=%#x, want 0xdeadbeef", addr)
+	}
+}
+
+// TestLibraryGetVersionedUsesInterposedAddr is
+// TestLibraryGetUsesInterposedAddr for GetVersioned.
+func TestLibraryGetVersionedUsesInterposedAddr(t *testing.T) {
+	const symbol = "ffi_synth144_interposed_versioned_symbol"
+	Interpose(symbol, 0xfeedface)
+	defer Uninterpose(symbol)
+
+	lib := &Library{}
+	addr, err := lib.GetVersioned(symbol, "GLIBC_2.3")
+	if err != nil {
+		t.Fatalf("GetVersioned: unexpected error %v", err)
+	}
+	if addr != 0xfeedface {
+		t.Fatalf("GetVersioned: got addr=%#x, want 0xfeedface", addr)
+	}
+}
+
+// TestLibraryUninterposeRestoresRealLookup confirms Uninterpose makes a
+// later Get fall back to the real lookup (and therefore fail, with a
+// zero-handle Library) again.
+func TestLibraryUninterposeRestoresRealLookup(t *testing.T) {
+	const symbol = "ffi_synth144_uninterposed_symbol"
+	Interpose(symbol, 0x1)
+	Uninterpose(symbol)
+
+	lib := &Library{}
+	if _, err := lib.Get(symbol); err == nil {
+		t.Fatalf("Get: want an error after Uninterpose, got nil")
+	}
+}
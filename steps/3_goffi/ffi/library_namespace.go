@@ -0,0 +1,89 @@
+package ffi
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Namespace is a dlmopen-style private link namespace: each Namespace
+// keeps its own path-keyed cache of opened Libraries, the same
+// deduplication OpenShared/CloseShared (library_refcount.go) give
+// globally, but independent of every other Namespace's cache and of the
+// package-wide OpenShared registry - so two Namespaces can each Open the
+// same path and get distinct *Library values rather than sharing one the
+// way two OpenShared(path) calls would.
+//
+// This is namespace isolation only in the sense of which Open calls get
+// deduplicated against which, not the symbol-scope isolation a real
+// dlmopen(LM_ID_NEWLM, ...) gives two conflicting versions of one
+// library loaded into separate namespaces: with no real loader on this
+// platform yet (loader_unsupported.go) to even have a GOT to isolate,
+// and darwin/windows dlopen/LoadLibrary each already deduplicating a
+// given path to one process-wide mapping with no namespace concept to
+// route around that, a Namespace's two Libraries for the same path can
+// still end up resolving through the exact same underlying mapping on
+// those platforms. Use Namespace to keep track of which Library belongs
+// to which logical namespace; don't rely on it to make two ABI-
+// incompatible versions of one .so safe to load side by side.
+type Namespace struct {
+	mu   sync.Mutex
+	libs map[string]*libraryRef
+}
+
+// NewNamespace returns an empty Namespace.
+func NewNamespace() *Namespace {
+	return &Namespace{libs: map[string]*libraryRef{}}
+}
+
+// Open is OpenShared, scoped to ns instead of the package-wide registry:
+// a later Open(path) on ns returns the Library an earlier Open(path) on
+// the same ns resolved, without being deduplicated against (or
+// deduplicating) any Open/OpenShared call made directly or through a
+// different Namespace.
+func (ns *Namespace) Open(path string) (*Library, error) {
+	return ns.open(path, func() (*Library, error) { return Open(path) })
+}
+
+// open is Open with the actual Open call factored out, for the same
+// testing reason openShared (library_refcount.go) factors it out.
+func (ns *Namespace) open(key string, open func() (*Library, error)) (*Library, error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	if ref, ok := ns.libs[key]; ok {
+		ref.count++
+		return ref.lib, nil
+	}
+
+	lib, err := open()
+	if err != nil {
+		return nil, err
+	}
+	ns.libs[key] = &libraryRef{lib: lib, count: 1}
+	return lib, nil
+}
+
+// Close releases one reference to the Library ns.Open(path) returned,
+// calling Library.Close on it only once every Open(path) call on ns has
+// had its own reference released.
+func (ns *Namespace) Close(path string) error {
+	return ns.close(path, func(lib *Library) error { return lib.Close() })
+}
+
+// close is Close with the actual Close call factored out, for the same
+// testing reason closeShared (library_refcount.go) factors it out.
+func (ns *Namespace) close(key string, closeFn func(*Library) error) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	ref, ok := ns.libs[key]
+	if !ok {
+		return fmt.Errorf("ffi: Namespace.Close: %q was never opened on this namespace, or is already fully closed", key)
+	}
+	ref.count--
+	if ref.count > 0 {
+		return nil
+	}
+	delete(ns.libs, key)
+	return closeFn(ref.lib)
+}
@@ -0,0 +1,96 @@
+package ffi
+
+import "testing"
+
+// TestNamespaceOpenDedups confirms a second open for the same key on one
+// Namespace returns the first call's Library without calling open again.
+func TestNamespaceOpenDedups(t *testing.T) {
+	ns := NewNamespace()
+	opens := 0
+	open := func() (*Library, error) {
+		opens++
+		return &Library{handle: uintptr(opens)}, nil
+	}
+
+	lib1, err := ns.open("libfoo.so", open)
+	if err != nil {
+		t.Fatalf("ns.open: unexpected error: %v", err)
+	}
+	lib2, err := ns.open("libfoo.so", open)
+	if err != nil {
+		t.Fatalf("ns.open: unexpected error: %v", err)
+	}
+	if lib1 != lib2 {
+		t.Fatal("ns.open: second call returned a different *Library")
+	}
+	if opens != 1 {
+		t.Fatalf("open was called %d times, want 1", opens)
+	}
+}
+
+// TestNamespacesAreIndependent confirms two Namespaces opening the same
+// key each get their own Library, neither deduplicated against the
+// other.
+func TestNamespacesAreIndependent(t *testing.T) {
+	ns1, ns2 := NewNamespace(), NewNamespace()
+	opens := 0
+	open := func() (*Library, error) {
+		opens++
+		return &Library{handle: uintptr(opens)}, nil
+	}
+
+	lib1, err := ns1.open("libfoo.so", open)
+	if err != nil {
+		t.Fatalf("ns1.open: unexpected error: %v", err)
+	}
+	lib2, err := ns2.open("libfoo.so", open)
+	if err != nil {
+		t.Fatalf("ns2.open: unexpected error: %v", err)
+	}
+	if lib1 == lib2 {
+		t.Fatal("two Namespaces shared one Library for the same key")
+	}
+	if opens != 2 {
+		t.Fatalf("open was called %d times, want 2", opens)
+	}
+}
+
+// TestNamespaceCloseRefCounting confirms N opens on one Namespace need N
+// closes before the underlying Library is actually closed, and that a
+// sibling Namespace's reference count is unaffected.
+func TestNamespaceCloseRefCounting(t *testing.T) {
+	ns := NewNamespace()
+	open := func() (*Library, error) { return &Library{}, nil }
+	closes := 0
+	closeFn := func(*Library) error { closes++; return nil }
+
+	const n = 3
+	for i := 0; i < n; i++ {
+		if _, err := ns.open("libfoo.so", open); err != nil {
+			t.Fatalf("ns.open: unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < n-1; i++ {
+		if err := ns.close("libfoo.so", closeFn); err != nil {
+			t.Fatalf("ns.close: unexpected error: %v", err)
+		}
+		if closes != 0 {
+			t.Fatalf("ns.close closed early, after %d of %d releases", i+1, n)
+		}
+	}
+	if err := ns.close("libfoo.so", closeFn); err != nil {
+		t.Fatalf("ns.close: unexpected error on final release: %v", err)
+	}
+	if closes != 1 {
+		t.Fatalf("close was called %d times, want 1", closes)
+	}
+}
+
+// TestNamespaceCloseUnknownKey confirms Close rejects a key with no
+// outstanding references on ns instead of silently succeeding.
+func TestNamespaceCloseUnknownKey(t *testing.T) {
+	ns := NewNamespace()
+	if err := ns.close("libfoo.so", func(*Library) error { return nil }); err == nil {
+		t.Fatal("ns.close: expected an error for a key never opened")
+	}
+}
@@ -0,0 +1,89 @@
+package ffi
+
+import (
+	"fmt"
+	"sync"
+)
+
+// libraryRef tracks one path's outstanding OpenShared count, along with
+// the *Library its first OpenShared call resolved.
+type libraryRef struct {
+	lib   *Library
+	count int
+}
+
+var libraryRefs = struct {
+	mu   sync.Mutex
+	refs map[string]*libraryRef
+}{refs: map[string]*libraryRef{}}
+
+// OpenShared is Open, but deduplicated by the file path actually refers
+// to - its libraryCacheKey, not the literal string - so two different
+// paths that name the same file (one reached through a symlink, one a
+// relative path, one a second hardlink) dedup exactly like two identical
+// calls do: as long as at least one Library a previous OpenShared call
+// for that file hasn't been closed yet via CloseShared, a later
+// OpenShared for the same file returns that same *Library instead of
+// calling Open (and loadLibrary) again - the same way a real dlopen
+// hands back the library's existing handle, and bumps its own internal
+// refcount, rather than mapping a second copy of a shared object that's
+// already loaded and duplicating its relocations.
+//
+// Every successful OpenShared must be matched by exactly one CloseShared
+// for an equivalent path (not necessarily the identical string - any
+// path libraryCacheKey resolves to the same file works); Library.Close
+// itself bypasses the count and closes unconditionally, so mixing the
+// two on one file will close it out from under any other OpenShared
+// caller still holding it.
+func OpenShared(path string) (*Library, error) {
+	return openShared(libraryCacheKey(path), func() (*Library, error) { return Open(path) })
+}
+
+// openShared is OpenShared with the actual Open call factored out, so
+// the reference-counting logic can be exercised with a fake open - one
+// that doesn't need a real shared library on disk to succeed or fail on
+// cue - instead of the real loader.
+func openShared(key string, open func() (*Library, error)) (*Library, error) {
+	libraryRefs.mu.Lock()
+	defer libraryRefs.mu.Unlock()
+
+	if ref, ok := libraryRefs.refs[key]; ok {
+		ref.count++
+		return ref.lib, nil
+	}
+
+	lib, err := open()
+	if err != nil {
+		return nil, err
+	}
+	libraryRefs.refs[key] = &libraryRef{lib: lib, count: 1}
+	return lib, nil
+}
+
+// CloseShared releases one reference to the Library an equivalent-path
+// OpenShared call returned, calling Library.Close on it only once every
+// such caller has released its own reference. It returns an error if
+// path's libraryCacheKey has no outstanding OpenShared references to
+// release - it (or an equivalent path resolving to the same file) was
+// never opened via OpenShared, or already fully closed.
+func CloseShared(path string) error {
+	return closeShared(libraryCacheKey(path), func(lib *Library) error { return lib.Close() })
+}
+
+// closeShared is CloseShared with the actual Close call factored out,
+// for the same testing reason openShared factors out Open.
+func closeShared(key string, closeFn func(*Library) error) error {
+	libraryRefs.mu.Lock()
+	defer libraryRefs.mu.Unlock()
+
+	ref, ok := libraryRefs.refs[key]
+	if !ok {
+		return fmt.Errorf("ffi: CloseShared: %q was never opened via OpenShared, or is already fully closed", key)
+	}
+	ref.count--
+	if ref.count > 0 {
+		return nil
+	}
+	delete(libraryRefs.refs, key)
+	return closeFn(ref.lib)
+}
@@ -0,0 +1,113 @@
+package ffi
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestOpenSharedDedups confirms a second openShared for the same key
+// returns the first call's Library without calling open again.
+func TestOpenSharedDedups(t *testing.T) {
+	const key = "library_refcount_test.TestOpenSharedDedups"
+	opens := 0
+	open := func() (*Library, error) {
+		opens++
+		return &Library{handle: uintptr(opens)}, nil
+	}
+
+	lib1, err := openShared(key, open)
+	if err != nil {
+		t.Fatalf("openShared: unexpected error: %v", err)
+	}
+	lib2, err := openShared(key, open)
+	if err != nil {
+		t.Fatalf("openShared: unexpected error: %v", err)
+	}
+
+	if lib1 != lib2 {
+		t.Fatalf("openShared: second call returned a different *Library")
+	}
+	if opens != 1 {
+		t.Fatalf("open was called %d times, want 1", opens)
+	}
+
+	closes := 0
+	closeFn := func(*Library) error { closes++; return nil }
+	closeShared(key, closeFn)
+	closeShared(key, closeFn)
+	if closes != 1 {
+		t.Fatalf("close was called %d times, want 1", closes)
+	}
+}
+
+// TestOpenSharedFailurePropagates confirms a failing open isn't cached:
+// the count never increments past a failed attempt, so a later
+// openShared call tries open again instead of replaying the error.
+func TestOpenSharedFailurePropagates(t *testing.T) {
+	const key = "library_refcount_test.TestOpenSharedFailurePropagates"
+	wantErr := fmt.Errorf("boom")
+	attempts := 0
+	open := func() (*Library, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, wantErr
+		}
+		return &Library{}, nil
+	}
+
+	if _, err := openShared(key, open); err != wantErr {
+		t.Fatalf("openShared: got err %v, want %v", err, wantErr)
+	}
+
+	lib, err := openShared(key, open)
+	if err != nil {
+		t.Fatalf("openShared: unexpected error on retry: %v", err)
+	}
+	if lib == nil {
+		t.Fatal("openShared: expected a Library on the successful retry")
+	}
+	if attempts != 2 {
+		t.Fatalf("open was attempted %d times, want 2", attempts)
+	}
+
+	closeShared(key, func(*Library) error { return nil })
+}
+
+// TestCloseSharedUnknownKey confirms closeShared rejects a key with no
+// outstanding references instead of silently succeeding.
+func TestCloseSharedUnknownKey(t *testing.T) {
+	const key = "library_refcount_test.TestCloseSharedUnknownKey"
+	if err := closeShared(key, func(*Library) error { return nil }); err == nil {
+		t.Fatal("closeShared: expected an error for a key never opened")
+	}
+}
+
+// TestOpenSharedRefCounting confirms N openShared calls need N
+// closeShared calls before the underlying Library is actually closed.
+func TestOpenSharedRefCounting(t *testing.T) {
+	const key = "library_refcount_test.TestOpenSharedRefCounting"
+	open := func() (*Library, error) { return &Library{}, nil }
+	closes := 0
+	closeFn := func(*Library) error { closes++; return nil }
+
+	const n = 3
+	for i := 0; i < n; i++ {
+		if _, err := openShared(key, open); err != nil {
+			t.Fatalf("openShared: unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < n-1; i++ {
+		if err := closeShared(key, closeFn); err != nil {
+			t.Fatalf("closeShared: unexpected error: %v", err)
+		}
+		if closes != 0 {
+			t.Fatalf("closeShared closed early, after %d of %d releases", i+1, n)
+		}
+	}
+	if err := closeShared(key, closeFn); err != nil {
+		t.Fatalf("closeShared: unexpected error on final release: %v", err)
+	}
+	if closes != 1 {
+		t.Fatalf("close was called %d times, want 1", closes)
+	}
+}
@@ -0,0 +1,78 @@
+package ffi
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// errReloadDefault is wrapped into the error Reload returns for a
+// Library from OpenDefault: there is no single on-disk path backing the
+// RTLD_DEFAULT namespace to reopen.
+var errReloadDefault = fmt.Errorf("a Library from OpenDefault has no path to reopen")
+
+// libraryBinding is one (name, resolve) pair RegisterFunc recorded
+// against a *Library, so Reload knows which registry entries to force
+// back into an unresolved state once it has reopened that library.
+type libraryBinding struct {
+	name    string
+	resolve func() (Spec, error)
+}
+
+// libraryBindings tracks every libraryBinding RegisterFunc has recorded,
+// keyed by the *Library each one was resolved through.
+var libraryBindings = struct {
+	mu    sync.Mutex
+	byLib map[*Library][]libraryBinding
+}{byLib: map[*Library][]libraryBinding{}}
+
+// trackLibraryBinding records that name resolves via resolve against
+// lib, for Reload's benefit - see RegisterFunc, its only caller.
+func trackLibraryBinding(lib *Library, name string, resolve func() (Spec, error)) {
+	libraryBindings.mu.Lock()
+	libraryBindings.byLib[lib] = append(libraryBindings.byLib[lib], libraryBinding{name: name, resolve: resolve})
+	libraryBindings.mu.Unlock()
+}
+
+// Reload re-opens lib's underlying shared object at the same path and
+// flags Open/OpenWithFlags originally used, atomically swaps lib's
+// handle for the new one, and closes the old one - then forces every
+// registry entry RegisterFunc has associated with lib to re-resolve its
+// Spec the next time it's looked up, so a freshly built plugin .so can
+// be swapped into a long-running process without restarting it or
+// recompiling the callers that reach it through Lookup/LookupFunc.
+//
+// Reload cannot help a Spec or Func a caller already obtained and is
+// holding directly - via Bind, MakeSpec, BindFunc, or a Lookup/
+// LookupFunc call made before Reload ran. Those already carry the old
+// handle's resolved fn address as a plain value; calling through one
+// after Reload has closed the old .so calls into memory that is no
+// longer mapped. Only names looked up through the registry after Reload
+// returns see the new address - code that must survive a reload should
+// go through Lookup/LookupFunc each time rather than caching the result.
+//
+// Reload fails for a Library from OpenDefault, which has no single path
+// to reopen.
+func (lib *Library) Reload() error {
+	if lib.isDefault {
+		return fmt.Errorf("ffi: Reload: %w", errReloadDefault)
+	}
+	if lib.path == "" {
+		return fmt.Errorf("ffi: Reload: library was not opened via Open/OpenWithFlags, nothing to reopen")
+	}
+
+	newHandle, err := loadLibrary(lib.path, lib.flags)
+	if err != nil {
+		return fmt.Errorf("ffi: Reload: %w", err)
+	}
+	oldHandle := atomic.SwapUintptr(&lib.handle, newHandle)
+
+	libraryBindings.mu.Lock()
+	bindings := append([]libraryBinding(nil), libraryBindings.byLib[lib]...)
+	libraryBindings.mu.Unlock()
+	for _, b := range bindings {
+		Register(b.name, b.resolve)
+	}
+
+	return closeLibrary(oldHandle)
+}
@@ -0,0 +1,82 @@
+//go:build !windows
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// openLibcForReloadTest opens libc.so.6 (or libSystem.B.dylib on darwin),
+// skipping the test instead of failing it on a system without one at the
+// usual path - the same accommodation libc/libc_test.go makes for the
+// same reason.
+func openLibcForReloadTest(t *testing.T) *Library {
+	t.Helper()
+	for _, path := range []string{"libc.so.6", "/lib/x86_64-linux-gnu/libc.so.6", "libSystem.B.dylib"} {
+		if lib, err := Open(path); err == nil {
+			return lib
+		}
+	}
+	t.Skip("libc not found at any known path")
+	return nil
+}
+
+type reloadAbsArgs struct {
+	N int32 `ffi:"arg"`
+	R int32 `ffi:"ret"`
+}
+
+// TestLibraryReloadRebindsRegisteredSymbols confirms Reload reopens the
+// library, leaves it in a working state, and forces a name registered
+// via RegisterFunc to re-resolve rather than keep serving the Spec
+// cached before Reload ran.
+func TestLibraryReloadRebindsRegisteredSymbols(t *testing.T) {
+	lib := openLibcForReloadTest(t)
+	defer lib.Close()
+
+	const name = "library_reload_test.abs"
+	RegisterFunc[reloadAbsArgs](name, lib, "abs")
+
+	spec, err := Lookup(name)
+	if err != nil {
+		t.Fatalf("Lookup: unexpected error: %v", err)
+	}
+	args := reloadAbsArgs{N: -7}
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 7 {
+		t.Fatalf("abs(-7) = %d, want 7", args.R)
+	}
+
+	if err := lib.Reload(); err != nil {
+		t.Fatalf("Reload: unexpected error: %v", err)
+	}
+
+	spec2, err := Lookup(name)
+	if err != nil {
+		t.Fatalf("Lookup after Reload: unexpected error: %v", err)
+	}
+	args2 := reloadAbsArgs{N: -9}
+	spec2.Call(unsafe.Pointer(&args2))
+	if args2.R != 9 {
+		t.Fatalf("abs(-9) = %d, want 9 after Reload", args2.R)
+	}
+}
+
+// TestLibraryReloadRejectsDefault confirms Reload refuses a Library from
+// OpenDefault, which has no single path to reopen.
+func TestLibraryReloadRejectsDefault(t *testing.T) {
+	lib := &Library{isDefault: true}
+	if err := lib.Reload(); err == nil {
+		t.Fatal("Reload: expected an error for a default Library, got nil")
+	}
+}
+
+// TestLibraryReloadRejectsUnknownPath confirms Reload refuses a Library
+// that wasn't built via Open/OpenWithFlags and so has no path recorded.
+func TestLibraryReloadRejectsUnknownPath(t *testing.T) {
+	lib := &Library{}
+	if err := lib.Reload(); err == nil {
+		t.Fatal("Reload: expected an error for a Library with no path, got nil")
+	}
+}
@@ -0,0 +1,17 @@
+package ffi
+
+import "testing"
+
+// TestLibraryGetOrStubMissing confirms GetOrStub reports found=false
+// for a symbol no loaded library actually defines, rather than
+// surfacing Get's error.
+func TestLibraryGetOrStubMissing(t *testing.T) {
+	lib := &Library{}
+	addr, found := lib.GetOrStub("ffi_synth78_bogus_symbol_does_not_exist")
+	if found {
+		t.Fatalf("GetOrStub: got found=true, addr=%#x, want found=false", addr)
+	}
+	if addr != 0 {
+		t.Fatalf("GetOrStub: got addr=%#x, want 0", addr)
+	}
+}
@@ -0,0 +1,32 @@
+package ffi
+
+import "errors"
+
+// SymbolInfo describes one entry a dynamic symbol table lists for a
+// loaded library - what Library.Symbols reports for each symbol it can
+// enumerate, once something in this package is actually able to.
+type SymbolInfo struct {
+	Name    string
+	Address uintptr
+	Size    uint64
+	Version string
+}
+
+// errSymbolsUnsupported is returned by Symbols on every platform this
+// package currently has a loader for: none of them parse a loaded
+// object's own symbol table today, only resolve one name at a time
+// through it via Get/GetVersioned. dlsym - real, on darwin, or the Win32
+// GetProcAddress it mirrors on windows - doesn't provide enumeration
+// either; that needs picking apart the object file's own format (ELF's
+// .dynsym, Mach-O's symtab load command, or PE's export directory)
+// directly, which no loader here does yet.
+var errSymbolsUnsupported = errors.New("ffi: Library.Symbols is not implemented on this platform yet")
+
+// Symbols is meant to expose lib's exported function and data symbols -
+// name, address, size, and version - for plugin discovery, and for
+// debugging a "symbol not found" Get/GetVersioned error against what the
+// library actually exports. It returns errSymbolsUnsupported
+// unconditionally for now; see that var's doc comment for why.
+func (lib *Library) Symbols() ([]SymbolInfo, error) {
+	return nil, errSymbolsUnsupported
+}
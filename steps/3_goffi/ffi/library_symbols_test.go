@@ -0,0 +1,18 @@
+package ffi
+
+import "testing"
+
+// TestLibrarySymbolsUnsupported confirms Symbols reports the honest
+// "not implemented yet" error rather than silently returning an empty
+// result a caller might mistake for "this library really has no
+// symbols".
+func TestLibrarySymbolsUnsupported(t *testing.T) {
+	lib := &Library{}
+	syms, err := lib.Symbols()
+	if err == nil {
+		t.Fatal("Symbols: expected an error, got nil")
+	}
+	if syms != nil {
+		t.Fatalf("Symbols: got %v, want nil", syms)
+	}
+}
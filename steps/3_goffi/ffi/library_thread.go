@@ -0,0 +1,90 @@
+package ffi
+
+import "runtime"
+
+// ThreadedLibrary marshals calls onto one dedicated OS thread, for a C
+// library (thread-local state, an OpenGL context bound to whichever
+// thread created it) that requires every call to originate from the
+// same thread rather than just "some goroutine or other" the way an
+// ordinary Spec.Call does. Build one with Library.Thread.
+type ThreadedLibrary struct {
+	lib  *Library
+	jobs chan func()
+	quit chan struct{}
+}
+
+// Thread starts a dedicated OS thread for lib and returns a
+// ThreadedLibrary that runs every job handed to Call on it, in the order
+// they arrive. The thread runs until Close.
+func (lib *Library) Thread() *ThreadedLibrary {
+	t := &ThreadedLibrary{
+		lib:  lib,
+		jobs: make(chan func()),
+		quit: make(chan struct{}),
+	}
+	go t.loop(ThreadAttrs{}, nil)
+	return t
+}
+
+// ThreadWithAttrs is Thread, but applying attrs (thread name, scheduling
+// policy/priority - see ThreadAttrs's own doc comment for what isn't
+// covered) to the dedicated thread before it runs any jobs. It blocks
+// until attrs has actually been applied, returning an error instead of
+// a *ThreadedLibrary if that failed - e.g. a realtime Policy without
+// CAP_SYS_NICE - so a caller never ends up with a ThreadedLibrary whose
+// thread silently isn't what it asked for.
+func (lib *Library) ThreadWithAttrs(attrs ThreadAttrs) (*ThreadedLibrary, error) {
+	t := &ThreadedLibrary{
+		lib:  lib,
+		jobs: make(chan func()),
+		quit: make(chan struct{}),
+	}
+	errCh := make(chan error, 1)
+	go t.loop(attrs, errCh)
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *ThreadedLibrary) loop(attrs ThreadAttrs, attrsErr chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if attrsErr != nil {
+		attrsErr <- ApplyThreadAttrs(attrs)
+	}
+	for {
+		select {
+		case job := <-t.jobs:
+			job()
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// Call runs fn on the ThreadedLibrary's dedicated OS thread and blocks
+// until it returns. fn is typically a closure around Spec.Call for a
+// Spec built against t.Library()'s symbols, so the C library sees every
+// one of those calls arrive from the same thread no matter which
+// goroutine called Call.
+func (t *ThreadedLibrary) Call(fn func()) {
+	done := make(chan struct{})
+	t.jobs <- func() {
+		fn()
+		close(done)
+	}
+	<-done
+}
+
+// Library returns the Library whose calls this ThreadedLibrary pins to
+// its dedicated thread.
+func (t *ThreadedLibrary) Library() *Library {
+	return t.lib
+}
+
+// Close stops the dedicated thread. Any Call issued afterward blocks
+// forever, since nothing is left to receive it.
+func (t *ThreadedLibrary) Close() {
+	close(t.quit)
+}
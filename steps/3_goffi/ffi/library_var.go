@@ -0,0 +1,64 @@
+package ffi
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Var resolves the data symbol name within lib - e.g. "stdout" or
+// "environ" - and copies sizeof(*out) bytes from its storage into out,
+// giving the C global's current value at the moment Var was called. Get
+// already resolves a data symbol's address just as well as a function
+// symbol's, but it hands back a bare uintptr and leaves reading it
+// safely - picking the right width, not copying through a Go pointer
+// field as if it were ordinary Go-managed memory - entirely to the
+// caller; Var does both in one call for the common case of a scalar or
+// pointer-shaped global.
+//
+// out must be a non-nil pointer to one of the kinds fieldToOffset (see
+// ffi.go) already classifies as a plain fixed-width value: an integer,
+// float, or pointer/uintptr kind. dlsym/GetProcAddress hand back an
+// address with no type attached, so Var has nothing to check that size
+// against except out's own Go type - but that's still worth checking: a
+// struct, slice, or other aggregate out would have Var blindly memcpy
+// raw C bytes over Go-managed memory that may itself hold pointers,
+// slice headers, or other Go-runtime-interpreted bits, corrupting
+// whatever out pointed to in a way nothing would catch until later. Var
+// rejects that kind of out up front instead.
+//
+// Var reads the global once; it does not track further changes the C
+// side makes to it afterward. Call it again to see a fresh value.
+func (lib *Library) Var(name string, out interface{}) error {
+	addr, err := lib.Get(name)
+	if err != nil {
+		return err
+	}
+	return readVar(addr, out)
+}
+
+// readVar does the actual validation and copy behind Var, taking the
+// already-resolved address directly so it can be exercised without a
+// real Library to resolve a symbol through.
+func readVar(addr uintptr, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("ffi: Var: out must be a non-nil pointer, got %T", out)
+	}
+	elem := v.Elem()
+	switch elem.Kind() {
+	case reflect.Int8, reflect.Uint8, reflect.Int16, reflect.Uint16,
+		reflect.Int32, reflect.Uint32, reflect.Int64, reflect.Uint64,
+		reflect.Int, reflect.Uint, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Ptr, reflect.UnsafePointer:
+	default:
+		return fmt.Errorf("ffi: Var: out points to unsupported kind %s", elem.Kind())
+	}
+
+	size := elem.Type().Size()
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(elem.UnsafeAddr())), size)
+	src := unsafe.Slice((*byte)(unsafe.Pointer(addr)), size)
+	copy(dst, src)
+	return nil
+}
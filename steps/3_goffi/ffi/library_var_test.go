@@ -0,0 +1,61 @@
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestReadVarScalar confirms readVar copies a scalar global's current
+// bytes into out, using a plain Go variable's own address as a stand-in
+// for a resolved data symbol's.
+func TestReadVarScalar(t *testing.T) {
+	global := int32(42)
+	addr := uintptr(unsafe.Pointer(&global))
+
+	var out int32
+	if err := readVar(addr, &out); err != nil {
+		t.Fatalf("readVar: unexpected error: %v", err)
+	}
+	if out != 42 {
+		t.Fatalf("readVar: out = %d, want 42", out)
+	}
+}
+
+// TestReadVarPointer confirms readVar works for a pointer-shaped global
+// like stdout or environ, the motivating case.
+func TestReadVarPointer(t *testing.T) {
+	target := int64(7)
+	global := unsafe.Pointer(&target)
+	addr := uintptr(unsafe.Pointer(&global))
+
+	var out unsafe.Pointer
+	if err := readVar(addr, &out); err != nil {
+		t.Fatalf("readVar: unexpected error: %v", err)
+	}
+	if out != global {
+		t.Fatalf("readVar: out = %v, want %v", out, global)
+	}
+}
+
+// TestReadVarNotPointer confirms readVar rejects a non-pointer out
+// rather than panicking inside reflect.
+func TestReadVarNotPointer(t *testing.T) {
+	var out int32
+	if err := readVar(0, out); err == nil {
+		t.Fatal("readVar: expected an error for a non-pointer out")
+	}
+}
+
+// TestReadVarUnsupportedKind confirms readVar rejects an out whose
+// pointed-to type isn't one of the plain fixed-width kinds it knows how
+// to copy safely - a struct, in this case, which could itself hold Go
+// pointers readVar has no business overwriting with raw C bytes.
+func TestReadVarUnsupportedKind(t *testing.T) {
+	type aggregate struct {
+		A, B int64
+	}
+	var out aggregate
+	if err := readVar(0, &out); err == nil {
+		t.Fatal("readVar: expected an error for a struct out")
+	}
+}
@@ -0,0 +1,78 @@
+package ffi
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"os"
+)
+
+// ErrChecksumMismatch is returned by a ChecksumSHA256 verify func when
+// the library's contents don't hash to the expected sum.
+var ErrChecksumMismatch = fmt.Errorf("ffi: library contents did not match the expected checksum")
+
+// ErrSignatureInvalid is returned by a SignatureEd25519 verify func when
+// the signature doesn't verify against the library's contents.
+var ErrSignatureInvalid = fmt.Errorf("ffi: library signature did not verify")
+
+// ChecksumSHA256 returns a verify func for OpenFileChecked/OpenBytesChecked
+// that accepts a library's contents only if their SHA-256 sum equals
+// want, returning ErrChecksumMismatch otherwise.
+func ChecksumSHA256(want [32]byte) func([]byte) error {
+	return func(b []byte) error {
+		got := sha256.Sum256(b)
+		if got != want {
+			return fmt.Errorf("%w: got %x, want %x", ErrChecksumMismatch, got, want)
+		}
+		return nil
+	}
+}
+
+// SignatureEd25519 returns a verify func for OpenFileChecked/OpenBytesChecked
+// that accepts a library's contents only if sig is a valid Ed25519
+// detached signature of them under pub, returning ErrSignatureInvalid
+// otherwise.
+func SignatureEd25519(pub ed25519.PublicKey, sig []byte) func([]byte) error {
+	return func(b []byte) error {
+		if !ed25519.Verify(pub, b, sig) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}
+}
+
+// OpenFileChecked is OpenWithFlags, but verify runs against the file's
+// full contents - read once up front - before the file is ever mapped
+// executable, for loading a plugin from a semi-trusted location: a
+// ChecksumSHA256 or SignatureEd25519 verify func catches a tampered or
+// wrong file before dlopen/LoadLibrary ever touches it, rather than
+// leaving the caller to find out only once a call into it crashes or
+// misbehaves.
+//
+// This reads and hashes the whole file separately from the load itself,
+// so it isn't watertight against the file changing on disk between the
+// read and the subsequent loadLibrary call (a TOCTOU window any
+// path-based verify-then-load has); OpenBytesChecked avoids that window
+// entirely for a library the caller already has in memory.
+func OpenFileChecked(path string, verify func([]byte) error, flags OpenFlags) (*Library, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(b); err != nil {
+		return nil, err
+	}
+	return OpenWithFlags(path, flags)
+}
+
+// OpenBytesChecked is OpenFromBytesWithFlags, but verify runs against b
+// before it's ever written to the temporary file OpenFromBytes maps -
+// the same use case as OpenFileChecked, without that function's
+// read-then-load TOCTOU window, since b never touches disk until after
+// verify has already accepted it.
+func OpenBytesChecked(b []byte, verify func([]byte) error, flags OpenFlags) (*Library, error) {
+	if err := verify(b); err != nil {
+		return nil, err
+	}
+	return OpenFromBytesWithFlags(b, flags)
+}
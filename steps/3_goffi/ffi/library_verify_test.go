@@ -0,0 +1,65 @@
+package ffi
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"testing"
+)
+
+// readLibcForVerifyTest reads libc.so.6's bytes, skipping the test
+// instead of failing it on a system without one at the usual path - the
+// same accommodation library_reload_test.go's openLibcForReloadTest
+// makes for the same reason.
+func readLibcForVerifyTest(t *testing.T) (path string, b []byte) {
+	t.Helper()
+	for _, candidate := range []string{"/lib/x86_64-linux-gnu/libc.so.6", "/usr/lib/x86_64-linux-gnu/libc.so.6"} {
+		if data, err := os.ReadFile(candidate); err == nil {
+			return candidate, data
+		}
+	}
+	t.Skip("libc.so.6 not found at any known path")
+	return "", nil
+}
+
+func TestOpenFileCheckedSHA256(t *testing.T) {
+	path, b := readLibcForVerifyTest(t)
+	sum := sha256.Sum256(b)
+
+	lib, err := OpenFileChecked(path, ChecksumSHA256(sum), RTLD_NOW|RTLD_LOCAL)
+	if err != nil {
+		t.Fatalf("OpenFileChecked: unexpected error: %v", err)
+	}
+	lib.Close()
+
+	var wrongSum [32]byte
+	copy(wrongSum[:], sum[:])
+	wrongSum[0] ^= 0xff
+	if _, err := OpenFileChecked(path, ChecksumSHA256(wrongSum), RTLD_NOW|RTLD_LOCAL); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("OpenFileChecked: got %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestOpenBytesCheckedSignature(t *testing.T) {
+	_, b := readLibcForVerifyTest(t)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	sig := ed25519.Sign(priv, b)
+
+	lib, err := OpenBytesChecked(b, SignatureEd25519(pub, sig), RTLD_NOW|RTLD_LOCAL)
+	if err != nil {
+		t.Fatalf("OpenBytesChecked: unexpected error: %v", err)
+	}
+	lib.Close()
+
+	tampered := append([]byte(nil), b...)
+	tampered[0] ^= 0xff
+	if _, err := OpenBytesChecked(tampered, SignatureEd25519(pub, sig), RTLD_NOW|RTLD_LOCAL); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("OpenBytesChecked: got %v, want ErrSignatureInvalid", err)
+	}
+}
@@ -0,0 +1,39 @@
+package ffi
+
+import "testing"
+
+// TestLibraryGetOptionalMissing confirms GetOptional reports ok=false
+// for a symbol no loaded library actually defines, rather than
+// surfacing Get's error - the same accommodation
+// TestLibraryGetOrStubMissing (library_stub_test.go) checks for
+// GetOrStub.
+func TestLibraryGetOptionalMissing(t *testing.T) {
+	lib := &Library{}
+	addr, ok := lib.GetOptional("ffi_synth149_bogus_symbol_does_not_exist")
+	if ok {
+		t.Fatalf("GetOptional: got ok=true, addr=%#x, want ok=false", addr)
+	}
+	if addr != 0 {
+		t.Fatalf("GetOptional: got addr=%#x, want 0", addr)
+	}
+}
+
+// TestLibraryGetOptionalFound confirms GetOptional reports ok=true and
+// the real address for a symbol that does resolve - exercised here via
+// Interpose (interpose.go), the same zero-handle-Library trick
+// TestLibraryGetUsesInterposedAddr (library_interpose_test.go) uses to
+// make Get succeed with no real library loaded.
+func TestLibraryGetOptionalFound(t *testing.T) {
+	const symbol = "ffi_synth149_interposed_symbol"
+	Interpose(symbol, 0x1234)
+	defer Uninterpose(symbol)
+
+	lib := &Library{}
+	addr, ok := lib.GetOptional(symbol)
+	if !ok {
+		t.Fatal("GetOptional: got ok=false, want true")
+	}
+	if addr != 0x1234 {
+		t.Fatalf("GetOptional: got addr=%#x, want 0x1234", addr)
+	}
+}
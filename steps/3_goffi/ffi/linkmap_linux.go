@@ -0,0 +1,282 @@
+//go:build linux
+
+package ffi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+// The AT_* auxiliary vector tags parseAuxv reads - see getauxval(3) and
+// elf(5)'s AUXILIARY VECTOR section. Every Linux process gets all three
+// from the kernel at exec time, dynamically linked or not.
+const (
+	atPhdr  = 3
+	atPhent = 4
+	atPhnum = 5
+)
+
+// Elf64_Phdr's p_type values this file cares about, and Elf64_Dyn's
+// DT_DEBUG tag - see elf(5).
+const (
+	ptDynamicType = 2
+	ptPhdrType    = 6
+	dtDebugTag    = 21
+)
+
+const (
+	elf64PhdrSize = 56 // sizeof(Elf64_Phdr)
+	elf64DynSize  = 16 // sizeof(Elf64_Dyn): {int64 d_tag; uint64 d_val}
+
+	// linkMapEntrySize covers struct link_map's first three fields
+	// (l_addr, l_name, l_ld) plus l_next - the only ones
+	// findLoadedSoname needs - not the struct's full size.
+	linkMapEntrySize = 32
+
+	maxDynEntries     = 2048 // far more than any real PT_DYNAMIC has
+	maxLinkMapEntries = 4096 // defends against a corrupt/cyclic l_next chain
+	maxLinkMapNameLen = 1024 // defends against a missing NUL terminator
+)
+
+// auxvPhdrInfo is what parseAuxv extracts from /proc/self/auxv: the
+// three AT_* entries needed to locate this process's own program header
+// table, the starting point for findLoadedSoname's walk down to
+// r_debug's link map.
+type auxvPhdrInfo struct {
+	phdr  uintptr
+	phent int
+	phnum int
+}
+
+// parseAuxv decodes data - the raw contents of /proc/self/auxv, a
+// sequence of (tag uint64, value uint64) pairs terminated by an AT_NULL
+// (tag 0) entry - for AT_PHDR, AT_PHENT and AT_PHNUM. ok is false if any
+// of the three is missing; every real Linux process's auxv has all
+// three, but a truncated or malformed read shouldn't be trusted past
+// that.
+func parseAuxv(data []byte) (auxvPhdrInfo, bool) {
+	var info auxvPhdrInfo
+	var haveP, haveEnt, haveNum bool
+	for i := 0; i+16 <= len(data); i += 16 {
+		tag := binary.LittleEndian.Uint64(data[i:])
+		val := binary.LittleEndian.Uint64(data[i+8:])
+		switch tag {
+		case atPhdr:
+			info.phdr, haveP = uintptr(val), true
+		case atPhent:
+			info.phent, haveEnt = int(val), true
+		case atPhnum:
+			info.phnum, haveNum = int(val), true
+		}
+	}
+	return info, haveP && haveEnt && haveNum
+}
+
+// findDynamicSegment scans phdrs (nPhdrs entries of phentSize bytes
+// each, in Elf64_Phdr's own field layout) for the PT_PHDR entry - to
+// compute this process's load bias against auxvPhdr, the runtime
+// address the kernel already reports for the phdr table itself - and
+// the PT_DYNAMIC entry, returning PT_DYNAMIC's p_vaddr plus that bias:
+// the dynamic section's actual runtime address. ok is false if either
+// entry is missing, which is the ordinary, expected outcome for a
+// statically linked binary - the usual CGO_ENABLED=0 build this package
+// exists for - rather than something to treat as an error.
+func findDynamicSegment(phdrs []byte, nPhdrs, phentSize int, auxvPhdr uintptr) (uintptr, bool) {
+	if phentSize < elf64PhdrSize {
+		return 0, false
+	}
+
+	var loadBias uintptr
+	var dynVaddr uint64
+	haveBias, haveDyn := false, false
+
+	for i := 0; i < nPhdrs; i++ {
+		off := i * phentSize
+		if off+elf64PhdrSize > len(phdrs) {
+			break
+		}
+		typ := binary.LittleEndian.Uint32(phdrs[off:])
+		vaddr := binary.LittleEndian.Uint64(phdrs[off+16:])
+		switch typ {
+		case ptPhdrType:
+			loadBias = auxvPhdr - uintptr(vaddr)
+			haveBias = true
+		case ptDynamicType:
+			dynVaddr = vaddr
+			haveDyn = true
+		}
+	}
+
+	if !haveBias || !haveDyn {
+		return 0, false
+	}
+	return uintptr(dynVaddr) + loadBias, true
+}
+
+// findDebugTag scans dyn (nEntries entries of elf64DynSize bytes each,
+// in Elf64_Dyn's {int64 d_tag; uint64 d_val} layout, stopping at
+// whichever comes first: a DT_NULL tag, the real end of the dynamic
+// section, or nEntries) for DT_DEBUG, returning its d_val - the runtime
+// address of struct r_debug, which the real dynamic linker fills in
+// only once it's finished relocating this process, which has always
+// already happened by the time any Go code - including this function -
+// gets to run.
+func findDebugTag(dyn []byte, nEntries int) (uintptr, bool) {
+	for i := 0; i < nEntries; i++ {
+		off := i * elf64DynSize
+		if off+elf64DynSize > len(dyn) {
+			break
+		}
+		tag := int64(binary.LittleEndian.Uint64(dyn[off:]))
+		if tag == 0 {
+			break // DT_NULL terminates the array
+		}
+		if tag == dtDebugTag {
+			return uintptr(binary.LittleEndian.Uint64(dyn[off+8:])), true
+		}
+	}
+	return 0, false
+}
+
+// parseLinkMapNode decodes one struct link_map node's l_addr, l_name
+// and l_next fields (l_ld, at offset 16, is skipped - findLoadedSoname
+// has no use for it) out of raw, which must be at least
+// linkMapEntrySize bytes read starting at a link_map pointer.
+func parseLinkMapNode(raw []byte) (addr uintptr, namePtr uintptr, next uintptr) {
+	addr = uintptr(binary.LittleEndian.Uint64(raw[0:]))
+	namePtr = uintptr(binary.LittleEndian.Uint64(raw[8:]))
+	next = uintptr(binary.LittleEndian.Uint64(raw[24:]))
+	return
+}
+
+// readMem views n bytes starting at addr, somewhere in this same
+// process's own address space, as a []byte. It's safe to call only with
+// an addr this process's own loader - the kernel's auxv, or a pointer
+// chased from data the kernel or the real dynamic linker placed in this
+// process's memory - actually computed, never one derived from another
+// process or untrusted input.
+func readMem(addr uintptr, n int) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(addr)), n)
+}
+
+// readCString reads a NUL-terminated string starting at addr, capped at
+// maxLen bytes so a corrupt link_map entry can't walk this process's
+// memory forever looking for a terminator that was never going to
+// appear. It returns "" for a nil addr (l_name is "" for the main
+// executable's own link_map node) or no terminator found within maxLen.
+func readCString(addr uintptr, maxLen int) string {
+	if addr == 0 {
+		return ""
+	}
+	b := readMem(addr, maxLen)
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return ""
+}
+
+// LinkMapEntry is one node of the process's real r_debug link map, the
+// same list gdb's "info sharedlibrary" or dlinfo(RTLD_DI_LINKMAP)
+// reports: a library the real dynamic linker has mapped in, in its own
+// link_map order (roughly load order).
+type LinkMapEntry struct {
+	// Path is the l_name the real dynamic linker recorded for this
+	// entry - usually the path it was loaded from, empty for the main
+	// executable's own node.
+	Path string
+
+	// Base is l_addr: the load bias this entry was actually mapped at.
+	Base uintptr
+}
+
+// walkLinkMap locates this process's own r_debug (see findLoadedSoname's
+// comment for the auxv/PT_DYNAMIC/DT_DEBUG chain that takes) and reads
+// every node of its link_map into entries. ok is false with no error for
+// a statically linked process - there's no r_debug to walk, which isn't
+// a failure, just nothing here. err is only set if /proc/self/auxv
+// itself couldn't be read or parsed.
+func walkLinkMap() (entries []LinkMapEntry, ok bool, err error) {
+	auxvData, err := os.ReadFile("/proc/self/auxv")
+	if err != nil {
+		return nil, false, fmt.Errorf("ffi: walkLinkMap: reading /proc/self/auxv: %w", err)
+	}
+	info, ok := parseAuxv(auxvData)
+	if !ok {
+		return nil, false, fmt.Errorf("ffi: walkLinkMap: /proc/self/auxv has no AT_PHDR/AT_PHENT/AT_PHNUM")
+	}
+
+	dynAddr, ok := findDynamicSegment(readMem(info.phdr, info.phent*info.phnum), info.phnum, info.phent, info.phdr)
+	if !ok {
+		return nil, false, nil // statically linked: nothing to find
+	}
+
+	debugAddr, ok := findDebugTag(readMem(dynAddr, maxDynEntries*elf64DynSize), maxDynEntries)
+	if !ok || debugAddr == 0 {
+		return nil, false, nil // DT_DEBUG unfilled: ld.so hasn't run (or isn't glibc-compatible)
+	}
+
+	// struct r_debug's r_map field sits right after r_version, padded
+	// out to r_map's own 8-byte pointer alignment.
+	rMap := uintptr(binary.LittleEndian.Uint64(readMem(debugAddr+8, 8)))
+
+	for node, visited := rMap, 0; node != 0 && visited < maxLinkMapEntries; visited++ {
+		base, namePtr, next := parseLinkMapNode(readMem(node, linkMapEntrySize))
+		entries = append(entries, LinkMapEntry{Path: readCString(namePtr, maxLinkMapNameLen), Base: base})
+		node = next
+	}
+	return entries, true, nil
+}
+
+// findLoadedSoname reports whether soname (e.g. "libssl.so.3") is
+// already mapped into this process by the real dynamic linker - true
+// whenever this binary is dynamically linked and has already linked
+// against (or dlopen'd) it, the PIE-plus-interpreter case a cgo build,
+// or this package loaded as a plugin into a C host, both fall into - by
+// walking r_debug's own link_map the same way gdb or any other
+// r_debug-aware tool does, rather than needing a real ELF loader of its
+// own running yet (see errLoaderUnsupported, loader_unsupported.go).
+// OpenShared (library_refcount.go) is meant to check this before ever
+// calling Open, so a symbol from a library the host process already has
+// mapped reuses that mapping's address instead of conflicting with its
+// already-initialized globals.
+//
+// ok and err follow walkLinkMap's own rules.
+func findLoadedSoname(soname string) (addr uintptr, ok bool, err error) {
+	entries, ok, err := walkLinkMap()
+	if !ok || err != nil {
+		return 0, false, err
+	}
+	for _, e := range entries {
+		if filepath.Base(e.Path) == soname {
+			return e.Base, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// ListLinkMap reports every entry in this process's real r_debug link
+// map, in the order walkLinkMap finds them (gdb's own "info
+// sharedlibrary" and dlinfo(RTLD_DI_LINKMAP) report the same order).
+//
+// This is read-only: it shows what the real dynamic linker has already
+// mapped, which - for a cgo build - can include libraries this package
+// itself never touched. It does not make this package's own OpenShared
+// calls show up here, and it does not insert anything into r_debug or
+// fire the rendezvous breakpoint (_dl_debug_state) the way a real
+// ld.so's dlopen does after updating the list - both of those would
+// need this package's own loader to actually map and relocate a
+// library's segments first, which (see errLoaderUnsupported,
+// loader_unsupported.go) doesn't exist yet on Linux. Once it does, the
+// natural place to synthesize a link_map node and call the real
+// _dl_debug_state (found the same way findLoadedSoname finds anything
+// else here) is wherever that loader finishes mapping a library in.
+//
+// ok and err follow walkLinkMap's own rules.
+func ListLinkMap() (entries []LinkMapEntry, ok bool, err error) {
+	return walkLinkMap()
+}
@@ -0,0 +1,186 @@
+//go:build linux
+
+package ffi
+
+import (
+	"encoding/binary"
+	"testing"
+	"unsafe"
+)
+
+func putAuxvEntry(buf []byte, off int, tag, val uint64) {
+	binary.LittleEndian.PutUint64(buf[off:], tag)
+	binary.LittleEndian.PutUint64(buf[off+8:], val)
+}
+
+func TestParseAuxv(t *testing.T) {
+	buf := make([]byte, 16*5)
+	putAuxvEntry(buf, 0, 33, 0x7fff00000000) // AT_SYSINFO_EHDR, irrelevant
+	putAuxvEntry(buf, 16, atPhdr, 0x400040)
+	putAuxvEntry(buf, 32, atPhent, 56)
+	putAuxvEntry(buf, 48, atPhnum, 9)
+	putAuxvEntry(buf, 64, 0, 0) // AT_NULL
+
+	info, ok := parseAuxv(buf)
+	if !ok {
+		t.Fatal("parseAuxv: ok = false, want true")
+	}
+	if info.phdr != 0x400040 || info.phent != 56 || info.phnum != 9 {
+		t.Fatalf("parseAuxv: got %+v", info)
+	}
+}
+
+func TestParseAuxvMissingEntry(t *testing.T) {
+	buf := make([]byte, 16*2)
+	putAuxvEntry(buf, 0, atPhdr, 0x400040)
+	putAuxvEntry(buf, 16, 0, 0)
+
+	if _, ok := parseAuxv(buf); ok {
+		t.Fatal("parseAuxv: ok = true with AT_PHENT/AT_PHNUM missing, want false")
+	}
+}
+
+func putPhdr(buf []byte, off int, typ uint32, vaddr uint64) {
+	binary.LittleEndian.PutUint32(buf[off:], typ)
+	binary.LittleEndian.PutUint64(buf[off+16:], vaddr)
+}
+
+func TestFindDynamicSegment(t *testing.T) {
+	buf := make([]byte, elf64PhdrSize*3)
+	putPhdr(buf, 0*elf64PhdrSize, 1, 0) // PT_LOAD, ignored
+	putPhdr(buf, 1*elf64PhdrSize, ptPhdrType, 0x40)
+	putPhdr(buf, 2*elf64PhdrSize, ptDynamicType, 0x2000)
+
+	// AT_PHDR says the phdr table itself ended up at 0x400040, and
+	// PT_PHDR's own p_vaddr (pre-relocation) is 0x40, so the bias is
+	// 0x400000; PT_DYNAMIC's runtime address is then 0x2000+0x400000.
+	dynAddr, ok := findDynamicSegment(buf, 3, elf64PhdrSize, 0x400040)
+	if !ok {
+		t.Fatal("findDynamicSegment: ok = false, want true")
+	}
+	if want := uintptr(0x402000); dynAddr != want {
+		t.Fatalf("findDynamicSegment: got %#x, want %#x", dynAddr, want)
+	}
+}
+
+func TestFindDynamicSegmentNoDynamic(t *testing.T) {
+	buf := make([]byte, elf64PhdrSize*2)
+	putPhdr(buf, 0*elf64PhdrSize, 1, 0)
+	putPhdr(buf, 1*elf64PhdrSize, ptPhdrType, 0x40)
+
+	if _, ok := findDynamicSegment(buf, 2, elf64PhdrSize, 0x400040); ok {
+		t.Fatal("findDynamicSegment: ok = true with no PT_DYNAMIC entry, want false (static binary case)")
+	}
+}
+
+func putDyn(buf []byte, off int, tag int64, val uint64) {
+	binary.LittleEndian.PutUint64(buf[off:], uint64(tag))
+	binary.LittleEndian.PutUint64(buf[off+8:], val)
+}
+
+func TestFindDebugTag(t *testing.T) {
+	buf := make([]byte, elf64DynSize*3)
+	putDyn(buf, 0*elf64DynSize, 1, 0x1000) // DT_NEEDED, ignored
+	putDyn(buf, 1*elf64DynSize, dtDebugTag, 0x403000)
+	putDyn(buf, 2*elf64DynSize, 0, 0) // DT_NULL
+
+	addr, ok := findDebugTag(buf, 3)
+	if !ok {
+		t.Fatal("findDebugTag: ok = false, want true")
+	}
+	if want := uintptr(0x403000); addr != want {
+		t.Fatalf("findDebugTag: got %#x, want %#x", addr, want)
+	}
+}
+
+func TestFindDebugTagAbsent(t *testing.T) {
+	buf := make([]byte, elf64DynSize*2)
+	putDyn(buf, 0*elf64DynSize, 1, 0x1000)
+	putDyn(buf, 1*elf64DynSize, 0, 0)
+
+	if _, ok := findDebugTag(buf, 2); ok {
+		t.Fatal("findDebugTag: ok = true with no DT_DEBUG entry, want false")
+	}
+}
+
+func TestParseLinkMapNode(t *testing.T) {
+	buf := make([]byte, linkMapEntrySize)
+	binary.LittleEndian.PutUint64(buf[0:], 0x7f0000000000)
+	binary.LittleEndian.PutUint64(buf[8:], 0x7f0000001000)
+	binary.LittleEndian.PutUint64(buf[16:], 0x7f0000002000) // l_ld, unused
+	binary.LittleEndian.PutUint64(buf[24:], 0x7f0000003000)
+
+	addr, namePtr, next := parseLinkMapNode(buf)
+	if addr != 0x7f0000000000 || namePtr != 0x7f0000001000 || next != 0x7f0000003000 {
+		t.Fatalf("parseLinkMapNode: got addr=%#x namePtr=%#x next=%#x", addr, namePtr, next)
+	}
+}
+
+// TestReadCString exercises readCString against this process's own
+// memory, which is the only memory it's ever meant to read - there's a
+// real Go string already backing the []byte, so &s[0] is a perfectly
+// ordinary address to read from, just like any address findLoadedSoname
+// chases starting from its own auxv.
+func TestReadCString(t *testing.T) {
+	s := "libfoo.so.1\x00trailing garbage that must not be read"
+	b := []byte(s)
+	got := readCString(uintptr(unsafe.Pointer(&b[0])), 1024)
+	if got != "libfoo.so.1" {
+		t.Fatalf("readCString: got %q, want %q", got, "libfoo.so.1")
+	}
+}
+
+func TestReadCStringNil(t *testing.T) {
+	if got := readCString(0, 1024); got != "" {
+		t.Fatalf("readCString(0, ...): got %q, want \"\"", got)
+	}
+}
+
+// TestFindLoadedSonameDoesNotCrash exercises the live /proc/self/auxv
+// path end to end. It can't assert a specific soname is found - whether
+// this particular test binary is even dynamically linked depends on the
+// build (CGO_ENABLED, the host toolchain's default linking mode)
+// entirely outside this test's control - so it only asserts the walk
+// completes cleanly: ok=false with no error is just as valid a result
+// here as finding something, since a statically linked test binary has
+// no r_debug to find at all.
+func TestFindLoadedSonameDoesNotCrash(t *testing.T) {
+	_, ok, err := findLoadedSoname("libc.so.6")
+	if err != nil {
+		t.Fatalf("findLoadedSoname: %v", err)
+	}
+	t.Logf("findLoadedSoname(libc.so.6): ok=%v", ok)
+}
+
+// TestFindLoadedSonameUnknownName confirms a soname that can't possibly
+// be mapped into any process reports ok=false rather than a false
+// match.
+func TestFindLoadedSonameUnknownName(t *testing.T) {
+	_, ok, err := findLoadedSoname("libthis-definitely-does-not-exist.so.999")
+	if err != nil {
+		t.Fatalf("findLoadedSoname: %v", err)
+	}
+	if ok {
+		t.Fatal("findLoadedSoname: ok = true for a soname nothing could have loaded")
+	}
+}
+
+// TestListLinkMapDoesNotCrash is ListLinkMap's own version of
+// TestFindLoadedSonameDoesNotCrash: whether this test binary is
+// dynamically linked at all is outside this test's control, so it only
+// asserts the walk completes cleanly and, if it does find entries, that
+// none of them are obviously garbage (an empty Path is legitimate - the
+// main executable's own node - but a Base of 0 for a named entry would
+// mean the parse went wrong).
+func TestListLinkMapDoesNotCrash(t *testing.T) {
+	entries, ok, err := ListLinkMap()
+	if err != nil {
+		t.Fatalf("ListLinkMap: %v", err)
+	}
+	t.Logf("ListLinkMap: ok=%v entries=%d", ok, len(entries))
+	for _, e := range entries {
+		if e.Path != "" && e.Base == 0 {
+			t.Fatalf("ListLinkMap: entry %+v has a named Path but a zero Base", e)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package ffi
+
+// OpenFlags controls the binding semantics Open resolves a library with.
+// The values match glibc's dlopen(3) flags bit for bit, since that's the
+// convention most C libraries document their own RTLD_* requirements
+// against, even though loader_unsupported.go can't honor them yet (see
+// its doc comment) and loader_windows.go's LoadLibrary has no equivalent
+// concept to map them onto.
+type OpenFlags int
+
+const (
+	// RTLD_LAZY resolves undefined symbols as they're first used,
+	// rather than all at once when the library is loaded.
+	RTLD_LAZY OpenFlags = 0x1
+	// RTLD_NOW resolves all undefined symbols before Open returns,
+	// surfacing a missing symbol immediately rather than on first use.
+	RTLD_NOW OpenFlags = 0x2
+	// RTLD_GLOBAL makes the library's symbols available for resolving
+	// undefined symbols in libraries opened after it.
+	RTLD_GLOBAL OpenFlags = 0x100
+	// RTLD_LOCAL is the default: the opposite of RTLD_GLOBAL. It's 0,
+	// so ORing it into a flags value is a no-op; it exists so callers
+	// can spell out the default explicitly.
+	RTLD_LOCAL OpenFlags = 0x0
+	// RTLD_NODELETE keeps the library mapped even after Close, so code
+	// and data it still has references into - e.g. a callback pointer
+	// handed to another library - remain valid.
+	RTLD_NODELETE OpenFlags = 0x1000
+)
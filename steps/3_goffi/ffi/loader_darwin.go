@@ -0,0 +1,154 @@
+//go:build darwin
+
+package ffi
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// libc_dlopen/dlsym/dlclose/dlerror are libSystem's loader entry points.
+// //go:cgo_import_dynamic asks the linker to resolve each symbol against
+// the dynamic shared cache at link time - the same lookup dlopen itself
+// would have to do if this package called it through cgo - leaving the
+// matching var below holding the resolved function's address once the
+// binary starts running. From there each is just another C function
+// address, callable through MakeSpec/Call exactly like a symbol resolved
+// via Library.Get: there's no separate darwin calling convention to
+// write, because asmcall (ffi_amd64.s/ffi_arm64.s) and the linknamed
+// asmcgocall/entersyscall/exitsyscall helpers (runtime.go) are already
+// OS-independent runtime entry points, not Linux-specific ones.
+//
+//go:cgo_import_dynamic libc_dlopen dlopen "/usr/lib/libSystem.B.dylib"
+//go:cgo_import_dynamic libc_dlsym dlsym "/usr/lib/libSystem.B.dylib"
+//go:cgo_import_dynamic libc_dlclose dlclose "/usr/lib/libSystem.B.dylib"
+//go:cgo_import_dynamic libc_dlerror dlerror "/usr/lib/libSystem.B.dylib"
+
+//go:linkname libc_dlopen libc_dlopen
+//go:linkname libc_dlsym libc_dlsym
+//go:linkname libc_dlclose libc_dlclose
+//go:linkname libc_dlerror libc_dlerror
+
+var (
+	libc_dlopen  uintptr
+	libc_dlsym   uintptr
+	libc_dlclose uintptr
+	libc_dlerror uintptr
+)
+
+// dlopenArgs, dlsymArgs, dlcloseArgs and dlerrorArgs are plain MakeSpec
+// argument structs for the four libSystem entry points above: the loader
+// classifies and calls them the same reflect-based way any other caller
+// of this package would call a symbol resolved through Library.Get,
+// rather than a hand-written calling sequence of its own.
+type dlopenArgs struct {
+	Path  string  `ffi:"arg,cstr"`
+	Flags int32   `ffi:"arg"`
+	R     uintptr `ffi:"ret"`
+}
+
+type dlsymArgs struct {
+	Handle uintptr `ffi:"arg"`
+	Symbol string  `ffi:"arg,cstr"`
+	R      uintptr `ffi:"ret"`
+}
+
+type dlcloseArgs struct {
+	Handle uintptr `ffi:"arg"`
+	R      int32   `ffi:"ret"`
+}
+
+type dlerrorArgs struct {
+	R uintptr `ffi:"ret"`
+}
+
+// dlerrorMessage calls dlerror() and copies its NUL-terminated result -
+// or "" if it returned NULL, meaning no error is pending - into a Go
+// string before anything else can overwrite libdl's static error buffer.
+func dlerrorMessage() string {
+	var args dlerrorArgs
+	spec := MakeSpec(libc_dlerror, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return ""
+	}
+	return GoString(args.R)
+}
+
+// loadLibrary, getProcAddress and closeLibrary back the OS-independent
+// Library type (library.go) with libSystem's real dlopen/dlsym/dlclose.
+// Unlike loader_unsupported.go's stand-in, flags is honored directly:
+// OpenFlags is already modeled bit-for-bit on dlopen's own RTLD_* flags
+// (loader.go), and darwin's dlopen is the dlopen those bits were modeled
+// against in the first place.
+func loadLibrary(path string, flags OpenFlags) (uintptr, error) {
+	args := dlopenArgs{Path: path, Flags: int32(flags)}
+	spec := MakeSpec(libc_dlopen, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return 0, fmt.Errorf("ffi: dlopen %q: %s", path, dlerrorMessage())
+	}
+	return args.R, nil
+}
+
+func getProcAddress(handle uintptr, symbol string) (uintptr, error) {
+	args := dlsymArgs{Handle: handle, Symbol: symbol}
+	spec := MakeSpec(libc_dlsym, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return 0, fmt.Errorf("ffi: dlsym %q: %s", symbol, dlerrorMessage())
+	}
+	return args.R, nil
+}
+
+// getProcAddressVersioned has no darwin equivalent: Mach-O has no symbol
+// versioning scheme analogous to an ELF symbol's glibc version, so
+// there's nothing for it to pin - the same gap loader_windows.go's
+// getProcAddressVersioned documents for DLL exports.
+func getProcAddressVersioned(handle uintptr, symbol, version string) (uintptr, error) {
+	return 0, errors.New("ffi: this platform's loader has no symbol versioning to pin")
+}
+
+// dlopenDefaultArgs mirrors dlopenArgs, except Path is a raw uintptr
+// rather than an `ffi:"cstr"` string: dlopen's NULL-means-"the main
+// program"` convention needs an actual null pointer, and cstr backs even
+// an empty Go string with a real (if zero-length) NUL-terminated buffer
+// rather than a null one, so dlopenArgs can't express it.
+type dlopenDefaultArgs struct {
+	Path  uintptr `ffi:"arg"`
+	Flags int32   `ffi:"arg"`
+	R     uintptr `ffi:"ret"`
+}
+
+// loadDefaultLibrary calls dlopen(NULL, flags) to get a handle for
+// RTLD_DEFAULT: the main program together with every library already
+// loaded into it, the same namespace dlsym(RTLD_DEFAULT, ...) searches
+// with no library of its own.
+func loadDefaultLibrary(flags OpenFlags) (uintptr, error) {
+	args := dlopenDefaultArgs{Path: 0, Flags: int32(flags)}
+	spec := MakeSpec(libc_dlopen, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R == 0 {
+		return 0, fmt.Errorf("ffi: dlopen(NULL): %s", dlerrorMessage())
+	}
+	return args.R, nil
+}
+
+// closeDefaultLibrary dlcloses the handle loadDefaultLibrary returned.
+// Unlike loader_windows.go's GetModuleHandle, dlopen(NULL) hands back a
+// handle indistinguishable from any other dlopen result, refcounted the
+// same way, so there's nothing unsafe about closing it the ordinary way.
+func closeDefaultLibrary(handle uintptr) error {
+	return closeLibrary(handle)
+}
+
+func closeLibrary(handle uintptr) error {
+	args := dlcloseArgs{Handle: handle}
+	spec := MakeSpec(libc_dlclose, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("ffi: dlclose: %s", dlerrorMessage())
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package ffi
+
+import "fmt"
+
+// ErrLibraryNotFound means Open/OpenWithFlags (and OpenVersioned/
+// OpenPkgConfig/OpenDefault built on top of them) could not map Path at
+// all - whether because the underlying loader (dlopen, LoadLibraryW, or
+// - on a platform with no loader yet, see loader_unsupported.go -
+// errLoaderUnsupported itself) rejected it, not because a symbol within
+// an otherwise-loaded library failed to resolve; that case is
+// ErrSymbolNotFound instead. Reason carries the underlying loader's own
+// message (dlerror's text, a Windows error, or errLoaderUnsupported's)
+// for a human to read; a caller branching on the failure itself should
+// use errors.As/errors.Is against ErrLibraryNotFound rather than parse
+// Reason.
+type ErrLibraryNotFound struct {
+	Path   string
+	Reason string
+}
+
+func (e *ErrLibraryNotFound) Error() string {
+	return fmt.Sprintf("ffi: library not found: %s: %s", e.Path, e.Reason)
+}
+
+// Is reports whether target is also an *ErrLibraryNotFound, ignoring
+// Path/Reason - so errors.Is(err, new(ErrLibraryNotFound)) answers "was
+// this a library-not-found failure" without the caller needing to know
+// or match the specific path involved.
+func (e *ErrLibraryNotFound) Is(target error) bool {
+	_, ok := target.(*ErrLibraryNotFound)
+	return ok
+}
+
+// ErrSymbolNotFound means Library.Get/GetVersioned/GetCxx resolved Lib
+// successfully but Name isn't defined there. Reason carries the
+// underlying loader's own message, the same way ErrLibraryNotFound.Reason
+// does.
+type ErrSymbolNotFound struct {
+	Lib    string
+	Name   string
+	Reason string
+}
+
+func (e *ErrSymbolNotFound) Error() string {
+	return fmt.Sprintf("ffi: symbol not found: %s in %s: %s", e.Name, e.Lib, e.Reason)
+}
+
+// Is reports whether target is also an *ErrSymbolNotFound, ignoring
+// Lib/Name/Reason - see ErrLibraryNotFound.Is.
+func (e *ErrSymbolNotFound) Is(target error) bool {
+	_, ok := target.(*ErrSymbolNotFound)
+	return ok
+}
+
+// ErrBadELF means the hand-rolled ELF loader loader_unsupported.go's
+// errLoaderUnsupported doc comment describes rejected Path's contents
+// outright - a bad magic number, an unsupported ELF class/byte order, or
+// a program/section header that doesn't fit within the file - rather
+// than failing to resolve or relocate a symbol within an otherwise
+// well-formed object. Nothing in this package constructs one yet, since
+// that loader doesn't exist here on any platform; it's declared now so
+// the loader can return it directly once it does, instead of another
+// bare error type getting established in its place first.
+type ErrBadELF struct {
+	Path   string
+	Reason string
+}
+
+func (e *ErrBadELF) Error() string {
+	return fmt.Sprintf("ffi: bad ELF: %s: %s", e.Path, e.Reason)
+}
+
+// Is reports whether target is also an *ErrBadELF, ignoring Path/Reason
+// - see ErrLibraryNotFound.Is.
+func (e *ErrBadELF) Is(target error) bool {
+	_, ok := target.(*ErrBadELF)
+	return ok
+}
+
+// ErrRelocUnsupported means the same future ELF loader encountered a
+// relocation type (see elf_reloc_unsupported.go's elfRelocType for the
+// set it already knows the names of) in Path that it doesn't implement
+// applying. Type is the raw ELF relocation type number (R_X86_64_* on
+// amd64, the analogous R_AARCH64_*/R_386_* etc. on another arch) rather
+// than elfRelocType itself, since a loader encountering an unsupported
+// type by definition doesn't have a named constant for it to report.
+type ErrRelocUnsupported struct {
+	Type uint32
+	Path string
+}
+
+func (e *ErrRelocUnsupported) Error() string {
+	return fmt.Sprintf("ffi: unsupported relocation type %d in %s", e.Type, e.Path)
+}
+
+// Is reports whether target is also an *ErrRelocUnsupported, ignoring
+// Type/Path - see ErrLibraryNotFound.Is.
+func (e *ErrRelocUnsupported) Is(target error) bool {
+	_, ok := target.(*ErrRelocUnsupported)
+	return ok
+}
@@ -0,0 +1,63 @@
+package ffi
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestErrLibraryNotFoundIsIgnoresFields confirms errors.Is matches any
+// *ErrLibraryNotFound regardless of Path/Reason, the way a caller
+// checking "was this a library-not-found failure" wants.
+func TestErrLibraryNotFoundIsIgnoresFields(t *testing.T) {
+	err := &ErrLibraryNotFound{Path: "/opt/lib/libfoo.so", Reason: "no such file"}
+	if !errors.Is(err, new(ErrLibraryNotFound)) {
+		t.Fatal("errors.Is(err, new(ErrLibraryNotFound)) = false, want true")
+	}
+	if errors.Is(err, new(ErrSymbolNotFound)) {
+		t.Fatal("errors.Is(err, new(ErrSymbolNotFound)) = true, want false")
+	}
+}
+
+// TestErrSymbolNotFoundAs confirms errors.As recovers the concrete type
+// and its fields from a plain error value.
+func TestErrSymbolNotFoundAs(t *testing.T) {
+	var err error = &ErrSymbolNotFound{Lib: "libfoo.so", Name: "frobnicate", Reason: "undefined symbol"}
+
+	var target *ErrSymbolNotFound
+	if !errors.As(err, &target) {
+		t.Fatal("errors.As(err, &target) = false, want true")
+	}
+	if target.Lib != "libfoo.so" || target.Name != "frobnicate" {
+		t.Fatalf("target = %+v, want Lib=libfoo.so Name=frobnicate", target)
+	}
+}
+
+// TestOpenWrapsLoaderFailureAsErrLibraryNotFound confirms Open's failure
+// on this platform (no real loader yet - see loader_unsupported.go) is
+// reported as an *ErrLibraryNotFound rather than a bare error, so a
+// caller can branch on it with errors.As.
+func TestOpenWrapsLoaderFailureAsErrLibraryNotFound(t *testing.T) {
+	_, err := Open("/definitely/does/not/exist.so")
+	if err == nil {
+		t.Fatal("Open: expected an error")
+	}
+	var target *ErrLibraryNotFound
+	if !errors.As(err, &target) {
+		t.Fatalf("Open error = %v (%T), want *ErrLibraryNotFound", err, err)
+	}
+}
+
+// TestErrBadELFAndErrRelocUnsupportedError confirm both error types'
+// messages mention the fields they carry, even though nothing in this
+// package constructs one yet (see ErrBadELF's doc comment).
+func TestErrBadELFAndErrRelocUnsupportedError(t *testing.T) {
+	badELF := &ErrBadELF{Path: "/lib/libfoo.so", Reason: "bad magic"}
+	if got := badELF.Error(); got == "" {
+		t.Fatal("ErrBadELF.Error() is empty")
+	}
+
+	reloc := &ErrRelocUnsupported{Type: 99, Path: "/lib/libfoo.so"}
+	if got := reloc.Error(); got == "" {
+		t.Fatal("ErrRelocUnsupported.Error() is empty")
+	}
+}
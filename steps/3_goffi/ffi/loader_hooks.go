@@ -0,0 +1,79 @@
+package ffi
+
+import "sync"
+
+// OnLibraryMapped registers fn to run every time Open/OpenWithFlags maps
+// a new library in, after loadLibrary itself has already succeeded - the
+// same LD_AUDIT la_objopen gives a real dynamic linker's audit library.
+// It's meant for policy that doesn't belong forked into the loader
+// itself: a deny-list that closes path right back if fn doesn't want it
+// loaded, a log line recording what a process actually pulled in, or a
+// metrics counter keyed by path.
+func OnLibraryMapped(fn func(path string, handle uintptr)) {
+	loaderHooksMu.Lock()
+	libraryMappedHooks = append(libraryMappedHooks, fn)
+	loaderHooksMu.Unlock()
+}
+
+// OnSymbolBound registers fn to run every time Library.Get/GetVersioned
+// resolves symbol to addr, including one Interpose overrode - the same
+// LD_AUDIT la_symbind gives. It's meant for the same kind of policy
+// OnLibraryMapped is, one symbol resolution at a time: logging which
+// symbols a library actually ends up calling, or lazily building a
+// symbol-to-address metrics table without touching Get's own callers.
+func OnSymbolBound(fn func(symbol string, addr uintptr)) {
+	loaderHooksMu.Lock()
+	symbolBoundHooks = append(symbolBoundHooks, fn)
+	loaderHooksMu.Unlock()
+}
+
+// OnLibraryUnmapped registers fn to run every time Close unloads a
+// library, after closeLibrary/closeDefaultLibrary itself has already
+// succeeded - the same LD_AUDIT la_objclose gives. Any Spec built from a
+// symbol this library resolved is already unsafe to Call again by the
+// time fn runs, the same as Close's own doc comment already says.
+func OnLibraryUnmapped(fn func(handle uintptr)) {
+	loaderHooksMu.Lock()
+	libraryUnmappedHooks = append(libraryUnmappedHooks, fn)
+	loaderHooksMu.Unlock()
+}
+
+var (
+	loaderHooksMu        sync.Mutex
+	libraryMappedHooks   []func(path string, handle uintptr)
+	symbolBoundHooks     []func(symbol string, addr uintptr)
+	libraryUnmappedHooks []func(handle uintptr)
+)
+
+// fireLibraryMapped, fireSymbolBound and fireLibraryUnmapped each run a
+// snapshot of their hook slice without holding loaderHooksMu while doing
+// so, so a hook registering another hook (or simply taking a while)
+// doesn't block Open/Get/Close calls running concurrently on other
+// goroutines - the same snapshot-then-call shape callThreadHooks
+// (thread_hooks_amd64.go) uses for OnThreadAttach/OnThreadDetach.
+func fireLibraryMapped(path string, handle uintptr) {
+	loaderHooksMu.Lock()
+	fns := append([]func(string, uintptr){}, libraryMappedHooks...)
+	loaderHooksMu.Unlock()
+	for _, fn := range fns {
+		fn(path, handle)
+	}
+}
+
+func fireSymbolBound(symbol string, addr uintptr) {
+	loaderHooksMu.Lock()
+	fns := append([]func(string, uintptr){}, symbolBoundHooks...)
+	loaderHooksMu.Unlock()
+	for _, fn := range fns {
+		fn(symbol, addr)
+	}
+}
+
+func fireLibraryUnmapped(handle uintptr) {
+	loaderHooksMu.Lock()
+	fns := append([]func(uintptr){}, libraryUnmappedHooks...)
+	loaderHooksMu.Unlock()
+	for _, fn := range fns {
+		fn(handle)
+	}
+}
@@ -0,0 +1,86 @@
+package ffi
+
+import "testing"
+
+// TestFireSymbolBoundRunsRegisteredHooks confirms fireSymbolBound invokes
+// every registered OnSymbolBound hook, in registration order.
+func TestFireSymbolBoundRunsRegisteredHooks(t *testing.T) {
+	loaderHooksMu.Lock()
+	saved := symbolBoundHooks
+	symbolBoundHooks = nil
+	loaderHooksMu.Unlock()
+	defer func() {
+		loaderHooksMu.Lock()
+		symbolBoundHooks = saved
+		loaderHooksMu.Unlock()
+	}()
+
+	var got []string
+	OnSymbolBound(func(symbol string, addr uintptr) { got = append(got, symbol) })
+	OnSymbolBound(func(symbol string, addr uintptr) { got = append(got, symbol+"2") })
+
+	fireSymbolBound("foo", 0x1)
+
+	if len(got) != 2 || got[0] != "foo" || got[1] != "foo2" {
+		t.Fatalf("fireSymbolBound: got %v, want [foo foo2]", got)
+	}
+}
+
+// TestLibraryGetFiresSymbolBoundForInterposedSymbol confirms Get fires
+// OnSymbolBound even when the address came from Interpose rather than a
+// real lookup - test policy hung off OnSymbolBound shouldn't need to
+// special-case which path the address took.
+func TestLibraryGetFiresSymbolBoundForInterposedSymbol(t *testing.T) {
+	loaderHooksMu.Lock()
+	saved := symbolBoundHooks
+	symbolBoundHooks = nil
+	loaderHooksMu.Unlock()
+	defer func() {
+		loaderHooksMu.Lock()
+		symbolBoundHooks = saved
+		loaderHooksMu.Unlock()
+	}()
+
+	const symbol = "ffi_synth145_interposed_symbol"
+	Interpose(symbol, 0x42)
+	defer Uninterpose(symbol)
+
+	var gotSymbol string
+	var gotAddr uintptr
+	OnSymbolBound(func(symbol string, addr uintptr) { gotSymbol, gotAddr = symbol, addr })
+
+	lib := &Library{}
+	if _, err := lib.Get(symbol); err != nil {
+		t.Fatalf("Get: unexpected error %v", err)
+	}
+
+	if gotSymbol != symbol || gotAddr != 0x42 {
+		t.Fatalf("OnSymbolBound hook saw (%q, %#x), want (%q, 0x42)", gotSymbol, gotAddr, symbol)
+	}
+}
+
+// TestLibraryGetSkipsSymbolBoundOnFailure confirms a Get that fails to
+// resolve symbol at all never fires OnSymbolBound for it.
+func TestLibraryGetSkipsSymbolBoundOnFailure(t *testing.T) {
+	loaderHooksMu.Lock()
+	saved := symbolBoundHooks
+	symbolBoundHooks = nil
+	loaderHooksMu.Unlock()
+	defer func() {
+		loaderHooksMu.Lock()
+		symbolBoundHooks = saved
+		loaderHooksMu.Unlock()
+	}()
+
+	fired := false
+	OnSymbolBound(func(symbol string, addr uintptr) { fired = true })
+
+	lib := &Library{}
+	if _, err := lib.Get("ffi_synth145_bogus_symbol_does_not_exist"); err == nil {
+		t.Fatal("Get: want an error for a bogus symbol on a zero-handle Library")
+	}
+
+	if fired {
+		t.Fatal("OnSymbolBound fired for a Get that returned an error")
+	}
+}
@@ -0,0 +1,178 @@
+//go:build !windows && !darwin
+
+package ffi
+
+import "errors"
+
+// errLoaderUnsupported is returned by loadLibrary/getProcAddress/
+// closeLibrary on platforms this package doesn't have a loader for yet:
+// unlike loader_windows.go's LoadLibrary/GetProcAddress, which call real
+// Win32 APIs through the syscall package, and loader_darwin.go's, which
+// calls libSystem's dlopen/dlsym directly, calling dlopen/dlsym here
+// would need either cgo (which this package exists to avoid) or a
+// hand-rolled ELF loader, neither of which exists yet.
+//
+// Whoever builds that ELF loader shouldn't need to special-case musl vs
+// glibc targets: symbol resolution and relocation are governed by the
+// ELF format itself, not by which libc the target .so happens to be
+// built against. The one place a libc flavor could matter is process
+// bootstrap - musl's pthread_create/TLS setup differs from glibc's - but
+// this package doesn't do any pthread/TLS bootstrap of its own to begin
+// with: Call/CallFast (ffi_amd64.go et al.) ride the calling goroutine's
+// own OS thread through asmcgocall (runtime.go), the same thread
+// transition cgo itself relies on, rather than spawning or initializing
+// any thread themselves. There's nothing here for musl to be
+// incompatible with yet.
+//
+// FreeBSD can't get loader_darwin.go's treatment either, despite also
+// being a plain dlopen/dlsym libc: darwin's //go:cgo_import_dynamic trick
+// only works because a non-cgo darwin binary is already dynamically
+// linked against libSystem.dylib (Apple requires it), giving the linker
+// a shared cache to resolve dlopen's address against at link time. A
+// non-cgo FreeBSD binary, like a non-cgo Linux one, is statically linked
+// with no libc loaded at all, so there's no symbol for that directive to
+// resolve there. elf_reloc_unsupported.go records the relocation types
+// (GLOB_DAT, JUMP_SLOT, RELATIVE, COPY, IRELATIVE) that loader will need
+// to apply once it exists - real-world shared objects like glibc or
+// libssl lean on all five, not just RELATIVE, so a loader that only
+// handled the simplest case would load plenty of .so files and then
+// crash the first time one of them touched a GOT entry the loader
+// skipped. The SysV AMD64 call trampoline itself (ffi_amd64.go/
+// ffi_amd64.s) needs none of this and already builds and runs unmodified
+// on freebsd/amd64 - asmcgocall/entersyscall/exitsyscall (runtime.go)
+// are generic runtime entry points, not Linux-specific ones - it's only
+// Library/Open that's stuck here until a real ELF loader exists.
+// elf_deps_unsupported.go's resolveLoadOrder covers one more piece that
+// loader will need: putting a library's DT_NEEDED dependencies, and
+// theirs in turn, in the right order before mapping and relocating any
+// of them. elf_hash_unsupported.go's elfHashSysV/elfHashGNU cover the
+// piece after that: once a .so's symbol table is actually mapped in,
+// looking a name up in its .hash or .gnu.hash section means hashing the
+// name the same way the object's own hash section was built, so the
+// right bucket can be checked instead of every symbol in turn.
+// elf_reloc_unsupported.go's elfRelocDTPMod64/DTPOff64/TPOff64/TLSDesc/
+// TLSDescCall and elf_tls_unsupported.go's allocateStaticTLS cover
+// __thread variables: a library with any of those needs its TLS blocks
+// sized and placed in the thread's static TLS area before relocation,
+// and those five relocation types applied against the result, before
+// the goroutine calling into it can read or write one.
+// elf_ifunc_unsupported.go's isIFuncSymbol covers one more symbol-table
+// detail a loader needs before applying elfRelocIRelative: telling an
+// STT_GNU_IFUNC symbol apart from an ordinary STT_FUNC one in the first
+// place, since it's only the former a loader must call through rather
+// than bind directly.
+// elf_rpath_unsupported.go's resolveLibraryPath covers a piece that
+// comes before any of the above: turning a bare soname from
+// resolveLoadOrder's dependency graph into an actual path to open,
+// honoring DT_RPATH/DT_RUNPATH (with $ORIGIN expansion), LD_LIBRARY_PATH,
+// and a caller-supplied list of default directories, the same search a
+// real ld.so performs before it ever gets to mapping or relocating
+// anything.
+// elf_debug_unsupported.go's ldDebugf is the piece whoever builds the
+// loader should print through at each mapping/relocation/symbol-
+// resolution decision it makes, gated by the same LD_DEBUG categories
+// glibc's own ld.so recognizes, so a loader failure on a real library
+// is actually debuggable instead of just returning an opaque error.
+//
+// Android's Bionic libc raises its own set of concerns on top of all of
+// the above, for whoever eventually builds the loader with android in
+// mind: Bionic's linker (since API level 24) enforces linker namespaces,
+// so resolveLibraryPath's plain DT_RPATH/DT_RUNPATH/LD_LIBRARY_PATH
+// search isn't enough on its own - an app-private namespace additionally
+// restricts which directories (the app's own native library directory
+// and a handful of public system ones) a lookup is even permitted to
+// search, and libdl itself (the symbol dlopen/dlsym resolve against) is
+// reached through Bionic's own libdl.so rather than glibc's, so a loader
+// built and tested only against a glibc/musl system's search semantics
+// would silently permit lookups Bionic would refuse. Bionic's
+// pthread_create/TLS bootstrap also differs from glibc's and musl's in
+// its own way, the same category of concern the musl paragraph above
+// already flags - but, as that paragraph explains, moot today, since
+// this package still rides the calling goroutine's own OS thread through
+// asmcgocall rather than bootstrapping one itself.
+//
+// OpenBSD raises a different concern once a loader exists there: its
+// kernel enforces msyscall/pinsyscall, killing any SYSCALL/INT instruction
+// that doesn't originate from a page libc.so mapped in via msyscall(2) at
+// process start. asmcall (ffi_amd64.s et al.) is already fine on that
+// front - it only ever CALLs a resolved C function pointer, the same
+// thing a real dlopen/dlsym-based loader would hand it, never issuing a
+// syscall instruction itself. rawsyscall_amd64.go's asmrawsyscall is the
+// one piece of this package that does issue a raw syscall directly
+// rather than calling through libc, which is exactly what OpenBSD
+// forbids from outside a pinned page - it's already gated `//go:build
+// linux` and must stay that way; an openbsd build needing that
+// functionality has to call through libc's own syscall(3) wrapper via
+// Call1..Call6 (syscall_amd64.go) instead, the same as any other libc
+// function.
+//
+// Solaris/illumos need the same real ELF loader every other platform on
+// this list does - a non-cgo binary there is statically linked with no
+// libc mapped, same as linux/freebsd, so there's no darwin-style
+// //go:cgo_import_dynamic shortcut - but need no trampoline changes once
+// they have one: the Go runtime itself only ever reaches libc there
+// through its own sysvicall6/asmsysvicall6 (runtime/os_solaris.go), which
+// is asmcgocall underneath just like Call is here, not through the
+// libcCall runtime entry point darwin and non-mips64 openbsd use instead
+// - proof asmcall's existing asmcgocall-based design (ffi_amd64.s et al.)
+// already fits this platform's calling convention unmodified. The one
+// loader-shaped piece that will differ is default search paths:
+// resolveLibraryPath (elf_rpath_unsupported.go) will need Solaris's/
+// illumos's own default directory list (e.g. /usr/lib/64 alongside
+// /usr/lib on 64 bit, plus whatever a given illumos distribution's
+// package system uses) rather than a Linux distribution's.
+//
+// Lazy binding is a cost/correctness tradeoff whoever builds the loader
+// will have to make explicitly, not something elfRelocJumpSlot's mere
+// existence settles on its own: a real ld.so defaults every JUMP_SLOT
+// GOT entry to a resolver thunk address and only applies the real one
+// the first time that PLT stub is actually called, deferring the symbol
+// lookup (and, for a versioned symbol, the version match) for every
+// function a library exports but a given process never calls - often
+// the overwhelming majority of a large library's PLT for any one
+// caller. RTLD_NOW's eager binding exists precisely because lazy
+// resolution moves a missing- or wrong-version-symbol failure from load
+// time to whatever arbitrary later call first touches it, which is why
+// OpenWithFlags already lets a caller request it explicitly instead of
+// leaving the choice to whatever this package's own loader eventually
+// defaults to. A resolver thunk here would need the same per-call entry
+// path asmcall already has - loading *Spec-equivalent binding state
+// (which library, which symbol name, which GOT slot to patch) into a
+// register before jumping to a small hand-written stub, the trampoline
+// generation buildCallbackTrampoline (callback_amd64.go) already does
+// for the opposite direction (Go being called from C) - rather than
+// anything Call/CallFast/CallBatch need to know about; once a slot is
+// resolved, every later call through it is an ordinary direct jump with
+// no lazy-binding overhead left at all.
+var errLoaderUnsupported = errors.New("ffi: Library is not yet supported on this platform")
+
+// loadLibrary ignores flags: there's no real dlopen call here yet (see
+// errLoaderUnsupported above) for it to control.
+func loadLibrary(path string, flags OpenFlags) (uintptr, error) {
+	return 0, errLoaderUnsupported
+}
+
+func getProcAddress(handle uintptr, symbol string) (uintptr, error) {
+	return 0, errLoaderUnsupported
+}
+
+// getProcAddressVersioned would be dlvsym, once there's a real loader to
+// call it through - see errLoaderUnsupported above.
+func getProcAddressVersioned(handle uintptr, symbol, version string) (uintptr, error) {
+	return 0, errLoaderUnsupported
+}
+
+func closeLibrary(handle uintptr) error {
+	return errLoaderUnsupported
+}
+
+// loadDefaultLibrary would be dlopen(NULL, flags) - RTLD_DEFAULT -
+// once there's a real loader to call it through; see errLoaderUnsupported
+// above.
+func loadDefaultLibrary(flags OpenFlags) (uintptr, error) {
+	return 0, errLoaderUnsupported
+}
+
+func closeDefaultLibrary(handle uintptr) error {
+	return errLoaderUnsupported
+}
@@ -0,0 +1,77 @@
+//go:build windows
+
+package ffi
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errNoSymbolVersioning is returned by getProcAddressVersioned: a DLL's
+// export table has no equivalent to an ELF symbol's glibc version, so
+// there's nothing for GetVersioned to pin.
+var errNoSymbolVersioning = errors.New("ffi: this platform's loader has no symbol versioning to pin")
+
+// procGetModuleHandle is GetModuleHandleW, for loadDefaultLibrary below.
+// Unlike LoadLibrary/GetProcAddress/FreeLibrary, the syscall package has
+// no wrapper for it, so it's resolved through kernel32.dll directly the
+// way any Win32 API this package has no built-in binding for would be.
+var procGetModuleHandle = syscall.NewLazyDLL("kernel32.dll").NewProc("GetModuleHandleW")
+
+// LoadLibrary loads the named DLL (searched the same way the Windows
+// LoadLibraryW API searches it) and returns a handle suitable for
+// GetProcAddress.
+func LoadLibrary(name string) (uintptr, error) {
+	h, err := syscall.LoadLibrary(name)
+	if err != nil {
+		return 0, err
+	}
+	return uintptr(h), nil
+}
+
+// GetProcAddress resolves symbol's address within the DLL handle points
+// at, for use as the fn passed to MakeSpec.
+func GetProcAddress(handle uintptr, symbol string) (uintptr, error) {
+	return syscall.GetProcAddress(syscall.Handle(handle), symbol)
+}
+
+// loadLibrary, getProcAddress and closeLibrary back the OS-independent
+// Library type (library.go) with this platform's real loader. flags is
+// ignored: LoadLibraryW has no equivalent to the POSIX RTLD_* binding
+// semantics OpenFlags expresses.
+func loadLibrary(path string, flags OpenFlags) (uintptr, error) {
+	return LoadLibrary(path)
+}
+
+func getProcAddress(handle uintptr, symbol string) (uintptr, error) {
+	return GetProcAddress(handle, symbol)
+}
+
+func getProcAddressVersioned(handle uintptr, symbol, version string) (uintptr, error) {
+	return 0, errNoSymbolVersioning
+}
+
+func closeLibrary(handle uintptr) error {
+	return syscall.FreeLibrary(syscall.Handle(handle))
+}
+
+// loadDefaultLibrary calls GetModuleHandleW(NULL), which returns a
+// handle for the calling process's own executable - the closest Win32
+// analog to dlopen(NULL)/RTLD_DEFAULT, though it only covers the main
+// EXE's own export table, not every DLL already loaded into the process
+// the way RTLD_DEFAULT does on a real dlopen.
+func loadDefaultLibrary(flags OpenFlags) (uintptr, error) {
+	r, _, e := procGetModuleHandle.Call(0)
+	if r == 0 {
+		return 0, e
+	}
+	return r, nil
+}
+
+// closeDefaultLibrary is a no-op: unlike LoadLibrary, GetModuleHandle
+// does not bump the module's reference count, so calling FreeLibrary on
+// its result the way closeLibrary would is wrong - it would drop a
+// reference the process never added.
+func closeDefaultLibrary(handle uintptr) error {
+	return nil
+}
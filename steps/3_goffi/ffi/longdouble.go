@@ -0,0 +1,115 @@
+package ffi
+
+import (
+	"math"
+	"math/bits"
+	"reflect"
+)
+
+// LongDouble is the Go-side storage for a C long double: x87's 80 bit
+// extended-precision format (1 sign bit, 15 exponent bits biased by
+// 16383, and a 64 bit mantissa with an explicit integer bit, unlike
+// float64's implicit one), stored little-endian the same way it sits in
+// memory on both 386 and amd64. It's a plain [10]byte rather than a
+// numeric type since Go has nothing wider than float64 to represent it
+// natively - Float64/LongDoubleFromFloat64 below are the only way to
+// get a value in or out without going through C.
+//
+// A LongDouble field embedded by value in an args/ret struct is
+// recognized by MakeSpec on 386 and amd64 (see longDoubleType's use in
+// ffi_386.go/ffi_amd64.go): as an argument it's always passed in
+// memory - the ABI never register-allocates a long double, regardless
+// of how many integer/SSE registers are still free - and as a
+// ffi:"ret" field it comes back through ST0, the one value asmcall
+// doesn't load/store via loadword/storeword's generic machinery.
+type LongDouble [10]byte
+
+// longDoubleType is reflect.TypeOf(LongDouble{}), cached once for the
+// identity comparison buildSpec uses to single a LongDouble field out
+// before its generic struct/array aggregate handling ever sees it.
+var longDoubleType = reflect.TypeOf(LongDouble{})
+
+// Float64 converts l to the nearest float64, for Go code that wants to
+// do arithmetic on a value a C API handed back as long double rather
+// than carry the raw 80 bit representation around. This is necessarily
+// lossy: float64's mantissa is 52 bits wide against long double's 64,
+// so the low 11 bits of l's mantissa are truncated (not rounded) away;
+// a long double magnitude outside float64's narrower 11 bit exponent
+// range flushes to zero (too small) or infinity (too large) rather than
+// reconstructing a subnormal float64 - a long double in float64's own
+// subnormal range is one such "too small" value, and also flushes to
+// zero here rather than being denormalized.
+func (l LongDouble) Float64() float64 {
+	mant := uint64(l[0]) | uint64(l[1])<<8 | uint64(l[2])<<16 | uint64(l[3])<<24 |
+		uint64(l[4])<<32 | uint64(l[5])<<40 | uint64(l[6])<<48 | uint64(l[7])<<56
+	se := uint16(l[8]) | uint16(l[9])<<8
+	sign := uint64(se>>15) << 63
+	exp := se & 0x7FFF
+
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float64frombits(sign)
+	case exp == 0x7FFF:
+		if mant == 1<<63 {
+			return math.Float64frombits(sign | 0x7FF<<52) // infinity
+		}
+		return math.Float64frombits(sign | 0x7FF<<52 | 1) // NaN; payload not preserved
+	}
+
+	e := int32(exp) - 16383 + 1023    // rebias from long double's exponent to float64's
+	frac := (mant &^ (1 << 63)) >> 11 // drop the explicit integer bit, narrow 63->52 bits
+
+	switch {
+	case e <= 0:
+		return math.Float64frombits(sign) // underflows float64's range: flush to zero
+	case e >= 0x7FF:
+		return math.Float64frombits(sign | 0x7FF<<52) // overflows: flush to infinity
+	}
+	return math.Float64frombits(sign | uint64(e)<<52 | frac)
+}
+
+// LongDoubleFromFloat64 widens f to long double's 80 bit representation,
+// for passing a Go float64 to a C function expecting long double. The
+// widening is exact for a normal or subnormal float64: every one of its
+// 52 mantissa bits is preserved, just shifted up into the low bits of
+// long double's wider 64 bit mantissa, with the vacated low bits left
+// zero rather than carrying any extra precision float64 never had.
+func LongDoubleFromFloat64(f float64) LongDouble {
+	b := math.Float64bits(f)
+	sign := byte(b >> 63 << 7)
+	exp64 := int32((b >> 52) & 0x7FF)
+	frac := b & (1<<52 - 1)
+
+	var exp80 uint16
+	var mant80 uint64
+
+	switch {
+	case exp64 == 0 && frac == 0:
+		// zero: exp80/mant80 stay zero.
+	case exp64 == 0x7FF:
+		exp80 = 0x7FFF
+		if frac == 0 {
+			mant80 = 1 << 63 // infinity
+		} else {
+			mant80 = 1<<63 | frac<<11 // NaN
+		}
+	case exp64 == 0:
+		// subnormal float64: its value has a far larger exponent range
+		// available in long double, so normalize it by hand instead of
+		// carrying the subnormal-ness over.
+		hb := bits.Len64(frac) - 1
+		mant80 = 1<<63 | (frac&^(1<<uint(hb)))<<uint(63-hb)
+		exp80 = uint16(hb + 15309) // hb - 1074 + 16383
+	default:
+		exp80 = uint16(exp64 - 1023 + 16383)
+		mant80 = 1<<63 | frac<<11
+	}
+
+	var l LongDouble
+	for i := 0; i < 8; i++ {
+		l[i] = byte(mant80 >> (8 * uint(i)))
+	}
+	l[8] = byte(exp80)
+	l[9] = byte(exp80>>8) | sign
+	return l
+}
@@ -0,0 +1,77 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"math"
+	"testing"
+	"unsafe"
+)
+
+func addrOfLdTarget() uintptr
+func ldTarget()
+
+// ldRetArgs has a LongDouble ffi:"ret" field and nothing else: ldTarget
+// takes no arguments, so there's nothing for MakeSpec to classify but the
+// return value asmcall's dedicated ldret/FMOVXP path stores.
+type ldRetArgs struct {
+	R LongDouble `ffi:"ret"`
+}
+
+// TestCallLongDoubleReturn exercises MakeSpec's LongDouble special case
+// and asmcall's FMOVXP store end to end: ldTarget leaves math.Pi in ST0
+// exactly as a C function declared to return long double would, and
+// asmcall pops it into R rather than ret0/xmmret0.
+func TestCallLongDoubleReturn(t *testing.T) {
+	var args ldRetArgs
+	spec := MakeSpec(addrOfLdTarget(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	got := args.R.Float64()
+	if got != math.Pi {
+		t.Fatalf("long double return: got %v, want %v", got, math.Pi)
+	}
+}
+
+// TestLongDoubleFloat64RoundTrip confirms Float64/LongDoubleFromFloat64
+// round-trip exactly for ordinary finite values: float64's mantissa is a
+// strict subset of long double's, so widening and narrowing back loses
+// nothing as long as the magnitude stays within float64's own range.
+func TestLongDoubleFloat64RoundTrip(t *testing.T) {
+	for _, f := range []float64{0, 1, -1, math.Pi, -math.Pi, 1e300, -1e300, 5e-300} {
+		ld := LongDoubleFromFloat64(f)
+		if got := ld.Float64(); got != f {
+			t.Errorf("LongDoubleFromFloat64(%v).Float64() = %v, want %v", f, got, f)
+		}
+	}
+}
+
+// TestLongDoubleFloat64SubnormalFlushesToZero documents Float64's one
+// lossy corner beyond ordinary mantissa truncation: a value in float64's
+// own subnormal range doesn't reconstruct as a subnormal float64 - it
+// flushes to zero instead, since Float64 never denormalizes on the way
+// back down. LongDoubleFromFloat64 itself is still exact going the other
+// way; only converting back through Float64 loses this one.
+func TestLongDoubleFloat64SubnormalFlushesToZero(t *testing.T) {
+	ld := LongDoubleFromFloat64(math.SmallestNonzeroFloat64)
+	if got := ld.Float64(); got != 0 {
+		t.Errorf("Float64() of a float64-subnormal-range long double: got %v, want 0", got)
+	}
+}
+
+// TestLongDoubleSpecialValues confirms infinities and zero survive the
+// round trip; NaN only round-trips as "some NaN", since long double's
+// wider mantissa can't be asked to preserve a float64 NaN's payload
+// through a narrowing Float64() that was never told to keep it.
+func TestLongDoubleSpecialValues(t *testing.T) {
+	if got := LongDoubleFromFloat64(math.Inf(1)).Float64(); got != math.Inf(1) {
+		t.Errorf("+Inf round trip: got %v", got)
+	}
+	if got := LongDoubleFromFloat64(math.Inf(-1)).Float64(); got != math.Inf(-1) {
+		t.Errorf("-Inf round trip: got %v", got)
+	}
+	if got := LongDoubleFromFloat64(math.NaN()).Float64(); !math.IsNaN(got) {
+		t.Errorf("NaN round trip: got %v, want some NaN", got)
+	}
+}
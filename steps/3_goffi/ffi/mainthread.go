@@ -0,0 +1,59 @@
+package ffi
+
+import "runtime"
+
+// mainThreadJobs queues funcs for RunMainThread to run - see
+// MainThreadExecutor.
+var mainThreadJobs = make(chan func())
+
+// init locks whichever goroutine runs it to its OS thread for the rest
+// of the process's lifetime. init functions run before any other
+// goroutine has had a chance to start, on the same OS thread the process
+// itself started on - the one GTK/Cocoa require UI calls to originate
+// from - so this captures that thread once, here, rather than leaving it
+// to chance the way an ordinary goroutine's thread assignment is left.
+func init() {
+	runtime.LockOSThread()
+}
+
+// MainThreadExecutor routes calls onto the process's main OS thread, for
+// a library (GTK, Cocoa) that requires every call to originate from that
+// specific thread rather than just some one consistent thread the way
+// ThreadedLibrary (library_thread.go) provides. There is exactly one:
+// the package-level MainThread value.
+type MainThreadExecutor struct{}
+
+// MainThread is the single MainThreadExecutor for this process.
+var MainThread = MainThreadExecutor{}
+
+// Call runs fn on the main OS thread and blocks until it returns. fn is
+// typically a closure around Spec.Call for a Spec whose C library needs
+// this. RunMainThread must already be running on that thread - normally
+// because it's the last thing the real func main() does - or Call
+// blocks forever.
+func (MainThreadExecutor) Call(fn func()) {
+	done := make(chan struct{})
+	mainThreadJobs <- func() {
+		fn()
+		close(done)
+	}
+	<-done
+}
+
+// RunMainThread services MainThreadExecutor's job queue until
+// StopMainThread is called. It must be called from the same goroutine
+// that ran this package's init (in practice, directly from func main(),
+// before spawning any other goroutine that might call MainThread.Call) -
+// that's the one init's runtime.LockOSThread pinned to the process's
+// main OS thread.
+func RunMainThread() {
+	for job := range mainThreadJobs {
+		job()
+	}
+}
+
+// StopMainThread stops RunMainThread's loop once the job it's currently
+// running (if any) finishes.
+func StopMainThread() {
+	close(mainThreadJobs)
+}
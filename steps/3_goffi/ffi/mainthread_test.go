@@ -0,0 +1,51 @@
+package ffi
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestMainThreadExecutor confirms MainThread.Call's jobs actually run -
+// dispatched through RunMainThread's queue rather than, say, just being
+// dropped - from many concurrent callers, and that RunMainThread returns
+// once StopMainThread is called.
+//
+// It can't assert the jobs ran on any particular OS thread (there's no
+// portable, cgo-free way to ask the OS "which thread is this" from
+// inside this package), so it only exercises the queue/dispatch plumbing
+// MainThreadExecutor is built from - the same thing
+// TestThreadedLibrarySerializesCalls (library_thread_test.go) checks for
+// ThreadedLibrary.
+//
+// This test owns mainThreadJobs' only StopMainThread call in the whole
+// package: the channel can't be reopened once closed, so only one test
+// may retire it.
+func TestMainThreadExecutor(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		RunMainThread()
+		close(done)
+	}()
+
+	var n atomic.Int64
+	var wg sync.WaitGroup
+	const jobs = 50
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			MainThread.Call(func() {
+				n.Add(1)
+			})
+		}()
+	}
+	wg.Wait()
+
+	if got := n.Load(); got != jobs {
+		t.Fatalf("jobs run: got %d, want %d", got, jobs)
+	}
+
+	StopMainThread()
+	<-done
+}
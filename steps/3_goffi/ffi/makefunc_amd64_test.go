@@ -0,0 +1,27 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+// TestMakeFunc drives addPairFlatTarget (func_amd64_test.s) through
+// MakeFunc instead of a hand-written Args struct, confirming the
+// reflect.StructOf/reflect.MakeFunc plumbing marshals a plain func's
+// arguments and result the same way Func[Args].Call does for funcAddArgs.
+func TestMakeFunc(t *testing.T) {
+	var add func(a, b int64) int64
+	MakeFunc(addrOfAddPairFlatTarget(), &add)
+
+	if got := add(3, 4); got != 7 {
+		t.Fatalf("MakeFunc: got %v, want 7", got)
+	}
+}
+
+// TestMakeFuncNoResult exercises a func with no return value, the
+// t.NumOut() == 0 path through MakeFunc's generated closure.
+func TestMakeFuncNoResult(t *testing.T) {
+	var add func(a, b int64)
+	MakeFunc(addrOfAddPairFlatTarget(), &add)
+
+	add(3, 4) // just confirm this doesn't panic or block
+}
@@ -0,0 +1,75 @@
+package ffi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ManifestLibrary declares one library a Manifest requires: Path is
+// passed to Open, and Symbols lists every name Validate must be able to
+// resolve within it via Get. Flags defaults to Open's own default
+// (RTLD_NOW|RTLD_LOCAL) when zero.
+type ManifestLibrary struct {
+	Path    string
+	Flags   OpenFlags
+	Symbols []string
+}
+
+// Manifest is a declared set of libraries and symbols a program depends
+// on, meant to be Validated once at startup - typically from main, right
+// after flags/config are parsed - so a missing library or renamed symbol
+// surfaces as a single, complete startup error instead of a panic or
+// ErrUnknownSymbol from whichever Call happens to run into it first in
+// production.
+//
+// A Manifest only lists what to check; it doesn't itself open the
+// libraries for use. RegisterFunc (or Register) still does the real
+// binding - Validate just confirms, eagerly, that every symbol it will
+// need resolves before anything depends on that being true.
+type Manifest struct {
+	Libraries []ManifestLibrary
+}
+
+// Validate opens every library in m.Libraries and resolves every one of
+// its Symbols, closing each library again once its symbols have been
+// checked. It returns nil only if every library opened and every symbol
+// resolved; otherwise it returns a single error, built with errors.Join,
+// aggregating one entry per failure so a caller sees every missing
+// library or symbol at once rather than stopping at the first.
+func (m Manifest) Validate() error {
+	var errs []error
+	for _, lib := range m.Libraries {
+		errs = append(errs, validateManifestLibrary(lib)...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateManifestLibrary opens lib.Path, resolves each of lib.Symbols
+// against it, and closes it again, returning one error per problem
+// found (a failed Open short-circuits the symbol checks, since none of
+// them can succeed without a handle to resolve against).
+func validateManifestLibrary(lib ManifestLibrary) []error {
+	l, err := OpenWithFlags(lib.Path, lib.Flags)
+	if err != nil {
+		return []error{fmt.Errorf("ffi: manifest: open %s: %w", lib.Path, err)}
+	}
+	defer l.Close()
+
+	var errs []error
+	for _, sym := range lib.Symbols {
+		if _, err := l.Get(sym); err != nil {
+			errs = append(errs, fmt.Errorf("ffi: manifest: %s: symbol %s: %w", lib.Path, sym, err))
+		}
+	}
+	return errs
+}
+
+// MustValidate is Validate, but panics instead of returning a non-nil
+// error - for the common case of calling it from an init() or the top
+// of main, where there's no meaningful way to continue running with a
+// dependency the manifest says is missing.
+func (m Manifest) MustValidate() {
+	if err := m.Validate(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,76 @@
+package ffi
+
+import (
+	"strings"
+	"testing"
+)
+
+// libcPathForManifestTest returns a libc.so.6 path to validate against,
+// skipping the test instead of failing it on a system without one at the
+// usual path - the same accommodation library_reload_test.go's
+// openLibcForReloadTest makes for the same reason.
+func libcPathForManifestTest(t *testing.T) string {
+	t.Helper()
+	for _, path := range []string{"/lib/x86_64-linux-gnu/libc.so.6", "/usr/lib/x86_64-linux-gnu/libc.so.6"} {
+		if lib, err := Open(path); err == nil {
+			lib.Close()
+			return path
+		}
+	}
+	t.Skip("libc.so.6 not found at any known path")
+	return ""
+}
+
+func TestManifestValidateAllPresent(t *testing.T) {
+	path := libcPathForManifestTest(t)
+	m := Manifest{Libraries: []ManifestLibrary{
+		{Path: path, Symbols: []string{"abs", "strlen", "malloc"}},
+	}}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate: unexpected error: %v", err)
+	}
+}
+
+func TestManifestValidateAggregatesMissingSymbols(t *testing.T) {
+	path := libcPathForManifestTest(t)
+	m := Manifest{Libraries: []ManifestLibrary{
+		{Path: path, Symbols: []string{"abs", "no_such_symbol_1", "no_such_symbol_2"}},
+	}}
+	err := m.Validate()
+	if err == nil {
+		t.Fatal("Validate: expected an error, got nil")
+	}
+	for _, want := range []string{"no_such_symbol_1", "no_such_symbol_2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error %q missing %q", err.Error(), want)
+		}
+	}
+}
+
+func TestManifestValidateMissingLibrary(t *testing.T) {
+	m := Manifest{Libraries: []ManifestLibrary{
+		{Path: "/no/such/library.so.999", Symbols: []string{"abs"}},
+	}}
+	if err := m.Validate(); err == nil {
+		t.Fatal("Validate: expected an error for a missing library, got nil")
+	}
+}
+
+func TestManifestMustValidatePanics(t *testing.T) {
+	m := Manifest{Libraries: []ManifestLibrary{
+		{Path: "/no/such/library.so.999", Symbols: []string{"abs"}},
+	}}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustValidate: expected a panic, got none")
+		}
+	}()
+	m.MustValidate()
+}
+
+func TestManifestValidateNoErrorForEmptyManifest(t *testing.T) {
+	var m Manifest
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate: unexpected error for empty manifest: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfSumManyTarget and sumManyTarget (manyargs_amd64_test.s) stand in for
+// a C function taking more plain integer arguments than fit in the 6 SysV
+// integer registers, so the rest spill to spec.stack.
+func addrOfSumManyTarget() uintptr
+func sumManyTarget()
+
+// manyArgs has 20 int64 arguments: 6 land in RDI/RSI/RDX/RCX/R8/R9 and the
+// remaining 14 spill onto spec.stack, well past the 6-register capacity and
+// deep enough into maxCallStackArgs to exercise more than one stackloop
+// iteration.
+type manyArgs struct {
+	A0, A1, A2, A3, A4, A5, A6, A7, A8, A9           int64 `ffi:"arg"`
+	A10, A11, A12, A13, A14, A15, A16, A17, A18, A19 int64 `ffi:"arg"`
+	R                                                int64 `ffi:"ret"`
+}
+
+// TestCallManyStackArgs exercises asmcall's stackloop end to end: it drives
+// 14 stack-spilled arguments through the real trampoline (not just the
+// register-passed 6) and checks both their values and their order survive
+// the round trip, which only holds if asmcall's fixed stackargs buffer
+// places them at the offsets spec.fn expects and the call site remains
+// 16-byte aligned regardless of how many of maxCallStackArgs are in use.
+func TestCallManyStackArgs(t *testing.T) {
+	args := manyArgs{
+		A0: 1, A1: 2, A2: 3, A3: 4, A4: 5, A5: 6, A6: 7, A7: 8, A8: 9, A9: 10,
+		A10: 11, A11: 12, A12: 13, A13: 14, A14: 15, A15: 16, A16: 17, A17: 18, A18: 19, A19: 20,
+	}
+	spec := MakeSpec(addrOfSumManyTarget(), &args)
+
+	if got := len(spec.stack); got != 14 {
+		t.Fatalf("manyArgs: got %d stack args, want 14", got)
+	}
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	const want = 210 // 1+2+...+20
+	if args.R != want {
+		t.Fatalf("many stack args call: got R=%d, want %d", args.R, want)
+	}
+}
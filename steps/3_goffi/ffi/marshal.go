@@ -0,0 +1,97 @@
+package ffi
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Marshaler lets a user-defined type pass itself to, and read itself
+// back from, a C function in a layout of its own choosing, without
+// MakeSpec needing any case for its Go shape: a *T args field, where
+// T's pointer type implements Marshaler, is detected automatically and
+// placed as a plain pointer argument to a scratch buffer MakeSpec fills
+// with EncodeFFI's bytes before the call and reads back into T via
+// DecodeFFI after - the same trick ffi:"cstr" uses for a string field,
+// just backed by an arbitrary user encoding instead of a NUL-terminated
+// copy. A type converting between time.Duration and C's struct
+// timespec, or net.IP and struct in_addr, can be dropped into an args
+// struct this way without patching fieldToOffset or anything else in
+// this package's core classification.
+type Marshaler interface {
+	// EncodeFFI returns the C-compatible bytes a scratch buffer should
+	// hold in the receiver's place for the call.
+	EncodeFFI() ([]byte, error)
+	// DecodeFFI parses data - the same bytes EncodeFFI produced, as the
+	// callee may have rewritten them in place - back into the receiver.
+	DecodeFFI(data []byte) error
+}
+
+var marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+
+// marshalElem reports whether t is a type whose pointer implements
+// Marshaler - the shape a *T args field must point at for MakeSpec to
+// treat it as one instead of an ordinary pointer argument.
+func marshalElem(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(marshalerType)
+}
+
+// marshalField records a *T args field MakeSpec found pointing at a
+// Marshaler, for prepareMarshalFields to encode/decode through before
+// and after each call.
+type marshalField struct {
+	offset uint16
+	elem   reflect.Type
+}
+
+// prepareMarshalFields replaces every field in fields that currently
+// points at a live value with a pointer to a scratch buffer freshly
+// filled by that value's EncodeFFI, bump-allocated out of a (see
+// arena.go) the same way prepareCStrings backs an ffi:"cstr" field. A
+// nil field is left nil - there is no value to encode, and nothing for
+// the callee to write into either. It returns a cleanup that must run
+// once the call completes: it restores each field's original pointer
+// and calls DecodeFFI on whatever the callee wrote into its scratch
+// buffer.
+func prepareMarshalFields(args unsafe.Pointer, fields []marshalField, a *arena) func() {
+	if len(fields) == 0 {
+		return func() {}
+	}
+	saved := make([]unsafe.Pointer, len(fields))
+	bufs := make([][]byte, len(fields))
+	for i, mf := range fields {
+		data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(mf.offset)))
+		target := *data
+		saved[i] = target
+		if target == nil {
+			continue
+		}
+		m := reflect.NewAt(mf.elem, target).Interface().(Marshaler)
+		encoded, err := m.EncodeFFI()
+		if err != nil {
+			panic("ffi: EncodeFFI: " + err.Error())
+		}
+		buf := a.alloc(len(encoded))
+		copy(buf, encoded)
+		bufs[i] = buf
+		if len(buf) > 0 {
+			*data = unsafe.Pointer(&buf[0])
+		} else {
+			*data = nil
+		}
+	}
+	return func() {
+		for i, mf := range fields {
+			data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(mf.offset)))
+			target := saved[i]
+			*data = target
+			if target == nil {
+				continue
+			}
+			m := reflect.NewAt(mf.elem, target).Interface().(Marshaler)
+			if err := m.DecodeFFI(bufs[i]); err != nil {
+				panic("ffi: DecodeFFI: " + err.Error())
+			}
+		}
+		_Cgo_use(a)
+	}
+}
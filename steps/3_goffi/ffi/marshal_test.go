@@ -0,0 +1,131 @@
+package ffi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// fakeDuration stands in for a user type like time.Duration that wants to
+// cross the call boundary as a C-side fixed-width encoding of its own
+// choosing instead of its native Go representation.
+type fakeDuration struct {
+	nanos int64
+}
+
+func (d *fakeDuration) EncodeFFI() ([]byte, error) {
+	buf := make([]byte, 8)
+	for i := 0; i < 8; i++ {
+		buf[i] = byte(d.nanos >> (8 * i))
+	}
+	return buf, nil
+}
+
+func (d *fakeDuration) DecodeFFI(data []byte) error {
+	if len(data) != 8 {
+		return errors.New("fakeDuration: want 8 bytes")
+	}
+	var n int64
+	for i := 0; i < 8; i++ {
+		n |= int64(data[i]) << (8 * i)
+	}
+	d.nanos = n
+	return nil
+}
+
+// failDuration's EncodeFFI always errors, for TestPrepareMarshalFieldsEncodeErrorPanics.
+type failDuration struct{}
+
+func (failDuration) EncodeFFI() ([]byte, error) { return nil, errors.New("boom") }
+func (failDuration) DecodeFFI([]byte) error     { return nil }
+
+// TestMarshalElem confirms marshalElem only reports true for a type whose
+// pointer implements Marshaler.
+func TestMarshalElem(t *testing.T) {
+	if !marshalElem(reflect.TypeOf(fakeDuration{})) {
+		t.Fatal("marshalElem: want true for *fakeDuration implementing Marshaler")
+	}
+	if marshalElem(reflect.TypeOf(int64(0))) {
+		t.Fatal("marshalElem: want false for int64")
+	}
+}
+
+// TestPrepareMarshalFieldsRoundTrips confirms prepareMarshalFields swaps a
+// field's pointer for a scratch buffer holding EncodeFFI's bytes, and that
+// the returned cleanup restores the original pointer and decodes whatever
+// the callee left in the buffer back into the receiver.
+func TestPrepareMarshalFieldsRoundTrips(t *testing.T) {
+	type marshalArgs struct {
+		D *fakeDuration
+	}
+	args := marshalArgs{D: &fakeDuration{nanos: 42}}
+	field := marshalField{offset: uint16(fieldByName(t, args, "D").Offset), elem: reflect.TypeOf(fakeDuration{})}
+
+	a := acquireArena()
+	defer releaseArena(a)
+
+	cleanup := prepareMarshalFields(unsafe.Pointer(&args), []marshalField{field}, a)
+	if args.D == nil || uintptr(unsafe.Pointer(args.D)) == 0 {
+		t.Fatal("prepareMarshalFields: field was left nil")
+	}
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(args.D)), 8)
+	if buf[0] != 42 {
+		t.Fatalf("prepareMarshalFields: scratch buffer = %v, want encoded 42", buf)
+	}
+
+	// Simulate the callee overwriting the scratch buffer in place.
+	buf[0] = 7
+
+	cleanup()
+	if args.D == nil || args.D.nanos != 7 {
+		t.Fatalf("prepareMarshalFields: D.nanos = %v, want 7 after decode", args.D)
+	}
+}
+
+// TestPrepareMarshalFieldsSkipsNil confirms a nil field is left nil and
+// never passed to EncodeFFI/DecodeFFI.
+func TestPrepareMarshalFieldsSkipsNil(t *testing.T) {
+	type marshalArgs struct {
+		D *fakeDuration
+	}
+	args := marshalArgs{}
+	field := marshalField{offset: uint16(fieldByName(t, args, "D").Offset), elem: reflect.TypeOf(fakeDuration{})}
+
+	a := acquireArena()
+	defer releaseArena(a)
+
+	cleanup := prepareMarshalFields(unsafe.Pointer(&args), []marshalField{field}, a)
+	if args.D != nil {
+		t.Fatalf("prepareMarshalFields: nil field became %v", args.D)
+	}
+	cleanup()
+	if args.D != nil {
+		t.Fatalf("prepareMarshalFields: nil field became %v after cleanup", args.D)
+	}
+}
+
+// TestPrepareMarshalFieldsEncodeErrorPanics confirms an EncodeFFI error
+// panics with a plain string, matching Call's own existing panic
+// precedent rather than a SpecError.
+func TestPrepareMarshalFieldsEncodeErrorPanics(t *testing.T) {
+	type marshalArgs struct {
+		D *failDuration
+	}
+	args := marshalArgs{D: &failDuration{}}
+	field := marshalField{offset: uint16(fieldByName(t, args, "D").Offset), elem: reflect.TypeOf(failDuration{})}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("prepareMarshalFields: expected a panic on EncodeFFI error")
+		}
+		if _, ok := r.(string); !ok {
+			t.Fatalf("prepareMarshalFields: panic value = %v (%T), want string", r, r)
+		}
+	}()
+
+	a := acquireArena()
+	defer releaseArena(a)
+	prepareMarshalFields(unsafe.Pointer(&args), []marshalField{field}, a)
+}
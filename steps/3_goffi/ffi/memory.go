@@ -0,0 +1,157 @@
+package ffi
+
+import "unsafe"
+
+// Memory is a bounds-checked view over a C pointer+size pair - the
+// typed-accessor, slice-projecting counterpart to BytesAtUnsafe/
+// StringAtUnsafe (ptrat.go) for a caller that wants more than a single
+// copy or cast out of a C buffer: repeated ReadAt/WriteAt, typed field
+// access at a byte offset, or a narrower Memory over part of the same
+// buffer, all checked against size instead of trusting every offset by
+// hand the way raw unsafe.Pointer arithmetic would.
+//
+// A Memory doesn't own the bytes it views, the same as BytesAtUnsafe's
+// result doesn't: it stays valid only as long as ptr's own backing
+// memory does.
+type Memory struct {
+	ptr  uintptr
+	size uintptr
+}
+
+// MemoryAt returns a Memory view over the n bytes starting at ptr.
+func MemoryAt(ptr uintptr, n uintptr) Memory {
+	return Memory{ptr: ptr, size: n}
+}
+
+// Ptr returns m's base address.
+func (m Memory) Ptr() uintptr {
+	return m.ptr
+}
+
+// Len returns the number of bytes m views.
+func (m Memory) Len() uintptr {
+	return m.size
+}
+
+// checkBounds panics if the n bytes starting at off fall outside m,
+// the same loud failure a Go slice's own bounds check gives instead of
+// the silent out-of-bounds read/write raw pointer arithmetic on m.ptr
+// would.
+func (m Memory) checkBounds(off, n uintptr) {
+	if off > m.size || n > m.size-off {
+		panic("ffi: Memory: access out of bounds")
+	}
+}
+
+// Bytes returns a []byte aliasing the whole of m, with no copy - see
+// BytesAtUnsafe's warning about how long that memory, and so the
+// result, stays valid.
+func (m Memory) Bytes() []byte {
+	if m.size == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(m.ptr)), m.size)
+}
+
+// ReadAt copies len(dst) bytes starting at offset off within m into
+// dst, panicking if that range falls outside m.
+func (m Memory) ReadAt(off uintptr, dst []byte) {
+	m.checkBounds(off, uintptr(len(dst)))
+	copy(dst, unsafe.Slice((*byte)(unsafe.Pointer(m.ptr+off)), len(dst)))
+}
+
+// WriteAt copies src into m starting at offset off, panicking if that
+// range falls outside m.
+func (m Memory) WriteAt(off uintptr, src []byte) {
+	m.checkBounds(off, uintptr(len(src)))
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(m.ptr+off)), len(src)), src)
+}
+
+// Slice returns a Memory over the n bytes of m starting at offset off,
+// panicking if that range falls outside m - the same projection a Go
+// slice expression (m[off:off+n]) gives, for a caller that wants to
+// hand a narrower view to code that shouldn't see the rest of m.
+func (m Memory) Slice(off, n uintptr) Memory {
+	m.checkBounds(off, n)
+	return Memory{ptr: m.ptr + off, size: n}
+}
+
+// Uint8At reads a byte at offset off within m.
+func (m Memory) Uint8At(off uintptr) uint8 {
+	m.checkBounds(off, 1)
+	return *(*uint8)(unsafe.Pointer(m.ptr + off))
+}
+
+// SetUint8At writes a byte at offset off within m.
+func (m Memory) SetUint8At(off uintptr, v uint8) {
+	m.checkBounds(off, 1)
+	*(*uint8)(unsafe.Pointer(m.ptr + off)) = v
+}
+
+// Uint16At reads a uint16 at offset off within m, in the host's native
+// byte order - the same order the C struct or array it's viewing was
+// already laid out in, not a fixed endianness encoding/binary would
+// impose.
+func (m Memory) Uint16At(off uintptr) uint16 {
+	m.checkBounds(off, 2)
+	return *(*uint16)(unsafe.Pointer(m.ptr + off))
+}
+
+// SetUint16At writes a uint16 at offset off within m, in the host's
+// native byte order.
+func (m Memory) SetUint16At(off uintptr, v uint16) {
+	m.checkBounds(off, 2)
+	*(*uint16)(unsafe.Pointer(m.ptr + off)) = v
+}
+
+// Uint32At reads a uint32 at offset off within m, in the host's native
+// byte order.
+func (m Memory) Uint32At(off uintptr) uint32 {
+	m.checkBounds(off, 4)
+	return *(*uint32)(unsafe.Pointer(m.ptr + off))
+}
+
+// SetUint32At writes a uint32 at offset off within m, in the host's
+// native byte order.
+func (m Memory) SetUint32At(off uintptr, v uint32) {
+	m.checkBounds(off, 4)
+	*(*uint32)(unsafe.Pointer(m.ptr + off)) = v
+}
+
+// Uint64At reads a uint64 at offset off within m, in the host's native
+// byte order.
+func (m Memory) Uint64At(off uintptr) uint64 {
+	m.checkBounds(off, 8)
+	return *(*uint64)(unsafe.Pointer(m.ptr + off))
+}
+
+// SetUint64At writes a uint64 at offset off within m, in the host's
+// native byte order.
+func (m Memory) SetUint64At(off uintptr, v uint64) {
+	m.checkBounds(off, 8)
+	*(*uint64)(unsafe.Pointer(m.ptr + off)) = v
+}
+
+// Float32At reads a float32 at offset off within m.
+func (m Memory) Float32At(off uintptr) float32 {
+	m.checkBounds(off, 4)
+	return *(*float32)(unsafe.Pointer(m.ptr + off))
+}
+
+// SetFloat32At writes a float32 at offset off within m.
+func (m Memory) SetFloat32At(off uintptr, v float32) {
+	m.checkBounds(off, 4)
+	*(*float32)(unsafe.Pointer(m.ptr + off)) = v
+}
+
+// Float64At reads a float64 at offset off within m.
+func (m Memory) Float64At(off uintptr) float64 {
+	m.checkBounds(off, 8)
+	return *(*float64)(unsafe.Pointer(m.ptr + off))
+}
+
+// SetFloat64At writes a float64 at offset off within m.
+func (m Memory) SetFloat64At(off uintptr, v float64) {
+	m.checkBounds(off, 8)
+	*(*float64)(unsafe.Pointer(m.ptr + off)) = v
+}
@@ -0,0 +1,136 @@
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func memoryOverBuf(buf []byte) Memory {
+	if len(buf) == 0 {
+		return MemoryAt(0, 0)
+	}
+	return MemoryAt(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+}
+
+// TestMemoryReadWriteAtRoundTrips confirms WriteAt followed by ReadAt
+// at the same offset gives back the bytes written.
+func TestMemoryReadWriteAtRoundTrips(t *testing.T) {
+	buf := make([]byte, 16)
+	m := memoryOverBuf(buf)
+
+	m.WriteAt(4, []byte{1, 2, 3})
+	got := make([]byte, 3)
+	m.ReadAt(4, got)
+	if got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("ReadAt(4): got %v, want [1 2 3]", got)
+	}
+	if buf[4] != 1 || buf[5] != 2 || buf[6] != 3 {
+		t.Fatalf("WriteAt didn't touch the backing buffer: %v", buf)
+	}
+}
+
+// TestMemoryReadAtOutOfBoundsPanics confirms ReadAt past the end of m
+// panics instead of reading out of bounds.
+func TestMemoryReadAtOutOfBoundsPanics(t *testing.T) {
+	buf := make([]byte, 8)
+	m := memoryOverBuf(buf)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("ReadAt: expected a panic reading past the end of m")
+		}
+	}()
+	m.ReadAt(4, make([]byte, 8))
+}
+
+// TestMemoryWriteAtOutOfBoundsPanics confirms WriteAt past the end of m
+// panics instead of writing out of bounds.
+func TestMemoryWriteAtOutOfBoundsPanics(t *testing.T) {
+	buf := make([]byte, 8)
+	m := memoryOverBuf(buf)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WriteAt: expected a panic writing past the end of m")
+		}
+	}()
+	m.WriteAt(8, []byte{1})
+}
+
+// TestMemoryUint32AtRoundTrips confirms SetUint32At/Uint32At agree and
+// land at the right offset in the backing buffer.
+func TestMemoryUint32AtRoundTrips(t *testing.T) {
+	buf := make([]byte, 16)
+	m := memoryOverBuf(buf)
+
+	m.SetUint32At(4, 0xDEADBEEF)
+	if got := m.Uint32At(4); got != 0xDEADBEEF {
+		t.Fatalf("Uint32At(4): got %#x, want 0xdeadbeef", got)
+	}
+	if m.Uint32At(0) != 0 || m.Uint32At(8) != 0 {
+		t.Fatal("SetUint32At(4, ...) touched bytes outside offset 4..8")
+	}
+}
+
+// TestMemoryFloat64AtRoundTrips confirms SetFloat64At/Float64At agree.
+func TestMemoryFloat64AtRoundTrips(t *testing.T) {
+	buf := make([]byte, 16)
+	m := memoryOverBuf(buf)
+
+	m.SetFloat64At(0, 3.5)
+	if got := m.Float64At(0); got != 3.5 {
+		t.Fatalf("Float64At(0): got %v, want 3.5", got)
+	}
+}
+
+// TestMemoryUint32AtOutOfBoundsPanics confirms a too-close-to-the-end
+// offset panics rather than reading past m.
+func TestMemoryUint32AtOutOfBoundsPanics(t *testing.T) {
+	buf := make([]byte, 4)
+	m := memoryOverBuf(buf)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Uint32At: expected a panic reading past the end of m")
+		}
+	}()
+	m.Uint32At(1)
+}
+
+// TestMemorySliceProjectsNarrowerView confirms Slice returns a Memory
+// over the requested sub-range, sharing the same backing bytes.
+func TestMemorySliceProjectsNarrowerView(t *testing.T) {
+	buf := make([]byte, 16)
+	m := memoryOverBuf(buf)
+
+	sub := m.Slice(8, 4)
+	if sub.Len() != 4 {
+		t.Fatalf("sub.Len(): got %d, want 4", sub.Len())
+	}
+	sub.SetUint32At(0, 42)
+	if m.Uint32At(8) != 42 {
+		t.Fatal("Slice's view doesn't alias the parent Memory's backing bytes")
+	}
+}
+
+// TestMemorySliceOutOfBoundsPanics confirms Slice panics instead of
+// returning a view that reaches past m.
+func TestMemorySliceOutOfBoundsPanics(t *testing.T) {
+	buf := make([]byte, 8)
+	m := memoryOverBuf(buf)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Slice: expected a panic for a range past the end of m")
+		}
+	}()
+	m.Slice(4, 8)
+}
+
+// TestMemoryBytesAliasesBackingStorage confirms Bytes returns a slice
+// that sees writes made through the typed accessors, and vice versa.
+func TestMemoryBytesAliasesBackingStorage(t *testing.T) {
+	buf := make([]byte, 4)
+	m := memoryOverBuf(buf)
+
+	m.SetUint8At(0, 0xAB)
+	if got := m.Bytes(); got[0] != 0xAB {
+		t.Fatalf("Bytes()[0]: got %#x, want 0xab", got[0])
+	}
+}
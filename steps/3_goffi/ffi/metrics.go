@@ -0,0 +1,71 @@
+package ffi
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// symbolMetrics is metricsBySymbol's value type: one per distinct symbol
+// recordCall has ever been asked to record.
+type symbolMetrics struct {
+	count         atomic.Uint64
+	totalDuration atomic.Int64 // nanoseconds, per time.Duration
+	errors        sync.Map     // map[int32]*atomic.Uint64, keyed by errno
+}
+
+// metricsBySymbol holds one symbolMetrics per symbol a CollectMetrics-
+// enabled Spec has been called under, created lazily on first use - the
+// same sync.Map-keyed-by-first-use pattern specCache (cache.go) uses.
+var metricsBySymbol sync.Map // map[string]*symbolMetrics
+
+// recordCall updates symbol's metrics with one more call's outcome.
+// Called from Call (ffi_amd64.go) when CollectMetrics was set on the
+// Spec being called. errno is whatever the call returned regardless of
+// whether UseErrno was ever set on that Spec - callers that never set
+// it will see every call bucketed under errno 0, which
+// CallMetricsSnapshot's Errors map makes visible rather than hiding.
+func recordCall(symbol string, d time.Duration, errno int32) {
+	v, _ := metricsBySymbol.LoadOrStore(symbol, &symbolMetrics{})
+	m := v.(*symbolMetrics)
+	m.count.Add(1)
+	m.totalDuration.Add(int64(d))
+
+	ev, _ := m.errors.LoadOrStore(errno, new(atomic.Uint64))
+	ev.(*atomic.Uint64).Add(1)
+}
+
+// CallMetrics is one symbol's accumulated call metrics, as reported by
+// CallMetricsSnapshot.
+type CallMetrics struct {
+	Symbol        string
+	Count         uint64
+	TotalDuration time.Duration
+	Errors        map[int32]uint64
+}
+
+// CallMetricsSnapshot returns the current CallMetrics for every symbol
+// recorded so far, in no particular order. Only a Spec with
+// CollectMetrics enabled (ffi_amd64.go) and a symbol set via SetSymbol
+// contributes - a Spec called with neither is invisible here, the same
+// way SetTracer's TraceEvent.Symbol is empty for a Func built with
+// BindFunc (func.go, trace.go).
+func CallMetricsSnapshot() []CallMetrics {
+	var out []CallMetrics
+	metricsBySymbol.Range(func(k, v interface{}) bool {
+		m := v.(*symbolMetrics)
+		errs := make(map[int32]uint64)
+		m.errors.Range(func(ek, ev interface{}) bool {
+			errs[ek.(int32)] = ev.(*atomic.Uint64).Load()
+			return true
+		})
+		out = append(out, CallMetrics{
+			Symbol:        k.(string),
+			Count:         m.count.Load(),
+			TotalDuration: time.Duration(m.totalDuration.Load()),
+			Errors:        errs,
+		})
+		return true
+	})
+	return out
+}
@@ -0,0 +1,63 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCallCollectMetricsRecords exercises Call's CollectMetrics branch
+// (ffi_amd64.go) end to end: each call under a distinct symbol should
+// show up in CallMetricsSnapshot with the right count and an errno 0
+// entry, since benchTarget never sets errno.
+func TestCallCollectMetricsRecords(t *testing.T) {
+	const symbol = "TestCallCollectMetricsRecords.benchTarget"
+
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 1, B: 2}
+
+	spec := MakeSpec(fn, &args)
+	spec.SetSymbol(symbol)
+	spec.CollectMetrics(true)
+
+	spec.Call(unsafe.Pointer(&args))
+	spec.Call(unsafe.Pointer(&args))
+
+	var got *CallMetrics
+	for _, m := range CallMetricsSnapshot() {
+		if m.Symbol == symbol {
+			m := m
+			got = &m
+			break
+		}
+	}
+	if got == nil {
+		t.Fatalf("CallMetricsSnapshot: no entry for symbol %q", symbol)
+	}
+	if got.Count != 2 {
+		t.Errorf("Count: got %d, want 2", got.Count)
+	}
+	if n := got.Errors[0]; n != 2 {
+		t.Errorf("Errors[0]: got %d, want 2", n)
+	}
+}
+
+// TestCallNoCollectMetricsLeavesNoEntry confirms Call skips the metrics
+// bookkeeping entirely when CollectMetrics was never enabled.
+func TestCallNoCollectMetricsLeavesNoEntry(t *testing.T) {
+	const symbol = "TestCallNoCollectMetricsLeavesNoEntry.benchTarget"
+
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 1, B: 2}
+
+	spec := MakeSpec(fn, &args)
+	spec.SetSymbol(symbol)
+	spec.Call(unsafe.Pointer(&args))
+
+	for _, m := range CallMetricsSnapshot() {
+		if m.Symbol == symbol {
+			t.Fatalf("CallMetricsSnapshot: unexpected entry for symbol %q: %+v", symbol, m)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// addrOfIsNullTarget and isNullTarget (nil_amd64_test.s) stand in for a
+// C function reporting whether a pointer argument is NULL, the same way
+// addrOfAddPairTarget/addPairTarget stand in for one that adds two ints.
+func addrOfIsNullTarget() uintptr
+func isNullTarget()
+
+// nilEmptyArgs exercises the ffi:"nil=empty" tag: Buf is left nil, so
+// without the tag its Data word (and so RDI) would be NULL.
+type nilEmptyArgs struct {
+	Buf []byte `ffi:"arg,nil=empty"`
+	R   int64  `ffi:"ret"`
+}
+
+// TestCallNilEmptySubstitutesNonNullPointer drives isNullTarget through
+// a real Call with a nil ffi:"nil=empty" slice, confirming the callee
+// sees a non-NULL pointer and that Buf is still nil once the call
+// returns.
+func TestCallNilEmptySubstitutesNonNullPointer(t *testing.T) {
+	args := nilEmptyArgs{Buf: nil}
+	spec := MakeSpec(addrOfIsNullTarget(), &args)
+	spec.Call(unsafe.Pointer(&args))
+
+	if args.R != 0 {
+		t.Fatalf("isNullTarget reported NULL despite ffi:\"nil=empty\": R = %d", args.R)
+	}
+	if args.Buf != nil {
+		t.Fatalf("Buf was not restored to nil after the call: %#v", args.Buf)
+	}
+}
+
+// nilPanicArgs exercises the ffi:"nil=panic" tag.
+type nilPanicArgs struct {
+	Buf []byte `ffi:"arg,nil=panic"`
+	R   int64  `ffi:"ret"`
+}
+
+// TestCallNilPanicPanicsBeforeCalling confirms a nil ffi:"nil=panic"
+// slice panics before isNullTarget ever runs, rather than silently
+// passing NULL through.
+func TestCallNilPanicPanicsBeforeCalling(t *testing.T) {
+	args := nilPanicArgs{Buf: nil}
+	spec := MakeSpec(addrOfIsNullTarget(), &args)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Call did not panic on a nil ffi:\"nil=panic\" field")
+		}
+		if msg, ok := r.(string); !ok || !strings.Contains(msg, "nil=panic") {
+			t.Fatalf("panic value = %v, want a message mentioning nil=panic", r)
+		}
+	}()
+	spec.Call(unsafe.Pointer(&args))
+}
+
+// TestCallNilDefaultPassesNullUnchanged confirms an untagged nil slice
+// field still passes NULL through exactly as before this feature
+// existed - nil=empty/nil=panic are opt-in.
+func TestCallNilDefaultPassesNullUnchanged(t *testing.T) {
+	type plainSliceArgs struct {
+		Buf []byte `ffi:"arg"`
+		R   int64  `ffi:"ret"`
+	}
+	args := plainSliceArgs{Buf: nil}
+	spec := MakeSpec(addrOfIsNullTarget(), &args)
+	spec.Call(unsafe.Pointer(&args))
+
+	if args.R != 1 {
+		t.Fatalf("isNullTarget reported non-NULL for an untagged nil slice: R = %d", args.R)
+	}
+}
@@ -0,0 +1,95 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func mustPanic(t *testing.T, f func()) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("did not panic")
+		}
+	}()
+	f()
+}
+
+// TestNilArgOffsets confirms nilArg computes the same offset pinArg
+// would for a Slice field's Data word and for a bare pointer field.
+func TestNilArgOffsets(t *testing.T) {
+	type s struct {
+		Buf []byte
+		P   unsafe.Pointer
+	}
+	typ := reflect.TypeOf(s{})
+
+	bufField, _ := typ.FieldByName("Buf")
+	if got, want := nilArg(bufField, false, "panic").offset, pinArg(bufField, false); got != want {
+		t.Fatalf("Buf offset = %d, want %d", got, want)
+	}
+
+	pField, _ := typ.FieldByName("P")
+	if got, want := nilArg(pField, false, "empty").offset, pinArg(pField, false); got != want {
+		t.Fatalf("P offset = %d, want %d", got, want)
+	}
+}
+
+// TestNilArgRejectsBadInput confirms nilArg panics on a non-pointer
+// field, a ffi:"ret" field, and an unrecognized mode.
+func TestNilArgRejectsBadInput(t *testing.T) {
+	type s struct {
+		N   int
+		Buf []byte
+	}
+	typ := reflect.TypeOf(s{})
+	nField, _ := typ.FieldByName("N")
+	bufField, _ := typ.FieldByName("Buf")
+
+	mustPanic(t, func() { nilArg(nField, false, "panic") })
+	mustPanic(t, func() { nilArg(bufField, true, "panic") })
+	mustPanic(t, func() { nilArg(bufField, false, "bogus") })
+}
+
+// TestPrepareNilFieldsPanic confirms prepareNilFields panics on a NULL
+// word for a "panic"-mode field, without touching memory.
+func TestPrepareNilFieldsPanic(t *testing.T) {
+	var word uintptr
+	fields := []nilField{{offset: 0, empty: false}}
+	mustPanic(t, func() { prepareNilFields(unsafe.Pointer(&word), fields) })
+	if word != 0 {
+		t.Fatalf("word was modified by a panic-mode field: %d", word)
+	}
+}
+
+// TestPrepareNilFieldsEmptySubstitutesAndRestores confirms
+// prepareNilFields writes &emptyBuf into a NULL "empty"-mode word and
+// the returned restore func puts it back to NULL afterward, while
+// leaving an already non-NULL word untouched throughout.
+func TestPrepareNilFieldsEmptySubstitutesAndRestores(t *testing.T) {
+	var nonNil uintptr = 42
+	words := [2]uintptr{0, nonNil}
+	fields := []nilField{
+		{offset: 0, empty: true},
+		{offset: uint16(unsafe.Sizeof(uintptr(0))), empty: true},
+	}
+	restore := prepareNilFields(unsafe.Pointer(&words[0]), fields)
+
+	if words[0] == 0 {
+		t.Fatal("NULL word was not substituted")
+	}
+	if words[0] != uintptr(unsafe.Pointer(&emptyBuf)) {
+		t.Fatalf("substituted word = %#x, want &emptyBuf (%#x)", words[0], uintptr(unsafe.Pointer(&emptyBuf)))
+	}
+	if words[1] != nonNil {
+		t.Fatalf("non-NULL word was modified: got %d, want %d", words[1], nonNil)
+	}
+
+	restore()
+	if words[0] != 0 {
+		t.Fatalf("word was not restored to NULL: got %#x", words[0])
+	}
+	if words[1] != nonNil {
+		t.Fatalf("non-NULL word changed by restore: got %d, want %d", words[1], nonNil)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build darwin
+
+package ffi
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// objcLibPath is where libobjc.A.dylib lives on every supported macOS
+// version - the same fixed-dylib-path convention cstring_darwin.go's
+// libcPath and loader_darwin.go's libc_dlopen cgo_import_dynamic comment
+// already rely on for a system library that's part of the dynamic
+// shared cache.
+const objcLibPath = "/usr/lib/libobjc.A.dylib"
+
+// objcLib, objcMsgSendFn and objcMsgSendStretFn are lazily resolved on
+// first use by objcLibrary/ObjcMsgSendAddr/ObjcMsgSendStretAddr, the same
+// sync.Once-backed pattern cstring.go's libc uses for malloc/free: a
+// plain Go program never touches Cocoa, so there's no reason to dlopen
+// libobjc up front.
+var (
+	objcOnce          sync.Once
+	objcLib           *Library
+	objcSelRegisterFn uintptr
+	objcErr           error
+)
+
+// objcLibrary dlopens libobjc.A.dylib and resolves sel_registerName, the
+// one symbol every helper in this file needs regardless of which
+// Objective-C message is actually being sent.
+func objcLibrary() (*Library, error) {
+	objcOnce.Do(func() {
+		objcLib, objcErr = Open(objcLibPath)
+		if objcErr != nil {
+			return
+		}
+		objcSelRegisterFn, objcErr = objcLib.Get("sel_registerName")
+	})
+	return objcLib, objcErr
+}
+
+// selRegisterNameArgs is a plain MakeSpec argument struct for
+// sel_registerName(const char*) - it takes the selector's human-readable
+// name ("alloc", "initWithFrame:", "stringWithUTF8String:") and returns
+// the opaque SEL the rest of the Objective-C runtime expects in an
+// objc_msgSend call's second argument.
+type selRegisterNameArgs struct {
+	Name string  `ffi:"arg,cstr"`
+	R    uintptr `ffi:"ret"`
+}
+
+// RegisterSelector resolves name to its SEL via the Objective-C
+// runtime's sel_registerName, for use as the Sel field of whatever
+// argument struct a caller defines for ObjcMsgSendAddr/
+// ObjcMsgSendStretAddr - objc_msgSend takes a SEL, not a bare C string,
+// and the runtime only promises a given selector's identity is stable
+// once it's gone through registration.
+func RegisterSelector(name string) (uintptr, error) {
+	if _, err := objcLibrary(); err != nil {
+		return 0, err
+	}
+	args := selRegisterNameArgs{Name: name}
+	spec := MakeSpec(objcSelRegisterFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return args.R, nil
+}
+
+// ObjcMsgSendAddr resolves objc_msgSend, the Objective-C runtime's
+// ordinary message dispatch entry point, for id/small-struct/scalar
+// return types. Build a MakeSpec argument struct of your own shaped
+// like the particular message being sent - an `ffi:"arg"` id receiver, a
+// `ffi:"arg"` SEL (from RegisterSelector), then whatever further
+// arguments and `ffi:"ret"` return field the method itself takes - the
+// same way any other C function's call signature is described to this
+// package; objc_msgSend has no fixed signature of its own to wrap.
+func ObjcMsgSendAddr() (uintptr, error) {
+	lib, err := objcLibrary()
+	if err != nil {
+		return 0, err
+	}
+	return lib.Get("objc_msgSend")
+}
+
+// ObjcMsgSendStretAddr resolves objc_msgSend_stret, the struct-return
+// variant of objc_msgSend that the Objective-C message-send ABI requires
+// whenever the method being called returns a struct too large to fit in
+// registers (the same large-struct-return convention MakeSpec's own
+// ffi:"ret" classification already knows how to describe on the caller's
+// side - see buildSpec's return-field handling - but objc_msgSend itself
+// is implemented as two entirely different C functions depending on
+// which convention applies, unlike an ordinary struct-returning C
+// function). Use this instead of ObjcMsgSendAddr whenever the method's
+// return type needs it; the Objective-C runtime headers document exactly
+// which types qualify per architecture.
+func ObjcMsgSendStretAddr() (uintptr, error) {
+	lib, err := objcLibrary()
+	if err != nil {
+		return 0, err
+	}
+	return lib.Get("objc_msgSend_stret")
+}
@@ -0,0 +1,64 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// outStructArgs has one ffi:"out" field of each kind outArg supports: a
+// pointer to a single value and a slice of them.
+type outStructArgs struct {
+	P *[4]byte
+	S []byte
+}
+
+func fieldByName(t *testing.T, v interface{}, name string) reflect.StructField {
+	t.Helper()
+	f, ok := reflect.TypeOf(v).FieldByName(name)
+	if !ok {
+		t.Fatalf("fieldByName: no field %q on %T", name, v)
+	}
+	return f
+}
+
+// TestZeroOutFields confirms zeroOutFields clears both a Ptr and a Slice
+// ffi:"out" field's buffer.
+func TestZeroOutFields(t *testing.T) {
+	var buf [4]byte
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	sliceBuf := []byte{1, 2, 3}
+
+	args := outStructArgs{P: &buf, S: sliceBuf}
+	fields := []checkField{
+		outArg(fieldByName(t, args, "P")),
+		outArg(fieldByName(t, args, "S")),
+	}
+
+	zeroOutFields(unsafe.Pointer(&args), fields)
+
+	if buf != [4]byte{} {
+		t.Fatalf("zeroOutFields: Ptr buffer not cleared, got %v", buf)
+	}
+	for i, b := range sliceBuf {
+		if b != 0 {
+			t.Fatalf("zeroOutFields: Slice buffer not cleared at %d, got %v", i, sliceBuf)
+		}
+	}
+}
+
+// TestOutArgRejectsUnsupportedKind confirms outArg panics on a field that
+// is neither a pointer nor a slice, rather than silently ignoring it.
+func TestOutArgRejectsUnsupportedKind(t *testing.T) {
+	type badArgs struct {
+		N int64
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("outArg: expected a panic for a non-pointer, non-slice field")
+		}
+	}()
+	outArg(fieldByName(t, badArgs{}, "N"))
+}
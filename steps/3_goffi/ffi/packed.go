@@ -0,0 +1,139 @@
+package ffi
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// packedField records an `ffi:"packed"`/`ffi:"align=N"` args field -
+// always a pointer to a struct used as a by-pointer argument - for
+// preparePackedFields to copy through a correctly packed/aligned
+// scratch buffer before and after each call: elem is the struct type it
+// points at, size is that buffer's length, align is the minimum power
+// of two its address must land on (1 if the field only asked for
+// "packed"), and tight says whether the buffer holds elem's fields back
+// to back with no inter-field or trailing padding - #pragma pack(1)
+// layout - rather than elem's own Go layout verbatim.
+type packedField struct {
+	offset uint16
+	elem   reflect.Type
+	size   int
+	align  int
+	tight  bool
+}
+
+// packedArg validates an `ffi:"packed"`/`ffi:"align=N"` field and
+// returns the packedField MakeSpec stores for it: the tags only make
+// sense on a pointer to a struct, and not on an `ffi:"ret"` field -
+// there is no scratch buffer to copy a return value back out of, since
+// the callee writes through the field's own pointer either way.
+func packedArg(f reflect.StructField, tags fieldTags, ret bool) packedField {
+	if ret {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"packed\"/\"align=\" is not supported on a ffi:\"ret\" field"})
+	}
+	if f.Type.Kind() != reflect.Ptr || f.Type.Elem().Kind() != reflect.Struct {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"packed\"/\"align=\" only applies to a pointer-to-struct field"})
+	}
+	if tags.align < 0 || tags.align&(tags.align-1) != 0 {
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"align=\" must be a power of two"})
+	}
+	elem := f.Type.Elem()
+	align := tags.align
+	if align == 0 {
+		align = 1
+	}
+	size := int(elem.Size())
+	if tags.packed {
+		_, size = packedOffsets(elem)
+	}
+	return packedField{offset: uint16(f.Offset), elem: elem, size: size, align: align, tight: tags.packed}
+}
+
+// packedOffsets returns the #pragma pack(1) byte offset of each of t's
+// fields - tightly packed one after another with no inter-field or
+// trailing padding, unlike t's own Go layout - and the run's total
+// size.
+func packedOffsets(t reflect.Type) ([]int, int) {
+	offsets := make([]int, t.NumField())
+	off := 0
+	for i := 0; i < t.NumField(); i++ {
+		offsets[i] = off
+		off += int(t.Field(i).Type.Size())
+	}
+	return offsets, off
+}
+
+// encodePacked copies src - a live t value - into buf using offsets'
+// tight layout (see packedOffsets) instead of t's own Go offsets.
+func encodePacked(src unsafe.Pointer, t reflect.Type, offsets []int, buf []byte) {
+	for i, off := range offsets {
+		f := t.Field(i)
+		n := int(f.Type.Size())
+		copy(buf[off:off+n], unsafe.Slice((*byte)(unsafe.Pointer(uintptr(src)+f.Offset)), n))
+	}
+}
+
+// decodePacked is encodePacked in reverse: it writes buf's tightly
+// packed bytes back out into dst's own Go-laid-out fields.
+func decodePacked(dst unsafe.Pointer, t reflect.Type, offsets []int, buf []byte) {
+	for i, off := range offsets {
+		f := t.Field(i)
+		n := int(f.Type.Size())
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(uintptr(dst)+f.Offset)), n), buf[off:off+n])
+	}
+}
+
+// preparePackedFields replaces every field in fields that currently
+// points at a live value with a pointer to a scratch buffer - bump
+// allocated out of a at the field's own align, the same in-place
+// pointer-overwrite trick ffi:"cstr" uses for a string field's Data
+// word - filled with that value's bytes: tightly packed (see
+// encodePacked) for a "packed" field, or a plain copy of elem's own Go
+// layout for a field that only asked for "align=N". A nil field is left
+// nil - there is no value to copy, and nothing for the callee to write
+// into either. It returns a cleanup that must run once the call
+// completes: it copies the buffer back into the original target -
+// unpacking it first, for a "packed" field - and restores the field's
+// original pointer.
+func preparePackedFields(args unsafe.Pointer, fields []packedField, a *arena) func() {
+	if len(fields) == 0 {
+		return func() {}
+	}
+	saved := make([]unsafe.Pointer, len(fields))
+	bufs := make([][]byte, len(fields))
+	offsets := make([][]int, len(fields))
+	for i, pf := range fields {
+		data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(pf.offset)))
+		target := *data
+		saved[i] = target
+		if target == nil {
+			continue
+		}
+		buf := a.allocAligned(pf.size, pf.align)
+		if pf.tight {
+			offs, _ := packedOffsets(pf.elem)
+			offsets[i] = offs
+			encodePacked(target, pf.elem, offs, buf)
+		} else {
+			copy(buf, unsafe.Slice((*byte)(target), pf.size))
+		}
+		bufs[i] = buf
+		*data = unsafe.Pointer(&buf[0])
+	}
+	return func() {
+		for i, pf := range fields {
+			data := (*unsafe.Pointer)(unsafe.Pointer(uintptr(args) + uintptr(pf.offset)))
+			target := saved[i]
+			*data = target
+			if target == nil {
+				continue
+			}
+			if pf.tight {
+				decodePacked(target, pf.elem, offsets[i], bufs[i])
+			} else {
+				copy(unsafe.Slice((*byte)(target), pf.size), bufs[i])
+			}
+		}
+		_Cgo_use(a)
+	}
+}
@@ -0,0 +1,185 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// cTightHeader mirrors a C struct like:
+//
+//	struct __attribute__((packed)) header {
+//	    uint8_t  kind;
+//	    uint32_t id;
+//	    uint8_t  flags;
+//	};
+//
+// which Go's own layout rules would otherwise pad to 12 bytes (3 bytes
+// after kind, 3 trailing) instead of #pragma pack(1)'s 6.
+type cTightHeader struct {
+	Kind  uint8
+	ID    uint32
+	Flags uint8
+}
+
+// TestPackedOffsetsTightlyPacks confirms packedOffsets ignores t's own Go
+// padding and lays every field back to back instead.
+func TestPackedOffsetsTightlyPacks(t *testing.T) {
+	offsets, size := packedOffsets(reflect.TypeOf(cTightHeader{}))
+	want := []int{0, 1, 5}
+	for i, o := range want {
+		if offsets[i] != o {
+			t.Fatalf("packedOffsets: offsets[%d] = %d, want %d", i, offsets[i], o)
+		}
+	}
+	if size != 6 {
+		t.Fatalf("packedOffsets: size = %d, want 6", size)
+	}
+}
+
+// TestPreparePackedFieldsRoundTrips confirms a "packed" field's scratch
+// buffer holds the target's fields with no padding, and that cleanup
+// unpacks whatever the callee left in it back into the target's own
+// Go-laid-out fields.
+func TestPreparePackedFieldsRoundTrips(t *testing.T) {
+	type packedArgs struct {
+		P *cTightHeader
+	}
+	h := &cTightHeader{Kind: 7, ID: 0xDEADBEEF, Flags: 0xAB}
+	args := packedArgs{P: h}
+	_, size := packedOffsets(reflect.TypeOf(cTightHeader{}))
+	field := packedField{
+		offset: uint16(fieldByName(t, args, "P").Offset),
+		elem:   reflect.TypeOf(cTightHeader{}),
+		size:   size,
+		align:  1,
+		tight:  true,
+	}
+
+	a := acquireArena()
+	defer releaseArena(a)
+
+	cleanup := preparePackedFields(unsafe.Pointer(&args), []packedField{field}, a)
+	if args.P == h {
+		t.Fatal("preparePackedFields: pointer was not swapped for a scratch buffer")
+	}
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(args.P)), size)
+	want := []byte{7, 0xEF, 0xBE, 0xAD, 0xDE, 0xAB}
+	for i, b := range want {
+		if buf[i] != b {
+			t.Fatalf("preparePackedFields: buf[%d] = %#x, want %#x", i, buf[i], b)
+		}
+	}
+	// Simulate the callee overwriting ID's low byte in place.
+	buf[1] = 0x11
+
+	cleanup()
+	if args.P != h {
+		t.Fatal("preparePackedFields: cleanup did not restore the original pointer")
+	}
+	if h.Kind != 7 || h.Flags != 0xAB {
+		t.Fatalf("preparePackedFields: Kind/Flags = %d/%d, want untouched 7/0xAB", h.Kind, h.Flags)
+	}
+	if h.ID != 0xDEADBE11 {
+		t.Fatalf("preparePackedFields: ID = %#x, want %#x", h.ID, 0xDEADBE11)
+	}
+}
+
+// TestPreparePackedFieldsAlignOnly confirms an "align=N" field without
+// "packed" copies the target's own Go layout verbatim into a buffer
+// whose address lands on the requested alignment.
+func TestPreparePackedFieldsAlignOnly(t *testing.T) {
+	type wide struct {
+		X [3]byte
+	}
+	type packedArgs struct {
+		P *wide
+	}
+	w := &wide{X: [3]byte{1, 2, 3}}
+	args := packedArgs{P: w}
+	field := packedField{
+		offset: uint16(fieldByName(t, args, "P").Offset),
+		elem:   reflect.TypeOf(wide{}),
+		size:   int(reflect.TypeOf(wide{}).Size()),
+		align:  64,
+		tight:  false,
+	}
+
+	a := acquireArena()
+	defer releaseArena(a)
+
+	cleanup := preparePackedFields(unsafe.Pointer(&args), []packedField{field}, a)
+	if addr := uintptr(unsafe.Pointer(args.P)); addr%64 != 0 {
+		t.Fatalf("preparePackedFields: scratch buffer at %#x is not 64 byte aligned", addr)
+	}
+	if *args.P != *w {
+		t.Fatalf("preparePackedFields: copy = %+v, want %+v", *args.P, *w)
+	}
+
+	cleanup()
+	if args.P != w {
+		t.Fatal("preparePackedFields: cleanup did not restore the original pointer")
+	}
+}
+
+// TestPreparePackedFieldsSkipsNil confirms a nil field is left nil and
+// never copied through a scratch buffer.
+func TestPreparePackedFieldsSkipsNil(t *testing.T) {
+	type packedArgs struct {
+		P *cTightHeader
+	}
+	args := packedArgs{}
+	_, size := packedOffsets(reflect.TypeOf(cTightHeader{}))
+	field := packedField{
+		offset: uint16(fieldByName(t, args, "P").Offset),
+		elem:   reflect.TypeOf(cTightHeader{}),
+		size:   size,
+		align:  1,
+		tight:  true,
+	}
+
+	a := acquireArena()
+	defer releaseArena(a)
+
+	cleanup := preparePackedFields(unsafe.Pointer(&args), []packedField{field}, a)
+	if args.P != nil {
+		t.Fatalf("preparePackedFields: nil field became %v", args.P)
+	}
+	cleanup()
+	if args.P != nil {
+		t.Fatalf("preparePackedFields: nil field became %v after cleanup", args.P)
+	}
+}
+
+// TestPackedArgRejectsRetField confirms packedArg refuses to combine
+// "packed"/"align=N" with "ret" - there's no scratch buffer to decode a
+// return value back out of.
+func TestPackedArgRejectsRetField(t *testing.T) {
+	type packedArgs struct {
+		P *cTightHeader
+	}
+	f := fieldByName(t, packedArgs{}, "P")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("packedArg: expected a panic for a ret field")
+		}
+	}()
+	packedArg(f, fieldTags{packed: true}, true)
+}
+
+// TestPackedArgRejectsNonStructPointer confirms packedArg refuses a
+// "packed"/"align=N" field that isn't a pointer to a struct.
+func TestPackedArgRejectsNonStructPointer(t *testing.T) {
+	type packedArgs struct {
+		N int64
+	}
+	f := fieldByName(t, packedArgs{}, "N")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("packedArg: expected a panic for a non-pointer-to-struct field")
+		}
+	}()
+	packedArg(f, fieldTags{packed: true}, false)
+}
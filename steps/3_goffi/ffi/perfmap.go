@@ -0,0 +1,89 @@
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// perfMapEnabled is set once, from a GODEBUG=ffiperfmap=1 setting - the
+// same pattern pointerCheckEnabled (pointercheck.go) and
+// readonlyCheckEnabled (readonlycheck.go) use. Like those, it can't be
+// toggled once the program has started.
+var perfMapEnabled bool
+
+func init() {
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if setting == "ffiperfmap=1" {
+			perfMapEnabled = true
+			break
+		}
+	}
+	if perfMapEnabled {
+		OnSymbolBound(func(symbol string, addr uintptr) {
+			perfMapWrite(addr, perfMapUnknownSymbolSize, symbol)
+		})
+	}
+}
+
+// perfMapUnknownSymbolSize is the size perfMapWrite records for a
+// loader-resolved symbol (via the OnSymbolBound hook registered above):
+// neither getProcAddress nor any of the elf_*_unsupported.go stubs this
+// package builds against report a symbol's actual extent, only its
+// address. A whole page is a deliberately generous guess rather than 0 -
+// some versions of Linux perf drop a zero-size map entry on the floor
+// instead of attributing samples inside it to the nearest preceding
+// name, and a too-small guess just makes samples past it misattribute
+// to whatever the loader resolved next.
+const perfMapUnknownSymbolSize = 0x1000
+
+var (
+	perfMapOnce sync.Once
+	perfMapFile *os.File
+	perfMapMu   sync.Mutex
+)
+
+// perfMapWrite appends one "ADDR SIZE NAME" line to this process's
+// /tmp/perf-PID.map - the format Linux perf (and anything else that
+// reads a JIT's perf map) expects one entry per line, addr and size in
+// hex with no 0x prefix - opening the file on first use and leaving it
+// open and append-only for the rest of the process's life, the same way
+// a JIT that wants samples symbolized appends to this file as it
+// compiles rather than rewriting it each time. It's a no-op unless
+// GODEBUG=ffiperfmap=1 was set at startup, so a program that never asks
+// for this pays nothing beyond perfMapEnabled's one bool check per call
+// site; a failure to open the file is also silent, since a perf map is
+// diagnostic tooling, not something a caller should have to handle an
+// error from.
+func perfMapWrite(addr, size uintptr, name string) {
+	if !perfMapEnabled {
+		return
+	}
+
+	perfMapOnce.Do(func() {
+		path := "/tmp/perf-" + strconv.Itoa(os.Getpid()) + ".map"
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		perfMapFile = f
+	})
+	if perfMapFile == nil {
+		return
+	}
+
+	perfMapMu.Lock()
+	fmt.Fprintf(perfMapFile, "%x %x %s\n", addr, size, name)
+	perfMapMu.Unlock()
+}
+
+// perfMapAddThunk records a JIT-built thunk's exact address and code
+// size under name. It's called right after a ThunkAllocator.Alloc that
+// backs a callback trampoline (callback_amd64.go, signal_callback_amd64.go)
+// succeeds, where both are known precisely - unlike perfMapWrite's
+// OnSymbolBound path above, which only ever has a loader's best guess.
+func perfMapAddThunk(addr uintptr, codeSize int, name string) {
+	perfMapWrite(addr, uintptr(codeSize), name)
+}
@@ -0,0 +1,78 @@
+package ffi
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// withPerfMapEnabled forces perfMapEnabled on and resets perfMapOnce/
+// perfMapFile so the next perfMapWrite reopens (and, if it already
+// existed from an earlier test in this same process, reuses) this
+// process's real /tmp/perf-PID.map, then restores all three afterward -
+// the same save/restore-a-package-global shape
+// TestFireSymbolBoundRunsRegisteredHooks (loader_hooks_test.go) uses for
+// symbolBoundHooks.
+func withPerfMapEnabled(t *testing.T) string {
+	t.Helper()
+	savedEnabled := perfMapEnabled
+	savedOnce := perfMapOnce
+	savedFile := perfMapFile
+	perfMapEnabled = true
+	perfMapOnce = sync.Once{}
+	perfMapFile = nil
+	t.Cleanup(func() {
+		perfMapEnabled = savedEnabled
+		perfMapOnce = savedOnce
+		perfMapFile = savedFile
+	})
+	return "/tmp/perf-" + strconv.Itoa(os.Getpid()) + ".map"
+}
+
+// TestPerfMapWriteDisabledIsNoop confirms perfMapWrite does nothing
+// unless GODEBUG=ffiperfmap=1 enabled it at startup - the common case,
+// since this test binary itself almost certainly didn't set that.
+func TestPerfMapWriteDisabledIsNoop(t *testing.T) {
+	if perfMapEnabled {
+		t.Skip("GODEBUG=ffiperfmap=1 is set for this test binary")
+	}
+	perfMapWrite(0x1000, 0x10, "ffi.test.should.not.appear")
+	if perfMapFile != nil {
+		t.Fatal("perfMapWrite: opened a perf map file while disabled")
+	}
+}
+
+// TestPerfMapWriteAppendsLine confirms an enabled perfMapWrite appends a
+// line in ADDR SIZE NAME form to this process's perf map file.
+func TestPerfMapWriteAppendsLine(t *testing.T) {
+	path := withPerfMapEnabled(t)
+
+	perfMapWrite(0xdeadbeef, 0x40, "ffi.test.symbol")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if !strings.Contains(string(data), "deadbeef 40 ffi.test.symbol\n") {
+		t.Fatalf("perf map %s: got %q, want a line for ffi.test.symbol", path, data)
+	}
+}
+
+// TestPerfMapAddThunkUsesExactSize confirms perfMapAddThunk records
+// codeSize as given, not perfMapUnknownSymbolSize's loader-resolved
+// guess.
+func TestPerfMapAddThunkUsesExactSize(t *testing.T) {
+	path := withPerfMapEnabled(t)
+
+	perfMapAddThunk(0xcafe0000, 7, "ffi.test.thunk")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if !strings.Contains(string(data), "cafe0000 7 ffi.test.thunk\n") {
+		t.Fatalf("perf map %s: got %q, want a 7-byte entry for ffi.test.thunk", path, data)
+	}
+}
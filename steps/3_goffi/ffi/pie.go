@@ -0,0 +1,39 @@
+package ffi
+
+// A note on -buildmode=pie and external linking, since neither gets any
+// dedicated code in this package - both already work, or don't, as a
+// consequence of decisions made for unrelated reasons elsewhere, and
+// it's worth writing down why rather than leaving that to be
+// rediscovered by reading assembly.
+//
+// funcPC (funcpc.go) reads a function's entry PC out of its funcval
+// struct at runtime, after the loader has already applied every
+// relocation a PIE binary needs - ASLR's randomized base included. There
+// is no address baked in anywhere ahead of that point for ASLR to
+// invalidate; funcPC's result is exactly as correct under PIE as it is
+// under a fixed-base executable, for free.
+//
+// _cgo_init's static initializer (see runtime.go's doc comment, and
+// cgoinit_amd64.s and its per-arch siblings) is the one place this
+// package ever asks the linker to bake in a cross-symbol address ahead
+// of runtime. That's still PIE-safe: DATA _cgo_init(SB)/8,
+// $x_cgo_init(SB) asks the assembler for a relocation against
+// x_cgo_init, not a literal address, and a relocation is exactly what a
+// PIE-aware linker already knows how to emit as a load-time (or
+// ASLR-base-relative) fixup instead of an absolute constant - the same
+// way it would for any other global function pointer initialized to
+// another symbol's address. Nothing about going through the assembler
+// instead of a post-link patch changed that; if anything, removing the
+// patch removed the one step in the old design that genuinely was
+// PIE-hostile, since overwriting a fixed .data offset after the fact
+// has no way to know what base address PIE's loader chose.
+//
+// External linking (-ldflags=-linkmode=external) is a different matter:
+// it requires cgo support to be enabled in the toolchain at all
+// (CGO_ENABLED=1), and turning that on is exactly the scenario
+// cgoref/refgen's own doc comment already flags as a collision - a real
+// runtime/cgo pulled into the same binary defines its own x_cgo_init,
+// competing with this package's. External-linking compatibility isn't
+// blocked by anything PIE- or ASLR-related; it's blocked on the same
+// real-cgo coexistence problem any other mixed binary has, which is a
+// separate concern from this one.
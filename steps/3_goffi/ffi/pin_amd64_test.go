@@ -0,0 +1,38 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// pinArgs exercises the ffi:"pin" tag: S is a plain *byte, classified
+// exactly like any other Ptr field (fieldToOffset's type64 case), but
+// also recorded in spec.pinFields so Call pins the byte it points at for
+// the call's duration.
+type pinArgs struct {
+	S *byte `ffi:"arg,pin"`
+	R int64 `ffi:"ret"`
+}
+
+// TestCallPinnedPointer drives strlenTarget (cstr_amd64_test.s) through a
+// ffi:"pin" field instead of ffi:"cstr": S is passed through unchanged,
+// not marshaled into a fresh buffer, so this only proves Call's
+// preparePinning/Pinner round trip doesn't corrupt S or panic on a live
+// pointer.
+func TestCallPinnedPointer(t *testing.T) {
+	buf := []byte("hello\x00")
+	args := pinArgs{S: &buf[0]}
+	spec := MakeSpec(addrOfStrlenTarget(), &args)
+
+	if got := len(spec.pinFields); got != 1 {
+		t.Fatalf("pinFields: got %d entries, want 1", got)
+	}
+
+	spec.Call(unsafe.Pointer(&args))
+
+	if args.R != 5 {
+		t.Fatalf("pinned call: got R=%d, want 5", args.R)
+	}
+}
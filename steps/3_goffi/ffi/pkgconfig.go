@@ -0,0 +1,120 @@
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// defaultPkgConfigLibDirs is searched after pkg-config's own -L output,
+// the same role defaultDirs plays in resolveLibraryPath
+// (elf_rpath_unsupported.go): a handful of hard-coded system directories
+// rather than a real /etc/ld.so.conf parse, which is a separable piece
+// of work with no bearing on this helper either.
+var defaultPkgConfigLibDirs = []string{
+	"/usr/lib", "/usr/lib64", "/lib", "/lib64",
+	"/usr/local/lib", "/usr/local/lib64",
+}
+
+// PkgConfigLibraryPath resolves name - a pkg-config module name, e.g.
+// "gtk+-3.0" - to the shared library file its "pkg-config --libs"
+// output points at, without opening it: a distro's .pc file is the one
+// place that records the actual library name and search directories a
+// package was built with, which is why the same module name resolves to
+// a different file (a different directory, or a different soname
+// suffix) on Fedora than on Debian, or in a vendored build with its own
+// .pc alongside it.
+//
+// Only the unversioned "lib<name>.so"/".dylib" candidates pkg-config's
+// -L directories (searched first) and defaultPkgConfigLibDirs (searched
+// after) actually contain are considered - the same limitation
+// resolveLibraryPath's own doc comment notes for not parsing
+// /etc/ld.so.cache: a -dev package's .pc typically sits next to that
+// unversioned development symlink rather than the versioned runtime
+// soname (libfoo.so.2) ld.so would resolve at link time, so that's the
+// file this looks for too.
+func PkgConfigLibraryPath(name string) (string, error) {
+	return pkgConfigLibraryPath(name, runPkgConfigLibs, defaultPkgConfigLibDirs, pkgConfigFileExists)
+}
+
+// OpenPkgConfig is PkgConfigLibraryPath followed by Open, for the common
+// case of wanting the library loaded rather than just its path.
+func OpenPkgConfig(name string) (*Library, error) {
+	path, err := PkgConfigLibraryPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return Open(path)
+}
+
+// pkgConfigLibraryPath is PkgConfigLibraryPath's logic with libs and
+// exists injected, the same way resolveLibraryPath takes its own exists
+// func, so the search can be exercised against fake pkg-config output
+// and a fake filesystem instead of the real ones.
+func pkgConfigLibraryPath(name string, libs func(string) ([]string, []string, error), defaultDirs []string, exists func(string) bool) (string, error) {
+	libDirs, libNames, err := libs(name)
+	if err != nil {
+		return "", err
+	}
+	if len(libNames) == 0 {
+		return "", fmt.Errorf("ffi: pkg-config --libs %s: no -l flags in output", name)
+	}
+
+	searchDirs := make([]string, 0, len(libDirs)+len(defaultDirs))
+	searchDirs = append(searchDirs, libDirs...)
+	searchDirs = append(searchDirs, defaultDirs...)
+
+	for _, libName := range libNames {
+		for _, suffix := range sharedLibSuffixes() {
+			for _, dir := range searchDirs {
+				candidate := dir + "/lib" + libName + suffix
+				if exists(candidate) {
+					return candidate, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("ffi: pkg-config %s: none of %v found under %v", name, libNames, searchDirs)
+}
+
+// pkgConfigFileExists is pkgConfigLibraryPath's real exists check - a
+// plain os.Stat, kept local rather than shared with
+// elf_rpath_unsupported.go's fileExists so this file doesn't inherit
+// that one's !windows && !darwin build tag; there's nothing loader- or
+// ELF-specific about stat-ing a candidate path.
+func pkgConfigFileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// sharedLibSuffixes lists the shared-library filename suffix(es) worth
+// trying on the current OS, in preference order.
+func sharedLibSuffixes() []string {
+	if runtime.GOOS == "darwin" {
+		return []string{".dylib"}
+	}
+	return []string{".so"}
+}
+
+// runPkgConfigLibs is pkgConfigLibraryPath's real libs func: it shells
+// out to the pkg-config binary itself (there's no .pc parser here -
+// pkg-config already has to be installed for the C library's own build
+// to have found it, so reusing it avoids reimplementing variable
+// expansion and Requires: chasing) and splits its -L/-l output.
+func runPkgConfigLibs(name string) (libDirs []string, libNames []string, err error) {
+	out, err := exec.Command("pkg-config", "--libs", name).Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ffi: pkg-config --libs %s: %w", name, err)
+	}
+	for _, tok := range strings.Fields(string(out)) {
+		switch {
+		case strings.HasPrefix(tok, "-L"):
+			libDirs = append(libDirs, tok[2:])
+		case strings.HasPrefix(tok, "-l"):
+			libNames = append(libNames, tok[2:])
+		}
+	}
+	return libDirs, libNames, nil
+}
@@ -0,0 +1,86 @@
+package ffi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakePkgConfigFS returns an exists func that reports present for
+// exactly the given paths - pkgconfig_test.go's own copy of
+// elf_rpath_unsupported_test.go's fakeFS, kept separate so this file
+// doesn't depend on that one's !windows && !darwin build tag.
+func fakePkgConfigFS(present ...string) func(string) bool {
+	set := make(map[string]bool, len(present))
+	for _, p := range present {
+		set[p] = true
+	}
+	return func(path string) bool { return set[path] }
+}
+
+// fakePkgConfigLibs returns a libs func that reports the given -L dirs
+// and -l names for exactly name, and errors for anything else.
+func fakePkgConfigLibs(name string, dirs, names []string) func(string) ([]string, []string, error) {
+	return func(got string) ([]string, []string, error) {
+		if got != name {
+			return nil, nil, fmt.Errorf("unexpected pkg-config name %q", got)
+		}
+		return dirs, names, nil
+	}
+}
+
+// TestPkgConfigLibraryPathPrefersPkgConfigDirs confirms a -L directory
+// pkg-config reports is searched before defaultPkgConfigLibDirs.
+func TestPkgConfigLibraryPathPrefersPkgConfigDirs(t *testing.T) {
+	libs := fakePkgConfigLibs("gtk+-3.0", []string{"/opt/gtk/lib"}, []string{"gtk-3"})
+	exists := fakePkgConfigFS("/opt/gtk/lib/libgtk-3.so", "/usr/lib/libgtk-3.so")
+
+	got, err := pkgConfigLibraryPath("gtk+-3.0", libs, []string{"/usr/lib"}, exists)
+	if err != nil {
+		t.Fatalf("pkgConfigLibraryPath: %v", err)
+	}
+	if got != "/opt/gtk/lib/libgtk-3.so" {
+		t.Fatalf("pkgConfigLibraryPath = %q, want /opt/gtk/lib/libgtk-3.so", got)
+	}
+}
+
+// TestPkgConfigLibraryPathFallsBackToDefaultDirs confirms defaultDirs is
+// still searched when the -L directories pkg-config reports don't
+// contain the library.
+func TestPkgConfigLibraryPathFallsBackToDefaultDirs(t *testing.T) {
+	libs := fakePkgConfigLibs("sqlite3", []string{"/opt/nothere"}, []string{"sqlite3"})
+	exists := fakePkgConfigFS("/usr/lib/libsqlite3.so")
+
+	got, err := pkgConfigLibraryPath("sqlite3", libs, []string{"/usr/lib"}, exists)
+	if err != nil {
+		t.Fatalf("pkgConfigLibraryPath: %v", err)
+	}
+	if got != "/usr/lib/libsqlite3.so" {
+		t.Fatalf("pkgConfigLibraryPath = %q, want /usr/lib/libsqlite3.so", got)
+	}
+}
+
+// TestPkgConfigLibraryPathNoMatch confirms a descriptive error, not a
+// panic or an empty success, when none of the candidate files exist.
+func TestPkgConfigLibraryPathNoMatch(t *testing.T) {
+	libs := fakePkgConfigLibs("missing", nil, []string{"missing"})
+	exists := fakePkgConfigFS()
+
+	_, err := pkgConfigLibraryPath("missing", libs, []string{"/usr/lib"}, exists)
+	if err == nil {
+		t.Fatal("pkgConfigLibraryPath: expected an error, got nil")
+	}
+}
+
+// TestPkgConfigLibraryPathPropagatesLibsError confirms a libs failure
+// (e.g. pkg-config not installed, or the module name unknown to it) is
+// returned as-is rather than masked behind a "no match" error.
+func TestPkgConfigLibraryPathPropagatesLibsError(t *testing.T) {
+	wantErr := errors.New("pkg-config: not found")
+	libs := func(string) ([]string, []string, error) { return nil, nil, wantErr }
+
+	_, err := pkgConfigLibraryPath("whatever", libs, nil, fakePkgConfigFS())
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("pkgConfigLibraryPath error = %v, want %v", err, wantErr)
+	}
+}
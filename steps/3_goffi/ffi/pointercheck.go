@@ -0,0 +1,117 @@
+package ffi
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// pointerCheckEnabled is set once, from a GODEBUG=ffipointercheck=1
+// setting, by init below. Like the standard library's own GODEBUG knobs,
+// it can't be toggled once the program has started.
+var pointerCheckEnabled bool
+
+func init() {
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if setting == "ffipointercheck=1" {
+			pointerCheckEnabled = true
+			break
+		}
+	}
+}
+
+//go:linkname cgoCheckPointer runtime.cgoCheckPointer
+func cgoCheckPointer(ptr interface{}, arg interface{})
+
+// checkField records a struct offset and its field's reflect.Type, so
+// checkArgPointers can reconstruct a properly-typed value at that offset
+// for cgoCheckPointer to walk - the same check generated cgo code runs on
+// every argument, applied here to whichever of this package's own fields
+// can hold one.
+type checkField struct {
+	offset uint16
+	typ    reflect.Type
+}
+
+// pointerCheckField reports whether f is a field checkArgPointers should
+// validate under GODEBUG=ffipointercheck=1: one whose Go type might hold a
+// Go pointer somewhere inside it. ffi:"cstr" and ffi:"pin" fields are
+// exempted - both already carry their own, more specific contract for
+// what they point to and how it stays valid for the call, the same reason
+// cgo's own pointer check doesn't apply to its special forms either.
+func pointerCheckField(f reflect.StructField, tags fieldTags) (checkField, bool) {
+	if tags.cstr || tags.pin {
+		return checkField{}, false
+	}
+	switch f.Type.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Slice, reflect.Struct, reflect.Array:
+		return checkField{offset: uint16(f.Offset), typ: f.Type}, true
+	}
+	return checkField{}, false
+}
+
+// outArg validates an `ffi:"out"` field and returns the checkField MakeSpec
+// stores for it: the same {offset, typ} pair pointerCheckField already
+// builds for an ordinary pointer/slice argument, just driven by
+// zeroOutFields and a second checkArgPointers pass instead of only the one
+// before the call. out exists on top of whatever placement the field's own
+// Kind already gets it (typically a plain Ptr or Slice argument, as
+// fieldToOffset would place it) - it's pure metadata layered over that, not
+// a new way to pass the field.
+func outArg(f reflect.StructField) checkField {
+	switch f.Type.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return checkField{offset: uint16(f.Offset), typ: f.Type}
+	}
+	panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "ffi:\"out\" on a field that isn't a pointer or slice"})
+}
+
+// zeroOutFields overwrites every ffi:\"out\" field's buffer with zeros right
+// before a debug-mode call, so a C function that doesn't actually write
+// anything can't be mistaken for one that wrote zeros on purpose, and a
+// short write is easier to spot against a known-zero background. Called
+// only when pointerCheckEnabled, for the same reason checkArgPointers is:
+// walking every out field on every call is too expensive to leave on
+// unconditionally.
+func zeroOutFields(args unsafe.Pointer, fields []checkField) {
+	for _, cf := range fields {
+		p := unsafe.Pointer(uintptr(args) + uintptr(cf.offset))
+		switch cf.typ.Kind() {
+		case reflect.Slice:
+			sh := (*reflect.SliceHeader)(p)
+			if sh.Data == 0 || sh.Len == 0 {
+				continue
+			}
+			n := uintptr(sh.Len) * cf.typ.Elem().Size()
+			clear(unsafe.Slice((*byte)(unsafe.Pointer(sh.Data)), n))
+		case reflect.Ptr:
+			ptr := *(*unsafe.Pointer)(p)
+			if ptr == nil {
+				continue
+			}
+			clear(unsafe.Slice((*byte)(ptr), cf.typ.Elem().Size()))
+		}
+	}
+}
+
+// checkArgPointers asks the runtime's cgo pointer checker whether any of
+// fields (built by pointerCheckField at MakeSpec time) is a Go pointer to
+// memory that itself contains an unpinned Go pointer - cgo's own rule for
+// what a foreign call may safely be handed. It panics, via
+// cgoCheckPointer, the same way an equivalent cgo call would. Called only
+// when pointerCheckEnabled, since reflecting over every argument on every
+// call is too expensive to leave on unconditionally.
+//
+// Call also runs this a second time, after the call, against spec's
+// ffi:"out" fields - not a true C-side buffer-overrun check, which nothing
+// on the Go side of this call can observe, but the same Go-pointer
+// validity check repeated once the callee has had a chance to write
+// through it, catching a buffer whose backing Go allocation no longer
+// passes the check it passed going in.
+func checkArgPointers(args unsafe.Pointer, fields []checkField) {
+	for _, cf := range fields {
+		v := reflect.NewAt(cf.typ, unsafe.Pointer(uintptr(args)+uintptr(cf.offset))).Elem().Interface()
+		cgoCheckPointer(v, nil)
+	}
+}
@@ -0,0 +1,61 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// pointerCheckArgs exercises every kind pointerCheckField recognizes: Bad
+// is a Go pointer to a Go pointer, the exact shape cgo's rules forbid;
+// Good is a Go pointer to a pointer-free byte, which they allow; Str and
+// Pinned are excluded via their ffi:"cstr"/ffi:"pin" tags, so neither
+// should ever reach cgoCheckPointer even though both are pointer-shaped.
+type pointerCheckArgs struct {
+	Bad    **int64
+	Good   *byte
+	Str    string `ffi:"cstr"`
+	Pinned *int64 `ffi:"pin"`
+}
+
+func TestPointerCheckField(t *testing.T) {
+	typ := reflect.TypeOf(pointerCheckArgs{})
+	want := map[string]bool{"Bad": true, "Good": true, "Str": false, "Pinned": false}
+
+	for name, expect := range want {
+		f, _ := typ.FieldByName(name)
+		_, got := pointerCheckField(f, parseFieldTags(f))
+		if got != expect {
+			t.Errorf("pointerCheckField(%s): got %v, want %v", name, got, expect)
+		}
+	}
+}
+
+func TestCheckArgPointersRejectsGoPointerToGoPointer(t *testing.T) {
+	n := int64(5)
+	p := &n
+	args := pointerCheckArgs{Bad: &p}
+	f, _ := reflect.TypeOf(args).FieldByName("Bad")
+	cf, ok := pointerCheckField(f, parseFieldTags(f))
+	if !ok {
+		t.Fatal("pointerCheckField(Bad) = false, want true")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("checkArgPointers did not panic on a Go pointer to a Go pointer")
+		}
+	}()
+	checkArgPointers(unsafe.Pointer(&args), []checkField{cf})
+}
+
+func TestCheckArgPointersAllowsPointerFreeTarget(t *testing.T) {
+	b := byte(1)
+	args := pointerCheckArgs{Good: &b}
+	f, _ := reflect.TypeOf(args).FieldByName("Good")
+	cf, ok := pointerCheckField(f, parseFieldTags(f))
+	if !ok {
+		t.Fatal("pointerCheckField(Good) = false, want true")
+	}
+	checkArgPointers(unsafe.Pointer(&args), []checkField{cf})
+}
@@ -0,0 +1,20 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCallMaskAsyncPreempt confirms enabling MaskAsyncPreempt doesn't
+// break an ordinary call - noopTarget (errno_amd64_test.go) is the same
+// trivial callee TestCallErrnoCapture already uses, this time driven
+// through Call rather than callAsmDirect, so invoke's mask/restore
+// bracket around asmcgocall actually runs.
+func TestCallMaskAsyncPreempt(t *testing.T) {
+	spec := MakeSpec(addrOfNoopTarget(), &noArgs{})
+	spec.MaskAsyncPreempt(true)
+	var args noArgs
+	spec.Call(unsafe.Pointer(&args))
+}
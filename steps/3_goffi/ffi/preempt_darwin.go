@@ -0,0 +1,83 @@
+//go:build darwin
+
+package ffi
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Darwin's pthread_sigmask(3) how values (sys/signal.h) - unlike
+// Linux's rt_sigprocmask, these don't start at 0.
+const (
+	darwinSigBlock   = 1
+	darwinSigSetMask = 3
+)
+
+// sigmaskLibc lazily opens libcPath and resolves pthread_sigmask from
+// it, the same Open+Get two-step sigactionLibc (signal_darwin.go) uses -
+// Darwin has no raw-syscall path for this the way Linux's
+// rt_sigprocmask (preempt_linux.go) does, so it has to go through libc
+// like every other Darwin primitive in this package.
+func sigmaskLibc() (uintptr, error) {
+	sigmaskOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			sigmaskErr = openErr
+			return
+		}
+		sigmaskFn, sigmaskErr = lib.Get("pthread_sigmask")
+	})
+	return sigmaskFn, sigmaskErr
+}
+
+var (
+	sigmaskOnce sync.Once
+	sigmaskFn   uintptr
+	sigmaskErr  error
+)
+
+// sigmaskArgs is a plain MakeSpec target for libc's
+// pthread_sigmask(int, const sigset_t *, sigset_t *) - set/old are raw
+// buffer addresses, the same reasoning sigactionArgs (signal_darwin.go)
+// gives for sigaction's act/old.
+type sigmaskArgs struct {
+	How int32   `ffi:"arg"`
+	Set uintptr `ffi:"arg"`
+	Old uintptr `ffi:"arg"`
+	R   int32   `ffi:"ret"`
+}
+
+// maskAsyncPreemptSignal blocks syscall.SIGURG - see preempt_linux.go's
+// doc comment for what that's for - on the calling OS thread via
+// pthread_sigmask(SIG_BLOCK, ...), returning false instead of an error
+// if libc's pthread_sigmask can't be resolved at all.
+func maskAsyncPreemptSignal() (oldMask uint64, ok bool) {
+	fn, err := sigmaskLibc()
+	if err != nil {
+		return 0, false
+	}
+	var newSet = uint32(1) << (uint(syscall.SIGURG) - 1)
+	var old uint32
+	args := sigmaskArgs{How: darwinSigBlock, Set: uintptr(unsafe.Pointer(&newSet)), Old: uintptr(unsafe.Pointer(&old))}
+	spec := MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return 0, false
+	}
+	return uint64(old), true
+}
+
+// restoreAsyncPreemptSignal puts the calling thread's signal mask back
+// to oldMask, as reported by a prior maskAsyncPreemptSignal.
+func restoreAsyncPreemptSignal(oldMask uint64) {
+	fn, err := sigmaskLibc()
+	if err != nil {
+		return
+	}
+	old := uint32(oldMask)
+	args := sigmaskArgs{How: darwinSigSetMask, Set: uintptr(unsafe.Pointer(&old))}
+	spec := MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+}
@@ -0,0 +1,49 @@
+//go:build linux
+
+package ffi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Linux's rt_sigprocmask(2) how values - not exported by the syscall
+// package under any GOARCH, unlike SYS_RT_SIGPROCMASK itself.
+const (
+	sigBlock   = 0
+	sigSetMask = 2
+)
+
+// maskAsyncPreemptSignal blocks syscall.SIGURG - the signal the Go
+// runtime sends a goroutine to preempt it mid-call (see
+// runtime/signal_unix.go's sigPreempt) - on the calling OS thread, via a
+// direct rt_sigprocmask(2) syscall: the same no-libc-required technique
+// rawsyscall_amd64.go documents for the rest of this package's Linux
+// support, here reached through the standard library's own
+// syscall.RawSyscall6 instead of a hand-written asm routine, since
+// that's already a bare SYSCALL instruction with no libc involved on
+// every GOARCH Linux runs under.
+//
+// how is SIG_BLOCK, not SIG_SETMASK: this only ever adds SIGURG to
+// whatever the thread's mask already was, never replaces it, so a
+// signal something else deliberately blocked stays blocked. The kernel
+// reports that prior mask back through oldMask for
+// restoreAsyncPreemptSignal to put back afterward.
+func maskAsyncPreemptSignal() (oldMask uint64, ok bool) {
+	var newMask = uint64(1) << (uint(syscall.SIGURG) - 1)
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_RT_SIGPROCMASK, sigBlock,
+		uintptr(unsafe.Pointer(&newMask)), uintptr(unsafe.Pointer(&oldMask)), 8, 0, 0)
+	if errno != 0 {
+		return 0, false
+	}
+	return oldMask, true
+}
+
+// restoreAsyncPreemptSignal puts the calling thread's signal mask back
+// to oldMask, as reported by a prior maskAsyncPreemptSignal - SIG_SETMASK
+// this time, since oldMask is already the complete mask to restore, not
+// a delta to merge in.
+func restoreAsyncPreemptSignal(oldMask uint64) {
+	syscall.RawSyscall6(syscall.SYS_RT_SIGPROCMASK, sigSetMask,
+		uintptr(unsafe.Pointer(&oldMask)), 0, 8, 0, 0)
+}
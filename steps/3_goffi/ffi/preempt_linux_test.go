@@ -0,0 +1,58 @@
+//go:build linux
+
+package ffi
+
+import (
+	"runtime"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// currentSigMask reads the calling thread's signal mask without
+// changing it - rt_sigprocmask(SIG_BLOCK, &zero, &cur) adds nothing to
+// the mask, so cur comes back as whatever it already was.
+func currentSigMask(t *testing.T) uint64 {
+	var zero, cur uint64
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_RT_SIGPROCMASK, sigBlock,
+		uintptr(unsafe.Pointer(&zero)), uintptr(unsafe.Pointer(&cur)), 8, 0, 0)
+	if errno != 0 {
+		t.Fatalf("rt_sigprocmask: %v", errno)
+	}
+	return cur
+}
+
+// TestMaskAsyncPreemptSignalRoundTrip locks the test goroutine to its OS
+// thread - the mask is per-thread, and nothing here should observe a
+// different thread's - then confirms maskAsyncPreemptSignal sets
+// SIGURG's bit and restoreAsyncPreemptSignal clears it again, leaving
+// the mask exactly as it found it.
+func TestMaskAsyncPreemptSignalRoundTrip(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	bit := uint64(1) << (uint(syscall.SIGURG) - 1)
+	before := currentSigMask(t)
+	if before&bit != 0 {
+		t.Skip("SIGURG is already blocked on this thread")
+	}
+
+	old, ok := maskAsyncPreemptSignal()
+	if !ok {
+		t.Fatal("maskAsyncPreemptSignal: not ok")
+	}
+	if old != before {
+		t.Fatalf("maskAsyncPreemptSignal: reported old mask %#x, want %#x", old, before)
+	}
+
+	during := currentSigMask(t)
+	if during&bit == 0 {
+		t.Fatalf("mask %#x does not have SIGURG (%#x) blocked", during, bit)
+	}
+
+	restoreAsyncPreemptSignal(old)
+	after := currentSigMask(t)
+	if after != before {
+		t.Fatalf("restoreAsyncPreemptSignal: mask %#x, want %#x", after, before)
+	}
+}
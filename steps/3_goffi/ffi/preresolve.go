@@ -0,0 +1,73 @@
+package ffi
+
+import "unsafe"
+
+// PreresolveError reports that name's registry entry failed to resolve
+// during a Preresolve pass; Err is the exact error Lookup(name) would
+// have returned.
+type PreresolveError struct {
+	Name string
+	Err  error
+}
+
+func (e *PreresolveError) Error() string {
+	return "ffi: preresolve " + e.Name + ": " + e.Err.Error()
+}
+
+func (e *PreresolveError) Unwrap() error { return e.Err }
+
+// Preresolve walks every name Register (or RegisterFunc) has ever
+// declared and calls Lookup on it, so the dlsym/buildSpec work Lookup
+// would otherwise defer to each symbol's first real use happens here
+// instead - the intended call site is once, at startup, before a
+// latency-sensitive service takes its first request. Once Lookup has run
+// for a name, sync.Once means a later real Lookup (or LookupFunc) of it
+// is the same cache hit it would have been anyway.
+//
+// For every symbol it resolves successfully, Preresolve also reads one
+// byte at the resolved Spec's entry point, which - on the first touch of
+// a given page - costs this goroutine the page fault a caller's first
+// Call would otherwise have paid. It only ever touches the one page fn
+// itself starts on; a symbol's full code may span more pages than that,
+// since nothing this package loads a library through reports a symbol's
+// size for it to fault in the rest.
+//
+// Preresolve does not JIT any MakeCallback trampoline: unlike a
+// registered symbol, a callback thunk has no name and nothing in this
+// package keeps a list of the ones a program has built, so there is
+// nothing for Preresolve to walk for that half of the job - a caller
+// that wants its callbacks' JIT cost paid up front still has to call
+// MakeCallback for each of them itself.
+//
+// It returns one *PreresolveError per name whose Lookup failed, in no
+// particular order (registry is a sync.Map, which doesn't guarantee
+// iteration order); a nil/empty result means every registered name
+// resolved.
+func Preresolve() []error {
+	var errs []error
+	registry.Range(func(key, value interface{}) bool {
+		name := key.(string)
+		spec, err := Lookup(name)
+		if err != nil {
+			errs = append(errs, &PreresolveError{Name: name, Err: err})
+			return true
+		}
+		touchCodePage(spec.fn)
+		return true
+	})
+	return errs
+}
+
+// touchCodePage reads a single byte at addr, faulting its page into the
+// process's resident set if it wasn't already - a no-op for a page
+// that's already mapped in, which is what makes it safe to call from
+// Preresolve on every successfully resolved Spec regardless of whether
+// anything has called that Spec yet. addr == 0 is left alone rather than
+// dereferenced, since a zero fn (an unset or stub Spec) isn't a loaded
+// symbol's address to begin with.
+func touchCodePage(addr uintptr) {
+	if addr == 0 {
+		return
+	}
+	_ = *(*byte)(unsafe.Pointer(addr))
+}
@@ -0,0 +1,57 @@
+package ffi
+
+import "testing"
+
+// TestPreresolveResolvesEverything checks that Preresolve forces every
+// registered name's resolve to run, so a later real Lookup is a cache
+// hit rather than the thing paying dlsym/buildSpec cost.
+func TestPreresolveResolvesEverything(t *testing.T) {
+	calls := 0
+	Register("preresolve-test-symbol", func() (Spec, error) {
+		calls++
+		return Spec{fn: 0x1234}, nil
+	})
+
+	if errs := Preresolve(); len(errs) != 0 {
+		t.Fatalf("Preresolve: unexpected errors: %v", errs)
+	}
+	if calls != 1 {
+		t.Fatalf("resolve called %d times by Preresolve, want 1", calls)
+	}
+
+	if _, err := Lookup("preresolve-test-symbol"); err != nil {
+		t.Fatalf("Lookup after Preresolve: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("resolve called %d times after a later Lookup, want still 1", calls)
+	}
+}
+
+// TestPreresolveReportsFailures checks that a name whose resolve fails
+// shows up in Preresolve's result instead of being silently dropped, and
+// that one failing name doesn't stop the rest from being attempted.
+func TestPreresolveReportsFailures(t *testing.T) {
+	Register("preresolve-test-bad-symbol", func() (Spec, error) {
+		return Spec{}, &SpecError{Kind: ErrUnsupportedKind, Msg: "preresolve-test-bad-symbol: boom"}
+	})
+	Register("preresolve-test-good-symbol", func() (Spec, error) {
+		return Spec{fn: 0x5678}, nil
+	})
+
+	errs := Preresolve()
+
+	var found *PreresolveError
+	for _, err := range errs {
+		if pe, ok := err.(*PreresolveError); ok && pe.Name == "preresolve-test-bad-symbol" {
+			found = pe
+			break
+		}
+	}
+	if found == nil {
+		t.Fatalf("Preresolve errors %v: missing entry for preresolve-test-bad-symbol", errs)
+	}
+
+	if _, err := Lookup("preresolve-test-good-symbol"); err != nil {
+		t.Fatalf("Lookup of preresolve-test-good-symbol after Preresolve: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package ffi
+
+import "fmt"
+
+// LibcFlavor identifies which libc implementation a process is linked
+// against, as DetectLibcFlavor reports it.
+type LibcFlavor int
+
+const (
+	// LibcUnknown means DetectLibcFlavor had no namespace to probe at
+	// all, or probed one and recognized neither glibc nor musl in it -
+	// not that the process has no libc, just that this package couldn't
+	// tell which one.
+	LibcUnknown LibcFlavor = iota
+	LibcGlibc
+	LibcMusl
+)
+
+// String returns the libc's usual short name (e.g. "glibc").
+func (f LibcFlavor) String() string {
+	switch f {
+	case LibcGlibc:
+		return "glibc"
+	case LibcMusl:
+		return "musl"
+	case LibcUnknown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("LibcFlavor(%d)", int(f))
+	}
+}
+
+// DetectLibcFlavor probes the process's default symbol namespace
+// (OpenDefault) for gnu_get_libc_version, a function only glibc exports,
+// the same GetOrStub-a-probe-symbol technique DetectSanitizers uses to
+// tell sanitizer runtimes apart. A namespace that resolves but doesn't
+// export it is assumed to be musl, the other libc this package's loader
+// targets on Linux; a namespace OpenDefault can't even open - true of
+// every platform routed through loader_unsupported.go today, see its own
+// doc comment - leaves nothing here to probe, and DetectLibcFlavor
+// reports LibcUnknown rather than guessing.
+func DetectLibcFlavor() LibcFlavor {
+	lib, err := OpenDefault()
+	if err != nil {
+		return LibcUnknown
+	}
+	defer lib.Close()
+	if _, ok := lib.GetOrStub("gnu_get_libc_version"); ok {
+		return LibcGlibc
+	}
+	return LibcMusl
+}
+
+// CgoStatus reports how well this package's cgo-free runtime shims are
+// actually working on this platform/GOARCH/Go version, so a caller that
+// wants to degrade gracefully - skip a feature instead of panicking or
+// hanging deep inside it - can check first instead of finding out the
+// hard way. ProbeCgoStatus builds one by actually exercising each
+// capability it reports on, not by assuming anything from GOOS/GOARCH
+// alone.
+type CgoStatus struct {
+	// Bootstrapped reports whether a cgo_init hook actually ran for this
+	// process - see bootstrapped() in cgobootstrap_nocgo.go and
+	// cgobootstrap_cgo.go. With cgo off it's always this package's own
+	// (cgoinit_amd64.s and siblings), so always true; with cgo on it's
+	// only true once RealCgoPresent is, since that build tag deliberately
+	// leaves installing one to whatever real cgo runtime the binary pulls
+	// in (see coexist.go) - a cgo-tagged binary that doesn't actually
+	// import real cgo anywhere has no cgo_init at all, and this reports
+	// that honestly rather than assuming one.
+	Bootstrapped bool
+
+	// RealCgoPresent reports whether a genuine runtime/cgo - not this
+	// package's fakecgo substitute - is also linked into this binary and
+	// has already run its own init (runtime.iscgo; see coexist.go).
+	// Binaries built with cgo off can never have one: the answer is
+	// unconditionally false there.
+	RealCgoPresent bool
+
+	// Libc is DetectLibcFlavor's result.
+	Libc LibcFlavor
+
+	// CallbacksAvailable reports whether MakeCallback (callback_amd64.go)
+	// is available at all and actually works: it's gated to amd64/
+	// !windows today, and even there a sufficiently locked-down seccomp/
+	// SELinux/W^X policy can refuse the executable mapping its JIT-built
+	// trampolines need.
+	CallbacksAvailable bool
+
+	// TLSAvailable reports whether OnThreadAttach/OnThreadDetach
+	// (thread_hooks_amd64.go) can actually fire. They need
+	// pthread_key_create resolved through libc, which needs a working
+	// default namespace to resolve it from - not true on every platform
+	// this package builds for yet (see loader_unsupported.go).
+	TLSAvailable bool
+
+	// SignalsAvailable reports whether CallProtected (protect.go) is
+	// available at all on this platform - it's gated !windows outright,
+	// with no Windows SEH-based equivalent built yet.
+	SignalsAvailable bool
+}
+
+// ProbeCgoStatus gathers a CgoStatus for the running process.
+// CallbacksAvailable's probe actually builds and releases a trial
+// MakeCallback trampoline, so calling it is not free; callers that only
+// need to check once should cache the result rather than calling
+// ProbeCgoStatus on every decision.
+func ProbeCgoStatus() CgoStatus {
+	return CgoStatus{
+		Bootstrapped:       bootstrapped(),
+		RealCgoPresent:     _Cgo_iscgo,
+		Libc:               DetectLibcFlavor(),
+		CallbacksAvailable: probeCallbacksAvailable(),
+		TLSAvailable:       probeTLSAvailable(),
+		SignalsAvailable:   probeSignalsAvailable(),
+	}
+}
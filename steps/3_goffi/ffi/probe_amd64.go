@@ -0,0 +1,37 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "unsafe"
+
+// probeCallbacksAvailable builds a trial MakeCallback trampoline for a
+// no-argument, no-return fnType and immediately releases it, recovering
+// from a panic the way callContained (callback_panic_amd64.go) recovers
+// a panicking fn - MakeCallback itself has no error-returning form to
+// call instead, see its own doc comment.
+func probeCallbacksAvailable() (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	ptr := MakeCallback(&struct{}{}, func(args unsafe.Pointer) {})
+	ReleaseCallback(ptr)
+	return true
+}
+
+// probeTLSAvailable is threadKeyReady (thread_hooks_amd64.go): it already
+// lazily resolves pthread_key_create through the default namespace and
+// reports false on any failure, including the Linux errLoaderUnsupported
+// case, so there's no separate probing logic to write here.
+func probeTLSAvailable() bool {
+	return threadKeyReady()
+}
+
+// probeSignalsAvailable is a compile-time answer, not a runtime probe:
+// CallProtected (protect.go) is gated //go:build !windows with no further
+// per-arch restriction, so it's available on every platform this file
+// itself builds for.
+func probeSignalsAvailable() bool {
+	return true
+}
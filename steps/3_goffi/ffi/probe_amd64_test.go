@@ -0,0 +1,24 @@
+//go:build !windows
+
+package ffi
+
+import "testing"
+
+// TestProbeCgoStatusSignalsAvailable confirms SignalsAvailable matches
+// protect.go's own //go:build !windows gate, which this test file
+// shares.
+func TestProbeCgoStatusSignalsAvailable(t *testing.T) {
+	if status := ProbeCgoStatus(); !status.SignalsAvailable {
+		t.Fatal("ProbeCgoStatus().SignalsAvailable = false, want true on !windows")
+	}
+}
+
+// TestProbeCallbacksAvailable confirms CallbacksAvailable is true on a
+// platform where MakeCallback genuinely works - this test binary itself,
+// built with no seccomp/W^X policy refusing callbackThunks its JIT
+// mapping.
+func TestProbeCallbacksAvailable(t *testing.T) {
+	if status := ProbeCgoStatus(); !status.CallbacksAvailable {
+		t.Fatal("ProbeCgoStatus().CallbacksAvailable = false, want true")
+	}
+}
@@ -0,0 +1,29 @@
+//go:build (!windows && !amd64) || nocgo_fallback_cgo || tinygo || gccgo
+
+package ffi
+
+// probeCallbacksAvailable is always false here: MakeCallback
+// (callback_amd64.go) hasn't been ported past amd64 yet, and under
+// nocgo_fallback_cgo it's unavailable for a different reason - that
+// build excludes callback_amd64.go on every arch, amd64 included, since
+// MakeCallback's trampoline reaches into native Spec fields the libffi
+// backend's Spec (ffi_cgofallback.go) doesn't have.
+func probeCallbacksAvailable() bool {
+	return false
+}
+
+// probeTLSAvailable is always false here: OnThreadAttach/OnThreadDetach
+// (thread_hooks_amd64.go) haven't been ported past amd64 yet, or - under
+// nocgo_fallback_cgo - are excluded for the same reason
+// probeCallbacksAvailable is.
+func probeTLSAvailable() bool {
+	return false
+}
+
+// probeSignalsAvailable is a compile-time answer, not a runtime probe:
+// CallProtected (protect.go) is gated //go:build !windows with no
+// further per-arch restriction, so it's available on every arch this
+// file itself builds for.
+func probeSignalsAvailable() bool {
+	return true
+}
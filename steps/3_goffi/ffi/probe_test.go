@@ -0,0 +1,48 @@
+package ffi
+
+import "testing"
+
+func TestLibcFlavorString(t *testing.T) {
+	cases := map[LibcFlavor]string{
+		LibcUnknown: "unknown",
+		LibcGlibc:   "glibc",
+		LibcMusl:    "musl",
+	}
+	for flavor, want := range cases {
+		if got := flavor.String(); got != want {
+			t.Fatalf("%d.String() = %q, want %q", flavor, got, want)
+		}
+	}
+}
+
+// TestDetectLibcFlavorUnknownWithoutLoader confirms DetectLibcFlavor
+// reports LibcUnknown rather than guessing when OpenDefault itself
+// fails - true on every platform routed through loader_unsupported.go
+// today, which is where this test runs.
+func TestDetectLibcFlavorUnknownWithoutLoader(t *testing.T) {
+	if _, err := OpenDefault(); err == nil {
+		t.Skip("OpenDefault succeeded on this platform; LibcUnknown is not the expected result here")
+	}
+	if got := DetectLibcFlavor(); got != LibcUnknown {
+		t.Fatalf("DetectLibcFlavor() = %v, want LibcUnknown", got)
+	}
+}
+
+// TestProbeCgoStatusBootstrapped confirms Bootstrapped agrees with
+// bootstrapped() (cgobootstrap_nocgo.go/cgobootstrap_cgo.go) - see its own
+// doc comment for why that's build-tag dependent rather than always true.
+func TestProbeCgoStatusBootstrapped(t *testing.T) {
+	if status := ProbeCgoStatus(); status.Bootstrapped != bootstrapped() {
+		t.Fatalf("ProbeCgoStatus().Bootstrapped = %v, want %v", status.Bootstrapped, bootstrapped())
+	}
+}
+
+// TestProbeCgoStatusRealCgoPresent confirms RealCgoPresent mirrors
+// _Cgo_iscgo directly - this package's own test binary never imports
+// real cgo, so both are expected to be false here regardless of build
+// tag; see coexist.go for what a true value would mean.
+func TestProbeCgoStatusRealCgoPresent(t *testing.T) {
+	if status := ProbeCgoStatus(); status.RealCgoPresent != _Cgo_iscgo {
+		t.Fatalf("ProbeCgoStatus().RealCgoPresent = %v, want %v", status.RealCgoPresent, _Cgo_iscgo)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package ffi
+
+// probeCallbacksAvailable is always false on Windows: MakeCallback
+// (callback_amd64.go) is gated !windows and has no Windows counterpart
+// yet.
+func probeCallbacksAvailable() bool {
+	return false
+}
+
+// probeTLSAvailable is always false on Windows: OnThreadAttach/
+// OnThreadDetach (thread_hooks_amd64.go) are gated !windows the same way
+// MakeCallback is.
+func probeTLSAvailable() bool {
+	return false
+}
+
+// probeSignalsAvailable is always false on Windows: CallProtected
+// (protect.go) is gated !windows, with no Windows SEH-based equivalent
+// built yet.
+func probeSignalsAvailable() bool {
+	return false
+}
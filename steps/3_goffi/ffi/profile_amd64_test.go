@@ -0,0 +1,75 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCallLabelProfileDoesNotChangeResult exercises Call's LabelProfile
+// branch (ffi_amd64.go) end to end: wrapping entersyscall/asmcgocall in
+// pprof.Do must not change the call's own behavior, only whether a
+// profiling sample taken mid-call carries spec's symbol as a label.
+func TestCallLabelProfileDoesNotChangeResult(t *testing.T) {
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 3, B: 4}
+
+	spec := MakeSpec(fn, &args)
+	spec.SetSymbol("benchTarget")
+	spec.LabelProfile(true)
+
+	spec.Call(unsafe.Pointer(&args))
+	if args.A != 3 || args.B != 4 {
+		t.Errorf("args changed by a LabelProfile-wrapped call: got %+v", args)
+	}
+}
+
+// TestCallNoLabelProfileDoesNotChangeResult confirms the same call
+// behaves identically with LabelProfile left off, the default.
+func TestCallNoLabelProfileDoesNotChangeResult(t *testing.T) {
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 10, B: 20}
+
+	spec := MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.A != 10 || args.B != 20 {
+		t.Errorf("args changed by an unwrapped call: got %+v", args)
+	}
+}
+
+// TestCallTraceRegionDoesNotChangeResult exercises Call's TraceRegion
+// branch: wrapping invoke in a runtime/trace region must not change the
+// call's own behavior, whether or not a trace is actually being
+// collected.
+func TestCallTraceRegionDoesNotChangeResult(t *testing.T) {
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 5, B: 6}
+
+	spec := MakeSpec(fn, &args)
+	spec.SetSymbol("benchTarget")
+	spec.TraceRegion(true)
+
+	spec.Call(unsafe.Pointer(&args))
+	if args.A != 5 || args.B != 6 {
+		t.Errorf("args changed by a TraceRegion-wrapped call: got %+v", args)
+	}
+}
+
+// TestCallLabelProfileAndTraceRegionDoesNotChangeResult exercises the
+// switch case in Call where both LabelProfile and TraceRegion are
+// enabled together, nesting one wrapper inside the other.
+func TestCallLabelProfileAndTraceRegionDoesNotChangeResult(t *testing.T) {
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 7, B: 8}
+
+	spec := MakeSpec(fn, &args)
+	spec.SetSymbol("benchTarget")
+	spec.LabelProfile(true)
+	spec.TraceRegion(true)
+
+	spec.Call(unsafe.Pointer(&args))
+	if args.A != 7 || args.B != 8 {
+		t.Errorf("args changed by a LabelProfile+TraceRegion-wrapped call: got %+v", args)
+	}
+}
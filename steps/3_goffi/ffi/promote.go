@@ -0,0 +1,75 @@
+package ffi
+
+import "math"
+
+// PromoteVariadicArg applies C's default argument promotions to v, the
+// same ones a real C compiler silently applies to a variadic call's
+// trailing arguments since no prototype is in scope for them: every
+// integer type narrower than int (int8/int16/uint8/uint16, and bool as
+// the obvious Go analogue of a sub-int C type) widens the same way a
+// named, prototyped parameter's exact width already is - zero/sign-
+// extended into a full 64 bit slot - and float32 widens to float64. It
+// returns the value already encoded the way CallVariadic expects it
+// (the bits of that widened integer, or math.Float64bits of the widened
+// float), plus whether it belongs in an SSE register.
+//
+// This is the piece CallVariadic's doc comment used to leave to the
+// caller by hand (bit-patterning a float32 through float64(x) and
+// math.Float64bits, sign/zero-extending a small integer into a uint64)
+// - see fieldToOffset's doc comment for why a fixed, prototyped argument
+// never goes through this: promotion is purely a variadic-call rule.
+//
+// v must be one of the types below; anything else panics with a
+// SpecError carrying ErrUnsupportedKind, the same error MakeSpec uses for
+// an unclassifiable fixed-argument field.
+func PromoteVariadicArg(v interface{}) (val uint64, isXmm bool) {
+	switch x := v.(type) {
+	case bool:
+		if x {
+			return 1, false
+		}
+		return 0, false
+	case int:
+		return uint64(int64(x)), false
+	case int8:
+		return uint64(int64(x)), false
+	case int16:
+		return uint64(int64(x)), false
+	case int32:
+		return uint64(int64(x)), false
+	case int64:
+		return uint64(x), false
+	case uint:
+		return uint64(x), false
+	case uint8:
+		return uint64(x), false
+	case uint16:
+		return uint64(x), false
+	case uint32:
+		return uint64(x), false
+	case uint64:
+		return x, false
+	case uintptr:
+		return uint64(x), false
+	case float32:
+		return math.Float64bits(float64(x)), true
+	case float64:
+		return math.Float64bits(x), true
+	default:
+		panic(&SpecError{Kind: ErrUnsupportedKind, Msg: "PromoteVariadicArg: unsupported type"})
+	}
+}
+
+// PromoteVariadicArgs applies PromoteVariadicArg to each of values in
+// order, building the varArgs/varIsXmm pair CallVariadic takes directly -
+// so a caller can write CallVariadic(ptr, PromoteVariadicArgs(a, b, c))
+// instead of hand-building two parallel slices and getting the
+// widening/bit-patterning right itself.
+func PromoteVariadicArgs(values ...interface{}) (varArgs []uint64, varIsXmm []bool) {
+	varArgs = make([]uint64, len(values))
+	varIsXmm = make([]bool, len(values))
+	for i, v := range values {
+		varArgs[i], varIsXmm[i] = PromoteVariadicArg(v)
+	}
+	return varArgs, varIsXmm
+}
@@ -0,0 +1,76 @@
+package ffi
+
+import (
+	"math"
+	"testing"
+)
+
+// TestPromoteVariadicArgIntegers confirms every integer width ends up
+// zero/sign-extended into the full 64 bit slot CallVariadic expects, and
+// never flagged as an SSE argument.
+func TestPromoteVariadicArgIntegers(t *testing.T) {
+	cases := []struct {
+		v    interface{}
+		want uint64
+	}{
+		{int8(-1), math.MaxUint64},
+		{int16(-2), math.MaxUint64 - 1},
+		{int32(-3), math.MaxUint64 - 2},
+		{uint8(200), 200},
+		{uint16(60000), 60000},
+		{uint32(1 << 31), 1 << 31},
+		{true, 1},
+		{false, 0},
+	}
+	for _, c := range cases {
+		val, isXmm := PromoteVariadicArg(c.v)
+		if val != c.want || isXmm {
+			t.Errorf("PromoteVariadicArg(%v) = (%d, %v), want (%d, false)", c.v, val, isXmm, c.want)
+		}
+	}
+}
+
+// TestPromoteVariadicArgFloats confirms float32 is promoted to float64
+// before being bit-patterned, matching C's default argument promotion for
+// a variadic float (and that float64 itself is passed through unchanged).
+func TestPromoteVariadicArgFloats(t *testing.T) {
+	val, isXmm := PromoteVariadicArg(float32(1.5))
+	if !isXmm || val != math.Float64bits(1.5) {
+		t.Errorf("PromoteVariadicArg(float32(1.5)) = (%d, %v), want (%d, true)", val, isXmm, math.Float64bits(1.5))
+	}
+	val, isXmm = PromoteVariadicArg(float64(2.5))
+	if !isXmm || val != math.Float64bits(2.5) {
+		t.Errorf("PromoteVariadicArg(float64(2.5)) = (%d, %v), want (%d, true)", val, isXmm, math.Float64bits(2.5))
+	}
+}
+
+// TestPromoteVariadicArgsBuildsParallelSlices confirms
+// PromoteVariadicArgs assembles exactly the varArgs/varIsXmm pair
+// CallVariadic takes, in the same order as its input.
+func TestPromoteVariadicArgsBuildsParallelSlices(t *testing.T) {
+	varArgs, varIsXmm := PromoteVariadicArgs(int8(5), float32(1.5), uint16(9))
+	wantArgs := []uint64{5, math.Float64bits(1.5), 9}
+	wantXmm := []bool{false, true, false}
+	for i := range wantArgs {
+		if varArgs[i] != wantArgs[i] || varIsXmm[i] != wantXmm[i] {
+			t.Errorf("index %d: got (%d, %v), want (%d, %v)", i, varArgs[i], varIsXmm[i], wantArgs[i], wantXmm[i])
+		}
+	}
+}
+
+// TestPromoteVariadicArgUnsupported confirms an unrecognized type panics
+// with a SpecError instead of silently truncating/misencoding it.
+func TestPromoteVariadicArgUnsupported(t *testing.T) {
+	defer func() {
+		r := recover()
+		se, ok := r.(*SpecError)
+		if !ok {
+			t.Fatalf("expected *SpecError panic, got %v", r)
+		}
+		if se.Kind != ErrUnsupportedKind {
+			t.Fatalf("expected ErrUnsupportedKind, got %v", se.Kind)
+		}
+	}()
+	PromoteVariadicArg("not a C scalar")
+	t.Fatal("expected PromoteVariadicArg to panic")
+}
@@ -0,0 +1,105 @@
+//go:build !windows
+
+package ffi
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+// ErrCallFaulted is the error CallProtected returns when the protected
+// call raised SIGSEGV or SIGBUS instead of returning normally - a crash
+// inside spec.fn, rather than inside this package's own trampoline or Go
+// code.
+var ErrCallFaulted = errors.New("ffi: call faulted (SIGSEGV/SIGBUS)")
+
+// protectMu serializes CallProtected: os/signal's Notify channel has no
+// way to say which OS thread raised a synchronous fault signal, so the
+// only way to attribute a fault to the call that caused it is to make
+// sure only one protected call - and, critically, only one abandoned,
+// still-faulting call left behind by a previous one (see the doc comment
+// below) - is ever outstanding at a time.
+var protectMu sync.Mutex
+
+var (
+	faultOnce sync.Once
+	faultSig  chan os.Signal
+)
+
+// initFaultNotify registers for the synchronous fault signals a crash in
+// spec.fn (non-Go code, from the runtime's point of view) can raise. A
+// buffer of 1 is deliberate: it's just enough room for the one
+// notification CallProtected itself is watching for, and caps how many
+// stale notifications an abandoned call's still-faulting thread (see
+// below) can leave queued up for the next call to drain.
+func initFaultNotify() {
+	faultSig = make(chan os.Signal, 1)
+	signal.Notify(faultSig, syscall.SIGSEGV, syscall.SIGBUS)
+}
+
+// CallProtected runs call - ordinarily a spec.Call or spec.CallFast
+// invocation - and reports a SIGSEGV or SIGBUS fault inside it as
+// ErrCallFaulted instead of letting the whole process die the way an
+// unprotected call's crash would. It's for a C function whose inputs (or
+// own bugs) aren't fully trusted, where losing that one call is
+// preferable to losing the process.
+//
+// This is containment, not continuation: unlike libffi's sigsetjmp-based
+// protected call mode, CallProtected can't resume execution past the
+// fault. Doing that safely means rewriting the faulting OS thread's
+// saved program counter from inside a signal handler - the way the Go
+// runtime's own internal fault handling does for an ordinary Go nil
+// dereference - and this package has no linkname into that machinery.
+// Instead, call runs on its own newly created, OS-thread-locked
+// goroutine that's deliberately leaked: when it faults, the kernel
+// re-delivers the same fault to it forever (nothing ever moves its
+// program counter past the bad instruction), so that one goroutine and
+// its OS thread are abandoned for the life of the process rather than
+// returned to the scheduler in a state nobody can reason about.
+// CallProtected itself still returns as soon as the fault is observed -
+// only that one OS thread is lost, not the process.
+//
+// Because a fault signal arrives with no indication of which thread
+// raised it, CallProtected only ever watches one shared notification
+// channel and refuses to run more than one call at a time (protectMu).
+// It also drains any notification left over from a call it already gave
+// up on before starting the next one - but that abandoned call's thread
+// is still spinning on the same fault in the background and can raise
+// another at any moment, including right as the next CallProtected's
+// own call is starting; there's no way to tell that apart from a genuine
+// new fault without the raw sigaction/ucontext handling described above.
+// A process that triggers more than one fault over its lifetime should
+// treat CallProtected's error as "something faulted since the last
+// drain," not "this specific call faulted."
+func CallProtected(call func()) error {
+	faultOnce.Do(initFaultNotify)
+
+	protectMu.Lock()
+	defer protectMu.Unlock()
+
+	select {
+	case <-faultSig:
+	default:
+	}
+
+	done := make(chan struct{})
+	go func() {
+		runtime.LockOSThread()
+		// Deliberately never UnlockOSThread, even if call panics:
+		// if call faulted, this goroutine's OS thread must never go
+		// back to the scheduler's pool (see the doc comment above).
+		call()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-faultSig:
+		return ErrCallFaulted
+	}
+}
@@ -0,0 +1,46 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+type crashArgs struct{}
+
+func crashTarget()
+func addrOfCrashTarget() uintptr
+
+// TestCallProtectedCatchesFault drives crashTarget (protect_amd64_test.s)
+// through CallProtected and confirms the SIGSEGV it raises comes back as
+// ErrCallFaulted instead of killing the test binary.
+func TestCallProtectedCatchesFault(t *testing.T) {
+	spec := MakeSpec(addrOfCrashTarget(), &crashArgs{})
+
+	err := CallProtected(func() {
+		spec.Call(nil)
+	})
+	if err != ErrCallFaulted {
+		t.Fatalf("CallProtected: got %v, want ErrCallFaulted", err)
+	}
+}
+
+// TestCallProtectedOrdinaryCall confirms CallProtected doesn't interfere
+// with a call that returns normally - including one started right after
+// TestCallProtectedCatchesFault's own faulted call, whose goroutine and
+// OS thread CallProtected left behind still spinning on that fault.
+func TestCallProtectedOrdinaryCall(t *testing.T) {
+	args := funcAddArgs{A: 3, B: 4}
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &args)
+
+	err := CallProtected(func() {
+		spec.Call(unsafe.Pointer(&args))
+	})
+	if err != nil {
+		t.Fatalf("CallProtected: unexpected error %v", err)
+	}
+	if args.R != 7 {
+		t.Fatalf("CallProtected: got R=%d, want 7", args.R)
+	}
+}
@@ -0,0 +1,68 @@
+package ffi
+
+import "unsafe"
+
+// BytesAt copies n bytes starting at ptr into a new, independently owned
+// []byte - the same contract GoBytes has, just named to read naturally
+// against a C function's own (pointer, length) return rather than cgo's
+// C.GoBytes convention.
+func BytesAt(ptr uintptr, n int) []byte {
+	return GoBytes(ptr, n)
+}
+
+// BytesAtUnsafe returns a []byte that aliases the n bytes starting at
+// ptr directly, with no copy: the result is only valid for as long as
+// ptr's own backing memory is, typically only until the C library that
+// returned it frees or reuses it. A caller that needs the bytes to
+// outlive that must copy them first - BytesAt, or append([]byte(nil),
+// result...).
+func BytesAtUnsafe(ptr uintptr, n int) []byte {
+	if ptr == 0 || n == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(ptr)), n)
+}
+
+// StringAt copies n bytes starting at ptr into a Go string, without
+// stopping early at a NUL byte the way GoStringN does - for a C API
+// that returns an exact (pointer, length) pair rather than a
+// NUL-terminated buffer.
+func StringAt(ptr uintptr, n int) string {
+	if ptr == 0 || n == 0 {
+		return ""
+	}
+	return string(unsafe.Slice((*byte)(unsafe.Pointer(ptr)), n))
+}
+
+// StringAtUnsafe returns a string backed directly by the n bytes
+// starting at ptr, with no copy - see BytesAtUnsafe's warning about how
+// long that memory, and so the result, stays valid. Go strings are
+// immutable, but unsafe.String's own contract still requires ptr's
+// backing memory not to change for as long as the result is in use.
+func StringAtUnsafe(ptr uintptr, n int) string {
+	if ptr == 0 || n == 0 {
+		return ""
+	}
+	return unsafe.String((*byte)(unsafe.Pointer(ptr)), n)
+}
+
+// StringAtNul copies the NUL-terminated C string at ptr into a Go
+// string - the same contract GoString has, just named to read naturally
+// alongside StringAt/StringAtUnsafe.
+func StringAtNul(ptr uintptr) string {
+	return GoString(ptr)
+}
+
+// StringAtNulUnsafe returns a string backed directly by the
+// NUL-terminated C string at ptr, with no copy - see BytesAtUnsafe's
+// warning about how long that memory, and so the result, stays valid.
+func StringAtNulUnsafe(ptr uintptr) string {
+	if ptr == 0 {
+		return ""
+	}
+	n := 0
+	for *(*byte)(unsafe.Pointer(ptr + uintptr(n))) != 0 {
+		n++
+	}
+	return unsafe.String((*byte)(unsafe.Pointer(ptr)), n)
+}
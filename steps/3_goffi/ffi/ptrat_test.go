@@ -0,0 +1,79 @@
+package ffi
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+)
+
+func TestBytesAt(t *testing.T) {
+	if b := BytesAt(0, 4); b != nil {
+		t.Fatalf("BytesAt(0, 4): got %v, want nil", b)
+	}
+	src := []byte{1, 2, 3, 4}
+	got := BytesAt(uintptr(unsafe.Pointer(&src[0])), len(src))
+	if !bytes.Equal(got, src) {
+		t.Fatalf("BytesAt: got %v, want %v", got, src)
+	}
+
+	src[0] = 0xff
+	if got[0] == 0xff {
+		t.Fatal("BytesAt: result aliases the source instead of copying it")
+	}
+}
+
+func TestBytesAtUnsafe(t *testing.T) {
+	if b := BytesAtUnsafe(0, 4); b != nil {
+		t.Fatalf("BytesAtUnsafe(0, 4): got %v, want nil", b)
+	}
+	src := []byte{1, 2, 3, 4}
+	got := BytesAtUnsafe(uintptr(unsafe.Pointer(&src[0])), len(src))
+	if !bytes.Equal(got, src) {
+		t.Fatalf("BytesAtUnsafe: got %v, want %v", got, src)
+	}
+
+	src[0] = 0xff
+	if got[0] != 0xff {
+		t.Fatal("BytesAtUnsafe: result did not alias the source")
+	}
+}
+
+func TestStringAt(t *testing.T) {
+	if s := StringAt(0, 5); s != "" {
+		t.Fatalf("StringAt(0, 5): got %q, want \"\"", s)
+	}
+	withNUL := append([]byte("ab"), 0, 'c', 'd')
+	if s := StringAt(uintptr(unsafe.Pointer(&withNUL[0])), len(withNUL)); s != "ab\x00cd" {
+		t.Fatalf("StringAt: got %q, want %q (should not stop at NUL)", s, "ab\x00cd")
+	}
+}
+
+func TestStringAtUnsafe(t *testing.T) {
+	if s := StringAtUnsafe(0, 5); s != "" {
+		t.Fatalf("StringAtUnsafe(0, 5): got %q, want \"\"", s)
+	}
+	buf := []byte("hello")
+	if s := StringAtUnsafe(uintptr(unsafe.Pointer(&buf[0])), len(buf)); s != "hello" {
+		t.Fatalf("StringAtUnsafe: got %q, want %q", s, "hello")
+	}
+}
+
+func TestStringAtNul(t *testing.T) {
+	if s := StringAtNul(0); s != "" {
+		t.Fatalf("StringAtNul(0): got %q, want \"\"", s)
+	}
+	buf := append([]byte("hello"), 0)
+	if s := StringAtNul(uintptr(unsafe.Pointer(&buf[0]))); s != "hello" {
+		t.Fatalf("StringAtNul: got %q, want %q", s, "hello")
+	}
+}
+
+func TestStringAtNulUnsafe(t *testing.T) {
+	if s := StringAtNulUnsafe(0); s != "" {
+		t.Fatalf("StringAtNulUnsafe(0): got %q, want \"\"", s)
+	}
+	buf := append([]byte("hello"), 0)
+	if s := StringAtNulUnsafe(uintptr(unsafe.Pointer(&buf[0]))); s != "hello" {
+		t.Fatalf("StringAtNulUnsafe: got %q, want %q", s, "hello")
+	}
+}
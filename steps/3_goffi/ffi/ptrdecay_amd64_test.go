@@ -0,0 +1,35 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfSumBufTarget and sumBufTarget (ptrdecay_amd64_test.s) stand in
+// for a C function taking a fixed-size buffer by pointer, the same way
+// addrOfAddPairTarget/addPairTarget stand in for one that adds two ints.
+func addrOfSumBufTarget() uintptr
+func sumBufTarget()
+
+// ptrDecayArgs exercises the ffi:"ptr" tag: Buf is a [4]byte array, which
+// would otherwise go through by-value aggregate classification, but the
+// tag decays it to a plain pointer to its first element instead.
+type ptrDecayArgs struct {
+	Buf [4]byte `ffi:"arg,ptr"`
+	R   int64   `ffi:"ret"`
+}
+
+// TestCallPtrDecay drives sumBufTarget through the real asmcall
+// trampoline, confirming it receives Buf's address rather than its bytes.
+func TestCallPtrDecay(t *testing.T) {
+	args := ptrDecayArgs{Buf: [4]byte{1, 2, 3, 4}}
+	spec := MakeSpec(addrOfSumBufTarget(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 10 {
+		t.Fatalf("ptr decay call: got R=%d, want 10", args.R)
+	}
+}
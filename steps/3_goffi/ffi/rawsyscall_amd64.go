@@ -0,0 +1,82 @@
+//go:build linux && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// rawSyscallParams is laid out to match asmrawsyscall's expectations in
+// rawsyscall_amd64.s exactly: six argument words in, the kernel's single
+// return word out.
+type rawSyscallParams struct {
+	nr                     uintptr
+	a1, a2, a3, a4, a5, a6 uintptr
+	ret                    uintptr
+}
+
+// asmrawsyscall (rawsyscall_amd64.s) loads nr/a1..a6 into RAX/RDI/RSI/
+// RDX/R10/R8/R9 - the Linux x86-64 syscall ABI's own argument registers,
+// not SysV's C calling convention asmcall (ffi_amd64.s) follows - issues
+// the SYSCALL instruction directly, and stores the result back through
+// ret. There is no libc, and so no C function pointer, anywhere in this
+// path: the kernel is the only thing asmrawsyscall ever calls.
+//
+// This file's `//go:build linux` is load-bearing, not incidental: OpenBSD
+// kills any SYSCALL instruction that doesn't originate from a page its
+// libc.so mapped in via msyscall(2)/pinsyscall, which a raw asmrawsyscall
+// built the same way for openbsd never would be. An openbsd equivalent of
+// RawSyscall has to go through libc's own syscall(3) wrapper - the same
+// path Call1..Call6 (syscall_amd64.go) already use for every other libc
+// call - not this file's direct-SYSCALL technique.
+func asmrawsyscall()
+
+var asmrawsyscallptr = funcPC(asmrawsyscall)
+
+// rawSyscall is the shared implementation behind RawSyscall/RawSyscall6:
+// it runs asmrawsyscall on the system stack via asmcgocall, the same
+// entersyscall/asmcgocall/exitsyscall bracket Call uses around spec.fn
+// (ffi_amd64.go), so a blocking syscall here parks its P for some other
+// goroutine exactly as a blocking libc call through Call would, rather
+// than wedging the whole scheduler.
+func rawSyscall(nr, a1, a2, a3, a4, a5, a6 uintptr) (r1 uintptr, errno syscall.Errno) {
+	params := &rawSyscallParams{nr: nr, a1: a1, a2: a2, a3: a3, a4: a4, a5: a5, a6: a6}
+
+	entersyscall()
+	asmcgocall(unsafe.Pointer(asmrawsyscallptr), uintptr(unsafe.Pointer(params)))
+	exitsyscall()
+
+	if _Cgo_always_false {
+		_Cgo_use(params)
+	}
+
+	// Linux's raw syscall convention reports failure as a small negative
+	// return value - -errno, not a separate carry flag or second
+	// register the way RawSyscall6's (r1, r2, err) shape on some other
+	// GOOS/GOARCH pairs might suggest - so there's nothing for a
+	// SetErrnoLocator-style callback to capture here, unlike Call1
+	// through Call6 (syscall_amd64.go), which call through an actual C
+	// function and so can't inspect its return value at all.
+	ret := params.ret
+	if s := int64(ret); s < 0 && s >= -4095 {
+		return 0, syscall.Errno(-s)
+	}
+	return ret, 0
+}
+
+// RawSyscall issues Linux syscall number nr directly via the SYSCALL
+// instruction, with no libc involved at any point - the fallback this
+// package's ordinary Call/Call1..Call6 can't offer a fully static binary
+// that has no libc mapped to resolve a function pointer from in the
+// first place. a1..a3 fill the syscall's first three argument registers;
+// the rest are passed as zero, the same contract syscall.RawSyscall
+// itself has.
+func RawSyscall(nr, a1, a2, a3 uintptr) (r1 uintptr, errno syscall.Errno) {
+	return rawSyscall(nr, a1, a2, a3, 0, 0, 0)
+}
+
+// RawSyscall6 is RawSyscall for a syscall that needs up to six arguments.
+func RawSyscall6(nr, a1, a2, a3, a4, a5, a6 uintptr) (r1 uintptr, errno syscall.Errno) {
+	return rawSyscall(nr, a1, a2, a3, a4, a5, a6)
+}
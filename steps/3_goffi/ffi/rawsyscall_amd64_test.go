@@ -0,0 +1,57 @@
+//go:build linux && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+// sysGetpid and sysClose are the syscall numbers RawSyscall/RawSyscall6
+// are exercised against below - fixed on linux/amd64, unlike the libc
+// entry points Call1..Call6 resolve by name.
+const (
+	sysGetpid = 39
+	sysClose  = 3
+)
+
+// TestRawSyscallGetpid confirms RawSyscall reaches the kernel directly:
+// its result must match os.Getpid, which goes through the standard
+// library's own syscall path.
+func TestRawSyscallGetpid(t *testing.T) {
+	r1, errno := RawSyscall(sysGetpid, 0, 0, 0)
+	if errno != 0 {
+		t.Fatalf("RawSyscall(getpid): errno=%d, want 0", errno)
+	}
+	if int(r1) != os.Getpid() {
+		t.Fatalf("RawSyscall(getpid) = %d, want %d", r1, os.Getpid())
+	}
+}
+
+// TestRawSyscallErrno confirms a failing syscall's negative return value
+// comes back as the matching positive errno, not as the raw negative
+// word the kernel itself returned - close(-1) is guaranteed EBADF on
+// every Linux kernel.
+func TestRawSyscallErrno(t *testing.T) {
+	r1, errno := RawSyscall(sysClose, ^uintptr(0), 0, 0) // close(-1)
+	if errno != syscall.EBADF {
+		t.Fatalf("RawSyscall(close(-1)): errno=%v, want EBADF", errno)
+	}
+	if r1 != 0 {
+		t.Fatalf("RawSyscall(close(-1)): r1=%d, want 0 on failure", r1)
+	}
+}
+
+// TestRawSyscall6 checks RawSyscall6 against the same getpid call,
+// confirming the three always-zero trailing arguments don't disturb
+// anything.
+func TestRawSyscall6(t *testing.T) {
+	r1, errno := RawSyscall6(sysGetpid, 0, 0, 0, 0, 0, 0)
+	if errno != 0 {
+		t.Fatalf("RawSyscall6(getpid): errno=%d, want 0", errno)
+	}
+	if int(r1) != os.Getpid() {
+		t.Fatalf("RawSyscall6(getpid) = %d, want %d", r1, os.Getpid())
+	}
+}
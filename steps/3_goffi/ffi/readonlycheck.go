@@ -0,0 +1,148 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// readonlyCheckEnabled is set once, from a GODEBUG=ffireadonlycheck=1
+// setting, by init below. Like the standard library's own GODEBUG knobs,
+// it can't be toggled once the program has started.
+var readonlyCheckEnabled bool
+
+func init() {
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if setting == "ffireadonlycheck=1" {
+			readonlyCheckEnabled = true
+			break
+		}
+	}
+}
+
+// ErrReadonlyWrite is the error CallReadonlyChecked wraps and returns
+// when spec.fn writes to one of spec's ffi:"readonly" fields - a
+// wrong-tag bug (the field isn't actually const on the C side) or a
+// genuine API misuse, either of which an ordinary spec.Call lets through
+// as silent memory corruption.
+var ErrReadonlyWrite = errors.New("ffi: call wrote to a ffi:\"readonly\" argument")
+
+// CallReadonlyChecked runs spec.Call(args), the same call an ordinary
+// call site would make, except that under GODEBUG=ffireadonlycheck=1 it
+// first substitutes, for every ffi:\"readonly\" slice field, a copy of
+// that slice's backing array living on its own mprotect'd, PROT_READ-only
+// page - so that if spec.fn writes to it instead of only reading it, the
+// write faults immediately instead of silently succeeding against
+// memory the caller believes is untouched once the call returns.
+//
+// The substitution is copy-in only: args keeps pointing at the caller's
+// original slice once CallReadonlyChecked returns (successfully or not),
+// exactly as if the field had never been tagged. The copy is read-only
+// for the live Go code too, not just spec.fn - a concurrent Go write to
+// the same backing array while spec.fn is running races either way, tag
+// or no tag, and is outside what this can detect.
+//
+// Detecting the fault at all means running the call the way
+// CallProtected does: on its own leaked, thread-locked goroutine, so a
+// SIGSEGV/SIGBUS can be observed instead of killing the process. See
+// CallProtected's doc comment for what that costs - at most one
+// CallReadonlyChecked (or CallProtected) outstanding at a time, and one
+// abandoned OS thread per fault ever observed. That cost is the reason
+// this is a GODEBUG-gated debug option and not spec.Call's default
+// behavior: with the flag off, or with no ffi:\"readonly\" fields at all,
+// CallReadonlyChecked is exactly spec.Call with no extra cost beyond the
+// len(spec.readonlyFields) == 0 check.
+//
+// CallReadonlyChecked doesn't return spec.Call's own result: Call's
+// return type differs from one arch backend to the next (int32 on
+// ffi_amd64.go, nothing at all on the others), and a faulted call never
+// reaches it anyway - the abandoned goroutine that faulted never gets to
+// spec.Call's own return. Use spec.Call directly when the result value
+// is needed and the readonly check isn't.
+func CallReadonlyChecked(spec *Spec, args unsafe.Pointer) error {
+	if !readonlyCheckEnabled || len(spec.readonlyFields) == 0 {
+		spec.Call(args)
+		return nil
+	}
+
+	restore, err := protectReadonlyFields(args, spec.readonlyFields)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	if faultErr := CallProtected(func() { spec.Call(args) }); faultErr != nil {
+		return fmt.Errorf("%w: %#x", ErrReadonlyWrite, spec.fn)
+	}
+	return nil
+}
+
+// protectReadonlyFields substitutes every field in fields with a copy of
+// its slice living on a dedicated, PROT_READ-only mmap mapping, the same
+// way jit_unix.go's jitMap/jitProtectExec build an executable mapping,
+// just without ever making this one writable again. A nil or empty slice
+// is left untouched - there's no backing array to protect and nothing
+// for a write to corrupt.
+//
+// The returned restore func puts every substituted field's Data word
+// back and unmaps every page it created, and must be called exactly
+// once, whether or not the protected call faulted.
+func protectReadonlyFields(args unsafe.Pointer, fields []readonlyField) (restore func(), err error) {
+	type protected struct {
+		dataOffset uint16
+		savedData  uintptr
+		mapAddr    uintptr
+		mapSize    int
+	}
+	var active []protected
+
+	restore = func() {
+		for _, p := range active {
+			data := (*uintptr)(unsafe.Pointer(uintptr(args) + uintptr(p.dataOffset)))
+			*data = p.savedData
+			b := unsafe.Slice((*byte)(unsafe.Pointer(p.mapAddr)), p.mapSize)
+			syscall.Munmap(b)
+		}
+	}
+
+	for _, f := range fields {
+		data := (*uintptr)(unsafe.Pointer(uintptr(args) + uintptr(f.dataOffset)))
+		length := *(*int)(unsafe.Pointer(uintptr(args) + uintptr(f.lenOffset)))
+		if *data == 0 || length == 0 {
+			continue
+		}
+
+		nbytes := length * int(f.elemSize)
+		size := pageAlign(nbytes)
+		b, merr := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+		if merr != nil {
+			restore()
+			return nil, fmt.Errorf("ffi: CallReadonlyChecked: mmap: %w", merr)
+		}
+		mapAddr := uintptr(unsafe.Pointer(&b[0]))
+
+		src := unsafe.Slice((*byte)(unsafe.Pointer(*data)), nbytes)
+		copy(b, src)
+
+		if merr := syscall.Mprotect(b, syscall.PROT_READ); merr != nil {
+			syscall.Munmap(b)
+			restore()
+			return nil, fmt.Errorf("ffi: CallReadonlyChecked: mprotect: %w", merr)
+		}
+
+		active = append(active, protected{
+			dataOffset: f.dataOffset,
+			savedData:  *data,
+			mapAddr:    mapAddr,
+			mapSize:    size,
+		})
+		*data = mapAddr
+	}
+
+	return restore, nil
+}
@@ -0,0 +1,73 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func writeBufTarget()
+func readBufTarget()
+func addrOfWriteBufTarget() uintptr
+func addrOfReadBufTarget() uintptr
+
+type readonlyWriteArgs struct {
+	Buf []byte `ffi:"arg,readonly"`
+}
+
+type readonlyReadArgs struct {
+	Buf []byte `ffi:"arg,readonly"`
+	R   int64  `ffi:"ret"`
+}
+
+// TestCallReadonlyCheckedWrite drives writeBufTarget - which writes
+// through its one argument instead of only reading it - through
+// CallReadonlyChecked. Under GODEBUG=ffireadonlycheck=1 the write must
+// fault against the mprotected copy and come back as ErrReadonlyWrite,
+// leaving the caller's own buffer untouched; with the flag off,
+// CallReadonlyChecked is plain spec.Call and the write reaches the real
+// buffer same as it always did.
+func TestCallReadonlyCheckedWrite(t *testing.T) {
+	args := readonlyWriteArgs{Buf: make([]byte, 8)}
+	spec := MakeSpec(addrOfWriteBufTarget(), &args)
+
+	err := CallReadonlyChecked(&spec, unsafe.Pointer(&args))
+
+	if readonlyCheckEnabled {
+		if !errors.Is(err, ErrReadonlyWrite) {
+			t.Fatalf("CallReadonlyChecked: got err=%v, want ErrReadonlyWrite", err)
+		}
+		if args.Buf[0] != 0 {
+			t.Fatalf("caller's buffer was modified despite the fault: %#x", args.Buf[0])
+		}
+	} else {
+		if err != nil {
+			t.Fatalf("CallReadonlyChecked: unexpected error %v", err)
+		}
+		if args.Buf[0] != 0x42 {
+			t.Fatalf("writeBufTarget's write did not reach the buffer: %#x", args.Buf[0])
+		}
+	}
+}
+
+// TestCallReadonlyCheckedRead confirms a well-behaved, read-only callee
+// works the same whether or not GODEBUG=ffireadonlycheck=1 is set: it
+// sees the real buffer's contents through the mprotected copy, and the
+// caller's own buffer is left exactly as it was.
+func TestCallReadonlyCheckedRead(t *testing.T) {
+	args := readonlyReadArgs{Buf: []byte{5, 6, 7}}
+	spec := MakeSpec(addrOfReadBufTarget(), &args)
+
+	err := CallReadonlyChecked(&spec, unsafe.Pointer(&args))
+	if err != nil {
+		t.Fatalf("CallReadonlyChecked: unexpected error %v", err)
+	}
+	if args.R != 5 {
+		t.Fatalf("readBufTarget returned %d, want 5", args.R)
+	}
+	if args.Buf[0] != 5 || args.Buf[1] != 6 || args.Buf[2] != 7 {
+		t.Fatalf("caller's buffer was modified: %v", args.Buf)
+	}
+}
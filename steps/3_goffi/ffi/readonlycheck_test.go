@@ -0,0 +1,101 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// TestReadonlyArgOffsets confirms readonlyArg computes the same Data
+// offset pinArg would for a Slice field, plus the Len offset and element
+// size protectReadonlyFields needs to know how many bytes to copy.
+func TestReadonlyArgOffsets(t *testing.T) {
+	type s struct {
+		Buf []byte
+	}
+	typ := reflect.TypeOf(s{})
+	bufField, _ := typ.FieldByName("Buf")
+
+	rf := readonlyArg(bufField, false)
+	if got, want := rf.dataOffset, pinArg(bufField, false); got != want {
+		t.Fatalf("dataOffset = %d, want %d", got, want)
+	}
+	if rf.lenOffset == rf.dataOffset {
+		t.Fatalf("lenOffset should not equal dataOffset")
+	}
+	if rf.elemSize != 1 {
+		t.Fatalf("elemSize = %d, want 1 for []byte", rf.elemSize)
+	}
+}
+
+// TestReadonlyArgRejectsBadInput confirms readonlyArg panics on a
+// non-slice field and on a ffi:"ret" field.
+func TestReadonlyArgRejectsBadInput(t *testing.T) {
+	type s struct {
+		N   int
+		Buf []byte
+	}
+	typ := reflect.TypeOf(s{})
+	nField, _ := typ.FieldByName("N")
+	bufField, _ := typ.FieldByName("Buf")
+
+	mustPanic(t, func() { readonlyArg(nField, false) })
+	mustPanic(t, func() { readonlyArg(bufField, true) })
+}
+
+// TestProtectReadonlyFieldsSkipsNilSlice confirms protectReadonlyFields
+// leaves a nil/empty slice's Data word untouched - there's no backing
+// array to protect, so there's nothing to substitute or restore.
+func TestProtectReadonlyFieldsSkipsNilSlice(t *testing.T) {
+	type s struct {
+		Buf []byte
+	}
+	var args s
+	typ := reflect.TypeOf(args)
+	bufField, _ := typ.FieldByName("Buf")
+	rf := readonlyArg(bufField, false)
+
+	restore, err := protectReadonlyFields(unsafe.Pointer(&args), []readonlyField{rf})
+	if err != nil {
+		t.Fatalf("protectReadonlyFields: unexpected error %v", err)
+	}
+	defer restore()
+
+	if args.Buf != nil {
+		t.Fatalf("nil slice was substituted: %#v", args.Buf)
+	}
+}
+
+// TestProtectReadonlyFieldsCopiesAndRestores confirms
+// protectReadonlyFields substitutes a non-empty slice's Data word with a
+// copy holding the same bytes, and that restore puts the original word
+// (and so the caller's own buffer) back afterward.
+func TestProtectReadonlyFieldsCopiesAndRestores(t *testing.T) {
+	type s struct {
+		Buf []byte
+	}
+	args := s{Buf: []byte{1, 2, 3}}
+	typ := reflect.TypeOf(args)
+	bufField, _ := typ.FieldByName("Buf")
+	rf := readonlyArg(bufField, false)
+
+	original := &args.Buf[0]
+	restore, err := protectReadonlyFields(unsafe.Pointer(&args), []readonlyField{rf})
+	if err != nil {
+		t.Fatalf("protectReadonlyFields: unexpected error %v", err)
+	}
+
+	if &args.Buf[0] == original {
+		t.Fatalf("Data word was not substituted with a copy")
+	}
+	if args.Buf[0] != 1 || args.Buf[1] != 2 || args.Buf[2] != 3 {
+		t.Fatalf("copy does not match original contents: %v", args.Buf)
+	}
+
+	restore()
+	if &args.Buf[0] != original {
+		t.Fatalf("Data word was not restored to the original buffer")
+	}
+}
@@ -0,0 +1,68 @@
+package ffi
+
+import "sync"
+
+// registryEntry defers resolve until the first Lookup, and caches
+// whatever it returns (Spec or error) for every Lookup after that, so a
+// symbol looked up by several packages only pays for dlsym/buildSpec
+// once no matter how many of them ask for it.
+type registryEntry struct {
+	once    sync.Once
+	resolve func() (Spec, error)
+	spec    Spec
+	err     error
+}
+
+// registry maps a symbol name to its registryEntry. It's process-wide so
+// a package can Register a binding from init() and any other package
+// can Lookup it by name, without either side needing a reference to the
+// other's Library or Spec variable.
+var registry sync.Map // map[string]*registryEntry
+
+// Register declares name as resolvable via resolve, without calling
+// resolve yet - so it's safe to call from init(), before whichever
+// Library the symbol lives in has necessarily been opened. resolve runs
+// at most once, on the first Lookup(name) by any caller; re-registering
+// an already-registered name replaces it.
+func Register(name string, resolve func() (Spec, error)) {
+	registry.Store(name, &registryEntry{resolve: resolve})
+}
+
+// RegisterFunc is Register for the common case of a Func[Args] resolved
+// by looking symbol up in lib, the same deferred-resolution relationship
+// Bind has to BindFunc. It also records the (name, resolve) pair against
+// lib, so Library.Reload can force name to re-resolve once lib's handle
+// has been swapped for a freshly reopened one.
+func RegisterFunc[Args any](name string, lib *Library, symbol string) {
+	resolve := func() (Spec, error) {
+		f, err := Bind[Args](lib, symbol)
+		return f.spec, err
+	}
+	Register(name, resolve)
+	trackLibraryBinding(lib, name, resolve)
+}
+
+// Lookup returns the Spec registered under name, resolving it via the
+// registered resolve func on the first call and returning the same Spec
+// (or error) on every call after that. It returns an *SpecError with
+// Kind ErrUnknownSymbol if name was never registered.
+func Lookup(name string) (Spec, error) {
+	v, ok := registry.Load(name)
+	if !ok {
+		return Spec{}, &SpecError{Kind: ErrUnknownSymbol, Msg: "no binding registered for " + name}
+	}
+	e := v.(*registryEntry)
+	e.once.Do(func() {
+		e.spec, e.err = e.resolve()
+	})
+	return e.spec, e.err
+}
+
+// LookupFunc is Lookup for a Func[Args] registered via RegisterFunc.
+func LookupFunc[Args any](name string) (Func[Args], error) {
+	spec, err := Lookup(name)
+	if err != nil {
+		return Func[Args]{}, err
+	}
+	return Func[Args]{spec: spec}, nil
+}
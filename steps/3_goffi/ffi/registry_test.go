@@ -0,0 +1,37 @@
+package ffi
+
+import "testing"
+
+// TestRegisterLookup exercises Register/Lookup's lazy, shared-resolution
+// contract: resolve only runs on the first of several Lookups, and every
+// Lookup after that gets back the exact Spec the first one resolved.
+func TestRegisterLookup(t *testing.T) {
+	calls := 0
+	Register("test-symbol", func() (Spec, error) {
+		calls++
+		return Spec{fn: 0x1234}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		got, err := Lookup("test-symbol")
+		if err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+		if got.fn != 0x1234 {
+			t.Fatalf("Lookup #%d: got fn=%#x, want 0x1234", i, got.fn)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("resolve called %d times, want 1", calls)
+	}
+}
+
+// TestLookupUnknownSymbol checks Lookup reports ErrUnknownSymbol for a
+// name nothing ever Registered, rather than panicking.
+func TestLookupUnknownSymbol(t *testing.T) {
+	_, err := Lookup("no-such-symbol")
+	se, ok := err.(*SpecError)
+	if !ok || se.Kind != ErrUnknownSymbol {
+		t.Fatalf("Lookup of unregistered name: got %v, want ErrUnknownSymbol", err)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+// RetryPredicate decides whether Retry should call spec again after
+// RetErrno reported err.
+type RetryPredicate func(err error) bool
+
+// IsEINTR is Retry's default RetryPredicate: true when err is
+// syscall.EINTR, the errno slow/interruptible C calls (read, write,
+// connect, ...) return if a signal arrived mid-call - not a real
+// failure, just something the Go runtime's own signal-driven preemption
+// makes common enough to retry for automatically.
+func IsEINTR(err error) bool {
+	return errors.Is(err, syscall.EINTR)
+}
+
+// Retry calls spec through RetErrno, calling it again each time the
+// result matches pred (IsEINTR if pred is nil), until either the call
+// succeeds (RetErrno returns nil) or pred says to stop. spec must be
+// built the way RetErrno itself requires - from a struct with a field
+// tagged ffi:"ret,errno" - since Retry is just RetErrno plus a retry
+// loop around it.
+func Retry(spec *Spec, args unsafe.Pointer, pred RetryPredicate) error {
+	if pred == nil {
+		pred = IsEINTR
+	}
+	for {
+		err := RetErrno(spec, args)
+		if err == nil || !pred(err) {
+			return err
+		}
+	}
+}
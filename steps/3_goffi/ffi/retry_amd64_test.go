@@ -0,0 +1,68 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// TestRetryRetriesOnEINTR confirms Retry keeps calling spec while
+// RetErrno reports EINTR, and stops as soon as fakeErrnoValue (and so
+// the sum's sign) changes to something that succeeds.
+func TestRetryRetriesOnEINTR(t *testing.T) {
+	fakeErrnoValue = int32(syscall.EINTR)
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &retErrnoArgs{})
+	spec.UseErrno(addrOfFakeErrnoLocationTarget())
+
+	args := retErrnoArgs{A: -5, B: 2} // sum -3: negative, so RetErrno "fails" every call
+	calls := 0
+	pred := func(err error) bool {
+		calls++
+		if calls == 3 {
+			// let the 3rd attempt "succeed" by making the sum non-negative
+			args.A = 5
+		}
+		return IsEINTR(err)
+	}
+
+	if err := Retry(&spec, unsafe.Pointer(&args), pred); err != nil {
+		t.Fatalf("Retry: got %v, want nil once the call succeeds", err)
+	}
+	if calls != 3 {
+		t.Fatalf("Retry: pred called %d times, want 3", calls)
+	}
+}
+
+// TestRetryStopsOnNonRetryableError confirms Retry returns immediately
+// when the default IsEINTR predicate doesn't match the errno RetErrno
+// reports.
+func TestRetryStopsOnNonRetryableError(t *testing.T) {
+	fakeErrnoValue = int32(syscall.EIO)
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &retErrnoArgs{})
+	spec.UseErrno(addrOfFakeErrnoLocationTarget())
+
+	args := retErrnoArgs{A: -5, B: 2}
+	err := Retry(&spec, unsafe.Pointer(&args), nil)
+	if err != syscall.EIO {
+		t.Fatalf("Retry: got %v, want syscall.EIO", err)
+	}
+}
+
+// TestRetrySucceedsWithoutRetrying confirms Retry returns nil on the
+// first call when it already succeeds, without ever consulting pred.
+func TestRetrySucceedsWithoutRetrying(t *testing.T) {
+	fakeErrnoValue = int32(syscall.EINTR)
+	spec := MakeSpec(addrOfAddPairFlatTarget(), &retErrnoArgs{})
+	spec.UseErrno(addrOfFakeErrnoLocationTarget())
+
+	args := retErrnoArgs{A: 5, B: 2}
+	pred := func(error) bool {
+		t.Fatal("Retry: pred should not be called when RetErrno already succeeded")
+		return false
+	}
+	if err := Retry(&spec, unsafe.Pointer(&args), pred); err != nil {
+		t.Fatalf("Retry: got %v, want nil", err)
+	}
+}
@@ -0,0 +1,56 @@
+package ffi
+
+import "unsafe"
+
+// These runtime internals exist on every architecture Go supports, so the
+// linknames below are shared by all the arch-specific Spec/Call
+// implementations in ffi_amd64.go, ffi_arm64.go and ffi_386.go.
+//
+// _Cgo_always_false and _Cgo_use themselves live in cguse_nocgo.go, not
+// here - see that file's doc comment for why they're the one pair in
+// this block that can't be declared unconditionally.
+
+// _Cgo_iscgo mirrors runtime.iscgo, the flag the runtime itself sets the
+// moment a real cgo runtime - not this package's fakecgo one - is actually
+// linked into the binary (see coexist.go). It's declared here rather than
+// in coexist.go because, like the four linknames above it, it's a plain
+// runtime global with no build-tag dependence of its own; what differs by
+// build tag is what reads it.
+//
+//go:linkname _Cgo_iscgo runtime.iscgo
+var _Cgo_iscgo bool
+
+// runtime's own declaration of asmcgocall (runtime/stubs.go) carries
+// go:noescape; ours should too, for the same reason - it only reads
+// through its uintptr arg for the duration of the call.
+//
+//go:linkname asmcgocall runtime.asmcgocall
+//go:noescape
+func asmcgocall(unsafe.Pointer, uintptr) int32
+
+//go:linkname entersyscall runtime.entersyscall
+func entersyscall()
+
+//go:linkname exitsyscall runtime.exitsyscall
+func exitsyscall()
+
+// funcPC used to live here too, as a //go:linkname to runtime.funcPC. It
+// doesn't anymore - see funcpc.go - because unlike these four, it stopped
+// having a linkable body on current toolchains: the compiler now
+// recognizes runtime.funcPC by name and inlines it away, so a linkname to
+// it resolves to nothing at link time. entersyscall/exitsyscall/
+// asmcgocall/cgoUse/cgoAlwaysFalse have carried the same name and
+// signature since at least Go 1.13 and don't have this problem; if a
+// future Go release renames or removes one of them, give it the same
+// build-tagged treatment funcpc.go uses rather than patching it in place.
+//
+// x_cgo_init/_cgo_init themselves - the fakecgo pre-main bootstrap hook -
+// live in cgobootstrap_nocgo.go, not here: unlike asmcgocall and friends,
+// which Call needs whether or not a real cgo runtime is also present,
+// installing this package's own bootstrap hook is the one thing that
+// must NOT happen when it is - see that file's doc comment.
+
+type emptyComplex64 struct {
+	a complex64
+}
+type emptyComplex128 complex128
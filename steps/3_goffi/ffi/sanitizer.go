@@ -0,0 +1,90 @@
+package ffi
+
+import "fmt"
+
+// SanitizerKind identifies one of the Clang/GCC runtime sanitizers a
+// loaded library may have been built with - each one installs its own
+// allocator and/or shadow-memory bookkeeping that calls crossing in and
+// out of Go are not instrumented for, so a mismatch here is a real
+// reason calls into lib might misbehave in ways that have nothing to do
+// with the arguments MakeSpec classified.
+type SanitizerKind int
+
+const (
+	SanitizerAddress   SanitizerKind = iota // ASan, compiler-rt's __asan_init
+	SanitizerThread                         // TSan, __tsan_init
+	SanitizerMemory                         // MSan, __msan_init
+	SanitizerUndefined                      // UBSan, __ubsan_default_options
+)
+
+// String returns the sanitizer's usual short name (e.g. "ASan").
+func (k SanitizerKind) String() string {
+	switch k {
+	case SanitizerAddress:
+		return "ASan"
+	case SanitizerThread:
+		return "TSan"
+	case SanitizerMemory:
+		return "MSan"
+	case SanitizerUndefined:
+		return "UBSan"
+	default:
+		return fmt.Sprintf("SanitizerKind(%d)", int(k))
+	}
+}
+
+// sanitizerProbeSymbols maps each SanitizerKind to a symbol its runtime
+// exports unconditionally once linked in, used only to detect its
+// presence - not called.
+var sanitizerProbeSymbols = map[SanitizerKind]string{
+	SanitizerAddress:   "__asan_init",
+	SanitizerThread:    "__tsan_init",
+	SanitizerMemory:    "__msan_init",
+	SanitizerUndefined: "__ubsan_default_options",
+}
+
+// DetectSanitizers probes lib for the runtime-init symbols ASan, TSan,
+// MSan, and UBSan each export once linked into a binary, and returns
+// every one found - a library can in principle carry more than one at a
+// time (ASan+UBSan is a common combination). The probe is a plain
+// GetOrStub per symbol, so it costs one failed dlsym per sanitizer not
+// present; Library.Symbols would be cheaper but isn't implemented on any
+// platform yet (see its own doc comment).
+func (lib *Library) DetectSanitizers() []SanitizerKind {
+	var found []SanitizerKind
+	for _, k := range []SanitizerKind{SanitizerAddress, SanitizerThread, SanitizerMemory, SanitizerUndefined} {
+		if _, ok := lib.GetOrStub(sanitizerProbeSymbols[k]); ok {
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+// ErrSanitizerIncompatible is returned by RequireNoSanitizer when
+// DetectSanitizers finds at least one sanitizer runtime linked into the
+// library.
+var ErrSanitizerIncompatible = fmt.Errorf("ffi: library was built with an incompatible sanitizer runtime")
+
+// RequireNoSanitizer is DetectSanitizers plus a clear, fail-fast error
+// for the common case: a caller who doesn't want to debug a sanitizer
+// build's shadow-memory and allocator interactions with calls crossing
+// the cgo-free boundary this package implements, and would rather get an
+// explicit error up front than a hard-to-diagnose crash or false-positive
+// report the first time the library runs.
+//
+// This package does not attempt the harder half of what a sanitizer-aware
+// interop mode would need - providing the shim symbols (e.g.
+// __asan_region_is_poisoned, __sanitizer_cov_trace_pc) an instrumented
+// library's compiler-inserted calls expect, or routing Go-side
+// allocations through the sanitizer's own allocator so both sides agree
+// on what memory is live. Both are effectively a partial reimplementation
+// of compiler-rt; DetectSanitizers plus this function is the honest,
+// supportable slice of that request - detection with a clear failure,
+// not transparent interop.
+func (lib *Library) RequireNoSanitizer() error {
+	found := lib.DetectSanitizers()
+	if len(found) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: detected %v", ErrSanitizerIncompatible, found)
+}
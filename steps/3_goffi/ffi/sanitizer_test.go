@@ -0,0 +1,30 @@
+package ffi
+
+import "testing"
+
+// TestDetectSanitizersNone confirms DetectSanitizers reports nothing for
+// this test binary itself, which is never built with a sanitizer
+// runtime linked in.
+func TestDetectSanitizersNone(t *testing.T) {
+	lib := &Library{}
+	if found := lib.DetectSanitizers(); len(found) != 0 {
+		t.Fatalf("DetectSanitizers: got %v, want none", found)
+	}
+	if err := lib.RequireNoSanitizer(); err != nil {
+		t.Fatalf("RequireNoSanitizer: got %v, want nil", err)
+	}
+}
+
+func TestSanitizerKindString(t *testing.T) {
+	cases := map[SanitizerKind]string{
+		SanitizerAddress:   "ASan",
+		SanitizerThread:    "TSan",
+		SanitizerMemory:    "MSan",
+		SanitizerUndefined: "UBSan",
+	}
+	for kind, want := range cases {
+		if got := kind.String(); got != want {
+			t.Fatalf("%d.String() = %q, want %q", kind, got, want)
+		}
+	}
+}
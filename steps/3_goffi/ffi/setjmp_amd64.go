@@ -0,0 +1,113 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// setjmpCallParams is laid out to match asmsetjmpcall's expectations in
+// setjmp_amd64.s exactly.
+type setjmpCallParams struct {
+	setjmpFn   uintptr
+	jmpbuf     uintptr
+	targetFn   uintptr
+	arg        uintptr
+	ret        uintptr
+	longjmpVal uintptr
+}
+
+// asmsetjmpcall (setjmp_amd64.s) is called by asmcgocall (via
+// CallSetjmpProtected) with a *setjmpCallParams in DI, the same ad hoc
+// asmcgocall convention asmcall (ffi_amd64.s) and asmrawsyscall
+// (rawsyscall_amd64.s) follow. It calls setjmpFn(jmpbuf) and, if that
+// returns zero, calls targetFn(arg) and stores its result through ret;
+// if setjmpFn's call instead "returns" nonzero - which only happens when
+// some later longjmp(jmpbuf, v) unwinds the C stack back to that call -
+// it stores v through longjmpVal and never calls targetFn at all.
+func asmsetjmpcall()
+
+var asmsetjmpcallptr = funcPC(asmsetjmpcall)
+
+// libcSetjmp lazily resolves libc's setjmp, the same libc()-style
+// two-step cstring.go's malloc/free use.
+func libcSetjmp() (setjmpFn uintptr, err error) {
+	setjmpOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			setjmpErr = openErr
+			return
+		}
+		setjmpFnAddr, setjmpErr = lib.Get("setjmp")
+	})
+	return setjmpFnAddr, setjmpErr
+}
+
+var (
+	setjmpOnce   sync.Once
+	setjmpFnAddr uintptr
+	setjmpErr    error
+)
+
+// LongjmpError is the error CallSetjmpProtected returns when target
+// called longjmp(jmpbuf, Value) instead of returning normally.
+type LongjmpError struct {
+	// Value is the value passed to longjmp - never 0, since longjmp
+	// itself substitutes 1 for a caller-supplied 0 (that's the one
+	// value setjmp's own return can't use to mean "a longjmp happened").
+	Value uintptr
+}
+
+func (e *LongjmpError) Error() string {
+	return fmt.Sprintf("ffi: call longjmp'd out with value %d instead of returning", e.Value)
+}
+
+// CallSetjmpProtected calls target(arg) - a single raw C function
+// pointer and argument, not a Spec - with jmpbuf established as its
+// longjmp target via libc's own setjmp, for a library like libpng or
+// libjpeg that reports errors by calling longjmp back out of however
+// deep in its own call stack the error was detected, rather than by an
+// ordinary return value. A successful call returns target's own return
+// value; a call that longjmp'd out instead returns a *LongjmpError
+// wrapping the value passed to longjmp.
+//
+// jmpbuf must point to at least as many bytes as the platform's own
+// jmp_buf needs - glibc's is 200 bytes on amd64 - the same "caller
+// supplies the address, this package never interprets what's there"
+// contract package libc's PthreadMutexInit/PthreadCondInit hold for
+// pthread_mutex_t/pthread_cond_t. Typically jmpbuf is whatever storage
+// the target library itself expects to find its own jmp_buf at (e.g.
+// libpng's png_jmpbuf(png_ptr)), already allocated by the time
+// CallSetjmpProtected is called.
+//
+// setjmp and the eventual call to target both happen within a single
+// asmcgocall switch to the system stack - the same g0 stack Call itself
+// runs spec.fn on (ffi_amd64.go) - so a longjmp anywhere inside target,
+// however deeply nested, safely unwinds back to this call rather than
+// to a goroutine stack frame that might have moved or already returned.
+// This is exactly why CallSetjmpProtected takes one raw target function
+// instead of an arbitrary closure: nothing here can keep a longjmp safe
+// across more than one asmcgocall switch, since longjmp can only unwind
+// to a still-live frame, and an earlier switch's g0 frame is gone the
+// moment it has returned to Go. A C library whose error path spans
+// several calls needs its own combined C-side entry point for this
+// primitive to protect as a single target.
+func CallSetjmpProtected(target, arg, jmpbuf uintptr) (uintptr, error) {
+	setjmpFn, err := libcSetjmp()
+	if err != nil {
+		return 0, err
+	}
+
+	params := &setjmpCallParams{setjmpFn: setjmpFn, jmpbuf: jmpbuf, targetFn: target, arg: arg}
+	asmcgocall(unsafe.Pointer(asmsetjmpcallptr), uintptr(unsafe.Pointer(params)))
+	if _Cgo_always_false {
+		_Cgo_use(params)
+	}
+
+	if params.longjmpVal != 0 {
+		return 0, &LongjmpError{Value: params.longjmpVal}
+	}
+	return params.ret, nil
+}
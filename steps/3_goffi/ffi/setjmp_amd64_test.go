@@ -0,0 +1,83 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func fakeSetjmp()
+func fakeLongjmp()
+func fakeLongjmpTarget()
+func fakeAddOneTarget()
+
+// fakeSetjmpAddr, fakeLongjmpTargetAddr and fakeAddOneTargetAddr
+// (setjmp_amd64_test.s) return the three functions above's real entry
+// addresses directly, rather than funcPC(fakeSetjmp) et al. As funcPC's
+// own doc comment explains, funcPC of an assembly function returns the
+// address of the ABIInternal-callable wrapper the compiler generates
+// around it, not the assembly body's own entry. fakeSetjmp and
+// fakeLongjmp specifically depend on introspecting their own immediate
+// caller's raw stack pointer and return address - exactly what such a
+// wrapper, interposed between asmsetjmpcall's CALL AX and the real
+// body, would corrupt - so these tests need the real body's address,
+// which only a same-file assembly symbol reference can give them.
+func fakeSetjmpAddr() uintptr
+func fakeLongjmpTargetAddr() uintptr
+func fakeAddOneTargetAddr() uintptr
+
+// callAsmSetjmp drives asmsetjmpcall directly with fakeSetjmp in place
+// of a real libc setjmpFn, so these tests exercise the actual
+// setjmp/longjmp stack-unwinding machinery without needing a real
+// loader (libcSetjmp, and so CallSetjmpProtected itself, always fails
+// with errLoaderUnsupported on this platform - see
+// TestCallSetjmpProtectedFailsCleanlyWithoutLoader).
+func callAsmSetjmp(target, arg, jmpbuf uintptr) setjmpCallParams {
+	params := setjmpCallParams{setjmpFn: fakeSetjmpAddr(), jmpbuf: jmpbuf, targetFn: target, arg: arg}
+	asmcgocall(unsafe.Pointer(asmsetjmpcallptr), uintptr(unsafe.Pointer(&params)))
+	return params
+}
+
+// TestAsmSetjmpCallOrdinaryReturn confirms asmsetjmpcall calls targetFn
+// and reports its return value when targetFn never longjmps.
+func TestAsmSetjmpCallOrdinaryReturn(t *testing.T) {
+	var buf [3]uintptr
+	params := callAsmSetjmp(fakeAddOneTargetAddr(), 41, uintptr(unsafe.Pointer(&buf)))
+	if params.longjmpVal != 0 {
+		t.Fatalf("longjmpVal = %d, want 0", params.longjmpVal)
+	}
+	if params.ret != 42 {
+		t.Fatalf("ret = %d, want 42", params.ret)
+	}
+}
+
+// TestAsmSetjmpCallLongjmp confirms asmsetjmpcall reports the value
+// passed to longjmp, rather than a return value, when targetFn longjmps
+// out instead of returning.
+func TestAsmSetjmpCallLongjmp(t *testing.T) {
+	var buf [3]uintptr
+	params := callAsmSetjmp(fakeLongjmpTargetAddr(), uintptr(unsafe.Pointer(&buf)), uintptr(unsafe.Pointer(&buf)))
+	if params.longjmpVal != 42 {
+		t.Fatalf("longjmpVal = %d, want 42", params.longjmpVal)
+	}
+	if params.ret != 0 {
+		t.Fatalf("ret = %d, want 0", params.ret)
+	}
+}
+
+// TestCallSetjmpProtectedFailsCleanlyWithoutLoader confirms
+// CallSetjmpProtected never panics: on a platform without a real loader
+// yet (loader_unsupported.go), Open fails and it returns that error
+// directly instead of calling setjmp at all.
+func TestCallSetjmpProtectedFailsCleanlyWithoutLoader(t *testing.T) {
+	_, err := CallSetjmpProtected(fakeAddOneTargetAddr(), 41, 0)
+	if err == nil {
+		t.Log("CallSetjmpProtected unexpectedly succeeded (a real loader must be present)")
+		return
+	}
+	if !errors.As(err, new(*LongjmpError)) {
+		t.Logf("CallSetjmpProtected: %v (expected until this platform has a real loader)", err)
+	}
+}
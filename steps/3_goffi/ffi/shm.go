@@ -0,0 +1,63 @@
+package ffi
+
+import "unsafe"
+
+// Mapping is a memory region obtained directly from the OS rather than
+// through Go's own allocator - an anonymous mmap (MapAnonymous) or an
+// attached POSIX shared memory object (CreateSharedMemory/OpenSharedMemory) -
+// exposed as a plain []byte for zero-copy exchange with a C library or
+// another process, with explicit lifetime control via Close instead of
+// leaving it to Go's GC.
+//
+// Mapping doesn't try to cover every mmap flag or shm_open mode: it's
+// the common case (a readable/writable region, shared so a write is
+// visible to whoever else maps the same memory) that CreateSharedMemory,
+// OpenSharedMemory and MapAnonymous all need, not a general mmap(2) binding.
+type Mapping struct {
+	data []byte
+	name string // empty for an anonymous mapping
+}
+
+// Bytes returns m's bytes directly, with no copy: a write through the
+// result is visible to whatever shares the same mapping (another
+// process holding the same named shared memory object, or C code this
+// mapping's Ptr was handed to), exactly as writing through a C mmap
+// result would be.
+func (m *Mapping) Bytes() []byte {
+	return m.data
+}
+
+// Ptr returns m's base address, for passing to a C function that takes
+// a raw pointer rather than Go's own []byte representation.
+func (m *Mapping) Ptr() uintptr {
+	if len(m.data) == 0 {
+		return 0
+	}
+	return uintptr(unsafe.Pointer(&m.data[0]))
+}
+
+// Len returns the size of m in bytes.
+func (m *Mapping) Len() int {
+	return len(m.data)
+}
+
+// Close unmaps m. A named mapping (CreateSharedMemory/OpenSharedMemory) stays
+// reachable by name for any other process that still has it open, or
+// until Unlink removes it - Close only detaches this process's own
+// view, the same as munmap leaving shm_unlink to a separate call.
+func (m *Mapping) Close() error {
+	return munmapMapping(m)
+}
+
+// Unlink removes m's underlying POSIX shared memory object by name, so
+// no further OpenSharedMemory call can attach to it - an already-open mapping
+// of it, including m's own until Close, stays valid, the same as
+// unlinking a file out from under a process that still has it open.
+// Unlink panics if m is an anonymous mapping (MapAnonymous): there's no
+// name to remove.
+func (m *Mapping) Unlink() error {
+	if m.name == "" {
+		panic("ffi: Mapping.Unlink: not a named shared mapping")
+	}
+	return unlinkShared(m.name)
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// shmPath turns a POSIX shared memory name (conventionally leading with
+// a "/", e.g. "/my-shm") into the tmpfs path glibc's own shm_open
+// resolves it to on Linux - /dev/shm is exactly the filesystem POSIX
+// shared memory objects live on here, so opening that path directly
+// gives the same object a real shm_open/shm_unlink pair would, without
+// needing cgo or a libc binding to call them through.
+func shmPath(name string) string {
+	if len(name) > 0 && name[0] == '/' {
+		name = name[1:]
+	}
+	return "/dev/shm/" + name
+}
+
+// CreateSharedMemory creates (or truncates, if it already exists) a POSIX
+// shared memory object called name, sized to size bytes, and maps it
+// into this process - for handing the same block of memory to another
+// process or a C library by name rather than by inherited file
+// descriptor. The object outlives this process's own mapping of it
+// (and any other process's) until something calls Unlink; see
+// Mapping.Close and Mapping.Unlink.
+func CreateSharedMemory(name string, size int) (*Mapping, error) {
+	f, err := os.OpenFile(shmPath(name), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("ffi: CreateSharedMemory(%q): %w", name, err)
+	}
+	defer f.Close()
+	if err := f.Truncate(int64(size)); err != nil {
+		return nil, fmt.Errorf("ffi: CreateSharedMemory(%q): %w", name, err)
+	}
+	return mapSharedFd(name, f, size)
+}
+
+// OpenSharedMemory attaches to an already-created POSIX shared memory object
+// called name, mapping its existing contents - size must match (or be
+// smaller than) whatever CreateSharedMemory sized it to.
+func OpenSharedMemory(name string, size int) (*Mapping, error) {
+	f, err := os.OpenFile(shmPath(name), os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("ffi: OpenSharedMemory(%q): %w", name, err)
+	}
+	defer f.Close()
+	return mapSharedFd(name, f, size)
+}
+
+func mapSharedFd(name string, f *os.File, size int) (*Mapping, error) {
+	b, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("ffi: mmap shared memory %q: %w", name, err)
+	}
+	return &Mapping{data: b, name: name}, nil
+}
+
+// unlinkShared removes name's underlying POSIX shared memory object -
+// see Mapping.Unlink.
+func unlinkShared(name string) error {
+	if err := os.Remove(shmPath(name)); err != nil {
+		return fmt.Errorf("ffi: Unlink(%q): %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build !windows && !linux
+
+package ffi
+
+import "errors"
+
+// errSharedMemoryUnsupported is returned by CreateSharedMemory/OpenSharedMemory/
+// unlinkShared here: unlike Linux, where /dev/shm is a tmpfs POSIX
+// shared memory objects live on directly (shm_linux.go), this platform's
+// shm_open opens an object through a kernel-private namespace with no
+// public filesystem path backing it, so reaching it without calling the
+// real shm_open/shm_unlink - which would need cgo or a libc binding,
+// the same gap loader_unsupported.go's errLoaderUnsupported documents
+// for dlopen on Linux - isn't possible yet. MapAnonymous (shm_unix.go)
+// is unaffected: it needs no named object, just mmap, which this
+// platform's syscall package already supports directly.
+var errSharedMemoryUnsupported = errors.New("ffi: named shared memory is not yet supported on this platform")
+
+func CreateSharedMemory(name string, size int) (*Mapping, error) {
+	return nil, errSharedMemoryUnsupported
+}
+
+func OpenSharedMemory(name string, size int) (*Mapping, error) {
+	return nil, errSharedMemoryUnsupported
+}
+
+func unlinkShared(name string) error {
+	return errSharedMemoryUnsupported
+}
@@ -0,0 +1,112 @@
+package ffi
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestMapAnonymousReadWrite confirms MapAnonymous returns a usable,
+// writable mapping of the requested size.
+func TestMapAnonymousReadWrite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("MapAnonymous is not yet supported on windows")
+	}
+	m, err := MapAnonymous(4096)
+	if err != nil {
+		t.Fatalf("MapAnonymous: unexpected error: %v", err)
+	}
+	defer m.Close()
+
+	if m.Len() != 4096 {
+		t.Fatalf("Len(): got %d, want 4096", m.Len())
+	}
+	if m.Ptr() == 0 {
+		t.Fatal("Ptr(): got 0 for a non-empty mapping")
+	}
+	m.Bytes()[0] = 0xAB
+	if m.Bytes()[0] != 0xAB {
+		t.Fatal("write through Bytes() didn't stick")
+	}
+}
+
+// TestMappingUnlinkPanicsForAnonymous confirms Unlink refuses to run on
+// a mapping with no name to remove.
+func TestMappingUnlinkPanicsForAnonymous(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("MapAnonymous is not yet supported on windows")
+	}
+	m, err := MapAnonymous(4096)
+	if err != nil {
+		t.Fatalf("MapAnonymous: unexpected error: %v", err)
+	}
+	defer m.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Unlink: expected a panic for an anonymous mapping")
+		}
+	}()
+	m.Unlink()
+}
+
+// TestCreateOpenSharedRoundTrips confirms a second attach via OpenSharedMemory
+// sees what CreateSharedMemory's own mapping wrote - the zero-copy contract
+// named shared memory exists for.
+func TestCreateOpenSharedRoundTrips(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("named shared memory is only implemented on linux so far")
+	}
+	name := "/ffi-test-shm-roundtrip"
+
+	writer, err := CreateSharedMemory(name, 4096)
+	if err != nil {
+		t.Fatalf("CreateSharedMemory: unexpected error: %v", err)
+	}
+	defer writer.Unlink()
+	defer writer.Close()
+
+	writer.Bytes()[0] = 0x42
+
+	reader, err := OpenSharedMemory(name, 4096)
+	if err != nil {
+		t.Fatalf("OpenSharedMemory: unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	if got := reader.Bytes()[0]; got != 0x42 {
+		t.Fatalf("OpenSharedMemory didn't see CreateSharedMemory's write: got %#x, want 0x42", got)
+	}
+}
+
+// TestOpenSharedMissingFails confirms OpenSharedMemory fails cleanly for a
+// name nothing has created.
+func TestOpenSharedMissingFails(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("named shared memory is only implemented on linux so far")
+	}
+	if _, err := OpenSharedMemory("/ffi-test-shm-does-not-exist", 4096); err == nil {
+		t.Fatal("OpenSharedMemory: expected an error for a name that was never created")
+	}
+}
+
+// TestUnlinkSharedRemovesObject confirms Unlink actually removes the
+// object: a later OpenSharedMemory of the same name fails.
+func TestUnlinkSharedRemovesObject(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("named shared memory is only implemented on linux so far")
+	}
+	name := "/ffi-test-shm-unlink"
+
+	m, err := CreateSharedMemory(name, 4096)
+	if err != nil {
+		t.Fatalf("CreateSharedMemory: unexpected error: %v", err)
+	}
+	if err := m.Unlink(); err != nil {
+		t.Fatalf("Unlink: unexpected error: %v", err)
+	}
+	m.Close()
+
+	if _, err := OpenSharedMemory(name, 4096); err == nil {
+		t.Fatal("OpenSharedMemory: expected an error after Unlink")
+	}
+}
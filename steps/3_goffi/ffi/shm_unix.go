@@ -0,0 +1,27 @@
+//go:build !windows
+
+package ffi
+
+import "syscall"
+
+// MapAnonymous mmaps size bytes, shared and readable/writable - not
+// backed by any file or named object, just like jitMap's (jit_unix.go)
+// mapping except never made executable, since this is for holding data
+// rather than JIT-built code.
+func MapAnonymous(size int) (*Mapping, error) {
+	b, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+	return &Mapping{data: b}, nil
+}
+
+// munmapMapping munmaps m's bytes, for Mapping.Close - the same
+// operation regardless of whether m came from MapAnonymous or
+// CreateShared/OpenShared.
+func munmapMapping(m *Mapping) error {
+	if len(m.data) == 0 {
+		return nil
+	}
+	return syscall.Munmap(m.data)
+}
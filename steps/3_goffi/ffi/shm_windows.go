@@ -0,0 +1,33 @@
+//go:build windows
+
+package ffi
+
+import "errors"
+
+// errSharedMemoryUnsupported is returned by every function in this file:
+// Windows has no mmap/shm_open equivalent in the standard syscall
+// package - the real APIs are CreateFileMapping/MapViewOfFile, which
+// this package doesn't bind yet (the same kind of gap
+// loader_unsupported.go's errLoaderUnsupported documents for dlopen on
+// platforms without a loader).
+var errSharedMemoryUnsupported = errors.New("ffi: shared memory mapping is not yet supported on this platform")
+
+func MapAnonymous(size int) (*Mapping, error) {
+	return nil, errSharedMemoryUnsupported
+}
+
+func CreateSharedMemory(name string, size int) (*Mapping, error) {
+	return nil, errSharedMemoryUnsupported
+}
+
+func OpenSharedMemory(name string, size int) (*Mapping, error) {
+	return nil, errSharedMemoryUnsupported
+}
+
+func unlinkShared(name string) error {
+	return errSharedMemoryUnsupported
+}
+
+func munmapMapping(m *Mapping) error {
+	return errSharedMemoryUnsupported
+}
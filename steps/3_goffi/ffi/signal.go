@@ -0,0 +1,71 @@
+package ffi
+
+import "fmt"
+
+// SignalAction is the portable subset of a POSIX struct sigaction this
+// package can round-trip through GetSignalAction/SetSignalAction: the
+// handler (SIG_DFL, SIG_IGN, or a real function pointer) and sa_flags.
+// The full C struct also carries a sa_mask - additional signals to
+// block while the handler runs - which this package always treats as
+// empty: SetSignalAction installs an action whose mask blocks nothing
+// else, and GetSignalAction never reports what a previous mask actually
+// was. Most libraries leave sa_mask empty anyway; something that relies
+// on a non-empty one needs sigaction's full struct, which this type
+// doesn't expose.
+type SignalAction struct {
+	Handler uintptr
+	Flags   int32
+}
+
+// GetSignalAction reads sig's current disposition without changing it -
+// libc sigaction(sig, NULL, &old), unlike the simpler signal(2), which
+// can only report the previous disposition by installing a new one in
+// the same call.
+func GetSignalAction(sig int) (SignalAction, error) {
+	return getSignalAction(sig)
+}
+
+// SetSignalAction installs act as sig's disposition and returns what it
+// replaced - libc sigaction(sig, &act, &old).
+func SetSignalAction(sig int, act SignalAction) (SignalAction, error) {
+	return setSignalAction(sig, act)
+}
+
+// SaveSignalActions reads the current disposition of every signal in
+// sigs, for RestoreSignalActions to put back later - the bracket half of
+// "save/restore signal dispositions around a library load and its
+// calls" this package can actually provide. Real cgo's own sigaction
+// interposition (runtime/cgo's wrapper around the libc symbol, linked in
+// process-wide so it sees every sigaction call a C library itself makes,
+// not just this package's own) has no equivalent here: there's no way in
+// plain Go to override a dynamically resolved libc symbol process-wide
+// without cgo, so nothing here can intercept a library's own sigaction
+// calls as they happen. SaveSignalActions/RestoreSignalActions give you
+// the coarser, caller-driven version instead: snapshot before handing
+// control to a library that might install its own handlers for signals
+// like SIGSEGV or SIGPROF, then restore once you're done with it.
+func SaveSignalActions(sigs ...int) (map[int]SignalAction, error) {
+	saved := make(map[int]SignalAction, len(sigs))
+	for _, sig := range sigs {
+		act, err := GetSignalAction(sig)
+		if err != nil {
+			return nil, fmt.Errorf("ffi: SaveSignalActions: signal %d: %w", sig, err)
+		}
+		saved[sig] = act
+	}
+	return saved, nil
+}
+
+// RestoreSignalActions puts back every disposition SaveSignalActions
+// recorded. It keeps going and returns the first error encountered, if
+// any, so one signal this platform can't set back doesn't prevent
+// restoring the rest.
+func RestoreSignalActions(saved map[int]SignalAction) error {
+	var firstErr error
+	for sig, act := range saved {
+		if _, err := SetSignalAction(sig, act); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("ffi: RestoreSignalActions: signal %d: %w", sig, err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,229 @@
+//go:build linux && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// signalRingSlotSize is one SignalSafeRecord slot's stride in
+// signalRing.buf: six uint64 registers (48 bytes) rounded up to 64 for
+// cache-line alignment, so two signal handlers on different threads
+// writing to adjacent slots at once don't share a cache line.
+const signalRingSlotSize = 64
+
+// signalRing is the lock-free ring buffer a SignalSafeCallback trampoline
+// writes into and its drain goroutine reads from. writeSeq is advanced
+// with a single LOCK XADD the trampoline itself issues (see
+// buildSignalTrampoline) - the only synchronization a signal handler can
+// safely perform, since a mutex might already be held by the very code
+// the signal interrupted. readSeq is owned by the drain goroutine alone
+// and never touched by the trampoline.
+//
+// A ring this size can still be overrun: if writers outpace the drain
+// goroutine by more than len(slots), old entries are overwritten before
+// being read. That's an inherent trade-off of a backpressure-free,
+// signal-safe design - the trampoline cannot block waiting for the
+// reader, any more than it could take a lock - so Dropped counts how
+// often it happens instead of pretending it can't.
+type signalRing struct {
+	buf      []byte
+	slots    int
+	writeSeq uint64 // atomic; written by the trampoline via LOCK XADD
+	readSeq  uint64 // owned by the drain goroutine
+	dropped  atomic.Int64
+	notify   [1]byte // the byte address the trampoline's write(2) sends
+}
+
+// SignalSafeRecord is one entry SignalSafeCallback's drain goroutine
+// delivers: the six SysV AMD64 integer argument registers (RDI, RSI,
+// RDX, RCX, R8, R9) exactly as they were when the signal handler invoked
+// the trampoline. There is no float/xmm, stack-argument, or return-value
+// support - the trampoline that captures these six integers is the most
+// MakeCallback-style classification this design can do with no Go
+// runtime entry at all (see buildSignalTrampoline's own doc comment for
+// why).
+type SignalSafeRecord struct {
+	Regs [6]uint64
+}
+
+// SignalSafeCallback is a MakeCallback alternative for a C-callable
+// function pointer a library invokes from inside a signal handler - code
+// running with no f of its own, unable to safely take a lock, allocate,
+// or do anything else cgocallback's ordinary needm/dropm path
+// (callback_amd64.go) assumes is always safe. Rather than enter Go at
+// all from that context, the trampoline SignalSafeCallback builds only
+// records the incoming registers into a signalRing and writes one byte
+// to a self-pipe - both async-signal-safe by POSIX's own definition -
+// and returns; a normal background goroutine, Pointer never having run
+// inside the signal itself, drains the ring and calls fn.
+type SignalSafeCallback struct {
+	ptr    uintptr
+	ring   *signalRing
+	readFd int
+	fn     func(SignalSafeRecord)
+	done   chan struct{}
+	thunk  uintptr
+}
+
+// MakeSignalSafeCallback returns a SignalSafeCallback whose Pointer is
+// safe to hand to a C library as a signal handler's own callback.
+// capacity is the ring buffer's size in records and must be a power of
+// two; fn is called from a dedicated background goroutine, never from
+// signal context, once per record the trampoline captured (subject to
+// Dropped if fn falls behind).
+func MakeSignalSafeCallback(capacity int, fn func(SignalSafeRecord)) (*SignalSafeCallback, error) {
+	if capacity <= 0 || capacity&(capacity-1) != 0 {
+		return nil, fmt.Errorf("ffi: MakeSignalSafeCallback: capacity %d is not a power of two", capacity)
+	}
+
+	ring := &signalRing{buf: make([]byte, capacity*signalRingSlotSize), slots: capacity}
+	ring.notify[0] = 1
+
+	var fds [2]int
+	if err := syscall.Pipe2(fds[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC); err != nil {
+		return nil, fmt.Errorf("ffi: MakeSignalSafeCallback: %w", err)
+	}
+
+	code := buildSignalTrampoline(ring, fds[1])
+	thunk, err := callbackThunks.Alloc(code)
+	if err != nil {
+		syscall.Close(fds[0])
+		syscall.Close(fds[1])
+		return nil, fmt.Errorf("ffi: MakeSignalSafeCallback: %v", err)
+	}
+	perfMapAddThunk(thunk, len(code), "ffi.signalcallback")
+
+	s := &SignalSafeCallback{ptr: thunk, ring: ring, readFd: fds[0], fn: fn, done: make(chan struct{}), thunk: thunk}
+	go s.drain()
+	return s, nil
+}
+
+// Pointer returns s's C-callable function pointer.
+func (s *SignalSafeCallback) Pointer() uintptr {
+	return s.ptr
+}
+
+// Dropped returns how many records the trampoline overwrote before
+// drain ever read them, because the ring filled up faster than fn could
+// keep up.
+func (s *SignalSafeCallback) Dropped() int64 {
+	return s.ring.dropped.Load()
+}
+
+// Close stops s's drain goroutine and frees its trampoline and pipe. The
+// C side must never invoke s.Pointer() again once Close returns.
+func (s *SignalSafeCallback) Close() error {
+	close(s.done)
+	syscall.Close(s.readFd)
+	return callbackThunks.Free(s.thunk)
+}
+
+// drain runs on an ordinary goroutine - never invoked from signal
+// context - blocking on the pipe's read end via the runtime's normal
+// netpoller-integrated file I/O, waking whenever the trampoline's
+// write(2) has posted a byte, and delivering every record the ring
+// buffer has accumulated since the last wakeup.
+func (s *SignalSafeCallback) drain() {
+	f := os.NewFile(uintptr(s.readFd), "ffi-signal-safe-callback")
+	defer f.Close()
+	buf := make([]byte, 64)
+	for {
+		if _, err := f.Read(buf); err != nil {
+			return // Close'd: readFd is already gone.
+		}
+		s.deliver()
+	}
+}
+
+// deliver copies out every record written since the last call (or all
+// of them, capped to s.ring.slots, if the writer lapped the reader) and
+// calls fn for each in order.
+func (s *SignalSafeCallback) deliver() {
+	write := atomic.LoadUint64(&s.ring.writeSeq)
+	read := s.ring.readSeq
+	if write-read > uint64(s.ring.slots) {
+		s.ring.dropped.Add(int64(write - read - uint64(s.ring.slots)))
+		read = write - uint64(s.ring.slots)
+	}
+	for ; read != write; read++ {
+		off := int(read%uint64(s.ring.slots)) * signalRingSlotSize
+		var rec SignalSafeRecord
+		for i := range rec.Regs {
+			rec.Regs[i] = *(*uint64)(unsafe.Pointer(&s.ring.buf[off+i*8]))
+		}
+		s.fn(rec)
+	}
+	s.ring.readSeq = read
+}
+
+// buildSignalTrampoline assembles a trampoline that, given no Go runtime
+// entry at all, does the minimum POSIX defines as async-signal-safe:
+// atomically claim the next ring slot (LOCK XADD against
+// &ring.writeSeq), copy RDI/RSI/RDX/RCX/R8/R9 into it, and issue a raw
+// write(2) syscall (the bare SYSCALL instruction, not a CALL to any
+// libc wrapper - resolving a PLT entry, or anything else that might
+// touch a lock, is exactly what this trampoline must not risk) to
+// notify fd. It never calls into cgocallback, needm, or any other part
+// of the Go runtime - there is no callbackCall-style marshaling against
+// a Spec here, only a fixed six-register capture, because doing more
+// (floats, stack arguments, variable layouts) would mean generating
+// code shaped like asmcall's, and asmcall itself is not signal-safe to
+// run arbitrarily interrupted code through.
+//
+// This trampoline is Linux/amd64-specific: the raw SYSCALL instruction's
+// calling convention and syscall numbers are not portable, and other
+// platforms have no equivalent file yet (the same incremental scoping
+// callback_amd64.go's own !windows callbacks started with).
+func buildSignalTrampoline(ring *signalRing, fd int) []byte {
+	code := []byte{0xf3, 0x0f, 0x1e, 0xfa} // endbr64
+
+	writeSeqAddr := uint64(uintptr(unsafe.Pointer(&ring.writeSeq)))
+	code = append(code, 0x49, 0xba) // MOVABS R10, writeSeqAddr
+	code = appendUint64LE(code, writeSeqAddr)
+	code = append(code, 0xb8, 0x01, 0x00, 0x00, 0x00) // MOV EAX, 1
+	code = append(code, 0xf0, 0x49, 0x0f, 0xc1, 0x02) // LOCK XADD [R10], RAX
+	code = append(code, 0x48, 0x25)                   // AND RAX, imm32
+	code = appendUint32LE(code, uint32(ring.slots-1))
+	code = append(code, 0x48, 0xc1, 0xe0, signalRingSlotSizeLog2()) // SHL RAX, log2(slotSize)
+
+	bufAddr := uint64(uintptr(unsafe.Pointer(&ring.buf[0])))
+	code = append(code, 0x49, 0xbb) // MOVABS R11, bufAddr
+	code = appendUint64LE(code, bufAddr)
+	code = append(code, 0x4c, 0x01, 0xd8) // ADD RAX, R11 -> RAX = slot address
+
+	code = append(code, 0x48, 0x89, 0x38)       // MOV [RAX+0],  RDI
+	code = append(code, 0x48, 0x89, 0x70, 0x08) // MOV [RAX+8],  RSI
+	code = append(code, 0x48, 0x89, 0x50, 0x10) // MOV [RAX+16], RDX
+	code = append(code, 0x48, 0x89, 0x48, 0x18) // MOV [RAX+24], RCX
+	code = append(code, 0x4c, 0x89, 0x40, 0x20) // MOV [RAX+32], R8
+	code = append(code, 0x4c, 0x89, 0x48, 0x28) // MOV [RAX+40], R9
+
+	code = append(code, 0xbf) // MOV EDI, fd
+	code = appendUint32LE(code, uint32(fd))
+	notifyAddr := uint64(uintptr(unsafe.Pointer(&ring.notify[0])))
+	code = append(code, 0x48, 0xbe) // MOVABS RSI, notifyAddr
+	code = appendUint64LE(code, notifyAddr)
+	code = append(code, 0xba, 0x01, 0x00, 0x00, 0x00) // MOV EDX, 1
+	code = append(code, 0xb8, 0x01, 0x00, 0x00, 0x00) // MOV EAX, 1 (SYS_write)
+	code = append(code, 0x0f, 0x05)                   // SYSCALL
+
+	code = append(code, 0xc3) // RET
+	return code
+}
+
+// signalRingSlotSizeLog2 is log2(signalRingSlotSize), computed rather
+// than hard-coded so buildSignalTrampoline's SHL operand always matches
+// the constant above even if it's ever changed.
+func signalRingSlotSizeLog2() byte {
+	n, shift := signalRingSlotSize, byte(0)
+	for n > 1 {
+		n >>= 1
+		shift++
+	}
+	return shift
+}
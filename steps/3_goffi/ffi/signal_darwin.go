@@ -0,0 +1,95 @@
+//go:build darwin
+
+package ffi
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// sigactionSize is sizeof(struct sigaction) on Darwin (sys/signal.h): an
+// 8 byte handler union, sa_mask as a plain 4 byte __darwin_sigset_t (not
+// the much larger Linux sigset_t), and a 4 byte sa_flags - 16 bytes
+// total, with no trailing padding since that already sums to a multiple
+// of the struct's own 8 byte (pointer) alignment.
+const sigactionSize = 16
+
+const (
+	sigactionOffHandler = 0
+	sigactionOffMask    = 8
+	sigactionOffFlags   = 12
+)
+
+// sigactionLibc lazily opens libcPath and resolves sigaction from it, the
+// same Open+Get two-step libc() in cstring.go uses for malloc/free.
+func sigactionLibc() (uintptr, error) {
+	sigactionOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			sigactionErr = openErr
+			return
+		}
+		sigactionFn, sigactionErr = lib.Get("sigaction")
+	})
+	return sigactionFn, sigactionErr
+}
+
+var (
+	sigactionOnce sync.Once
+	sigactionFn   uintptr
+	sigactionErr  error
+)
+
+// sigactionArgs is a plain MakeSpec target for libc's
+// sigaction(int, const struct sigaction *, struct sigaction *). act and
+// old are raw buffer addresses rather than a Go struct MakeSpec would
+// classify itself, since either one is allowed to be NULL - a contract a
+// typed ffi:"ptr" field can't express.
+type sigactionArgs struct {
+	Sig int32   `ffi:"arg"`
+	Act uintptr `ffi:"arg"`
+	Old uintptr `ffi:"arg"`
+	R   int32   `ffi:"ret"`
+}
+
+func getSignalAction(sig int) (SignalAction, error) {
+	return doSigaction(sig, nil)
+}
+
+func setSignalAction(sig int, act SignalAction) (SignalAction, error) {
+	buf := encodeSigaction(act)
+	return doSigaction(sig, &buf)
+}
+
+func doSigaction(sig int, act *[sigactionSize]byte) (SignalAction, error) {
+	fn, err := sigactionLibc()
+	if err != nil {
+		return SignalAction{}, err
+	}
+	var old [sigactionSize]byte
+	args := sigactionArgs{Sig: int32(sig), Old: uintptr(unsafe.Pointer(&old[0]))}
+	if act != nil {
+		args.Act = uintptr(unsafe.Pointer(&act[0]))
+	}
+	spec := MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return SignalAction{}, fmt.Errorf("ffi: sigaction(%d): returned %d", sig, args.R)
+	}
+	return decodeSigaction(old), nil
+}
+
+func encodeSigaction(act SignalAction) [sigactionSize]byte {
+	var buf [sigactionSize]byte
+	*(*uintptr)(unsafe.Pointer(&buf[sigactionOffHandler])) = act.Handler
+	*(*int32)(unsafe.Pointer(&buf[sigactionOffFlags])) = act.Flags
+	return buf
+}
+
+func decodeSigaction(buf [sigactionSize]byte) SignalAction {
+	return SignalAction{
+		Handler: *(*uintptr)(unsafe.Pointer(&buf[sigactionOffHandler])),
+		Flags:   *(*int32)(unsafe.Pointer(&buf[sigactionOffFlags])),
+	}
+}
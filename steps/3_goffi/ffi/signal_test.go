@@ -0,0 +1,27 @@
+package ffi
+
+import "testing"
+
+// TestSaveRestoreSignalActions exercises the Save/Restore pairing against
+// whatever getSignalAction/setSignalAction actually do on this platform -
+// a real round trip on Darwin, or the honest errSignalActionUnsupported
+// everywhere else (signal_unsupported.go) - without hard-coding either
+// outcome, so the test means the same thing on every target this package
+// cross-compiles for.
+func TestSaveRestoreSignalActions(t *testing.T) {
+	const sigint = 2
+
+	saved, err := SaveSignalActions(sigint)
+	if err != nil {
+		if _, direct := GetSignalAction(sigint); direct == nil {
+			t.Fatalf("SaveSignalActions failed but GetSignalAction(%d) alone succeeded: %v", sigint, err)
+		}
+		return
+	}
+	if len(saved) != 1 {
+		t.Fatalf("SaveSignalActions: got %d entries, want 1", len(saved))
+	}
+	if err := RestoreSignalActions(saved); err != nil {
+		t.Fatalf("RestoreSignalActions: %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !darwin
+
+package ffi
+
+import "errors"
+
+// errSignalActionUnsupported is returned by getSignalAction/setSignalAction
+// on every platform besides Darwin: Windows has no POSIX sigaction to
+// call at all (its vectored-exception-handler model isn't a disposition
+// table this type could represent), and Linux/FreeBSD's ffi.Open can't
+// dlopen libc yet for the same reason CString/cmem.Malloc can't there
+// either - see loader_unsupported.go's own doc comment.
+var errSignalActionUnsupported = errors.New("ffi: signal dispositions are not yet supported on this platform")
+
+func getSignalAction(sig int) (SignalAction, error) {
+	return SignalAction{}, errSignalActionUnsupported
+}
+
+func setSignalAction(sig int, act SignalAction) (SignalAction, error) {
+	return SignalAction{}, errSignalActionUnsupported
+}
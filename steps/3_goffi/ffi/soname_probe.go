@@ -0,0 +1,115 @@
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sonameCandidate is one file soname_probe.go's probe found on disk
+// that looks like baseName with a version suffix attached.
+type sonameCandidate struct {
+	path    string
+	version sonameVersion
+}
+
+// probeSonames lists every dir in dirs for files named baseName, or
+// baseName followed by ".<version>" (baseName "libssl.so" matches
+// "libssl.so", "libssl.so.1.1", "libssl.so.3", ...) - the same shape
+// readdir-based probing a real ld.so performs when a .so.<N> symlink
+// doesn't exist and it has to pick the newest matching soname itself.
+// A candidate whose version suffix doesn't parse (parseSonameVersion
+// returns ok=false) is skipped rather than failing the whole probe -
+// plenty of real directories have unrelated baseName-prefixed files
+// (baseName.bak, baseName.orig from a package manager) that aren't
+// sonames at all.
+func probeSonames(baseName string, dirs []string) []sonameCandidate {
+	var found []sonameCandidate
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if name == baseName {
+				found = append(found, sonameCandidate{path: dir + "/" + name, version: nil})
+				continue
+			}
+			suffix := strings.TrimPrefix(name, baseName+".")
+			if suffix == name {
+				continue
+			}
+			v, ok := parseSonameVersion(suffix)
+			if !ok {
+				continue
+			}
+			found = append(found, sonameCandidate{path: dir + "/" + name, version: v})
+		}
+	}
+	return found
+}
+
+// VersionedLibraryPath resolves baseName (e.g. "libssl.so") to whichever
+// soname found under searchDirs satisfies constraint (e.g. ">=1.1,
+// <3"), preferring the highest matching version when more than one
+// does - the same preference a real dynamic linker has for the newest
+// compatible soname. The error, when no candidate matches, lists every
+// version probeSonames actually found so the caller can tell "nothing
+// installed at all" apart from "installed, but none of these versions
+// satisfy the constraint" without re-running the probe itself.
+func VersionedLibraryPath(baseName, constraint string) (string, error) {
+	return versionedLibraryPath(baseName, constraint, defaultPkgConfigLibDirs, probeSonames)
+}
+
+// OpenVersioned is VersionedLibraryPath followed by Open, for the common
+// case of wanting the matching library loaded rather than just its path.
+func OpenVersioned(baseName, constraint string) (*Library, error) {
+	path, err := VersionedLibraryPath(baseName, constraint)
+	if err != nil {
+		return nil, err
+	}
+	return Open(path)
+}
+
+// versionedLibraryPath is VersionedLibraryPath's logic with probe
+// injected, the same way pkgConfigLibraryPath takes its own libs func,
+// so the search can be exercised against a fake directory listing
+// instead of a real filesystem.
+func versionedLibraryPath(baseName, constraint string, dirs []string, probe func(string, []string) []sonameCandidate) (string, error) {
+	clauses, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := probe(baseName, dirs)
+
+	var best *sonameCandidate
+	for i := range candidates {
+		c := &candidates[i]
+		if c.version == nil || !matchesAll(clauses, c.version) {
+			continue
+		}
+		if best == nil || c.version.compare(best.version) > 0 {
+			best = c
+		}
+	}
+	if best != nil {
+		return best.path, nil
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("ffi: %s: no soname matching %q found under %v", baseName, constraint, dirs)
+	}
+	found := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if c.version == nil {
+			found = append(found, baseName)
+		} else {
+			found = append(found, baseName+"."+c.version.String())
+		}
+	}
+	sort.Strings(found)
+	return "", fmt.Errorf("ffi: %s: no soname satisfies %q; found %s", baseName, constraint, strings.Join(found, ", "))
+}
@@ -0,0 +1,92 @@
+package ffi
+
+import (
+	"strings"
+	"testing"
+)
+
+// fakeSonameProbe returns a probe func that reports candidates for
+// exactly baseName, ignoring dirs entirely - versionedLibraryPath's
+// counterpart to pkgconfig_test.go's fakePkgConfigLibs.
+func fakeSonameProbe(baseName string, candidates ...sonameCandidate) func(string, []string) []sonameCandidate {
+	return func(got string, _ []string) []sonameCandidate {
+		if got != baseName {
+			return nil
+		}
+		return candidates
+	}
+}
+
+func versionOrPanic(s string) sonameVersion {
+	v, ok := parseSonameVersion(s)
+	if !ok {
+		panic("bad test version: " + s)
+	}
+	return v
+}
+
+// TestVersionedLibraryPathPicksHighestMatching confirms the highest
+// version satisfying the constraint is picked, not just the first one
+// probeSonames happened to list.
+func TestVersionedLibraryPathPicksHighestMatching(t *testing.T) {
+	probe := fakeSonameProbe("libssl.so",
+		sonameCandidate{path: "/usr/lib/libssl.so.1.0", version: versionOrPanic("1.0")},
+		sonameCandidate{path: "/usr/lib/libssl.so.1.1", version: versionOrPanic("1.1")},
+		sonameCandidate{path: "/usr/lib/libssl.so.3", version: versionOrPanic("3")},
+	)
+
+	got, err := versionedLibraryPath("libssl.so", ">=1.1, <3", nil, probe)
+	if err != nil {
+		t.Fatalf("versionedLibraryPath: %v", err)
+	}
+	if got != "/usr/lib/libssl.so.1.1" {
+		t.Fatalf("versionedLibraryPath = %q, want /usr/lib/libssl.so.1.1", got)
+	}
+}
+
+// TestVersionedLibraryPathNoneMatchListsFound confirms the error lists
+// every version actually found when none satisfies the constraint, so a
+// caller (or a human reading the error) can tell what's actually
+// installed.
+func TestVersionedLibraryPathNoneMatchListsFound(t *testing.T) {
+	probe := fakeSonameProbe("libssl.so",
+		sonameCandidate{path: "/usr/lib/libssl.so.3", version: versionOrPanic("3")},
+	)
+
+	_, err := versionedLibraryPath("libssl.so", ">=1.1, <3", nil, probe)
+	if err == nil {
+		t.Fatal("versionedLibraryPath: expected an error")
+	}
+	if !strings.Contains(err.Error(), "libssl.so.3") {
+		t.Fatalf("versionedLibraryPath error = %q, want it to mention libssl.so.3", err.Error())
+	}
+}
+
+// TestVersionedLibraryPathNoneFound confirms a distinct error (no
+// candidates at all, as opposed to candidates that don't satisfy the
+// constraint) when probeSonames turns up nothing.
+func TestVersionedLibraryPathNoneFound(t *testing.T) {
+	probe := fakeSonameProbe("libssl.so")
+
+	_, err := versionedLibraryPath("libssl.so", ">=1.1, <3", nil, probe)
+	if err == nil {
+		t.Fatal("versionedLibraryPath: expected an error")
+	}
+}
+
+// TestVersionedLibraryPathRejectsBadConstraint confirms a malformed
+// constraint string is rejected before probe is ever called.
+func TestVersionedLibraryPathRejectsBadConstraint(t *testing.T) {
+	called := false
+	probe := func(string, []string) []sonameCandidate {
+		called = true
+		return nil
+	}
+
+	if _, err := versionedLibraryPath("libssl.so", "not a constraint", nil, probe); err == nil {
+		t.Fatal("versionedLibraryPath: expected an error for a malformed constraint")
+	}
+	if called {
+		t.Fatal("versionedLibraryPath: probe should not be called once the constraint fails to parse")
+	}
+}
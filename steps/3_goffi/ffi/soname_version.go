@@ -0,0 +1,134 @@
+package ffi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sonameVersion is a shared library's dotted version suffix - the part
+// of its filename after "<base>." - e.g. libssl.so.1.1 parses to
+// {1, 1}, ordered the same way dpkg/rpm compare package versions:
+// component by component, left to right, shorter is lower when every
+// shared component is equal (1.1 < 1.1.0 is false; 1.1 < 1.1.1 is true).
+type sonameVersion []int
+
+// parseSonameVersion parses s (e.g. "1.1", "3", "1.0.2k" with the
+// trailing letter dropped) into a sonameVersion, or reports ok=false for
+// anything containing a non-numeric component parseSonameVersion
+// doesn't understand - a caller should treat that candidate as
+// unversioned rather than fail the whole probe over it, the same way
+// compareVersionSplit below.
+func parseSonameVersion(s string) (v sonameVersion, ok bool) {
+	for _, part := range strings.Split(s, ".") {
+		part = strings.TrimRight(part, "abcdefghijklmnopqrstuvwxyz")
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		v = append(v, n)
+	}
+	return v, true
+}
+
+func (v sonameVersion) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// compare returns -1, 0, or 1 as v is less than, equal to, or greater
+// than other, comparing component by component and treating a missing
+// trailing component as 0 (1.1 == 1.1.0).
+func (v sonameVersion) compare(other sonameVersion) int {
+	for i := 0; i < len(v) || i < len(other); i++ {
+		var a, b int
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(other) {
+			b = other[i]
+		}
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionClause is one comma-separated term of a constraint string, e.g.
+// the ">=1.1" half of ">=1.1, <3".
+type versionClause struct {
+	op      string
+	version sonameVersion
+}
+
+func (c versionClause) matches(v sonameVersion) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "==", "=":
+		return cmp == 0
+	default:
+		return false
+	}
+}
+
+// parseVersionConstraint parses a comma-separated constraint string like
+// ">=1.1, <3" into the clauses a candidate sonameVersion must satisfy
+// (a candidate matches the constraint only if every clause does).
+func parseVersionConstraint(s string) ([]versionClause, error) {
+	var clauses []versionClause
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		op, rest := splitVersionOp(term)
+		if op == "" {
+			return nil, fmt.Errorf("ffi: version constraint %q: missing comparison operator", term)
+		}
+		v, ok := parseSonameVersion(rest)
+		if !ok {
+			return nil, fmt.Errorf("ffi: version constraint %q: invalid version %q", term, rest)
+		}
+		clauses = append(clauses, versionClause{op: op, version: v})
+	}
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("ffi: version constraint %q: no clauses", s)
+	}
+	return clauses, nil
+}
+
+// splitVersionOp splits the leading comparison operator (one of >=, <=,
+// ==, >, <, =) off term, longest operator first so ">=" isn't cut short
+// as ">" followed by a malformed "=1.1".
+func splitVersionOp(term string) (op, rest string) {
+	for _, candidate := range []string{">=", "<=", "==", ">", "<", "="} {
+		if strings.HasPrefix(term, candidate) {
+			return candidate, strings.TrimSpace(term[len(candidate):])
+		}
+	}
+	return "", term
+}
+
+func matchesAll(clauses []versionClause, v sonameVersion) bool {
+	for _, c := range clauses {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package ffi
+
+import "testing"
+
+// TestParseSonameVersion confirms both multi-component versions and a
+// trailing letter suffix (as in OpenSSL's 1.0.2k-style sonames) parse.
+func TestParseSonameVersion(t *testing.T) {
+	cases := []struct {
+		in   string
+		want sonameVersion
+	}{
+		{"3", sonameVersion{3}},
+		{"1.1", sonameVersion{1, 1}},
+		{"1.0.2", sonameVersion{1, 0, 2}},
+	}
+	for _, c := range cases {
+		got, ok := parseSonameVersion(c.in)
+		if !ok {
+			t.Fatalf("parseSonameVersion(%q): ok=false", c.in)
+		}
+		if got.compare(c.want) != 0 {
+			t.Fatalf("parseSonameVersion(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+// TestParseSonameVersionRejectsNonNumeric confirms a component
+// parseSonameVersion can't reduce to an integer reports ok=false rather
+// than silently truncating it.
+func TestParseSonameVersionRejectsNonNumeric(t *testing.T) {
+	if _, ok := parseSonameVersion("abc"); ok {
+		t.Fatal("parseSonameVersion(\"abc\"): expected ok=false")
+	}
+}
+
+// TestSonameVersionCompareShorterIsLowerOnlyWhenDifferent confirms 1.1
+// and 1.1.0 compare equal, but 1.1 and 1.1.1 don't.
+func TestSonameVersionCompareShorterIsLowerOnlyWhenDifferent(t *testing.T) {
+	v1, _ := parseSonameVersion("1.1")
+	v2, _ := parseSonameVersion("1.1.0")
+	if v1.compare(v2) != 0 {
+		t.Fatalf("1.1 vs 1.1.0: got %d, want 0", v1.compare(v2))
+	}
+	v3, _ := parseSonameVersion("1.1.1")
+	if v1.compare(v3) >= 0 {
+		t.Fatalf("1.1 vs 1.1.1: got %d, want <0", v1.compare(v3))
+	}
+}
+
+// TestParseVersionConstraint confirms a comma-separated constraint
+// string parses into clauses that together bound the expected range.
+func TestParseVersionConstraint(t *testing.T) {
+	clauses, err := parseVersionConstraint(">=1.1, <3")
+	if err != nil {
+		t.Fatalf("parseVersionConstraint: %v", err)
+	}
+	if len(clauses) != 2 {
+		t.Fatalf("parseVersionConstraint: got %d clauses, want 2", len(clauses))
+	}
+
+	v11, _ := parseSonameVersion("1.1")
+	v3, _ := parseSonameVersion("3")
+	v30, _ := parseSonameVersion("3.0")
+	if !matchesAll(clauses, v11) {
+		t.Error("1.1 should satisfy >=1.1, <3")
+	}
+	if matchesAll(clauses, v3) {
+		t.Error("3 should not satisfy >=1.1, <3")
+	}
+	if matchesAll(clauses, v30) {
+		t.Error("3.0 should not satisfy >=1.1, <3")
+	}
+}
+
+// TestParseVersionConstraintRejectsMissingOperator confirms a bare
+// version with no comparison operator is an error, not silently
+// interpreted as equality.
+func TestParseVersionConstraintRejectsMissingOperator(t *testing.T) {
+	if _, err := parseVersionConstraint("1.1"); err == nil {
+		t.Fatal("parseVersionConstraint(\"1.1\"): expected an error")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestSpecSize pins Spec's size to a regression test: Spec is copied by
+// MakeSpec's cache-hit path and (via callParams) escapes to the heap once
+// per Call (see Call's own doc comment), so a field added in a careless
+// position - an extra bool declared between two pointer-sized fields,
+// say - costs every caller padding nobody asked for, silently, with
+// nothing short of unsafe.Sizeof to notice. 512 was this type's size
+// before its fields were last grouped by alignment (see Spec's own doc
+// comment); this asserts that work wasn't undone by a later field
+// addition landing in the wrong spot.
+func TestSpecSize(t *testing.T) {
+	if got := unsafe.Sizeof(Spec{}); got >= 512 {
+		t.Errorf("unsafe.Sizeof(Spec{}) = %d, want < 512 (fields grouped by alignment, not declaration order)", got)
+	}
+}
@@ -0,0 +1,285 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+// Package sqlite binds just enough of sqlite3's C API - open, prepare,
+// step, the column accessors and exec's callback form - to round-trip a
+// real query through a real database file, doubling as both an
+// integration test suite and a worked usage reference for package ffi:
+// it exercises string arguments (Open, Prepare), opaque handles (DB,
+// Stmt), int64 and float64 returns (ColumnInt64, ColumnDouble), a
+// NUL-terminated C string return (ColumnText, errmsg) and a callback
+// invoked synchronously from the C side with a char** argument (Exec).
+//
+// Unlike package libc, this package is intentionally not a general
+// sqlite3 binding: no blobs, no bound parameters, no backup API. See
+// each function's own doc comment for exactly what it covers.
+package sqlite
+
+import (
+	"fmt"
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// sqlite3 result codes this package branches on directly; the rest are
+// surfaced to the caller only via Error.Code, same as libc leaves an
+// uninterpreted syscall.Errno for its own callers to branch on.
+const (
+	sqliteOK   = 0
+	sqliteRow  = 100
+	sqliteDone = 101
+)
+
+// openFn, closeFn, prepareV2Fn, stepFn, finalizeFn, errmsgFn, execFn,
+// columnCountFn, columnInt64Fn, columnDoubleFn, columnTextFn and
+// columnBytesFn are resolved once by init below. initErr holds whatever
+// error resolving them failed with, if any - every exported function
+// here returns it unchanged rather than failing some other way, the
+// same convention package libc's own initErr follows.
+var (
+	openFn, closeFn, prepareV2Fn, stepFn, finalizeFn, errmsgFn, execFn uintptr
+	columnCountFn, columnInt64Fn, columnDoubleFn, columnTextFn         uintptr
+	columnBytesFn                                                      uintptr
+	initErr                                                            error
+)
+
+func init() {
+	var lib *ffi.Library
+	var lastErr error
+	for _, name := range libNames {
+		l, err := ffi.Open(name)
+		if err == nil {
+			lib = l
+			break
+		}
+		lastErr = err
+	}
+	if lib == nil {
+		initErr = lastErr
+		return
+	}
+
+	for name, fn := range map[string]*uintptr{
+		"sqlite3_open":          &openFn,
+		"sqlite3_close":         &closeFn,
+		"sqlite3_prepare_v2":    &prepareV2Fn,
+		"sqlite3_step":          &stepFn,
+		"sqlite3_finalize":      &finalizeFn,
+		"sqlite3_errmsg":        &errmsgFn,
+		"sqlite3_exec":          &execFn,
+		"sqlite3_column_count":  &columnCountFn,
+		"sqlite3_column_int64":  &columnInt64Fn,
+		"sqlite3_column_double": &columnDoubleFn,
+		"sqlite3_column_text":   &columnTextFn,
+		"sqlite3_column_bytes":  &columnBytesFn,
+	} {
+		addr, err := lib.Get(name)
+		if err != nil {
+			initErr = err
+			return
+		}
+		*fn = addr
+	}
+}
+
+// Error is what every function in this package returns for an sqlite3
+// result code other than SQLITE_OK (or, for Step, SQLITE_ROW/
+// SQLITE_DONE) - Code is the raw result code, Msg is whatever
+// sqlite3_errmsg reported against the connection at the time.
+type Error struct {
+	Code int32
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("sqlite: %s (code %d)", e.Msg, e.Code)
+}
+
+// DB is an open sqlite3 connection, wrapping the opaque sqlite3* handle
+// sqlite3_open returned - the same "opaque to Go" contract
+// libc.Dlopen's handle and package ffi's own *Library hold theirs
+// under.
+type DB struct {
+	handle uintptr
+}
+
+// errmsg reports sqlite3_errmsg(db.handle) for use in an *Error once a
+// call against db has already failed.
+func (db *DB) errmsg() string {
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		R      uintptr `ffi:"ret"`
+	}{Handle: db.handle}
+	spec := ffi.MakeSpec(errmsgFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return ffi.GoString(args.R)
+}
+
+// Open opens (creating if necessary) the sqlite3 database file at path
+// via sqlite3_open.
+func Open(path string) (*DB, error) {
+	if initErr != nil {
+		return nil, initErr
+	}
+	args := struct {
+		Path   string         `ffi:"cstr"`
+		Handle unsafe.Pointer `ffi:"arg"`
+		R      int32          `ffi:"ret"`
+	}{Path: path}
+	var handle uintptr
+	args.Handle = unsafe.Pointer(&handle)
+	spec := ffi.MakeSpec(openFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != sqliteOK {
+		db := &DB{handle: handle}
+		err := &Error{Code: args.R, Msg: db.errmsg()}
+		db.Close()
+		return nil, err
+	}
+	return &DB{handle: handle}, nil
+}
+
+// Close closes db via sqlite3_close - Open's necessary counterpart.
+func (db *DB) Close() error {
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Handle: db.handle}
+	spec := ffi.MakeSpec(closeFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != sqliteOK {
+		return &Error{Code: args.R, Msg: db.errmsg()}
+	}
+	return nil
+}
+
+// Stmt is a prepared statement, wrapping the opaque sqlite3_stmt*
+// handle sqlite3_prepare_v2 returned.
+type Stmt struct {
+	db     *DB
+	handle uintptr
+}
+
+// Prepare compiles sql into a Stmt via sqlite3_prepare_v2, passing -1
+// for sql's length so sqlite3 takes it as NUL-terminated and ignoring
+// the "pzTail" trailing-SQL output parameter - this package only ever
+// prepares one statement per sqlite3_prepare_v2 call.
+func (db *DB) Prepare(sql string) (*Stmt, error) {
+	args := struct {
+		Handle uintptr        `ffi:"arg"`
+		SQL    string         `ffi:"cstr"`
+		NByte  int32          `ffi:"arg"`
+		Stmt   unsafe.Pointer `ffi:"arg"`
+		PzTail uintptr        `ffi:"arg"`
+		R      int32          `ffi:"ret"`
+	}{Handle: db.handle, SQL: sql, NByte: -1}
+	var stmt uintptr
+	args.Stmt = unsafe.Pointer(&stmt)
+	spec := ffi.MakeSpec(prepareV2Fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != sqliteOK {
+		return nil, &Error{Code: args.R, Msg: db.errmsg()}
+	}
+	return &Stmt{db: db, handle: stmt}, nil
+}
+
+// Step advances s to its next row via sqlite3_step, returning hasRow
+// true when a row is now available to read via the Column* accessors,
+// or false once the statement has run to completion (SQLITE_DONE). Any
+// other result code comes back as an *Error.
+func (s *Stmt) Step() (hasRow bool, err error) {
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Handle: s.handle}
+	spec := ffi.MakeSpec(stepFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	switch args.R {
+	case sqliteRow:
+		return true, nil
+	case sqliteDone:
+		return false, nil
+	default:
+		return false, &Error{Code: args.R, Msg: s.db.errmsg()}
+	}
+}
+
+// ColumnCount reports the number of columns in s's current row via
+// sqlite3_column_count.
+func (s *Stmt) ColumnCount() int {
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Handle: s.handle}
+	spec := ffi.MakeSpec(columnCountFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return int(args.R)
+}
+
+// ColumnInt64 reads column col of s's current row as an int64 via
+// sqlite3_column_int64.
+func (s *Stmt) ColumnInt64(col int) int64 {
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		Col    int32   `ffi:"arg"`
+		R      int64   `ffi:"ret"`
+	}{Handle: s.handle, Col: int32(col)}
+	spec := ffi.MakeSpec(columnInt64Fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return args.R
+}
+
+// ColumnDouble reads column col of s's current row as a float64 via
+// sqlite3_column_double.
+func (s *Stmt) ColumnDouble(col int) float64 {
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		Col    int32   `ffi:"arg"`
+		R      float64 `ffi:"ret"`
+	}{Handle: s.handle, Col: int32(col)}
+	spec := ffi.MakeSpec(columnDoubleFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return args.R
+}
+
+// ColumnText reads column col of s's current row as a string, via
+// sqlite3_column_text (a pointer into sqlite3-owned storage, valid only
+// until the next Step/Finalize call) together with sqlite3_column_bytes
+// for its exact length - text columns aren't NUL-terminated-by-contract
+// the way a C string argument is, so this copies exactly the reported
+// byte count via ffi.GoBytes rather than scanning for a NUL the way
+// ffi.GoString does.
+func (s *Stmt) ColumnText(col int) string {
+	textArgs := struct {
+		Handle uintptr `ffi:"arg"`
+		Col    int32   `ffi:"arg"`
+		R      uintptr `ffi:"ret"`
+	}{Handle: s.handle, Col: int32(col)}
+	textSpec := ffi.MakeSpec(columnTextFn, &textArgs)
+	textSpec.Call(unsafe.Pointer(&textArgs))
+
+	bytesArgs := struct {
+		Handle uintptr `ffi:"arg"`
+		Col    int32   `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Handle: s.handle, Col: int32(col)}
+	bytesSpec := ffi.MakeSpec(columnBytesFn, &bytesArgs)
+	bytesSpec.Call(unsafe.Pointer(&bytesArgs))
+
+	return string(ffi.GoBytes(textArgs.R, int(bytesArgs.R)))
+}
+
+// Finalize destroys s via sqlite3_finalize - Prepare's necessary
+// counterpart, the same way Close is Open's.
+func (s *Stmt) Finalize() error {
+	args := struct {
+		Handle uintptr `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Handle: s.handle}
+	spec := ffi.MakeSpec(finalizeFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != sqliteOK {
+		return &Error{Code: args.R, Msg: s.db.errmsg()}
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package sqlite
+
+// libNames is tried in order by init below - macOS ships sqlite3 as part
+// of the base system at this fixed path.
+var libNames = []string{"/usr/lib/libsqlite3.dylib"}
@@ -0,0 +1,62 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package sqlite
+
+import (
+	"unsafe"
+
+	"mewbak/nocgo/ffi"
+)
+
+// Exec runs sql (which may contain several ;-separated statements) via
+// sqlite3_exec, invoking row for every result row produced by any
+// SELECT among them. row receives that row's column values and column
+// names, already converted to Go strings - sqlite3_exec itself only
+// ever hands its callback text (everything arrives pre-stringified by
+// sqlite3, even numeric columns), so unlike Stmt.ColumnInt64/
+// ColumnDouble there's no typed variant here. Returning a non-nil error
+// from row aborts the exec early, same as returning non-zero from a
+// plain C sqlite3_exec callback does; Exec reports that same error back
+// to its own caller.
+func (db *DB) Exec(sql string, row func(cols, values []string) error) error {
+	var rowErr error
+
+	callback := ffi.NewCallback(func(arg uintptr, argc int32, argv, colNames uintptr) int32 {
+		n := int(argc)
+		values := make([]string, n)
+		cols := make([]string, n)
+		for i := 0; i < n; i++ {
+			valPtr := *(*uintptr)(unsafe.Pointer(argv + uintptr(i)*unsafe.Sizeof(uintptr(0))))
+			namePtr := *(*uintptr)(unsafe.Pointer(colNames + uintptr(i)*unsafe.Sizeof(uintptr(0))))
+			if valPtr != 0 {
+				values[i] = ffi.GoString(valPtr)
+			}
+			cols[i] = ffi.GoString(namePtr)
+		}
+		if err := row(cols, values); err != nil {
+			rowErr = err
+			return 1
+		}
+		return 0
+	})
+	defer ffi.ReleaseCallback(callback)
+
+	args := struct {
+		Handle   uintptr `ffi:"arg"`
+		SQL      string  `ffi:"cstr"`
+		Callback uintptr `ffi:"arg"`
+		Arg      uintptr `ffi:"arg"`
+		ErrMsg   uintptr `ffi:"arg"`
+		R        int32   `ffi:"ret"`
+	}{Handle: db.handle, SQL: sql, Callback: callback}
+	spec := ffi.MakeSpec(execFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+
+	if rowErr != nil {
+		return rowErr
+	}
+	if args.R != sqliteOK {
+		return &Error{Code: args.R, Msg: db.errmsg()}
+	}
+	return nil
+}
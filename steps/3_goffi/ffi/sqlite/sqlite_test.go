@@ -0,0 +1,139 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package sqlite
+
+import "testing"
+
+// skipIfUnresolved skips t when init failed to resolve a libsqlite3 -
+// expected on a system with no sqlite3 installed, same as package
+// libc's own skipIfUnresolved.
+func skipIfUnresolved(t *testing.T) {
+	t.Helper()
+	if initErr != nil {
+		t.Skipf("sqlite3 not resolved: %v", initErr)
+	}
+}
+
+// TestOpenPrepareStepClose exercises the full round trip this package
+// exists to validate: create a table, insert a couple of rows, then
+// read them back via Prepare/Step/Column*.
+func TestOpenPrepareStepClose(t *testing.T) {
+	skipIfUnresolved(t)
+
+	path := t.TempDir() + "/sqlite_test.db"
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Exec("CREATE TABLE t (id INTEGER, name TEXT, score REAL)", nil); err != nil {
+		t.Fatalf("Exec(CREATE TABLE): %v", err)
+	}
+	if err := db.Exec("INSERT INTO t VALUES (1, 'alice', 1.5), (2, 'bob', 2.5)", nil); err != nil {
+		t.Fatalf("Exec(INSERT): %v", err)
+	}
+
+	stmt, err := db.Prepare("SELECT id, name, score FROM t ORDER BY id")
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	defer stmt.Finalize()
+
+	if got := stmt.ColumnCount(); got != 3 {
+		t.Fatalf("ColumnCount: got %d, want 3", got)
+	}
+
+	type row struct {
+		id    int64
+		name  string
+		score float64
+	}
+	var got []row
+	for {
+		hasRow, err := stmt.Step()
+		if err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		if !hasRow {
+			break
+		}
+		got = append(got, row{
+			id:    stmt.ColumnInt64(0),
+			name:  stmt.ColumnText(1),
+			score: stmt.ColumnDouble(2),
+		})
+	}
+
+	want := []row{{1, "alice", 1.5}, {2, "bob", 2.5}}
+	if len(got) != len(want) {
+		t.Fatalf("rows: got %d, want %d (%+v)", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i] != w {
+			t.Fatalf("row %d: got %+v, want %+v", i, got[i], w)
+		}
+	}
+}
+
+// TestPrepareInvalidSQL confirms Prepare reports an *Error (rather than
+// a usable Stmt) for SQL sqlite3 itself rejects.
+func TestPrepareInvalidSQL(t *testing.T) {
+	skipIfUnresolved(t)
+
+	db, err := Open(t.TempDir() + "/sqlite_test_invalid.db")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Prepare("NOT VALID SQL AT ALL"); err == nil {
+		t.Fatal("Prepare: expected an error for invalid SQL")
+	}
+}
+
+// TestExecCallback confirms Exec's callback receives each row's column
+// names and values, and that returning an error from it aborts the exec
+// and is reported back to the caller.
+func TestExecCallback(t *testing.T) {
+	skipIfUnresolved(t)
+
+	db, err := Open(t.TempDir() + "/sqlite_test_exec.db")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Exec("CREATE TABLE t (id INTEGER, name TEXT)", nil); err != nil {
+		t.Fatalf("Exec(CREATE TABLE): %v", err)
+	}
+	if err := db.Exec("INSERT INTO t VALUES (7, 'carol')", nil); err != nil {
+		t.Fatalf("Exec(INSERT): %v", err)
+	}
+
+	var sawCols, sawVals []string
+	if err := db.Exec("SELECT id, name FROM t", func(cols, vals []string) error {
+		sawCols = cols
+		sawVals = vals
+		return nil
+	}); err != nil {
+		t.Fatalf("Exec(SELECT): %v", err)
+	}
+	if len(sawCols) != 2 || sawCols[0] != "id" || sawCols[1] != "name" {
+		t.Fatalf("Exec callback cols: got %v", sawCols)
+	}
+	if len(sawVals) != 2 || sawVals[0] != "7" || sawVals[1] != "carol" {
+		t.Fatalf("Exec callback vals: got %v", sawVals)
+	}
+
+	stopErr := errStop{}
+	if err := db.Exec("SELECT id FROM t", func(cols, vals []string) error {
+		return stopErr
+	}); err != stopErr {
+		t.Fatalf("Exec: got %v, want %v from an aborting callback", err, stopErr)
+	}
+}
+
+type errStop struct{}
+
+func (errStop) Error() string { return "stop" }
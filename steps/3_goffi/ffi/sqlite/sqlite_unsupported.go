@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package sqlite
+
+// libNames is tried in order by init below - Debian/Ubuntu, Fedora/RHEL
+// and Arch each ship sqlite3's soname under a slightly different name,
+// and unlike libc.so.6 there's no single name every distribution agrees
+// on.
+var libNames = []string{"libsqlite3.so.0", "libsqlite3.so"}
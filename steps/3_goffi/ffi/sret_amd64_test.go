@@ -0,0 +1,54 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// largeStruct24 is bigger than two eightbytes, so it can't come back in
+// RAX:RDX - exactly the shape that needs a hidden return pointer.
+type largeStruct24 struct {
+	A, B, C int64
+}
+
+// sretArgs is the typed argument struct for fillStruct24Target
+// (func_amd64_test.s): Out points at caller-allocated storage for the
+// struct, handed to MakeSpec explicitly via ffi:"sret" rather than embedded
+// inline for MakeSpec to classify.
+type sretArgs struct {
+	Out *largeStruct24 `ffi:"arg,sret"`
+}
+
+func fillStruct24Target()
+func addrOfFillStruct24Target() uintptr
+
+// TestSretCall drives fillStruct24Target through MakeSpec/Call, confirming
+// an ffi:"sret" field's pointer value reaches RDI ahead of the call and the
+// callee's writes land in the caller-owned struct it points at.
+func TestSretCall(t *testing.T) {
+	spec := MustSpecFor[sretArgs](addrOfFillStruct24Target())
+
+	var out largeStruct24
+	args := sretArgs{Out: &out}
+	spec.Call(unsafe.Pointer(&args))
+
+	if out != (largeStruct24{A: 1, B: 2, C: 3}) {
+		t.Fatalf("sret call: got %+v, want {A:1 B:2 C:3}", out)
+	}
+}
+
+// TestSretConflictsWithRet confirms MakeSpec rejects a field tagged both
+// ffi:"sret" and ffi:"ret" rather than silently picking one.
+func TestSretConflictsWithRet(t *testing.T) {
+	type badArgs struct {
+		Out *largeStruct24 `ffi:"sret,ret"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MakeSpec: expected a panic for a field tagged both sret and ret")
+		}
+	}()
+	MakeSpec(0, &badArgs{})
+}
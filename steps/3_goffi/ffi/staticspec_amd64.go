@@ -0,0 +1,54 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+// StaticSpec builds a Spec directly from precomputed argument tables,
+// skipping buildSpec's reflection entirely - the counterpart a spec
+// generator (see cmd/ffigen) can emit as Go literals instead of an args
+// struct type for MakeSpec to classify at init time. It exists for
+// binaries that can't afford reflect - binary size, init cost, or a
+// runtime like TinyGo that only partially implements it - not as a
+// faster path for programs already happy with MakeSpec.
+//
+// intargs and xmmargs must each hold exactly 6 and 8 entries, one per
+// SysV AMD64 integer/SSE argument register, in the same left-to-right
+// order buildSpec would have placed them; leave an unused trailing slot's
+// Kind as ArgUnused. stack holds any arguments that spilled past those
+// registers, in order. ret0/ret1/xmmret0/xmmret1 describe the return
+// value the way placeRetEightbytes does - ArgUnused for a C function
+// that returns nothing, or for whichever register halves a single scalar
+// return doesn't use.
+//
+// A Spec built this way has no cstr, pin, or pointer-check support -
+// those all depend on struct field information StaticSpec never sees -
+// and can't be passed to CallVariadic, which needs the usedInt/usedXmm
+// counts MakeVariadicSpec records. A caller that needs any of that
+// should build its Spec with MakeSpec instead.
+func StaticSpec(fn uintptr, intargs [6]Arg, xmmargs [8]Arg, stack []Arg, ret0, ret1, xmmret0, xmmret1 Arg) Spec {
+	var spec Spec
+	spec.fn = fn
+
+	xmmUsed := 0
+	for i, a := range intargs {
+		spec.intargs[i] = a.toArgument()
+	}
+	for i, a := range xmmargs {
+		spec.xmmargs[i] = a.toArgument()
+		if a.Kind != ArgUnused {
+			xmmUsed = i + 1
+		}
+	}
+	for _, a := range stack {
+		spec.stack = append(spec.stack, a.toArgument())
+	}
+	spec.ret0 = ret0.toArgument()
+	spec.ret1 = ret1.toArgument()
+	spec.xmmret0 = xmmret0.toArgument()
+	spec.xmmret1 = xmmret1.toArgument()
+	spec.rax = uint8(xmmUsed)
+
+	if len(spec.stack) > maxCallStackArgs {
+		panic(&SpecError{Kind: ErrTooManyArgs, Msg: "too many stack-passed arguments for asmcall"})
+	}
+	return spec
+}
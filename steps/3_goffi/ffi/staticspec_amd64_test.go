@@ -0,0 +1,46 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// staticSpecArgs is a plain Go struct, not an ffi:"..."-tagged MakeSpec
+// args type: StaticSpec never looks at struct tags, so the offsets below
+// are the only thing tying it to addPairFlatTarget's calling convention.
+type staticSpecArgs struct {
+	A, B, R int64
+}
+
+// unusedIntArgs/unusedXmmArgs are the all-ArgUnused tables a StaticSpec
+// caller passes for every register file slot it isn't using.
+var (
+	unusedIntArgs = [6]Arg{{Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}}
+	unusedXmmArgs = [8]Arg{{Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}, {Kind: ArgUnused}}
+)
+
+// TestStaticSpec drives addPairFlatTarget (func_amd64_test.s) through a
+// Spec built entirely from Go literals, with no reflect.ValueOf/TypeOf
+// call anywhere in the path - confirming StaticSpec's tables line up
+// with what buildSpec would have produced for the same two-int64 call.
+func TestStaticSpec(t *testing.T) {
+	intargs := unusedIntArgs
+	intargs[0] = Arg{Offset: 0, Kind: ArgInt64, Size: 8}
+	intargs[1] = Arg{Offset: 8, Kind: ArgInt64, Size: 8}
+
+	spec := StaticSpec(
+		addrOfAddPairFlatTarget(),
+		intargs, unusedXmmArgs, nil,
+		Arg{Offset: 16, Kind: ArgInt64, Size: 8},
+		Arg{Kind: ArgUnused}, Arg{Kind: ArgUnused}, Arg{Kind: ArgUnused},
+	)
+
+	args := staticSpecArgs{A: 3, B: 4}
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 7 {
+		t.Fatalf("StaticSpec: got R=%d, want 7", args.R)
+	}
+}
@@ -0,0 +1,172 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// specEncodingMagic/specEncodingVersion prefix every EncodeSpec output,
+// the same defensive-header convention a persisted binary format needs
+// regardless of how simple its payload is: DecodeSpec can then reject a
+// file from some future, incompatible layout instead of misreading it
+// as valid argument tables.
+const (
+	specEncodingMagic   = "FFS1"
+	specEncodingVersion = 1
+)
+
+// argEncodedSize is how many bytes encodeArg/decodeArg use per Arg:
+// Offset (uint16) + Kind (uint16) + Size (uint8).
+const argEncodedSize = 5
+
+func encodeArg(b []byte, a Arg) []byte {
+	b = binary.LittleEndian.AppendUint16(b, a.Offset)
+	b = binary.LittleEndian.AppendUint16(b, uint16(a.Kind))
+	return append(b, a.Size)
+}
+
+func decodeArg(b []byte) (Arg, error) {
+	if len(b) < argEncodedSize {
+		return Arg{}, fmt.Errorf("ffi: DecodeSpec: truncated argument entry")
+	}
+	return Arg{
+		Offset: binary.LittleEndian.Uint16(b[0:2]),
+		Kind:   ArgKind(binary.LittleEndian.Uint16(b[2:4])),
+		Size:   b[4],
+	}, nil
+}
+
+// ArgLayout returns spec's argument-layout tables in StaticSpec's
+// generator-facing Arg form - the inverse of StaticSpec itself, for
+// EncodeSpec to serialize a Spec that MakeSpec built via reflection
+// rather than one a generator already expressed as Arg literals.
+func (spec *Spec) ArgLayout() (intargs [6]Arg, xmmargs [8]Arg, stack []Arg, ret0, ret1, xmmret0, xmmret1 Arg) {
+	for i, a := range spec.intargs {
+		intargs[i] = a.toArg()
+	}
+	for i, a := range spec.xmmargs {
+		xmmargs[i] = a.toArg()
+	}
+	for _, a := range spec.stack {
+		stack = append(stack, a.toArg())
+	}
+	return intargs, xmmargs, stack, spec.ret0.toArg(), spec.ret1.toArg(), spec.xmmret0.toArg(), spec.xmmret1.toArg()
+}
+
+// EncodeSpec serializes spec's argument-layout tables - everything
+// StaticSpec takes except fn - to a compact binary form a build-time
+// binding generator (cmd/ffigen) can precompute once, embed as data, and
+// ship instead of either a generated Go-literal StaticSpec call or
+// asking every program that links the result to reflect over its own
+// args struct at init time (what MakeSpec does otherwise). DecodeSpec
+// plus a freshly resolved symbol address reconstructs an equivalent
+// Spec.
+//
+// Like StaticSpec itself, the result has no cstr/pin/pointer-check
+// support and can't be passed to CallVariadic - those all depend on
+// struct field information this format never captures.
+func EncodeSpec(spec Spec) []byte {
+	intargs, xmmargs, stack, ret0, ret1, xmmret0, xmmret1 := spec.ArgLayout()
+
+	out := make([]byte, 0, len(specEncodingMagic)+1+2+argEncodedSize*(6+8+len(stack)+4))
+	out = append(out, specEncodingMagic...)
+	out = append(out, specEncodingVersion)
+
+	for _, a := range intargs {
+		out = encodeArg(out, a)
+	}
+	for _, a := range xmmargs {
+		out = encodeArg(out, a)
+	}
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(stack)))
+	for _, a := range stack {
+		out = encodeArg(out, a)
+	}
+	out = encodeArg(out, ret0)
+	out = encodeArg(out, ret1)
+	out = encodeArg(out, xmmret0)
+	out = encodeArg(out, xmmret1)
+
+	return out
+}
+
+// DecodeSpec is EncodeSpec's inverse: given data and fn (a symbol
+// address resolved at runtime, e.g. via Library.Get), it reconstructs
+// the Spec EncodeSpec's source value described, as if that Spec had
+// instead been built by StaticSpec(fn, ...) directly.
+func DecodeSpec(fn uintptr, data []byte) (Spec, error) {
+	if len(data) < len(specEncodingMagic)+1 {
+		return Spec{}, fmt.Errorf("ffi: DecodeSpec: data too short to hold a header")
+	}
+	if string(data[:len(specEncodingMagic)]) != specEncodingMagic {
+		return Spec{}, fmt.Errorf("ffi: DecodeSpec: not an EncodeSpec payload (bad magic)")
+	}
+	pos := len(specEncodingMagic)
+	if version := data[pos]; version != specEncodingVersion {
+		return Spec{}, fmt.Errorf("ffi: DecodeSpec: unsupported encoding version %d", version)
+	}
+	pos++
+
+	readArg := func() (Arg, error) {
+		if pos+argEncodedSize > len(data) {
+			return Arg{}, fmt.Errorf("ffi: DecodeSpec: truncated data")
+		}
+		a, err := decodeArg(data[pos : pos+argEncodedSize])
+		pos += argEncodedSize
+		return a, err
+	}
+
+	var intargs [6]Arg
+	for i := range intargs {
+		a, err := readArg()
+		if err != nil {
+			return Spec{}, err
+		}
+		intargs[i] = a
+	}
+
+	var xmmargs [8]Arg
+	for i := range xmmargs {
+		a, err := readArg()
+		if err != nil {
+			return Spec{}, err
+		}
+		xmmargs[i] = a
+	}
+
+	if pos+2 > len(data) {
+		return Spec{}, fmt.Errorf("ffi: DecodeSpec: truncated data")
+	}
+	stackLen := binary.LittleEndian.Uint16(data[pos : pos+2])
+	pos += 2
+
+	stack := make([]Arg, stackLen)
+	for i := range stack {
+		a, err := readArg()
+		if err != nil {
+			return Spec{}, err
+		}
+		stack[i] = a
+	}
+
+	ret0, err := readArg()
+	if err != nil {
+		return Spec{}, err
+	}
+	ret1, err := readArg()
+	if err != nil {
+		return Spec{}, err
+	}
+	xmmret0, err := readArg()
+	if err != nil {
+		return Spec{}, err
+	}
+	xmmret1, err := readArg()
+	if err != nil {
+		return Spec{}, err
+	}
+
+	return StaticSpec(fn, intargs, xmmargs, stack, ret0, ret1, xmmret0, xmmret1), nil
+}
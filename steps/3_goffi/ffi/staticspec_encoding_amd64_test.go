@@ -0,0 +1,60 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestEncodeDecodeSpecRoundTrip drives addPairFlatTarget through a Spec
+// that was built once via StaticSpec, round-tripped through
+// EncodeSpec/DecodeSpec, and only then given a real fn - the workflow a
+// build-time generator and a later runtime rehydration step would each
+// play one half of.
+func TestEncodeDecodeSpecRoundTrip(t *testing.T) {
+	intargs := unusedIntArgs
+	intargs[0] = Arg{Offset: 0, Kind: ArgInt64, Size: 8}
+	intargs[1] = Arg{Offset: 8, Kind: ArgInt64, Size: 8}
+
+	original := StaticSpec(
+		0, // fn is deliberately unknown at encode time
+		intargs, unusedXmmArgs, nil,
+		Arg{Offset: 16, Kind: ArgInt64, Size: 8},
+		Arg{Kind: ArgUnused}, Arg{Kind: ArgUnused}, Arg{Kind: ArgUnused},
+	)
+
+	data := EncodeSpec(original)
+
+	spec, err := DecodeSpec(addrOfAddPairFlatTarget(), data)
+	if err != nil {
+		t.Fatalf("DecodeSpec: %v", err)
+	}
+
+	args := staticSpecArgs{A: 3, B: 4}
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 7 {
+		t.Fatalf("round-tripped Spec: got R=%d, want 7", args.R)
+	}
+}
+
+// TestDecodeSpecRejectsBadMagic confirms DecodeSpec reports an honest
+// error for data that isn't an EncodeSpec payload, rather than reading
+// garbage argument tables out of it.
+func TestDecodeSpecRejectsBadMagic(t *testing.T) {
+	if _, err := DecodeSpec(0, []byte("not a spec")); err == nil {
+		t.Fatal("DecodeSpec: expected an error for bad magic, got nil")
+	}
+}
+
+// TestDecodeSpecRejectsTruncatedData confirms DecodeSpec reports an
+// honest error instead of panicking on a payload cut short mid-table.
+func TestDecodeSpecRejectsTruncatedData(t *testing.T) {
+	data := EncodeSpec(StaticSpec(0, unusedIntArgs, unusedXmmArgs, nil,
+		Arg{Kind: ArgUnused}, Arg{Kind: ArgUnused}, Arg{Kind: ArgUnused}, Arg{Kind: ArgUnused}))
+
+	if _, err := DecodeSpec(0, data[:len(data)-1]); err == nil {
+		t.Fatal("DecodeSpec: expected an error for truncated data, got nil")
+	}
+}
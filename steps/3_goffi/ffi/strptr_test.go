@@ -0,0 +1,59 @@
+package ffi
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// strPtrArgs exercises strPtrArg's field: S is a plain Go string, which
+// strPtrArg expands into its own Data and Len words rather than a
+// NUL-terminated copy the way ffi:"cstr" would.
+type strPtrArgs struct {
+	S string `ffi:"arg,strptr"`
+}
+
+// TestStrPtrArgReadsDataAndLenWords confirms the two arguments strPtrArg
+// returns point at S's own Data and Len words, matching reflect's own
+// StringHeader for the same value.
+func TestStrPtrArgReadsDataAndLenWords(t *testing.T) {
+	args := strPtrArgs{S: "hello"}
+	f, _ := reflect.TypeOf(args).FieldByName("S")
+
+	data, length := strPtrArg(f, false, type64, 8)
+
+	sh := (*reflect.StringHeader)(unsafe.Pointer(&args.S))
+	if got := *(*uintptr)(unsafe.Pointer(uintptr(unsafe.Pointer(&args)) + uintptr(data.offset))); got != sh.Data {
+		t.Fatalf("data word: got %#x, want %#x", got, sh.Data)
+	}
+	if got := *(*int)(unsafe.Pointer(uintptr(unsafe.Pointer(&args)) + uintptr(length.offset))); got != sh.Len {
+		t.Fatalf("len word: got %d, want %d", got, sh.Len)
+	}
+}
+
+// TestStrPtrArgRejectsNonStringField confirms strPtrArg panics on a field
+// that isn't a string.
+func TestStrPtrArgRejectsNonStringField(t *testing.T) {
+	type badArgs struct {
+		N int64
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("strPtrArg: expected a panic for a non-string field")
+		}
+	}()
+	f, _ := reflect.TypeOf(badArgs{}).FieldByName("N")
+	strPtrArg(f, false, type64, 8)
+}
+
+// TestStrPtrArgRejectsRetField confirms strPtrArg panics when tagged on
+// an ffi:"ret" field, the same restriction ffi:"cstr" has.
+func TestStrPtrArgRejectsRetField(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("strPtrArg: expected a panic for a ret field")
+		}
+	}()
+	f, _ := reflect.TypeOf(strPtrArgs{}).FieldByName("S")
+	strPtrArg(f, true, type64, 8)
+}
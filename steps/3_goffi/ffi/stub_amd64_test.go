@@ -0,0 +1,38 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCallStubSkipsFnAndReturnsErrno exercises Call's Stub branch with
+// fn left at its zero value - the same state Library.GetOrStub leaves it
+// in for a missing symbol - to confirm Call never dereferences it.
+func TestCallStubSkipsFnAndReturnsErrno(t *testing.T) {
+	args := benchArgs{A: 1, B: 2}
+
+	spec := MakeSpec(0, &args)
+	spec.Stub(38) // ENOSYS on Linux
+
+	if got := spec.Call(unsafe.Pointer(&args)); got != 38 {
+		t.Errorf("Call: got %d, want 38", got)
+	}
+	if args.A != 1 || args.B != 2 {
+		t.Errorf("args changed by a stubbed call: got %+v", args)
+	}
+}
+
+// TestCallNoStubCallsFn confirms a Spec built the ordinary way, with
+// Stub never called, still calls fn as usual.
+func TestCallNoStubCallsFn(t *testing.T) {
+	fn := addrOfBenchTarget()
+	args := benchArgs{A: 5, B: 6}
+
+	spec := MakeSpec(fn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.A != 5 || args.B != 6 {
+		t.Errorf("args changed by an unstubbed call: got %+v", args)
+	}
+}
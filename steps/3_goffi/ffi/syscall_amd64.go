@@ -0,0 +1,89 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// errnoLocator, once set via SetErrnoLocator, is the "int *"-returning C
+// function Call1 through Call6 pass to Spec.UseErrno - see UseErrno's own
+// doc comment for why errno has to be captured inside the same asmcall
+// frame rather than read back from Go afterward.
+var errnoLocator uintptr
+
+// SetErrnoLocator configures the C function - e.g. libc's
+// "__errno_location" symbol, resolved once via a Library - that Call1
+// through Call6 use to read back errno. They report a zero syscall.Errno
+// until this has been called at least once.
+func SetErrnoLocator(fn uintptr) {
+	errnoLocator = fn
+}
+
+// callWords is the shared implementation behind Call1..Call6: it builds a
+// throwaway Spec entirely out of StaticSpec - no reflection, no argument
+// struct type, just the raw uintptr words syscall.Syscall itself takes -
+// and runs it once. words holds every declared argument slot, padded with
+// zeros past n; buf's last two words receive the two integer return
+// registers (see placeRetEightbytes - RAX, then RDX).
+func callWords(fn uintptr, words [6]uintptr, n int) (r1, r2 uintptr, errno syscall.Errno) {
+	unused := Arg{Kind: ArgUnused}
+
+	var intargs [6]Arg
+	for i := 0; i < n; i++ {
+		intargs[i] = Arg{Offset: uint16(i * 8), Kind: ArgInt64, Size: 8}
+	}
+	for i := n; i < 6; i++ {
+		intargs[i] = unused
+	}
+
+	ret0 := Arg{Offset: 48, Kind: ArgInt64, Size: 8}
+	ret1 := Arg{Offset: 56, Kind: ArgInt64, Size: 8}
+
+	spec := StaticSpec(fn, intargs, [8]Arg{unused, unused, unused, unused, unused, unused, unused, unused}, nil, ret0, ret1, unused, unused)
+	if errnoLocator != 0 {
+		spec.UseErrno(errnoLocator)
+	}
+
+	var buf [8]uintptr
+	copy(buf[:6], words[:])
+
+	e := spec.Call(unsafe.Pointer(&buf[0]))
+	return buf[6], buf[7], syscall.Errno(e)
+}
+
+// Call1 calls fn with one uintptr argument, the way syscall.Syscall calls a
+// raw system call number - except fn is a plain C function address, called
+// through this package's own asmcall trampoline rather than the syscall
+// instruction. r1/r2 are fn's two integer return registers (RAX, then RDX);
+// most callers only need r1. errno is only meaningful once SetErrnoLocator
+// has been called.
+func Call1(fn, a1 uintptr) (r1, r2 uintptr, errno syscall.Errno) {
+	return callWords(fn, [6]uintptr{a1}, 1)
+}
+
+// Call2 is Call1 for a two-argument fn.
+func Call2(fn, a1, a2 uintptr) (r1, r2 uintptr, errno syscall.Errno) {
+	return callWords(fn, [6]uintptr{a1, a2}, 2)
+}
+
+// Call3 is Call1 for a three-argument fn.
+func Call3(fn, a1, a2, a3 uintptr) (r1, r2 uintptr, errno syscall.Errno) {
+	return callWords(fn, [6]uintptr{a1, a2, a3}, 3)
+}
+
+// Call4 is Call1 for a four-argument fn.
+func Call4(fn, a1, a2, a3, a4 uintptr) (r1, r2 uintptr, errno syscall.Errno) {
+	return callWords(fn, [6]uintptr{a1, a2, a3, a4}, 4)
+}
+
+// Call5 is Call1 for a five-argument fn.
+func Call5(fn, a1, a2, a3, a4, a5 uintptr) (r1, r2 uintptr, errno syscall.Errno) {
+	return callWords(fn, [6]uintptr{a1, a2, a3, a4, a5}, 5)
+}
+
+// Call6 is Call1 for a six-argument fn.
+func Call6(fn, a1, a2, a3, a4, a5, a6 uintptr) (r1, r2 uintptr, errno syscall.Errno) {
+	return callWords(fn, [6]uintptr{a1, a2, a3, a4, a5, a6}, 6)
+}
@@ -0,0 +1,33 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+// TestCall2 drives addPairFlatTarget (func_amd64_test.s) through Call2,
+// confirming callWords' hand-built StaticSpec lines up with the same
+// two-int64-argument, one-int64-return convention TestStaticSpec already
+// exercises via MakeSpec/StaticSpec directly.
+func TestCall2(t *testing.T) {
+	r1, _, errno := Call2(addrOfAddPairFlatTarget(), 3, 4)
+	if r1 != 7 {
+		t.Fatalf("Call2: got r1=%d, want 7", r1)
+	}
+	if errno != 0 {
+		t.Fatalf("Call2: got errno=%d, want 0 with no SetErrnoLocator", errno)
+	}
+}
+
+// TestCallErrnoLocator confirms Call1 reports the errno its configured
+// locator points at, the same way TestCallErrnoCapture exercises
+// Spec.UseErrno directly.
+func TestCallErrnoLocator(t *testing.T) {
+	fakeErrnoValue = 23
+	SetErrnoLocator(addrOfFakeErrnoLocationTarget())
+	defer SetErrnoLocator(0)
+
+	_, _, errno := Call1(addrOfNoopTarget(), 0)
+	if errno != 23 {
+		t.Fatalf("Call1: got errno=%d, want 23", errno)
+	}
+}
@@ -0,0 +1,64 @@
+package ffi
+
+// SchedPolicy is a Linux scheduling policy for SetCurrentThreadScheduling -
+// see sched(7). SchedOther is the default, time-shared policy every
+// thread starts with; SchedFIFO and SchedRR are the realtime policies
+// real-time audio or control-loop code needs, usually only available to
+// a process with CAP_SYS_NICE or running as root.
+type SchedPolicy int32
+
+const (
+	SchedOther SchedPolicy = 0
+	SchedFIFO  SchedPolicy = 1
+	SchedRR    SchedPolicy = 2
+)
+
+// ThreadAttrs configures the OS thread a ThreadedLibrary's dedicated
+// thread runs on - see Library.ThreadWithAttrs - or, applied directly
+// via ApplyThreadAttrs, the calling goroutine's own current OS thread.
+//
+// There's deliberately no stack size or guard size knob here, unlike a
+// real pthread_attr_t: every OS thread this package's calls ever run on
+// is one the Go runtime itself created through its own clone(2)-based
+// thread startup, not pthread_create - see callback_foreign_amd64.go's
+// doc comment on why iscgo, and so _cgo_thread_start's pthread_create
+// path, is deliberately never turned on here. Go doesn't expose a way
+// to size an individual thread's stack the way pthread_attr_setstacksize/
+// pthread_attr_setguardsize would; a real knob for that would need this
+// package to spawn its own OS threads through pthread_create instead of
+// leaving all thread creation to the Go runtime, which is a materially
+// bigger change than Name/Policy/Priority below.
+type ThreadAttrs struct {
+	// Name sets the thread's name (as 'ps -L'/'top -H' show it), via
+	// prctl(PR_SET_NAME) - truncated to 15 bytes plus the NUL terminator,
+	// the same limit Linux's own TASK_COMM_LEN imposes. Left empty, the
+	// thread keeps whatever name it already has.
+	Name string
+
+	// Policy and Priority set the thread's scheduling policy and, for
+	// SchedFIFO/SchedRR, its realtime priority (1-99; ignored for
+	// SchedOther) via sched_setscheduler. Policy's zero value,
+	// SchedOther, is a no-op: every thread already starts there.
+	Policy   SchedPolicy
+	Priority int32
+}
+
+// ApplyThreadAttrs applies attrs to the calling goroutine's current OS
+// thread. The caller is responsible for runtime.LockOSThread-ing first
+// if it needs the attributes to stick past the current goroutine
+// potentially migrating to a different thread - ThreadedLibrary's own
+// dedicated thread (library_thread.go) already does this before calling
+// ApplyThreadAttrs on Library.ThreadWithAttrs's behalf.
+func ApplyThreadAttrs(attrs ThreadAttrs) error {
+	if attrs.Name != "" {
+		if err := SetCurrentThreadName(attrs.Name); err != nil {
+			return err
+		}
+	}
+	if attrs.Policy != SchedOther || attrs.Priority != 0 {
+		if err := SetCurrentThreadScheduling(attrs.Policy, attrs.Priority); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+//go:build linux
+
+package ffi
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// libcThreadAttrs lazily resolves libc's prctl/sched_setscheduler, the
+// same libc()-style two-step cstring.go's malloc/free use. Both are
+// called with pid/tid 0 or no explicit thread handle at all - prctl
+// always targets the calling thread, and sched_setscheduler's pid==0
+// means "the calling thread" - so neither needs a pthread_t or tid this
+// package would otherwise have no way to obtain for a Go-runtime-created
+// thread.
+func libcThreadAttrs() (prctlFn, schedSetSchedulerFn uintptr, err error) {
+	threadAttrsOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			threadAttrsErr = openErr
+			return
+		}
+		if threadAttrsPrctlFn, threadAttrsErr = lib.Get("prctl"); threadAttrsErr != nil {
+			return
+		}
+		threadAttrsSchedSetSchedulerFn, threadAttrsErr = lib.Get("sched_setscheduler")
+	})
+	return threadAttrsPrctlFn, threadAttrsSchedSetSchedulerFn, threadAttrsErr
+}
+
+var (
+	threadAttrsOnce                sync.Once
+	threadAttrsPrctlFn             uintptr
+	threadAttrsSchedSetSchedulerFn uintptr
+	threadAttrsErr                 error
+)
+
+// prSetName is Linux's PR_SET_NAME, from <linux/prctl.h>.
+const prSetName = 15
+
+// SetCurrentThreadName sets the calling OS thread's name (as
+// 'ps -L'/'top -H' show it) via prctl(PR_SET_NAME), truncated to 15
+// bytes plus the NUL terminator - Linux's own TASK_COMM_LEN limit.
+func SetCurrentThreadName(name string) error {
+	if len(name) > 15 {
+		name = name[:15]
+	}
+	prctlFn, _, err := libcThreadAttrs()
+	if err != nil {
+		return err
+	}
+	args := struct {
+		Option int32   `ffi:"arg"`
+		Name   string  `ffi:"cstr"`
+		Arg3   uintptr `ffi:"arg"`
+		Arg4   uintptr `ffi:"arg"`
+		Arg5   uintptr `ffi:"arg"`
+		R      int32   `ffi:"ret"`
+	}{Option: prSetName, Name: name}
+	spec := MakeSpec(prctlFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("ffi: SetCurrentThreadName(%q): prctl returned %d", name, args.R)
+	}
+	return nil
+}
+
+// schedParam lays out struct sched_param (<sched.h>) on Linux: a single
+// int, the realtime priority - the only field sched_setscheduler reads
+// for SchedFIFO/SchedRR, and the only one it even looks at for
+// SchedOther (where it must be 0).
+type schedParam struct {
+	Priority int32
+}
+
+// SetCurrentThreadScheduling sets the calling OS thread's scheduling
+// policy and, for SchedFIFO/SchedRR, its realtime priority, via
+// sched_setscheduler(0, policy, &param) - pid 0 meaning the calling
+// thread. Setting a realtime policy typically needs CAP_SYS_NICE or
+// root; sched_setscheduler's own EPERM surfaces here unchanged.
+func SetCurrentThreadScheduling(policy SchedPolicy, priority int32) error {
+	schedSetSchedulerFn, _, err := libcThreadAttrs()
+	if err != nil {
+		return err
+	}
+	param := schedParam{Priority: priority}
+	args := struct {
+		Pid    int32          `ffi:"arg"`
+		Policy int32          `ffi:"arg"`
+		Param  unsafe.Pointer `ffi:"arg"`
+		R      int32          `ffi:"ret"`
+	}{Pid: 0, Policy: int32(policy), Param: unsafe.Pointer(&param)}
+	spec := MakeSpec(schedSetSchedulerFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return fmt.Errorf("ffi: SetCurrentThreadScheduling(%v, %d): sched_setscheduler returned %d", policy, priority, args.R)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package ffi
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestSetCurrentThreadNameFailsCleanlyWithoutLoader confirms
+// SetCurrentThreadName never panics: on a platform without a real
+// loader yet (loader_unsupported.go), Open fails and it returns that
+// error directly instead of calling prctl at all.
+func TestSetCurrentThreadNameFailsCleanlyWithoutLoader(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SetCurrentThreadName is only implemented on linux so far")
+	}
+	if err := SetCurrentThreadName("ffi-test"); err != nil {
+		t.Logf("SetCurrentThreadName: %v (expected until this platform has a real loader)", err)
+	}
+}
+
+// TestApplyThreadAttrsZeroValueIsNoOp confirms the zero ThreadAttrs
+// (no name, SchedOther, priority 0) never calls into libc at all, so it
+// can't fail even without a real loader.
+func TestApplyThreadAttrsZeroValueIsNoOp(t *testing.T) {
+	if err := ApplyThreadAttrs(ThreadAttrs{}); err != nil {
+		t.Fatalf("ApplyThreadAttrs(zero value): got %v, want nil", err)
+	}
+}
+
+// TestThreadWithAttrsZeroValueSucceeds confirms ThreadWithAttrs with a
+// zero ThreadAttrs behaves like Thread: it always succeeds, since
+// ApplyThreadAttrs is a no-op for the zero value regardless of platform
+// or loader support.
+func TestThreadWithAttrsZeroValueSucceeds(t *testing.T) {
+	th, err := (&Library{}).ThreadWithAttrs(ThreadAttrs{})
+	if err != nil {
+		t.Fatalf("ThreadWithAttrs(zero value): unexpected error: %v", err)
+	}
+	defer th.Close()
+
+	done := make(chan struct{})
+	th.Call(func() { close(done) })
+	<-done
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package ffi
+
+import "errors"
+
+// errThreadAttrsUnsupported is returned by SetCurrentThreadName/
+// SetCurrentThreadScheduling here: PR_SET_NAME and sched_setscheduler
+// are Linux-specific (prctl itself is a Linux syscall with no darwin/
+// BSD/Windows equivalent, and sched_setscheduler's realtime policies are
+// a Linux scheduler concept) - a darwin build would need
+// pthread_setname_np and thread_policy_set instead, and Windows would
+// need SetThreadDescription and SetThreadPriority, neither of which this
+// package binds yet.
+var errThreadAttrsUnsupported = errors.New("ffi: thread name/scheduling attributes are not yet supported on this platform")
+
+func SetCurrentThreadName(name string) error {
+	return errThreadAttrsUnsupported
+}
+
+func SetCurrentThreadScheduling(policy SchedPolicy, priority int32) error {
+	return errThreadAttrsUnsupported
+}
@@ -0,0 +1,189 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// OnThreadAttach registers fn to run the first time a given foreign
+// (non-Go) OS thread invokes a MakeCallback trampoline - the thread
+// callbackasm's CALL into runtime.cgocallback borrows a Go m for via
+// runtime.needm (see callback_foreign_amd64.go's doc comment). It's for
+// per-thread Go-side setup a library's own audio/IO callback thread,
+// which may call back into Go for its entire lifetime, needs done once
+// rather than on every single callback.
+//
+// fn runs on the foreign thread itself, inside the callback that
+// triggered the attach - the same restrictions that apply inside any
+// other callback apply to it.
+func OnThreadAttach(fn func()) {
+	threadHooksMu.Lock()
+	threadAttachHooks = append(threadAttachHooks, fn)
+	threadHooksMu.Unlock()
+}
+
+// OnThreadDetach registers fn to run once a thread that previously
+// triggered an OnThreadAttach hook is about to exit. It's delivered
+// through a pthread TLS destructor (see threadKey below), the only
+// signal this package has for "this OS thread is done calling back" -
+// needm and dropm pair on every individual call, not once per thread,
+// so neither gives a point to hang a once-per-thread teardown hook off
+// of directly.
+//
+// fn runs on the exiting thread itself, during its pthread TLS
+// destructor callout - after most of that thread's own TLS has already
+// been torn down, the same point any other pthread_key_create
+// destructor runs at.
+func OnThreadDetach(fn func()) {
+	threadHooksMu.Lock()
+	threadDetachHooks = append(threadDetachHooks, fn)
+	threadHooksMu.Unlock()
+}
+
+var (
+	threadHooksMu     sync.Mutex
+	threadAttachHooks []func()
+	threadDetachHooks []func()
+)
+
+// threadAttached is called from callbackCall (callback_amd64.go) before
+// every callback dispatch. On the common, already-attached path it costs
+// one pthread_getspecific call against a TLS slot this package owns,
+// independent of whatever TLS slots the foreign library or libc itself
+// are using; OnThreadAttach hooks only actually run the first time a
+// given thread is seen.
+func threadAttached() {
+	if !threadKeyReady() {
+		return // pthread_key_create itself failed; see its doc comment
+	}
+	if threadGetSpecific() != 0 {
+		return
+	}
+	threadSetSpecific(1)
+	callThreadHooks(&threadAttachHooks)
+}
+
+// callThreadHooks runs a snapshot of *hooks without holding
+// threadHooksMu while doing so, so a hook registering another hook (or
+// simply taking a while) doesn't block OnThreadAttach/OnThreadDetach
+// callers running concurrently on other threads.
+func callThreadHooks(hooks *[]func()) {
+	threadHooksMu.Lock()
+	fns := append([]func(){}, *hooks...)
+	threadHooksMu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// threadKey is the pthread_key_t (see threadKeyReady) this package's own
+// attach/detach tracking uses; its value is irrelevant past
+// pthread_key_create, but kept 8 bytes wide regardless of this
+// platform's actual pthread_key_t width (4 bytes on Linux, 8 on Darwin)
+// since pthread_key_create only ever writes the low bytes a narrower
+// type would occupy and the rest stay zero.
+var (
+	threadKeyOnce  sync.Once
+	threadKeyValue uint64
+	threadKeyOK    bool
+)
+
+// threadKeyReady lazily runs pthread_key_create once, registering
+// threadDetachDestructor as its destructor, and reports whether it
+// succeeded. A failure here (exhausted the process's pthread key
+// budget, or libc couldn't be resolved at all) just means
+// OnThreadAttach/OnThreadDetach never fire - not that callbacks
+// themselves stop working.
+func threadKeyReady() bool {
+	threadKeyOnce.Do(func() {
+		createFn, _, _, err := pthreadKeyFuncs()
+		if err != nil {
+			return
+		}
+		destructor := NewClosure(threadDetachCif, func(args unsafe.Pointer) {
+			callThreadHooks(&threadDetachHooks)
+		})
+		args := struct {
+			Key unsafe.Pointer `ffi:"arg"`
+			Dtr uintptr        `ffi:"arg"`
+			R   int32          `ffi:"ret"`
+		}{Key: unsafe.Pointer(&threadKeyValue), Dtr: destructor.Pointer()}
+		spec := MakeSpec(createFn, &args)
+		spec.Call(unsafe.Pointer(&args))
+		if args.R != 0 {
+			ReleaseClosure(destructor)
+			return
+		}
+		threadKeyOK = true
+	})
+	return threadKeyOK
+}
+
+// threadDetachCif is the Cif NewClosure builds threadDetachDestructor's
+// trampoline from: pthread_key_create's destructor is a plain void
+// (*)(void*), the same shape PrepCif/NewClosure already support directly
+// with no MakeSpec struct needed.
+var threadDetachCif = PrepCif(0, TypeVoid, TypePointer)
+
+// threadGetSpecific and threadSetSpecific wrap pthread_getspecific/
+// pthread_setspecific against threadKeyValue; both are no-ops (reading
+// as 0) if pthread_key_create itself never succeeded, via
+// threadKeyReady's own guard in threadAttached.
+func threadGetSpecific() uintptr {
+	_, getFn, _, err := pthreadKeyFuncs()
+	if err != nil {
+		return 0
+	}
+	args := struct {
+		Key uint64  `ffi:"arg"`
+		R   uintptr `ffi:"ret"`
+	}{Key: threadKeyValue}
+	spec := MakeSpec(getFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+	return args.R
+}
+
+func threadSetSpecific(value uintptr) {
+	_, _, setFn, err := pthreadKeyFuncs()
+	if err != nil {
+		return
+	}
+	args := struct {
+		Key   uint64  `ffi:"arg"`
+		Value uintptr `ffi:"arg"`
+		R     int32   `ffi:"ret"`
+	}{Key: threadKeyValue, Value: value}
+	spec := MakeSpec(setFn, &args)
+	spec.Call(unsafe.Pointer(&args))
+}
+
+// pthreadKeyFuncs resolves pthread_key_create/pthread_getspecific/
+// pthread_setspecific from libc, the same lazy Open+Get two-step
+// cstring.go's own libc() uses for malloc/free.
+func pthreadKeyFuncs() (createFn, getFn, setFn uintptr, err error) {
+	pthreadKeyFuncsOnce.Do(func() {
+		lib, openErr := Open(libcPath)
+		if openErr != nil {
+			pthreadKeyFuncsErr = openErr
+			return
+		}
+		if pthreadKeyCreateFn, pthreadKeyFuncsErr = lib.Get("pthread_key_create"); pthreadKeyFuncsErr != nil {
+			return
+		}
+		if pthreadGetSpecificFn, pthreadKeyFuncsErr = lib.Get("pthread_getspecific"); pthreadKeyFuncsErr != nil {
+			return
+		}
+		pthreadSetSpecificFn, pthreadKeyFuncsErr = lib.Get("pthread_setspecific")
+	})
+	return pthreadKeyCreateFn, pthreadGetSpecificFn, pthreadSetSpecificFn, pthreadKeyFuncsErr
+}
+
+var (
+	pthreadKeyFuncsOnce  sync.Once
+	pthreadKeyCreateFn   uintptr
+	pthreadGetSpecificFn uintptr
+	pthreadSetSpecificFn uintptr
+	pthreadKeyFuncsErr   error
+)
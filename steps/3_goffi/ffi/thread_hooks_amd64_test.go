@@ -0,0 +1,72 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+// TestCallThreadHooksRunsRegisteredHooks confirms callThreadHooks invokes
+// every hook in a *[]func() in registration order. The pthread_key_create/
+// pthread_create half of OnThreadAttach/OnThreadDetach - a real foreign
+// thread actually triggering them - needs a genuine OS thread to behave
+// correctly and stays reviewed by eye for now, the same way
+// TestCallbackCall's callbackasm half does (see its own doc comment).
+func TestCallThreadHooksRunsRegisteredHooks(t *testing.T) {
+	var hooks []func()
+	var got []int
+	hooks = append(hooks, func() { got = append(got, 1) })
+	hooks = append(hooks, func() { got = append(got, 2) })
+
+	callThreadHooks(&hooks)
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("callThreadHooks: got %v, want [1 2]", got)
+	}
+}
+
+// TestCallThreadHooksSnapshotsBeforeRunning confirms a hook that registers
+// another hook on the same slice doesn't see it run in the same
+// callThreadHooks pass - the snapshot callThreadHooks takes under
+// threadHooksMu is taken once, up front.
+func TestCallThreadHooksSnapshotsBeforeRunning(t *testing.T) {
+	ran := 0
+	var hooks []func()
+	hooks = append(hooks, func() {
+		ran++
+		hooks = append(hooks, func() { ran++ })
+	})
+
+	callThreadHooks(&hooks)
+
+	if ran != 1 {
+		t.Fatalf("callThreadHooks: ran = %d, want 1", ran)
+	}
+}
+
+// TestOnThreadAttachDetachRegister confirms OnThreadAttach/OnThreadDetach
+// append to the package-level hook slices they document themselves as
+// backing.
+func TestOnThreadAttachDetachRegister(t *testing.T) {
+	threadHooksMu.Lock()
+	savedAttach, savedDetach := threadAttachHooks, threadDetachHooks
+	threadAttachHooks, threadDetachHooks = nil, nil
+	threadHooksMu.Unlock()
+	defer func() {
+		threadHooksMu.Lock()
+		threadAttachHooks, threadDetachHooks = savedAttach, savedDetach
+		threadHooksMu.Unlock()
+	}()
+
+	OnThreadAttach(func() {})
+	OnThreadDetach(func() {})
+
+	threadHooksMu.Lock()
+	gotAttach, gotDetach := len(threadAttachHooks), len(threadDetachHooks)
+	threadHooksMu.Unlock()
+
+	if gotAttach != 1 {
+		t.Fatalf("OnThreadAttach: threadAttachHooks has %d entries, want 1", gotAttach)
+	}
+	if gotDetach != 1 {
+		t.Fatalf("OnThreadDetach: threadDetachHooks has %d entries, want 1", gotDetach)
+	}
+}
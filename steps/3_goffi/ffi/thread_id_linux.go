@@ -0,0 +1,12 @@
+//go:build linux
+
+package ffi
+
+import "syscall"
+
+// currentOSThreadID returns the calling goroutine's current OS thread id
+// (Linux's gettid(2), via the syscall package's own wrapper) - the only
+// platform-specific piece ThreadAffinity needs.
+func currentOSThreadID() (int, error) {
+	return syscall.Gettid(), nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package ffi
+
+import "errors"
+
+// errThreadIDUnsupported is returned by currentOSThreadID here: Linux's
+// gettid(2) has no portable equivalent the syscall package exposes on
+// darwin/Windows - the same gap thread_attrs_unsupported.go's
+// SetCurrentThreadName/SetCurrentThreadScheduling already have to live
+// with on these platforms.
+var errThreadIDUnsupported = errors.New("ffi: current OS thread id is not yet available on this platform")
+
+func currentOSThreadID() (int, error) {
+	return 0, errThreadIDUnsupported
+}
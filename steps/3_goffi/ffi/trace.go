@@ -0,0 +1,55 @@
+package ffi
+
+import (
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// TraceEvent describes one Func[Args].Call, for a tracer installed via
+// SetTracer. Symbol is the name Bind resolved Fn under, or "" for a
+// Func built with BindFunc, which never has one. Args is the same
+// pointer the call was made with - still valid and already holding the
+// call's result by the time the tracer runs - so a tracer that needs to
+// inspect individual fields can do so via reflect itself; TraceEvent
+// doesn't pre-decode them, since doing that generically for an arbitrary
+// Args type would cost every untraced call a reflect.Value it has no use
+// for. Raw register values and errno aren't included: both are
+// arch-specific (errno only exists at all once UseErrno is set on the
+// underlying Spec), and TraceEvent exists at this package's one
+// arch-independent call site.
+type TraceEvent struct {
+	Symbol   string
+	Fn       uintptr
+	Args     unsafe.Pointer
+	Duration time.Duration
+}
+
+// tracer holds the func SetTracer last installed, or nil if none has
+// been. It's an atomic.Pointer rather than a plain var guarded by a
+// mutex because Func[Args].Call reads it on every call, including ones
+// made while a concurrent SetTracer is replacing it.
+var tracer atomic.Pointer[func(TraceEvent)]
+
+// SetTracer installs fn to be called with a TraceEvent after every
+// Func[Args].Call made from this point on, or removes tracing entirely
+// if fn is nil. It's meant for interactive debugging of ABI mismatches,
+// not as a permanent instrumentation path: fn runs synchronously on the
+// calling goroutine, in between the foreign call returning and
+// Func[Args].Call itself returning, so a slow or blocking fn adds that
+// same latency to every traced call.
+func SetTracer(fn func(TraceEvent)) {
+	if fn == nil {
+		tracer.Store(nil)
+		return
+	}
+	tracer.Store(&fn)
+}
+
+// trace calls the currently installed tracer, if any, with the given
+// event. Called from Func[Args].Call (func.go).
+func trace(ev TraceEvent) {
+	if fn := tracer.Load(); fn != nil {
+		(*fn)(ev)
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "testing"
+
+// TestSetTracer drives addPairFlatTarget (func_amd64_test.s) through
+// BindFunc/Func.Call with a tracer installed, confirming it sees the
+// bound symbol name, the resolved fn address, and a duration - then
+// confirms SetTracer(nil) turns tracing back off.
+func TestSetTracer(t *testing.T) {
+	defer SetTracer(nil)
+
+	fn := addrOfAddPairFlatTarget()
+	f := BindFunc[funcAddArgs](fn)
+	f.symbol = "addPairFlatTarget"
+
+	var got TraceEvent
+	n := 0
+	SetTracer(func(ev TraceEvent) {
+		got = ev
+		n++
+	})
+
+	args := funcAddArgs{A: 3, B: 4}
+	f.Call(&args)
+
+	if n != 1 {
+		t.Fatalf("SetTracer: tracer called %d times, want 1", n)
+	}
+	if got.Symbol != "addPairFlatTarget" {
+		t.Fatalf("SetTracer: got Symbol=%q, want %q", got.Symbol, "addPairFlatTarget")
+	}
+	if got.Fn != fn {
+		t.Fatalf("SetTracer: got Fn=%v, want %v", got.Fn, fn)
+	}
+
+	SetTracer(nil)
+	n = 0
+	f.Call(&args)
+	if n != 0 {
+		t.Fatalf("SetTracer(nil): tracer still called after being cleared")
+	}
+}
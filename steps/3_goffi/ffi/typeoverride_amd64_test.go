@@ -0,0 +1,37 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addrOfEchoIntTarget and echoIntTarget (typeoverride_amd64_test.s) stand
+// in for a C function taking a plain "int" and returning it widened to a
+// 64 bit value, the same way addPairTarget stands in for one taking two.
+func addrOfEchoIntTarget() uintptr
+func echoIntTarget()
+
+// typeOverrideArgs exercises fieldToOffset's "type=" override: V's Go
+// Kind is Int64, which on its own would classify as a full 8 byte
+// type64 load, but the tag forces a 4 byte signed load instead.
+type typeOverrideArgs struct {
+	V int64 `ffi:"arg,type=int32"`
+	R int64 `ffi:"ret"`
+}
+
+// TestCallTypeOverride sets V to a value whose low 32 bits, read and
+// sign-extended on their own, differ from V's own 64 bit value - so the
+// call only comes back with R==1 if the "type=int32" tag actually won
+// over V's native Int64 classification.
+func TestCallTypeOverride(t *testing.T) {
+	args := typeOverrideArgs{V: 1<<32 + 1}
+	spec := MakeSpec(addrOfEchoIntTarget(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 1 {
+		t.Fatalf("type= override call: got R=%#x, want 1", args.R)
+	}
+}
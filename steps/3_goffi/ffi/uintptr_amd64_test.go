@@ -0,0 +1,31 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// uintptrArgs exercises fieldToOffset's reflect.Uintptr case: V is a
+// plain Go uintptr (the Kind a dlsym result or opaque handle typically
+// has), classified exactly like a same-sized Int/Uint field rather than
+// panicking on an unsupported Kind.
+type uintptrArgs struct {
+	V uintptr
+	R int64 `ffi:"ret"`
+}
+
+// TestCallUintptrField drives echoIntTarget (typeoverride_amd64_test.s)
+// through a uintptr argument, confirming it's loaded as a full 64 bit
+// word rather than rejected by fieldToOffset.
+func TestCallUintptrField(t *testing.T) {
+	args := uintptrArgs{V: 0xdeadbeef}
+	spec := MakeSpec(addrOfEchoIntTarget(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	if args.R != 0xdeadbeef {
+		t.Fatalf("uintptr field call: got R=%#x, want 0xdeadbeef", args.R)
+	}
+}
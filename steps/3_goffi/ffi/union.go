@@ -0,0 +1,52 @@
+package ffi
+
+import "unsafe"
+
+// Union is the Go-side storage for a C union embedded in an args/ret
+// struct by value: T fixes the union's size and alignment the same way
+// a C union's own size is its largest member's - typically a fixed
+// byte array, Union[[8]byte] for an 8 byte union, Union[[16]byte] for
+// a 16 byte one. MakeSpec needs no case of its own for a Union[T]
+// field: its one member, raw, is classified exactly like a plain
+// [N]byte array field would be (see classifyField in ffi_amd64.go,
+// which already walks a struct's members recursively) - which is
+// genuinely all a union is once it's serialized into registers or onto
+// the stack.
+//
+// AsU32/AsF64/AsPtr reinterpret the union's storage in place as one
+// member at a time, the same way reading or writing through a C
+// union's member names does; each panics if T isn't big enough to hold
+// the member being asked for.
+type Union[T any] struct {
+	raw T
+}
+
+// checkSize panics if u's storage isn't big enough to hold an n byte
+// member - a deliberately loud failure instead of the silent
+// out-of-bounds read/write reinterpreting past T's own size would
+// otherwise be.
+func (u *Union[T]) checkSize(n uintptr) {
+	if unsafe.Sizeof(u.raw) < n {
+		panic("ffi: Union: storage is too small for this member")
+	}
+}
+
+// AsU32 returns a pointer to u's storage reinterpreted as a uint32, for
+// *u.AsU32() = v or v := *u.AsU32() to write or read that member in
+// place, the same way u.member would through a C union.
+func (u *Union[T]) AsU32() *uint32 {
+	u.checkSize(4)
+	return (*uint32)(unsafe.Pointer(&u.raw))
+}
+
+// AsF64 is AsU32 for a float64 member.
+func (u *Union[T]) AsF64() *float64 {
+	u.checkSize(8)
+	return (*float64)(unsafe.Pointer(&u.raw))
+}
+
+// AsPtr is AsU32 for a pointer-typed member.
+func (u *Union[T]) AsPtr() *unsafe.Pointer {
+	u.checkSize(unsafe.Sizeof(uintptr(0)))
+	return (*unsafe.Pointer)(unsafe.Pointer(&u.raw))
+}
@@ -0,0 +1,22 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestUnionClassifiesAsItsStorage confirms a Union[T] field embedded by
+// value in an args struct classifies exactly like a plain [N]byte array
+// field of the same size - MakeSpec needs no case of its own for it.
+func TestUnionClassifiesAsItsStorage(t *testing.T) {
+	type unionArgs struct {
+		U Union[[8]byte]
+	}
+	wantClasses, wantN, wantOK := classifyEightbytes(reflect.TypeOf([8]byte{}))
+	gotClasses, gotN, gotOK := classifyEightbytes(reflect.TypeOf(unionArgs{}).Field(0).Type)
+	if gotOK != wantOK || gotN != wantN || gotClasses != wantClasses {
+		t.Fatalf("classifyEightbytes(Union[[8]byte]) = %v/%d/%v, want %v/%d/%v", gotClasses, gotN, gotOK, wantClasses, wantN, wantOK)
+	}
+}
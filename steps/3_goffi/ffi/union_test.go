@@ -0,0 +1,54 @@
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// See union_amd64_test.go for TestUnionClassifiesAsItsStorage, which
+// depends on classifyEightbytes (ffi_amd64.go) and so can't live in this
+// arch-independent file.
+
+// TestUnionAsU32RoundTrips confirms AsU32 reads and writes the union's
+// storage in place.
+func TestUnionAsU32RoundTrips(t *testing.T) {
+	var u Union[[8]byte]
+	*u.AsU32() = 0xDEADBEEF
+	if got := *u.AsU32(); got != 0xDEADBEEF {
+		t.Fatalf("AsU32: got %#x, want 0xdeadbeef", got)
+	}
+}
+
+// TestUnionAsF64RoundTrips confirms AsF64 reads and writes the same
+// storage a prior AsU32 write touched, the same way a C union's members
+// alias one another.
+func TestUnionAsF64RoundTrips(t *testing.T) {
+	var u Union[[8]byte]
+	*u.AsF64() = 3.5
+	if got := *u.AsF64(); got != 3.5 {
+		t.Fatalf("AsF64: got %v, want 3.5", got)
+	}
+}
+
+// TestUnionAsPtrRoundTrips confirms AsPtr reads and writes a
+// pointer-typed member.
+func TestUnionAsPtrRoundTrips(t *testing.T) {
+	var u Union[[8]byte]
+	var x int
+	*u.AsPtr() = unsafe.Pointer(&x)
+	if got := *u.AsPtr(); got != unsafe.Pointer(&x) {
+		t.Fatalf("AsPtr: got %p, want %p", got, &x)
+	}
+}
+
+// TestUnionTooSmallPanics confirms an accessor panics instead of
+// silently reading/writing past T's own storage.
+func TestUnionTooSmallPanics(t *testing.T) {
+	var u Union[[2]byte]
+	defer func() {
+		if recover() == nil {
+			t.Fatal("AsU32: expected a panic for a 2 byte union")
+		}
+	}()
+	u.AsU32()
+}
@@ -0,0 +1,116 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import "unsafe"
+
+// MakeVariadicSpec builds a call specification for a variadic C function
+// such as printf, execl or open(path, flags, mode). fixedArgs is
+// classified exactly like MakeSpec classifies args for MakeSpec; it
+// describes the function's named parameters. variadicTypes documents the
+// C types of the trailing variadic parameters this Spec is meant to be
+// used with - callers binding to a fixed-shape variadic call (e.g. always
+// "%s %d" for a given printf wrapper) can set it once and have every
+// CallVariadic on this Spec checked against it, catching a caller that
+// passes the wrong shape of varArgs/varIsXmm before it corrupts a
+// register. Pass nil to opt out of this check.
+//
+// variadicTypes is otherwise just documentation: CallVariadic always
+// reclassifies the actual values passed on each call, since SysV requires
+// the AL register to reflect the true number of vector registers used on
+// that particular call.
+func MakeVariadicSpec(fn uintptr, fixedArgs interface{}, variadicTypes []argtype) Spec {
+	spec := MakeSpec(fn, fixedArgs)
+	spec.variadicTypes = variadicTypes
+	return spec
+}
+
+// CallVariadic invokes a Spec built by MakeVariadicSpec, supplying this
+// call's variadic tail as raw 64-bit values: integers zero/sign-extended
+// to 64 bits, floats and doubles alike bit-patterned via
+// math.Float64bits(float64(x)) widened to uint64 - SysV's default
+// argument promotions widen every variadic float to double, so there is
+// no Float32bits case here even when the C parameter is declared float.
+// varIsXmm[i] says whether varArgs[i] must be passed in an SSE register
+// (a double or float argument) per the SysV AMD64 variadic calling
+// convention. PromoteVariadicArg/PromoteVariadicArgs (promote.go) build
+// both from plain Go values instead of requiring the caller to do this
+// widening/bit-patterning by hand.
+//
+// The variadic tail is reclassified from scratch on every call - it picks
+// up in the integer/xmm registers right after where the fixed arguments
+// left off, spills to the stack once those run out, and leaves rax set to
+// the number of XMM registers actually used (capped at 8), which is what
+// tells a variadic callee like printf how many vector registers to expect.
+func (spec Spec) CallVariadic(fixedPtr unsafe.Pointer, varArgs []uint64, varIsXmm []bool) int32 {
+	spec, buf := spec.prepareVariadic(fixedPtr, varArgs, varIsXmm)
+	errno := spec.Call(unsafe.Pointer(&buf[0]))
+
+	// spec.Call wrote any ret0/ret1/xmmret0/xmmret1 into buf, not
+	// fixedPtr - copy the fixed-args portion back so a ffi:"ret" field
+	// in fixedArgs (e.g. a syscall's return value) actually reaches the
+	// caller instead of being silently dropped with buf.
+	copy(rawBytes(fixedPtr, spec.argsSize), buf[:spec.argsSize])
+
+	return errno
+}
+
+// prepareVariadic validates varArgs/varIsXmm against variadicTypes (if
+// set), then does the actual reclassification CallVariadic's doc comment
+// describes: it returns a Spec with stack/rax set for this call's
+// variadic tail, and the combined fixed+variadic argument buffer that
+// Spec's fixed-argument offsets and intargs/xmmargs/stack entries now all
+// point into. Split out of CallVariadic so a test can drive the real
+// asmcall trampoline with callAsmDirect instead of going through Call.
+func (spec Spec) prepareVariadic(fixedPtr unsafe.Pointer, varArgs []uint64, varIsXmm []bool) (Spec, []byte) {
+	if len(varArgs) != len(varIsXmm) {
+		panic("ffi: CallVariadic: varArgs and varIsXmm must be the same length")
+	}
+	if spec.variadicTypes != nil {
+		if len(varArgs) != len(spec.variadicTypes) {
+			panic("ffi: CallVariadic: varArgs does not match the variadicTypes this Spec was made with")
+		}
+		for i, t := range spec.variadicTypes {
+			if xmm := t == typeFloat || t == typeDouble; xmm != varIsXmm[i] {
+				panic("ffi: CallVariadic: varIsXmm does not match the variadicTypes this Spec was made with")
+			}
+		}
+	}
+
+	buf := make([]byte, spec.argsSize+uintptr(len(varArgs))*8)
+	copy(buf, rawBytes(fixedPtr, spec.argsSize))
+
+	intreg, xmmreg := spec.usedInt, spec.usedXmm
+	stack := append([]argument(nil), spec.stack...)
+
+	for i, v := range varArgs {
+		off := spec.argsSize + uintptr(i)*8
+		*(*uint64)(unsafe.Pointer(&buf[off])) = v
+
+		a := argument{offset: uint16(off), t: type64, size: 8}
+		if varIsXmm[i] {
+			a.t = typeDouble
+			if xmmreg < 8 {
+				spec.xmmargs[xmmreg] = a
+				xmmreg++
+				continue
+			}
+		} else if intreg < 6 {
+			spec.intargs[intreg] = a
+			intreg++
+			continue
+		}
+		stack = append(stack, a)
+	}
+
+	spec.stack = stack
+	spec.rax = uint8(xmmreg)
+	return spec, buf
+}
+
+// rawBytes views the n bytes at p as a byte slice, for copying a fixed
+// argument struct of a size only known at runtime into the combined
+// fixed+variadic buffer CallVariadic builds.
+func rawBytes(p unsafe.Pointer, n uintptr) []byte {
+	return (*[1 << 30]byte)(p)[:n:n]
+}
@@ -0,0 +1,65 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// addVariadicTarget (variadic_amd64_test.s) stands in for a printf-style C
+// function: it reads the number of SSE registers used out of AL exactly
+// as the SysV AMD64 variadic convention requires a callee to, proving
+// CallVariadic's reclassification loop sets %al correctly and not just
+// spec.usedInt/usedXmm's fixed-argument count.
+func addVariadicTarget()
+
+// variadicFixedArgs is the fixed (named) parameter of the stand-in
+// printf-style call: a single integer, classified and placed exactly
+// like any other MakeSpec argument, plus the integer return addVariadicTarget
+// hands back in RAX.
+type variadicFixedArgs struct {
+	A int64 `ffi:"arg"`
+	R int64 `ffi:"ret"`
+}
+
+// TestCallVariadic exercises prepareVariadic's reclassification end to
+// end via callAsmDirect: two variadic doubles take XMM0/XMM1 (so AL must
+// come back as 2) and a trailing variadic integer takes the next free
+// integer register after the fixed A, proving variadic args pick up
+// register assignment where the fixed arguments left off rather than
+// starting over at RDI/XMM0.
+func TestCallVariadic(t *testing.T) {
+	fixed := variadicFixedArgs{A: 100}
+	spec := MakeVariadicSpec(addrOfAddVariadicTarget(), &fixed, nil)
+
+	varArgs := []uint64{
+		uint64(int64f(10)),
+		uint64(int64f(20)),
+		7,
+	}
+	varIsXmm := []bool{true, true, false}
+
+	spec, buf := spec.prepareVariadic(unsafe.Pointer(&fixed), varArgs, varIsXmm)
+	if spec.rax != 2 {
+		t.Fatalf("spec.rax: got %d, want 2 (two variadic doubles)", spec.rax)
+	}
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&buf[0]))})
+	copy(rawBytes(unsafe.Pointer(&fixed), spec.argsSize), buf[:spec.argsSize])
+
+	// A(100) + 10 + 20 + trailing int(7) + AL(2) == 139
+	if fixed.R != 139 {
+		t.Fatalf("variadic call result: got %d, want 139", fixed.R)
+	}
+}
+
+// int64f reinterprets an int64 as the float64 it represents, the same
+// conversion CallVariadic's callers are documented to do for a variadic
+// double argument (math.Float64bits(float64(x))).
+func int64f(x int64) uint64 {
+	f := float64(x)
+	return *(*uint64)(unsafe.Pointer(&f))
+}
+
+func addrOfAddVariadicTarget() uintptr
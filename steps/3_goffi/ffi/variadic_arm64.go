@@ -0,0 +1,70 @@
+package ffi
+
+import "unsafe"
+
+// MakeVariadicSpec builds a call specification for a variadic C function
+// such as printf or open(path, flags, mode). fixedArgs is classified
+// exactly like MakeSpec classifies args for MakeSpec; it describes the
+// function's named parameters. variadicTypes documents the C types of the
+// trailing variadic parameters this Spec is meant to be used with - see
+// the amd64 backend's identical function for the full rationale. Pass nil
+// to opt out of the check.
+//
+// Unlike the amd64 backend, where CallVariadic must also set AL to the
+// number of vector registers used (SysV's printf convention), there's no
+// equivalent register to track here: AAPCS64 variadic functions read
+// their own argument list without that hint.
+func MakeVariadicSpec(fn uintptr, fixedArgs interface{}, variadicTypes []argtype) Spec {
+	spec := MakeSpec(fn, fixedArgs)
+	spec.variadicTypes = variadicTypes
+	return spec
+}
+
+// CallVariadic invokes a Spec built by MakeVariadicSpec, supplying this
+// call's variadic tail as raw 64-bit values - see the amd64 backend's
+// identical method for the value-encoding rules (integers widened to 64
+// bits, floats/doubles bit-patterned via math.Float64bits), and
+// PromoteVariadicArg/PromoteVariadicArgs (promote.go, arch-independent)
+// for building varArgs/varIsXmm from plain Go values instead of doing
+// that encoding by hand. Where the variadic tail lands - more X/V
+// registers on linux/arm64, always the
+// stack on darwin/arm64 - is decided by prepareVariadic
+// (variadic_arm64_other.go/variadic_arm64_darwin.go), per each
+// platform's own ABI.
+func (spec Spec) CallVariadic(fixedPtr unsafe.Pointer, varArgs []uint64, varIsXmm []bool) {
+	spec, buf := spec.prepareVariadic(fixedPtr, varArgs, varIsXmm)
+	spec.Call(unsafe.Pointer(&buf[0]))
+
+	// spec.Call wrote any ret0/ret1/fltret into buf, not fixedPtr - copy
+	// the fixed-args portion back so a ffi:"ret" field in fixedArgs
+	// actually reaches the caller instead of being silently dropped
+	// with buf.
+	copy(rawBytes(fixedPtr, spec.argsSize), buf[:spec.argsSize])
+}
+
+// checkVariadicShape validates varArgs/varIsXmm against variadicTypes, if
+// the Spec was made with one - shared by both platforms' prepareVariadic
+// before they diverge on where the tail actually lands.
+func (spec Spec) checkVariadicShape(varArgs []uint64, varIsXmm []bool) {
+	if len(varArgs) != len(varIsXmm) {
+		panic("ffi: CallVariadic: varArgs and varIsXmm must be the same length")
+	}
+	if spec.variadicTypes == nil {
+		return
+	}
+	if len(varArgs) != len(spec.variadicTypes) {
+		panic("ffi: CallVariadic: varArgs does not match the variadicTypes this Spec was made with")
+	}
+	for i, t := range spec.variadicTypes {
+		if xmm := t == typeFloat || t == typeDouble; xmm != varIsXmm[i] {
+			panic("ffi: CallVariadic: varIsXmm does not match the variadicTypes this Spec was made with")
+		}
+	}
+}
+
+// rawBytes views the n bytes at p as a byte slice, for copying a fixed
+// argument struct of a size only known at runtime into the combined
+// fixed+variadic buffer CallVariadic builds.
+func rawBytes(p unsafe.Pointer, n uintptr) []byte {
+	return (*[1 << 30]byte)(p)[:n:n]
+}
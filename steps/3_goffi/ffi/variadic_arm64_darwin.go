@@ -0,0 +1,34 @@
+//go:build darwin && arm64
+
+package ffi
+
+import "unsafe"
+
+// prepareVariadic reclassifies spec's variadic tail from scratch on every
+// call. Unlike plain AAPCS64 (variadic_arm64_other.go), Apple's arm64 ABI
+// requires every variadic argument to be passed on the stack, regardless
+// of how many X/V registers the fixed arguments left unused - see
+// Apple's "Writing ARM64 Code for Apple Platforms", the section on
+// variadic functions. spec.usedInt/usedXmm are therefore irrelevant here;
+// they only matter to the non-Apple classification this file replaces.
+func (spec Spec) prepareVariadic(fixedPtr unsafe.Pointer, varArgs []uint64, varIsXmm []bool) (Spec, []byte) {
+	spec.checkVariadicShape(varArgs, varIsXmm)
+
+	buf := make([]byte, spec.argsSize+uintptr(len(varArgs))*8)
+	copy(buf, rawBytes(fixedPtr, spec.argsSize))
+
+	stack := append([]argument(nil), spec.stack...)
+	for i, v := range varArgs {
+		off := spec.argsSize + uintptr(i)*8
+		*(*uint64)(unsafe.Pointer(&buf[off])) = v
+
+		a := argument{offset: uint16(off), t: type64, size: 8}
+		if varIsXmm[i] {
+			a.t = typeDouble
+		}
+		stack = append(stack, a)
+	}
+
+	spec.stack = stack
+	return spec, buf
+}
@@ -0,0 +1,38 @@
+//go:build darwin && arm64
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestPrepareVariadicDarwin confirms Apple's arm64 ABI deviation: every
+// variadic argument lands on the stack, even though the fixed argument
+// only used one of eight available X registers and plenty of room is
+// left in both register files.
+func TestPrepareVariadicDarwin(t *testing.T) {
+	fixed := variadicFixedArgs{A: 1} // consumes intargs[0]
+	spec := MakeVariadicSpec(0, &fixed, nil)
+
+	varArgs := []uint64{
+		int64f(1.5),
+		7,
+	}
+	varIsXmm := []bool{true, false}
+
+	spec, _ = spec.prepareVariadic(unsafe.Pointer(&fixed), varArgs, varIsXmm)
+
+	if len(spec.stack) != 2 {
+		t.Fatalf("spec.stack: got %d entries, want 2 - darwin/arm64 spills every variadic argument", len(spec.stack))
+	}
+	if spec.stack[0].t != typeDouble {
+		t.Fatalf("spec.stack[0]: got t=%v, want typeDouble", spec.stack[0].t)
+	}
+	if spec.stack[1].t != type64 {
+		t.Fatalf("spec.stack[1]: got t=%v, want type64", spec.stack[1].t)
+	}
+	if spec.fltargs[0].t != typeUnused {
+		t.Fatalf("fltargs[0]: want typeUnused - the variadic double must not have used a register, got %+v", spec.fltargs[0])
+	}
+}
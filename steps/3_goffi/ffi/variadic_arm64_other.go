@@ -0,0 +1,46 @@
+//go:build arm64 && !darwin
+
+package ffi
+
+import "unsafe"
+
+// prepareVariadic reclassifies spec's variadic tail from scratch on every
+// call, the same way the amd64 backend's does: per plain AAPCS64 (the
+// ABI linux/arm64 and every other non-Apple arm64 target follows),
+// variadic arguments are classified exactly like fixed ones - an integer
+// continues filling X registers from usedInt, a float/double continues
+// filling V registers from usedXmm, either spilling to the stack once
+// its register file is exhausted. Apple's arm64 ABI deviates from this -
+// see variadic_arm64_darwin.go's identically named function.
+func (spec Spec) prepareVariadic(fixedPtr unsafe.Pointer, varArgs []uint64, varIsXmm []bool) (Spec, []byte) {
+	spec.checkVariadicShape(varArgs, varIsXmm)
+
+	buf := make([]byte, spec.argsSize+uintptr(len(varArgs))*8)
+	copy(buf, rawBytes(fixedPtr, spec.argsSize))
+
+	intreg, fltreg := spec.usedInt, spec.usedXmm
+	stack := append([]argument(nil), spec.stack...)
+
+	for i, v := range varArgs {
+		off := spec.argsSize + uintptr(i)*8
+		*(*uint64)(unsafe.Pointer(&buf[off])) = v
+
+		a := argument{offset: uint16(off), t: type64, size: 8}
+		if varIsXmm[i] {
+			a.t = typeDouble
+			if fltreg < 8 {
+				spec.fltargs[fltreg] = a
+				fltreg++
+				continue
+			}
+		} else if intreg < 8 {
+			spec.intargs[intreg] = a
+			intreg++
+			continue
+		}
+		stack = append(stack, a)
+	}
+
+	spec.stack = stack
+	return spec, buf
+}
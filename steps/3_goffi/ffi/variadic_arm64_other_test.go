@@ -0,0 +1,55 @@
+//go:build arm64 && !darwin
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestPrepareVariadicOther confirms that on plain AAPCS64 (every arm64
+// target but darwin), a variadic tail is classified exactly like a fixed
+// argument would be: it keeps filling X/V registers from where the fixed
+// arguments left off, only spilling to the stack once those run out.
+func TestPrepareVariadicOther(t *testing.T) {
+	fixed := variadicFixedArgs{A: 1} // consumes intargs[0]
+	spec := MakeVariadicSpec(0, &fixed, nil)
+
+	varArgs := []uint64{
+		int64f(1.5),
+		int64f(2.5),
+		7,
+	}
+	varIsXmm := []bool{true, true, false}
+
+	spec, _ = spec.prepareVariadic(unsafe.Pointer(&fixed), varArgs, varIsXmm)
+
+	if spec.fltargs[0].t != typeDouble || spec.fltargs[1].t != typeDouble {
+		t.Fatalf("fltargs[0..1]: want both typeDouble (the two variadic doubles), got %+v", spec.fltargs[:2])
+	}
+	if spec.intargs[1].t != type64 {
+		t.Fatalf("intargs[1]: want type64 (the trailing variadic int, after fixed A in intargs[0]), got %+v", spec.intargs[1])
+	}
+	if len(spec.stack) != 0 {
+		t.Fatalf("spec.stack: got %d entries, want 0 - everything fit in registers", len(spec.stack))
+	}
+}
+
+// TestPrepareVariadicOtherSpills confirms a variadic tail that exhausts
+// the integer register file spills to the stack, the same as a fixed
+// argument would.
+func TestPrepareVariadicOtherSpills(t *testing.T) {
+	fixed := variadicFixedArgs{A: 1} // consumes intargs[0]
+	spec := MakeVariadicSpec(0, &fixed, nil)
+
+	varArgs := make([]uint64, 8) // 7 left in intargs, 1 must spill
+	varIsXmm := make([]bool, 8)
+	for i := range varArgs {
+		varArgs[i] = uint64(i)
+	}
+
+	spec, _ = spec.prepareVariadic(unsafe.Pointer(&fixed), varArgs, varIsXmm)
+	if len(spec.stack) != 1 {
+		t.Fatalf("spec.stack: got %d entries, want 1 (the 8th variadic int, after 7 free X registers)", len(spec.stack))
+	}
+}
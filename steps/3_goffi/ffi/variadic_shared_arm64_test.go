@@ -0,0 +1,20 @@
+package ffi
+
+import "unsafe"
+
+// variadicFixedArgs is the fixed (named) parameter of a stand-in
+// printf-style call: a single integer, classified and placed exactly
+// like any other MakeSpec argument. Shared by
+// variadic_arm64_other_test.go and variadic_arm64_darwin_test.go, which
+// exercise prepareVariadic's two platform-specific classification rules
+// against the same fixed shape.
+type variadicFixedArgs struct {
+	A int64 `ffi:"arg"`
+}
+
+// int64f reinterprets a float64 as the raw bits CallVariadic's callers
+// are documented to pass for a variadic double argument
+// (math.Float64bits(float64(x))).
+func int64f(x float64) uint64 {
+	return *(*uint64)(unsafe.Pointer(&x))
+}
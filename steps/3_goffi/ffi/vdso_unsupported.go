@@ -0,0 +1,328 @@
+//go:build !windows && !darwin && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// auxvATSysinfoEHDR is AT_SYSINFO_EHDR, the auxiliary-vector entry whose
+// value is the vDSO's already-mapped ELF header address - the kernel's
+// own shortcut for finding it, since nothing else in a process's address
+// space points at it.
+const auxvATSysinfoEHDR = 33
+
+// elf64Ehdr, elf64Phdr, elf64Dyn and elf64Sym are the handful of ELF64
+// structures vdsoLoad needs, laid out to match their on-disk/in-memory
+// form directly rather than going through debug/elf - this package parses
+// every other ELF structure it touches from scratch too (see
+// elf_hash_unsupported.go and its siblings), and the vDSO, being mapped
+// directly into this process rather than dlopen'd from a file, has no
+// path through that package anyway.
+type elf64Ehdr struct {
+	Ident     [16]byte
+	Type      uint16
+	Machine   uint16
+	Version   uint32
+	Entry     uint64
+	Phoff     uint64
+	Shoff     uint64
+	Flags     uint32
+	Ehsize    uint16
+	Phentsize uint16
+	Phnum     uint16
+	Shentsize uint16
+	Shnum     uint16
+	Shstrndx  uint16
+}
+
+type elf64Phdr struct {
+	Type   uint32
+	Flags  uint32
+	Offset uint64
+	Vaddr  uint64
+	Paddr  uint64
+	Filesz uint64
+	Memsz  uint64
+	Align  uint64
+}
+
+// elf64Dyn is one PT_DYNAMIC entry. Val doubles as d_val/d_ptr, same as
+// the gABI's own union - which one applies depends on Tag alone.
+type elf64Dyn struct {
+	Tag int64
+	Val uint64
+}
+
+type elf64Sym struct {
+	Name  uint32
+	Info  uint8
+	Other uint8
+	Shndx uint16
+	Value uint64
+	Size  uint64
+}
+
+const (
+	elfPTDynamic = 2
+
+	elfDTNull   = 0
+	elfDTHash   = 4
+	elfDTStrtab = 5
+	elfDTSymtab = 6
+)
+
+// vdsoMaxSymbolScan bounds VDSOLookup's linear scan when the vDSO carries
+// no classic DT_HASH (so there's no nchain to read the real symbol count
+// from) - generous for a vDSO, which in practice exports only a handful
+// of symbols (clock_gettime, gettimeofday, getcpu, time, and their
+// versioned aliases), never anywhere near this many.
+const vdsoMaxSymbolScan = 64
+
+// vdsoImage is the handful of addresses VDSOLookup needs, resolved once
+// by vdsoLoad and cached for the life of the process - the vDSO is
+// mapped once at exec time and never moves or changes afterward.
+type vdsoImage struct {
+	base   uintptr
+	symtab uintptr
+	strtab uintptr
+	nsyms  int
+}
+
+var (
+	vdsoOnce    sync.Once
+	vdsoImg     *vdsoImage
+	vdsoLoadErr error
+)
+
+// VDSOLookup resolves name's address within the kernel-provided vDSO
+// mapping, or reports found=false if the vDSO couldn't be located or
+// doesn't export that symbol. Unlike Library.Get, this never calls
+// dlopen/dlsym: the vDSO has no backing file for dlopen to map, only
+// this process's own auxv-provided pointer into memory the kernel
+// already mapped at exec time, so VDSOLookup walks its ELF dynamic
+// symbol table directly instead.
+//
+// The result, when found, is a plain function pointer suitable for
+// MakeSpec - calling through it follows the platform's ordinary C
+// calling convention, the same as a symbol resolved from any shared
+// library.
+func VDSOLookup(name string) (addr uintptr, found bool) {
+	img, err := vdsoLoad()
+	if err != nil {
+		return 0, false
+	}
+	for i := 0; i < img.nsyms; i++ {
+		sym := (*elf64Sym)(unsafe.Pointer(img.symtab + uintptr(i)*unsafe.Sizeof(elf64Sym{})))
+		if sym.Name == 0 || sym.Value == 0 {
+			continue
+		}
+		if isIFuncSymbol(sym.Info) {
+			// Not something the vDSO actually does in practice, but
+			// VDSOLookup's contract is a plain callable address, and an
+			// ifunc's value is a resolver to call, not the function
+			// itself - skip it rather than hand back the wrong thing.
+			continue
+		}
+		if GoString(img.strtab+uintptr(sym.Name)) == name {
+			return img.base + uintptr(sym.Value), true
+		}
+	}
+	return 0, false
+}
+
+// vdsoLoad locates and parses the vDSO's ELF image on first use, caching
+// the result (success or failure) for every later call.
+func vdsoLoad() (*vdsoImage, error) {
+	vdsoOnce.Do(func() {
+		base, err := vdsoBase()
+		if err != nil {
+			vdsoLoadErr = err
+			return
+		}
+		vdsoImg, vdsoLoadErr = vdsoParse(base)
+	})
+	return vdsoImg, vdsoLoadErr
+}
+
+// vdsoBase reads this process's own auxiliary vector to find
+// AT_SYSINFO_EHDR - Go exposes no other way to get at it, since the
+// runtime doesn't surface auxv itself.
+func vdsoBase() (uintptr, error) {
+	data, err := os.ReadFile("/proc/self/auxv")
+	if err != nil {
+		return 0, fmt.Errorf("ffi: vdso: reading /proc/self/auxv: %w", err)
+	}
+	for len(data) >= 16 {
+		tag := binary.LittleEndian.Uint64(data[0:8])
+		val := binary.LittleEndian.Uint64(data[8:16])
+		data = data[16:]
+		if tag == auxvATSysinfoEHDR {
+			if val == 0 {
+				return 0, fmt.Errorf("ffi: vdso: AT_SYSINFO_EHDR is 0 (no vDSO mapped)")
+			}
+			return uintptr(val), nil
+		}
+		if tag == 0 { // AT_NULL
+			break
+		}
+	}
+	return 0, fmt.Errorf("ffi: vdso: AT_SYSINFO_EHDR not found in auxv")
+}
+
+// vdsoParse walks the ELF image mapped at base - already valid, mapped
+// memory in this same process, not a file to read - down to its dynamic
+// symbol and string tables.
+func vdsoParse(base uintptr) (*vdsoImage, error) {
+	ehdr := (*elf64Ehdr)(unsafe.Pointer(base))
+	if ehdr.Ident[0] != 0x7f || ehdr.Ident[1] != 'E' || ehdr.Ident[2] != 'L' || ehdr.Ident[3] != 'F' {
+		return nil, fmt.Errorf("ffi: vdso: no ELF magic at %#x", base)
+	}
+
+	var dynVaddr uint64
+	var haveDyn bool
+	for i := 0; i < int(ehdr.Phnum); i++ {
+		ph := (*elf64Phdr)(unsafe.Pointer(base + uintptr(ehdr.Phoff) + uintptr(i)*uintptr(ehdr.Phentsize)))
+		if ph.Type == elfPTDynamic {
+			dynVaddr = ph.Vaddr
+			haveDyn = true
+			break
+		}
+	}
+	if !haveDyn {
+		return nil, fmt.Errorf("ffi: vdso: no PT_DYNAMIC segment")
+	}
+
+	var symtab, strtab, hash uint64
+dynLoop:
+	for i := 0; ; i++ {
+		d := (*elf64Dyn)(unsafe.Pointer(base + uintptr(dynVaddr) + uintptr(i)*unsafe.Sizeof(elf64Dyn{})))
+		switch d.Tag {
+		case elfDTNull:
+			break dynLoop
+		case elfDTSymtab:
+			symtab = d.Val
+		case elfDTStrtab:
+			strtab = d.Val
+		case elfDTHash:
+			hash = d.Val
+		}
+	}
+
+	if symtab == 0 || strtab == 0 {
+		return nil, fmt.Errorf("ffi: vdso: missing DT_SYMTAB/DT_STRTAB")
+	}
+
+	nsyms := vdsoMaxSymbolScan
+	if hash != 0 {
+		// The classic SysV .hash layout is nbucket, nchain, then the
+		// bucket/chain arrays - nchain equals the dynamic symbol table's
+		// exact length, both uint32, per the gABI. That's all this
+		// needs: a bound, not a bucket lookup, since a vDSO exports so
+		// few symbols that VDSOLookup's linear scan over all of them is
+		// simpler than implementing the hash function to skip it.
+		nchain := *(*uint32)(unsafe.Pointer(base + uintptr(hash) + 4))
+		if nchain > 0 {
+			nsyms = int(nchain)
+		}
+	}
+
+	return &vdsoImage{
+		base:   base,
+		symtab: base + uintptr(symtab),
+		strtab: base + uintptr(strtab),
+		nsyms:  nsyms,
+	}, nil
+}
+
+// Timespec mirrors struct timespec, laid out to match the C struct
+// exactly so VDSOClockGettime can pass it by pointer directly.
+type Timespec struct {
+	Sec  int64
+	Nsec int64
+}
+
+// Clock IDs accepted by VDSOClockGettime - the handful clock_gettime(2)
+// documents as vDSO-accelerated on Linux; others work through the vDSO
+// too, but fall back to an actual syscall internally, leaving nothing for
+// calling through the vDSO to save.
+const (
+	ClockRealtime  int32 = 0
+	ClockMonotonic int32 = 1
+)
+
+var (
+	vdsoClockGettimeOnce sync.Once
+	vdsoClockGettimeFn   uintptr
+
+	vdsoGetcpuOnce sync.Once
+	vdsoGetcpuFn   uintptr
+)
+
+// VDSOClockGettime calls the vDSO's own clock_gettime directly, with no
+// libc in between - the whole point of the vDSO existing at all, since
+// the kernel maps it specifically so a timestamp read needs no syscall
+// trap. It returns an error if this kernel's vDSO doesn't export
+// clock_gettime (one built without VDSO_HAS_CLOCK_GETTIME, or no vDSO at
+// all) or if the call itself reports failure, e.g. for an unsupported
+// clockID.
+func VDSOClockGettime(clockID int32) (Timespec, error) {
+	vdsoClockGettimeOnce.Do(func() {
+		vdsoClockGettimeFn, _ = VDSOLookup("clock_gettime")
+	})
+	if vdsoClockGettimeFn == 0 {
+		return Timespec{}, fmt.Errorf("ffi: VDSOClockGettime: vDSO does not export clock_gettime")
+	}
+
+	var ts Timespec
+	args := struct {
+		ClockID int32          `ffi:"arg"`
+		TS      unsafe.Pointer `ffi:"arg"`
+		R       int32          `ffi:"ret"`
+	}{ClockID: clockID, TS: unsafe.Pointer(&ts)}
+	spec := MakeSpec(vdsoClockGettimeFn, &args)
+	if errnoLocator != 0 {
+		spec.UseErrno(errnoLocator)
+	}
+	errno := spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return Timespec{}, fmt.Errorf("ffi: VDSOClockGettime: clock_gettime failed: errno %d", errno)
+	}
+	return ts, nil
+}
+
+// VDSOGetcpu calls the vDSO's own getcpu, reporting the CPU (and NUMA
+// node) the calling thread is currently running on. node may be nil if
+// the caller only wants cpu - getcpu(2)'s own third argument (an
+// obsolete cache pointer, unused by the kernel since Linux 2.6.24) is
+// always passed as NULL, the same as every other caller has been free to
+// do since.
+func VDSOGetcpu() (cpu, node uint32, err error) {
+	vdsoGetcpuOnce.Do(func() {
+		vdsoGetcpuFn, _ = VDSOLookup("getcpu")
+	})
+	if vdsoGetcpuFn == 0 {
+		return 0, 0, fmt.Errorf("ffi: VDSOGetcpu: vDSO does not export getcpu")
+	}
+
+	var rawCPU, rawNode uint32
+	args := struct {
+		CPU  unsafe.Pointer `ffi:"arg"`
+		Node unsafe.Pointer `ffi:"arg"`
+		TC   unsafe.Pointer `ffi:"arg"`
+		R    int32          `ffi:"ret"`
+	}{CPU: unsafe.Pointer(&rawCPU), Node: unsafe.Pointer(&rawNode), TC: nil}
+	spec := MakeSpec(vdsoGetcpuFn, &args)
+	if errnoLocator != 0 {
+		spec.UseErrno(errnoLocator)
+	}
+	errno := spec.Call(unsafe.Pointer(&args))
+	if args.R != 0 {
+		return 0, 0, fmt.Errorf("ffi: VDSOGetcpu: getcpu failed: errno %d", errno)
+	}
+	return rawCPU, rawNode, nil
+}
@@ -0,0 +1,35 @@
+package ffi
+
+import "reflect"
+
+// M128 is the Go-side storage for a C __m128/__m128d/__m128i SIMD vector
+// argument or return value: 16 raw bytes, passed or returned in a single
+// whole XMM register rather than the two separate eightbyte registers a
+// same-sized struct of two float64s would classify into (SysV gives a
+// short vector type its own SSE/SSEUP classification specifically so it
+// stays in one register - see classifyEightbytes's doc comment for the
+// two-separate-registers case this is not).
+//
+// A M128 field embedded by value in an args/ret struct is recognized by
+// MakeSpec on amd64 (see m128Type's use in ffi_amd64.go) ahead of the
+// generic struct/array aggregate classification, the same way
+// longDoubleType is. It carries no conversion helpers the way
+// LongDouble/Float16 do: a vector's 16 bytes are whatever lanes the
+// caller's C signature says they are, and slicing them up is the
+// caller's job, not this package's.
+type M128 [16]byte
+
+// M256 is M128's 256 bit counterpart, for a C __m256/__m256d/__m256i
+// argument or return value passed or returned in a single whole YMM
+// register. Only amd64 has a register wide enough for this; there's no
+// AVX equivalent on 386 or arm64; see m256Type's amd64-only use.
+type M256 [32]byte
+
+// m128Type and m256Type are reflect.TypeOf(M128{})/reflect.TypeOf(M256{}),
+// cached once for the identity comparisons buildSpec (ffi_amd64.go) uses
+// to single these fields out before its generic aggregate handling ever
+// sees them.
+var (
+	m128Type = reflect.TypeOf(M128{})
+	m256Type = reflect.TypeOf(M256{})
+)
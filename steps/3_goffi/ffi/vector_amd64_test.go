@@ -0,0 +1,92 @@
+//go:build !windows && !nocgo_fallback_cgo && !tinygo && !gccgo
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func addrOfVecM128Target() uintptr
+func vecM128Target()
+
+func addrOfVecM256Target() uintptr
+func vecM256Target()
+
+// vecM128Args has one M128 argument and a separate M128 ffi:"ret" field:
+// vecM128Target doubles whatever arrives in XMM0 and leaves the result
+// there, so this exercises MakeSpec's M128 argument classification (a
+// single xmmargs slot, not two) and its vecret classification together.
+type vecM128Args struct {
+	V M128
+	R M128 `ffi:"ret"`
+}
+
+// TestCallM128ArgAndReturn exercises asmcall's dedicated MOVOU argument
+// load and vecret store end to end.
+func TestCallM128ArgAndReturn(t *testing.T) {
+	var args vecM128Args
+	for i := range args.V {
+		args.V[i] = byte(i + 1)
+	}
+	spec := MakeSpec(addrOfVecM128Target(), &args)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	for i, want := range args.V {
+		if got := args.R[i]; got != want*2 {
+			t.Fatalf("R[%d] = %d, want %d", i, got, want*2)
+		}
+	}
+}
+
+// vecM256Args is vecM128Args's __m256 counterpart.
+type vecM256Args struct {
+	V M256
+	R M256 `ffi:"ret"`
+}
+
+// TestCallM256ArgAndReturn exercises asmcall's dedicated VMOVDQU argument
+// load and vecret store end to end, including that the vecret store
+// happens before UseVZeroUpper's VZEROUPPER would otherwise erase Y0's
+// upper 128 bits.
+func TestCallM256ArgAndReturn(t *testing.T) {
+	var args vecM256Args
+	for i := range args.V {
+		args.V[i] = byte(i + 1)
+	}
+	spec := MakeSpec(addrOfVecM256Target(), &args)
+	spec.UseVZeroUpper(true)
+
+	callAsmDirect(&callParams{spec: &spec, base: uintptr(unsafe.Pointer(&args))})
+
+	for i, want := range args.V {
+		if got := args.R[i]; got != want*2 {
+			t.Fatalf("R[%d] = %d, want %d", i, got, want*2)
+		}
+	}
+}
+
+// TestMakeSpecMisalignedVector confirms a M128 field that doesn't sit at
+// a 16 byte aligned offset is rejected at MakeSpec time rather than
+// silently generating a misaligned MOVOU - see ErrMisalignedVector.
+func TestMakeSpecMisalignedVector(t *testing.T) {
+	type misaligned struct {
+		Pad [1]byte
+		V   M128
+	}
+
+	defer func() {
+		r := recover()
+		se, ok := r.(*SpecError)
+		if !ok {
+			t.Fatalf("expected *SpecError panic, got %v", r)
+		}
+		if se.Kind != ErrMisalignedVector {
+			t.Fatalf("expected ErrMisalignedVector, got %v", se.Kind)
+		}
+	}()
+	var args misaligned
+	MakeSpec(addrOfVecM128Target(), &args)
+	t.Fatal("expected MakeSpec to panic")
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package ffi
+
+import "unsafe"
+
+// VTableCall calls the slot-th method (0-based, in declaration order) of
+// objPtr's COM vtable. A COM interface pointer's first field is itself a
+// pointer to a table of method pointers shared by every instance of that
+// interface, so unlike an ordinary Spec - built once for a fixed fn - a
+// vtable method's address can only be resolved per object, per call.
+// spec is otherwise an ordinary Spec built with MakeSpec/MustSpecFor:
+// its first ffi:"arg" field must be a uintptr holding the interface
+// pointer itself (COM's implicit this, passed in RCX the same way any
+// other Microsoft x64 call's first argument would be - see
+// ffi_windows_amd64.go), and args must already have that field set to
+// objPtr before calling VTableCall. A COM method's HRESULT return needs
+// no special handling beyond an ordinary int32 ffi:"ret" field; VTableCall
+// only resolves which function Call actually invokes.
+func VTableCall(spec Spec, objPtr uintptr, slot int, args unsafe.Pointer) {
+	vtable := *(*uintptr)(unsafe.Pointer(objPtr))
+	methods := unsafe.Slice((*uintptr)(unsafe.Pointer(vtable)), slot+1)
+	spec.fn = methods[slot]
+	spec.Call(args)
+}
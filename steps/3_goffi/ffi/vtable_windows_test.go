@@ -0,0 +1,34 @@
+//go:build windows
+
+package ffi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// vtableBenchTarget (vtable_windows_test.s) is a trivial asm function,
+// just enough of a real call target to exercise VTableCall end to end.
+func vtableBenchTarget()
+
+// TestVTableCallResolvesSlot builds a fake COM object - an object header
+// holding a pointer to a 2-method vtable, neither backed by any real COM
+// interface - and checks VTableCall resolves the right method address out
+// of slot 0 rather than, say, treating objPtr itself as the vtable.
+func TestVTableCallResolvesSlot(t *testing.T) {
+	vtable := [2]uintptr{funcPC(vtableBenchTarget), 0}
+	vtablePtr := uintptr(unsafe.Pointer(&vtable))
+	objHeader := vtablePtr
+	obj := uintptr(unsafe.Pointer(&objHeader))
+
+	var args winVTableArgs
+	spec := MakeSpec(0, &args)
+
+	args.This = obj
+	VTableCall(spec, obj, 0, unsafe.Pointer(&args))
+}
+
+type winVTableArgs struct {
+	This uintptr `ffi:"arg"`
+	R    int32   `ffi:"ret"`
+}